@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/secrets"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// failingAlertTypes fail a one-shot check; other alerts are reported as warnings
+var failingAlertTypes = map[string]bool{
+	"replica_lag":          true,
+	"replication_stopped":  true,
+	"replication_retrying": true,
+	"checksum_mismatch":    true,
+	"checksum_error":       true,
+	"consistency_mismatch": true,
+	"consistency_error":    true,
+}
+
+// checkReport is the result of one monitoring cycle over all pairs
+type checkReport struct {
+	Passed    bool              `json:"passed"`
+	Timestamp time.Time         `json:"timestamp"`
+	Pairs     []pairCheckReport `json:"pairs"`
+}
+
+// pairCheckReport is the result of one monitoring cycle of a pair
+type pairCheckReport struct {
+	Name            string             `json:"name"`
+	Passed          bool               `json:"passed"`
+	SourceConnected bool               `json:"source_connected"`
+	TargetConnected bool               `json:"target_connected"`
+	LagSeconds      *float64           `json:"lag_seconds"`
+	LagStatus       string             `json:"lag_status,omitempty"`
+	HealthScore     *float64           `json:"health_score"`
+	Checksums       []tableCheckReport `json:"checksums"`
+	Consistency     []tableCheckReport `json:"consistency"`
+	Failures        []string           `json:"failures"`
+	Warnings        []string           `json:"warnings"`
+}
+
+// tableCheckReport is the checksum or consistency result of one table
+type tableCheckReport struct {
+	Table  string `json:"table"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// runCheck implements the "check" subcommand, running a single monitoring
+// cycle for all pairs and exiting non-zero when any check failed
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	configSource := addConfigFlags(fs)
+	pairNames := fs.String("pair", "", "Comma-separated pairs to check (default: all)")
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	cfg, err := configSource.load()
+	if err != nil {
+		log.Printf("Failed to load configuration: %v", err)
+		return 1
+	}
+	if _, err := secrets.Resolve(context.Background(), cfg); err != nil {
+		log.Printf("Failed to resolve database secrets: %v", err)
+		return 1
+	}
+	if err := database.EnableSQLAudit(cfg.SQLAudit); err != nil {
+		log.Printf("Failed to enable the SQL audit: %v", err)
+		return 1
+	}
+
+	if *pairNames != "" {
+		var selected []config.DatabasePair
+		for _, name := range strings.Split(*pairNames, ",") {
+			found := false
+			for _, pair := range cfg.DatabasePairs {
+				if pair.Name == strings.TrimSpace(name) {
+					selected = append(selected, pair)
+					found = true
+				}
+			}
+			if !found {
+				log.Printf("Database pair '%s' not found in configuration", name)
+				return 1
+			}
+		}
+		cfg.DatabasePairs = selected
+	}
+
+	metricsStorage := storage.NewMetricsStorage()
+	alertManager := alert.NewAlertManager(cfg)
+	engine := monitor.NewMonitoringEngine(cfg, metricsStorage, alertManager)
+	engine.RunOnce()
+	engine.Stop()
+
+	report := buildCheckReport(cfg, metricsStorage.GetCurrentMetrics(), alertManager.GetActiveAlerts())
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printCheckReport(report)
+	}
+
+	if !report.Passed {
+		return 3
+	}
+	return 0
+}
+
+// buildCheckReport summarizes the results of the cycle per pair
+func buildCheckReport(cfg *config.Config, metrics *storage.CurrentMetrics, activeAlerts []alert.Alert) checkReport {
+	report := checkReport{Passed: true, Timestamp: time.Now()}
+
+	for _, pair := range cfg.DatabasePairs {
+		// Fan-out pairs are only checked once their primary pair cut over
+		if pair.WaitForCutOver {
+			continue
+		}
+		pr := pairCheckReport{
+			Name:        pair.Name,
+			Checksums:   []tableCheckReport{},
+			Consistency: []tableCheckReport{},
+			Failures:    []string{},
+			Warnings:    []string{},
+		}
+
+		if status, ok := metrics.ConnectionStatus[pair.Name]; ok {
+			pr.SourceConnected = status.SourceConnected
+			pr.TargetConnected = status.TargetConnected
+		}
+		if !pr.SourceConnected {
+			pr.Failures = append(pr.Failures, "source database not connected")
+		}
+		if !pr.TargetConnected {
+			pr.Failures = append(pr.Failures, "target database not connected")
+		}
+
+		if lag, ok := metrics.ReplicaLag[pair.Name]; ok {
+			seconds := lag.LagSeconds
+			pr.LagSeconds = &seconds
+			pr.LagStatus = lag.Status
+		}
+		if health, ok := metrics.HealthScore[pair.Name]; ok {
+			score := health.Score
+			pr.HealthScore = &score
+		}
+
+		for _, result := range metrics.ChecksumResults {
+			if result.DatabasePair != pair.Name {
+				continue
+			}
+			table := tableCheckReport{Table: result.TableName, Passed: result.Match && result.Error == nil}
+			switch {
+			case result.Error != nil:
+				table.Detail = result.Error.Error()
+			case result.Skipped:
+				table.Passed = true
+				table.Detail = "skipped by pre-flight size limits"
+			case result.Match:
+				table.Detail = "checksums match"
+			default:
+				table.Detail = fmt.Sprintf("source %s, target %s", result.SourceChecksum, result.TargetChecksum)
+			}
+			pr.Checksums = append(pr.Checksums, table)
+		}
+
+		for _, result := range metrics.ConsistencyResults {
+			if result.DatabasePair != pair.Name {
+				continue
+			}
+			table := tableCheckReport{Table: result.TableName, Passed: result.Consistent && result.Error == nil}
+			if result.Error != nil {
+				table.Detail = result.Error.Error()
+			} else {
+				table.Detail = fmt.Sprintf("source %d rows, target %d rows", result.SourceRowCount, result.TargetRowCount)
+				if result.Approximate {
+					table.Detail += " (approximate)"
+				}
+				if result.Backfill != "" {
+					table.Detail += fmt.Sprintf(" (within %g%% for %s)", result.Tolerance, result.Backfill)
+				}
+			}
+			pr.Consistency = append(pr.Consistency, table)
+		}
+
+		sort.Slice(pr.Checksums, func(i, j int) bool { return pr.Checksums[i].Table < pr.Checksums[j].Table })
+		sort.Slice(pr.Consistency, func(i, j int) bool { return pr.Consistency[i].Table < pr.Consistency[j].Table })
+
+		for _, a := range activeAlerts {
+			if a.DatabasePair != pair.Name {
+				continue
+			}
+			message := fmt.Sprintf("%s: %s", a.Severity, a.Message)
+			if failingAlertTypes[a.Type] {
+				pr.Failures = append(pr.Failures, message)
+			} else {
+				pr.Warnings = append(pr.Warnings, message)
+			}
+		}
+		sort.Strings(pr.Failures)
+		sort.Strings(pr.Warnings)
+
+		pr.Passed = len(pr.Failures) == 0
+		if !pr.Passed {
+			report.Passed = false
+		}
+		report.Pairs = append(report.Pairs, pr)
+	}
+
+	return report
+}
+
+// printCheckReport prints a human-readable check report
+func printCheckReport(report checkReport) {
+	for _, pr := range report.Pairs {
+		fmt.Printf("Pair: %s  [%s]\n", pr.Name, passFail(pr.Passed))
+		fmt.Printf("  Connections: source %s, target %s\n", connected(pr.SourceConnected), connected(pr.TargetConnected))
+		if pr.LagSeconds != nil {
+			fmt.Printf("  Replica lag: %.2fs (%s)\n", *pr.LagSeconds, pr.LagStatus)
+		}
+		if pr.HealthScore != nil {
+			fmt.Printf("  Health score: %.0f\n", *pr.HealthScore)
+		}
+		for _, table := range pr.Checksums {
+			fmt.Printf("  Checksum    %-30s %s  %s\n", table.Table, passFail(table.Passed), table.Detail)
+		}
+		for _, table := range pr.Consistency {
+			fmt.Printf("  Consistency %-30s %s  %s\n", table.Table, passFail(table.Passed), table.Detail)
+		}
+		for _, failure := range pr.Failures {
+			fmt.Printf("  FAILURE: %s\n", failure)
+		}
+		for _, warning := range pr.Warnings {
+			fmt.Printf("  warning: %s\n", warning)
+		}
+		fmt.Println()
+	}
+
+	if report.Passed {
+		fmt.Printf("Result: PASS (%d pair(s))\n", len(report.Pairs))
+	} else {
+		fmt.Printf("Result: FAIL (%d pair(s))\n", len(report.Pairs))
+	}
+}
+
+// passFail labels a check outcome
+func passFail(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// connected labels a connection status
+func connected(ok bool) string {
+	if ok {
+		return "connected"
+	}
+	return "disconnected"
+}
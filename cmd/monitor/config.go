@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// configSource is where the monitor and its subcommands read the
+// configuration from: a file, or the environment alone
+type configSource struct {
+	path    *string
+	fromEnv *bool
+}
+
+// addConfigFlags registers the flags choosing the configuration source
+func addConfigFlags(fs *flag.FlagSet) configSource {
+	return configSource{
+		path:    fs.String("config", "config.yaml", "Path to configuration file"),
+		fromEnv: fs.Bool("config-from-env", false, "Read the configuration from "+config.EnvPrefix+" environment variables instead of a file"),
+	}
+}
+
+// load reads the configuration
+func (s configSource) load() (*config.Config, error) {
+	if *s.fromEnv {
+		return config.LoadConfigFromEnv()
+	}
+	return config.LoadConfig(*s.path)
+}
+
+// String names the source, e.g. in log messages
+func (s configSource) String() string {
+	if *s.fromEnv {
+		return "the environment"
+	}
+	return *s.path
+}
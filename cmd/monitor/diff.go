@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/secrets"
+)
+
+// checksumDiffReport is the JSON output of "diff -checksum"
+type checksumDiffReport struct {
+	Pair           string   `json:"pair"`
+	Table          string   `json:"table"`
+	TargetTable    string   `json:"target_table"`
+	SourceChecksum string   `json:"source_checksum"`
+	TargetChecksum string   `json:"target_checksum"`
+	Match          bool     `json:"match"`
+	Skipped        bool     `json:"skipped"`
+	EstimatedRows  int64    `json:"estimated_rows,omitempty"`
+	EstimatedBytes int64    `json:"estimated_bytes,omitempty"`
+	Excluded       []string `json:"excluded,omitempty"`
+	View           bool     `json:"view,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// runDiff implements the "diff" subcommand, comparing one table row by row,
+// or only its checksum with -checksum
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	configSource := addConfigFlags(fs)
+	pairName := fs.String("pair", "", "Database pair name")
+	tableName := fs.String("table", "", "Table to compare")
+	chunkSize := fs.Int("chunk-size", 1000, "Rows compared per chunk, in primary key order")
+	maxRows := fs.Int("max-rows", 100, "Maximum number of differing rows to report")
+	checksumOnly := fs.Bool("checksum", false, "Compare the table checksum only, as the monitoring cycle does")
+	jsonOutput := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	if *pairName == "" || *tableName == "" {
+		fmt.Fprintln(os.Stderr, "usage: monitor diff -pair NAME -table TABLE [-checksum | -chunk-size N -max-rows N] [-json]")
+		return 2
+	}
+
+	cfg, err := configSource.load()
+	if err != nil {
+		log.Printf("Failed to load configuration: %v", err)
+		return 1
+	}
+	if _, err := secrets.Resolve(context.Background(), cfg); err != nil {
+		log.Printf("Failed to resolve database secrets: %v", err)
+		return 1
+	}
+	if err := database.EnableSQLAudit(cfg.SQLAudit); err != nil {
+		log.Printf("Failed to enable the SQL audit: %v", err)
+		return 1
+	}
+
+	var pair *config.DatabasePair
+	for i := range cfg.DatabasePairs {
+		if cfg.DatabasePairs[i].Name == *pairName {
+			pair = &cfg.DatabasePairs[i]
+		}
+	}
+	if pair == nil {
+		log.Printf("Database pair '%s' not found in configuration", *pairName)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Diff)
+	defer cancel()
+
+	// Checksums and row diffs read from the check endpoints, as the monitor does
+	checkSource, checkTarget := pair.CheckDatabases()
+	connMgr := database.NewConnectionManager(checkSource, checkTarget, pair.Name, nil, cfg.Timeouts.Connect)
+	defer connMgr.Close()
+	if err := connMgr.ConnectSource(ctx); err != nil {
+		log.Printf("Failed to connect: %v", err)
+		return 1
+	}
+	if err := connMgr.ConnectTarget(ctx); err != nil {
+		log.Printf("Failed to connect: %v", err)
+		return 1
+	}
+
+	// Only monitored tables are compared, as through the API
+	tables, err := monitor.MonitoredTables(ctx, connMgr, pair)
+	if err != nil {
+		log.Printf("Failed to list the monitored tables: %v", err)
+		return 1
+	}
+	if !slices.Contains(tables, *tableName) {
+		log.Printf("Table '%s' is not monitored for database pair '%s'", *tableName, pair.Name)
+		return 1
+	}
+
+	if *checksumOnly {
+		return runChecksumDiff(ctx, cfg, pair, connMgr, *tableName, *jsonOutput)
+	}
+
+	result, err := monitor.NewDiffEngine(connMgr, pair.TableMappings, pair.Masking, pair.ChecksumExclusions).DiffTable(ctx, *tableName, monitor.DiffOptions{
+		ChunkSize: *chunkSize,
+		MaxRows:   *maxRows,
+	})
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(monitor.ToStorageDiffResult(pair.Name, result))
+	} else {
+		printDiffResult(pair.Name, result)
+	}
+
+	if err != nil {
+		return 1
+	}
+	if result.Differs() {
+		return 3
+	}
+	return 0
+}
+
+// runChecksumDiff compares the checksum of one table, with the pair's
+// pre-flight limits and column exclusions
+func runChecksumDiff(ctx context.Context, cfg *config.Config, pair *config.DatabasePair, connMgr *database.ConnectionManager, tableName string, jsonOutput bool) int {
+	validator := monitor.NewChecksumValidator(connMgr, pair.ChecksumPreflight, pair.TableMappings, pair.ChecksumExclusions, pair.Views, cfg.Timeouts.Checksum)
+	result, err := validator.ValidateTable(ctx, tableName)
+
+	if jsonOutput {
+		report := checksumDiffReport{
+			Pair:           pair.Name,
+			Table:          result.TableName,
+			TargetTable:    result.TargetTable,
+			SourceChecksum: result.SourceChecksum,
+			TargetChecksum: result.TargetChecksum,
+			Match:          result.Match,
+			Skipped:        result.Skipped,
+			EstimatedRows:  result.EstimatedRows,
+			EstimatedBytes: result.EstimatedBytes,
+			Excluded:       result.Excluded,
+			View:           result.View,
+		}
+		if err != nil {
+			report.Error = err.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printChecksumResult(pair.Name, result, err)
+	}
+
+	if err != nil || result.Skipped {
+		return 1
+	}
+	if !result.Match {
+		return 3
+	}
+	return 0
+}
+
+// printChecksumResult prints a human-readable checksum comparison
+func printChecksumResult(pairName string, result *monitor.ChecksumResult, err error) {
+	fmt.Printf("Pair:     %s\n", pairName)
+	if result.TargetTable != "" && result.TargetTable != result.TableName {
+		fmt.Printf("Table:    %s -> %s\n", result.TableName, result.TargetTable)
+	} else {
+		fmt.Printf("Table:    %s\n", result.TableName)
+	}
+	if result.View {
+		fmt.Printf("View:     hashed column by column\n")
+	}
+	if len(result.Excluded) > 0 {
+		fmt.Printf("Excluded: %s\n", strings.Join(result.Excluded, ", "))
+	}
+
+	if err != nil {
+		fmt.Printf("Error:    %v\n", err)
+		return
+	}
+	if result.Skipped {
+		fmt.Printf("Skipped:  over pre-flight limits (~%d rows, ~%d bytes); add it to allowed_tables to opt in\n", result.EstimatedRows, result.EstimatedBytes)
+		fmt.Println("Compare it row by row without -checksum")
+		return
+	}
+
+	fmt.Printf("Source:   %s\n", result.SourceChecksum)
+	fmt.Printf("Target:   %s\n", result.TargetChecksum)
+	if result.Match {
+		fmt.Println("Checksums match")
+	} else {
+		fmt.Println("Checksums differ; run without -checksum to find the differing rows")
+	}
+}
+
+// printDiffResult prints a human-readable diff report
+func printDiffResult(pairName string, result *monitor.DiffResult) {
+	primaryKey := result.PrimaryKey
+	if primaryKey == "" {
+		primaryKey = "none"
+	}
+	fmt.Printf("Pair:     %s\n", pairName)
+	if result.TargetTable != result.TableName {
+		fmt.Printf("Table:    %s -> %s (primary key: %s)\n", result.TableName, result.TargetTable, primaryKey)
+	} else {
+		fmt.Printf("Table:    %s (primary key: %s)\n", result.TableName, primaryKey)
+	}
+	fmt.Printf("Chunks:   %d scanned, %d mismatched\n", result.ChunksScanned, result.ChunksMismatched)
+	fmt.Printf("Duration: %v\n", result.Duration)
+	if result.Warning != "" {
+		fmt.Printf("Warning:  %s\n", result.Warning)
+	}
+
+	if result.Error != nil {
+		fmt.Printf("Error:    %v\n", result.Error)
+		return
+	}
+
+	if len(result.Differences) == 0 {
+		if result.Differs() {
+			fmt.Println("Table hashes differ")
+		} else {
+			fmt.Println("No differences found")
+		}
+		return
+	}
+
+	// Composite keys print as (a, b) = (1, x)
+	keyFormat := "  %s = %s: %s\n"
+	if strings.Contains(result.PrimaryKey, ", ") {
+		keyFormat = "  (%s) = (%s): %s\n"
+	}
+	fmt.Printf("\n%d differing row(s):\n", len(result.Differences))
+	for _, diff := range result.Differences {
+		fmt.Printf(keyFormat, result.PrimaryKey, diff.PrimaryKey, diff.Kind)
+		for _, col := range diff.Columns {
+			fmt.Printf("    %s: source=%s target=%s\n", col.Column, quoteValue(col.SourceValue, col.SourceNull), quoteValue(col.TargetValue, col.TargetNull))
+		}
+	}
+	if result.Truncated {
+		fmt.Println("  ... (row limit reached, more differences may exist)")
+	}
+}
+
+// quoteValue prints a column value quoted, and SQL NULL as a bare NULL
+func quoteValue(value string, null bool) string {
+	if null {
+		return "NULL"
+	}
+	return strconv.Quote(value)
+}
@@ -1,42 +1,204 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/archive"
+	"mariadb-encryption-monitor/internal/cloudwatch"
 	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/federation"
 	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/notify"
+	"mariadb-encryption-monitor/internal/secrets"
+	"mariadb-encryption-monitor/internal/selftest"
+	"mariadb-encryption-monitor/internal/statsd"
 	"mariadb-encryption-monitor/internal/storage"
+	"mariadb-encryption-monitor/internal/timeseries"
+	"mariadb-encryption-monitor/internal/tracing"
 	"mariadb-encryption-monitor/internal/web"
 )
 
 func main() {
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "suggest-tables":
+			os.Exit(runSuggestTables(os.Args[2:]))
+		case "validate-config":
+			os.Exit(runValidateConfig(os.Args[2:]))
+		}
+	}
+
 	// Parse command-line flags
-	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	configSource := addConfigFlags(flag.CommandLine)
+	selfTest := flag.Bool("self-test", false, "Monitor a scripted in-memory database instead of the configured pairs")
+	selfTestScenarios := flag.String("self-test-scenarios", strings.Join(selftest.DefaultScenarios, ","), "Comma-separated self-test scenarios to play")
+	selfTestPhase := flag.Duration("self-test-phase", 0, "Duration of each self-test scenario and recovery (default: 3 monitoring intervals, at least 1m)")
 	flag.Parse()
 
 	// Load configuration
 	log.Println("Loading configuration...")
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := configSource.load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Replace the configured pairs with the scripted database; notifiers,
+	// thresholds and the web server keep their configuration
+	if *selfTest {
+		if _, err := selftest.Prepare(cfg, strings.Split(*selfTestScenarios, ","), *selfTestPhase); err != nil {
+			log.Fatalf("Failed to prepare self-test: %v", err)
+		}
+	}
+
+	// Read database settings referencing Secrets Manager or SSM parameters
+	resolver, err := secrets.Resolve(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to resolve database secrets: %v", err)
+	}
+
 	log.Printf("Configuration loaded successfully")
+	for _, name := range cfg.UnknownEnv() {
+		log.Printf("Warning: environment variable %s names no setting and is ignored", name)
+	}
 	log.Printf("Monitoring interval: %v", cfg.MonitoringInterval)
 	log.Printf("Replica lag threshold: %v", cfg.ReplicaLagThreshold)
 	log.Printf("Web server port: %d", cfg.WebServerPort)
 	log.Printf("Tables to monitor: %v", cfg.TablesToMonitor)
+	if cfg.ReadOnly {
+		log.Printf("Read-only mode: database sessions only allow read-only transactions")
+	}
+	if err := database.EnableSQLAudit(cfg.SQLAudit); err != nil {
+		log.Fatalf("Failed to enable the SQL audit: %v", err)
+	}
+	if cfg.SQLAudit.Enabled {
+		log.Printf("SQL audit: statements are logged to %s (%d allowlist pattern(s), %s mode)", cfg.SQLAudit.Output, len(cfg.SQLAudit.Allowlist), cfg.SQLAudit.Mode)
+	}
+	for _, pair := range cfg.DatabasePairs {
+		if pair.SSHTunnel.Enabled() {
+			log.Printf("Database pair '%s' is reached through the SSH bastion %s@%s:%d", pair.Name, pair.SSHTunnel.User, pair.SSHTunnel.Host, pair.SSHTunnel.Port)
+		}
+	}
+
+	// Trace monitoring cycles, checks and SQL queries
+	var tracingProvider *tracing.Provider
+	if cfg.OpenTelemetry.Enabled {
+		tracingProvider, err = tracing.NewProvider(cfg.OpenTelemetry)
+		if err != nil {
+			log.Fatalf("Failed to set up OpenTelemetry: %v", err)
+		}
+		tracing.SetProvider(tracingProvider)
+		tracingProvider.Start()
+	}
 
 	// Initialize components
 	metricsStorage := storage.NewMetricsStorage()
+	rollups := make([]storage.RollupTier, 0, len(cfg.LagHistory.Rollups))
+	for _, tier := range cfg.LagHistory.Rollups {
+		rollups = append(rollups, storage.RollupTier(tier))
+	}
+	metricsStorage.SetLagRetention(cfg.LagHistory.Raw, rollups)
+	metricsStorage.SetReportRetention(cfg.Reports.Retention, cfg.Reports.Location())
 	alertManager := alert.NewAlertManager(cfg)
+
+	// Deliver alert events to configured notifiers
+	notifiers, err := notify.BuildNotifiers(cfg.Notifiers)
+	if err != nil {
+		log.Fatalf("Failed to configure notifiers: %v", err)
+	}
+	dispatcher := notify.NewDispatcher(notifiers, cfg.Notifiers.Routes)
+	dispatcher.Start()
+	alertManager.AddListener(dispatcher.Enqueue)
 	monitoringEngine := monitor.NewMonitoringEngine(cfg, metricsStorage, alertManager)
-	webServer := web.NewWebServer(cfg, metricsStorage, alertManager)
+	monitoringEngine.AddPairListener(dispatcher.EnqueuePair)
+
+	// Push check metrics to a StatsD/DogStatsD agent
+	var statsdClient *statsd.Client
+	if cfg.StatsD.Enabled {
+		statsdClient, err = statsd.NewClient(cfg.StatsD)
+		if err != nil {
+			log.Fatalf("Failed to configure StatsD metrics: %v", err)
+		}
+		statsdClient.Start()
+		monitoringEngine.SetStatsD(statsdClient)
+	}
+
+	// Write measurements to InfluxDB or TimescaleDB for long-term reporting
+	var timeseriesSink *timeseries.Sink
+	if cfg.TimeSeries.Enabled {
+		timeseriesSink, err = timeseries.NewSink(cfg.TimeSeries)
+		if err != nil {
+			log.Fatalf("Failed to configure the time-series sink: %v", err)
+		}
+		timeseriesSink.Start()
+		monitoringEngine.SetTimeSeries(timeseriesSink)
+	}
+
+	// Federation aggregates pair rollups from peer monitors
+	var aggregator *federation.Aggregator
+	if len(cfg.Federation.Peers) > 0 {
+		aggregator = federation.NewAggregator(cfg.Federation)
+		aggregator.Start()
+	}
+
+	// CloudWatch enrichment for pairs running on RDS
+	var poller *cloudwatch.Poller
+	if cfg.AWS.Enabled {
+		poller, err = cloudwatch.NewPoller(cfg, metricsStorage)
+		if err != nil {
+			log.Fatalf("Failed to configure CloudWatch enrichment: %v", err)
+		}
+		poller.Start()
+	}
+
+	// Permanent audit archive of results and alert events in S3
+	var archiver *archive.Archiver
+	if cfg.Archive.Enabled {
+		archiver, err = archive.NewArchiver(cfg, metricsStorage, alertManager)
+		if err != nil {
+			log.Fatalf("Failed to configure S3 archive: %v", err)
+		}
+		archiver.Start()
+	}
+
+	// Reconnect pairs with the new credentials when secrets are rotated
+	if resolver != nil {
+		resolver.Start(func(pair config.DatabasePair) {
+			if err := monitoringEngine.UpdateDatabaseConfig(pair); err != nil {
+				log.Printf("[%s] Failed to apply rotated database secrets: %v", pair.Name, err)
+			}
+		})
+	}
+
+	// Daily digest of every pair and its insights
+	var digest *notify.DigestScheduler
+	if cfg.Digest.Enabled {
+		digest = notify.NewDigestScheduler(cfg, metricsStorage, alertManager, monitoringEngine, dispatcher)
+		digest.Start()
+	}
+
+	// Summary reports of the last complete day or week
+	var reports *notify.ReportScheduler
+	if cfg.Reports.Schedule != "" {
+		reports = notify.NewReportScheduler(cfg, metricsStorage, dispatcher)
+		reports.Start()
+	}
+
+	webServer := web.NewWebServer(cfg, metricsStorage, alertManager, monitoringEngine, aggregator)
+	webServer.AddQueue("notifications", dispatcher.Queue)
+	webServer.AddQueue("cycle_slots", monitoringEngine.CycleSlots)
 
 	// Start monitoring engine
 	if err := monitoringEngine.Start(); err != nil {
@@ -52,7 +214,11 @@ func main() {
 	}()
 
 	log.Println("MariaDB Encryption Migration Monitor is running")
-	log.Printf("Access the web interface at http://localhost:%d", cfg.WebServerPort)
+	scheme := "http"
+	if cfg.WebTLS.Enabled() {
+		scheme = "https"
+	}
+	log.Printf("Access the web interface at %s://localhost:%d", scheme, cfg.WebServerPort)
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
@@ -60,6 +226,48 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutdown signal received")
+
+	// Drain in-flight requests before the databases are disconnected
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	if err := webServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Web server shutdown error: %v", err)
+	}
+	cancel()
+
+	if resolver != nil {
+		resolver.Stop()
+	}
+	if digest != nil {
+		digest.Stop()
+	}
+	if reports != nil {
+		reports.Stop()
+	}
 	monitoringEngine.Stop()
+	database.CloseSSHTunnels()
+	database.CloseSQLAudit()
+	// Uploads the results of the last cycles
+	if archiver != nil {
+		archiver.Stop()
+	}
+	// Delivers the remaining notifications within the shutdown timeout
+	notifyCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	dispatcher.Stop(notifyCtx)
+	cancel()
+	if aggregator != nil {
+		aggregator.Stop()
+	}
+	if poller != nil {
+		poller.Stop()
+	}
+	if statsdClient != nil {
+		statsdClient.Stop()
+	}
+	if timeseriesSink != nil {
+		timeseriesSink.Stop()
+	}
+	if tracingProvider != nil {
+		tracingProvider.Stop()
+	}
 	log.Println("Shutdown complete")
 }
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/secrets"
+)
+
+// suggestReport is the JSON output of the suggest-tables subcommand
+type suggestReport struct {
+	Pair        string                    `json:"pair"`
+	StatsSource string                    `json:"stats_source,omitempty"` // where write rates were read from
+	Tables      []monitor.TableSuggestion `json:"tables"`
+}
+
+// runSuggestTables implements the "suggest-tables" subcommand, ranking the
+// source tables of a pair and proposing which to monitor and how
+func runSuggestTables(args []string) int {
+	fs := flag.NewFlagSet("suggest-tables", flag.ExitOnError)
+	configSource := addConfigFlags(fs)
+	pairName := fs.String("pair", "", "Database pair name")
+	limit := fs.Int("limit", 20, "Number of tables to suggest")
+	sample := fs.Duration("sample", 10*time.Second, "How long to sample write counters (0 ranks by size only)")
+	jsonOutput := fs.Bool("json", false, "Print the ranking as JSON")
+	fs.Parse(args)
+
+	if *pairName == "" {
+		fmt.Fprintln(os.Stderr, "usage: monitor suggest-tables -pair NAME [-limit N] [-sample 10s] [-json]")
+		return 2
+	}
+
+	cfg, err := configSource.load()
+	if err != nil {
+		log.Printf("Failed to load configuration: %v", err)
+		return 1
+	}
+	if _, err := secrets.Resolve(context.Background(), cfg); err != nil {
+		log.Printf("Failed to resolve database secrets: %v", err)
+		return 1
+	}
+	if err := database.EnableSQLAudit(cfg.SQLAudit); err != nil {
+		log.Printf("Failed to enable the SQL audit: %v", err)
+		return 1
+	}
+
+	var pair *config.DatabasePair
+	for i := range cfg.DatabasePairs {
+		if cfg.DatabasePairs[i].Name == *pairName {
+			pair = &cfg.DatabasePairs[i]
+		}
+	}
+	if pair == nil {
+		log.Printf("Database pair '%s' not found in configuration", *pairName)
+		return 1
+	}
+
+	// The pair's own limits decide the strategies, so the suggestion fits
+	// its configuration
+	opts := monitor.SuggestOptions{
+		Sample:             *sample,
+		ChecksumMaxRows:    1000000,
+		ApproximateMinRows: 10000000,
+		Limit:              *limit,
+	}
+	if pair.ChecksumPreflight.MaxRows > 0 {
+		opts.ChecksumMaxRows = pair.ChecksumPreflight.MaxRows
+	}
+	if pair.ApproximateCounts.Enabled {
+		opts.ApproximateMinRows = pair.ApproximateCounts.MinRows
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Connect+*sample+time.Minute)
+	defer cancel()
+
+	// Writes happen on the source; the target only replays them
+	connMgr := database.NewConnectionManager(&pair.SourceDB, nil, pair.Name, nil, cfg.Timeouts.Connect)
+	defer connMgr.Close()
+	if err := connMgr.ConnectSource(ctx); err != nil {
+		log.Printf("Failed to connect: %v", err)
+		return 1
+	}
+	db, err := connMgr.GetSourceConnection()
+	if err != nil {
+		log.Printf("Failed to connect: %v", err)
+		return 1
+	}
+
+	if *sample > 0 && !*jsonOutput {
+		fmt.Fprintf(os.Stderr, "Sampling write counters for %v...\n", *sample)
+	}
+	tables, statsSource, err := monitor.SuggestTables(ctx, db, opts)
+	if err != nil {
+		log.Printf("Failed to rank tables: %v", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(suggestReport{Pair: pair.Name, StatsSource: statsSource, Tables: tables})
+		return 0
+	}
+	printSuggestions(pair.Name, statsSource, *sample, tables, opts)
+	return 0
+}
+
+// printSuggestions prints the ranking and a configuration snippet for the
+// suggested tables
+func printSuggestions(pairName, statsSource string, sample time.Duration, tables []monitor.TableSuggestion, opts monitor.SuggestOptions) {
+	fmt.Printf("Pair: %s\n", pairName)
+	switch {
+	case sample == 0:
+		fmt.Println("Write rates: not sampled; ranked by size")
+	case statsSource == "":
+		fmt.Println("Write rates: unavailable (enable userstat or performance_schema); ranked by size")
+	default:
+		fmt.Printf("Write rates: sampled from %s over %v\n", statsSource, sample)
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tTABLE\tROWS (EST)\tSIZE\tWRITES/S\tPRIMARY KEY\tSTRATEGY\tNOTES")
+	var suggested []string
+	strategies := make(map[string]bool)
+	for i, t := range tables {
+		writes := "-"
+		if t.WritesPerSecond != nil {
+			writes = fmt.Sprintf("%.1f", *t.WritesPerSecond)
+		}
+		strategy := t.Strategy
+		if !t.Suggested {
+			strategy = "(not suggested)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", i+1, t.Table, t.EstimatedRows, formatBytes(t.EstimatedBytes),
+			writes, t.PrimaryKey, strategy, strings.Join(t.Notes, "; "))
+		if t.Suggested {
+			suggested = append(suggested, t.Table)
+			strategies[t.Strategy] = true
+		}
+	}
+	tw.Flush()
+
+	if len(suggested) == 0 {
+		fmt.Println("\nNo tables to suggest")
+		return
+	}
+	fmt.Println("\nSuggested configuration:")
+	fmt.Println("    tables_to_monitor:")
+	for _, table := range suggested {
+		fmt.Printf("      - %q\n", table)
+	}
+	if strategies[monitor.StrategyCount] || strategies[monitor.StrategyApproximate] {
+		fmt.Println("    checksum_preflight:")
+		fmt.Printf("      max_rows: %d\n", opts.ChecksumMaxRows)
+		fmt.Println(`      action: "skip"`)
+	}
+	if strategies[monitor.StrategyApproximate] {
+		fmt.Println("    approximate_counts:")
+		fmt.Println("      enabled: true")
+		fmt.Printf("      min_rows: %d\n", opts.ApproximateMinRows)
+	}
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 GiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/secrets"
+)
+
+// runValidateConfig implements the "validate-config" subcommand, checking a
+// configuration file without starting the monitor. With -strict, unknown keys
+// are errors and every database is connected and probed for the privileges
+// the monitor needs.
+func runValidateConfig(args []string) int {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configSource := addConfigFlags(fs)
+	strict := fs.Bool("strict", false, "Fail on unknown keys and check database connections and privileges")
+	fs.Parse(args)
+
+	errorCount, warningCount := 0, 0
+	report := func(isError bool, format string, a ...interface{}) {
+		label := "warning"
+		if isError {
+			label = "ERROR"
+			errorCount++
+		} else {
+			warningCount++
+		}
+		fmt.Printf("%s: %s\n", label, fmt.Sprintf(format, a...))
+	}
+	summary := func() int {
+		if errorCount > 0 {
+			fmt.Printf("\nResult: INVALID (%d error(s), %d warning(s))\n", errorCount, warningCount)
+			return 1
+		}
+		fmt.Printf("\nResult: VALID (%d warning(s))\n", warningCount)
+		return 0
+	}
+
+	fmt.Printf("Validating %s\n", configSource)
+	if !*configSource.fromEnv {
+		unknown, err := config.UnknownFields(*configSource.path)
+		if err != nil {
+			report(true, "%v", err)
+			return summary()
+		}
+		for _, field := range unknown {
+			report(*strict, "unknown key, ignored by the monitor: %s", field)
+		}
+	}
+
+	cfg, err := configSource.load()
+	if err != nil {
+		report(true, "%v", err)
+		return summary()
+	}
+	for _, name := range cfg.UnknownEnv() {
+		report(*strict, "environment variable names no setting, ignored by the monitor: %s", name)
+	}
+	fmt.Printf("Parsed %d database pair(s)\n", len(cfg.DatabasePairs))
+
+	if !*strict {
+		return summary()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(cfg.DatabasePairs)+1)*(cfg.Timeouts.Connect+time.Minute))
+	defer cancel()
+	if _, err := secrets.Resolve(ctx, cfg); err != nil {
+		report(true, "failed to resolve database secrets: %v", err)
+		return summary()
+	}
+	if err := database.EnableSQLAudit(cfg.SQLAudit); err != nil {
+		report(true, "failed to enable the SQL audit: %v", err)
+		return summary()
+	}
+
+	for i := range cfg.DatabasePairs {
+		pair := &cfg.DatabasePairs[i]
+		fmt.Printf("\nPair: %s\n", pair.Name)
+		for _, check := range monitor.CheckPermissions(ctx, pair, cfg.Timeouts.Connect) {
+			if check.Error == "" {
+				fmt.Printf("  ok: %s %s\n", check.Database, check.Check)
+				continue
+			}
+			fmt.Print("  ")
+			report(!check.Optional, "%s %s: %s", check.Database, check.Check, check.Error)
+			if check.Hint != "" {
+				fmt.Printf("    fix: %s\n", check.Hint)
+			}
+		}
+	}
+	return summary()
+}
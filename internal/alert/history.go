@@ -0,0 +1,76 @@
+package alert
+
+import "time"
+
+// MaxHistoryPageSize bounds the alerts returned by one history query
+const MaxHistoryPageSize = 1000
+
+// HistoryQuery filters and pages the alert history. Empty fields match
+// every alert.
+type HistoryQuery struct {
+	Pair     string
+	Type     string
+	Severity string
+	Resolved *bool
+
+	// Alerts raised in [Since, Until)
+	Since time.Time
+	Until time.Time
+
+	Offset int
+	Limit  int // zero uses alert_history.api_limit
+}
+
+// matches reports whether an alert passes the filters of a query
+func (q HistoryQuery) matches(a *Alert) bool {
+	switch {
+	case q.Pair != "" && a.DatabasePair != q.Pair:
+		return false
+	case q.Type != "" && a.Type != q.Type:
+		return false
+	case q.Severity != "" && a.Severity != q.Severity:
+		return false
+	case q.Resolved != nil && a.Resolved != *q.Resolved:
+		return false
+	case !q.Since.IsZero() && a.Timestamp.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !a.Timestamp.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// HistoryPage is one page of the alerts matching a history query, newest
+// first
+type HistoryPage struct {
+	Total  int     `json:"total"` // alerts matching the filters
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+	Alerts []Alert `json:"alerts"`
+}
+
+// QueryAlertHistory returns the alerts of the history, including resolved
+// ones, that match a query, newest first
+func (am *AlertManager) QueryAlertHistory(query HistoryQuery) HistoryPage {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = am.config.AlertHistory.APILimit
+	}
+	limit = min(limit, MaxHistoryPageSize)
+
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	page := HistoryPage{Offset: query.Offset, Limit: limit, Alerts: make([]Alert, 0, min(limit, len(am.alerts)))}
+	for i := len(am.alerts) - 1; i >= 0; i-- {
+		alert := am.alerts[i]
+		if !query.matches(alert) {
+			continue
+		}
+		if page.Total >= query.Offset && len(page.Alerts) < limit {
+			page.Alerts = append(page.Alerts, *alert)
+		}
+		page.Total++
+	}
+	return page
+}
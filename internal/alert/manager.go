@@ -2,36 +2,139 @@ package alert
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"mariadb-encryption-monitor/internal/clock"
 	"mariadb-encryption-monitor/internal/config"
 )
 
 // Alert represents an alert
 type Alert struct {
-	ID        string
-	Timestamp time.Time
-	Severity  string
+	ID           string
+	Timestamp    time.Time
+	Severity     string
+	Type         string
+	DatabasePair string
+	TableName    string
+	Message      string
+	Resolved     bool
+	UpdatedAt    time.Time // last severity or message change
+	NotifiedAt   time.Time // last created, updated or re-notified event
+
+	// Owner, runbook and ticket of the pair, for responders
+	Metadata config.PairMetadata
+
+	// Alerts ingested from another system, e.g. SourceAlertmanager, keep its labels
+	Source string
+	Labels map[string]string
+
+	// Acknowledged alerts are not re-notified until their severity changes
+	Acknowledged   bool
+	AcknowledgedBy string
+	AcknowledgedAt time.Time
+
+	// Alerts raised during a maintenance window are recorded but not notified
+	Suppressed   bool
+	SuppressedBy string // maintenance window name
+
+	// ID of the rehearsal fault that raised the alert; its message is
+	// prefixed with REHEARSAL so no responder mistakes it for a real alert
+	Rehearsal string `json:",omitempty"`
+}
+
+// Alert event types
+const (
+	EventCreated  = "alert_created"
+	EventUpdated  = "alert_updated"
+	EventResolved = "alert_resolved"
+
+	// An alert still active after alert_policy.renotify_interval
+	EventRenotified = "alert_renotified"
+
+	// An operator took ownership of an active alert
+	EventAcknowledged = "alert_acknowledged"
+)
+
+// SourceAlertmanager marks alerts ingested from Prometheus Alertmanager
+const SourceAlertmanager = "alertmanager"
+
+// AlertEvent describes a change to an alert
+type AlertEvent struct {
 	Type      string
-	Message   string
-	Resolved  bool
+	Alert     Alert
+	Timestamp time.Time
+}
+
+// HistoryStats describes the in-memory alert history and its evictions
+type HistoryStats struct {
+	Retained       int    `json:"retained"`
+	Active         int    `json:"active"`
+	MaxAlerts      int    `json:"max_alerts"`
+	MaxAge         string `json:"max_age,omitempty"`
+	EvictedTotal   uint64 `json:"evicted_total"`
+	EvictedByCount uint64 `json:"evicted_by_count"`
+	EvictedByAge   uint64 `json:"evicted_by_age"`
 }
 
 // AlertManager manages alerts
 type AlertManager struct {
 	config       *config.Config
-	alerts       []Alert
+	clock        clock.Clock
+	alerts       []*Alert
 	activeAlerts map[string]*Alert
+	listeners    []func(AlertEvent)
 	mu           sync.RWMutex
+
+	// Consecutive breaching checks of conditions not firing yet, and
+	// consecutive OK checks of firing alerts, by alert key
+	breaches map[string]int
+	passes   map[string]int
+
+	evictedByCount uint64
+	evictedByAge   uint64
 }
 
 // NewAlertManager creates a new alert manager
 func NewAlertManager(cfg *config.Config) *AlertManager {
 	return &AlertManager{
 		config:       cfg,
-		alerts:       make([]Alert, 0),
+		clock:        clock.Real,
+		alerts:       make([]*Alert, 0),
 		activeAlerts: make(map[string]*Alert),
+		breaches:     make(map[string]int),
+		passes:       make(map[string]int),
+	}
+}
+
+// SetClock sets the clock alerts are timestamped by, which also drives
+// re-notification and the maximum age of the history. It must be called
+// before any alert is evaluated.
+func (am *AlertManager) SetClock(c clock.Clock) {
+	am.clock = c
+}
+
+// AddListener registers a function called for every alert event.
+// Listeners are called synchronously and must not block.
+func (am *AlertManager) AddListener(listener func(AlertEvent)) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	am.listeners = append(am.listeners, listener)
+}
+
+// emit delivers events to all registered listeners
+func (am *AlertManager) emit(events []AlertEvent) {
+	am.mu.RLock()
+	listeners := am.listeners
+	am.mu.RUnlock()
+
+	for _, event := range events {
+		for _, listener := range listeners {
+			listener(event)
+		}
 	}
 }
 
@@ -40,6 +143,14 @@ type ReplicaLagMetric struct {
 	LagSeconds float64
 	Status     string
 	Error      error
+
+	ConnectRetry     int64
+	MasterRetryCount *int64 // nil when the replica does not report it
+
+	SlowestHop string // hop of a replication chain with the most lag
+	Backlog    string // binary log backlog of the replication threads
+	Writes     string // write rate of the source's binary log
+	Rehearsal  string // ID of the rehearsal fault the sample was replaced by
 }
 
 // EvaluateReplicaLag evaluates replica lag and generates alerts if needed
@@ -50,25 +161,54 @@ func (am *AlertManager) EvaluateReplicaLag(pairName string, metric *ReplicaLagMe
 
 	alertKey := fmt.Sprintf("replica_lag_%s", pairName)
 
-	// Check if lag exceeds threshold
-	if metric.Status == "ok" && metric.LagSeconds > am.config.ReplicaLagThreshold.Seconds() {
+	// Check if lag exceeds a threshold tier
+	severity, threshold := am.lagSeverity(pairName, metric.LagSeconds)
+	if metric.Status == "ok" && severity != "" {
+		details := ""
+		if metric.SlowestHop != "" {
+			details = "; slowest hop: " + metric.SlowestHop
+		}
+		if metric.Backlog != "" {
+			details += "; " + metric.Backlog
+		}
+		if metric.Writes != "" {
+			details += "; " + metric.Writes
+		}
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "WARNING",
-			Type:      "replica_lag",
-			Message:   fmt.Sprintf("[%s] Replica lag (%.2f seconds) exceeds threshold (%.2f seconds)", pairName, metric.LagSeconds, am.config.ReplicaLagThreshold.Seconds()),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     severity,
+			Type:         "replica_lag",
+			DatabasePair: pairName,
+			Rehearsal:    metric.Rehearsal,
+			Message:      fmt.Sprintf("[%s] %sReplica lag (%.2f seconds) exceeds %s threshold (%.2f seconds)%s", pairName, rehearsalPrefix(metric.Rehearsal), metric.LagSeconds, severity, threshold.Seconds(), details),
+			Resolved:     false,
+		}
+		am.addAlert(alertKey, alert)
+	} else if metric.Status == "connection_retrying" {
+		attempts := ""
+		if metric.MasterRetryCount != nil {
+			attempts = fmt.Sprintf(", up to %d attempts", *metric.MasterRetryCount)
+		}
+		alert := Alert{
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "CRITICAL",
+			Type:         "replication_retrying",
+			DatabasePair: pairName,
+			Message:      fmt.Sprintf("[%s] Replica stuck reconnecting to primary (retry every %ds%s): %v", pairName, metric.ConnectRetry, attempts, metric.Error),
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else if metric.Status == "replication_stopped" {
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "CRITICAL",
-			Type:      "replication_stopped",
-			Message:   fmt.Sprintf("[%s] Replication stopped: %v", pairName, metric.Error),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "CRITICAL",
+			Type:         "replication_stopped",
+			DatabasePair: pairName,
+			Message:      fmt.Sprintf("[%s] Replication stopped: %v", pairName, metric.Error),
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else {
@@ -77,18 +217,139 @@ func (am *AlertManager) EvaluateReplicaLag(pairName string, metric *ReplicaLagMe
 	}
 }
 
+// LagAnomaly represents a replica lag sample scored against its baseline
+// for alert evaluation
+type LagAnomaly struct {
+	Severity   string // "" when the sample is not anomalous
+	LagSeconds float64
+	Baseline   float64
+	StdDev     float64
+	ZScore     float64
+}
+
+// EvaluateLagAnomaly raises an INFO or WARNING alert while replica lag
+// deviates from its baseline. Lag past a replica_lag threshold tier is
+// alerted by EvaluateReplicaLag instead.
+func (am *AlertManager) EvaluateLagAnomaly(pairName string, anomaly *LagAnomaly) {
+	if anomaly == nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("lag_anomaly_%s", pairName)
+	if threshold, _ := am.lagSeverity(pairName, anomaly.LagSeconds); anomaly.Severity == "" || threshold != "" {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     anomaly.Severity,
+		Type:         "replica_lag_anomaly",
+		DatabasePair: pairName,
+		Message: fmt.Sprintf("[%s] Replica lag (%.0f seconds) deviates from its baseline (%.1f ± %.1f seconds, z-score %.1f)",
+			pairName, anomaly.LagSeconds, anomaly.Baseline, anomaly.StdDev, anomaly.ZScore),
+		Resolved: false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// Failover represents a failover behind the cluster endpoint of a database
+// for alert evaluation
+type Failover struct {
+	Database    string // "source" or "target"
+	Description string
+}
+
+// EvaluateFailovers raises an INFO alert noting a failover of a pair's
+// source or target, without waiting for alert_policy.fire_after since it is
+// an event. It resolves after resolve_after checks without a failover.
+func (am *AlertManager) EvaluateFailovers(pairName string, failovers []Failover) {
+	for _, database := range []string{"source", "target"} {
+		alertKey := fmt.Sprintf("failover_%s_%s", pairName, database)
+		i := slices.IndexFunc(failovers, func(f Failover) bool { return f.Database == database })
+		if i < 0 {
+			am.resolveAlert(alertKey)
+			continue
+		}
+		am.raise(alertKey, Alert{
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "INFO",
+			Type:         "failover",
+			DatabasePair: pairName,
+			Message:      fmt.Sprintf("[%s] Failover detected: %s; reconnected and re-baselined replica lag measurement", pairName, failovers[i].Description),
+		})
+	}
+}
+
+// PreflightFailure is a required privilege or server feature a pair's
+// checks lack
+type PreflightFailure struct {
+	Database string
+	Check    string
+	Error    string
+}
+
+// EvaluatePreflight raises a WARNING alert while pre-flight checks of a pair
+// fail, and resolves it once they pass
+func (am *AlertManager) EvaluatePreflight(pairName string, failures []PreflightFailure) {
+	alertKey := fmt.Sprintf("preflight_%s", pairName)
+	if len(failures) == 0 {
+		am.resolveAlert(alertKey)
+		return
+	}
+	first := failures[0]
+	message := fmt.Sprintf("[%s] Pre-flight check failed: %s %s: %s", pairName, first.Database, first.Check, first.Error)
+	if len(failures) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(failures)-1)
+	}
+	am.raise(alertKey, Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     "WARNING",
+		Type:         "preflight",
+		DatabasePair: pairName,
+		Message:      message,
+	})
+}
+
+// EvaluateUnreachable raises a single CRITICAL alert while the circuit
+// breaker of a database is open, rather than one per failed cycle, and
+// resolves it once the database is reachable. since is the first failed
+// health check of the outage, nil while the breaker is closed.
+func (am *AlertManager) EvaluateUnreachable(pairName, database string, since *time.Time) {
+	alertKey := fmt.Sprintf("database_unreachable_%s_%s", pairName, database)
+	if since == nil {
+		am.resolveAlert(alertKey)
+		return
+	}
+	am.raise(alertKey, Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     "CRITICAL",
+		Type:         "database_unreachable",
+		DatabasePair: pairName,
+		Message: fmt.Sprintf("[%s] %s database unreachable since %s; its checks are backed off until it responds",
+			pairName, database, since.UTC().Format(time.RFC3339)),
+	})
+}
+
 // ChecksumResult represents checksum data for alert evaluation
 type ChecksumResult struct {
 	TableName      string
 	SourceChecksum string
 	TargetChecksum string
 	Match          bool
+	Skipped        bool   // pre-flight size limits prevented the checksum
+	Rehearsal      string // ID of the rehearsal fault the result was replaced by
 	Error          error
 }
 
-// EvaluateChecksum evaluates checksum results and generates alerts if needed
+// EvaluateChecksum evaluates checksum results and generates alerts if needed.
+// Skipped checksums say nothing about the table and leave its alerts as they are.
 func (am *AlertManager) EvaluateChecksum(pairName string, result *ChecksumResult) {
-	if result == nil {
+	if result == nil || result.Skipped {
 		return
 	}
 
@@ -96,22 +357,27 @@ func (am *AlertManager) EvaluateChecksum(pairName string, result *ChecksumResult
 
 	if !result.Match && result.Error == nil {
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "CRITICAL",
-			Type:      "checksum_mismatch",
-			Message:   fmt.Sprintf("[%s] Checksum mismatch for table %s (source: %s, target: %s)", pairName, result.TableName, result.SourceChecksum, result.TargetChecksum),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "CRITICAL",
+			Type:         "checksum_mismatch",
+			DatabasePair: pairName,
+			TableName:    result.TableName,
+			Rehearsal:    result.Rehearsal,
+			Message:      fmt.Sprintf("[%s] %sChecksum mismatch for table %s (source: %s, target: %s)", pairName, rehearsalPrefix(result.Rehearsal), result.TableName, result.SourceChecksum, result.TargetChecksum),
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else if result.Error != nil {
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "WARNING",
-			Type:      "checksum_error",
-			Message:   fmt.Sprintf("[%s] Checksum validation error for table %s: %v", pairName, result.TableName, result.Error),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "WARNING",
+			Type:         "checksum_error",
+			DatabasePair: pairName,
+			TableName:    result.TableName,
+			Message:      fmt.Sprintf("[%s] Checksum validation error for table %s: %v", pairName, result.TableName, result.Error),
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else {
@@ -126,7 +392,17 @@ type ConsistencyResult struct {
 	SourceRowCount int64
 	TargetRowCount int64
 	Consistent     bool
+	Approximate    bool
+	Backfill       string // ID of the backfill the comparison was relaxed for
+	Rehearsal      string // ID of the rehearsal fault the result was replaced by
 	Error          error
+
+	// Differences of an append-only table's key range and recent rows, and
+	// of the rows it gained in the window; WindowOnly when the row counts
+	// above were not compared
+	Window      string
+	WindowDrift int64
+	WindowOnly  bool
 }
 
 // EvaluateConsistency evaluates consistency results and generates alerts if needed
@@ -135,26 +411,63 @@ func (am *AlertManager) EvaluateConsistency(pairName string, result *Consistency
 		return
 	}
 
+	// Estimated row counts differ between instances on their own, so they
+	// neither raise nor resolve a mismatch; the next exact count decides
+	if result.Approximate && result.Error == nil && result.Window == "" {
+		return
+	}
+
 	alertKey := fmt.Sprintf("consistency_%s_%s", pairName, result.TableName)
 
-	if !result.Consistent && result.Error == nil {
+	countsCompared := !result.WindowOnly && !result.Approximate
+	var drift int64
+	if countsCompared {
+		drift = result.SourceRowCount - result.TargetRowCount
+		if drift < 0 {
+			drift = -drift
+		}
+	}
+	drift = max(drift, result.WindowDrift)
+	severity := am.driftSeverity(pairName, drift)
+	if severity == "" && result.Window != "" {
+		// A differing key range is not a matter of how many rows drifted
+		severity = "WARNING"
+	}
+
+	if !result.Consistent && result.Error == nil && severity != "" {
+		during := ""
+		if result.Backfill != "" {
+			during = fmt.Sprintf(" beyond backfill %s tolerance", result.Backfill)
+		}
+		message := fmt.Sprintf("[%s] %sRow count mismatch%s for table %s (source: %d, target: %d)", pairName, rehearsalPrefix(result.Rehearsal), during, result.TableName, result.SourceRowCount, result.TargetRowCount)
+		if !countsCompared {
+			message = fmt.Sprintf("[%s] %sRecent rows mismatch for table %s", pairName, rehearsalPrefix(result.Rehearsal), result.TableName)
+		}
+		if result.Window != "" {
+			message += ": " + result.Window
+		}
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "CRITICAL",
-			Type:      "consistency_mismatch",
-			Message:   fmt.Sprintf("[%s] Row count mismatch for table %s (source: %d, target: %d)", pairName, result.TableName, result.SourceRowCount, result.TargetRowCount),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     severity,
+			Type:         "consistency_mismatch",
+			DatabasePair: pairName,
+			TableName:    result.TableName,
+			Rehearsal:    result.Rehearsal,
+			Message:      message,
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else if result.Error != nil {
 		alert := Alert{
-			ID:        fmt.Sprintf("%s_%d", alertKey, time.Now().Unix()),
-			Timestamp: time.Now(),
-			Severity:  "WARNING",
-			Type:      "consistency_error",
-			Message:   fmt.Sprintf("[%s] Consistency check error for table %s: %v", pairName, result.TableName, result.Error),
-			Resolved:  false,
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "WARNING",
+			Type:         "consistency_error",
+			DatabasePair: pairName,
+			TableName:    result.TableName,
+			Message:      fmt.Sprintf("[%s] Consistency check error for table %s: %v", pairName, result.TableName, result.Error),
+			Resolved:     false,
 		}
 		am.addAlert(alertKey, alert)
 	} else {
@@ -163,31 +476,611 @@ func (am *AlertManager) EvaluateConsistency(pairName string, result *Consistency
 	}
 }
 
-// addAlert adds or updates an alert
+// ClockSkewMetric represents clock skew data for alert evaluation
+type ClockSkewMetric struct {
+	SourceSkew       time.Duration
+	TargetSkew       time.Duration
+	SourceTargetSkew time.Duration
+	Error            error
+}
+
+// EvaluateClockSkew evaluates clock skew and generates alerts if needed
+func (am *AlertManager) EvaluateClockSkew(pairName string, metric *ClockSkewMetric) {
+	if metric == nil || metric.Error != nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("clock_skew_%s", pairName)
+
+	worst := absDuration(metric.SourceSkew)
+	for _, skew := range []time.Duration{metric.TargetSkew, metric.SourceTargetSkew} {
+		if absDuration(skew) > worst {
+			worst = absDuration(skew)
+		}
+	}
+
+	severity := ""
+	tiers := am.config.PairThresholds(pairName).ClockSkew
+	if tiers.CriticalAt > 0 && worst > tiers.CriticalAt {
+		severity = "CRITICAL"
+	} else if tiers.WarningAt > 0 && worst > tiers.WarningAt {
+		severity = "WARNING"
+	}
+
+	if severity == "" {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     severity,
+		Type:         "clock_skew",
+		DatabasePair: pairName,
+		Message: fmt.Sprintf("[%s] Clock skew detected (source: %v, target: %v, source-to-target: %v); Seconds_Behind_Master may be unreliable",
+			pairName, metric.SourceSkew.Round(time.Millisecond), metric.TargetSkew.Round(time.Millisecond), metric.SourceTargetSkew.Round(time.Millisecond)),
+		Resolved: false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// WarmupResult represents a target warm-up check for alert evaluation
+type WarmupResult struct {
+	Ready    bool
+	Problems []string
+	Error    error
+}
+
+// EvaluateWarmup raises a warning while the target is not ready for production reads
+func (am *AlertManager) EvaluateWarmup(pairName string, result *WarmupResult) {
+	if result == nil || result.Error != nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("warmup_%s", pairName)
+	if result.Ready {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     "WARNING",
+		Type:         "target_not_warm",
+		DatabasePair: pairName,
+		Message:      fmt.Sprintf("[%s] Target is not ready for production reads: %s", pairName, strings.Join(result.Problems, "; ")),
+		Resolved:     false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// SemiSyncMetric represents semi-sync replication state for alert evaluation
+type SemiSyncMetric struct {
+	Async    bool // replication is asynchronous right now
+	Problems []string
+	Error    error
+}
+
+// EvaluateSemiSync raises an alert while semi-sync replication is degraded:
+// critical while replication is asynchronous, a warning when commits went
+// unacknowledged since the last check but semi-sync has recovered
+func (am *AlertManager) EvaluateSemiSync(pairName string, metric *SemiSyncMetric) {
+	if metric == nil || metric.Error != nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("semi_sync_%s", pairName)
+	if len(metric.Problems) == 0 {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	severity := "WARNING"
+	if metric.Async {
+		severity = "CRITICAL"
+	}
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     severity,
+		Type:         "semi_sync_degraded",
+		DatabasePair: pairName,
+		Message:      fmt.Sprintf("[%s] Semi-sync replication degraded, commits may be lost on failover: %s", pairName, strings.Join(metric.Problems, "; ")),
+		Resolved:     false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// ReplicationFilters represents the replication filters and skip settings of
+// a target for alert evaluation
+type ReplicationFilters struct {
+	Skipping bool // the target skips events or errors
+	Problems []string
+	Error    error
+}
+
+// EvaluateReplicationFilters raises an alert while the target filters or
+// skips replicated changes: critical while it skips events or errors, whose
+// loss depends on what fails, a warning for filters alone
+func (am *AlertManager) EvaluateReplicationFilters(pairName string, result *ReplicationFilters) {
+	if result == nil || result.Error != nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("replication_filters_%s", pairName)
+	if len(result.Problems) == 0 {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	severity := "WARNING"
+	if result.Skipping {
+		severity = "CRITICAL"
+	}
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     severity,
+		Type:         "replication_filters_active",
+		DatabasePair: pairName,
+		Message:      fmt.Sprintf("[%s] Target does not apply every replicated change, so the databases may diverge: %s", pairName, strings.Join(result.Problems, "; ")),
+		Resolved:     false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// TableDefinitions represents a table definition check for alert evaluation
+type TableDefinitions struct {
+	Problems    []string // prefixed with the table
+	Unencrypted int      // tables not encrypted on the target
+	Error       error
+}
+
+// EvaluateTableDefinitions raises an alert while the definitions of monitored
+// tables do not match the migration: critical while a table is not encrypted
+// on the target, a warning for other differences
+func (am *AlertManager) EvaluateTableDefinitions(pairName string, result *TableDefinitions) {
+	if result == nil || result.Error != nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("table_definitions_%s", pairName)
+	if len(result.Problems) == 0 {
+		am.resolveAlert(alertKey)
+		return
+	}
+
+	severity := "WARNING"
+	if result.Unencrypted > 0 {
+		severity = "CRITICAL"
+	}
+	problems := result.Problems
+	more := ""
+	if len(problems) > 3 {
+		problems, more = problems[:3], fmt.Sprintf(" (and %d more)", len(result.Problems)-3)
+	}
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     severity,
+		Type:         "table_definition_mismatch",
+		DatabasePair: pairName,
+		Message:      fmt.Sprintf("[%s] Table definitions do not match the migration: %s%s", pairName, strings.Join(problems, "; "), more),
+		Resolved:     false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// DivergenceResult represents a table's divergence counter of a dual_write
+// pair for alert evaluation
+type DivergenceResult struct {
+	TableName     string
+	Consecutive   int // divergent checks in a row
+	AlertAfter    int // dual_write.alert_after
+	Divergent     int64
+	Checks        int64
+	DivergedSince time.Time
+}
+
+// EvaluateDivergence raises a critical alert once the source and target of a
+// dual_write pair diverged on a table in alert_after consecutive checks, and
+// resolves it at the first check in which they agree
+func (am *AlertManager) EvaluateDivergence(pairName string, result *DivergenceResult) {
+	if result == nil {
+		return
+	}
+
+	alertKey := fmt.Sprintf("dual_write_divergence_%s_%s", pairName, result.TableName)
+	if result.Consecutive == 0 {
+		am.resolveAlert(alertKey)
+		return
+	}
+	if result.Consecutive < result.AlertAfter {
+		return
+	}
+
+	alert := Alert{
+		ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+		Timestamp:    am.clock.Now(),
+		Severity:     "CRITICAL",
+		Type:         "dual_write_divergence",
+		DatabasePair: pairName,
+		TableName:    result.TableName,
+		Message: fmt.Sprintf("[%s] Dual writes diverged on table %s in %d consecutive checks since %s (%d of %d checks divergent)",
+			pairName, result.TableName, result.Consecutive, result.DivergedSince.UTC().Format("15:04:05 UTC"), result.Divergent, result.Checks),
+		Resolved: false,
+	}
+	am.addAlert(alertKey, alert)
+}
+
+// PTChecksumTable is pt-table-checksum's result for one table for alert evaluation
+type PTChecksumTable struct {
+	TableName  string
+	Chunks     int
+	DiffChunks int
+	FirstDiff  string // description of the first differing chunk
+}
+
+// PTChecksumResult represents a read of pt-table-checksum's results for
+// alert evaluation
+type PTChecksumResult struct {
+	Tables []PTChecksumTable
+	Error  error
+}
+
+// EvaluatePTChecksum raises a critical alert for each table with chunks that
+// pt-table-checksum found differing, and a warning when its results cannot
+// be read. Alerts of tables no longer in the results are resolved.
+func (am *AlertManager) EvaluatePTChecksum(pairName string, result *PTChecksumResult) {
+	if result == nil {
+		return
+	}
+
+	errorKey := fmt.Sprintf("pt_checksum_error_%s", pairName)
+	if result.Error != nil {
+		am.addAlert(errorKey, Alert{
+			ID:           fmt.Sprintf("%s_%d", errorKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "WARNING",
+			Type:         "pt_checksum_error",
+			DatabasePair: pairName,
+			Message:      fmt.Sprintf("[%s] Cannot read pt-table-checksum results: %v", pairName, result.Error),
+			Resolved:     false,
+		})
+		return
+	}
+	am.resolveAlert(errorKey)
+
+	reported := make(map[string]bool, len(result.Tables))
+	for _, table := range result.Tables {
+		alertKey := fmt.Sprintf("pt_checksum_%s_%s", pairName, table.TableName)
+		reported[alertKey] = true
+		if table.DiffChunks == 0 {
+			am.resolveAlert(alertKey)
+			continue
+		}
+		am.addAlert(alertKey, Alert{
+			ID:           fmt.Sprintf("%s_%d", alertKey, am.clock.Now().Unix()),
+			Timestamp:    am.clock.Now(),
+			Severity:     "CRITICAL",
+			Type:         "pt_checksum_diff",
+			DatabasePair: pairName,
+			TableName:    table.TableName,
+			Message: fmt.Sprintf("[%s] pt-table-checksum found %d of %d chunks differing for table %s; first: %s",
+				pairName, table.DiffChunks, table.Chunks, table.TableName, table.FirstDiff),
+			Resolved: false,
+		})
+	}
+
+	am.mu.RLock()
+	var stale []string
+	for key, alert := range am.activeAlerts {
+		if alert.DatabasePair == pairName && alert.Type == "pt_checksum_diff" && !reported[key] {
+			stale = append(stale, key)
+		}
+	}
+	am.mu.RUnlock()
+	for _, key := range stale {
+		am.resolveNow(key)
+	}
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// lagSeverity returns the highest threshold tier exceeded by a lag value
+func (am *AlertManager) lagSeverity(pairName string, lagSeconds float64) (string, time.Duration) {
+	tiers := am.config.PairThresholds(pairName).ReplicaLag
+	if tiers.CriticalAt > 0 && lagSeconds > tiers.CriticalAt.Seconds() {
+		return "CRITICAL", tiers.CriticalAt
+	}
+	if tiers.WarningAt > 0 && lagSeconds > tiers.WarningAt.Seconds() {
+		return "WARNING", tiers.WarningAt
+	}
+	return "", 0
+}
+
+// rehearsalPrefix marks the message of an alert raised by a rehearsal fault
+func rehearsalPrefix(faultID string) string {
+	if faultID == "" {
+		return ""
+	}
+	return fmt.Sprintf("REHEARSAL (%s): ", faultID)
+}
+
+// driftSeverity returns the highest threshold tier reached by a row count difference
+func (am *AlertManager) driftSeverity(pairName string, drift int64) string {
+	tiers := am.config.PairThresholds(pairName).RowCountDrift
+	if tiers.CriticalAt > 0 && drift >= tiers.CriticalAt {
+		return "CRITICAL"
+	}
+	if tiers.WarningAt > 0 && drift >= tiers.WarningAt {
+		return "WARNING"
+	}
+	return ""
+}
+
+// addAlert adds or updates an alert. A new alert fires only after
+// alert_policy.fire_after consecutive breaching checks.
 func (am *AlertManager) addAlert(key string, alert Alert) {
+	if !am.breached(key) {
+		return
+	}
+	am.raise(key, alert)
+}
+
+// raise stores an alert and notifies its events, unless a maintenance window
+// of the pair suppresses it
+func (am *AlertManager) raise(key string, alert Alert) {
+	alert.Metadata = am.config.PairMetadata(alert.DatabasePair)
+	if window, ok := am.config.ActiveMaintenanceWindow(alert.DatabasePair, alert.Timestamp); ok {
+		alert.Suppressed = true
+		alert.SuppressedBy = window
+	}
+	am.emit(am.storeAlert(key, alert))
+}
+
+// storeAlert adds or updates an alert and returns the resulting events
+func (am *AlertManager) storeAlert(key string, alert Alert) []AlertEvent {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	// Check if alert already exists to avoid duplicates
+	var events []AlertEvent
 	if existing, exists := am.activeAlerts[key]; exists {
-		if existing.Message == alert.Message {
-			return // Duplicate alert, don't add
+		if existing.Type == alert.Type {
+			// A suppressed alert still active after its maintenance window is
+			// notified as new; a notified alert is never suppressed afterwards
+			if existing.Suppressed && !alert.Suppressed {
+				existing.Suppressed = false
+				existing.SuppressedBy = ""
+				existing.Severity = alert.Severity
+				existing.Message = alert.Message
+				existing.UpdatedAt = alert.Timestamp
+				existing.NotifiedAt = alert.Timestamp
+				return []AlertEvent{{Type: EventCreated, Alert: *existing, Timestamp: alert.Timestamp}}
+			}
+			renotify := am.config.AlertPolicy.RenotifyInterval > 0 && !existing.Suppressed && !existing.Acknowledged &&
+				alert.Timestamp.Sub(existing.NotifiedAt) >= am.config.AlertPolicy.RenotifyInterval
+			if existing.Message == alert.Message && existing.Severity == alert.Severity && !renotify {
+				return nil // Duplicate alert, don't add
+			}
+			// Same condition with a new value or tier: upgrade/downgrade in place
+			severityChanged := existing.Severity != alert.Severity
+			if existing.Message != alert.Message || severityChanged {
+				existing.Severity = alert.Severity
+				existing.Message = alert.Message
+				existing.UpdatedAt = alert.Timestamp
+			}
+			switch {
+			case severityChanged:
+				existing.NotifiedAt = alert.Timestamp
+				existing.Acknowledged = false
+				existing.AcknowledgedBy = ""
+				existing.AcknowledgedAt = time.Time{}
+				events = append(events, AlertEvent{Type: EventUpdated, Alert: *existing, Timestamp: alert.Timestamp})
+			case renotify:
+				existing.NotifiedAt = alert.Timestamp
+				events = append(events, AlertEvent{Type: EventRenotified, Alert: *existing, Timestamp: alert.Timestamp})
+			}
+			return events
 		}
+		// A different condition replaces the previous one
+		existing.Resolved = true
+		existing.UpdatedAt = alert.Timestamp
+		events = append(events, AlertEvent{Type: EventResolved, Alert: *existing, Timestamp: alert.Timestamp})
 	}
 
-	am.activeAlerts[key] = &alert
-	am.alerts = append(am.alerts, alert)
+	stored := alert
+	stored.UpdatedAt = alert.Timestamp
+	stored.NotifiedAt = alert.Timestamp
+	am.activeAlerts[key] = &stored
+	am.alerts = append(am.alerts, &stored)
+	am.trimHistory()
+
+	return append(events, AlertEvent{Type: EventCreated, Alert: stored, Timestamp: alert.Timestamp})
 }
 
-// resolveAlert resolves an active alert
-func (am *AlertManager) resolveAlert(key string) {
+// trimHistory evicts resolved alerts older than max_age, then the oldest
+// resolved alerts beyond max_alerts. Callers must hold am.mu.
+func (am *AlertManager) trimHistory() {
+	limits := am.config.AlertHistory
+
+	if limits.MaxAge > 0 {
+		cutoff := am.clock.Now().Add(-limits.MaxAge)
+		kept := am.alerts[:0]
+		for _, alert := range am.alerts {
+			if alert.Resolved && alert.UpdatedAt.Before(cutoff) {
+				am.evictedByAge++
+				continue
+			}
+			kept = append(kept, alert)
+		}
+		clear(am.alerts[len(kept):])
+		am.alerts = kept
+	}
+
+	excess := len(am.alerts) - limits.MaxAlerts
+	if limits.MaxAlerts <= 0 || excess <= 0 {
+		return
+	}
+	kept := am.alerts[:0]
+	for _, alert := range am.alerts {
+		if excess > 0 && alert.Resolved {
+			excess--
+			am.evictedByCount++
+			continue
+		}
+		kept = append(kept, alert)
+	}
+	clear(am.alerts[len(kept):])
+	am.alerts = kept
+}
+
+// HistoryStats returns the size of the alert history and eviction counters
+func (am *AlertManager) HistoryStats() HistoryStats {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	stats := HistoryStats{
+		Retained:       len(am.alerts),
+		Active:         len(am.activeAlerts),
+		MaxAlerts:      am.config.AlertHistory.MaxAlerts,
+		EvictedTotal:   am.evictedByCount + am.evictedByAge,
+		EvictedByCount: am.evictedByCount,
+		EvictedByAge:   am.evictedByAge,
+	}
+	if am.config.AlertHistory.MaxAge > 0 {
+		stats.MaxAge = am.config.AlertHistory.MaxAge.String()
+	}
+	return stats
+}
+
+// breached records a breaching check of a condition and reports whether its
+// alert fires: it is already active, or the condition breached
+// alert_policy.fire_after checks in a row
+func (am *AlertManager) breached(key string) bool {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	if alert, exists := am.activeAlerts[key]; exists {
-		alert.Resolved = true
-		delete(am.activeAlerts, key)
+	delete(am.passes, key)
+	if _, active := am.activeAlerts[key]; active {
+		return true
 	}
+	am.breaches[key]++
+	if am.breaches[key] < am.config.AlertPolicy.FireAfter {
+		return false
+	}
+	delete(am.breaches, key)
+	return true
+}
+
+// passed records an OK check of a condition and reports whether its alert
+// resolves: it passed alert_policy.resolve_after checks in a row
+func (am *AlertManager) passed(key string) bool {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	delete(am.breaches, key)
+	if _, active := am.activeAlerts[key]; !active {
+		return false
+	}
+	am.passes[key]++
+	if am.passes[key] < am.config.AlertPolicy.ResolveAfter {
+		return false
+	}
+	delete(am.passes, key)
+	return true
+}
+
+// resolveAlert resolves an active alert after alert_policy.resolve_after
+// consecutive OK checks
+func (am *AlertManager) resolveAlert(key string) {
+	if am.passed(key) {
+		am.resolveNow(key)
+	}
+}
+
+// resolveNow resolves an active alert immediately
+func (am *AlertManager) resolveNow(key string) {
+	am.mu.Lock()
+	alert, exists := am.activeAlerts[key]
+	if !exists {
+		am.mu.Unlock()
+		return
+	}
+	alert.Resolved = true
+	alert.UpdatedAt = am.clock.Now()
+	delete(am.activeAlerts, key)
+	am.trimHistory()
+	event := AlertEvent{Type: EventResolved, Alert: *alert, Timestamp: alert.UpdatedAt}
+	am.mu.Unlock()
+
+	am.emit([]AlertEvent{event})
+}
+
+// ResolvePairAlerts resolves the active alerts of a pair with the given types,
+// e.g. of checks that no longer run for it, or all of them without types
+func (am *AlertManager) ResolvePairAlerts(pairName string, types ...string) {
+	am.mu.RLock()
+	var keys []string
+	for key, alert := range am.activeAlerts {
+		if alert.DatabasePair == pairName && (len(types) == 0 || slices.Contains(types, alert.Type)) {
+			keys = append(keys, key)
+		}
+	}
+	am.mu.RUnlock()
+
+	for _, key := range keys {
+		am.resolveNow(key)
+	}
+}
+
+// Acknowledge marks an active alert as taken care of by someone, which stops
+// its re-notification until its severity changes
+func (am *AlertManager) Acknowledge(id, by string) (Alert, error) {
+	am.mu.Lock()
+	var acknowledged *Alert
+	for _, alert := range am.activeAlerts {
+		if alert.ID == id {
+			acknowledged = alert
+			break
+		}
+	}
+	if acknowledged == nil {
+		am.mu.Unlock()
+		return Alert{}, fmt.Errorf("active alert '%s' not found", id)
+	}
+	if acknowledged.Acknowledged {
+		alert := *acknowledged
+		am.mu.Unlock()
+		return alert, fmt.Errorf("alert '%s' was already acknowledged by %s", id, alert.AcknowledgedBy)
+	}
+	acknowledged.Acknowledged = true
+	acknowledged.AcknowledgedBy = by
+	acknowledged.AcknowledgedAt = am.clock.Now()
+	alert := *acknowledged
+	am.mu.Unlock()
+
+	am.emit([]AlertEvent{{Type: EventAcknowledged, Alert: alert, Timestamp: alert.AcknowledgedAt}})
+	return alert, nil
+}
+
+// RaiseExternal raises or updates an alert ingested from another system.
+// The other system has already applied its own hysteresis.
+func (am *AlertManager) RaiseExternal(key string, alert Alert) {
+	am.raise("external_"+key, alert)
+}
+
+// ResolveExternal resolves an alert ingested from another system
+func (am *AlertManager) ResolveExternal(key string) {
+	am.resolveNow("external_" + key)
 }
 
 // GetActiveAlerts returns all active alerts
@@ -208,11 +1101,15 @@ func (am *AlertManager) GetAlertHistory() []Alert {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	// Return last 100 alerts
+	// Return the most recent alerts
 	start := 0
-	if len(am.alerts) > 100 {
-		start = len(am.alerts) - 100
+	if limit := am.config.AlertHistory.APILimit; limit > 0 && len(am.alerts) > limit {
+		start = len(am.alerts) - limit
 	}
 
-	return am.alerts[start:]
+	history := make([]Alert, 0, len(am.alerts)-start)
+	for _, alert := range am.alerts[start:] {
+		history = append(history, *alert)
+	}
+	return history
 }
@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+func TestEvaluateConsistencyApproximate(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ConsistencyResult
+		want   string // type of the active alert, if any
+	}{
+		{
+			name:   "exact mismatch",
+			result: ConsistencyResult{SourceRowCount: 1000, TargetRowCount: 900},
+			want:   "consistency_mismatch",
+		},
+		{
+			name:   "approximate mismatch",
+			result: ConsistencyResult{SourceRowCount: 1000, TargetRowCount: 900, Approximate: true},
+		},
+		{
+			name:   "approximate count with a differing window",
+			result: ConsistencyResult{SourceRowCount: 1000, TargetRowCount: 900, Approximate: true, Window: "max id differs"},
+			want:   "consistency_mismatch",
+		},
+		{
+			name:   "approximate count that failed",
+			result: ConsistencyResult{Approximate: true, Error: errors.New("estimate failed")},
+			want:   "consistency_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Thresholds.RowCountDrift = config.CountThresholds{WarningAt: 10, CriticalAt: 1000}
+			am := NewAlertManager(cfg)
+
+			result := tt.result
+			result.TableName = "shop.orders"
+			am.EvaluateConsistency("primary", &result)
+
+			active := am.GetActiveAlerts()
+			switch {
+			case tt.want == "" && len(active) > 0:
+				t.Errorf("got %s alert %q, want none", active[0].Type, active[0].Message)
+			case tt.want != "" && len(active) != 1:
+				t.Errorf("got %d alerts, want one %s alert", len(active), tt.want)
+			case tt.want != "" && active[0].Type != tt.want:
+				t.Errorf("got %s alert, want %s", active[0].Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateConsistencyApproximateKeepsExactAlert(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Thresholds.RowCountDrift = config.CountThresholds{WarningAt: 10}
+	am := NewAlertManager(cfg)
+
+	am.EvaluateConsistency("primary", &ConsistencyResult{TableName: "shop.orders", SourceRowCount: 1000, TargetRowCount: 900})
+	// An estimate within tolerance does not resolve what the exact count found
+	am.EvaluateConsistency("primary", &ConsistencyResult{TableName: "shop.orders", SourceRowCount: 1000, TargetRowCount: 990, Consistent: true, Approximate: true})
+	if active := am.GetActiveAlerts(); len(active) != 1 {
+		t.Fatalf("got %d alerts, want the exact mismatch still active", len(active))
+	}
+
+	am.EvaluateConsistency("primary", &ConsistencyResult{TableName: "shop.orders", SourceRowCount: 1000, TargetRowCount: 1000, Consistent: true})
+	if active := am.GetActiveAlerts(); len(active) != 0 {
+		t.Fatalf("got %d alerts after an exact match, want none", len(active))
+	}
+}
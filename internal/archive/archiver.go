@@ -0,0 +1,338 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// maxPendingEvents bounds the alert events buffered between uploads
+const maxPendingEvents = 100000
+
+// lastUploadKey names the object, under the prefix, holding the time of the
+// last complete upload, so a run missed while the monitor was down is made
+// up at startup
+const lastUploadKey = "last-upload"
+
+// dataset is a series of results archived as one object per batch
+type dataset struct {
+	name    string
+	columns []string
+	// rows calls emit for each row with a timestamp in (from, to], oldest first
+	rows func(store *storage.MetricsStorage, from, to time.Time, emit func(row []string))
+}
+
+// datasets are the check results and operator actions archived from the
+// in-memory history
+var datasets = []dataset{
+	{
+		name:    "replica_lag",
+		columns: []string{"timestamp", "pair", "lag_seconds", "status", "io_running", "sql_running", "error"},
+		rows: func(store *storage.MetricsStorage, from, to time.Time, emit func([]string)) {
+			for _, m := range store.GetReplicaLagHistory(time.Since(from)) {
+				if inBatch(m.Timestamp, from, to) {
+					emit([]string{timestamp(m.Timestamp), m.DatabasePair, formatFloat(m.LagSeconds), m.Status, m.IORunning, m.SQLRunning, errorText(m.Error)})
+				}
+			}
+		},
+	},
+	{
+		name:    "checksum",
+		columns: []string{"timestamp", "pair", "table", "target_table", "source_checksum", "target_checksum", "match", "skipped", "error"},
+		rows: func(store *storage.MetricsStorage, from, to time.Time, emit func([]string)) {
+			for _, c := range store.GetChecksumHistory(time.Since(from)) {
+				if inBatch(c.Timestamp, from, to) {
+					emit([]string{timestamp(c.Timestamp), c.DatabasePair, c.TableName, c.TargetTable, c.SourceChecksum, c.TargetChecksum,
+						strconv.FormatBool(c.Match), strconv.FormatBool(c.Skipped), errorText(c.Error)})
+				}
+			}
+		},
+	},
+	{
+		name:    "consistency",
+		columns: []string{"timestamp", "pair", "table", "target_table", "source_rows", "target_rows", "consistent", "approximate", "tolerance_percent", "backfill", "error"},
+		rows: func(store *storage.MetricsStorage, from, to time.Time, emit func([]string)) {
+			for _, c := range store.GetConsistencyHistory(time.Since(from)) {
+				if inBatch(c.Timestamp, from, to) {
+					emit([]string{timestamp(c.Timestamp), c.DatabasePair, c.TableName, c.TargetTable,
+						strconv.FormatInt(c.SourceRowCount, 10), strconv.FormatInt(c.TargetRowCount, 10),
+						strconv.FormatBool(c.Consistent), strconv.FormatBool(c.Approximate), formatFloat(c.Tolerance), c.Backfill, errorText(c.Error)})
+				}
+			}
+		},
+	},
+	{
+		name:    "health_score",
+		columns: []string{"timestamp", "pair", "score"},
+		rows: func(store *storage.MetricsStorage, from, to time.Time, emit func([]string)) {
+			for _, h := range store.GetHealthScoreHistory(time.Since(from)) {
+				if inBatch(h.Timestamp, from, to) {
+					emit([]string{timestamp(h.Timestamp), h.DatabasePair, formatFloat(h.Score)})
+				}
+			}
+		},
+	},
+	{
+		name:    "audit",
+		columns: []string{"timestamp", "actor", "role", "client_ip", "action", "pair", "target", "reason", "details"},
+		rows: func(store *storage.MetricsStorage, from, to time.Time, emit func([]string)) {
+			for _, e := range store.GetAuditLog(time.Since(from)) {
+				if inBatch(e.Timestamp, from, to) {
+					emit([]string{timestamp(e.Timestamp), e.Actor, e.Role, e.ClientIP, e.Action, e.DatabasePair, e.Target, e.Reason, e.Details})
+				}
+			}
+		},
+	},
+}
+
+// alertEventColumns are the columns of the alert_events dataset
+var alertEventColumns = []string{"timestamp", "event", "alert_id", "pair", "table", "type", "severity", "message", "source", "acknowledged_by", "suppressed_by"}
+
+// Archiver uploads batches of results and alert events to S3 on a schedule.
+// Each dataset keeps its own watermark, so a failed upload is retried with
+// the next batch without duplicating the datasets that succeeded.
+type Archiver struct {
+	config   *config.Config
+	client   *s3Client
+	storage  *storage.MetricsStorage
+	clock    clock.Clock
+	settle   time.Duration // how long after its timestamp a result may still be stored
+	stopChan chan struct{}
+	done     chan struct{}
+
+	mu         sync.Mutex
+	events     []alert.AlertEvent   // alert events not yet uploaded
+	watermarks map[string]time.Time // dataset name to the end of its last uploaded batch
+}
+
+// NewArchiver creates an archiver using the default AWS credential chain and
+// starts recording alert events
+func NewArchiver(cfg *config.Config, store *storage.MetricsStorage, alertMgr *alert.AlertManager) (*Archiver, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Archive.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Archive.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	a := &Archiver{
+		config:     cfg,
+		client:     newS3Client(awsCfg, cfg.Archive.Region, cfg.Archive.Endpoint, cfg.Archive.Timeout),
+		storage:    store,
+		clock:      clock.Real,
+		settle:     max(cfg.Timeouts.ReplicaLag, cfg.Timeouts.Checksum, cfg.Timeouts.Consistency),
+		stopChan:   make(chan struct{}),
+		done:       make(chan struct{}),
+		watermarks: make(map[string]time.Time),
+	}
+	alertMgr.AddListener(a.recordEvent)
+	return a, nil
+}
+
+// SetClock sets the clock the schedule runs on. It must be called before
+// Start.
+func (a *Archiver) SetClock(c clock.Clock) {
+	a.clock = c
+}
+
+// Start starts archiving in the background
+func (a *Archiver) Start() {
+	log.Printf("Starting S3 archive to s3://%s/%s (schedule: %s)", a.config.Archive.Bucket, a.config.Archive.Prefix, a.config.Archive.Schedule)
+	go a.archiveLoop()
+}
+
+// Stop stops the schedule and uploads everything not archived yet, since
+// no more results are stored once the monitor stops
+func (a *Archiver) Stop() {
+	close(a.stopChan)
+	<-a.done
+	now := a.clock.Now()
+	if a.archive(now) {
+		a.saveLastUpload(now)
+	}
+}
+
+// archiveLoop makes up a run missed since the last complete upload, then
+// uploads a batch whenever the schedule fires
+func (a *Archiver) archiveLoop() {
+	defer close(a.done)
+
+	if last, ok := a.loadLastUpload(); ok {
+		missed := a.config.Archive.Cron().Next(last)
+		if now := a.clock.Now(); !missed.IsZero() && !missed.After(now) {
+			log.Printf("Archive run of %s was missed, archiving now", missed.Format(time.RFC3339))
+			a.run(now)
+		}
+	}
+	clock.RunSchedule(a.clock, a.config.Archive.Cron(), a.stopChan, a.run)
+}
+
+// run uploads the batch of a scheduled run
+func (a *Archiver) run(at time.Time) {
+	// Results still being checked are stored with an earlier timestamp;
+	// leave them for the next batch
+	if a.archive(at.Add(-a.settle)) {
+		a.saveLastUpload(at)
+	}
+}
+
+// loadLastUpload reads the time of the last complete upload; ok is false
+// when nothing was uploaded yet or it cannot be read
+func (a *Archiver) loadLastUpload() (last time.Time, ok bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Archive.Timeout)
+	defer cancel()
+	body, found, err := a.client.getObject(ctx, a.config.Archive.Bucket, a.config.Archive.Prefix+lastUploadKey)
+	if err != nil {
+		log.Printf("Failed to read the last archive upload, not making up missed runs: %v", err)
+		return time.Time{}, false
+	}
+	if !found {
+		return time.Time{}, false
+	}
+	last, err = time.Parse(time.RFC3339, strings.TrimSpace(string(body)))
+	if err != nil {
+		log.Printf("Invalid last archive upload time '%s', not making up missed runs", body)
+		return time.Time{}, false
+	}
+	return last, true
+}
+
+// saveLastUpload records the time of a complete upload
+func (a *Archiver) saveLastUpload(at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Archive.Timeout)
+	defer cancel()
+	body := []byte(at.UTC().Format(time.RFC3339) + "\n")
+	if err := a.client.putObject(ctx, a.config.Archive.Bucket, a.config.Archive.Prefix+lastUploadKey, "text/plain; charset=utf-8", "", body); err != nil {
+		log.Printf("Failed to record the last archive upload: %v", err)
+	}
+}
+
+// recordEvent buffers an alert event until the next upload
+func (a *Archiver) recordEvent(event alert.AlertEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.events) >= maxPendingEvents {
+		a.events = a.events[1:]
+	}
+	a.events = append(a.events, event)
+}
+
+// archive uploads every dataset's results up to a point in time, and the
+// buffered alert events, reporting whether every upload succeeded
+func (a *Archiver) archive(to time.Time) bool {
+	complete := true
+	oldest := a.clock.Now().Add(-a.storage.HistoryDuration())
+	for _, ds := range datasets {
+		a.mu.Lock()
+		from := a.watermarks[ds.name]
+		a.mu.Unlock()
+		if from.Before(oldest) {
+			from = oldest
+		}
+		if !from.Before(to) {
+			continue
+		}
+
+		var rows [][]string
+		ds.rows(a.storage, from, to, func(row []string) { rows = append(rows, row) })
+		if err := a.upload(ds.name, ds.columns, rows, from, to); err != nil {
+			log.Printf("Failed to archive %s: %v", ds.name, err)
+			complete = false
+			continue
+		}
+		a.mu.Lock()
+		a.watermarks[ds.name] = to
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	events := a.events
+	a.mu.Unlock()
+	if len(events) == 0 {
+		return complete
+	}
+	rows := make([][]string, len(events))
+	for i, e := range events {
+		rows[i] = []string{timestamp(e.Timestamp), e.Type, e.Alert.ID, e.Alert.DatabasePair, e.Alert.TableName, e.Alert.Type,
+			e.Alert.Severity, e.Alert.Message, e.Alert.Source, e.Alert.AcknowledgedBy, e.Alert.SuppressedBy}
+	}
+	if err := a.upload("alert_events", alertEventColumns, rows, events[0].Timestamp, events[len(events)-1].Timestamp); err != nil {
+		log.Printf("Failed to archive alert events: %v", err)
+		return false
+	}
+	a.mu.Lock()
+	a.events = a.events[min(len(events), len(a.events)):]
+	a.mu.Unlock()
+	return complete
+}
+
+// upload writes rows as a gzip-compressed CSV object, keyed by dataset, day
+// and batch range, e.g. prefix/checksum/2024/05/01/checksum-20240501T100000Z-20240501T110000Z.csv.gz.
+// Empty batches are skipped.
+func (a *Archiver) upload(name string, columns []string, rows [][]string, from, to time.Time) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	cw := csv.NewWriter(zw)
+	cw.Write(columns)
+	cw.WriteAll(rows)
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	const compact = "20060102T150405Z"
+	key := fmt.Sprintf("%s%s/%s/%s-%s-%s.csv.gz", a.config.Archive.Prefix, name, to.UTC().Format("2006/01/02"),
+		name, from.UTC().Format(compact), to.UTC().Format(compact))
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Archive.Timeout)
+	defer cancel()
+	if err := a.client.putObject(ctx, a.config.Archive.Bucket, key, "text/csv; charset=utf-8", "gzip", buf.Bytes()); err != nil {
+		return err
+	}
+	log.Printf("Archived %d %s row(s) to s3://%s/%s", len(rows), name, a.config.Archive.Bucket, key)
+	return nil
+}
+
+// inBatch reports whether a timestamp falls in the batch (from, to]
+func inBatch(ts, from, to time.Time) bool {
+	return ts.After(from) && !ts.After(to)
+}
+
+// timestamp formats a row timestamp
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatFloat formats a number without trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// errorText returns the message of an error, or "" for nil
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
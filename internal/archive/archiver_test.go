@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// fakeS3 keeps the last-upload marker and reports every time it is written
+type fakeS3 struct {
+	marker  string
+	written chan string
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/"+lastUploadKey) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if s.marker == "" {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, s.marker)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.marker = string(body)
+		s.written <- strings.TrimSpace(s.marker)
+	}
+}
+
+// waitForTimer waits until the archiver sleeps on the fake clock
+func waitForTimer(t *testing.T, c *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the archiver started no timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestArchiverMakesUpMissedRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		marker  string
+		catchUp bool
+	}{
+		{"run missed since the last upload", "2026-03-02T08:00:00Z", true},
+		{"no run missed", "2026-03-02T10:00:00Z", false},
+		{"nothing uploaded yet", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3 := &fakeS3{marker: tt.marker, written: make(chan string, 1)}
+			server := httptest.NewServer(s3)
+			defer server.Close()
+
+			t.Setenv("AWS_ACCESS_KEY_ID", "test")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			yaml := fmt.Sprintf(`
+monitoring_interval: 10s
+source_db: {host: source, port: 3306, username: monitor, password: secret, database: shop}
+target_db: {host: target, port: 3306, username: monitor, password: secret, database: shop}
+tables_to_monitor: [orders]
+archive:
+  enabled: true
+  bucket: audit
+  region: us-east-1
+  endpoint: %s
+  schedule: "0 * * * *"
+`, server.URL)
+			if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Date(2026, 3, 2, 10, 20, 0, 0, time.UTC)
+			fake := clock.NewFake(start)
+			a, err := NewArchiver(cfg, storage.NewMetricsStorage(), alert.NewAlertManager(cfg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.SetClock(fake)
+			a.Start()
+
+			if tt.catchUp {
+				if got := <-s3.written; got != start.Format(time.RFC3339) {
+					t.Errorf("startup run recorded at %s, want %s", got, start.Format(time.RFC3339))
+				}
+			}
+			waitForTimer(t, fake)
+			select {
+			case got := <-s3.written:
+				t.Fatalf("unexpected run recorded at %s", got)
+			default:
+			}
+
+			fake.Set(time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC))
+			if got, want := <-s3.written, "2026-03-02T11:00:00Z"; got != want {
+				t.Errorf("scheduled run recorded at %s, want %s", got, want)
+			}
+
+			waitForTimer(t, fake)
+			a.Stop()
+			<-s3.written
+		})
+	}
+}
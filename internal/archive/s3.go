@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// s3Client uploads and downloads objects through the signed S3 REST API
+type s3Client struct {
+	awsConfig aws.Config
+	client    *http.Client
+	signer    *v4.Signer
+	region    string
+	endpoint  string // S3-compatible endpoint, addressed path-style; "" for AWS
+}
+
+// newS3Client creates a new S3 client
+func newS3Client(awsConfig aws.Config, region, endpoint string, timeout time.Duration) *s3Client {
+	if region == "" {
+		region = awsConfig.Region
+	}
+	return &s3Client{
+		awsConfig: awsConfig,
+		client:    &http.Client{Timeout: timeout},
+		signer:    v4.NewSigner(),
+		region:    region,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+// s3Error is the XML body of a failed S3 request
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// putObject uploads an object
+func (c *s3Client) putObject(ctx context.Context, bucket, key, contentType, contentEncoding string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(bucket, key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	resp, err := c.send(ctx, req, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// getObject downloads an object; found is false when it does not exist
+func (c *s3Client) getObject(ctx context.Context, bucket, key string) (body []byte, found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(bucket, key), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.send(ctx, req, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err = io.ReadAll(resp.Body)
+		return body, err == nil, err
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, responseError(resp)
+	}
+}
+
+// send signs and sends a request with its payload
+func (c *s3Client) send(ctx context.Context, req *http.Request, payload []byte) (*http.Response, error) {
+	if c.region == "" {
+		return nil, fmt.Errorf("no AWS region configured")
+	}
+
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := c.awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	if err := c.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", c.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+	return c.client.Do(req)
+}
+
+// responseError describes a failed request from its XML error body
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var apiErr s3Error
+	if xml.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+		return fmt.Errorf("%s: %s", apiErr.Code, apiErr.Message)
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// objectURL returns the URL of an object: virtual-hosted on AWS, path-style
+// on a custom endpoint
+func (c *s3Client) objectURL(bucket, key string) string {
+	path := (&url.URL{Path: "/" + key}).EscapedPath()
+	if c.endpoint != "" {
+		return c.endpoint + "/" + url.PathEscape(bucket) + path
+	}
+	domain := "amazonaws.com"
+	if strings.HasPrefix(c.region, "cn-") {
+		domain = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://%s.s3.%s.%s%s", bucket, c.region, domain, path)
+}
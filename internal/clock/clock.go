@@ -0,0 +1,231 @@
+// Package clock abstracts the passage of time so the monitoring engine, the
+// metrics storage and the alert manager can run against a fake clock. Paired
+// with a scripted SQL driver (see database.SetDriver), a simulation can
+// fast-forward weeks of a migration in seconds.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer fires once on its channel
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker fires on its channel at a fixed interval
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a clock that only moves when told to. Timers and tickers fire
+// when Advance or Set moves the clock past their deadline; like the time
+// package's, their channels hold one pending tick and drop the rest.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFake creates a fake clock set to a point in time
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer creates a timer firing once d has passed on the fake clock
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.addWaiter(d, 0)
+}
+
+// NewTicker creates a ticker firing every d on the fake clock
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.addWaiter(d, d)}
+}
+
+// Advance moves the clock forward, firing the timers and tickers due on the way
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to a point in time, firing the timers and tickers due
+// up to it in deadline order. Setting an earlier time fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool {
+			return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].deadline.After(t) {
+			break
+		}
+		w := f.waiters[0]
+		if w.deadline.After(f.now) {
+			f.now = w.deadline
+		}
+		w.fire(f.now)
+		if w.interval > 0 {
+			w.deadline = w.deadline.Add(w.interval)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+	}
+	if t.After(f.now) {
+		f.now = t
+	}
+}
+
+// Waiters returns the number of pending timers and tickers, so a simulation
+// can wait for the code under test to block before advancing the clock
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) addWaiter(d, interval time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{
+		clock:    f,
+		ch:       make(chan time.Time, 1),
+		deadline: f.now.Add(d),
+		interval: interval,
+	}
+	if d <= 0 {
+		w.fire(f.now)
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	return w
+}
+
+// remove drops a waiter, reporting whether it was pending
+func (f *Fake) remove(w *fakeWaiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeWaiter is a timer, or a ticker when interval is set
+type fakeWaiter struct {
+	clock    *Fake
+	ch       chan time.Time
+	deadline time.Time
+	interval time.Duration
+}
+
+func (w *fakeWaiter) fire(now time.Time) {
+	select {
+	case w.ch <- now:
+	default: // the previous tick was not received yet
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.ch }
+
+func (w *fakeWaiter) Stop() bool { return w.clock.remove(w) }
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.Stop() }
+
+// Schedule tells when a recurring job runs next
+type Schedule interface {
+	// Next returns the first run after a point in time, or the zero time
+	// when the job never runs again
+	Next(after time.Time) time.Time
+}
+
+// RunSchedule calls fire with the time of every run of a schedule, sleeping
+// on the clock until it is due, until stop is closed. A run that falls while
+// fire is still busy with the previous one is skipped.
+func RunSchedule(c Clock, s Schedule, stop <-chan struct{}, fire func(at time.Time)) {
+	var last time.Time
+	for {
+		now := c.Now()
+		// A timer may fire a little before the wall clock reaches its run
+		next := s.Next(maxTime(now, last))
+		if next.IsZero() {
+			<-stop
+			return
+		}
+
+		timer := c.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C():
+			last = next
+			fire(next)
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
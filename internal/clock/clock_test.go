@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// everyHour runs on the hour
+type everyHour struct{}
+
+func (everyHour) Next(after time.Time) time.Time {
+	return after.Truncate(time.Hour).Add(time.Hour)
+}
+
+// waitForTimer waits until the code under test sleeps on the fake clock
+func waitForTimer(t *testing.T, c *Fake) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no timer was started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunSchedule(t *testing.T) {
+	start := time.Date(2026, 3, 2, 8, 20, 0, 0, time.UTC)
+	c := NewFake(start)
+	fired := make(chan time.Time)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunSchedule(c, everyHour{}, stop, func(at time.Time) { fired <- at })
+	}()
+
+	for _, want := range []time.Time{start.Add(40 * time.Minute), start.Add(100 * time.Minute)} {
+		waitForTimer(t, c)
+		c.Advance(time.Minute)
+		select {
+		case at := <-fired:
+			t.Fatalf("fired at %s before the run was due", at)
+		default:
+		}
+
+		c.Set(want)
+		if at := <-fired; !at.Equal(want) {
+			t.Errorf("fired at %s, want %s", at, want)
+		}
+	}
+
+	waitForTimer(t, c)
+	close(stop)
+	<-done
+	if n := c.Waiters(); n != 0 {
+		t.Errorf("%d timer(s) left after stopping", n)
+	}
+}
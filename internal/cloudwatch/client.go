@@ -0,0 +1,173 @@
+package cloudwatch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// rdsMetrics are the AWS/RDS metrics fetched for each instance, keyed by query ID
+var rdsMetrics = []struct {
+	ID   string
+	Name string
+}{
+	{"replicalag", "ReplicaLag"},
+	{"cpu", "CPUUtilization"},
+	{"freestorage", "FreeStorageSpace"},
+	{"binlogusage", "BinLogDiskUsage"},
+}
+
+// InstanceMetrics holds the latest datapoint of each metric for an RDS instance.
+// A nil value means CloudWatch returned no datapoint in the lookback window.
+type InstanceMetrics struct {
+	ReplicaLagSeconds     *float64
+	CPUUtilization        *float64
+	FreeStorageSpaceBytes *float64
+	BinLogDiskUsageBytes  *float64
+}
+
+// Client queries CloudWatch through the signed Query API
+type Client struct {
+	awsConfig aws.Config
+	client    *http.Client
+	signer    *v4.Signer
+	lookback  time.Duration
+}
+
+// NewClient creates a new CloudWatch client
+func NewClient(awsConfig aws.Config, timeout time.Duration) *Client {
+	return &Client{
+		awsConfig: awsConfig,
+		client:    &http.Client{Timeout: timeout},
+		signer:    v4.NewSigner(),
+		lookback:  10 * time.Minute,
+	}
+}
+
+// getMetricDataResponse is the part of the GetMetricData XML response we use
+type getMetricDataResponse struct {
+	Results []struct {
+		ID     string    `xml:"Id"`
+		Values []float64 `xml:"Values>member"`
+	} `xml:"GetMetricDataResult>MetricDataResults>member"`
+}
+
+// errorResponse is the XML body of a failed Query API request
+type errorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// GetRDSMetrics fetches the latest RDS metrics of an instance in one GetMetricData call
+func (c *Client) GetRDSMetrics(ctx context.Context, region, instanceID string) (*InstanceMetrics, error) {
+	if region == "" {
+		region = c.awsConfig.Region
+	}
+	if region == "" {
+		return nil, fmt.Errorf("no AWS region configured")
+	}
+
+	now := time.Now().UTC()
+	form := url.Values{}
+	form.Set("Action", "GetMetricData")
+	form.Set("Version", "2010-08-01")
+	form.Set("StartTime", now.Add(-c.lookback).Format(time.RFC3339))
+	form.Set("EndTime", now.Format(time.RFC3339))
+	form.Set("ScanBy", "TimestampDescending")
+	for i, metric := range rdsMetrics {
+		prefix := fmt.Sprintf("MetricDataQueries.member.%d.", i+1)
+		form.Set(prefix+"Id", metric.ID)
+		form.Set(prefix+"MetricStat.Metric.Namespace", "AWS/RDS")
+		form.Set(prefix+"MetricStat.Metric.MetricName", metric.Name)
+		form.Set(prefix+"MetricStat.Metric.Dimensions.member.1.Name", "DBInstanceIdentifier")
+		form.Set(prefix+"MetricStat.Metric.Dimensions.member.1.Value", instanceID)
+		form.Set(prefix+"MetricStat.Period", "60")
+		form.Set(prefix+"MetricStat.Stat", "Average")
+	}
+
+	body, err := c.do(ctx, region, form.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var resp getMetricDataResponse
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode GetMetricData response: %w", err)
+	}
+
+	metrics := &InstanceMetrics{}
+	for _, result := range resp.Results {
+		if len(result.Values) == 0 {
+			continue
+		}
+		latest := result.Values[0] // TimestampDescending
+		switch result.ID {
+		case "replicalag":
+			metrics.ReplicaLagSeconds = &latest
+		case "cpu":
+			metrics.CPUUtilization = &latest
+		case "freestorage":
+			metrics.FreeStorageSpaceBytes = &latest
+		case "binlogusage":
+			metrics.BinLogDiskUsageBytes = &latest
+		}
+	}
+	return metrics, nil
+}
+
+// do sends a SigV4-signed Query API request and returns the response body
+func (c *Client) do(ctx context.Context, region, payload string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint(region), strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.Header.Set("Content-Length", strconv.Itoa(len(payload)))
+
+	creds, err := c.awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256([]byte(payload))
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "monitoring", region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr errorResponse
+		if xml.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+			return nil, fmt.Errorf("%s: %s", apiErr.Code, apiErr.Message)
+		}
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return body, nil
+}
+
+// endpoint returns the CloudWatch endpoint of a region
+func endpoint(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "https://monitoring." + region + ".amazonaws.com.cn/"
+	}
+	return "https://monitoring." + region + ".amazonaws.com/"
+}
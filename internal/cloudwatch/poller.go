@@ -0,0 +1,114 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// Poller periodically pulls CloudWatch metrics for every pair with RDS instances
+type Poller struct {
+	config   *config.Config
+	client   *Client
+	storage  *storage.MetricsStorage
+	stopChan chan struct{}
+}
+
+// NewPoller creates a new CloudWatch poller using the default AWS credential chain
+func NewPoller(cfg *config.Config, store *storage.MetricsStorage) (*Poller, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.AWS.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.AWS.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &Poller{
+		config:   cfg,
+		client:   NewClient(awsCfg, cfg.AWS.Timeout),
+		storage:  store,
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// Start starts polling CloudWatch in the background
+func (p *Poller) Start() {
+	log.Printf("Starting CloudWatch enrichment (poll interval: %v)", p.config.AWS.PollInterval)
+	go p.pollLoop()
+}
+
+// Stop stops polling CloudWatch
+func (p *Poller) Stop() {
+	close(p.stopChan)
+}
+
+// pollLoop polls all pairs at the configured interval
+func (p *Poller) pollLoop() {
+	ticker := time.NewTicker(p.config.AWS.PollInterval)
+	defer ticker.Stop()
+
+	p.pollAll()
+	for {
+		select {
+		case <-ticker.C:
+			p.pollAll()
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// pollAll polls every pair with RDS instances concurrently
+func (p *Poller) pollAll() {
+	var wg sync.WaitGroup
+	for _, pair := range p.config.Pairs() {
+		if !pair.RDS.Enabled() {
+			continue
+		}
+		wg.Add(1)
+		go func(pair config.DatabasePair) {
+			defer wg.Done()
+			metric := &storage.RDSMetric{
+				DatabasePair: pair.Name,
+				Timestamp:    time.Now(),
+			}
+			if pair.RDS.SourceInstanceID != "" {
+				metric.Source = p.pollInstance(pair.Name, pair.RDS.Region, pair.RDS.SourceInstanceID)
+			}
+			if pair.RDS.TargetInstanceID != "" {
+				metric.Target = p.pollInstance(pair.Name, pair.RDS.Region, pair.RDS.TargetInstanceID)
+			}
+			p.storage.StoreRDSMetric(metric)
+		}(pair)
+	}
+	wg.Wait()
+}
+
+// pollInstance fetches the metrics of one RDS instance
+func (p *Poller) pollInstance(pairName, region, instanceID string) *storage.RDSInstanceMetrics {
+	result := &storage.RDSInstanceMetrics{InstanceID: instanceID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.AWS.Timeout)
+	defer cancel()
+
+	metrics, err := p.client.GetRDSMetrics(ctx, region, instanceID)
+	if err != nil {
+		log.Printf("[%s] CloudWatch error for RDS instance '%s': %v", pairName, instanceID, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ReplicaLagSeconds = metrics.ReplicaLagSeconds
+	result.CPUUtilization = metrics.CPUUtilization
+	result.FreeStorageSpaceBytes = metrics.FreeStorageSpaceBytes
+	result.BinLogDiskUsageBytes = metrics.BinLogDiskUsageBytes
+	return result
+}
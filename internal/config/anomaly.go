@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LagAnomalyConfig alerts when replica lag deviates from its recent baseline,
+// even while it stays below the replica_lag thresholds. The baseline is an
+// exponentially weighted moving average (EWMA) of the lag and its variance;
+// a sample is anomalous when its z-score, the deviation from the average in
+// standard deviations, reaches a tier.
+type LagAnomalyConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Alpha         float64       `yaml:"alpha"`           // weight of the newest sample, defaults to 0.02
+	MinSamples    int           `yaml:"min_samples"`     // samples before the baseline is trusted, defaults to 30
+	InfoZScore    float64       `yaml:"info_z_score"`    // defaults to 3
+	WarningZScore float64       `yaml:"warning_z_score"` // defaults to 5
+	MinDeviation  time.Duration `yaml:"min_deviation"`   // smaller deviations are never anomalous, defaults to 5s
+	RelearnAfter  int           `yaml:"relearn_after"`   // anomalous samples in a row before the lag becomes the new baseline, defaults to 120
+}
+
+// validate checks the anomaly detection settings and applies defaults
+func (a *LagAnomalyConfig) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if a.Alpha == 0 {
+		a.Alpha = 0.02
+	}
+	if a.Alpha < 0 || a.Alpha > 1 {
+		return fmt.Errorf("alpha must be between 0 and 1")
+	}
+	if a.MinSamples < 0 {
+		return fmt.Errorf("min_samples cannot be negative")
+	}
+	if a.MinSamples == 0 {
+		a.MinSamples = 30
+	}
+	if a.RelearnAfter < 0 {
+		return fmt.Errorf("relearn_after cannot be negative")
+	}
+	if a.RelearnAfter == 0 {
+		a.RelearnAfter = 120
+	}
+	if a.InfoZScore < 0 || a.WarningZScore < 0 || a.MinDeviation < 0 {
+		return fmt.Errorf("info_z_score, warning_z_score and min_deviation cannot be negative")
+	}
+	if a.InfoZScore == 0 {
+		a.InfoZScore = 3
+	}
+	if a.WarningZScore == 0 {
+		a.WarningZScore = 5
+	}
+	if a.WarningZScore < a.InfoZScore {
+		return fmt.Errorf("warning_z_score must not be lower than info_z_score")
+	}
+	if a.MinDeviation == 0 {
+		a.MinDeviation = 5 * time.Second
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ArchiveConfig uploads check results and alert events to S3 on a schedule,
+// as gzip-compressed CSV batches: a permanent audit trail beyond the retention
+// of the in-memory history. Credentials come from the default AWS chain.
+type ArchiveConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Bucket   string        `yaml:"bucket"`
+	Prefix   string        `yaml:"prefix"`   // key prefix, e.g. "audit/mariadb-monitor/"
+	Region   string        `yaml:"region"`   // defaults to aws.region, then the AWS environment
+	Endpoint string        `yaml:"endpoint"` // S3-compatible endpoint, addressed path-style; defaults to AWS
+	Schedule string        `yaml:"schedule"` // cron expression; defaults to hourly
+	Timezone string        `yaml:"timezone"` // IANA name for the schedule; defaults to UTC
+	Format   string        `yaml:"format"`   // "csv", the only format
+	Timeout  time.Duration `yaml:"timeout"`  // per upload; defaults to 1m
+
+	cron *Schedule
+}
+
+// validate checks the archive settings, applies defaults and parses the schedule
+func (a *ArchiveConfig) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if a.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if a.Prefix != "" && !strings.HasSuffix(a.Prefix, "/") {
+		a.Prefix += "/"
+	}
+	a.Prefix = strings.TrimPrefix(a.Prefix, "/")
+	if a.Endpoint != "" && !strings.HasPrefix(a.Endpoint, "http://") && !strings.HasPrefix(a.Endpoint, "https://") {
+		return fmt.Errorf("endpoint must be an http:// or https:// URL")
+	}
+
+	switch a.Format {
+	case "":
+		a.Format = "csv"
+	case "csv":
+	case "parquet":
+		return fmt.Errorf("format 'parquet' is not supported; use 'csv'")
+	default:
+		return fmt.Errorf("format must be 'csv'")
+	}
+
+	if a.Schedule == "" {
+		a.Schedule = "0 * * * *"
+	}
+	location, err := time.LoadLocation(a.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	if a.cron, err = parseSchedule(a.Schedule, location); err != nil {
+		return err
+	}
+
+	if a.Timeout == 0 {
+		a.Timeout = time.Minute
+	}
+	if a.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	return nil
+}
+
+// Cron returns the parsed schedule; nil when archiving is disabled
+func (a *ArchiveConfig) Cron() *Schedule {
+	return a.cron
+}
@@ -0,0 +1,57 @@
+package config
+
+import "fmt"
+
+// HopConfig is an intermediate instance of a chained replication topology
+// (source -> intermediate -> ... -> target). Each intermediate replicates from
+// the instance before it, and the target from the last intermediate.
+type HopConfig struct {
+	Name string         `yaml:"name"`
+	DB   DatabaseConfig `yaml:"db"` // unset fields default to the source_db of the pair
+}
+
+// Databases returns the source, the intermediates in chain order and the
+// target of a pair, followed by the check endpoints and the warm-up
+// connection that are set
+func (p *DatabasePair) Databases() []*DatabaseConfig {
+	dbs := []*DatabaseConfig{&p.SourceDB}
+	for i := range p.Intermediates {
+		dbs = append(dbs, &p.Intermediates[i].DB)
+	}
+	dbs = append(dbs, &p.TargetDB)
+	if p.CheckEndpoints.HasSource() {
+		dbs = append(dbs, &p.CheckEndpoints.Source)
+	}
+	if p.CheckEndpoints.HasTarget() {
+		dbs = append(dbs, &p.CheckEndpoints.Target)
+	}
+	if p.Warmup.Enabled && p.Warmup.HasDB() {
+		dbs = append(dbs, &p.Warmup.DB)
+	}
+	return dbs
+}
+
+// validateIntermediates checks the replication chain of a pair and fills in
+// unset intermediate database settings from the source
+func (p *DatabasePair) validateIntermediates() error {
+	names := make(map[string]bool, len(p.Intermediates))
+	for i := range p.Intermediates {
+		hop := &p.Intermediates[i]
+		if hop.Name == "" {
+			return fmt.Errorf("intermediate %d: name is required", i)
+		}
+		if hop.Name == "source" || hop.Name == "target" || names[hop.Name] {
+			return fmt.Errorf("intermediate '%s': name must be unique and not 'source' or 'target'", hop.Name)
+		}
+		names[hop.Name] = true
+
+		if !hop.DB.hasEndpoint() {
+			return fmt.Errorf("intermediate '%s': db.host, db.socket or db.dsn is required", hop.Name)
+		}
+		hop.DB = hop.DB.inherit(p.SourceDB)
+		if err := hop.DB.validate(); err != nil {
+			return fmt.Errorf("intermediate '%s': %w", hop.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AlertRoute selects the alerts a chat notifier delivers, so that teams on
+// different platforms each receive the pairs they own
+type AlertRoute struct {
+	MinSeverity string   `yaml:"min_severity"` // "WARNING" (default) or "CRITICAL"
+	Pairs       []string `yaml:"pairs"`        // empty means every pair
+}
+
+// Matches reports whether events of an alert with a severity on a pair are
+// delivered. Severities other than WARNING and CRITICAL, e.g. INFO of
+// ingested alerts, rank below WARNING.
+func (r AlertRoute) Matches(pairName, severity string) bool {
+	if len(r.Pairs) > 0 && !slices.Contains(r.Pairs, pairName) {
+		return false
+	}
+	switch r.MinSeverity {
+	case "CRITICAL":
+		return severity == "CRITICAL"
+	default:
+		return severity == "CRITICAL" || severity == "WARNING"
+	}
+}
+
+// validate applies the default severity and checks the listed pairs exist
+func (r *AlertRoute) validate(pairs []DatabasePair) error {
+	switch r.MinSeverity {
+	case "":
+		r.MinSeverity = "WARNING"
+	case "WARNING", "CRITICAL":
+	default:
+		return fmt.Errorf("min_severity must be 'WARNING' or 'CRITICAL'")
+	}
+	for _, name := range r.Pairs {
+		if !slices.ContainsFunc(pairs, func(p DatabasePair) bool { return p.Name == name }) {
+			return fmt.Errorf("unknown database pair '%s' in pairs", name)
+		}
+	}
+	return nil
+}
+
+// TelegramConfig sends alert events as messages from a Telegram bot to a chat
+type TelegramConfig struct {
+	Name       string        `yaml:"name"`
+	BotToken   string        `yaml:"bot_token"`
+	ChatID     string        `yaml:"chat_id"` // numeric chat ID, or @username of a channel
+	APIURL     string        `yaml:"api_url"` // defaults to https://api.telegram.org
+	Timeout    time.Duration `yaml:"timeout"`
+	AlertRoute `yaml:",inline"`
+}
+
+// validate checks Telegram settings and applies defaults
+func (t *TelegramConfig) validate(pairs []DatabasePair) error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if t.BotToken == "" || t.ChatID == "" {
+		return fmt.Errorf("telegram '%s': bot_token and chat_id are required", t.Name)
+	}
+	if t.APIURL == "" {
+		t.APIURL = "https://api.telegram.org"
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("telegram '%s': timeout cannot be negative", t.Name)
+	}
+	if t.Timeout == 0 {
+		t.Timeout = 10 * time.Second
+	}
+	if err := t.AlertRoute.validate(pairs); err != nil {
+		return fmt.Errorf("telegram '%s': %w", t.Name, err)
+	}
+	return nil
+}
+
+// TeamsConfig posts alert events as Adaptive Cards to Microsoft Teams
+// incoming webhooks (Workflows "when a Teams webhook request is received")
+type TeamsConfig struct {
+	Name       string        `yaml:"name"`
+	URLs       []string      `yaml:"urls"`
+	Timeout    time.Duration `yaml:"timeout"`
+	AlertRoute `yaml:",inline"`
+}
+
+// validate checks Teams settings and applies defaults
+func (t *TeamsConfig) validate(pairs []DatabasePair) error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.URLs) == 0 {
+		return fmt.Errorf("teams '%s': at least one url is required", t.Name)
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("teams '%s': timeout cannot be negative", t.Name)
+	}
+	if t.Timeout == 0 {
+		t.Timeout = 10 * time.Second
+	}
+	if err := t.AlertRoute.validate(pairs); err != nil {
+		return fmt.Errorf("teams '%s': %w", t.Name, err)
+	}
+	return nil
+}
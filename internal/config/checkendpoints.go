@@ -0,0 +1,56 @@
+package config
+
+import "fmt"
+
+// CheckEndpointsConfig points the checksums, row counts and row diffs of a
+// pair at other instances than its source and target, e.g. a dedicated read
+// replica of the source, so their load never lands on the production
+// writer. Results are still attributed to the pair's source and target;
+// replica lag, clock skew and the other replication checks keep running
+// against them.
+type CheckEndpointsConfig struct {
+	Source DatabaseConfig `yaml:"source"` // unset fields default to the source_db of the pair
+	Target DatabaseConfig `yaml:"target"` // unset fields default to the target_db of the pair
+}
+
+// HasSource reports whether the source side of the checks has its own endpoint
+func (c *CheckEndpointsConfig) HasSource() bool {
+	return c.Source.hasEndpoint()
+}
+
+// HasTarget reports whether the target side of the checks has its own endpoint
+func (c *CheckEndpointsConfig) HasTarget() bool {
+	return c.Target.hasEndpoint()
+}
+
+// CheckDatabases returns the databases the checksums, row counts and row
+// diffs of a pair run against: its check endpoints where set, otherwise its
+// source and target
+func (p *DatabasePair) CheckDatabases() (source, target *DatabaseConfig) {
+	source, target = &p.SourceDB, &p.TargetDB
+	if p.CheckEndpoints.HasSource() {
+		source = &p.CheckEndpoints.Source
+	}
+	if p.CheckEndpoints.HasTarget() {
+		target = &p.CheckEndpoints.Target
+	}
+	return source, target
+}
+
+// validateCheckEndpoints fills in unset check endpoint settings from the
+// source and target of a pair
+func (p *DatabasePair) validateCheckEndpoints() error {
+	if p.CheckEndpoints.HasSource() {
+		p.CheckEndpoints.Source = p.CheckEndpoints.Source.inherit(p.SourceDB)
+		if err := p.CheckEndpoints.Source.validate(); err != nil {
+			return fmt.Errorf("check_endpoints.source: %w", err)
+		}
+	}
+	if p.CheckEndpoints.HasTarget() {
+		p.CheckEndpoints.Target = p.CheckEndpoints.Target.inherit(p.TargetDB)
+		if err := p.CheckEndpoints.Target.validate(); err != nil {
+			return fmt.Errorf("check_endpoints.target: %w", err)
+		}
+	}
+	return nil
+}
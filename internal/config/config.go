@@ -2,7 +2,14 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -15,156 +22,1731 @@ type DatabaseConfig struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	Database string `yaml:"database"`
+
+	// References to AWS Secrets Manager secrets or SSM parameters the host,
+	// username and password are read from, resolved at startup and refreshed
+	// on rotation. See ParseSecretRef for the accepted forms.
+	HostFrom     string `yaml:"host_from"`
+	UsernameFrom string `yaml:"username_from"`
+	PasswordFrom string `yaml:"password_from"`
+
+	// Driver options added to the connection DSN
+	Timeout           time.Duration     `yaml:"timeout"`            // dial timeout; defaults to timeouts.connect
+	ReadTimeout       time.Duration     `yaml:"read_timeout"`       // I/O read timeout
+	Charset           string            `yaml:"charset"`            // connection character set, e.g. utf8mb4
+	Collation         string            `yaml:"collation"`          // connection collation, e.g. utf8mb4_unicode_ci
+	Loc               string            `yaml:"loc"`                // time zone DATETIME values are read in, e.g. UTC or Local
+	TimeZone          string            `yaml:"time_zone"`          // session time_zone, e.g. +00:00 or SYSTEM
+	InterpolateParams bool              `yaml:"interpolate_params"` // interpolate placeholders client-side instead of preparing
+	Params            map[string]string `yaml:"params"`             // other DSN parameters, e.g. tls
+
+	// Instead of host and port: a unix socket path, or a complete driver DSN
+	// such as "user:pass@tcp(host:3306)/db?tls=true" that the driver
+	// options above are applied on top of
+	Socket string `yaml:"socket"`
+	DSN    string `yaml:"dsn"`
+
+	// The host is an Aurora cluster endpoint, "writer" or "reader". Pools
+	// are reopened when their connections reach an instance of the other
+	// role after a failover.
+	AuroraEndpoint string `yaml:"aurora_endpoint"`
+
+	ReadOnlySession bool             `yaml:"-"` // set for every database by read_only
+	SSHTunnel       *SSHTunnelConfig `yaml:"-"` // set for every database of a pair with ssh_tunnel
+}
+
+// validate checks the secret references and driver options of a database
+func (d *DatabaseConfig) validate() error {
+	for _, ref := range []struct{ field, value string }{
+		{"host_from", d.HostFrom}, {"username_from", d.UsernameFrom}, {"password_from", d.PasswordFrom},
+	} {
+		if ref.value == "" {
+			continue
+		}
+		if _, err := ParseSecretRef(ref.value); err != nil {
+			return fmt.Errorf("%s: %w", ref.field, err)
+		}
+	}
+	if err := d.validateEndpoint(); err != nil {
+		return err
+	}
+	if d.Timeout < 0 || d.ReadTimeout < 0 {
+		return fmt.Errorf("timeout and read_timeout cannot be negative")
+	}
+	if d.Loc != "" {
+		if _, err := time.LoadLocation(d.Loc); err != nil {
+			return fmt.Errorf("loc: %w", err)
+		}
+	}
+	if strings.ContainsAny(d.TimeZone, "'\\") {
+		return fmt.Errorf("time_zone: '%s' is not a valid time zone", d.TimeZone)
+	}
+	for name := range d.Params {
+		switch name {
+		case "":
+			return fmt.Errorf("params: parameter names cannot be empty")
+		case "parseTime", "timeout", "readTimeout", "charset", "collation", "loc", "time_zone", "interpolateParams":
+			return fmt.Errorf("params: set %s with its own option", name)
+		}
+	}
+	return nil
+}
+
+// DatabasePair represents a source-target database pair to monitor
+type DatabasePair struct {
+	Name            string         `yaml:"name"`
+	SourceDB        DatabaseConfig `yaml:"source_db"`
+	TargetDB        DatabaseConfig `yaml:"target_db"`
+	TablesToMonitor TableList      `yaml:"tables_to_monitor"`
+
+	// "replication" (default) or "dual_write", while the application writes
+	// to both databases and the target does not replicate from the source
+	Mode      string          `yaml:"mode"`
+	DualWrite DualWriteConfig `yaml:"dual_write"`
+
+	// Instances between source and target in a chained replication
+	// topology; their lag is measured per hop and summed
+	Intermediates []HopConfig `yaml:"intermediates"`
+
+	// Read endpoints the checksums, row counts and row diffs run against
+	// instead of the source and target
+	CheckEndpoints CheckEndpointsConfig `yaml:"check_endpoints"`
+
+	// SSH bastion every database of the pair is reached through, including
+	// intermediates and check endpoints
+	SSHTunnel SSHTunnelConfig `yaml:"ssh_tunnel"`
+
+	// Discover tables from information_schema on the source. Enabled by
+	// tables_to_monitor: "*", "schema.*" entries or include patterns.
+	TableDiscovery TableDiscoveryConfig `yaml:"table_discovery"`
+
+	// Target table names of source tables that were renamed on the target,
+	// e.g. orders: orders_encrypted. Unmapped tables keep their name.
+	TableMappings TableMappings `yaml:"table_mappings"`
+
+	ApproximateCounts ApproximateCountConfig `yaml:"approximate_counts"`
+
+	// Primary key and recent row comparisons of append-only tables
+	ConsistencyWindows ConsistencyWindowConfig `yaml:"consistency_windows"`
+
+	ChecksumPreflight ChecksumPreflightConfig `yaml:"checksum_preflight"`
+	ChecksumSchedule  ChecksumScheduleConfig  `yaml:"checksum_schedule"`
+
+	// Columns left out of the checksum and row diff of a table, e.g.
+	// orders: [updated_at] for a column set by a trigger on the target
+	// only. Tables with exclusions are hashed column by column instead of
+	// with CHECKSUM TABLE.
+	ChecksumExclusions ChecksumExclusions `yaml:"checksum_exclusions"`
+
+	// Monitored tables that are views. CHECKSUM TABLE does not read views,
+	// so they are hashed column by column, and they have no tablespace to
+	// track the encryption of.
+	Views TableList `yaml:"views"`
+
+	// Per-pair threshold overrides; a metric with both tiers at zero uses
+	// the global thresholds. Adjustable at runtime through the API.
+	Thresholds    ThresholdsConfig `yaml:"thresholds"`
+	CheckInterval time.Duration    `yaml:"check_interval"` // defaults to monitoring_interval
+
+	// RDS instances behind the pair, for CloudWatch enrichment
+	RDS RDSConfig `yaml:"rds"`
+
+	// Optional pre-cutover check that the target is warmed up
+	Warmup WarmupConfig `yaml:"warmup"`
+
+	// Alert when semi-synchronous replication from the source to the target
+	// falls back to asynchronous replication
+	SemiSync SemiSyncConfig `yaml:"semi_sync"`
+
+	// Alert when replica lag deviates from its recent baseline, before it
+	// reaches the replica_lag thresholds
+	LagAnomaly LagAnomalyConfig `yaml:"lag_anomaly"`
+
+	// Ingest the results of pt-table-checksum runs
+	PTChecksum PTChecksumConfig `yaml:"pt_checksum"`
+
+	// Track tablespace encryption progress of the monitored tables
+	EncryptionStatus EncryptionStatusConfig `yaml:"encryption_status"`
+
+	// Check the ENCRYPTION and ROW_FORMAT options of the monitored tables
+	TableDefinitions TableDefinitionsConfig `yaml:"table_definitions"`
+
+	// Checks keep running during maintenance windows, but new alerts are suppressed
+	MaintenanceWindows []MaintenanceWindow `yaml:"maintenance_windows"`
+
+	// Masks column values in row diffs, so data of the migrated tables is
+	// never shown unmasked by the monitor
+	Masking MaskingConfig `yaml:"masking"`
+
+	// The migration is done: only a heartbeat runs, at idle.heartbeat_interval.
+	// Pairs are also marked complete at runtime through the API.
+	MigrationComplete bool `yaml:"migration_complete"`
+
+	// Owner, runbook and ticket of the pair, shown with the pair and its alerts
+	Metadata PairMetadata `yaml:"metadata"`
+
+	// Read replicas of the target, each monitored as a pair from the target
+	FanOut FanOutConfig `yaml:"fan_out"`
+
+	// Set on pairs expanded from fan_out: the pair whose target is the source,
+	// and whether monitoring waits until that pair cuts over
+	FanOutOf       string `yaml:"-"`
+	WaitForCutOver bool   `yaml:"-"`
+}
+
+// WarmupConfig verifies that the target can serve production reads before
+// cutover: its InnoDB buffer pool hit rate and the plans of representative queries
+type WarmupConfig struct {
+	Enabled              bool          `yaml:"enabled"`
+	Interval             time.Duration `yaml:"interval"`                 // defaults to 5m
+	MinBufferPoolHitRate float64       `yaml:"min_buffer_pool_hit_rate"` // percent; defaults to 99
+	MaxStatementTime     time.Duration `yaml:"max_statement_time"`       // cap on each EXPLAIN; defaults to 5s
+	Queries              []WarmupQuery `yaml:"queries"`
+
+	// Connection of the checks to the target, e.g. as a dedicated user with
+	// only SELECT and PROCESS privileges; unset fields default to the
+	// target_db of the pair. Its sessions are always read-only.
+	DB DatabaseConfig `yaml:"db"`
+}
+
+// HasDB reports whether the warm-up checks connect with their own settings
+func (w *WarmupConfig) HasDB() bool {
+	return w.DB.hasEndpoint() || w.DB.Username != "" || w.DB.UsernameFrom != ""
+}
+
+// SemiSyncConfig monitors semi-synchronous replication between the source and
+// the target. Semi-sync silently degrades to asynchronous replication when the
+// target does not acknowledge commits within rpl_semi_sync_master_timeout,
+// which exposes the commits since to data loss on failover.
+type SemiSyncConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// WarmupQuery is a representative read query whose plan is checked on the
+// target. The query itself is never executed, only explained.
+type WarmupQuery struct {
+	Name    string   `yaml:"name"`
+	SQL     string   `yaml:"sql"`     // a single read-only SELECT ending in LIMIT
+	Indexes []string `yaml:"indexes"` // indexes the plan must use; when empty, no table may be fully scanned
+}
+
+// RDSConfig identifies the RDS instances of a database pair
+type RDSConfig struct {
+	Region           string `yaml:"region"` // defaults to aws.region
+	SourceInstanceID string `yaml:"source_instance_id"`
+	TargetInstanceID string `yaml:"target_instance_id"`
+}
+
+// Enabled reports whether any RDS instance is configured for the pair
+func (r RDSConfig) Enabled() bool {
+	return r.SourceInstanceID != "" || r.TargetInstanceID != ""
+}
+
+// TableList is a list of table names that may also be written as a single
+// scalar in YAML, e.g. tables_to_monitor: "*"
+type TableList []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of table names
+func (tl *TableList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*tl = TableList{value.Value}
+		return nil
+	}
+
+	var tables []string
+	if err := value.Decode(&tables); err != nil {
+		return err
+	}
+	*tl = tables
+	return nil
+}
+
+// TableDiscoveryConfig controls automatic enumeration of tables to monitor
+type TableDiscoveryConfig struct {
+	Include         []string      `yaml:"include"` // regular expressions; empty means all tables
+	Exclude         []string      `yaml:"exclude"` // regular expressions
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// DiscoveryEnabled reports whether tables are discovered rather than only listed
+func (p *DatabasePair) DiscoveryEnabled() bool {
+	return len(p.TableDiscovery.Include) > 0 || len(p.ExplicitTables()) != len(p.TablesToMonitor)
+}
+
+// ExplicitTables returns the configured table names excluding the "*" and
+// "schema.*" wildcards
+func (p *DatabasePair) ExplicitTables() []string {
+	tables := make([]string, 0, len(p.TablesToMonitor))
+	for _, table := range p.TablesToMonitor {
+		if _, wildcard := tableWildcard(table); !wildcard {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// DiscoverySchemas returns the schemas whose tables are discovered: "" for
+// the connection's default database and the schema of each "schema.*" entry
+func (p *DatabasePair) DiscoverySchemas() []string {
+	var schemas []string
+	for _, table := range p.TablesToMonitor {
+		if schema, wildcard := tableWildcard(table); wildcard && !slices.Contains(schemas, schema) {
+			schemas = append(schemas, schema)
+		}
+	}
+	if len(schemas) == 0 && len(p.TableDiscovery.Include) > 0 {
+		schemas = append(schemas, "")
+	}
+	return schemas
+}
+
+// SplitTable splits a "schema.table" name; the schema is empty for a table
+// in the connection's default database
+func SplitTable(name string) (schema, table string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return schema, table
+	}
+	return "", name
+}
+
+// tableWildcard reports whether a tables_to_monitor entry is "*" or
+// "schema.*" and returns its schema
+func tableWildcard(entry string) (schema string, ok bool) {
+	schema, table := SplitTable(entry)
+	return schema, table == "*"
+}
+
+// validateTableName rejects names that are not "table" or "schema.table"
+func validateTableName(name string) error {
+	schema, table := SplitTable(name)
+	if table == "" || strings.Contains(table, ".") || (strings.Contains(name, ".") && schema == "") {
+		return fmt.Errorf("'%s' is not a table or schema.table name", name)
+	}
+	return nil
+}
+
+// validateTables rejects malformed tables_to_monitor entries
+func (p *DatabasePair) validateTables() error {
+	for _, entry := range p.TablesToMonitor {
+		if _, wildcard := tableWildcard(entry); wildcard && !strings.HasPrefix(entry, ".") {
+			continue
+		}
+		if err := validateTableName(entry); err != nil {
+			return fmt.Errorf("tables_to_monitor: %w", err)
+		}
+	}
+	return nil
+}
+
+// TableMappings maps source table names to differently named target tables
+type TableMappings map[string]string
+
+// Target returns the name of a source table on the target
+func (m TableMappings) Target(sourceTable string) string {
+	if target, ok := m[sourceTable]; ok {
+		return target
+	}
+	return sourceTable
+}
+
+// validate rejects empty names and target tables mapped more than once
+func (m TableMappings) validate() error {
+	sources := make(map[string]string, len(m))
+	for _, source := range slices.Sorted(maps.Keys(m)) {
+		target := m[source]
+		if source == "" || target == "" {
+			return fmt.Errorf("source and target table names are required")
+		}
+		for _, name := range []string{source, target} {
+			if err := validateTableName(name); err != nil {
+				return err
+			}
+		}
+		if other, ok := sources[target]; ok {
+			return fmt.Errorf("target table '%s' is mapped from both '%s' and '%s'", target, other, source)
+		}
+		sources[target] = source
+	}
+	return nil
+}
+
+// ChecksumExclusions maps source table names to columns left out of their checksum
+type ChecksumExclusions map[string][]string
+
+// Excluded reports whether a column of a source table is left out
+func (e ChecksumExclusions) Excluded(table, column string) bool {
+	return slices.Contains(e[table], column)
+}
+
+// validate rejects empty table and column names
+func (e ChecksumExclusions) validate() error {
+	for _, table := range slices.Sorted(maps.Keys(e)) {
+		if table == "" {
+			return fmt.Errorf("table name is required")
+		}
+		if len(e[table]) == 0 {
+			return fmt.Errorf("table '%s': at least one column is required", table)
+		}
+		if slices.Contains(e[table], "") {
+			return fmt.Errorf("table '%s': column names cannot be empty", table)
+		}
+	}
+	return nil
+}
+
+// IsView reports whether a monitored table is a view
+func (p *DatabasePair) IsView(table string) bool {
+	return slices.Contains(p.Views, table)
+}
+
+// validateViews requires views to be listed in tables_to_monitor, since
+// table discovery only finds base tables
+func (p *DatabasePair) validateViews() error {
+	explicit := p.ExplicitTables()
+	for _, view := range p.Views {
+		if err := validateTableName(view); err != nil {
+			return err
+		}
+		if !slices.Contains(explicit, view) {
+			return fmt.Errorf("'%s' is not listed in tables_to_monitor", view)
+		}
+	}
+	return nil
+}
+
+// ChecksumPreflightConfig limits which tables may be fully scanned by CHECKSUM TABLE,
+// based on size estimates from information_schema
+type ChecksumPreflightConfig struct {
+	MaxRows       int64    `yaml:"max_rows"`
+	MaxBytes      int64    `yaml:"max_bytes"`
+	Action        string   `yaml:"action"`         // "warn" logs and continues, "skip" requires opt-in
+	AllowedTables []string `yaml:"allowed_tables"` // tables explicitly opted in to checksums regardless of size
+}
+
+// Enabled reports whether any size limit is configured
+func (p ChecksumPreflightConfig) Enabled() bool {
+	return p.MaxRows > 0 || p.MaxBytes > 0
+}
+
+// ChecksumScheduleConfig decides when checksums run. By default they run
+// every check interval; in "quiet_replication" mode only once replica lag has
+// stayed below max_lag for quiet_for, so they run while the target has caught
+// up and the replica is idle enough to scan cheaply.
+//
+// Either way a run can be staggered: it checksums up to tables_per_cycle
+// tables, and starts no further table once budget has been spent; the next
+// run continues with the following tables, round-robin.
+type ChecksumScheduleConfig struct {
+	Mode     string        `yaml:"mode"`      // "interval" or "quiet_replication"
+	MaxLag   time.Duration `yaml:"max_lag"`   // defaults to 5s
+	QuietFor time.Duration `yaml:"quiet_for"` // defaults to 10m
+
+	TablesPerCycle int           `yaml:"tables_per_cycle"` // 0 checksums every table
+	Budget         time.Duration `yaml:"budget"`           // checksum time per cycle; 0 is unlimited
+}
+
+// QuietReplication reports whether checksums wait for quiet replication
+func (s ChecksumScheduleConfig) QuietReplication() bool {
+	return s.Mode == "quiet_replication"
+}
+
+// Staggered reports whether a run may checksum only part of the tables
+func (s ChecksumScheduleConfig) Staggered() bool {
+	return s.TablesPerCycle > 0 || s.Budget > 0
+}
+
+// validate checks the checksum schedule and applies defaults
+func (s *ChecksumScheduleConfig) validate() error {
+	switch s.Mode {
+	case "":
+		s.Mode = "interval"
+	case "interval", "quiet_replication":
+	default:
+		return fmt.Errorf("mode must be 'interval' or 'quiet_replication'")
+	}
+	if s.MaxLag < 0 || s.QuietFor < 0 {
+		return fmt.Errorf("max_lag and quiet_for cannot be negative")
+	}
+	if s.TablesPerCycle < 0 || s.Budget < 0 {
+		return fmt.Errorf("tables_per_cycle and budget cannot be negative")
+	}
+	if s.MaxLag == 0 {
+		s.MaxLag = 5 * time.Second
+	}
+	if s.QuietFor == 0 {
+		s.QuietFor = 10 * time.Minute
+	}
+	return nil
+}
+
+// ApproximateCountConfig controls estimated row counts for very large InnoDB
+// tables between exact COUNT(*) runs. Estimates are only displayed; mismatch
+// alerts are raised and resolved by the exact counts.
+type ApproximateCountConfig struct {
+	Enabled          bool    `yaml:"enabled"`
+	Method           string  `yaml:"method"`            // "statistics" (information_schema) or "explain"
+	MinRows          int64   `yaml:"min_rows"`          // only tables estimated above this are approximated
+	ExactEvery       int     `yaml:"exact_every"`       // run an exact count every N checks
+	TolerancePercent float64 `yaml:"tolerance_percent"` // estimates further apart are shown as differing
+}
+
+// Config holds the application configuration
+type Config struct {
+	// Legacy single database pair (for backward compatibility)
+	SourceDB        DatabaseConfig `yaml:"source_db,omitempty"`
+	TargetDB        DatabaseConfig `yaml:"target_db,omitempty"`
+	TablesToMonitor TableList      `yaml:"tables_to_monitor,omitempty"`
+
+	// New multi-database support
+	DatabasePairs []DatabasePair `yaml:"database_pairs,omitempty"`
+
+	MonitoringInterval  time.Duration `yaml:"monitoring_interval"`
+	ReplicaLagThreshold time.Duration `yaml:"replica_lag_threshold"`
+	WebServerPort       int           `yaml:"web_server_port"`
+	LogLevel            string        `yaml:"log_level"`
+
+	// What happens when a monitoring cycle runs longer than the check
+	// interval: "skip" (default) the ticks that passed, "queue" the next cycle
+	// to run right away, or "cancel" the cycle once the interval elapses
+	CycleOverlap string `yaml:"cycle_overlap"`
+
+	// Maximum concurrent heavy queries (checksums, row counts) per host:port,
+	// shared by all pairs pointing at the same instance; defaults to 2, and a
+	// negative value removes the limit
+	MaxConcurrentQueriesPerInstance int `yaml:"max_concurrent_queries_per_instance"`
+
+	// Maximum monitoring cycles running at once across all pairs; the cycles
+	// of other pairs wait for a free slot
+	MaxConcurrentPairs int `yaml:"max_concurrent_pairs"`
+
+	// Every database session only allows read-only transactions, so the
+	// servers reject any write the monitor could attempt
+	ReadOnly bool `yaml:"read_only"`
+
+	SQLAudit SQLAuditConfig `yaml:"sql_audit"`
+
+	WebTLS WebTLSConfig `yaml:"web_tls"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	AccessLog AccessLogConfig `yaml:"access_log"`
+
+	Thresholds ThresholdsConfig `yaml:"thresholds"`
+
+	Federation FederationConfig `yaml:"federation"`
+
+	Notifiers NotifiersConfig `yaml:"notifiers"`
+
+	AlertHistory AlertHistoryConfig `yaml:"alert_history"`
+
+	LagHistory LagHistoryConfig `yaml:"lag_history"`
+
+	AlertPolicy AlertPolicyConfig `yaml:"alert_policy"`
+
+	AlertIngestion AlertIngestionConfig `yaml:"alert_ingestion"`
+
+	HealthScore HealthScoreConfig `yaml:"health_score"`
+
+	Insights InsightsConfig `yaml:"insights"`
+
+	Digest DigestConfig `yaml:"digest"`
+
+	Reports ReportsConfig `yaml:"reports"`
+
+	Backfill BackfillConfig `yaml:"backfill"`
+
+	Rehearsal RehearsalConfig `yaml:"rehearsal"`
+
+	Idle IdleConfig `yaml:"idle"`
+
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	Timeouts TimeoutsConfig `yaml:"timeouts"`
+
+	AWS AWSConfig `yaml:"aws"`
+
+	Archive ArchiveConfig `yaml:"archive"`
+
+	StatsD StatsDConfig `yaml:"statsd"`
+
+	TimeSeries TimeSeriesConfig `yaml:"timeseries"`
+
+	OpenTelemetry OpenTelemetryConfig `yaml:"opentelemetry"`
+
+	Secrets SecretsConfig `yaml:"secrets"`
+
+	// Bearer tokens allowed to change settings through the API. Runtime
+	// settings changes are disabled when empty.
+	AdminTokens []string `yaml:"admin_tokens"`
+
+	Auth AuthConfig `yaml:"auth"`
+
+	path       string   // file the configuration was loaded from
+	unknownEnv []string // MONITOR_ variables that name no setting
+
+	// Pairs added or removed through the API and their settings, and writes
+	// to the configuration file, are guarded by pairsMu
+	pairsMu      sync.RWMutex
+	pairSettings map[string]PairSettings // key: database_pair
+}
+
+// TimeoutsConfig holds per-check query timeouts. Checksum, consistency and
+// diff timeouts apply per table. Shutdown bounds how long in-flight web
+// requests, and then queued notifications, may drain on SIGTERM.
+type TimeoutsConfig struct {
+	Connect     time.Duration `yaml:"connect"`
+	ReplicaLag  time.Duration `yaml:"replica_lag"`
+	Checksum    time.Duration `yaml:"checksum"`
+	Consistency time.Duration `yaml:"consistency"`
+	Diff        time.Duration `yaml:"diff"`
+	ClockSkew   time.Duration `yaml:"clock_skew"`
+	Warmup      time.Duration `yaml:"warmup"`
+	Shutdown    time.Duration `yaml:"shutdown"`
+}
+
+// AWSConfig enables pulling CloudWatch metrics for pairs running on RDS.
+// Credentials come from the default AWS chain (environment, shared config,
+// instance or task role).
+type AWSConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Region       string        `yaml:"region"` // defaults to the region of the AWS environment
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// StatsDConfig enables pushing check metrics to a StatsD or DogStatsD agent
+type StatsDConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Address       string        `yaml:"address"`        // UDP host:port of the agent
+	Prefix        string        `yaml:"prefix"`         // prepended to every metric name
+	Tags          []string      `yaml:"tags"`           // key:value tags added to every metric
+	Format        string        `yaml:"format"`         // dogstatsd (tags) or statsd (tag values folded into names)
+	FlushInterval time.Duration `yaml:"flush_interval"` // metrics are batched into packets between flushes
+}
+
+// StatsD formats
+const (
+	StatsDFormatDogStatsD = "dogstatsd"
+	StatsDFormatStatsD    = "statsd"
+)
+
+// OpenTelemetryConfig enables exporting traces of monitoring cycles, checks
+// and SQL queries, and cycle and check duration metrics, over OTLP/HTTP
+type OpenTelemetryConfig struct {
+	Enabled        bool              `yaml:"enabled"`
+	Endpoint       string            `yaml:"endpoint"`     // collector base URL; /v1/traces and /v1/metrics are appended
+	ServiceName    string            `yaml:"service_name"` // service.name resource attribute
+	Headers        map[string]string `yaml:"headers"`
+	SampleRatio    float64           `yaml:"sample_ratio"` // share of cycles traced, 0-1; zero traces every cycle
+	ExportInterval time.Duration     `yaml:"export_interval"`
+	Timeout        time.Duration     `yaml:"timeout"`
+}
+
+// AlertHistoryConfig bounds the in-memory alert history. Resolved alerts are
+// evicted oldest first; active alerts are never evicted.
+type AlertHistoryConfig struct {
+	MaxAlerts int           `yaml:"max_alerts"`
+	MaxAge    time.Duration `yaml:"max_age"`   // zero keeps resolved alerts until max_alerts is reached
+	APILimit  int           `yaml:"api_limit"` // most recent alerts returned by /api/alerts, and page size of /api/alerts/history
+}
+
+// LagHistoryConfig sets how long replica lag history is kept in memory: raw
+// samples for Raw, then rollups with the min, average and max lag of each
+// bucket, for longer-range trend charts
+type LagHistoryConfig struct {
+	Raw     time.Duration     `yaml:"raw"`     // defaults to 24h
+	Rollups []LagRollupConfig `yaml:"rollups"` // defaults to 1m buckets for 7d and 5m buckets for 30d
+}
+
+// LagRollupConfig is one rollup tier of the lag history
+type LagRollupConfig struct {
+	Interval  time.Duration `yaml:"interval"`  // bucket width
+	Retention time.Duration `yaml:"retention"` // how long buckets are kept
+}
+
+// validate applies the default tiers and checks that each tier is coarser
+// and kept longer than the one before it
+func (l *LagHistoryConfig) validate() error {
+	if l.Raw < 0 {
+		return fmt.Errorf("raw cannot be negative")
+	}
+	if l.Raw == 0 {
+		l.Raw = 24 * time.Hour
+	}
+	if l.Rollups == nil {
+		l.Rollups = []LagRollupConfig{
+			{Interval: time.Minute, Retention: 7 * 24 * time.Hour},
+			{Interval: 5 * time.Minute, Retention: 30 * 24 * time.Hour},
+		}
+	}
+	for i, tier := range l.Rollups {
+		if tier.Interval < time.Minute || tier.Retention <= tier.Interval {
+			return fmt.Errorf("rollups[%d]: interval must be at least 1m and retention longer than interval", i)
+		}
+		if i > 0 && (tier.Interval <= l.Rollups[i-1].Interval || tier.Retention <= l.Rollups[i-1].Retention) {
+			return fmt.Errorf("rollups[%d]: interval and retention must be longer than those of the previous tier", i)
+		}
+	}
+	return nil
+}
+
+// AlertPolicyConfig adds hysteresis to alerts so that values bouncing around
+// a threshold do not fire and resolve every cycle, and re-notifies alerts
+// that stay active
+type AlertPolicyConfig struct {
+	FireAfter        int           `yaml:"fire_after"`        // consecutive breaching checks before an alert fires; defaults to 1
+	ResolveAfter     int           `yaml:"resolve_after"`     // consecutive OK checks before an alert resolves; defaults to 1
+	RenotifyInterval time.Duration `yaml:"renotify_interval"` // re-send still-active alerts this often; zero disables
+}
+
+// HealthScoreConfig controls the composite 0-100 health score of each pair
+type HealthScoreConfig struct {
+	Window  time.Duration      `yaml:"window"`  // pass rates and connection stability are measured over this window
+	Weights HealthScoreWeights `yaml:"weights"` // relative weights; inputs without data are left out
+}
+
+// InsightsConfig tunes the rules that derive findings from each pair's recent results
+type InsightsConfig struct {
+	Window          time.Duration `yaml:"window"`           // lag is compared with the window before it, and connection drops counted within it
+	StaleValidation time.Duration `yaml:"stale_validation"` // tables without a checksum or row count for this long are reported
+}
+
+// HealthScoreWeights weights the inputs of the health score
+type HealthScoreWeights struct {
+	ReplicaLag  float64 `yaml:"replica_lag"`
+	Checksum    float64 `yaml:"checksum"`
+	Consistency float64 `yaml:"consistency"`
+	Connection  float64 `yaml:"connection"`
+}
+
+// BackfillConfig bounds backfills declared through the API. While a backfill
+// runs, consistency checks of its tables allow a relative row count difference.
+type BackfillConfig struct {
+	DefaultTolerancePercent float64       `yaml:"default_tolerance_percent"` // used when a declaration sets none
+	MaxDuration             time.Duration `yaml:"max_duration"`              // longest backfill window accepted
+}
+
+// RehearsalConfig allows synthetic faults to be injected into the checks of
+// the configured pairs through the API, to rehearse alert routing,
+// escalation and dashboards with the on-call team before a migration
+type RehearsalConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	MaxDuration time.Duration `yaml:"max_duration"` // longest fault accepted; defaults to 4h
+}
+
+// IdleConfig sets the checks of pairs whose migration is complete: instead of
+// full validation, a heartbeat checks the connections, read_only and replica lag
+type IdleConfig struct {
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"` // defaults to 1h
+}
+
+// CircuitBreakerConfig sets how the checks of an unreachable database back
+// off: after failure_threshold failed health checks in a row it is no longer
+// pinged until its backoff elapses, which doubles on every failed probe up to
+// max_backoff
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // defaults to 3
+	InitialBackoff   time.Duration `yaml:"initial_backoff"`   // defaults to 1m
+	MaxBackoff       time.Duration `yaml:"max_backoff"`       // defaults to 30m
+}
+
+// NotifiersConfig holds outbound alert notification settings
+type NotifiersConfig struct {
+	Webhooks     []WebhookConfig      `yaml:"webhooks"`
+	Alertmanager []AlertmanagerConfig `yaml:"alertmanager"`
+	Telegram     []TelegramConfig     `yaml:"telegram"`
+	Teams        []TeamsConfig        `yaml:"teams"`
+	Grafana      []GrafanaConfig      `yaml:"grafana"`
+
+	// Send alerts to some notifiers only, by pair, table, type and severity
+	Routes []NotifierRoute `yaml:"routes"`
+}
+
+// AlertmanagerConfig holds settings for pushing alerts to Prometheus
+// Alertmanager through its /api/v2/alerts endpoint
+type AlertmanagerConfig struct {
+	Name           string            `yaml:"name"`
+	URLs           []string          `yaml:"urls"`          // base URLs of every Alertmanager in the cluster
+	GeneratorURL   string            `yaml:"generator_url"` // link back to this monitor, shown in Alertmanager
+	Labels         map[string]string `yaml:"labels"`        // extra labels added to every alert
+	Headers        map[string]string `yaml:"headers"`
+	ResendInterval time.Duration     `yaml:"resend_interval"` // firing alerts are re-sent so Alertmanager does not time them out
+	Timeout        time.Duration     `yaml:"timeout"`
+}
+
+// WebhookConfig holds settings for a generic outbound webhook
+type WebhookConfig struct {
+	Name           string            `yaml:"name"`
+	URLs           []string          `yaml:"urls"`
+	Template       string            `yaml:"template"` // Go template rendered with the alert event; default is a JSON payload
+	Secret         string            `yaml:"secret"`   // HMAC-SHA256 signing key
+	Headers        map[string]string `yaml:"headers"`
+	Events         []string          `yaml:"events"` // empty means all alert_* events; pair_* lifecycle events and daily_digest must be listed
+	MaxRetries     int               `yaml:"max_retries"`
+	InitialBackoff time.Duration     `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration     `yaml:"max_backoff"`
+	Timeout        time.Duration     `yaml:"timeout"`
+}
+
+// FederationConfig lists peer monitors whose pair rollups are aggregated
+// into a single global view
+type FederationConfig struct {
+	LocalName    string           `yaml:"local_name"` // label for this monitor in the global view
+	Peers        []FederationPeer `yaml:"peers"`
+	PollInterval time.Duration    `yaml:"poll_interval"`
+	Timeout      time.Duration    `yaml:"timeout"`
+}
+
+// FederationPeer identifies a peer monitor instance
+type FederationPeer struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"` // bearer token for peers with authentication enabled
+}
+
+// ThresholdsConfig holds two-tier alert thresholds per metric
+type ThresholdsConfig struct {
+	ReplicaLag    DurationThresholds `yaml:"replica_lag,omitempty"`
+	RowCountDrift CountThresholds    `yaml:"row_count_drift,omitempty"` // absolute row difference between source and target
+	ClockSkew     DurationThresholds `yaml:"clock_skew,omitempty"`      // largest clock difference between monitor, source and target
+}
+
+// DurationThresholds defines WARNING and CRITICAL tiers for a duration metric.
+// A zero tier is disabled.
+type DurationThresholds struct {
+	WarningAt  time.Duration `yaml:"warning_at,omitempty"`
+	CriticalAt time.Duration `yaml:"critical_at,omitempty"`
+}
+
+// CountThresholds defines WARNING and CRITICAL tiers for a count metric.
+// A zero tier is disabled.
+type CountThresholds struct {
+	WarningAt  int64 `yaml:"warning_at,omitempty"`
+	CriticalAt int64 `yaml:"critical_at,omitempty"`
+}
+
+// RateLimitConfig holds token-bucket limits for the REST API, applied per
+// client IP or per API token
+type RateLimitConfig struct {
+	Enabled                    bool    `yaml:"enabled"`
+	RequestsPerMinute          float64 `yaml:"requests_per_minute"`
+	Burst                      int     `yaml:"burst"`
+	ExpensiveRequestsPerMinute float64 `yaml:"expensive_requests_per_minute"` // on-demand checks and exports
+	ExpensiveBurst             int     `yaml:"expensive_burst"`
+	TrustProxyHeaders          bool    `yaml:"trust_proxy_headers"` // use X-Forwarded-For for the client IP
+}
+
+// WebTLSConfig serves the web UI and API over HTTPS. With client_ca_file the
+// listener also asks for client certificates (mutual TLS); a verified
+// certificate whose common name is listed in auth.client_certs authenticates
+// the caller.
+type WebTLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"` // CA bundle that signs client certificates
+	ClientAuth   string `yaml:"client_auth"`    // "optional" (default) or "require"
+}
+
+// Enabled reports whether the web server serves HTTPS
+func (t WebTLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// validate checks TLS settings and applies defaults
+func (t *WebTLSConfig) validate() error {
+	if !t.Enabled() {
+		if t.KeyFile != "" || t.ClientCAFile != "" {
+			return fmt.Errorf("cert_file is required with key_file or client_ca_file")
+		}
+		return nil
+	}
+	if t.KeyFile == "" {
+		return fmt.Errorf("key_file is required with cert_file")
+	}
+	switch t.ClientAuth {
+	case "":
+		t.ClientAuth = "optional"
+	case "optional", "require":
+	default:
+		return fmt.Errorf("client_auth must be 'optional' or 'require', got '%s'", t.ClientAuth)
+	}
+	if t.ClientAuth == "require" && t.ClientCAFile == "" {
+		return fmt.Errorf("client_auth 'require' needs client_ca_file")
+	}
+	return nil
+}
+
+// Roles granted to authenticated callers
+const (
+	RoleViewer = "viewer" // read-only access to the dashboard and API
+	RoleAdmin  = "admin"  // may also change settings at runtime
+)
+
+// AuthConfig protects the web UI and API. Admin tokens and API tokens, as
+// bearer tokens or X-API-Key headers, and client certificates are accepted in
+// every mode.
+type AuthConfig struct {
+	Mode          string          `yaml:"mode"` // "none", "basic" or "oidc"
+	Users         []BasicAuthUser `yaml:"users"`
+	OIDC          OIDCConfig      `yaml:"oidc"`
+	APITokens     []APIToken      `yaml:"api_tokens"`
+	ClientCerts   []ClientCert    `yaml:"client_certs"`
+	SessionSecret string          `yaml:"session_secret"` // signs OIDC session cookies; random per process when empty
+	SessionTTL    time.Duration   `yaml:"session_ttl"`
+
+	// Origins of pages other than the monitor's own, e.g.
+	// https://ops.example.com, allowed to open the WebSocket
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// BasicAuthUser is a user for HTTP basic authentication
+type BasicAuthUser struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"` // bcrypt hash
+	Role         string `yaml:"role"`
+}
+
+// APIToken is a bearer token for machine clients such as federation peers
+type APIToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"`
+}
+
+// ClientCert allows the TLS client certificates with a common name, verified
+// against web_tls.client_ca_file, to call with a role
+type ClientCert struct {
+	CommonName string `yaml:"common_name"`
+	Role       string `yaml:"role"`
+}
+
+// OIDCConfig holds OpenID Connect Authorization Code flow settings
+type OIDCConfig struct {
+	IssuerURL    string            `yaml:"issuer_url"`
+	ClientID     string            `yaml:"client_id"`
+	ClientSecret string            `yaml:"client_secret"`
+	RedirectURL  string            `yaml:"redirect_url"` // must end in /auth/callback
+	Scopes       []string          `yaml:"scopes"`
+	GroupsClaim  string            `yaml:"groups_claim"`
+	GroupRoles   map[string]string `yaml:"group_roles"`  // group -> role; the highest mapped role wins
+	DefaultRole  string            `yaml:"default_role"` // role for users without a mapped group; empty denies them
+}
+
+// validRole reports whether a role name is known
+func validRole(role string) bool {
+	return role == RoleViewer || role == RoleAdmin
+}
+
+// validate checks authentication settings and applies defaults
+func (a *AuthConfig) validate() error {
+	switch a.Mode {
+	case "":
+		a.Mode = "none"
+	case "none":
+	case "basic":
+		if len(a.Users) == 0 {
+			return fmt.Errorf("basic mode requires at least one user")
+		}
+		for i, user := range a.Users {
+			if user.Username == "" || user.PasswordHash == "" {
+				return fmt.Errorf("users[%d]: username and password_hash are required", i)
+			}
+			if user.Role == "" {
+				a.Users[i].Role = RoleViewer
+			} else if !validRole(user.Role) {
+				return fmt.Errorf("user '%s': unknown role '%s'", user.Username, user.Role)
+			}
+		}
+	case "oidc":
+		if err := a.OIDC.validate(); err != nil {
+			return fmt.Errorf("oidc: %w", err)
+		}
+	default:
+		return fmt.Errorf("mode must be 'none', 'basic' or 'oidc', got '%s'", a.Mode)
+	}
+
+	for i, token := range a.APITokens {
+		if token.Token == "" {
+			return fmt.Errorf("api_tokens[%d]: token is required", i)
+		}
+		if token.Role == "" {
+			a.APITokens[i].Role = RoleViewer
+		} else if !validRole(token.Role) {
+			return fmt.Errorf("api token '%s': unknown role '%s'", token.Name, token.Role)
+		}
+	}
+
+	for i, cert := range a.ClientCerts {
+		if cert.CommonName == "" {
+			return fmt.Errorf("client_certs[%d]: common_name is required", i)
+		}
+		if cert.Role == "" {
+			a.ClientCerts[i].Role = RoleViewer
+		} else if !validRole(cert.Role) {
+			return fmt.Errorf("client cert '%s': unknown role '%s'", cert.CommonName, cert.Role)
+		}
+	}
+
+	if a.SessionTTL == 0 {
+		a.SessionTTL = 12 * time.Hour
+	}
+
+	for i, origin := range a.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("allowed_origins[%d]: '%s' must be a scheme and host such as https://ops.example.com", i, origin)
+		}
+		a.AllowedOrigins[i] = u.Scheme + "://" + u.Host
+	}
+
+	return nil
+}
+
+// validate checks OIDC settings and applies defaults
+func (o *OIDCConfig) validate() error {
+	if o.IssuerURL == "" || o.ClientID == "" || o.RedirectURL == "" {
+		return fmt.Errorf("issuer_url, client_id and redirect_url are required")
+	}
+	if len(o.Scopes) == 0 {
+		o.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+	if o.GroupsClaim == "" {
+		o.GroupsClaim = "groups"
+	}
+	for group, role := range o.GroupRoles {
+		if !validRole(role) {
+			return fmt.Errorf("group_roles: unknown role '%s' for group '%s'", role, group)
+		}
+	}
+	if o.DefaultRole != "" && !validRole(o.DefaultRole) {
+		return fmt.Errorf("unknown default_role '%s'", o.DefaultRole)
+	}
+	return nil
 }
 
-// DatabasePair represents a source-target database pair to monitor
-type DatabasePair struct {
-	Name            string         `yaml:"name"`
-	SourceDB        DatabaseConfig `yaml:"source_db"`
-	TargetDB        DatabaseConfig `yaml:"target_db"`
-	TablesToMonitor []string       `yaml:"tables_to_monitor"`
-}
+// AccessLogConfig controls structured JSON access logs for the web server
+type AccessLogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Output  string `yaml:"output"` // "stdout", "stderr" or a file path
+}
+
+// LoadConfig loads configuration from a YAML file with environment variable overrides
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if err := config.load(os.Environ()); err != nil {
+		return nil, err
+	}
+	config.path = path
+
+	return &config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	if len(c.DatabasePairs) == 0 {
+		return fmt.Errorf("at least one database pair must be configured")
+	}
+
+	if err := c.expandFanOut(); err != nil {
+		return err
+	}
+
+	// Validate each database pair
+	for i := range c.DatabasePairs {
+		pair := &c.DatabasePairs[i]
+		if pair.Name == "" {
+			return fmt.Errorf("database pair %d: name is required", i)
+		}
+		if err := c.validatePair(pair); err != nil {
+			return err
+		}
+	}
+
+	if c.MonitoringInterval < 10*time.Second {
+		return fmt.Errorf("monitoring interval must be at least 10 seconds")
+	}
+
+	switch c.CycleOverlap {
+	case "":
+		c.CycleOverlap = "skip"
+	case "skip", "queue", "cancel":
+	default:
+		return fmt.Errorf("cycle_overlap must be 'skip', 'queue' or 'cancel'")
+	}
+
+	if c.WebServerPort == 0 {
+		c.WebServerPort = 8080 // Default port
+	}
+
+	if c.ReplicaLagThreshold == 0 {
+		c.ReplicaLagThreshold = 60 * time.Second // Default threshold
+	}
 
-// Config holds the application configuration
-type Config struct {
-	// Legacy single database pair (for backward compatibility)
-	SourceDB            DatabaseConfig   `yaml:"source_db,omitempty"`
-	TargetDB            DatabaseConfig   `yaml:"target_db,omitempty"`
-	TablesToMonitor     []string         `yaml:"tables_to_monitor,omitempty"`
-	
-	// New multi-database support
-	DatabasePairs       []DatabasePair   `yaml:"database_pairs,omitempty"`
-	
-	MonitoringInterval  time.Duration    `yaml:"monitoring_interval"`
-	ReplicaLagThreshold time.Duration    `yaml:"replica_lag_threshold"`
-	WebServerPort       int              `yaml:"web_server_port"`
-	LogLevel            string           `yaml:"log_level"`
-}
+	// The legacy single lag threshold becomes the WARNING tier
+	if c.Thresholds.ReplicaLag.WarningAt == 0 && c.Thresholds.ReplicaLag.CriticalAt == 0 {
+		c.Thresholds.ReplicaLag.WarningAt = c.ReplicaLagThreshold
+	}
+	if c.Thresholds.ReplicaLag.WarningAt > 0 && c.Thresholds.ReplicaLag.CriticalAt > 0 &&
+		c.Thresholds.ReplicaLag.CriticalAt < c.Thresholds.ReplicaLag.WarningAt {
+		return fmt.Errorf("thresholds.replica_lag: critical_at must not be lower than warning_at")
+	}
 
-// LoadConfig loads configuration from a YAML file with environment variable overrides
-func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// Skew of a couple of seconds already distorts Seconds_Behind_Master
+	if c.Thresholds.ClockSkew.WarningAt == 0 && c.Thresholds.ClockSkew.CriticalAt == 0 {
+		c.Thresholds.ClockSkew.WarningAt = 2 * time.Second
+	}
+	if c.Thresholds.ClockSkew.WarningAt > 0 && c.Thresholds.ClockSkew.CriticalAt > 0 &&
+		c.Thresholds.ClockSkew.CriticalAt < c.Thresholds.ClockSkew.WarningAt {
+		return fmt.Errorf("thresholds.clock_skew: critical_at must not be lower than warning_at")
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// By default any row count difference is CRITICAL
+	if c.Thresholds.RowCountDrift.WarningAt == 0 && c.Thresholds.RowCountDrift.CriticalAt == 0 {
+		c.Thresholds.RowCountDrift.CriticalAt = 1
+	}
+	if c.Thresholds.RowCountDrift.WarningAt < 0 || c.Thresholds.RowCountDrift.CriticalAt < 0 {
+		return fmt.Errorf("thresholds.row_count_drift values cannot be negative")
+	}
+	if c.Thresholds.RowCountDrift.WarningAt > 0 && c.Thresholds.RowCountDrift.CriticalAt > 0 &&
+		c.Thresholds.RowCountDrift.CriticalAt < c.Thresholds.RowCountDrift.WarningAt {
+		return fmt.Errorf("thresholds.row_count_drift: critical_at must not be lower than warning_at")
+	}
+
+	// A negative limit disables it
+	if c.MaxConcurrentQueriesPerInstance == 0 {
+		c.MaxConcurrentQueriesPerInstance = 2 // Default limit
+	}
+	if c.MaxConcurrentPairs < 0 {
+		return fmt.Errorf("max_concurrent_pairs cannot be negative")
+	}
+	if c.MaxConcurrentPairs == 0 {
+		c.MaxConcurrentPairs = 4 // Default limit
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerMinute == 0 {
+			c.RateLimit.RequestsPerMinute = 120
+		}
+		if c.RateLimit.Burst == 0 {
+			c.RateLimit.Burst = 30
+		}
+		if c.RateLimit.ExpensiveRequestsPerMinute == 0 {
+			c.RateLimit.ExpensiveRequestsPerMinute = 6
+		}
+		if c.RateLimit.ExpensiveBurst == 0 {
+			c.RateLimit.ExpensiveBurst = 2
+		}
+		if c.RateLimit.RequestsPerMinute < 0 || c.RateLimit.ExpensiveRequestsPerMinute < 0 || c.RateLimit.Burst < 0 || c.RateLimit.ExpensiveBurst < 0 {
+			return fmt.Errorf("rate_limit values cannot be negative")
+		}
+	}
+
+	if c.AccessLog.Enabled && c.AccessLog.Output == "" {
+		c.AccessLog.Output = "stdout"
+	}
+
+	if c.SQLAudit.Enabled {
+		if err := c.SQLAudit.validate(); err != nil {
+			return fmt.Errorf("sql_audit: %w", err)
+		}
+	}
+
+	if err := c.LagHistory.validate(); err != nil {
+		return fmt.Errorf("lag_history: %w", err)
+	}
+
+	if c.AlertHistory.MaxAlerts < 0 || c.AlertHistory.MaxAge < 0 || c.AlertHistory.APILimit < 0 {
+		return fmt.Errorf("alert_history values cannot be negative")
+	}
+	if c.AlertHistory.MaxAlerts == 0 {
+		c.AlertHistory.MaxAlerts = 1000
+	}
+	if c.AlertHistory.APILimit == 0 {
+		c.AlertHistory.APILimit = 100
+	}
+
+	if c.AlertPolicy.FireAfter < 0 || c.AlertPolicy.ResolveAfter < 0 || c.AlertPolicy.RenotifyInterval < 0 {
+		return fmt.Errorf("alert_policy values cannot be negative")
+	}
+	if c.AlertPolicy.FireAfter == 0 {
+		c.AlertPolicy.FireAfter = 1
+	}
+	if c.AlertPolicy.ResolveAfter == 0 {
+		c.AlertPolicy.ResolveAfter = 1
+	}
+
+	if c.HealthScore.Window == 0 {
+		c.HealthScore.Window = time.Hour
+	}
+	weights := &c.HealthScore.Weights
+	if weights.ReplicaLag < 0 || weights.Checksum < 0 || weights.Consistency < 0 || weights.Connection < 0 {
+		return fmt.Errorf("health_score.weights cannot be negative")
+	}
+	if *weights == (HealthScoreWeights{}) {
+		*weights = HealthScoreWeights{ReplicaLag: 30, Checksum: 25, Consistency: 25, Connection: 20}
+	}
+
+	if c.Insights.Window < 0 || c.Insights.StaleValidation < 0 {
+		return fmt.Errorf("insights values cannot be negative")
+	}
+	if c.Insights.Window == 0 {
+		c.Insights.Window = 30 * time.Minute
+	}
+	if c.Insights.StaleValidation == 0 {
+		c.Insights.StaleValidation = 48 * time.Hour
+	}
+
+	if err := c.Digest.validate(); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+
+	if err := c.Reports.validate(); err != nil {
+		return fmt.Errorf("reports: %w", err)
+	}
+
+	if c.Backfill.DefaultTolerancePercent < 0 || c.Backfill.DefaultTolerancePercent > 100 {
+		return fmt.Errorf("backfill.default_tolerance_percent must be between 0 and 100")
+	}
+	if c.Backfill.DefaultTolerancePercent == 0 {
+		c.Backfill.DefaultTolerancePercent = 5
+	}
+	if c.Backfill.MaxDuration < 0 {
+		return fmt.Errorf("backfill.max_duration cannot be negative")
+	}
+	if c.Backfill.MaxDuration == 0 {
+		c.Backfill.MaxDuration = 7 * 24 * time.Hour
+	}
+
+	if c.Rehearsal.MaxDuration < 0 {
+		return fmt.Errorf("rehearsal.max_duration cannot be negative")
+	}
+	if c.Rehearsal.MaxDuration == 0 {
+		c.Rehearsal.MaxDuration = 4 * time.Hour
+	}
+
+	if c.Idle.HeartbeatInterval < 0 {
+		return fmt.Errorf("idle.heartbeat_interval cannot be negative")
+	}
+	if c.Idle.HeartbeatInterval == 0 {
+		c.Idle.HeartbeatInterval = time.Hour
+	}
+
+	if c.CircuitBreaker.FailureThreshold < 0 || c.CircuitBreaker.InitialBackoff < 0 || c.CircuitBreaker.MaxBackoff < 0 {
+		return fmt.Errorf("circuit_breaker settings cannot be negative")
+	}
+	if c.CircuitBreaker.FailureThreshold == 0 {
+		c.CircuitBreaker.FailureThreshold = 3
+	}
+	if c.CircuitBreaker.InitialBackoff == 0 {
+		c.CircuitBreaker.InitialBackoff = time.Minute
+	}
+	if c.CircuitBreaker.MaxBackoff == 0 {
+		c.CircuitBreaker.MaxBackoff = 30 * time.Minute
+	}
+	if c.CircuitBreaker.MaxBackoff < c.CircuitBreaker.InitialBackoff {
+		return fmt.Errorf("circuit_breaker.max_backoff cannot be shorter than initial_backoff")
+	}
+
+	if err := c.Auth.validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+
+	if err := c.WebTLS.validate(); err != nil {
+		return fmt.Errorf("web_tls: %w", err)
+	}
+	if len(c.Auth.ClientCerts) > 0 && c.WebTLS.ClientCAFile == "" {
+		return fmt.Errorf("auth.client_certs requires web_tls.client_ca_file")
+	}
+
+	if err := c.Federation.validate(); err != nil {
+		return fmt.Errorf("federation: %w", err)
+	}
+
+	if err := c.Secrets.validate(); err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	if err := c.AlertIngestion.validate(c.DatabasePairs); err != nil {
+		return fmt.Errorf("alert_ingestion: %w", err)
+	}
+
+	if err := c.Archive.validate(); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	if c.Archive.Region == "" {
+		c.Archive.Region = c.AWS.Region
 	}
 
-	// Convert legacy single database config to database pairs format
-	if config.SourceDB.Host != "" && len(config.DatabasePairs) == 0 {
-		config.DatabasePairs = []DatabasePair{
-			{
-				Name:            "default",
-				SourceDB:        config.SourceDB,
-				TargetDB:        config.TargetDB,
-				TablesToMonitor: config.TablesToMonitor,
-			},
+	if c.AWS.Enabled {
+		if c.AWS.PollInterval == 0 {
+			c.AWS.PollInterval = time.Minute // RDS publishes basic metrics every minute
+		}
+		if c.AWS.PollInterval < 10*time.Second {
+			return fmt.Errorf("aws.poll_interval must be at least 10 seconds")
+		}
+		if c.AWS.Timeout == 0 {
+			c.AWS.Timeout = 10 * time.Second
+		}
+	}
+
+	if c.StatsD.Enabled {
+		if err := c.StatsD.validate(); err != nil {
+			return fmt.Errorf("statsd: %w", err)
+		}
+	}
+
+	if c.TimeSeries.Enabled {
+		if err := c.TimeSeries.validate(); err != nil {
+			return fmt.Errorf("timeseries: %w", err)
 		}
 	}
 
-	// Apply environment variable overrides for legacy config
-	if host := os.Getenv("SOURCE_DB_HOST"); host != "" {
-		config.SourceDB.Host = host
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].SourceDB.Host = host
+	if c.OpenTelemetry.Enabled {
+		if err := c.OpenTelemetry.validate(); err != nil {
+			return fmt.Errorf("opentelemetry: %w", err)
 		}
 	}
-	if user := os.Getenv("SOURCE_DB_USERNAME"); user != "" {
-		config.SourceDB.Username = user
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].SourceDB.Username = user
+
+	for i := range c.Notifiers.Webhooks {
+		if err := c.Notifiers.Webhooks[i].validate(); err != nil {
+			return fmt.Errorf("notifiers.webhooks[%d]: %w", i, err)
 		}
 	}
-	if pass := os.Getenv("SOURCE_DB_PASSWORD"); pass != "" {
-		config.SourceDB.Password = pass
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].SourceDB.Password = pass
+	for i := range c.Notifiers.Alertmanager {
+		if err := c.Notifiers.Alertmanager[i].validate(); err != nil {
+			return fmt.Errorf("notifiers.alertmanager[%d]: %w", i, err)
 		}
 	}
-	if host := os.Getenv("TARGET_DB_HOST"); host != "" {
-		config.TargetDB.Host = host
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].TargetDB.Host = host
+	for i := range c.Notifiers.Telegram {
+		if err := c.Notifiers.Telegram[i].validate(c.DatabasePairs); err != nil {
+			return fmt.Errorf("notifiers.telegram[%d]: %w", i, err)
 		}
 	}
-	if user := os.Getenv("TARGET_DB_USERNAME"); user != "" {
-		config.TargetDB.Username = user
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].TargetDB.Username = user
+	for i := range c.Notifiers.Teams {
+		if err := c.Notifiers.Teams[i].validate(c.DatabasePairs); err != nil {
+			return fmt.Errorf("notifiers.teams[%d]: %w", i, err)
 		}
 	}
-	if pass := os.Getenv("TARGET_DB_PASSWORD"); pass != "" {
-		config.TargetDB.Password = pass
-		if len(config.DatabasePairs) > 0 {
-			config.DatabasePairs[0].TargetDB.Password = pass
+	for i := range c.Notifiers.Grafana {
+		if err := c.Notifiers.Grafana[i].validate(c.DatabasePairs); err != nil {
+			return fmt.Errorf("notifiers.grafana[%d]: %w", i, err)
 		}
 	}
+	if err := c.Notifiers.validateRoutes(c.DatabasePairs); err != nil {
+		return fmt.Errorf("notifiers: %w", err)
+	}
+
+	if c.Timeouts.Connect == 0 {
+		c.Timeouts.Connect = 10 * time.Second
+	}
+	if c.Timeouts.ReplicaLag == 0 {
+		c.Timeouts.ReplicaLag = 10 * time.Second
+	}
+	if c.Timeouts.Checksum == 0 {
+		c.Timeouts.Checksum = 10 * time.Minute
+	}
+	if c.Timeouts.Consistency == 0 {
+		c.Timeouts.Consistency = 5 * time.Minute
+	}
+	if c.Timeouts.Diff == 0 {
+		c.Timeouts.Diff = 30 * time.Minute
+	}
+	if c.Timeouts.ClockSkew == 0 {
+		c.Timeouts.ClockSkew = 5 * time.Second
+	}
+	if c.Timeouts.Warmup == 0 {
+		c.Timeouts.Warmup = 30 * time.Second
+	}
+	if c.Timeouts.Shutdown == 0 {
+		c.Timeouts.Shutdown = 15 * time.Second
+	}
+
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
 
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	c.pairSettings = make(map[string]PairSettings, len(c.DatabasePairs))
+	for _, pair := range c.DatabasePairs {
+		c.pairSettings[pair.Name] = pair.settings()
 	}
 
-	return &config, nil
+	return nil
 }
 
-// Validate checks if the configuration is valid
-func (c *Config) Validate() error {
-	if len(c.DatabasePairs) == 0 {
-		return fmt.Errorf("at least one database pair must be configured")
+// validatePair checks the settings of a database pair and applies defaults
+func (c *Config) validatePair(pair *DatabasePair) error {
+	// Validate source database
+	if !pair.SourceDB.hasEndpoint() {
+		return fmt.Errorf("database pair '%s': source database host, socket or dsn is required", pair.Name)
+	}
+	if pair.SourceDB.Port == 0 && pair.SourceDB.hasHost() && pair.SourceDB.Socket == "" {
+		return fmt.Errorf("database pair '%s': source database port is required", pair.Name)
+	}
+	if pair.SourceDB.Username == "" && pair.SourceDB.UsernameFrom == "" && pair.SourceDB.DSN == "" {
+		return fmt.Errorf("database pair '%s': source database username is required", pair.Name)
+	}
+	if pair.SourceDB.Database == "" && pair.SourceDB.DSN == "" {
+		return fmt.Errorf("database pair '%s': source database name is required", pair.Name)
 	}
 
-	// Validate each database pair
-	for i, pair := range c.DatabasePairs {
-		if pair.Name == "" {
-			return fmt.Errorf("database pair %d: name is required", i)
+	// Validate target database
+	if !pair.TargetDB.hasEndpoint() {
+		return fmt.Errorf("database pair '%s': target database host, socket or dsn is required", pair.Name)
+	}
+	if pair.TargetDB.Port == 0 && pair.TargetDB.hasHost() && pair.TargetDB.Socket == "" {
+		return fmt.Errorf("database pair '%s': target database port is required", pair.Name)
+	}
+	if pair.TargetDB.Username == "" && pair.TargetDB.UsernameFrom == "" && pair.TargetDB.DSN == "" {
+		return fmt.Errorf("database pair '%s': target database username is required", pair.Name)
+	}
+	if pair.TargetDB.Database == "" && pair.TargetDB.DSN == "" {
+		return fmt.Errorf("database pair '%s': target database name is required", pair.Name)
+	}
+	if err := pair.SourceDB.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': source_db: %w", pair.Name, err)
+	}
+	if err := pair.TargetDB.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': target_db: %w", pair.Name, err)
+	}
+	if err := pair.validateIntermediates(); err != nil {
+		return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+	}
+	if err := pair.validateCheckEndpoints(); err != nil {
+		return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+	}
+	if err := pair.validateMode(); err != nil {
+		return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+	}
+
+	if err := pair.validateTables(); err != nil {
+		return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+	}
+	for _, pattern := range append(pair.TableDiscovery.Include, pair.TableDiscovery.Exclude...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("database pair '%s': invalid table_discovery pattern '%s': %w", pair.Name, pattern, err)
+		}
+	}
+	if err := pair.TableMappings.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': table_mappings: %w", pair.Name, err)
+	}
+	if err := pair.ChecksumExclusions.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': checksum_exclusions: %w", pair.Name, err)
+	}
+	if err := pair.validateViews(); err != nil {
+		return fmt.Errorf("database pair '%s': views: %w", pair.Name, err)
+	}
+
+	if pair.TableDiscovery.RefreshInterval == 0 {
+		pair.TableDiscovery.RefreshInterval = 10 * time.Minute
+	}
+
+	switch pair.ChecksumPreflight.Action {
+	case "":
+		pair.ChecksumPreflight.Action = "warn"
+	case "warn", "skip":
+	default:
+		return fmt.Errorf("database pair '%s': checksum_preflight.action must be 'warn' or 'skip'", pair.Name)
+	}
+
+	if err := pair.ChecksumSchedule.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': checksum_schedule: %w", pair.Name, err)
+	}
+
+	if err := pair.ApproximateCounts.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': approximate_counts: %w", pair.Name, err)
+	}
+
+	if err := pair.Masking.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': masking: %w", pair.Name, err)
+	}
+
+	if err := pair.Metadata.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': metadata: %w", pair.Name, err)
+	}
+
+	if err := pair.Warmup.validate(pair.TargetDB); err != nil {
+		return fmt.Errorf("database pair '%s': warmup: %w", pair.Name, err)
+	}
+
+	if err := pair.PTChecksum.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': pt_checksum: %w", pair.Name, err)
+	}
+
+	if err := pair.EncryptionStatus.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': encryption_status: %w", pair.Name, err)
+	}
+	if err := pair.TableDefinitions.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': table_definitions: %w", pair.Name, err)
+	}
+
+	if err := pair.LagAnomaly.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': lag_anomaly: %w", pair.Name, err)
+	}
+
+	if err := pair.ConsistencyWindows.validate(); err != nil {
+		return fmt.Errorf("database pair '%s': consistency_windows: %w", pair.Name, err)
+	}
+
+	// The target of a chain acknowledges to the last intermediate, not the source
+	if pair.SemiSync.Enabled && len(pair.Intermediates) > 0 {
+		return fmt.Errorf("database pair '%s': semi_sync is not supported with intermediates", pair.Name)
+	}
+
+	for j := range pair.MaintenanceWindows {
+		if err := pair.MaintenanceWindows[j].validate(j); err != nil {
+			return fmt.Errorf("database pair '%s': maintenance_windows: %w", pair.Name, err)
 		}
+	}
+
+	if err := pair.settings().validate(); err != nil {
+		return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+	}
 
-		// Validate source database
-		if pair.SourceDB.Host == "" {
-			return fmt.Errorf("database pair '%s': source database host is required", pair.Name)
+	if err := pair.applyReadOnly(c.ReadOnly); err != nil {
+		return fmt.Errorf("database pair '%s': read_only: %w", pair.Name, err)
+	}
+	if err := pair.applySSHTunnel(); err != nil {
+		return fmt.Errorf("database pair '%s': ssh_tunnel: %w", pair.Name, err)
+	}
+	return nil
+}
+
+// validate checks warm-up settings and applies defaults
+func (w *WarmupConfig) validate(target DatabaseConfig) error {
+	if !w.Enabled {
+		return nil
+	}
+
+	if w.Interval == 0 {
+		w.Interval = 5 * time.Minute
+	}
+	if w.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if w.MinBufferPoolHitRate == 0 {
+		w.MinBufferPoolHitRate = 99
+	}
+	if w.MinBufferPoolHitRate < 0 || w.MinBufferPoolHitRate > 100 {
+		return fmt.Errorf("min_buffer_pool_hit_rate must be between 0 and 100")
+	}
+	if w.MaxStatementTime == 0 {
+		w.MaxStatementTime = 5 * time.Second
+	}
+	if w.MaxStatementTime < time.Millisecond {
+		return fmt.Errorf("max_statement_time must be at least 1ms")
+	}
+
+	if w.HasDB() {
+		// The checks must reach the target itself, whose buffer pool they measure
+		if !w.DB.hasEndpoint() {
+			if target.DSN != "" {
+				return fmt.Errorf("db.dsn is required when target_db uses a dsn")
+			}
+			w.DB.Host, w.DB.HostFrom, w.DB.Socket = target.Host, target.HostFrom, target.Socket
 		}
-		if pair.SourceDB.Port == 0 {
-			return fmt.Errorf("database pair '%s': source database port is required", pair.Name)
+		w.DB = w.DB.inherit(target)
+		if err := w.DB.validate(); err != nil {
+			return fmt.Errorf("db: %w", err)
 		}
-		if pair.SourceDB.Username == "" {
-			return fmt.Errorf("database pair '%s': source database username is required", pair.Name)
+	}
+
+	for i := range w.Queries {
+		query := &w.Queries[i]
+		if query.Name == "" {
+			query.Name = fmt.Sprintf("query %d", i+1)
 		}
-		if pair.SourceDB.Database == "" {
-			return fmt.Errorf("database pair '%s': source database name is required", pair.Name)
+		sql, err := validateLimitedSelect(query.SQL)
+		if err != nil {
+			return fmt.Errorf("query '%s': %w", query.Name, err)
 		}
+		query.SQL = sql
+	}
+	return nil
+}
+
+// validate checks approximate count settings and applies defaults
+func (a *ApproximateCountConfig) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+
+	switch a.Method {
+	case "":
+		a.Method = "statistics"
+	case "statistics", "explain":
+	default:
+		return fmt.Errorf("method must be 'statistics' or 'explain', got '%s'", a.Method)
+	}
+
+	if a.MinRows == 0 {
+		a.MinRows = 10000000 // Default: tables above 10M rows
+	}
+	if a.ExactEvery == 0 {
+		a.ExactEvery = 10 // Default: exact count every 10th check
+	}
+	if a.TolerancePercent == 0 {
+		a.TolerancePercent = 5 // Default: 5% confidence bound
+	}
+	if a.TolerancePercent < 0 || a.TolerancePercent > 100 {
+		return fmt.Errorf("tolerance_percent must be between 0 and 100")
+	}
 
-		// Validate target database
-		if pair.TargetDB.Host == "" {
-			return fmt.Errorf("database pair '%s': target database host is required", pair.Name)
+	return nil
+}
+
+// validate checks StatsD settings and applies defaults
+func (s *StatsDConfig) validate() error {
+	if s.Address == "" {
+		s.Address = "127.0.0.1:8125"
+	}
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return fmt.Errorf("invalid address '%s': %w", s.Address, err)
+	}
+	if s.Prefix == "" {
+		s.Prefix = "mariadb_monitor"
+	}
+	switch s.Format {
+	case "":
+		s.Format = StatsDFormatDogStatsD
+	case StatsDFormatDogStatsD, StatsDFormatStatsD:
+	default:
+		return fmt.Errorf("format must be %s or %s", StatsDFormatDogStatsD, StatsDFormatStatsD)
+	}
+	for _, tag := range s.Tags {
+		if tag == "" || strings.ContainsAny(tag, ",|") {
+			return fmt.Errorf("invalid tag '%s'", tag)
 		}
-		if pair.TargetDB.Port == 0 {
-			return fmt.Errorf("database pair '%s': target database port is required", pair.Name)
+	}
+	if s.FlushInterval == 0 {
+		s.FlushInterval = time.Second
+	}
+	if s.FlushInterval < 0 {
+		return fmt.Errorf("flush_interval cannot be negative")
+	}
+	return nil
+}
+
+// validate checks OpenTelemetry settings and applies defaults
+func (o *OpenTelemetryConfig) validate() error {
+	if o.Endpoint == "" {
+		o.Endpoint = "http://localhost:4318"
+	}
+	if !strings.HasPrefix(o.Endpoint, "http://") && !strings.HasPrefix(o.Endpoint, "https://") {
+		return fmt.Errorf("endpoint must be an http or https URL")
+	}
+	o.Endpoint = strings.TrimRight(o.Endpoint, "/")
+	if o.ServiceName == "" {
+		o.ServiceName = "mariadb-encryption-monitor"
+	}
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio must be between 0 and 1")
+	}
+	if o.SampleRatio == 0 {
+		o.SampleRatio = 1
+	}
+	if o.ExportInterval == 0 {
+		o.ExportInterval = 10 * time.Second
+	}
+	if o.ExportInterval < time.Second {
+		return fmt.Errorf("export_interval must be at least 1 second")
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return nil
+}
+
+// validate checks federation settings and applies defaults
+func (f *FederationConfig) validate() error {
+	if f.LocalName == "" {
+		f.LocalName = "local"
+	}
+	if f.PollInterval == 0 {
+		f.PollInterval = 30 * time.Second
+	}
+	if f.Timeout == 0 {
+		f.Timeout = 10 * time.Second
+	}
+
+	names := make(map[string]bool)
+	for i, peer := range f.Peers {
+		if peer.Name == "" {
+			return fmt.Errorf("peer %d: name is required", i)
 		}
-		if pair.TargetDB.Username == "" {
-			return fmt.Errorf("database pair '%s': target database username is required", pair.Name)
+		if peer.URL == "" {
+			return fmt.Errorf("peer '%s': url is required", peer.Name)
 		}
-		if pair.TargetDB.Database == "" {
-			return fmt.Errorf("database pair '%s': target database name is required", pair.Name)
+		if names[peer.Name] || peer.Name == f.LocalName {
+			return fmt.Errorf("peer '%s': duplicate name", peer.Name)
 		}
+		names[peer.Name] = true
+		f.Peers[i].URL = strings.TrimRight(peer.URL, "/")
 	}
 
-	if c.MonitoringInterval < 10*time.Second {
-		return fmt.Errorf("monitoring interval must be at least 10 seconds")
-	}
+	return nil
+}
 
-	if c.WebServerPort == 0 {
-		c.WebServerPort = 8080 // Default port
+// validate checks Alertmanager settings and applies defaults
+func (a *AlertmanagerConfig) validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(a.URLs) == 0 {
+		return fmt.Errorf("alertmanager '%s': at least one url is required", a.Name)
 	}
+	if a.ResendInterval < 0 || a.Timeout < 0 {
+		return fmt.Errorf("alertmanager '%s': durations cannot be negative", a.Name)
+	}
+	if a.ResendInterval == 0 {
+		a.ResendInterval = time.Minute
+	}
+	if a.Timeout == 0 {
+		a.Timeout = 10 * time.Second
+	}
+	return nil
+}
 
-	if c.ReplicaLagThreshold == 0 {
-		c.ReplicaLagThreshold = 60 * time.Second // Default threshold
+// validate checks webhook settings and applies defaults
+func (w *WebhookConfig) validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(w.URLs) == 0 {
+		return fmt.Errorf("webhook '%s': at least one url is required", w.Name)
+	}
+	for _, event := range w.Events {
+		switch event {
+		case "alert_created", "alert_updated", "alert_resolved", "alert_renotified", "alert_acknowledged",
+			"pair_added", "pair_paused", "pair_resumed", "pair_warmup", "pair_ready", "pair_cut_over",
+			"pair_standby", "pair_activated", "pair_completed", "pair_removed", "check_paused", "check_resumed", "daily_digest", "sla_report":
+		default:
+			return fmt.Errorf("webhook '%s': unknown event '%s'", w.Name, event)
+		}
 	}
 
-	if c.LogLevel == "" {
-		c.LogLevel = "info"
+	if w.MaxRetries < 0 {
+		return fmt.Errorf("webhook '%s': max_retries cannot be negative", w.Name)
+	}
+	if w.MaxRetries == 0 {
+		w.MaxRetries = 3
+	}
+	if w.InitialBackoff == 0 {
+		w.InitialBackoff = time.Second
+	}
+	if w.MaxBackoff == 0 {
+		w.MaxBackoff = time.Minute
+	}
+	if w.Timeout == 0 {
+		w.Timeout = 10 * time.Second
 	}
 
 	return nil
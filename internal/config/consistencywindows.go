@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsistencyWindowConfig adds comparisons to the row count check of
+// append-only tables that catch a replication gap sooner than a full
+// COUNT(*): the lowest primary key, the highest primary key of the rows older
+// than settle, and the rows created within the recent window. Full counts can
+// then run less often.
+type ConsistencyWindowConfig struct {
+	Window         time.Duration     `yaml:"window"`           // rows created this far back are counted, defaults to 1h
+	Settle         time.Duration     `yaml:"settle"`           // newer rows may still be replicating and are left out, defaults to 1m
+	FullCountEvery int               `yaml:"full_count_every"` // run the full count every N checks, defaults to 1 (every check)
+	Tables         map[string]string `yaml:"tables"`           // source table name to the column holding its rows' creation time
+}
+
+// Column returns the creation time column of a table, or "" when the table
+// has no windowed checks
+func (w ConsistencyWindowConfig) Column(table string) string {
+	return w.Tables[table]
+}
+
+// validate checks the window settings and applies defaults
+func (w *ConsistencyWindowConfig) validate() error {
+	if len(w.Tables) == 0 {
+		return nil
+	}
+	for table, column := range w.Tables {
+		if table == "" || column == "" {
+			return fmt.Errorf("tables: table and column names cannot be empty")
+		}
+	}
+	if w.Window < 0 || w.Settle < 0 {
+		return fmt.Errorf("window and settle cannot be negative")
+	}
+	if w.Window == 0 {
+		w.Window = time.Hour
+	}
+	if w.Settle == 0 {
+		w.Settle = time.Minute
+	}
+	if w.Settle >= w.Window {
+		return fmt.Errorf("settle (%s) must be shorter than window (%s)", w.Settle, w.Window)
+	}
+	if w.FullCountEvery < 0 {
+		return fmt.Errorf("full_count_every cannot be negative")
+	}
+	if w.FullCountEvery == 0 {
+		w.FullCountEvery = 1
+	}
+	return nil
+}
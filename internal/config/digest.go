@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DigestConfig sends a summary of every pair, with its insights, to the
+// webhooks listing the daily_digest event
+type DigestConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Schedule string `yaml:"schedule"` // cron expression; defaults to 09:00 every day
+	Timezone string `yaml:"timezone"` // IANA name for the schedule; defaults to UTC
+
+	cron *Schedule
+}
+
+// validate applies defaults and parses the schedule
+func (d *DigestConfig) validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.Schedule == "" {
+		d.Schedule = "0 9 * * *"
+	}
+	location, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	d.cron, err = parseSchedule(d.Schedule, location)
+	return err
+}
+
+// Cron returns the parsed schedule; nil when the digest is disabled
+func (d *DigestConfig) Cron() *Schedule {
+	return d.cron
+}
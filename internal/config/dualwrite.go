@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pair modes
+const (
+	PairModeReplication = "replication" // the target replicates from the source
+	PairModeDualWrite   = "dual_write"  // the application writes to both databases
+)
+
+// DualWriteCheckInterval is the check interval of dual_write pairs without
+// their own check_interval
+const DualWriteCheckInterval = 15 * time.Second
+
+// DualWriteConfig tunes the checks of a pair in dual_write mode. With the
+// application writing to both databases there is no replication to measure:
+// replica lag is not checked, and checksums and exact row counts run every
+// check interval, counting per table how often the two databases diverged.
+type DualWriteConfig struct {
+	// Consecutive divergent checks of a table before an alert fires. A write
+	// lands on one database a moment before the other, so a check catching
+	// it in between is expected now and then.
+	AlertAfter int `yaml:"alert_after"` // defaults to 3
+}
+
+// DualWriteMode reports whether the application writes to both databases of
+// the pair
+func (p *DatabasePair) DualWriteMode() bool {
+	return p.Mode == PairModeDualWrite
+}
+
+// validateMode checks the pair's mode and the settings it rules out, and
+// applies the defaults of dual_write mode
+func (p *DatabasePair) validateMode() error {
+	switch p.Mode {
+	case "":
+		p.Mode = PairModeReplication
+	case PairModeReplication, PairModeDualWrite:
+	default:
+		return fmt.Errorf("mode must be '%s' or '%s'", PairModeReplication, PairModeDualWrite)
+	}
+	if !p.DualWriteMode() {
+		return nil
+	}
+
+	switch {
+	case len(p.Intermediates) > 0:
+		return fmt.Errorf("intermediates are not supported in dual_write mode")
+	case p.SemiSync.Enabled:
+		return fmt.Errorf("semi_sync is not supported in dual_write mode")
+	case p.ChecksumSchedule.QuietReplication():
+		return fmt.Errorf("checksum_schedule 'quiet_replication' is not supported in dual_write mode")
+	case p.ApproximateCounts.Enabled:
+		return fmt.Errorf("approximate_counts is not supported in dual_write mode, which compares exact row counts")
+	case len(p.FanOut.Replicas) > 0 && p.FanOut.Start != FanOutStartAlways:
+		return fmt.Errorf("fan_out.start must be '%s' in dual_write mode, where the target never cuts over", FanOutStartAlways)
+	}
+
+	if p.DualWrite.AlertAfter < 0 {
+		return fmt.Errorf("dual_write.alert_after cannot be negative")
+	}
+	if p.DualWrite.AlertAfter == 0 {
+		p.DualWrite.AlertAfter = 3
+	}
+	if p.CheckInterval == 0 {
+		p.CheckInterval = DualWriteCheckInterval
+	}
+	return nil
+}
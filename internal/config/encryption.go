@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EncryptionStatusConfig tracks the InnoDB tablespace encryption of both
+// databases: how many monitored tables and tablespaces are encrypted, and
+// when each monitored table finished encrypting on the target
+type EncryptionStatusConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // defaults to 5m
+}
+
+// validate checks the encryption status settings and applies defaults
+func (e *EncryptionStatusConfig) validate() error {
+	if !e.Enabled {
+		return nil
+	}
+	if e.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if e.Interval == 0 {
+		e.Interval = 5 * time.Minute
+	}
+	return nil
+}
+
+// TableDefinitionsConfig checks the table options in SHOW CREATE TABLE of the
+// monitored tables: the target's must encrypt them, and its ROW_FORMAT must
+// match the source's or the expected one
+type TableDefinitionsConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // defaults to 15m
+
+	// ENCRYPTION_KEY_ID the target's tables must use (MariaDB); any when 0
+	KeyID int `yaml:"key_id"`
+
+	// The source's tables must not be encrypted during the transition
+	SourceUnencrypted bool `yaml:"source_unencrypted"`
+
+	// ROW_FORMAT the target's tables must have, e.g. DYNAMIC when the
+	// source's COMPRESSED tables are rebuilt; the source's when empty
+	RowFormat string `yaml:"row_format"`
+}
+
+// validate checks the table definition settings and applies defaults
+func (t *TableDefinitionsConfig) validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	if t.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if t.Interval == 0 {
+		t.Interval = 15 * time.Minute
+	}
+	if t.KeyID < 0 {
+		return fmt.Errorf("key_id cannot be negative")
+	}
+	t.RowFormat = strings.ToUpper(t.RowFormat)
+	switch t.RowFormat {
+	case "", "DEFAULT", "DYNAMIC", "COMPACT", "REDUNDANT", "COMPRESSED", "FIXED", "PAGE":
+	default:
+		return fmt.Errorf("unknown row_format '%s'", t.RowFormat)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Aurora cluster endpoint roles of aurora_endpoint
+const (
+	AuroraWriter = "writer"
+	AuroraReader = "reader"
+)
+
+// hasEndpoint reports whether a database names where it is reached: a host
+// or its secret reference, a unix socket or a complete DSN
+func (d *DatabaseConfig) hasEndpoint() bool {
+	return d.Host != "" || d.HostFrom != "" || d.Socket != "" || d.DSN != ""
+}
+
+// usesSocket reports whether a database is reached through a unix socket
+func (d *DatabaseConfig) usesSocket() bool {
+	if d.DSN != "" {
+		cfg, err := mysql.ParseDSN(d.DSN)
+		return err == nil && cfg.Net == "unix"
+	}
+	return d.Socket != ""
+}
+
+// Address describes where a database is reached, for logs and hints and to
+// tell instances apart
+func (d *DatabaseConfig) Address() string {
+	switch {
+	case d.DSN != "":
+		if cfg, err := mysql.ParseDSN(d.DSN); err == nil {
+			return cfg.Addr
+		}
+		return "dsn"
+	case d.Socket != "":
+		return d.Socket
+	case d.Host == "" && d.HostFrom != "":
+		return d.HostFrom
+	default:
+		return d.Host + ":" + strconv.Itoa(d.Port)
+	}
+}
+
+// ClusterEndpoint reports whether a database is reached through an RDS
+// cluster endpoint, whose DNS name moves to another instance on failover:
+// aurora_endpoint is set, or the host is a cluster, reader or custom
+// endpoint such as mydb.cluster-abc123.eu-west-1.rds.amazonaws.com
+func (d *DatabaseConfig) ClusterEndpoint() bool {
+	if d.AuroraEndpoint != "" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(d.Address())
+	if err != nil {
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return strings.Contains(host, ".cluster-") &&
+		(strings.HasSuffix(host, ".rds.amazonaws.com") || strings.HasSuffix(host, ".rds.amazonaws.com.cn"))
+}
+
+// validateEndpoint checks that a database is reached in one way only
+func (d *DatabaseConfig) validateEndpoint() error {
+	if d.DSN != "" {
+		if d.hasHost() || d.Socket != "" || d.Port != 0 || d.Username != "" || d.UsernameFrom != "" ||
+			d.Password != "" || d.PasswordFrom != "" || d.Database != "" {
+			return fmt.Errorf("dsn replaces host, port, socket, username, password and database")
+		}
+		cfg, err := mysql.ParseDSN(d.DSN)
+		if err != nil {
+			return fmt.Errorf("dsn: %w", err)
+		}
+		if cfg.DBName == "" {
+			return fmt.Errorf("dsn: a database name is required")
+		}
+	}
+	if d.Socket != "" && d.hasHost() {
+		return fmt.Errorf("socket and host cannot both be set")
+	}
+
+	switch d.AuroraEndpoint {
+	case "", AuroraWriter, AuroraReader:
+	default:
+		return fmt.Errorf("aurora_endpoint must be '%s' or '%s'", AuroraWriter, AuroraReader)
+	}
+	return nil
+}
+
+// hasHost reports whether a database is reached by host name
+func (d *DatabaseConfig) hasHost() bool {
+	return d.Host != "" || d.HostFrom != ""
+}
+
+// paramNames returns the names of the DSN parameters of a database, from
+// params and from its dsn
+func (d *DatabaseConfig) paramNames() []string {
+	names := slices.Collect(maps.Keys(d.Params))
+	if d.DSN != "" {
+		if cfg, err := mysql.ParseDSN(d.DSN); err == nil {
+			names = slices.AppendSeq(names, maps.Keys(cfg.Params))
+		}
+	}
+	return names
+}
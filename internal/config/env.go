@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables that set configuration keys,
+// e.g. MONITOR_DATABASE_PAIRS_0_SOURCE_DB_HOST for database_pairs[0].source_db.host
+const EnvPrefix = "MONITOR_"
+
+// envKeyAliases are shorter names of keys in environment variables, e.g.
+// MONITOR_PAIRS_0_SOURCE_HOST
+var envKeyAliases = map[string]string{
+	"database_pairs": "pairs",
+	"source_db":      "source",
+	"target_db":      "target",
+}
+
+// LoadConfigFromEnv builds the configuration from MONITOR_ environment
+// variables alone, for containers without a mounted configuration file.
+// Settings changed through the API then last until the monitor restarts.
+func LoadConfigFromEnv() (*Config, error) {
+	var config Config
+	if err := config.load(os.Environ()); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// load applies the environment to a parsed configuration: MONITOR_ variables
+// first, then the legacy single-pair overrides, and validates the result
+func (config *Config) load(environ []string) error {
+	if err := applyEnv(config, environ); err != nil {
+		return err
+	}
+
+	// Convert legacy single database config to database pairs format
+	if config.SourceDB.Host != "" && len(config.DatabasePairs) == 0 {
+		config.DatabasePairs = []DatabasePair{
+			{
+				Name:            "default",
+				SourceDB:        config.SourceDB,
+				TargetDB:        config.TargetDB,
+				TablesToMonitor: config.TablesToMonitor,
+			},
+		}
+	}
+
+	// Apply environment variable overrides for legacy config
+	if host := os.Getenv("SOURCE_DB_HOST"); host != "" {
+		config.SourceDB.Host = host
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].SourceDB.Host = host
+		}
+	}
+	if user := os.Getenv("SOURCE_DB_USERNAME"); user != "" {
+		config.SourceDB.Username = user
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].SourceDB.Username = user
+		}
+	}
+	if pass := os.Getenv("SOURCE_DB_PASSWORD"); pass != "" {
+		config.SourceDB.Password = pass
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].SourceDB.Password = pass
+		}
+	}
+	if host := os.Getenv("TARGET_DB_HOST"); host != "" {
+		config.TargetDB.Host = host
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].TargetDB.Host = host
+		}
+	}
+	if user := os.Getenv("TARGET_DB_USERNAME"); user != "" {
+		config.TargetDB.Username = user
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].TargetDB.Username = user
+		}
+	}
+	if pass := os.Getenv("TARGET_DB_PASSWORD"); pass != "" {
+		config.TargetDB.Password = pass
+		if len(config.DatabasePairs) > 0 {
+			config.DatabasePairs[0].TargetDB.Password = pass
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+// applyEnv sets the keys named by MONITOR_ environment variables. The name
+// after the prefix is the key's path in upper case, with list indexes as
+// numbers; map entries take the rest of the name, lowercased, as their key.
+// A variable naming a list, map or section sets it from a YAML value, e.g.
+// MONITOR_PAIRS_0_TABLES_TO_MONITOR='[orders, customers]'. Variables that
+// name no key are listed by UnknownEnv rather than failing: Kubernetes sets
+// MONITOR_SERVICE_HOST and the like for a service named "monitor".
+func applyEnv(config *Config, environ []string) error {
+	// Sorted, so that list entries are set in order
+	sort.Strings(environ)
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		path, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok || path == "" {
+			continue
+		}
+		if err := setEnvKey(reflect.ValueOf(config).Elem(), strings.Split(path, "_"), value); err != nil {
+			if err == errNoSetting {
+				config.unknownEnv = append(config.unknownEnv, name)
+				continue
+			}
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// errNoSetting is returned for variable names that lead to no key
+var errNoSetting = errors.New("no such setting")
+
+// setEnvKey sets the key of v that tokens, the upper-case words of a variable
+// name, lead to
+func setEnvKey(v reflect.Value, tokens []string, value string) error {
+	if len(tokens) == 0 {
+		return decodeEnvValue(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setEnvKey(v.Elem(), tokens, value)
+
+	case reflect.Struct:
+		// The longest key matching the name wins; shorter ones are tried when
+		// the rest of the name does not fit it
+		for _, field := range envFields(v) {
+			if len(field.words) > len(tokens) || !slicesEqualFold(field.words, tokens[:len(field.words)]) {
+				continue
+			}
+			if err := setEnvKey(field.value, tokens[len(field.words):], value); err == nil {
+				return nil
+			} else if len(field.words) == len(tokens) {
+				return err
+			}
+		}
+		return errNoSetting
+
+	case reflect.Slice:
+		index, err := strconv.Atoi(tokens[0])
+		if err != nil || index < 0 {
+			return fmt.Errorf("%s is not a list index", tokens[0])
+		}
+		for v.Len() <= index {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+		return setEnvKey(v.Index(index), tokens[1:], value)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return errNoSetting
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		entry := reflect.New(v.Type().Elem()).Elem()
+		if err := decodeEnvValue(entry, value); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(strings.ToLower(strings.Join(tokens, "_"))).Convert(v.Type().Key()), entry)
+		return nil
+	}
+	return errNoSetting
+}
+
+// envField is a configuration key of a struct, as the words of its name
+type envField struct {
+	words []string
+	value reflect.Value
+}
+
+// envFields lists the keys of a struct by their yaml names and aliases,
+// including those of inlined structs, longest first
+func envFields(v reflect.Value) []envField {
+	var fields []envField
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(options, "inline") {
+			fields = append(fields, envFields(v.Field(i))...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields = append(fields, envField{strings.Split(name, "_"), v.Field(i)})
+		if alias, ok := envKeyAliases[name]; ok {
+			fields = append(fields, envField{strings.Split(alias, "_"), v.Field(i)})
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return len(fields[i].words) > len(fields[j].words) })
+	return fields
+}
+
+// decodeEnvValue sets a key from a variable's value. Strings are taken as
+// they are, so that passwords need no YAML quoting; other values are YAML.
+func decodeEnvValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.String {
+		node := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		return node.Decode(v.Addr().Interface())
+	}
+	if err := yaml.Unmarshal([]byte(value), v.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	return nil
+}
+
+// UnknownEnv lists the MONITOR_ environment variables that name no setting,
+// which are ignored; they are usually typos
+func (c *Config) UnknownEnv() []string {
+	return c.unknownEnv
+}
+
+// slicesEqualFold reports whether two lists of words are equal, ignoring case
+func slicesEqualFold(a, b []string) bool {
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
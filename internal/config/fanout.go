@@ -0,0 +1,175 @@
+package config
+
+import "fmt"
+
+// Fan-out start modes
+const (
+	FanOutStartCutOver = "cut_over" // replicas are monitored once the pair cuts over
+	FanOutStartAlways  = "always"
+)
+
+// FanOutConfig lists the read replicas of a pair's target. After cutover the
+// target is the new primary, and each replica is monitored as a pair of its
+// own from the target to the replica.
+type FanOutConfig struct {
+	Start    string          `yaml:"start"` // "cut_over" (default) or "always"
+	Replicas []ReplicaConfig `yaml:"replicas"`
+}
+
+// ReplicaConfig is a read replica of a pair's target
+type ReplicaConfig struct {
+	Name string         `yaml:"name"` // name of the replica's pair; unique among pairs
+	DB   DatabaseConfig `yaml:"db"`   // unset fields default to the target_db of the pair
+}
+
+// expandFanOut appends a pair from the target to each replica of every pair
+// with fan_out. Pairs expanded by an earlier call are kept as they are.
+func (c *Config) expandFanOut() error {
+	names := make(map[string]bool, len(c.DatabasePairs))
+	for _, pair := range c.DatabasePairs {
+		names[pair.Name] = true
+	}
+
+	for i := range c.DatabasePairs {
+		pair := &c.DatabasePairs[i]
+		if len(pair.FanOut.Replicas) == 0 {
+			continue
+		}
+		if pair.FanOut.Start == "" {
+			pair.FanOut.Start = FanOutStartCutOver
+		}
+		if pair.FanOut.Start != FanOutStartCutOver && pair.FanOut.Start != FanOutStartAlways {
+			return fmt.Errorf("database pair '%s': fan_out.start must be '%s' or '%s'", pair.Name, FanOutStartCutOver, FanOutStartAlways)
+		}
+
+		for _, replica := range pair.FanOut.Replicas {
+			if replica.Name == "" || !replica.DB.hasEndpoint() {
+				return fmt.Errorf("database pair '%s': fan_out replicas need a name and db.host, db.host_from, db.socket or db.dsn", pair.Name)
+			}
+			if names[replica.Name] {
+				if c.fanOutPair(replica.Name) == pair.Name {
+					continue
+				}
+				return fmt.Errorf("database pair '%s': fan_out replica name '%s' is already used by another pair", pair.Name, replica.Name)
+			}
+			names[replica.Name] = true
+			c.DatabasePairs = append(c.DatabasePairs, pair.fanOutPair(replica))
+			pair = &c.DatabasePairs[i] // append may have moved the pairs
+		}
+	}
+	return nil
+}
+
+// fanOutPair returns the pair a fan-out pair was expanded from, or ""
+func (c *Config) fanOutPair(name string) string {
+	for _, pair := range c.DatabasePairs {
+		if pair.Name == name {
+			return pair.FanOutOf
+		}
+	}
+	return ""
+}
+
+// fanOutPair builds the pair monitoring a replica of the target. Tables are
+// named as on the target, so table mappings are applied to the table list,
+// views and masking rules.
+func (p *DatabasePair) fanOutPair(replica ReplicaConfig) DatabasePair {
+	tables := make(TableList, len(p.TablesToMonitor))
+	for i, table := range p.TablesToMonitor {
+		if _, wildcard := tableWildcard(table); wildcard {
+			tables[i] = table
+		} else {
+			tables[i] = p.TableMappings.Target(table)
+		}
+	}
+
+	masking := MaskingConfig{HashKey: p.Masking.HashKey, Rules: make([]MaskingRule, len(p.Masking.Rules))}
+	for i, rule := range p.Masking.Rules {
+		if rule.Table != "" {
+			rule.Table = p.TableMappings.Target(rule.Table)
+		}
+		masking.Rules[i] = rule
+	}
+
+	views := make(TableList, len(p.Views))
+	for i, view := range p.Views {
+		views[i] = p.TableMappings.Target(view)
+	}
+
+	windows := p.ConsistencyWindows
+	if len(windows.Tables) > 0 {
+		windows.Tables = make(map[string]string, len(p.ConsistencyWindows.Tables))
+		for table, column := range p.ConsistencyWindows.Tables {
+			windows.Tables[p.TableMappings.Target(table)] = column
+		}
+	}
+
+	// The source of a fan-out pair is the encrypted target
+	definitions := p.TableDefinitions
+	definitions.SourceUnencrypted = false
+
+	return DatabasePair{
+		Name:               replica.Name,
+		SourceDB:           p.TargetDB,
+		TargetDB:           replica.DB.inherit(p.TargetDB),
+		TablesToMonitor:    tables,
+		SSHTunnel:          p.SSHTunnel,
+		TableDiscovery:     p.TableDiscovery,
+		ApproximateCounts:  p.ApproximateCounts,
+		ConsistencyWindows: windows,
+		ChecksumPreflight:  p.ChecksumPreflight,
+		Views:              views,
+		Thresholds:         p.Thresholds,
+		CheckInterval:      p.CheckInterval,
+		LagAnomaly:         p.LagAnomaly,
+		EncryptionStatus:   p.EncryptionStatus,
+		TableDefinitions:   definitions,
+		MaintenanceWindows: p.MaintenanceWindows,
+		Masking:            masking,
+		Metadata:           p.Metadata,
+		FanOutOf:           p.Name,
+		WaitForCutOver:     p.FanOut.Start == FanOutStartCutOver,
+	}
+}
+
+// inherit returns the database with unset fields taken from base
+func (d DatabaseConfig) inherit(base DatabaseConfig) DatabaseConfig {
+	// A DSN names the whole connection; only driver options are inherited
+	if d.DSN == "" {
+		if d.Port == 0 && d.Socket == "" {
+			d.Port = base.Port
+		}
+		if d.Username == "" && d.UsernameFrom == "" {
+			d.Username, d.Password = base.Username, base.Password
+			d.UsernameFrom, d.PasswordFrom = base.UsernameFrom, base.PasswordFrom
+		}
+		if d.Database == "" {
+			d.Database = base.Database
+		}
+	}
+	if d.Timeout == 0 {
+		d.Timeout = base.Timeout
+	}
+	if d.ReadTimeout == 0 {
+		d.ReadTimeout = base.ReadTimeout
+	}
+	if d.Charset == "" {
+		d.Charset = base.Charset
+	}
+	if d.Collation == "" {
+		d.Collation = base.Collation
+	}
+	if d.Loc == "" {
+		d.Loc = base.Loc
+	}
+	if d.TimeZone == "" {
+		d.TimeZone = base.TimeZone
+	}
+	if !d.InterpolateParams {
+		d.InterpolateParams = base.InterpolateParams
+	}
+	if d.Params == nil {
+		d.Params = base.Params
+	}
+	return d
+}
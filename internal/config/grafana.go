@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// GrafanaAnnotationTag marks every annotation pushed to Grafana; dashboards
+// generated by /api/grafana/dashboard show annotations with this tag
+const GrafanaAnnotationTag = "mariadb-monitor"
+
+// GrafanaConfig pushes alert events to Grafana as annotations, so that
+// graphs carry a marker where an alert fired, spanning until it resolved
+type GrafanaConfig struct {
+	Name         string        `yaml:"name"`
+	URL          string        `yaml:"url"`           // base URL of Grafana
+	APIToken     string        `yaml:"api_token"`     // service account token allowed to write annotations
+	DashboardUID string        `yaml:"dashboard_uid"` // empty means organization-wide annotations
+	Tags         []string      `yaml:"tags"`          // added to the mariadb-monitor, pair, type and severity tags
+	Timeout      time.Duration `yaml:"timeout"`
+	AlertRoute   `yaml:",inline"`
+}
+
+// validate checks Grafana settings and applies defaults
+func (g *GrafanaConfig) validate(pairs []DatabasePair) error {
+	if g.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if g.URL == "" || g.APIToken == "" {
+		return fmt.Errorf("grafana '%s': url and api_token are required", g.Name)
+	}
+	if g.Timeout < 0 {
+		return fmt.Errorf("grafana '%s': timeout cannot be negative", g.Name)
+	}
+	if g.Timeout == 0 {
+		g.Timeout = 10 * time.Second
+	}
+	if err := g.AlertRoute.validate(pairs); err != nil {
+		return fmt.Errorf("grafana '%s': %w", g.Name, err)
+	}
+	return nil
+}
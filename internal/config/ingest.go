@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AlertIngestionConfig accepts Prometheus Alertmanager webhook payloads at
+// POST /api/ingest/alertmanager, so that infrastructure alerts about the
+// databases of a pair appear next to the monitor's own alerts
+type AlertIngestionConfig struct {
+	Enabled   bool           `yaml:"enabled"`
+	PairLabel string         `yaml:"pair_label"` // label whose value is a pair name; default "pair"
+	Mappings  []LabelMapping `yaml:"mappings"`   // tried in order before pair_label
+}
+
+// LabelMapping assigns alerts whose label has a value to a pair, e.g.
+// dbinstance_identifier "prod-db-1" to "production-db"
+type LabelMapping struct {
+	Label string `yaml:"label"`
+	Value string `yaml:"value"`
+	Pair  string `yaml:"pair"`
+}
+
+// validate applies defaults and checks that mappings name known pairs
+func (c *AlertIngestionConfig) validate(pairs []DatabasePair) error {
+	if c.PairLabel == "" {
+		c.PairLabel = "pair"
+	}
+	for i, m := range c.Mappings {
+		if m.Label == "" || m.Value == "" || m.Pair == "" {
+			return fmt.Errorf("mapping %d: label, value and pair are required", i)
+		}
+		if !slices.ContainsFunc(pairs, func(p DatabasePair) bool { return p.Name == m.Pair }) {
+			return fmt.Errorf("mapping %d: database pair '%s' not found", i, m.Pair)
+		}
+	}
+	return nil
+}
+
+// IngestedAlertPair returns the pair an ingested alert belongs to, by its labels
+func (c *Config) IngestedAlertPair(labels map[string]string) (string, bool) {
+	for _, m := range c.AlertIngestion.Mappings {
+		if labels[m.Label] == m.Value {
+			return m.Pair, true
+		}
+	}
+	name := labels[c.AlertIngestion.PairLabel]
+	if _, ok := c.PairSettings(name); ok {
+		return name, true
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownFields lists the keys of a configuration file that no setting
+// reads, e.g. "line 12: field check_intervall not found in type
+// config.DatabasePair". LoadConfig ignores them, so they are usually typos.
+func UnknownFields(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var config Config
+	err = dec.Decode(&config)
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil, nil // other errors are reported by LoadConfig
+	}
+
+	var unknown []string
+	for _, msg := range typeErr.Errors {
+		if strings.Contains(msg, " not found in type ") {
+			unknown = append(unknown, msg)
+		}
+	}
+	return unknown, nil
+}
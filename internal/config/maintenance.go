@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // window timezones must resolve in minimal container images
+)
+
+// MaintenanceWindow is a period during which a pair's checks still run but
+// its new alerts are suppressed. A window is either recurring (schedule and
+// duration) or a one-off range (start and end).
+type MaintenanceWindow struct {
+	Name     string        `yaml:"name"`
+	Schedule string        `yaml:"schedule"` // cron expression for the window start: minute hour day-of-month month day-of-week
+	Duration time.Duration `yaml:"duration"`
+	Timezone string        `yaml:"timezone"` // IANA name for the schedule; defaults to UTC
+	Start    time.Time     `yaml:"start"`    // RFC3339
+	End      time.Time     `yaml:"end"`      // RFC3339
+
+	cron     *cronSchedule
+	location *time.Location
+}
+
+// maxWindowDuration bounds recurring windows so the active check stays cheap
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// validate checks a maintenance window and parses its schedule
+func (w *MaintenanceWindow) validate(index int) error {
+	if w.Name == "" {
+		w.Name = fmt.Sprintf("window %d", index+1)
+	}
+
+	recurring := w.Schedule != ""
+	oneOff := !w.Start.IsZero() || !w.End.IsZero()
+	switch {
+	case recurring && oneOff:
+		return fmt.Errorf("window '%s': use either schedule and duration or start and end", w.Name)
+	case recurring:
+		cron, err := parseCron(w.Schedule)
+		if err != nil {
+			return fmt.Errorf("window '%s': invalid schedule: %w", w.Name, err)
+		}
+		if w.Duration <= 0 || w.Duration > maxWindowDuration {
+			return fmt.Errorf("window '%s': duration must be positive and at most 7 days", w.Name)
+		}
+		location, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return fmt.Errorf("window '%s': invalid timezone: %w", w.Name, err)
+		}
+		w.cron, w.location = cron, location
+	case oneOff:
+		if w.Start.IsZero() || w.End.IsZero() || !w.End.After(w.Start) {
+			return fmt.Errorf("window '%s': start and end are required and end must be after start", w.Name)
+		}
+	default:
+		return fmt.Errorf("window '%s': schedule or start and end is required", w.Name)
+	}
+	return nil
+}
+
+// Active reports whether the window covers a point in time
+func (w *MaintenanceWindow) Active(at time.Time) bool {
+	if w.cron == nil {
+		return !at.Before(w.Start) && at.Before(w.End)
+	}
+
+	// Look for a scheduled start within the last duration
+	local := at.In(w.location)
+	for start := local.Truncate(time.Minute); local.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.cron.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveMaintenanceWindow returns the name of the maintenance window that
+// covers a pair at a point in time, if any
+func (c *Config) ActiveMaintenanceWindow(pairName string, at time.Time) (string, bool) {
+	c.pairsMu.RLock()
+	defer c.pairsMu.RUnlock()
+
+	for i := range c.DatabasePairs {
+		if c.DatabasePairs[i].Name != pairName {
+			continue
+		}
+		for j := range c.DatabasePairs[i].MaintenanceWindows {
+			window := &c.DatabasePairs[i].MaintenanceWindows[j]
+			if window.Active(at) {
+				return window.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// cronSchedule is a parsed five-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	domAny, dowAny                bool
+}
+
+// parseCron parses "minute hour day-of-month month day-of-week". Fields
+// accept *, values, ranges (a-b), lists (a,b) and steps (*/n, a-b/n).
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%s): %w", i+1, field, err)
+		}
+		sets[i] = set
+	}
+
+	// Both 0 and 7 mean Sunday
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField expands one cron field into the set of values it matches
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step '%s'", stepPart)
+			}
+			step = n
+		}
+
+		low, high := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid value '%s'", from)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid value '%s'", to)
+				}
+			} else if hasStep {
+				high = max // a/n means from a to the end of the range
+			}
+		}
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("'%s' is outside %d-%d", part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// matches reports whether a minute matches the schedule
+func (cs *cronSchedule) matches(t time.Time) bool {
+	return cs.minute[t.Minute()] && cs.hour[t.Hour()] && cs.month[int(t.Month())] && cs.dayMatches(t)
+}
+
+// dayMatches reports whether a day matches the schedule. As in cron, when
+// both day-of-month and day-of-week are restricted, either may match.
+func (cs *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := cs.dom[t.Day()]
+	dowMatch := cs.dow[int(t.Weekday())]
+	switch {
+	case cs.domAny && cs.dowAny:
+		return true
+	case cs.domAny:
+		return dowMatch
+	case cs.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
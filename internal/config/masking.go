@@ -0,0 +1,70 @@
+package config
+
+import "fmt"
+
+// Masking methods
+const (
+	MaskFull    = "full"    // replace the whole value
+	MaskPartial = "partial" // keep only the last characters
+	MaskHash    = "hash"    // keyed hash; equal values still compare equal
+)
+
+// MaskingConfig masks column values before they leave the monitor, e.g. in
+// row diffs shown in the dashboard, API responses and the diff command
+type MaskingConfig struct {
+	HashKey string        `yaml:"hash_key"` // HMAC key of hash masking; required by hash rules
+	Rules   []MaskingRule `yaml:"rules"`
+}
+
+// MaskingRule masks columns of a table, or of every table when Table is empty
+type MaskingRule struct {
+	Table   string   `yaml:"table"`
+	Columns []string `yaml:"columns"`
+	Method  string   `yaml:"method"` // "full", "partial" or "hash"
+	Keep    int      `yaml:"keep"`   // characters kept by partial masking; defaults to 4
+}
+
+// validate checks the masking rules and applies defaults
+func (m *MaskingConfig) validate() error {
+	for i := range m.Rules {
+		rule := &m.Rules[i]
+		if len(rule.Columns) == 0 {
+			return fmt.Errorf("rule %d: at least one column is required", i)
+		}
+		switch rule.Method {
+		case MaskFull:
+		case MaskHash:
+			// Unkeyed hashes of short values such as card numbers are
+			// reversed by hashing every candidate
+			if m.HashKey == "" {
+				return fmt.Errorf("rule %d: hash_key is required by hash masking", i)
+			}
+		case MaskPartial:
+			if rule.Keep < 0 {
+				return fmt.Errorf("rule %d: keep cannot be negative", i)
+			}
+			if rule.Keep == 0 {
+				rule.Keep = 4
+			}
+		default:
+			return fmt.Errorf("rule %d: method must be '%s', '%s' or '%s'", i, MaskFull, MaskPartial, MaskHash)
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule masking a column of a table, or nil. The first
+// matching rule wins.
+func (m MaskingConfig) Rule(table, column string) *MaskingRule {
+	for i, rule := range m.Rules {
+		if rule.Table != "" && rule.Table != table {
+			continue
+		}
+		for _, c := range rule.Columns {
+			if c == column {
+				return &m.Rules[i]
+			}
+		}
+	}
+	return nil
+}
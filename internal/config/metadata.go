@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// PairMetadata tells responders who owns a pair and where its migration plan
+// lives. It is shown on the dashboard and attached to every alert of the pair.
+type PairMetadata struct {
+	Owner       string `yaml:"owner" json:"owner,omitempty"`             // team or person responsible for the database
+	RunbookURL  string `yaml:"runbook_url" json:"runbook_url,omitempty"` // http(s) link to the runbook or migration plan
+	Ticket      string `yaml:"ticket" json:"ticket,omitempty"`           // change or migration ticket ID
+	Description string `yaml:"description" json:"description,omitempty"`
+}
+
+// IsZero reports whether no metadata is set
+func (m PairMetadata) IsZero() bool {
+	return m == PairMetadata{}
+}
+
+// validate checks the runbook URL
+func (m PairMetadata) validate() error {
+	if m.RunbookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(m.RunbookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("runbook_url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// PairMetadata returns the metadata of a database pair
+func (c *Config) PairMetadata(pairName string) PairMetadata {
+	c.pairsMu.RLock()
+	defer c.pairsMu.RUnlock()
+
+	for i := range c.DatabasePairs {
+		if c.DatabasePairs[i].Name == pairName {
+			return c.DatabasePairs[i].Metadata
+		}
+	}
+	return PairMetadata{}
+}
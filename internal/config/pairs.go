@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pairs returns the database pairs, including those added at runtime
+func (c *Config) Pairs() []DatabasePair {
+	c.pairsMu.RLock()
+	defer c.pairsMu.RUnlock()
+	return slices.Clone(c.DatabasePairs)
+}
+
+// AddPair validates a database pair given as its database_pairs entry, in
+// YAML or JSON, appends the entry to the configuration file and adds the
+// pair. A configuration from the environment alone has no file; the pair
+// then lasts until a restart. Pairs added at runtime cannot fan out or read
+// their settings from secrets, which are set up at startup.
+func (c *Config) AddPair(entry []byte) (DatabasePair, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(entry, &doc); err != nil {
+		return DatabasePair{}, fmt.Errorf("invalid database pair: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return DatabasePair{}, fmt.Errorf("invalid database pair: expected a mapping of its settings")
+	}
+
+	var pair DatabasePair
+	decoder := yaml.NewDecoder(bytes.NewReader(entry))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pair); err != nil {
+		return DatabasePair{}, fmt.Errorf("invalid database pair: %w", err)
+	}
+	if pair.Name == "" {
+		return DatabasePair{}, fmt.Errorf("database pair: name is required")
+	}
+	if len(pair.FanOut.Replicas) > 0 {
+		return DatabasePair{}, fmt.Errorf("database pair '%s': fan_out is only supported in the configuration file", pair.Name)
+	}
+	for _, db := range pair.Databases() {
+		if db.HostFrom != "" || db.UsernameFrom != "" || db.PasswordFrom != "" {
+			return DatabasePair{}, fmt.Errorf("database pair '%s': host_from, username_from and password_from are only supported in the configuration file", pair.Name)
+		}
+	}
+	if err := c.validatePair(&pair); err != nil {
+		return DatabasePair{}, err
+	}
+
+	c.pairsMu.Lock()
+	defer c.pairsMu.Unlock()
+
+	if _, exists := c.pairSettings[pair.Name]; exists {
+		return DatabasePair{}, fmt.Errorf("database pair '%s' already exists", pair.Name)
+	}
+	if c.path != "" {
+		if err := c.persistAddedPair(doc.Content[0]); err != nil {
+			return DatabasePair{}, fmt.Errorf("failed to persist database pair: %w", err)
+		}
+	}
+
+	c.DatabasePairs = append(slices.Clip(c.DatabasePairs), pair)
+	c.pairSettings[pair.Name] = pair.settings()
+	return pair, nil
+}
+
+// RemovePair removes a database pair and its entry in the configuration
+// file. Pairs with fan_out, and the pairs they expand to, are only removed
+// through the configuration file.
+func (c *Config) RemovePair(pairName string) (DatabasePair, error) {
+	c.pairsMu.Lock()
+	defer c.pairsMu.Unlock()
+
+	i := slices.IndexFunc(c.DatabasePairs, func(pair DatabasePair) bool { return pair.Name == pairName })
+	if i < 0 {
+		return DatabasePair{}, fmt.Errorf("database pair '%s' not found", pairName)
+	}
+	pair := c.DatabasePairs[i]
+	if pair.FanOutOf != "" {
+		return DatabasePair{}, fmt.Errorf("database pair '%s' is a fan_out replica of pair '%s' and is only removed through the configuration file", pairName, pair.FanOutOf)
+	}
+	if len(pair.FanOut.Replicas) > 0 {
+		return DatabasePair{}, fmt.Errorf("database pair '%s' has fan_out replicas and is only removed through the configuration file", pairName)
+	}
+
+	if c.path != "" {
+		if err := c.persistRemovedPair(pairName); err != nil {
+			return DatabasePair{}, fmt.Errorf("failed to persist removal: %w", err)
+		}
+	}
+
+	c.DatabasePairs = slices.Delete(slices.Clone(c.DatabasePairs), i, i+1)
+	delete(c.pairSettings, pairName)
+	return pair, nil
+}
+
+// persistAddedPair appends a pair's entry to database_pairs in the
+// configuration file, leaving the rest of the file untouched
+func (c *Config) persistAddedPair(entry *yaml.Node) error {
+	doc, err := readYAMLFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	root := doc.Content[0]
+	pairs := mappingValue(root, "database_pairs")
+	if pairs == nil {
+		// The top-level pair would no longer be read once database_pairs is set
+		if mappingValue(root, "source_db") != nil {
+			return fmt.Errorf("%s configures its pair with top-level source_db and target_db; move it under database_pairs first", c.path)
+		}
+		pairs = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingValue(root, "database_pairs", pairs)
+	}
+	if pairs.Kind != yaml.SequenceNode {
+		return fmt.Errorf("database_pairs in %s is not a list", c.path)
+	}
+
+	blockStyle(entry)
+	pairs.Content = append(pairs.Content, entry)
+	return writeYAMLFile(c.path, doc)
+}
+
+// persistRemovedPair removes a pair's entry from database_pairs in the
+// configuration file
+func (c *Config) persistRemovedPair(pairName string) error {
+	doc, err := readYAMLFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	pairNode := findPairNode(doc.Content[0], pairName)
+	if pairNode == nil {
+		return fmt.Errorf("database pair '%s' is not listed under database_pairs in %s", pairName, c.path)
+	}
+	pairs := mappingValue(doc.Content[0], "database_pairs")
+	pairs.Content = slices.DeleteFunc(pairs.Content, func(node *yaml.Node) bool { return node == pairNode })
+	return writeYAMLFile(c.path, doc)
+}
+
+// blockStyle writes an entry given as JSON in the block style of the rest of
+// the file. Scalars lose their quotes, which the encoder keeps where a string
+// would otherwise read as another type.
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// PTChecksumConfig reads the results table of percona-toolkit's
+// pt-table-checksum, so its per-chunk differences show up next to the
+// monitor's own checks. pt-table-checksum writes each chunk's checksum on
+// the source and replicates the statement, so differences are only visible
+// on the replica: read_from defaults to the target.
+type PTChecksumConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Table    string        `yaml:"table"`     // --replicate table; defaults to percona.checksums
+	ReadFrom string        `yaml:"read_from"` // "target" (default) or "source"
+	Interval time.Duration `yaml:"interval"`  // defaults to 5m
+	MaxDiffs int           `yaml:"max_diffs"` // differing chunks kept per table; defaults to 20
+}
+
+// ptChecksumTablePattern matches a table name, optionally qualified by its schema
+var ptChecksumTablePattern = regexp.MustCompile(`^[A-Za-z0-9_$]+(\.[A-Za-z0-9_$]+)?$`)
+
+// validate checks the pt-table-checksum settings and applies defaults
+func (p *PTChecksumConfig) validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.Table == "" {
+		p.Table = "percona.checksums"
+	}
+	if !ptChecksumTablePattern.MatchString(p.Table) {
+		return fmt.Errorf("table must be a table name such as percona.checksums")
+	}
+	switch p.ReadFrom {
+	case "":
+		p.ReadFrom = "target"
+	case "source", "target":
+	default:
+		return fmt.Errorf("read_from must be 'source' or 'target'")
+	}
+	if p.Interval < 0 || p.MaxDiffs < 0 {
+		return fmt.Errorf("interval and max_diffs cannot be negative")
+	}
+	if p.Interval == 0 {
+		p.Interval = 5 * time.Minute
+	}
+	if p.MaxDiffs == 0 {
+		p.MaxDiffs = 20
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// readOnlyOverrides are DSN parameters that would make a read-only session
+// writable again
+var readOnlyOverrides = []string{"transaction_read_only", "tx_read_only"}
+
+// applyReadOnly marks every database of a pair for read-only sessions when
+// read_only is set. Settings that would let the monitor write are rejected;
+// features that write to the databases must be refused here as well.
+func (p *DatabasePair) applyReadOnly(readOnly bool) error {
+	for _, db := range p.Databases() {
+		db.ReadOnlySession = readOnly
+		if !readOnly {
+			continue
+		}
+		for _, name := range db.paramNames() {
+			if slices.Contains(readOnlyOverrides, strings.ToLower(name)) {
+				return fmt.Errorf("database %s: params.%s cannot be set", db.Address(), name)
+			}
+		}
+	}
+	return nil
+}
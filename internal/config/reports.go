@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReportsConfig sets up the summary reports of each pair over a calendar day
+// or a Monday-to-Sunday week, and posts them on a schedule to the webhooks
+// listing the sla_report event
+type ReportsConfig struct {
+	Timezone  string        `yaml:"timezone"`  // IANA name days start in; defaults to UTC
+	Retention time.Duration `yaml:"retention"` // how long daily aggregates are kept; defaults to 35 days
+	Schedule  string        `yaml:"schedule"`  // cron expression; no report is posted when empty
+	Period    string        `yaml:"period"`    // "day" (default) or "week"; the last complete one is posted
+
+	cron     *Schedule
+	location *time.Location
+}
+
+// validate applies defaults and parses the schedule
+func (r *ReportsConfig) validate() error {
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	r.location = location
+
+	if r.Retention == 0 {
+		r.Retention = 35 * 24 * time.Hour
+	}
+	if r.Retention < 8*24*time.Hour {
+		return fmt.Errorf("retention must be at least 8 days to cover the last complete week")
+	}
+
+	switch r.Period {
+	case "":
+		r.Period = "day"
+	case "day", "week":
+	default:
+		return fmt.Errorf("period must be 'day' or 'week', got '%s'", r.Period)
+	}
+
+	if r.Schedule != "" {
+		if r.cron, err = parseSchedule(r.Schedule, location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Location returns the timezone days start in
+func (r *ReportsConfig) Location() *time.Location {
+	if r.location == nil {
+		return time.UTC
+	}
+	return r.location
+}
+
+// Cron returns the parsed schedule; nil when no report is posted
+func (r *ReportsConfig) Cron() *Schedule {
+	return r.cron
+}
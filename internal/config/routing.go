@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// NotifierRoute sends the alerts it matches to the listed notifiers, e.g. the
+// alerts of the payments pair to the payments team's channel. Notifiers
+// listed in no route receive every alert.
+type NotifierRoute struct {
+	// Empty lists match everything
+	Pairs      []string `yaml:"pairs"`
+	Tables     []string `yaml:"tables"`
+	Types      []string `yaml:"types"`      // e.g. replication_stopped
+	Severities []string `yaml:"severities"` // CRITICAL, WARNING or INFO
+
+	Notifiers []string `yaml:"notifiers"` // names of webhook, alertmanager, telegram, teams or grafana notifiers
+
+	// Routes are tried in order and the first match wins; with continue,
+	// later routes are tried too
+	Continue bool `yaml:"continue"`
+}
+
+// Matches reports whether the route applies to an alert
+func (r NotifierRoute) Matches(pairName, tableName, alertType, severity string) bool {
+	return matchesAny(r.Pairs, pairName) && matchesAny(r.Tables, tableName) &&
+		matchesAny(r.Types, alertType) && matchesAny(r.Severities, severity)
+}
+
+// matchesAny reports whether a value is listed, or nothing is
+func matchesAny(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
+
+// validateRoutes checks that routes name existing pairs and notifiers, and
+// that routed notifiers have unique names
+func (n *NotifiersConfig) validateRoutes(pairs []DatabasePair) error {
+	if len(n.Routes) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, w := range n.Webhooks {
+		names = append(names, w.Name)
+	}
+	for _, a := range n.Alertmanager {
+		names = append(names, a.Name)
+	}
+	for _, t := range n.Telegram {
+		names = append(names, t.Name)
+	}
+	for _, t := range n.Teams {
+		names = append(names, t.Name)
+	}
+	for _, g := range n.Grafana {
+		names = append(names, g.Name)
+	}
+
+	for i, route := range n.Routes {
+		if len(route.Notifiers) == 0 {
+			return fmt.Errorf("routes[%d]: notifiers is required", i)
+		}
+		for _, name := range route.Notifiers {
+			count := 0
+			for _, other := range names {
+				if other == name {
+					count++
+				}
+			}
+			switch {
+			case count == 0:
+				return fmt.Errorf("routes[%d]: unknown notifier '%s'", i, name)
+			case count > 1:
+				return fmt.Errorf("routes[%d]: %d notifiers are named '%s'", i, count, name)
+			}
+		}
+		for _, name := range route.Pairs {
+			if !slices.ContainsFunc(pairs, func(p DatabasePair) bool { return p.Name == name }) {
+				return fmt.Errorf("routes[%d]: unknown database pair '%s' in pairs", i, name)
+			}
+		}
+		for _, severity := range route.Severities {
+			if severity != "CRITICAL" && severity != "WARNING" && severity != "INFO" {
+				return fmt.Errorf("routes[%d]: severities must be 'CRITICAL', 'WARNING' or 'INFO'", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxScheduleSearch bounds how far ahead Next looks for a matching minute;
+// an expression such as "0 0 30 2 *" never fires
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule is a cron expression evaluated in a timezone
+type Schedule struct {
+	cron     *cronSchedule
+	location *time.Location
+}
+
+// parseSchedule parses a cron expression for a timezone
+func parseSchedule(expr string, location *time.Location) (*Schedule, error) {
+	cron, err := parseCron(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+	return &Schedule{cron: cron, location: location}, nil
+}
+
+// Next returns the first minute the schedule fires in after a point in
+// time, or the zero time when it never fires. A nil Schedule never fires.
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !s.cron.month[int(month)]:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, s.location)
+		case !s.cron.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, s.location)
+		case !s.cron.hour[t.Hour()]:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, s.location)
+		case !s.cron.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		expr     string
+		location *time.Location
+		after    string
+		want     string // empty when the schedule never fires
+	}{
+		{"later the same day", "0 9 * * *", time.UTC, "2026-03-02T08:15:00Z", "2026-03-02T09:00:00Z"},
+		{"next day", "0 9 * * *", time.UTC, "2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z"},
+		{"within the minute", "0 9 * * *", time.UTC, "2026-03-02T08:59:59Z", "2026-03-02T09:00:00Z"},
+		{"every 15 minutes", "*/15 * * * *", time.UTC, "2026-03-02T08:16:00Z", "2026-03-02T08:30:00Z"},
+		{"hourly across midnight", "0 * * * *", time.UTC, "2026-12-31T23:30:00Z", "2027-01-01T00:00:00Z"},
+		{"next Monday", "0 6 * * 1", time.UTC, "2026-03-04T12:00:00Z", "2026-03-09T06:00:00Z"},
+		{"day of month or of week", "0 0 13 * 5", time.UTC, "2026-03-01T00:00:00Z", "2026-03-06T00:00:00Z"},
+		{"leap day", "0 0 29 2 *", time.UTC, "2026-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"timezone", "0 9 * * *", berlin, "2026-07-01T06:00:00Z", "2026-07-01T07:00:00Z"},
+		{"skipped by daylight saving time", "30 2 * * *", berlin, "2026-03-28T12:00:00Z", "2026-03-30T00:30:00Z"},
+		{"never", "0 0 30 2 *", time.UTC, "2026-03-01T00:00:00Z", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSchedule(tt.expr, tt.location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, _ := time.Parse(time.RFC3339, tt.after)
+			got := s.Next(after)
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("Next(%s) = %s, want never", tt.after, got)
+				}
+				return
+			}
+			want, _ := time.Parse(time.RFC3339, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got.UTC().Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Secret reference services
+const (
+	SecretsManager = "secretsmanager"
+	SSMParameter   = "ssm"
+)
+
+// SecretsConfig configures how host_from, username_from and password_from
+// references of the databases are resolved
+type SecretsConfig struct {
+	Region          string        `yaml:"region"`           // used when a reference is not an ARN; defaults to the region of the AWS environment
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // how often references are resolved again to pick up rotation
+	Timeout         time.Duration `yaml:"timeout"`
+}
+
+// SecretRef is a parsed reference to a Secrets Manager secret or SSM
+// parameter, e.g.
+//
+//	arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-AbCdEf#password
+//	arn:aws:ssm:eu-west-1:123456789012:parameter/prod/db/password
+//	secretsmanager:prod/db#username
+//	ssm:/prod/db/host
+//
+// Key selects a field of a JSON secret value.
+type SecretRef struct {
+	Service string // SecretsManager or SSMParameter
+	Region  string // region of an ARN reference, otherwise empty
+	ID      string // secret ID or ARN, or parameter name
+	Key     string
+}
+
+// ParseSecretRef parses a secret reference
+func ParseSecretRef(ref string) (SecretRef, error) {
+	value, key, _ := strings.Cut(ref, "#")
+
+	if strings.HasPrefix(value, "arn:") {
+		parts := strings.SplitN(value, ":", 6)
+		if len(parts) != 6 || parts[3] == "" || parts[5] == "" {
+			return SecretRef{}, fmt.Errorf("invalid ARN '%s'", value)
+		}
+		switch parts[2] {
+		case SecretsManager:
+			return SecretRef{Service: SecretsManager, Region: parts[3], ID: value, Key: key}, nil
+		case SSMParameter:
+			name, ok := strings.CutPrefix(parts[5], "parameter")
+			if !ok || !strings.HasPrefix(name, "/") || name == "/" {
+				return SecretRef{}, fmt.Errorf("ARN '%s' is not an SSM parameter", value)
+			}
+			// Names of parameters outside a hierarchy have no leading slash
+			if strings.Count(name, "/") == 1 {
+				name = name[1:]
+			}
+			return SecretRef{Service: SSMParameter, Region: parts[3], ID: name, Key: key}, nil
+		default:
+			return SecretRef{}, fmt.Errorf("ARN '%s' is not a Secrets Manager secret or SSM parameter", value)
+		}
+	}
+
+	service, id, ok := strings.Cut(value, ":")
+	if !ok || id == "" || (service != SecretsManager && service != SSMParameter) {
+		return SecretRef{}, fmt.Errorf("'%s' must be an ARN or start with '%s:' or '%s:'", value, SecretsManager, SSMParameter)
+	}
+	return SecretRef{Service: service, ID: id, Key: key}, nil
+}
+
+// HasSecretRefs reports whether any database reads its settings from a secret
+func (c *Config) HasSecretRefs() bool {
+	for _, pair := range c.DatabasePairs {
+		for _, db := range pair.Databases() {
+			if db.HostFrom != "" || db.UsernameFrom != "" || db.PasswordFrom != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate applies defaults to the secrets settings
+func (s *SecretsConfig) validate() error {
+	if s.RefreshInterval == 0 {
+		s.RefreshInterval = time.Hour
+	}
+	if s.RefreshInterval < 0 {
+		return fmt.Errorf("refresh_interval cannot be negative")
+	}
+	if s.Timeout == 0 {
+		s.Timeout = 10 * time.Second
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	return nil
+}
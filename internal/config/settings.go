@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PairSettings holds the settings of a database pair that can be tuned at
+// runtime without a restart
+type PairSettings struct {
+	Thresholds       ThresholdsConfig // per-pair overrides of the global thresholds
+	TolerancePercent float64          // approximate_counts.tolerance_percent
+	CheckInterval    time.Duration    // zero means monitoring_interval
+}
+
+// settings returns the tunable settings configured for the pair
+func (p *DatabasePair) settings() PairSettings {
+	return PairSettings{
+		Thresholds:       p.Thresholds,
+		TolerancePercent: p.ApproximateCounts.TolerancePercent,
+		CheckInterval:    p.CheckInterval,
+	}
+}
+
+// validate checks that the settings are usable
+func (s PairSettings) validate() error {
+	if s.CheckInterval != 0 && s.CheckInterval < 10*time.Second {
+		return fmt.Errorf("check_interval must be at least 10 seconds")
+	}
+	if s.TolerancePercent < 0 || s.TolerancePercent > 100 {
+		return fmt.Errorf("approximate_counts.tolerance_percent must be between 0 and 100")
+	}
+
+	t := s.Thresholds
+	if t.ReplicaLag.WarningAt < 0 || t.ReplicaLag.CriticalAt < 0 ||
+		t.ClockSkew.WarningAt < 0 || t.ClockSkew.CriticalAt < 0 ||
+		t.RowCountDrift.WarningAt < 0 || t.RowCountDrift.CriticalAt < 0 {
+		return fmt.Errorf("thresholds cannot be negative")
+	}
+	if t.ReplicaLag.WarningAt > 0 && t.ReplicaLag.CriticalAt > 0 && t.ReplicaLag.CriticalAt < t.ReplicaLag.WarningAt {
+		return fmt.Errorf("thresholds.replica_lag: critical_at must not be lower than warning_at")
+	}
+	if t.ClockSkew.WarningAt > 0 && t.ClockSkew.CriticalAt > 0 && t.ClockSkew.CriticalAt < t.ClockSkew.WarningAt {
+		return fmt.Errorf("thresholds.clock_skew: critical_at must not be lower than warning_at")
+	}
+	if t.RowCountDrift.WarningAt > 0 && t.RowCountDrift.CriticalAt > 0 && t.RowCountDrift.CriticalAt < t.RowCountDrift.WarningAt {
+		return fmt.Errorf("thresholds.row_count_drift: critical_at must not be lower than warning_at")
+	}
+
+	return nil
+}
+
+// PairSettings returns the current settings of a database pair
+func (c *Config) PairSettings(pairName string) (PairSettings, bool) {
+	c.pairsMu.RLock()
+	defer c.pairsMu.RUnlock()
+
+	settings, ok := c.pairSettings[pairName]
+	return settings, ok
+}
+
+// PairThresholds returns the thresholds in effect for a database pair. A
+// metric without per-pair tiers uses the global thresholds.
+func (c *Config) PairThresholds(pairName string) ThresholdsConfig {
+	settings, _ := c.PairSettings(pairName)
+	effective := c.Thresholds
+
+	if settings.Thresholds.ReplicaLag != (DurationThresholds{}) {
+		effective.ReplicaLag = settings.Thresholds.ReplicaLag
+	}
+	if settings.Thresholds.RowCountDrift != (CountThresholds{}) {
+		effective.RowCountDrift = settings.Thresholds.RowCountDrift
+	}
+	if settings.Thresholds.ClockSkew != (DurationThresholds{}) {
+		effective.ClockSkew = settings.Thresholds.ClockSkew
+	}
+
+	return effective
+}
+
+// PairCheckInterval returns how often a database pair is checked
+func (c *Config) PairCheckInterval(pairName string) time.Duration {
+	settings, _ := c.PairSettings(pairName)
+	if settings.CheckInterval > 0 {
+		return settings.CheckInterval
+	}
+	return c.MonitoringInterval
+}
+
+// UpdatePairSettings validates new settings for a database pair, writes them
+// back to the configuration file and applies them. A configuration from the
+// environment alone has no file; the settings then last until a restart.
+func (c *Config) UpdatePairSettings(pairName string, settings PairSettings) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	c.pairsMu.Lock()
+	defer c.pairsMu.Unlock()
+
+	if _, ok := c.pairSettings[pairName]; !ok {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	if c.path != "" {
+		if err := c.persistPairSettings(pairName, settings); err != nil {
+			return fmt.Errorf("failed to persist settings: %w", err)
+		}
+	}
+
+	c.pairSettings[pairName] = settings
+	return nil
+}
+
+// persistPairSettings rewrites the pair's entry in the configuration file,
+// leaving the rest of the file (including comments) untouched
+func (c *Config) persistPairSettings(pairName string, settings PairSettings) error {
+	doc, err := readYAMLFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	pairNode := findPairNode(doc.Content[0], pairName)
+	if pairNode == nil {
+		return fmt.Errorf("database pair '%s' is not listed under database_pairs in %s", pairName, c.path)
+	}
+
+	var thresholds yaml.Node
+	if err := thresholds.Encode(settings.Thresholds); err != nil {
+		return err
+	}
+	if len(thresholds.Content) == 0 {
+		deleteMappingKey(pairNode, "thresholds")
+	} else {
+		setMappingValue(pairNode, "thresholds", &thresholds)
+	}
+
+	if settings.CheckInterval == 0 {
+		deleteMappingKey(pairNode, "check_interval")
+	} else {
+		setMappingValue(pairNode, "check_interval", scalarNode(settings.CheckInterval.String()))
+	}
+
+	approx := mappingValue(pairNode, "approximate_counts")
+	if approx == nil && settings.TolerancePercent != 0 {
+		approx = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(pairNode, "approximate_counts", approx)
+	}
+	if approx != nil {
+		var tolerance yaml.Node
+		if err := tolerance.Encode(settings.TolerancePercent); err != nil {
+			return err
+		}
+		setMappingValue(approx, "tolerance_percent", &tolerance)
+	}
+
+	return writeYAMLFile(c.path, doc)
+}
+
+// readYAMLFile parses a YAML file into a document node with a root node
+func readYAMLFile(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return &doc, nil
+}
+
+// findPairNode returns the mapping node of a pair under database_pairs
+func findPairNode(root *yaml.Node, pairName string) *yaml.Node {
+	pairs := mappingValue(root, "database_pairs")
+	if pairs == nil || pairs.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, pair := range pairs.Content {
+		if name := mappingValue(pair, "name"); name != nil && name.Value == pairName {
+			return pair
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node for a key of a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value for a key of a mapping node, appending the key if missing
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, scalarNode(key), value)
+}
+
+// deleteMappingKey removes a key and its value from a mapping node
+func deleteMappingKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// scalarNode creates a plain string scalar node
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// writeYAMLFile atomically replaces a file with the encoded document, keeping its permissions
+func writeYAMLFile(path string, doc *yaml.Node) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := yaml.NewEncoder(tmp)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
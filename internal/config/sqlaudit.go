@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SQL audit modes
+const (
+	SQLAuditEnforce = "enforce" // statements outside the allowlist are refused
+	SQLAuditReport  = "report"  // statements outside the allowlist run and are flagged
+)
+
+// SQLAuditConfig logs every statement the monitor runs against the databases
+// and checks it against an allowlist, so what runs in production can be
+// reviewed and approved
+type SQLAuditConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Output  string `yaml:"output"` // "stdout", "stderr" or a file path
+
+	// Regular expressions, matched case-insensitively against the whole
+	// statement; every statement is allowed when empty
+	Allowlist []string `yaml:"allowlist"`
+	Mode      string   `yaml:"mode"` // "enforce" (default) or "report"
+}
+
+// validate checks SQL audit settings and applies defaults
+func (c *SQLAuditConfig) validate() error {
+	if c.Output == "" {
+		c.Output = "stdout"
+	}
+	if c.Mode == "" {
+		c.Mode = SQLAuditEnforce
+	}
+	if c.Mode != SQLAuditEnforce && c.Mode != SQLAuditReport {
+		return fmt.Errorf("mode must be 'enforce' or 'report'")
+	}
+	_, err := c.AllowlistPatterns()
+	return err
+}
+
+// AllowlistPatterns compiles the allowlist, anchored to match whole
+// statements. Newlines in a statement match '.'.
+func (c *SQLAuditConfig) AllowlistPatterns() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(c.Allowlist))
+	for _, pattern := range c.Allowlist {
+		re, err := regexp.Compile(`(?is)^(?:` + pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("allowlist: invalid pattern '%s': %w", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// forbiddenSelectClauses match parts of a SELECT that write, lock or stall.
+// They are matched against the statement with string literals removed.
+var forbiddenSelectClauses = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`(?i)\bINTO\b`), "SELECT ... INTO writes files or variables"},
+	{regexp.MustCompile(`(?i)\bFOR\s+UPDATE\b`), "FOR UPDATE takes row locks"},
+	{regexp.MustCompile(`(?i)\bLOCK\s+IN\s+SHARE\s+MODE\b`), "LOCK IN SHARE MODE takes row locks"},
+	{regexp.MustCompile(`(?i)\b(SLEEP|BENCHMARK|GET_LOCK|RELEASE_LOCK|RELEASE_ALL_LOCKS|LOAD_FILE|MASTER_POS_WAIT|MASTER_GTID_WAIT)\s*\(`), "function has side effects or can stall the server"},
+}
+
+// validateReadOnlySelect checks that user-supplied SQL is a single read-only
+// SELECT and returns it without surrounding whitespace or a trailing ';'.
+// Comments are rejected because MariaDB executes /*! ... */ comments.
+func validateReadOnlySelect(sql string) (string, error) {
+	sql = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+
+	code, err := stripSQLLiterals(sql)
+	if err != nil {
+		return "", err
+	}
+	if fields := strings.Fields(code); len(fields) == 0 || !strings.EqualFold(fields[0], "SELECT") {
+		return "", fmt.Errorf("sql must be a SELECT statement")
+	}
+	if strings.Contains(code, ";") {
+		return "", fmt.Errorf("sql must be a single statement")
+	}
+	if strings.Contains(code, "/*") || strings.Contains(code, "--") || strings.Contains(code, "#") {
+		return "", fmt.Errorf("sql cannot contain comments")
+	}
+	for _, clause := range forbiddenSelectClauses {
+		if clause.pattern.MatchString(code) {
+			return "", fmt.Errorf("sql is not read-only: %s", clause.reason)
+		}
+	}
+	return sql, nil
+}
+
+// trailingLimit matches a LIMIT clause ending a statement: LIMIT n,
+// LIMIT m, n or LIMIT n OFFSET m
+var trailingLimit = regexp.MustCompile(`(?i)\bLIMIT\s+\d+(\s*,\s*\d+|\s+OFFSET\s+\d+)?\s*$`)
+
+// validateLimitedSelect checks that user-supplied SQL is a single read-only
+// SELECT whose result is bounded by a LIMIT clause at its end, so that it
+// cannot return a whole table should it ever run
+func validateLimitedSelect(sql string) (string, error) {
+	sql, err := validateReadOnlySelect(sql)
+	if err != nil {
+		return "", err
+	}
+	code, err := stripSQLLiterals(sql)
+	if err != nil {
+		return "", err
+	}
+	if !trailingLimit.MatchString(code) {
+		return "", fmt.Errorf("sql must end in a LIMIT clause")
+	}
+	return sql, nil
+}
+
+// stripSQLLiterals blanks out quoted strings and identifiers so that their
+// contents are not mistaken for SQL
+func stripSQLLiterals(sql string) (string, error) {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range sql {
+		switch {
+		case quote == 0 && (r == '\'' || r == '"' || r == '`'):
+			quote = r
+			b.WriteRune(' ')
+		case quote == 0:
+			b.WriteRune(r)
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '`':
+			escaped = true
+		case r == quote:
+			quote = 0
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("sql has an unterminated %c quote", quote)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import "testing"
+
+func TestValidateReadOnlySelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain select", sql: "SELECT id FROM orders", want: "SELECT id FROM orders"},
+		{name: "trailing semicolon and whitespace", sql: "  select 1 ;  ", want: "select 1"},
+		{name: "keywords inside literals", sql: "SELECT 'INTO; -- #' AS `for update`", want: "SELECT 'INTO; -- #' AS `for update`"},
+		{name: "escaped quote", sql: `SELECT 'it\'s' FROM t`, want: `SELECT 'it\'s' FROM t`},
+		{name: "doubled quote", sql: "SELECT 'it''s; fine' FROM t", want: "SELECT 'it''s; fine' FROM t"},
+		{name: "empty", sql: " ; ", wantErr: true},
+		{name: "not a select", sql: "DELETE FROM orders", wantErr: true},
+		{name: "with clause", sql: "WITH x AS (SELECT 1) SELECT * FROM x", wantErr: true},
+		{name: "two statements", sql: "SELECT 1; DROP TABLE orders", wantErr: true},
+		{name: "executable comment", sql: "SELECT 1 /*!50000 , SLEEP(10) */", wantErr: true},
+		{name: "line comment", sql: "SELECT 1 -- x", wantErr: true},
+		{name: "hash comment", sql: "SELECT 1 # x", wantErr: true},
+		{name: "into outfile", sql: "SELECT * FROM orders INTO OUTFILE '/tmp/x'", wantErr: true},
+		{name: "for update", sql: "SELECT * FROM orders FOR  UPDATE", wantErr: true},
+		{name: "lock in share mode", sql: "SELECT * FROM orders LOCK IN SHARE MODE", wantErr: true},
+		{name: "sleep", sql: "SELECT sleep (5)", wantErr: true},
+		{name: "get_lock", sql: "SELECT GET_LOCK('x', 10)", wantErr: true},
+		{name: "load_file", sql: "SELECT LOAD_FILE('/etc/passwd')", wantErr: true},
+		{name: "unterminated quote", sql: "SELECT 'x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateReadOnlySelect(tt.sql)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("validateReadOnlySelect(%q) = %q, want an error", tt.sql, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("validateReadOnlySelect(%q) = %q, %v; want %q", tt.sql, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLimitedSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr bool
+	}{
+		{name: "limit", sql: "SELECT * FROM orders ORDER BY id LIMIT 100"},
+		{name: "limit with offset", sql: "SELECT * FROM orders LIMIT 100 OFFSET 200"},
+		{name: "limit offset, count", sql: "SELECT * FROM orders limit 200, 100;"},
+		{name: "no limit", sql: "SELECT * FROM orders", wantErr: true},
+		{name: "limit in a subquery only", sql: "SELECT * FROM (SELECT * FROM orders LIMIT 10) o JOIN items i ON i.order_id = o.id", wantErr: true},
+		{name: "limit inside a literal", sql: "SELECT * FROM orders WHERE note = 'LIMIT 1'", wantErr: true},
+		{name: "limit by placeholder", sql: "SELECT * FROM orders LIMIT ?", wantErr: true},
+		{name: "not read-only", sql: "SELECT * FROM orders FOR UPDATE LIMIT 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateLimitedSelect(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateLimitedSelect(%q) error = %v, want error: %v", tt.sql, err, tt.wantErr)
+			}
+		})
+	}
+}
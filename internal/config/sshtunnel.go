@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SSHTunnelConfig reaches the databases of a pair through an SSH bastion
+// (jump host), for instances without a route from the monitor
+type SSHTunnelConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"` // defaults to 22
+	User string `yaml:"user"`
+
+	// Authenticate with a private key file, the keys of the SSH agent at
+	// SSH_AUTH_SOCK, or both
+	PrivateKeyFile string `yaml:"private_key_file"`
+	Passphrase     string `yaml:"passphrase"` // of an encrypted private key
+	UseAgent       bool   `yaml:"use_agent"`
+
+	// The bastion's host key is verified against known_hosts_file (default
+	// ~/.ssh/known_hosts) unless insecure_ignore_host_key is set
+	KnownHostsFile        string `yaml:"known_hosts_file"`
+	InsecureIgnoreHostKey bool   `yaml:"insecure_ignore_host_key"`
+
+	KeepAlive time.Duration `yaml:"keepalive"` // defaults to 30s; a bastion not answering is redialled
+}
+
+// Enabled reports whether the databases are reached through a bastion
+func (t *SSHTunnelConfig) Enabled() bool {
+	return t.Host != ""
+}
+
+// validate checks SSH tunnel settings and applies defaults
+func (t *SSHTunnelConfig) validate() error {
+	if t.User == "" {
+		return fmt.Errorf("user is required")
+	}
+	if t.PrivateKeyFile == "" && !t.UseAgent {
+		return fmt.Errorf("private_key_file or use_agent is required")
+	}
+	if t.Port == 0 {
+		t.Port = 22
+	}
+	if t.KeepAlive < 0 {
+		return fmt.Errorf("keepalive cannot be negative")
+	}
+	if t.KeepAlive == 0 {
+		t.KeepAlive = 30 * time.Second
+	}
+	if t.KnownHostsFile == "" && !t.InsecureIgnoreHostKey {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("known_hosts_file is required: %w", err)
+		}
+		t.KnownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return nil
+}
+
+// applySSHTunnel routes every database of a pair through its bastion
+func (p *DatabasePair) applySSHTunnel() error {
+	if !p.SSHTunnel.Enabled() {
+		return nil
+	}
+	if err := p.SSHTunnel.validate(); err != nil {
+		return err
+	}
+	for _, db := range p.Databases() {
+		if db.usesSocket() {
+			return fmt.Errorf("unix socket %s cannot be reached through the bastion", db.Address())
+		}
+		db.SSHTunnel = &p.SSHTunnel
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time-series backends
+const (
+	TimeSeriesInfluxDB    = "influxdb"
+	TimeSeriesTimescaleDB = "timescaledb"
+)
+
+// TimeSeriesConfig writes every lag, checksum and consistency measurement to
+// InfluxDB or TimescaleDB, for reporting over months rather than the
+// in-memory history. Points are batched, and kept while the database is
+// unreachable up to buffer_size.
+type TimeSeriesConfig struct {
+	Enabled       bool              `yaml:"enabled"`
+	Backend       string            `yaml:"backend"`        // influxdb or timescaledb
+	BatchSize     int               `yaml:"batch_size"`     // points per write
+	FlushInterval time.Duration     `yaml:"flush_interval"` // pending points are written at least this often
+	BufferSize    int               `yaml:"buffer_size"`    // points kept while writes fail; the oldest are dropped beyond it
+	MaxRetries    int               `yaml:"max_retries"`    // retries of a failed write before it waits for the next flush
+	RetryBackoff  time.Duration     `yaml:"retry_backoff"`  // wait before the first retry, doubled for each further one
+	Timeout       time.Duration     `yaml:"timeout"`        // per write
+	InfluxDB      InfluxDBConfig    `yaml:"influxdb"`
+	TimescaleDB   TimescaleDBConfig `yaml:"timescaledb"`
+}
+
+// InfluxDBConfig is the InfluxDB v2 write API. InfluxDB 1.8 accepts it too,
+// with "username:password" as token and "database/retention" as bucket.
+type InfluxDBConfig struct {
+	URL    string `yaml:"url"` // e.g. http://influxdb:8086
+	Token  string `yaml:"token"`
+	Org    string `yaml:"org"`
+	Bucket string `yaml:"bucket"`
+}
+
+// TimescaleDBConfig is the PostgreSQL database with the TimescaleDB
+// extension. The table is created as a hypertable when it does not exist.
+type TimescaleDBConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Database string `yaml:"database"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslmode"` // libpq sslmode; defaults to verify-full
+	Table    string `yaml:"table"`   // optionally schema-qualified
+
+	// CA bundle that verify-ca and verify-full check the server certificate
+	// against; the system roots when empty
+	SSLRootCert string `yaml:"sslrootcert"`
+}
+
+// Address returns the host:port of the database
+func (t TimescaleDBConfig) Address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// sqlName matches an unquoted, optionally schema-qualified SQL name
+var sqlName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// validate checks the time-series settings and applies defaults
+func (t *TimeSeriesConfig) validate() error {
+	switch t.Backend {
+	case TimeSeriesInfluxDB:
+		if err := t.InfluxDB.validate(); err != nil {
+			return fmt.Errorf("influxdb: %w", err)
+		}
+	case TimeSeriesTimescaleDB:
+		if err := t.TimescaleDB.validate(); err != nil {
+			return fmt.Errorf("timescaledb: %w", err)
+		}
+	default:
+		return fmt.Errorf("backend must be %s or %s", TimeSeriesInfluxDB, TimeSeriesTimescaleDB)
+	}
+
+	if t.BatchSize == 0 {
+		t.BatchSize = 500
+	}
+	if t.BufferSize == 0 {
+		t.BufferSize = 50000
+	}
+	if t.BatchSize < 0 || t.BufferSize < t.BatchSize {
+		return fmt.Errorf("batch_size must be positive and buffer_size at least batch_size")
+	}
+	// A batch is one INSERT, and PostgreSQL binds at most 65535 parameters,
+	// five per point
+	if t.Backend == TimeSeriesTimescaleDB && t.BatchSize > 10000 {
+		return fmt.Errorf("batch_size cannot exceed 10000 with timescaledb")
+	}
+	if t.FlushInterval == 0 {
+		t.FlushInterval = 10 * time.Second
+	}
+	if t.MaxRetries == 0 {
+		t.MaxRetries = 3
+	}
+	if t.RetryBackoff == 0 {
+		t.RetryBackoff = time.Second
+	}
+	if t.Timeout == 0 {
+		t.Timeout = 10 * time.Second
+	}
+	if t.FlushInterval < 0 || t.MaxRetries < 0 || t.RetryBackoff < 0 || t.Timeout < 0 {
+		return fmt.Errorf("flush_interval, max_retries, retry_backoff and timeout cannot be negative")
+	}
+	return nil
+}
+
+// validate checks the InfluxDB settings
+func (i *InfluxDBConfig) validate() error {
+	if !strings.HasPrefix(i.URL, "http://") && !strings.HasPrefix(i.URL, "https://") {
+		return fmt.Errorf("url must be an http:// or https:// URL")
+	}
+	i.URL = strings.TrimSuffix(i.URL, "/")
+	if i.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	return nil
+}
+
+// validate checks the TimescaleDB settings and applies defaults
+func (t *TimescaleDBConfig) validate() error {
+	if t.Host == "" || t.Database == "" || t.Username == "" {
+		return fmt.Errorf("host, database and username are required")
+	}
+	if t.Port == 0 {
+		t.Port = 5432
+	}
+	// Only verify-ca and verify-full check the server certificate, as in libpq
+	switch t.SSLMode {
+	case "":
+		t.SSLMode = "verify-full"
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("sslmode must be disable, allow, prefer, require, verify-ca or verify-full")
+	}
+	if t.Table == "" {
+		t.Table = "monitor_measurements"
+	}
+	if !sqlName.MatchString(t.Table) {
+		return fmt.Errorf("invalid table name '%s'", t.Table)
+	}
+	return nil
+}
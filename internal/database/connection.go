@@ -1,93 +1,248 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/tracing"
+)
+
+// tracedDriverName is the MySQL driver wrapped to trace every query. Spans
+// are only recorded when a tracing provider is installed.
+const tracedDriverName = "mysql-traced"
+
+// driverName is the driver connections are opened with
+var driverName = tracedDriverName
+
+func init() {
+	sql.Register(tracedDriverName, tracing.WrapDriver(mysql.MySQLDriver{}, dsnAttributes))
+}
+
+// SetDriver replaces the driver connections are opened with, e.g. by the
+// self-test's scripted database. It must be called before connecting.
+func SetDriver(name string) {
+	driverName = name
+}
+
+// dsnAttributes describes the database of a DSN on query spans
+func dsnAttributes(dsn string) []tracing.Attribute {
+	attrs := []tracing.Attribute{tracing.String("db.system", "mariadb")}
+	if cfg, err := mysql.ParseDSN(dsn); err == nil {
+		attrs = append(attrs, tracing.String("server.address", cfg.Addr), tracing.String("db.name", cfg.DBName))
+	}
+	return attrs
+}
+
+// Background reconnection backoff for databases unreachable at startup
+const (
+	reconnectInitialBackoff = 5 * time.Second
+	reconnectMaxBackoff     = 5 * time.Minute
 )
 
 // ConnectionManager manages database connections with retry logic
 type ConnectionManager struct {
-	sourceConn *sql.DB
-	targetConn *sql.DB
-	sourceConfig *config.DatabaseConfig
-	targetConfig *config.DatabaseConfig
-	pairName   string
+	mu             sync.RWMutex
+	sourceConn     *sql.DB
+	targetConn     *sql.DB
+	closed         bool
+	sourceConfig   *config.DatabaseConfig
+	targetConfig   *config.DatabaseConfig
+	pairName       string
+	limiter        *InstanceLimiter
+	connectTimeout time.Duration
+
+	// Failover detection of cluster endpoints, by database type
+	instances map[string]instanceIdentity
+	lost      map[string]bool // unreachable since the last check
+	failovers []Failover      // not yet taken by TakeFailovers
 }
 
-// NewConnectionManager creates a new connection manager for a database pair
-func NewConnectionManager(sourceDB, targetDB *config.DatabaseConfig, pairName string) *ConnectionManager {
+// NewConnectionManager creates a new connection manager for a database pair.
+// sourceDB is nil for a manager of a single database, e.g. an intermediate of
+// a replication chain, which only connects its target; targetDB is nil for
+// commands that only read the source.
+func NewConnectionManager(sourceDB, targetDB *config.DatabaseConfig, pairName string, limiter *InstanceLimiter, connectTimeout time.Duration) *ConnectionManager {
 	return &ConnectionManager{
-		sourceConfig: sourceDB,
-		targetConfig: targetDB,
-		pairName:     pairName,
+		sourceConfig:   sourceDB,
+		targetConfig:   targetDB,
+		pairName:       pairName,
+		limiter:        limiter,
+		connectTimeout: connectTimeout,
+		instances:      make(map[string]instanceIdentity),
+		lost:           make(map[string]bool),
 	}
 }
 
-// ConnectSource establishes connection to source database with retry logic
-func (cm *ConnectionManager) ConnectSource() error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
-		cm.sourceConfig.Username,
-		cm.sourceConfig.Password,
-		cm.sourceConfig.Host,
-		cm.sourceConfig.Port,
-		cm.sourceConfig.Database,
-	)
+// BuildDSN builds the driver DSN of a database, including its driver options
+func BuildDSN(db *config.DatabaseConfig, connectTimeout time.Duration) string {
+	cfg := mysql.NewConfig()
+	if db.DSN != "" {
+		// Validated when the configuration is loaded
+		if parsed, err := mysql.ParseDSN(db.DSN); err == nil {
+			cfg = parsed
+		}
+	} else {
+		cfg.User = db.Username
+		cfg.Passwd = db.Password
+		cfg.Net = "tcp"
+		cfg.Addr = fmt.Sprintf("%s:%d", db.Host, db.Port)
+		if db.Socket != "" {
+			cfg.Net, cfg.Addr = "unix", db.Socket
+		}
+		cfg.DBName = db.Database
+	}
+	if db.SSHTunnel != nil {
+		cfg.Net = sshNetwork(db.SSHTunnel)
+	}
+	// Results are scanned into time.Time, whatever the DSN says
+	cfg.ParseTime = true
+	if cfg.Timeout == 0 {
+		cfg.Timeout = connectTimeout
+	}
+	if db.Timeout > 0 {
+		cfg.Timeout = db.Timeout
+	}
+	if db.ReadTimeout > 0 {
+		cfg.ReadTimeout = db.ReadTimeout
+	}
+	if db.Collation != "" {
+		cfg.Collation = db.Collation
+	}
+	if db.Charset != "" {
+		cfg.Apply(mysql.Charset(db.Charset, cfg.Collation))
+	}
+	// Validated when the configuration is loaded
+	if loc, err := time.LoadLocation(db.Loc); db.Loc != "" && err == nil {
+		cfg.Loc = loc
+	}
+	// Sent as SET time_zone = '...' when a connection is opened
+	if db.TimeZone != "" {
+		if cfg.Params == nil {
+			cfg.Params = make(map[string]string)
+		}
+		cfg.Params["time_zone"] = "'" + db.TimeZone + "'"
+	}
+	if db.InterpolateParams {
+		cfg.InterpolateParams = true
+	}
+	// Connections to an instance that turned read-only are discarded
+	if db.AuroraEndpoint == config.AuroraWriter {
+		cfg.RejectReadOnly = true
+	}
+
+	dsn := cfg.FormatDSN()
+	if len(db.Params) == 0 {
+		return dsn
+	}
+	params := url.Values{}
+	for name, value := range db.Params {
+		params.Set(name, value)
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + params.Encode()
+}
+
+// configs returns the current settings of both databases
+func (cm *ConnectionManager) configs() (source, target *config.DatabaseConfig) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.sourceConfig, cm.targetConfig
+}
 
-	return cm.connectWithRetry(&cm.sourceConn, dsn, fmt.Sprintf("source[%s]", cm.pairName))
+// ConnectSource establishes connection to source database with retry logic
+func (cm *ConnectionManager) ConnectSource(ctx context.Context) error {
+	source, _ := cm.configs()
+	return cm.connectWithRetry(ctx, &cm.sourceConn, source, fmt.Sprintf("source[%s]", cm.pairName))
 }
 
 // ConnectTarget establishes connection to target database with retry logic
-func (cm *ConnectionManager) ConnectTarget() error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
-		cm.targetConfig.Username,
-		cm.targetConfig.Password,
-		cm.targetConfig.Host,
-		cm.targetConfig.Port,
-		cm.targetConfig.Database,
-	)
+func (cm *ConnectionManager) ConnectTarget(ctx context.Context) error {
+	_, target := cm.configs()
+	return cm.connectWithRetry(ctx, &cm.targetConn, target, fmt.Sprintf("target[%s]", cm.pairName))
+}
+
+// UpdateConfig replaces the settings of both databases. Connection pools of
+// databases whose DSN changed are reopened with the new settings; the old
+// pool is kept when the database cannot be reached with them.
+func (cm *ConnectionManager) UpdateConfig(ctx context.Context, source, target *config.DatabaseConfig) error {
+	var errs []error
+	for _, side := range []struct {
+		conn   **sql.DB
+		cfg    **config.DatabaseConfig
+		db     *config.DatabaseConfig
+		dbType string
+	}{
+		{&cm.sourceConn, &cm.sourceConfig, source, "source"},
+		{&cm.targetConn, &cm.targetConfig, target, "target"},
+	} {
+		if side.db == nil {
+			continue
+		}
+		cm.mu.Lock()
+		oldDSN := BuildDSN(*side.cfg, cm.connectTimeout)
+		*side.cfg = side.db
+		connected := *side.conn != nil
+		cm.mu.Unlock()
+
+		dsn := BuildDSN(side.db, cm.connectTimeout)
+		if dsn == oldDSN || !connected {
+			// KeepConnected picks up the new settings of unconnected databases
+			continue
+		}
 
-	return cm.connectWithRetry(&cm.targetConn, dsn, fmt.Sprintf("target[%s]", cm.pairName))
+		db, err := cm.open(ctx, side.db)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconnect to %s database: %w", side.dbType, err))
+			continue
+		}
+		cm.mu.Lock()
+		if cm.closed {
+			cm.mu.Unlock()
+			db.Close()
+			return fmt.Errorf("connection manager for %s is closed", cm.pairName)
+		}
+		old := *side.conn
+		*side.conn = db
+		cm.mu.Unlock()
+		old.Close() // waits for queries still running on the old pool
+		log.Printf("[%s] Reconnected to %s database with updated settings", cm.pairName, side.dbType)
+	}
+	return errors.Join(errs...)
 }
 
 // connectWithRetry attempts to connect with exponential backoff
-func (cm *ConnectionManager) connectWithRetry(conn **sql.DB, dsn, dbType string) error {
+func (cm *ConnectionManager) connectWithRetry(ctx context.Context, conn **sql.DB, cfg *config.DatabaseConfig, dbType string) error {
 	maxRetries := 3
 	retryInterval := 5 * time.Second
 
 	var lastErr error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		db, err := sql.Open("mysql", dsn)
+		db, err := cm.open(ctx, cfg)
 		if err != nil {
 			lastErr = err
-			log.Printf("Attempt %d/%d: Failed to open %s database connection: %v", attempt, maxRetries, dbType, err)
-			if attempt < maxRetries {
-				time.Sleep(retryInterval)
+			log.Printf("Attempt %d/%d: Failed to connect to %s database: %v", attempt, maxRetries, dbType, err)
+			if attempt < maxRetries && !sleepContext(ctx, retryInterval) {
+				return ctx.Err()
 			}
 			continue
 		}
 
-		// Test the connection
-		if err := db.Ping(); err != nil {
-			lastErr = err
-			db.Close()
-			log.Printf("Attempt %d/%d: Failed to ping %s database: %v", attempt, maxRetries, dbType, err)
-			if attempt < maxRetries {
-				time.Sleep(retryInterval)
-			}
-			continue
+		if !cm.setConn(conn, db) {
+			return fmt.Errorf("connection manager for %s is closed", dbType)
 		}
-
-		// Configure connection pool
-		db.SetMaxOpenConns(10)
-		db.SetMaxIdleConns(5)
-		db.SetConnMaxLifetime(time.Hour)
-
-		*conn = db
 		log.Printf("Successfully connected to %s database", dbType)
 		return nil
 	}
@@ -95,8 +250,125 @@ func (cm *ConnectionManager) connectWithRetry(conn **sql.DB, dsn, dbType string)
 	return fmt.Errorf("failed to connect to %s database after %d attempts: %w", dbType, maxRetries, lastErr)
 }
 
+// open opens a connection pool of a database and verifies it with a ping
+func (cm *ConnectionManager) open(ctx context.Context, cfg *config.DatabaseConfig) (*sql.DB, error) {
+	dsn := BuildDSN(cfg, cm.connectTimeout)
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReadOnlySession || sqlAudit != nil {
+		d := db.Driver()
+		db.Close()
+		if sqlAudit != nil {
+			// Audited below the read-only setup, so that is logged too
+			d = sqlAudit.wrap(d, cm.pairName, cfg.Address())
+		}
+		var connector driver.Connector = &dsnConnector{driver: d, dsn: dsn}
+		if cfg.ReadOnlySession {
+			// Every connection of the pool is made read-only before it is used
+			connector = &readOnlyConnector{driver: d, dsn: dsn}
+		}
+		db = sql.OpenDB(connector)
+	}
+
+	// Test the connection
+	pingCtx, cancel := context.WithTimeout(ctx, cm.connectTimeout)
+	err = db.PingContext(pingCtx)
+	cancel()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Configure connection pool
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	return db, nil
+}
+
+// setConn stores an established connection, or closes it when the manager
+// was closed in the meantime
+func (cm *ConnectionManager) setConn(conn **sql.DB, db *sql.DB) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.closed {
+		db.Close()
+		return false
+	}
+	*conn = db
+	return true
+}
+
+// KeepConnected retries connections that could not be established in the
+// background, with exponential backoff, until both databases are connected
+// or ctx is done. Established connection pools reconnect on their own.
+func (cm *ConnectionManager) KeepConnected(ctx context.Context) {
+	go func() {
+		backoff := reconnectInitialBackoff
+		for {
+			missing := false
+			source, target := cm.configs()
+			for _, side := range []struct {
+				conn   **sql.DB
+				db     *config.DatabaseConfig
+				dbType string
+			}{
+				{&cm.sourceConn, source, "source"},
+				{&cm.targetConn, target, "target"},
+			} {
+				if side.db == nil {
+					continue
+				}
+				cm.mu.RLock()
+				connected, closed := *side.conn != nil, cm.closed
+				cm.mu.RUnlock()
+				if closed {
+					return
+				}
+				if connected {
+					continue
+				}
+
+				db, err := cm.open(ctx, side.db)
+				if err != nil {
+					missing = true
+					log.Printf("[%s] Reconnecting to %s database failed: %v (next attempt in %v)", cm.pairName, side.dbType, err, backoff)
+					continue
+				}
+				if !cm.setConn(side.conn, db) {
+					return
+				}
+				log.Printf("[%s] Reconnected to %s database", cm.pairName, side.dbType)
+			}
+
+			if !missing || !sleepContext(ctx, backoff) {
+				return
+			}
+			backoff = min(2*backoff, reconnectMaxBackoff)
+		}
+	}()
+}
+
+// sleepContext waits for the given duration, returning false if the context is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // GetSourceConnection returns the source database connection
 func (cm *ConnectionManager) GetSourceConnection() (*sql.DB, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 	if cm.sourceConn == nil {
 		return nil, fmt.Errorf("source database connection not established")
 	}
@@ -105,34 +377,76 @@ func (cm *ConnectionManager) GetSourceConnection() (*sql.DB, error) {
 
 // GetTargetConnection returns the target database connection
 func (cm *ConnectionManager) GetTargetConnection() (*sql.DB, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 	if cm.targetConn == nil {
 		return nil, fmt.Errorf("target database connection not established")
 	}
 	return cm.targetConn, nil
 }
 
+// AcquireSource blocks until a heavy query may run on the source instance
+// and returns a function that releases the slot
+func (cm *ConnectionManager) AcquireSource(ctx context.Context) (func(), error) {
+	source, _ := cm.configs()
+	return cm.limiter.Acquire(ctx, instanceKey(source))
+}
+
+// AcquireTarget blocks until a heavy query may run on the target instance
+// and returns a function that releases the slot
+func (cm *ConnectionManager) AcquireTarget(ctx context.Context) (func(), error) {
+	_, target := cm.configs()
+	return cm.limiter.Acquire(ctx, instanceKey(target))
+}
+
+// instanceKey identifies a physical database instance
+func instanceKey(cfg *config.DatabaseConfig) string {
+	return cfg.Address()
+}
+
 // HealthCheck verifies the health of both database connections
-func (cm *ConnectionManager) HealthCheck() (sourceOK, targetOK bool) {
-	sourceOK = false
-	targetOK = false
+func (cm *ConnectionManager) HealthCheck(ctx context.Context) (sourceOK, targetOK bool) {
+	return cm.CheckHealth(ctx, true, true)
+}
 
-	if cm.sourceConn != nil {
-		if err := cm.sourceConn.Ping(); err == nil {
-			sourceOK = true
-		}
-	}
+// CheckHealth pings the given databases only; a database that is not pinged
+// is reported unhealthy. Each database has its own connect timeout, so an
+// unresponsive source does not fail the target.
+func (cm *ConnectionManager) CheckHealth(ctx context.Context, source, target bool) (sourceOK, targetOK bool) {
+	cm.mu.RLock()
+	sourceConn, targetConn := cm.sourceConn, cm.targetConn
+	cm.mu.RUnlock()
 
-	if cm.targetConn != nil {
-		if err := cm.targetConn.Ping(); err == nil {
-			targetOK = true
-		}
+	sourceCfg, targetCfg := cm.configs()
+	if source && sourceConn != nil {
+		sourceOK = cm.ping(ctx, &cm.sourceConn, sourceConn, sourceCfg, "source")
+	}
+	if target && targetConn != nil {
+		targetOK = cm.ping(ctx, &cm.targetConn, targetConn, targetCfg, "target")
 	}
-
 	return sourceOK, targetOK
 }
 
+// ping checks one database within the connect timeout, following a failover
+// once it answers and marking its connection lost otherwise
+func (cm *ConnectionManager) ping(ctx context.Context, slot **sql.DB, conn *sql.DB, cfg *config.DatabaseConfig, dbType string) bool {
+	ctx, cancel := context.WithTimeout(ctx, cm.connectTimeout)
+	defer cancel()
+
+	if err := conn.PingContext(ctx); err != nil {
+		cm.connectionLost(cfg, dbType)
+		return false
+	}
+	cm.checkFailover(ctx, slot, conn, cfg, dbType)
+	return true
+}
+
 // Close closes both database connections
 func (cm *ConnectionManager) Close() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.closed = true
+
 	if cm.sourceConn != nil {
 		cm.sourceConn.Close()
 		log.Println("Closed source database connection")
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// instanceQuery identifies the instance a connection pool reaches. MariaDB
+// has no server_uuid, and Aurora readers only set innodb_read_only.
+const instanceQuery = "SHOW GLOBAL VARIABLES WHERE Variable_name IN ('server_uuid', 'server_id', 'hostname', 'read_only', 'innodb_read_only')"
+
+// instanceIdentity is the instance a connection pool reaches and its role
+type instanceIdentity struct {
+	id             string // server_uuid, or hostname and server_id
+	readOnly       bool   // read_only or innodb_read_only
+	innodbReadOnly bool   // tells Aurora writer and reader instances apart
+}
+
+// Failover is a change of the instance behind a cluster endpoint
+type Failover struct {
+	Database string // "source" or "target"
+	Address  string
+	From     string // server_uuid, or hostname and server_id
+	To       string
+	Reasons  []string // what gave the failover away
+}
+
+// String describes the failover for logs and alerts
+func (f Failover) String() string {
+	if f.From == f.To {
+		return fmt.Sprintf("the %s database %s changed role on instance %s (%s)", f.Database, f.Address, f.To, strings.Join(f.Reasons, ", "))
+	}
+	return fmt.Sprintf("the %s database %s now reaches %s instead of %s (%s)", f.Database, f.Address, f.To, f.From, strings.Join(f.Reasons, ", "))
+}
+
+// readInstance reads the identity of the instance a pool reaches
+func readInstance(ctx context.Context, db *sql.DB) (instanceIdentity, error) {
+	rows, err := db.QueryContext(ctx, instanceQuery)
+	if err != nil {
+		return instanceIdentity{}, err
+	}
+	defer rows.Close()
+
+	variables := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return instanceIdentity{}, err
+		}
+		variables[strings.ToLower(name)] = value
+	}
+	if err := rows.Err(); err != nil {
+		return instanceIdentity{}, err
+	}
+
+	on := func(name string) bool {
+		return strings.EqualFold(variables[name], "ON") || variables[name] == "1"
+	}
+	identity := instanceIdentity{
+		id:             variables["server_uuid"],
+		readOnly:       on("read_only") || on("innodb_read_only"),
+		innodbReadOnly: on("innodb_read_only"),
+	}
+	if identity.id == "" {
+		identity.id = variables["hostname"] + "/" + variables["server_id"]
+	}
+	return identity, nil
+}
+
+// connectionLost notes that a cluster endpoint could not be reached, so the
+// next check reopens its pool and compares the instance it then reaches
+func (cm *ConnectionManager) connectionLost(cfg *config.DatabaseConfig, dbType string) {
+	if cfg == nil || !cfg.ClusterEndpoint() {
+		return
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.lost[dbType] = true
+}
+
+// checkFailover detects a failover behind a cluster endpoint: a lost
+// connection, a read_only flip, or an Aurora endpoint reaching an instance
+// of the other role. Pooled connections outlive the endpoint's DNS change,
+// so the pool is then replaced by one dialled to the instance the endpoint
+// resolves to now. A different instance, or a flipped read_only, is recorded
+// as a failover for TakeFailovers.
+func (cm *ConnectionManager) checkFailover(ctx context.Context, conn **sql.DB, db *sql.DB, cfg *config.DatabaseConfig, dbType string) {
+	if cfg == nil || !cfg.ClusterEndpoint() {
+		return
+	}
+
+	cm.mu.RLock()
+	previous, known := cm.instances[dbType]
+	lost := cm.lost[dbType]
+	cm.mu.RUnlock()
+
+	current, err := readInstance(ctx, db)
+	if err != nil {
+		log.Printf("[%s] Failed to identify the instance of the %s database: %v", cm.pairName, dbType, err)
+		return
+	}
+
+	var reasons []string
+	if lost {
+		reasons = append(reasons, "connection lost")
+	}
+	if known && current.readOnly != previous.readOnly {
+		reasons = append(reasons, fmt.Sprintf("read_only turned %s", onOff(current.readOnly)))
+	}
+	if wrongRole(cfg, current) {
+		reasons = append(reasons, fmt.Sprintf("%s endpoint reached a %s instance", cfg.AuroraEndpoint, auroraRole(current)))
+	}
+	if len(reasons) > 0 {
+		log.Printf("[%s] Possible failover of the %s database %s (%s); reconnecting", cm.pairName, dbType, cfg.Address(), strings.Join(reasons, ", "))
+		if fresh := cm.reopen(ctx, conn, db, cfg, dbType); fresh != nil {
+			if current, err = readInstance(ctx, fresh); err != nil {
+				log.Printf("[%s] Failed to identify the instance of the %s database: %v", cm.pairName, dbType, err)
+				return
+			}
+		}
+		// The endpoint's DNS change has not propagated yet; the next check
+		// reconnects again and compares with the instance reached before
+		if wrongRole(cfg, current) {
+			log.Printf("[%s] The %s endpoint of the %s database still reaches a %s instance", cm.pairName, cfg.AuroraEndpoint, dbType, auroraRole(current))
+			return
+		}
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.instances[dbType] = current
+	delete(cm.lost, dbType)
+	if !known || (current.id == previous.id && current.readOnly == previous.readOnly) {
+		return
+	}
+	if current.id != previous.id {
+		reasons = append(reasons, "server changed")
+	}
+	failover := Failover{
+		Database: dbType,
+		Address:  cfg.Address(),
+		From:     previous.id,
+		To:       current.id,
+		Reasons:  reasons,
+	}
+	log.Printf("[%s] Failover detected: %s", cm.pairName, failover)
+	cm.failovers = append(cm.failovers, failover)
+	if len(cm.failovers) > maxPendingFailovers {
+		cm.failovers = cm.failovers[len(cm.failovers)-maxPendingFailovers:]
+	}
+}
+
+// maxPendingFailovers bounds the failovers kept until TakeFailovers
+const maxPendingFailovers = 16
+
+// TakeFailovers returns the failovers detected since the last call
+func (cm *ConnectionManager) TakeFailovers() []Failover {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	failovers := cm.failovers
+	cm.failovers = nil
+	return failovers
+}
+
+// reopen replaces a connection pool with one dialled anew and returns it, or
+// nil when the database cannot be reached or the pool was replaced already
+func (cm *ConnectionManager) reopen(ctx context.Context, conn **sql.DB, db *sql.DB, cfg *config.DatabaseConfig, dbType string) *sql.DB {
+	fresh, err := cm.open(ctx, cfg)
+	if err != nil {
+		log.Printf("[%s] Failed to reconnect to %s database: %v", cm.pairName, dbType, err)
+		return nil
+	}
+	cm.mu.Lock()
+	if cm.closed || *conn != db {
+		cm.mu.Unlock()
+		fresh.Close()
+		return nil
+	}
+	*conn = fresh
+	cm.mu.Unlock()
+	db.Close() // waits for queries still running on the old pool
+	return fresh
+}
+
+// wrongRole reports whether an Aurora endpoint reaches an instance of the
+// other role, e.g. the old writer after a failover
+func wrongRole(cfg *config.DatabaseConfig, identity instanceIdentity) bool {
+	return cfg.AuroraEndpoint != "" && identity.innodbReadOnly != (cfg.AuroraEndpoint == config.AuroraReader)
+}
+
+// auroraRole returns the Aurora role of an instance
+func auroraRole(identity instanceIdentity) string {
+	if identity.innodbReadOnly {
+		return config.AuroraReader
+	}
+	return config.AuroraWriter
+}
+
+// onOff renders a boolean server variable
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"sync"
+)
+
+// InstanceLimiter bounds the number of concurrent heavy queries per database
+// instance (host:port), shared across all pairs that point at the same instance
+type InstanceLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+}
+
+// NewInstanceLimiter creates a new instance limiter allowing limit concurrent
+// queries per instance. A limit of zero or less disables limiting; the
+// configuration turns 0 into the default of 2, so only a negative
+// max_concurrent_queries_per_instance reaches here unlimited.
+func NewInstanceLimiter(limit int) *InstanceLimiter {
+	return &InstanceLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a query slot is available on the given instance or the
+// context is done, and returns a function that releases the slot
+func (il *InstanceLimiter) Acquire(ctx context.Context, instance string) (func(), error) {
+	if il == nil || il.limit <= 0 {
+		return func() {}, nil
+	}
+
+	sem := il.semaphore(instance)
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-sem })
+	}, nil
+}
+
+// semaphore returns the semaphore channel for an instance, creating it on first use
+func (il *InstanceLimiter) semaphore(instance string) chan struct{} {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+
+	sem, exists := il.slots[instance]
+	if !exists {
+		sem = make(chan struct{}, il.limit)
+		il.slots[instance] = sem
+	}
+	return sem
+}
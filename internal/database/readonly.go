@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// readOnlyStatement limits a session to read-only transactions. It also
+// applies to statements run with autocommit, so the server rejects every
+// write with ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION.
+const readOnlyStatement = "SET SESSION TRANSACTION READ ONLY"
+
+// readOnlyVariables hold the access mode of a session: MySQL and MariaDB
+// 11.1+ name it transaction_read_only, older MariaDB tx_read_only
+var readOnlyVariables = []string{"@@session.transaction_read_only", "@@session.tx_read_only"}
+
+// readOnlyConnector opens connections whose session only allows reads, and
+// verifies the access mode before a connection is handed out
+type readOnlyConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect opens a connection and makes its session read-only
+func (c *readOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := makeReadOnly(ctx, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to make the session read-only: %w", err)
+	}
+	return conn, nil
+}
+
+// Driver returns the driver connections are opened with
+func (c *readOnlyConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// makeReadOnly sets the session of a connection read-only and reads the
+// access mode back
+func makeReadOnly(ctx context.Context, conn driver.Conn) error {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return fmt.Errorf("driver does not support queries without preparing them")
+	}
+	if _, err := queryValue(ctx, queryer, readOnlyStatement); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, variable := range readOnlyVariables {
+		value, err := queryValue(ctx, queryer, "SELECT "+variable)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value != "1" {
+			return fmt.Errorf("%s is %s after %s", variable, value, readOnlyStatement)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to read the access mode: %w", errors.Join(errs...))
+}
+
+// queryValue runs a query and returns the first column of its first row, or
+// "" when it returns no rows
+func queryValue(ctx context.Context, queryer driver.QueryerContext, query string) (string, error) {
+	rows, err := queryer.QueryContext(ctx, query, nil)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns := rows.Columns()
+	if len(columns) == 0 {
+		return "", nil
+	}
+	dest := make([]driver.Value, len(columns))
+	if err := rows.Next(dest); err != nil {
+		if err == io.EOF {
+			return "", nil
+		}
+		return "", err
+	}
+	switch v := dest[0].(type) {
+	case []byte:
+		return string(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
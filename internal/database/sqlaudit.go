@@ -0,0 +1,381 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// sqlAudit logs the statements of every connection opened afterwards; nil
+// unless EnableSQLAudit was called
+var sqlAudit *sqlAuditor
+
+// EnableSQLAudit logs every statement run on the databases to the
+// configured output and checks it against the allowlist. It must be called
+// before connecting.
+func EnableSQLAudit(cfg config.SQLAuditConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	allowlist, err := cfg.AllowlistPatterns()
+	if err != nil {
+		return err
+	}
+
+	auditor := &sqlAuditor{allowlist: allowlist, enforce: cfg.Mode != config.SQLAuditReport}
+	switch cfg.Output {
+	case "stdout":
+		auditor.out = os.Stdout
+	case "stderr":
+		auditor.out = os.Stderr
+	default:
+		file, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open SQL audit log: %w", err)
+		}
+		auditor.out, auditor.file = file, file
+	}
+	sqlAudit = auditor
+	return nil
+}
+
+// CloseSQLAudit closes the SQL audit log file, after the connections were
+// closed
+func CloseSQLAudit() {
+	if sqlAudit != nil && sqlAudit.file != nil {
+		sqlAudit.file.Close()
+	}
+}
+
+// sqlAuditEntry is one statement in the SQL audit log
+type sqlAuditEntry struct {
+	Time       time.Time `json:"time"`
+	Pair       string    `json:"pair"`
+	Database   string    `json:"database"` // host:port
+	Statement  string    `json:"statement"`
+	Args       int       `json:"args,omitempty"` // bound arguments, whose values are not logged
+	DurationMS float64   `json:"duration_ms"`    // of a query, until its rows were read
+	Rows       int64     `json:"rows"`           // returned by a query or affected by a statement
+	Error      string    `json:"error,omitempty"`
+
+	// The statement matches no allowlist pattern; it was refused unless the
+	// mode is report
+	NotAllowlisted bool `json:"not_allowlisted,omitempty"`
+	Refused        bool `json:"refused,omitempty"`
+}
+
+// sqlAuditor writes audit entries as JSON lines and enforces the allowlist
+type sqlAuditor struct {
+	mu        sync.Mutex
+	out       io.Writer
+	file      *os.File // nil for stdout and stderr
+	allowlist []*regexp.Regexp
+	enforce   bool
+}
+
+// wrap returns a driver whose connections audit their statements as those of
+// a database of a pair
+func (a *sqlAuditor) wrap(d driver.Driver, pairName, address string) driver.Driver {
+	return &auditedDriver{driver: d, audit: &statementAudit{auditor: a, pair: pairName, database: address}}
+}
+
+// allowed reports whether a statement matches the allowlist
+func (a *sqlAuditor) allowed(query string) bool {
+	if len(a.allowlist) == 0 {
+		return true
+	}
+	for _, pattern := range a.allowlist {
+		if pattern.MatchString(query) {
+			return true
+		}
+	}
+	return false
+}
+
+// write emits a single JSON line
+func (a *sqlAuditor) write(entry sqlAuditEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.out.Write(append(line, '\n'))
+}
+
+// statementAudit audits the statements run on one database
+type statementAudit struct {
+	auditor  *sqlAuditor
+	pair     string
+	database string
+}
+
+// begin checks a statement against the allowlist before it runs. A refused
+// statement is logged and returns an error.
+func (s *statementAudit) begin(query string, args int) (*sqlAuditEntry, error) {
+	entry := &sqlAuditEntry{Pair: s.pair, Database: s.database, Statement: query, Args: args}
+	if !s.auditor.allowed(query) {
+		entry.NotAllowlisted = true
+		if s.auditor.enforce {
+			entry.Time = time.Now()
+			entry.Refused = true
+			err := fmt.Errorf("statement refused by sql_audit.allowlist: %s", query)
+			entry.Error = err.Error()
+			s.auditor.write(*entry)
+			return nil, err
+		}
+	}
+	entry.Time = time.Now()
+	return entry, nil
+}
+
+// end logs a statement once it finished
+func (s *statementAudit) end(entry *sqlAuditEntry, rows int64, err error) {
+	entry.DurationMS = float64(time.Since(entry.Time).Microseconds()) / 1000
+	entry.Rows = rows
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.auditor.write(*entry)
+}
+
+// query audits a query whose rows are counted until they are closed.
+// driver.ErrSkip means the driver falls back to a prepared statement, which
+// is audited itself.
+func (s *statementAudit) query(query string, args []driver.NamedValue, run func() (driver.Rows, error)) (driver.Rows, error) {
+	entry, err := s.begin(query, len(args))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := run()
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	if err != nil {
+		s.end(entry, 0, err)
+		return nil, err
+	}
+	return &auditedRows{Rows: rows, audit: s, entry: entry}, nil
+}
+
+// exec audits a statement with the rows it affected
+func (s *statementAudit) exec(query string, args []driver.NamedValue, run func() (driver.Result, error)) (driver.Result, error) {
+	entry, err := s.begin(query, len(args))
+	if err != nil {
+		return nil, err
+	}
+	result, err := run()
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	var affected int64
+	if err == nil {
+		affected, _ = result.RowsAffected()
+	}
+	s.end(entry, affected, err)
+	return result, err
+}
+
+// dsnConnector opens connections of a driver by DSN, as sql.Open does
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect opens a connection
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver returns the driver connections are opened with
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// auditedDriver opens audited connections
+type auditedDriver struct {
+	driver driver.Driver
+	audit  *statementAudit
+}
+
+// Open opens a connection of the wrapped driver
+func (d *auditedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &auditedConn{Conn: conn, audit: d.audit}, nil
+}
+
+// auditedConn audits the statements of a connection and forwards the
+// optional driver interfaces of the wrapped connection
+type auditedConn struct {
+	driver.Conn
+	audit *statementAudit
+}
+
+// QueryContext audits a query run without preparing it
+func (c *auditedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.audit.query(query, args, func() (driver.Rows, error) {
+		return queryer.QueryContext(ctx, query, args)
+	})
+}
+
+// ExecContext audits a statement run without preparing it
+func (c *auditedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.audit.exec(query, args, func() (driver.Result, error) {
+		return execer.ExecContext(ctx, query, args)
+	})
+}
+
+// PrepareContext prepares a statement whose executions are audited. A
+// statement outside an enforced allowlist is refused before it is prepared.
+func (c *auditedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if _, err := c.audit.begin(query, 0); err != nil {
+		return nil, err
+	}
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &auditedStmt{Stmt: stmt, query: query, audit: c.audit}, nil
+}
+
+// BeginTx starts a transaction on the wrapped connection
+func (c *auditedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping checks the wrapped connection
+func (c *auditedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the wrapped connection before reuse
+func (c *auditedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the wrapped connection can be reused
+func (c *auditedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue lets the wrapped connection convert query arguments
+func (c *auditedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// auditedStmt audits the executions of a prepared statement
+type auditedStmt struct {
+	driver.Stmt
+	query string
+	audit *statementAudit
+}
+
+// QueryContext audits one execution of the statement
+func (s *auditedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.audit.query(s.query, args, func() (driver.Rows, error) {
+		if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			return queryer.QueryContext(ctx, args)
+		}
+		return s.Stmt.Query(namedValues(args))
+	})
+}
+
+// ExecContext audits one execution of the statement
+func (s *auditedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.audit.exec(s.query, args, func() (driver.Result, error) {
+		if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+			return execer.ExecContext(ctx, args)
+		}
+		return s.Stmt.Exec(namedValues(args))
+	})
+}
+
+// CheckNamedValue lets the wrapped statement convert query arguments
+func (s *auditedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// auditedRows counts the rows of a query and logs it when they are closed
+type auditedRows struct {
+	driver.Rows
+	audit *statementAudit
+	entry *sqlAuditEntry
+	rows  int64
+	err   error
+	done  bool
+}
+
+// Next reads the next row
+func (r *auditedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.rows++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+// Close closes the rows and logs the query
+func (r *auditedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.done {
+		r.done = true
+		r.audit.end(r.entry, r.rows, r.err)
+	}
+	return err
+}
+
+// namedValues converts named arguments for drivers without context support
+func namedValues(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+	return vals
+}
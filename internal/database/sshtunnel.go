@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// sshTunnels are the bastions connections are dialled through, by the
+// network name registered with the MySQL driver. Databases behind the same
+// bastion share its SSH connection.
+var (
+	sshTunnelsMu sync.Mutex
+	sshTunnels   = make(map[string]*sshTunnel)
+)
+
+// sshTunnel dials database connections through an SSH bastion. The SSH
+// connection is established on the first dial and redialled once it fails.
+type sshTunnel struct {
+	cfg config.SSHTunnelConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// sshNetwork returns the driver network that dials through a bastion,
+// registering it on first use
+func sshNetwork(cfg *config.SSHTunnelConfig) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%t", cfg.Host, cfg.Port, cfg.User, cfg.PrivateKeyFile, cfg.UseAgent)
+	network := "ssh-" + strconv.FormatUint(h.Sum64(), 36)
+
+	sshTunnelsMu.Lock()
+	defer sshTunnelsMu.Unlock()
+	if _, ok := sshTunnels[network]; !ok {
+		tunnel := &sshTunnel{cfg: *cfg}
+		sshTunnels[network] = tunnel
+		mysql.RegisterDialContext(network, tunnel.dial)
+	}
+	return network
+}
+
+// CloseSSHTunnels closes the SSH connections of every bastion; connections
+// dialled later reconnect
+func CloseSSHTunnels() {
+	sshTunnelsMu.Lock()
+	defer sshTunnelsMu.Unlock()
+	for _, tunnel := range sshTunnels {
+		tunnel.mu.Lock()
+		if tunnel.client != nil {
+			tunnel.client.Close()
+			tunnel.client = nil
+		}
+		tunnel.mu.Unlock()
+	}
+}
+
+// dial opens a connection to a database address through the bastion
+func (t *sshTunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel via %s:%d to %s: %w", t.cfg.Host, t.cfg.Port, addr, err)
+	}
+	return conn, nil
+}
+
+// connect returns the SSH connection to the bastion, establishing it if needed
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	clientConfig, closeAgent, err := t.clientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel via %s:%d: %w", t.cfg.Host, t.cfg.Port, err)
+	}
+	defer closeAgent()
+
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel via %s: %w", addr, err)
+	}
+	// The handshake is bounded by the dial's deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh tunnel via %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	t.client = client
+	go t.keepAlive(client)
+	log.Printf("SSH tunnel established via %s as %s", addr, t.cfg.User)
+	return client, nil
+}
+
+// clientConfig builds the SSH client settings; the returned function closes
+// the agent connection once the handshake is done
+func (t *sshTunnel) clientConfig() (*ssh.ClientConfig, func(), error) {
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !t.cfg.InsecureIgnoreHostKey {
+		callback, err := knownhosts.New(t.cfg.KnownHostsFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read known hosts: %w", err)
+		}
+		hostKeyCallback = callback
+	}
+
+	var auth []ssh.AuthMethod
+	if t.cfg.PrivateKeyFile != "" {
+		key, err := os.ReadFile(t.cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		var signer ssh.Signer
+		if t.cfg.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(t.cfg.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse private key %s: %w", t.cfg.PrivateKeyFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	closeAgent := func() {}
+	if t.cfg.UseAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, nil, fmt.Errorf("use_agent is set but SSH_AUTH_SOCK is not")
+		}
+		agentConn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to the SSH agent: %w", err)
+		}
+		closeAgent = func() { agentConn.Close() }
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+	}
+
+	return &ssh.ClientConfig{
+		User:            t.cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, closeAgent, nil
+}
+
+// keepAlive probes the bastion every keepalive interval and drops the SSH
+// connection when it stops answering, so that the next dial reconnects
+func (t *sshTunnel) keepAlive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(t.cfg.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			t.drop(client)
+			return
+		case <-ticker.C:
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+			select {
+			case err := <-reply:
+				if err == nil {
+					continue
+				}
+				log.Printf("SSH tunnel via %s:%d failed: %v", t.cfg.Host, t.cfg.Port, err)
+			case <-time.After(t.cfg.KeepAlive):
+				log.Printf("SSH tunnel via %s:%d stopped answering keepalives", t.cfg.Host, t.cfg.Port)
+			}
+			client.Close()
+			t.drop(client)
+			return
+		}
+	}
+}
+
+// drop forgets a closed SSH connection unless it was already replaced
+func (t *sshTunnel) drop(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == client {
+		t.client = nil
+	}
+}
@@ -0,0 +1,172 @@
+package federation
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/monitor"
+)
+
+// PairRollup mirrors the /api/pairs rollup served by each monitor
+type PairRollup struct {
+	Name              string    `json:"name"`
+	Status            string    `json:"status"`
+	Lifecycle         string    `json:"lifecycle"`
+	Mode              string    `json:"mode"`
+	SourceConnected   bool      `json:"source_connected"`
+	TargetConnected   bool      `json:"target_connected"`
+	LagSeconds        float64   `json:"lag_seconds"`
+	LagStatus         string    `json:"lag_status"`
+	SourceWriteBytes  *float64  `json:"source_write_bytes_per_second,omitempty"`
+	HealthScore       *float64  `json:"health_score"`
+	ChecksumPassed    int       `json:"checksum_passed"`
+	ChecksumTotal     int       `json:"checksum_total"`
+	ConsistencyPassed int       `json:"consistency_passed"`
+	ConsistencyTotal  int       `json:"consistency_total"`
+	ActiveAlerts      int       `json:"active_alerts"`
+	CriticalAlerts    int       `json:"critical_alerts"`
+	SuppressedAlerts  int       `json:"suppressed_alerts"`
+	Maintenance       string    `json:"maintenance,omitempty"`
+	LastChecked       time.Time `json:"last_checked"`
+
+	PausedChecks []monitor.PausedCheck `json:"paused_checks,omitempty"`
+
+	Metadata config.PairMetadata `json:"metadata"`
+}
+
+// PeerState represents the last known state of a peer monitor
+type PeerState struct {
+	Name      string       `json:"name"`
+	URL       string       `json:"url"`
+	Reachable bool         `json:"reachable"`
+	Error     string       `json:"error,omitempty"`
+	LastPoll  time.Time    `json:"last_poll"`
+	Pairs     []PairRollup `json:"pairs"`
+}
+
+// Aggregator polls peer monitors and keeps their pair rollups
+type Aggregator struct {
+	config   config.FederationConfig
+	client   *http.Client
+	mu       sync.RWMutex
+	peers    map[string]*PeerState // key: peer name
+	stopChan chan struct{}
+}
+
+// NewAggregator creates a new federation aggregator
+func NewAggregator(cfg config.FederationConfig) *Aggregator {
+	peers := make(map[string]*PeerState, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		peers[peer.Name] = &PeerState{
+			Name:  peer.Name,
+			URL:   peer.URL,
+			Pairs: make([]PairRollup, 0),
+		}
+	}
+
+	return &Aggregator{
+		config:   cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		peers:    peers,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start starts polling peers in the background
+func (a *Aggregator) Start() {
+	log.Printf("Starting federation with %d peer monitor(s)", len(a.config.Peers))
+	go a.pollLoop()
+}
+
+// Stop stops polling peers
+func (a *Aggregator) Stop() {
+	close(a.stopChan)
+}
+
+// Peers returns the last known state of every peer
+func (a *Aggregator) Peers() []PeerState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	states := make([]PeerState, 0, len(a.config.Peers))
+	for _, peer := range a.config.Peers {
+		states = append(states, *a.peers[peer.Name])
+	}
+	return states
+}
+
+// pollLoop polls all peers at the configured interval
+func (a *Aggregator) pollLoop() {
+	ticker := time.NewTicker(a.config.PollInterval)
+	defer ticker.Stop()
+
+	a.pollAll()
+	for {
+		select {
+		case <-ticker.C:
+			a.pollAll()
+		case <-a.stopChan:
+			return
+		}
+	}
+}
+
+// pollAll polls every peer concurrently
+func (a *Aggregator) pollAll() {
+	var wg sync.WaitGroup
+	for _, peer := range a.config.Peers {
+		wg.Add(1)
+		go func(peer config.FederationPeer) {
+			defer wg.Done()
+			pairs, err := a.fetchPairs(peer)
+
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			state := a.peers[peer.Name]
+			state.LastPoll = time.Now()
+			if err != nil {
+				log.Printf("Federation: failed to poll peer '%s': %v", peer.Name, err)
+				state.Reachable = false
+				state.Error = err.Error()
+				return
+			}
+			state.Reachable = true
+			state.Error = ""
+			state.Pairs = pairs
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// fetchPairs fetches the pair rollups of a peer. The unversioned path is
+// also served by peers older than /api/v1.
+func (a *Aggregator) fetchPairs(peer config.FederationPeer) ([]PairRollup, error) {
+	req, err := http.NewRequest(http.MethodGet, peer.URL+"/api/pairs", nil)
+	if err != nil {
+		return nil, err
+	}
+	if peer.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+peer.Token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var pairs []PairRollup
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, fmt.Errorf("failed to decode pairs: %w", err)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"math"
+	"sync"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// minLagStdDev floors the standard deviation of the lag baseline:
+// Seconds_Behind_Master has a resolution of one second, so a perfectly
+// steady lag would otherwise make any change infinitely anomalous
+const minLagStdDev = 0.5
+
+// lagAnomaly scores a replica lag sample against the baseline of the
+// samples before it
+type lagAnomaly struct {
+	Severity   string // "", "INFO" or "WARNING"
+	LagSeconds float64
+	Baseline   float64 // moving average of the lag, in seconds
+	StdDev     float64
+	ZScore     float64
+	Ready      bool // the baseline has min_samples samples
+}
+
+// lagAnomalyDetector keeps an exponentially weighted moving average of the
+// replica lag of a pair and of its noise. Anomalous samples are kept out of
+// the baseline so that slowly rising lag keeps standing out, until
+// relearn_after of them in a row make the lag the new normal.
+type lagAnomalyDetector struct {
+	config config.LagAnomalyConfig
+
+	mu        sync.Mutex
+	samples   int
+	mean      float64
+	variance  float64 // of the lag around its trend
+	last      float64 // the previous sample
+	anomalous int     // anomalous samples since the last normal one
+}
+
+// newLagAnomalyDetector creates a detector, or returns nil when anomaly
+// detection is disabled
+func newLagAnomalyDetector(cfg config.LagAnomalyConfig) *lagAnomalyDetector {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &lagAnomalyDetector{config: cfg}
+}
+
+// reset drops the baseline, e.g. after a failover to another instance
+func (d *lagAnomalyDetector) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples, d.mean, d.variance, d.last, d.anomalous = 0, 0, 0, 0, 0
+}
+
+// observe scores a lag sample, then folds it into the baseline unless it is
+// anomalous. Only rising lag is anomalous.
+func (d *lagAnomalyDetector) observe(lagSeconds float64) lagAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := lagAnomaly{
+		LagSeconds: lagSeconds,
+		Baseline:   d.mean,
+		StdDev:     math.Max(math.Sqrt(d.variance), minLagStdDev),
+		Ready:      d.samples >= d.config.MinSamples,
+	}
+	deviation := lagSeconds - d.mean
+	result.ZScore = deviation / result.StdDev
+	defer func() { d.last = lagSeconds }()
+
+	if result.Ready && deviation >= d.config.MinDeviation.Seconds() {
+		switch {
+		case result.ZScore >= d.config.WarningZScore:
+			result.Severity = "WARNING"
+		case result.ZScore >= d.config.InfoZScore:
+			result.Severity = "INFO"
+		}
+	}
+
+	// Samples past info_z_score stay out of the baseline even when they
+	// deviate less than min_deviation, so a slow rise does not drag it along
+	if !result.Ready || result.ZScore < d.config.InfoZScore {
+		d.anomalous = 0
+		d.add(lagSeconds)
+		return result
+	}
+	if result.Severity == "" {
+		return result
+	}
+	d.anomalous++
+	if d.anomalous >= d.config.RelearnAfter {
+		// The lag stayed up: learn it as the new baseline
+		d.samples, d.mean, d.variance, d.anomalous = 0, 0, 0, 0
+		d.add(lagSeconds)
+	}
+	return result
+}
+
+// add folds a sample into the moving average and noise. The noise is
+// estimated from the change between consecutive samples rather than from
+// the deviation from the average, so a trend does not inflate it. Until
+// the baseline has 1/alpha samples, they are weighted equally.
+func (d *lagAnomalyDetector) add(lagSeconds float64) {
+	d.samples++
+	alpha := max(d.config.Alpha, 1/float64(d.samples))
+	d.mean += alpha * (lagSeconds - d.mean)
+	if d.samples > 1 {
+		step := lagSeconds - d.last
+		d.variance += max(d.config.Alpha, 1/float64(d.samples-1)) * (step*step/2 - d.variance)
+	}
+}
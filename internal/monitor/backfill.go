@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// Backfill is a declared job reprocessing the history of some tables of a
+// pair. While it runs, consistency checks of those tables are compared within
+// a relative tolerance and annotated instead of alerting on every difference.
+type Backfill struct {
+	ID               string    `json:"id"`
+	Pair             string    `json:"pair"`
+	Tables           []string  `json:"tables"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	TolerancePercent float64   `json:"tolerance_percent"`
+	Reason           string    `json:"reason,omitempty"`
+	DeclaredBy       string    `json:"declared_by"`
+	DeclaredAt       time.Time `json:"declared_at"`
+}
+
+// covers reports whether the backfill relaxes a table at a point in time
+func (b *Backfill) covers(table string, at time.Time) bool {
+	return !at.Before(b.Start) && at.Before(b.End) && slices.Contains(b.Tables, table)
+}
+
+// DeclareBackfill registers a backfill. A zero start means now and a zero
+// tolerance uses backfill.default_tolerance_percent.
+func (me *MonitoringEngine) DeclareBackfill(b Backfill) (Backfill, error) {
+	if me.findPairMonitor(b.Pair) == nil {
+		return Backfill{}, fmt.Errorf("database pair '%s' not found", b.Pair)
+	}
+	if len(b.Tables) == 0 {
+		return Backfill{}, fmt.Errorf("tables is required")
+	}
+	for _, table := range b.Tables {
+		if table == "" {
+			return Backfill{}, fmt.Errorf("table names cannot be empty")
+		}
+	}
+
+	now := me.clock.Now()
+	if b.Start.IsZero() {
+		b.Start = now
+	}
+	switch {
+	case b.End.IsZero():
+		return Backfill{}, fmt.Errorf("end is required")
+	case !b.End.After(b.Start):
+		return Backfill{}, fmt.Errorf("end must be after start")
+	case !b.End.After(now):
+		return Backfill{}, fmt.Errorf("end must be in the future")
+	case b.End.Sub(b.Start) > me.config.Backfill.MaxDuration:
+		return Backfill{}, fmt.Errorf("backfill cannot be longer than %v", me.config.Backfill.MaxDuration)
+	}
+
+	if b.TolerancePercent < 0 || b.TolerancePercent > 100 {
+		return Backfill{}, fmt.Errorf("tolerance_percent must be between 0 and 100")
+	}
+	if b.TolerancePercent == 0 {
+		b.TolerancePercent = me.config.Backfill.DefaultTolerancePercent
+	}
+
+	me.backfillMu.Lock()
+	me.nextBackfillID++
+	b.ID = fmt.Sprintf("backfill-%d", me.nextBackfillID)
+	b.DeclaredAt = now
+	me.backfills = append(me.backfills, b)
+	me.backfillMu.Unlock()
+
+	log.Printf("[%s] Backfill %s declared for %v from %s to %s (tolerance %.1f%%)",
+		b.Pair, b.ID, b.Tables, b.Start.Format(time.RFC3339), b.End.Format(time.RFC3339), b.TolerancePercent)
+	return b, nil
+}
+
+// Backfills returns the running and upcoming backfills, optionally for one pair
+func (me *MonitoringEngine) Backfills(pairName string) []Backfill {
+	me.backfillMu.Lock()
+	defer me.backfillMu.Unlock()
+
+	me.pruneBackfills(me.clock.Now())
+	backfills := make([]Backfill, 0, len(me.backfills))
+	for _, b := range me.backfills {
+		if pairName == "" || b.Pair == pairName {
+			backfills = append(backfills, b)
+		}
+	}
+	return backfills
+}
+
+// CancelBackfill removes a backfill before its end
+func (me *MonitoringEngine) CancelBackfill(id string) (Backfill, error) {
+	me.backfillMu.Lock()
+	defer me.backfillMu.Unlock()
+
+	for i, b := range me.backfills {
+		if b.ID == id {
+			me.backfills = slices.Delete(me.backfills, i, i+1)
+			log.Printf("[%s] Backfill %s cancelled", b.Pair, b.ID)
+			return b, nil
+		}
+	}
+	return Backfill{}, fmt.Errorf("backfill '%s' not found", id)
+}
+
+// pruneBackfills drops backfills that have ended; backfillMu must be held
+func (me *MonitoringEngine) pruneBackfills(now time.Time) {
+	me.backfills = slices.DeleteFunc(me.backfills, func(b Backfill) bool {
+		return !now.Before(b.End)
+	})
+}
+
+// applyBackfill relaxes a consistency result whose table is being backfilled:
+// the counts are compared within the backfill tolerance (or the approximate
+// count tolerance, if larger) and the result is annotated with the backfill
+func (me *MonitoringEngine) applyBackfill(pairName string, result *ConsistencyResult) {
+	if result.Error != nil {
+		return
+	}
+
+	me.backfillMu.Lock()
+	var backfill *Backfill
+	for i := range me.backfills {
+		if me.backfills[i].Pair == pairName && me.backfills[i].covers(result.TableName, result.Timestamp) {
+			b := me.backfills[i]
+			backfill = &b
+			break
+		}
+	}
+	me.backfillMu.Unlock()
+	if backfill == nil {
+		return
+	}
+
+	result.Backfill = backfill.ID
+	if backfill.TolerancePercent > result.Tolerance {
+		result.Tolerance = backfill.TolerancePercent
+	}
+	result.Consistent = withinTolerance(result.SourceRowCount, result.TargetRowCount, result.Tolerance)
+}
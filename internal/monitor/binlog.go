@@ -0,0 +1,218 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// BinlogPosition is a position in the binary log of a replica's primary
+type BinlogPosition struct {
+	File string
+	Pos  int64
+}
+
+// BinlogBacklog is how far the replication threads of a replica are behind,
+// in bytes of the primary's binary log. The IO thread falls behind when the
+// network or the primary is slow; the SQL thread when applying is, e.g. while
+// a large ALTER TABLE ... ENCRYPTION='Y' replays.
+type BinlogBacklog struct {
+	SourcePosition *BinlogPosition // primary's current position; nil when unknown
+	ReadPosition   BinlogPosition  // received by the IO thread
+	ExecPosition   BinlogPosition  // applied by the SQL thread
+
+	IOBytes  *int64 // source position minus read position; nil when unknown
+	SQLBytes *int64 // read position minus exec position; nil when unknown
+}
+
+// Bottleneck reports which thread holds the larger backlog: "io", "sql", or
+// "" when neither is behind or the backlog is unknown
+func (b *BinlogBacklog) Bottleneck() string {
+	var ioBytes, sqlBytes int64
+	if b.IOBytes != nil {
+		ioBytes = *b.IOBytes
+	}
+	if b.SQLBytes != nil {
+		sqlBytes = *b.SQLBytes
+	}
+	switch {
+	case ioBytes == 0 && sqlBytes == 0:
+		return ""
+	case ioBytes >= sqlBytes:
+		return "io"
+	}
+	return "sql"
+}
+
+// String describes the backlog, e.g. for alert messages
+func (b *BinlogBacklog) String() string {
+	bytes := func(n *int64) string {
+		if n == nil {
+			return "unknown"
+		}
+		return fmt.Sprintf("%d bytes", *n)
+	}
+	return fmt.Sprintf("IO thread %s behind, SQL thread %s behind", bytes(b.IOBytes), bytes(b.SQLBytes))
+}
+
+// binlogFile is a binary log of the primary and its size
+type binlogFile struct {
+	name string
+	size int64
+}
+
+// backlogPositions reads the read and exec positions from a scanned replica
+// status row, indexed by the SHOW SLAVE STATUS column names
+func backlogPositions(values []interface{}, columnMap map[string]int) (*BinlogBacklog, bool) {
+	readFile, ok1 := stringColumn(values, columnMap, "Master_Log_File")
+	readPos, ok2 := numericColumn(values, columnMap, "Read_Master_Log_Pos")
+	execFile, ok3 := stringColumn(values, columnMap, "Relay_Master_Log_File")
+	execPos, ok4 := numericColumn(values, columnMap, "Exec_Master_Log_Pos")
+	if !ok1 || !ok2 || !ok3 || !ok4 || readFile == "" || execFile == "" {
+		return nil, false
+	}
+	return &BinlogBacklog{
+		ReadPosition: BinlogPosition{File: readFile, Pos: int64(readPos)},
+		ExecPosition: BinlogPosition{File: execFile, Pos: int64(execPos)},
+	}, true
+}
+
+// measureBacklog completes the positions of a backlog with the primary's
+// position and computes the byte distances. source may be nil, in which case
+// only a SQL backlog within one binary log can be computed.
+func measureBacklog(ctx context.Context, source *sql.DB, flavor serverFlavor, backlog *BinlogBacklog) {
+	var files []binlogFile
+	if source != nil {
+		if pos, err := sourcePosition(ctx, source, flavor.sourceStatusQuery()); err == nil {
+			backlog.SourcePosition = pos
+		}
+		// Sizes are only needed when a backlog spans binary logs
+		if backlog.ReadPosition.File != backlog.ExecPosition.File ||
+			(backlog.SourcePosition != nil && backlog.SourcePosition.File != backlog.ReadPosition.File) {
+			files, _ = binaryLogs(ctx, source)
+		}
+	}
+
+	if backlog.SourcePosition != nil {
+		if n, ok := binlogDistance(backlog.ReadPosition, *backlog.SourcePosition, files); ok {
+			backlog.IOBytes = &n
+		}
+	}
+	if n, ok := binlogDistance(backlog.ExecPosition, backlog.ReadPosition, files); ok {
+		backlog.SQLBytes = &n
+	}
+}
+
+// sourcePosition reads the current binary log position of a primary with
+// SHOW MASTER STATUS, or the statement replacing it
+func sourcePosition(ctx context.Context, db *sql.DB, query string) (*BinlogPosition, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, fmt.Errorf("binary logging is disabled")
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+	columnMap := make(map[string]int)
+	for i, col := range columns {
+		columnMap[col] = i
+	}
+
+	file, ok := stringColumn(values, columnMap, "File")
+	pos, ok2 := numericColumn(values, columnMap, "Position")
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("%s returned no position", query)
+	}
+	return &BinlogPosition{File: file, Pos: int64(pos)}, nil
+}
+
+// binaryLogs lists the binary logs of a primary, oldest first
+func binaryLogs(ctx context.Context, db *sql.DB) ([]binlogFile, error) {
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) < 2 {
+		return nil, fmt.Errorf("SHOW BINARY LOGS returned %d columns", len(columns))
+	}
+	var files []binlogFile
+	for rows.Next() {
+		// MySQL 8 adds an Encrypted column; only the first two are needed
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+		columnMap := map[string]int{"Log_name": 0, "File_size": 1}
+		name, ok := stringColumn(values, columnMap, "Log_name")
+		size, ok2 := numericColumn(values, columnMap, "File_size")
+		if ok && ok2 {
+			files = append(files, binlogFile{name: name, size: int64(size)})
+		}
+	}
+	return files, rows.Err()
+}
+
+// binlogDistance returns the bytes of binary log between two positions.
+// Positions in different logs need the sizes of the logs between them.
+func binlogDistance(from, to BinlogPosition, files []binlogFile) (int64, bool) {
+	if from.File == to.File {
+		return max(to.Pos-from.Pos, 0), true
+	}
+
+	var distance int64
+	counting := false
+	for _, f := range files {
+		switch {
+		case f.name == from.File:
+			counting = true
+			distance += max(f.size-from.Pos, 0)
+		case f.name == to.File:
+			if !counting {
+				return 0, false // to precedes from, or from was purged
+			}
+			return distance + to.Pos, true
+		case counting:
+			distance += f.size
+		}
+	}
+	return 0, false
+}
+
+// stringColumn reads a text column from a scanned result row
+func stringColumn(values []interface{}, columnMap map[string]int, name string) (string, bool) {
+	idx, ok := columnMap[name]
+	if !ok || idx >= len(values) {
+		return "", false
+	}
+	switch v := values[idx].(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
@@ -0,0 +1,223 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// binlogRateAlpha weights a write rate sample in the moving average the
+// current rate is compared with, averaging over roughly the last 20 samples
+const binlogRateAlpha = 0.05
+
+// burstFactor is how many times its moving average the source's write rate
+// must be for lag to be put down to a write burst
+const burstFactor = 2.0
+
+// BinlogRate is how fast the source writes its binary log. Comparing it with
+// the target's lag tells lag caused by a burst of writes on the source from
+// lag caused by the target applying slowly.
+type BinlogRate struct {
+	Timestamp time.Time
+	Position  BinlogPosition
+
+	// Rates since the previous sample; nil on the first sample, after a
+	// restart of the source or when the previous position was purged
+	BytesPerSecond  *float64
+	EventsPerSecond *float64 // transactions written to the binary log
+
+	// Moving average of BytesPerSecond, which Burst compares it with
+	AvgBytesPerSecond float64
+
+	Error error
+}
+
+// Burst reports whether the source writes its binary log at least
+// burstFactor times faster than on average
+func (r *BinlogRate) Burst() bool {
+	return r.BytesPerSecond != nil && r.AvgBytesPerSecond > 0 && *r.BytesPerSecond >= burstFactor*r.AvgBytesPerSecond
+}
+
+// String describes the write rate, e.g. for alert messages
+func (r *BinlogRate) String() string {
+	if r.BytesPerSecond == nil {
+		return ""
+	}
+	s := fmt.Sprintf("source writing %.0f bytes/s of binary log", *r.BytesPerSecond)
+	if r.EventsPerSecond != nil {
+		s += fmt.Sprintf(" (%.1f transactions/s)", *r.EventsPerSecond)
+	}
+	if r.AvgBytesPerSecond > 0 {
+		s += fmt.Sprintf(", %.1fx its average", *r.BytesPerSecond/r.AvgBytesPerSecond)
+	}
+	if r.Burst() {
+		s += ": a write burst on the source"
+	}
+	return s
+}
+
+// binlogSample is a reading of the source's binary log position and commit
+// counter
+type binlogSample struct {
+	at       time.Time
+	position BinlogPosition
+	commits  int64 // -1 when the server reports no counter
+}
+
+// BinlogRateMonitor samples the binary log position of a pair's source each
+// cycle and derives its write throughput
+type BinlogRateMonitor struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	timeout time.Duration
+	flavor  flavorCache
+
+	mu      sync.Mutex
+	last    *binlogSample
+	average float64 // moving average of the bytes written per second
+	lastErr string  // logged once until the error changes
+}
+
+// NewBinlogRateMonitor creates a new binary log write rate monitor
+func NewBinlogRateMonitor(connMgr *database.ConnectionManager, timeout time.Duration) *BinlogRateMonitor {
+	return &BinlogRateMonitor{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		timeout: timeout,
+	}
+}
+
+// Measure reads the source's binary log position and computes the write
+// rate since the previous measurement
+func (bm *BinlogRateMonitor) Measure(ctx context.Context) (*BinlogRate, error) {
+	rate := &BinlogRate{Timestamp: bm.clock.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, bm.timeout)
+	defer cancel()
+
+	source, err := bm.connMgr.GetSourceConnection()
+	if err != nil {
+		rate.Error = fmt.Errorf("source connection error: %w", err)
+		return rate, rate.Error
+	}
+	position, err := sourcePosition(ctx, source, bm.flavor.get(ctx, source).sourceStatusQuery())
+	if err != nil {
+		rate.Error = fmt.Errorf("binary log position error: %w", err)
+		return rate, rate.Error
+	}
+	commits, err := binlogCommits(ctx, source)
+	if err != nil {
+		rate.Error = fmt.Errorf("binary log status error: %w", err)
+		return rate, rate.Error
+	}
+	rate.Position = *position
+	sample := &binlogSample{at: rate.Timestamp, position: *position, commits: commits}
+
+	bm.mu.Lock()
+	last := bm.last
+	bm.mu.Unlock()
+
+	var bytesWritten *int64
+	if last != nil {
+		var files []binlogFile
+		if last.position.File != position.File {
+			files, _ = binaryLogs(ctx, source)
+		}
+		if n, ok := binlogDistance(last.position, *position, files); ok {
+			bytesWritten = &n
+		}
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.last = sample
+	if last == nil {
+		return rate, nil
+	}
+	elapsed := sample.at.Sub(last.at).Seconds()
+	if elapsed <= 0 {
+		return rate, nil
+	}
+	if bytesWritten != nil {
+		bytesPerSecond := float64(*bytesWritten) / elapsed
+		rate.BytesPerSecond = &bytesPerSecond
+		// The burst is judged against the average of the samples before it
+		if bm.average == 0 {
+			bm.average = bytesPerSecond
+		}
+		rate.AvgBytesPerSecond = bm.average
+		bm.average += binlogRateAlpha * (bytesPerSecond - bm.average)
+	}
+	// The counter resets when the source restarts
+	if sample.commits >= 0 && last.commits >= 0 && sample.commits >= last.commits {
+		eventsPerSecond := float64(sample.commits-last.commits) / elapsed
+		rate.EventsPerSecond = &eventsPerSecond
+	}
+	return rate, nil
+}
+
+// reset drops the previous sample and the average write rate, e.g. after a
+// failover of the source, whose binary log positions then start over
+func (bm *BinlogRateMonitor) reset() {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.last = nil
+	bm.average = 0
+}
+
+// logError reports whether an error differs from the previous one, so that a
+// source without binary logging is logged once rather than every cycle
+func (bm *BinlogRateMonitor) logError(err error) bool {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	changed := message != bm.lastErr
+	bm.lastErr = message
+	return changed && err != nil
+}
+
+// binlogCommits reads the number of transactions written to the binary log:
+// Binlog_commits on MariaDB, otherwise the uses of the transaction and
+// statement caches every binary log write goes through. It returns -1 when
+// the server reports neither.
+func binlogCommits(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := db.QueryContext(ctx, "SHOW GLOBAL STATUS LIKE 'Binlog_%'")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	status := make(map[string]int64)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return 0, err
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			status[strings.ToLower(name)] = n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if commits, ok := status["binlog_commits"]; ok {
+		return commits, nil
+	}
+	cacheUse, ok1 := status["binlog_cache_use"]
+	stmtCacheUse, ok2 := status["binlog_stmt_cache_use"]
+	if !ok1 && !ok2 {
+		return -1, nil
+	}
+	return cacheUse + stmtCacheUse, nil
+}
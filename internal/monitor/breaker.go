@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// circuitBreaker backs off the health checks of an unreachable database.
+// After failure_threshold failed checks in a row it opens: the database is
+// reported down without being pinged until its backoff elapses, then probed
+// once. A failed probe doubles the backoff up to max_backoff; a successful
+// one closes the breaker.
+type circuitBreaker struct {
+	failures  int       // failed health checks in a row
+	since     time.Time // first failed check of the current outage
+	backoff   time.Duration
+	openUntil time.Time // zero while closed
+}
+
+// open reports whether the breaker is open, probing or not
+func (b *circuitBreaker) open() bool {
+	return !b.openUntil.IsZero()
+}
+
+// allow reports whether the database is to be pinged: while the breaker is
+// closed, and once the backoff of an open breaker elapsed
+func (b *circuitBreaker) allow(now time.Time) bool {
+	return !now.Before(b.openUntil)
+}
+
+// record notes the result of a health check and reports whether the
+// breaker opened or closed with it
+func (b *circuitBreaker) record(cfg config.CircuitBreakerConfig, ok bool, now time.Time) (opened, closed bool) {
+	if ok {
+		closed = b.open()
+		*b = circuitBreaker{}
+		return false, closed
+	}
+	if b.failures == 0 {
+		b.since = now
+	}
+	b.failures++
+	if b.failures < cfg.FailureThreshold {
+		return false, false
+	}
+	if b.open() {
+		b.backoff = min(2*b.backoff, cfg.MaxBackoff)
+	} else {
+		b.backoff = cfg.InitialBackoff
+		opened = true
+	}
+	b.openUntil = now.Add(b.backoff)
+	return opened, false
+}
+
+// pairBreakers holds the circuit breakers of a pair's databases
+type pairBreakers struct {
+	mu     sync.Mutex
+	source circuitBreaker
+	target circuitBreaker
+}
+
+// probeNow makes open breakers probe their database on the next health
+// check, as after new credentials or hosts were applied
+func (p *pairBreakers) probeNow() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, b := range []*circuitBreaker{&p.source, &p.target} {
+		if b.open() {
+			b.openUntil = b.since
+		}
+	}
+}
+
+// pairHealth is the outcome of a pair's health check
+type pairHealth struct {
+	SourceOK, TargetOK bool
+
+	// Next probe of a database whose circuit breaker is open; nil while it
+	// is closed
+	SourceRetryAt, TargetRetryAt *time.Time
+}
+
+// backedOff reports whether a database is down with its breaker open, so
+// checks skip it without logging every cycle
+func (h pairHealth) backedOff() bool {
+	return (!h.SourceOK && h.SourceRetryAt != nil) || (!h.TargetOK && h.TargetRetryAt != nil)
+}
+
+// allBackedOff reports whether the breakers of both databases are open, so
+// the cycle has nothing to check
+func (h pairHealth) allBackedOff() bool {
+	return !h.SourceOK && !h.TargetOK && h.SourceRetryAt != nil && h.TargetRetryAt != nil
+}
+
+// healthCheck pings the databases of a pair whose circuit breaker allows it,
+// logs breakers opening and closing, and raises or resolves the sustained
+// unreachable alert of each database
+func (me *MonitoringEngine) healthCheck(ctx context.Context, pm *DatabasePairMonitor) pairHealth {
+	cfg := me.config.CircuitBreaker
+	now := me.clock.Now()
+
+	pm.breakers.mu.Lock()
+	defer pm.breakers.mu.Unlock()
+
+	var health pairHealth
+	health.SourceOK, health.TargetOK = pm.connMgr.CheckHealth(ctx, pm.breakers.source.allow(now), pm.breakers.target.allow(now))
+	for _, side := range []struct {
+		name    string
+		breaker *circuitBreaker
+		ok      bool
+		retryAt **time.Time
+	}{
+		{"source", &pm.breakers.source, health.SourceOK, &health.SourceRetryAt},
+		{"target", &pm.breakers.target, health.TargetOK, &health.TargetRetryAt},
+	} {
+		b := side.breaker
+		if b.allow(now) {
+			since := b.since
+			opened, closed := b.record(cfg, side.ok, now)
+			switch {
+			case opened:
+				log.Printf("[%s] %s database unreachable for %d health checks, backing off its checks for %v",
+					pm.pairName, side.name, b.failures, b.backoff)
+			case closed:
+				log.Printf("[%s] %s database reachable again after %v", pm.pairName, side.name, now.Sub(since).Round(time.Second))
+			case b.open():
+				log.Printf("[%s] %s database still unreachable, backing off its checks for %v", pm.pairName, side.name, b.backoff)
+			}
+		}
+
+		var since *time.Time
+		if b.open() {
+			retryAt := b.openUntil
+			*side.retryAt = &retryAt
+			since = &b.since
+		}
+		me.alertMgr.EvaluateUnreachable(pm.pairName, side.name, since)
+	}
+	return health
+}
@@ -0,0 +1,197 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// HopLag is the replication lag of one hop of a replication chain, measured
+// on the instance that replicates: an intermediate or the target
+type HopLag struct {
+	Name       string
+	LagSeconds float64
+	Status     string
+	Error      error
+}
+
+// hopMonitor measures the lag of an intermediate instance of a pair's
+// replication chain, from the instance before it
+type hopMonitor struct {
+	name              string
+	connMgr           *database.ConnectionManager // connects only the intermediate, as its target
+	replicaLagMonitor *ReplicaLagMonitor
+}
+
+// newHopMonitors creates monitors for the intermediates of a pair
+func newHopMonitors(pair *config.DatabasePair, limiter *database.InstanceLimiter, cfg *config.Config) []*hopMonitor {
+	hops := make([]*hopMonitor, 0, len(pair.Intermediates))
+	for i := range pair.Intermediates {
+		hop := &pair.Intermediates[i]
+		connMgr := database.NewConnectionManager(nil, &hop.DB, pair.Name+"/"+hop.Name, limiter, cfg.Timeouts.Connect)
+		hops = append(hops, &hopMonitor{
+			name:              hop.Name,
+			connMgr:           connMgr,
+			replicaLagMonitor: NewReplicaLagMonitor(connMgr, cfg.Timeouts.ReplicaLag),
+		})
+	}
+	return hops
+}
+
+// measureChain measures the lag of every intermediate of a pair and combines
+// it with the target's lag. The combined lag is the sum of all hops; its
+// status is that of the first hop, in chain order, that is not ok.
+func (me *MonitoringEngine) measureChain(ctx context.Context, pm *DatabasePairMonitor, target *ReplicaLagMetric) *ReplicaLagMetric {
+	hops := make([]HopLag, len(pm.hops)+1)
+
+	var wg sync.WaitGroup
+	for i, hop := range pm.hops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hops[i] = HopLag{Name: hop.name, Status: "unknown"}
+			metric, err := hop.replicaLagMonitor.MeasureLag(ctx)
+			if err != nil {
+				log.Printf("[%s] Replica lag monitoring error on intermediate '%s': %v", pm.pairName, hop.name, err)
+			}
+			if metric != nil {
+				hops[i] = HopLag{Name: hop.name, LagSeconds: metric.LagSeconds, Status: metric.Status, Error: metric.Error}
+			}
+		}()
+	}
+	wg.Wait()
+	hops[len(pm.hops)] = HopLag{Name: "target", LagSeconds: target.LagSeconds, Status: target.Status, Error: target.Error}
+
+	combined := *target
+	combined.Hops = hops
+	combined.LagSeconds = 0
+	combined.Status = "ok"
+	combined.Error = nil
+	for _, hop := range hops {
+		combined.LagSeconds += hop.LagSeconds
+		if combined.Status == "ok" && hop.Status != "ok" {
+			combined.Status = hop.Status
+			combined.Error = fmt.Errorf("hop '%s': %v", hop.Name, hop.Error)
+		}
+	}
+	return &combined
+}
+
+// slowestHop describes the hop with the most lag, e.g. for alert messages
+func slowestHop(hops []HopLag) string {
+	if len(hops) < 2 {
+		return ""
+	}
+	slowest := hops[0]
+	for _, hop := range hops[1:] {
+		if hop.LagSeconds > slowest.LagSeconds {
+			slowest = hop
+		}
+	}
+	return fmt.Sprintf("%s (%.2f seconds)", slowest.Name, slowest.LagSeconds)
+}
+
+// connectHops connects the intermediates of a pair
+func (me *MonitoringEngine) connectHops(pm *DatabasePairMonitor) {
+	for _, hop := range pm.hops {
+		if err := hop.connMgr.ConnectTarget(pm.ctx); err != nil {
+			log.Printf("Warning: Failed to connect to intermediate '%s' for pair '%s': %v", hop.name, pm.pairName, err)
+		}
+	}
+}
+
+// connectCheckEndpoints connects the check endpoints and the warm-up
+// connection of a pair
+func (me *MonitoringEngine) connectCheckEndpoints(pm *DatabasePairMonitor) {
+	if pm.checkConnMgr != nil {
+		if err := pm.checkConnMgr.ConnectSource(pm.ctx); err != nil {
+			log.Printf("Warning: Failed to connect to source check endpoint for pair '%s': %v", pm.pairName, err)
+		}
+		if err := pm.checkConnMgr.ConnectTarget(pm.ctx); err != nil {
+			log.Printf("Warning: Failed to connect to target check endpoint for pair '%s': %v", pm.pairName, err)
+		}
+	}
+	if pm.warmupConnMgr != nil {
+		if err := pm.warmupConnMgr.ConnectTarget(pm.ctx); err != nil {
+			log.Printf("Warning: Failed to connect to target for warm-up checks of pair '%s': %v", pm.pairName, err)
+		}
+	}
+}
+
+// warmupDatabase returns the connection settings of the warm-up checks of a
+// pair, whose sessions are read-only whatever read_only says
+func warmupDatabase(pair *config.DatabasePair) *config.DatabaseConfig {
+	db := pair.Warmup.DB
+	db.ReadOnlySession = true
+	return &db
+}
+
+// keepConnected retries databases of a pair, including its intermediates,
+// check endpoints and warm-up connection, that could not be connected
+func (pm *DatabasePairMonitor) keepConnected(ctx context.Context) {
+	pm.connMgr.KeepConnected(ctx)
+	for _, hop := range pm.hops {
+		hop.connMgr.KeepConnected(ctx)
+	}
+	if pm.checkConnMgr != nil {
+		pm.checkConnMgr.KeepConnected(ctx)
+	}
+	if pm.warmupConnMgr != nil {
+		pm.warmupConnMgr.KeepConnected(ctx)
+	}
+}
+
+// close closes the connections of a pair, including its intermediates, check
+// endpoints and warm-up connection
+func (pm *DatabasePairMonitor) close() {
+	pm.connMgr.Close()
+	for _, hop := range pm.hops {
+		hop.connMgr.Close()
+	}
+	if pm.checkConnMgr != nil {
+		pm.checkConnMgr.Close()
+	}
+	if pm.warmupConnMgr != nil {
+		pm.warmupConnMgr.Close()
+	}
+}
+
+// UpdateDatabaseConfig replaces the database settings of a pair, e.g. after
+// its credentials were rotated, and reconnects the databases that changed
+func (me *MonitoringEngine) UpdateDatabaseConfig(pair config.DatabasePair) error {
+	pm := me.findPairMonitor(pair.Name)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pair.Name)
+	}
+
+	if err := pm.connMgr.UpdateConfig(pm.ctx, &pair.SourceDB, &pair.TargetDB); err != nil {
+		return err
+	}
+	for i, hop := range pm.hops {
+		if i < len(pair.Intermediates) && pair.Intermediates[i].Name == hop.name {
+			if err := hop.connMgr.UpdateConfig(pm.ctx, nil, &pair.Intermediates[i].DB); err != nil {
+				return fmt.Errorf("intermediate '%s': %w", hop.name, err)
+			}
+		}
+	}
+	if pm.checkConnMgr != nil {
+		source, target := pair.CheckDatabases()
+		if err := pm.checkConnMgr.UpdateConfig(pm.ctx, source, target); err != nil {
+			return fmt.Errorf("check endpoints: %w", err)
+		}
+	}
+	if pm.warmupConnMgr != nil && pair.Warmup.HasDB() {
+		if err := pm.warmupConnMgr.UpdateConfig(pm.ctx, nil, warmupDatabase(&pair)); err != nil {
+			return fmt.Errorf("warm-up connection: %w", err)
+		}
+	}
+	pm.mu.Lock()
+	pm.pairConfig = pair
+	pm.mu.Unlock()
+	pm.breakers.probeNow()
+	return nil
+}
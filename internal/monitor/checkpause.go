@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+)
+
+// PausableChecks are the checks of a pair that can be paused on their own
+var PausableChecks = []string{"replica_lag", "clock_skew", "checksum", "consistency", "warmup", "semi_sync", "pt_checksum", "binlog_rate", "replication_filters", "encryption_status", "table_definitions"}
+
+// Check pause event types
+const (
+	EventCheckPaused  = "check_paused"
+	EventCheckResumed = "check_resumed"
+)
+
+// PausedCheck is a check of a pair that does not run until it is resumed
+type PausedCheck struct {
+	Check    string     `json:"check"`
+	Reason   string     `json:"reason"`
+	PausedAt time.Time  `json:"paused_at"`
+	Until    *time.Time `json:"until,omitempty"` // resumed automatically; nil until resumed by hand
+}
+
+// PauseCheck stops running one check of a pair until it is resumed, or until
+// a point in time when until is not zero
+func (me *MonitoringEngine) PauseCheck(pairName, check, reason string, until time.Time) error {
+	if !slices.Contains(PausableChecks, check) {
+		return fmt.Errorf("unknown check '%s'", check)
+	}
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	paused := PausedCheck{Check: check, Reason: reason, PausedAt: me.clock.Now()}
+	if !until.IsZero() {
+		paused.Until = &until
+	}
+
+	pm.mu.Lock()
+	if _, ok := pm.pausedChecks[check]; ok {
+		pm.mu.Unlock()
+		return fmt.Errorf("check '%s' of database pair '%s' is already paused", check, pairName)
+	}
+	if pm.pausedChecks == nil {
+		pm.pausedChecks = make(map[string]PausedCheck)
+	}
+	pm.pausedChecks[check] = paused
+	pm.mu.Unlock()
+
+	log.Printf("[%s] Check %s paused (%s)", pairName, check, reason)
+	me.emitCheckEvent(pm, EventCheckPaused, check, reason)
+	return nil
+}
+
+// ResumeCheck resumes a paused check of a pair
+func (me *MonitoringEngine) ResumeCheck(pairName, check, reason string) error {
+	if !slices.Contains(PausableChecks, check) {
+		return fmt.Errorf("unknown check '%s'", check)
+	}
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	pm.mu.Lock()
+	if _, ok := pm.pausedChecks[check]; !ok {
+		pm.mu.Unlock()
+		return fmt.Errorf("check '%s' of database pair '%s' is not paused", check, pairName)
+	}
+	delete(pm.pausedChecks, check)
+	pm.mu.Unlock()
+
+	log.Printf("[%s] Check %s resumed (%s)", pairName, check, reason)
+	me.emitCheckEvent(pm, EventCheckResumed, check, reason)
+	return nil
+}
+
+// PausedChecks returns the paused checks of every pair that has any, sorted by check name
+func (me *MonitoringEngine) PausedChecks() map[string][]PausedCheck {
+	result := make(map[string][]PausedCheck)
+	for _, pm := range me.pairs() {
+		pm.mu.RLock()
+		for _, paused := range pm.pausedChecks {
+			result[pm.pairName] = append(result[pm.pairName], paused)
+		}
+		pm.mu.RUnlock()
+		sort.Slice(result[pm.pairName], func(i, j int) bool {
+			return result[pm.pairName][i].Check < result[pm.pairName][j].Check
+		})
+	}
+	return result
+}
+
+// pausedCheckSet returns the checks of a pair paused for this cycle, resuming
+// the ones whose pause has expired
+func (me *MonitoringEngine) pausedCheckSet(pm *DatabasePairMonitor) map[string]bool {
+	now := me.clock.Now()
+	var expired []string
+
+	pm.mu.Lock()
+	paused := make(map[string]bool, len(pm.pausedChecks))
+	for check, p := range pm.pausedChecks {
+		if p.Until != nil && !now.Before(*p.Until) {
+			delete(pm.pausedChecks, check)
+			expired = append(expired, check)
+			continue
+		}
+		paused[check] = true
+	}
+	pm.mu.Unlock()
+
+	for _, check := range expired {
+		log.Printf("[%s] Check %s resumed (pause expired)", pm.pairName, check)
+		me.emitCheckEvent(pm, EventCheckResumed, check, "pause expired")
+	}
+	return paused
+}
+
+// emitCheckEvent tells the pair listeners that a check was paused or resumed.
+// The pair's lifecycle state is unchanged.
+func (me *MonitoringEngine) emitCheckEvent(pm *DatabasePairMonitor, eventType, check, reason string) {
+	pm.mu.RLock()
+	state := pm.state
+	pm.mu.RUnlock()
+
+	event := PairEvent{
+		Type:      eventType,
+		Pair:      pm.pairName,
+		From:      state,
+		To:        state,
+		Check:     check,
+		Reason:    reason,
+		Timestamp: me.clock.Now(),
+	}
+
+	me.listenersMu.RLock()
+	listeners := me.pairListeners
+	me.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
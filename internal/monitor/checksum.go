@@ -1,42 +1,78 @@
 package monitor
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"slices"
 	"time"
 
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
 	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/tracing"
 )
 
 // ChecksumResult represents the result of a checksum validation
 type ChecksumResult struct {
 	TableName      string
+	TargetTable    string // name of the table on the target
 	SourceChecksum string
 	TargetChecksum string
 	Match          bool
+	Skipped        bool  // pre-flight size limits prevented the checksum
+	EstimatedRows  int64 // source size estimate from information_schema
+	EstimatedBytes int64
+	Excluded       []string // columns left out of the checksum, generated columns included
+	View           bool     // hashed column by column, as CHECKSUM TABLE does not read views
+	Rehearsal      string   // ID of the rehearsal fault the result was replaced by
 	Timestamp      time.Time
 	Error          error
 }
 
 // ChecksumValidator validates data integrity using checksums
 type ChecksumValidator struct {
-	connMgr *database.ConnectionManager
+	connMgr    *database.ConnectionManager
+	clock      clock.Clock
+	preflight  config.ChecksumPreflightConfig
+	mappings   config.TableMappings
+	exclusions config.ChecksumExclusions
+	views      []string
+	allowed    map[string]bool
+	timeout    time.Duration // per table
 }
 
 // NewChecksumValidator creates a new checksum validator
-func NewChecksumValidator(connMgr *database.ConnectionManager) *ChecksumValidator {
+func NewChecksumValidator(connMgr *database.ConnectionManager, preflight config.ChecksumPreflightConfig, mappings config.TableMappings, exclusions config.ChecksumExclusions, views []string, timeout time.Duration) *ChecksumValidator {
+	allowed := make(map[string]bool, len(preflight.AllowedTables))
+	for _, table := range preflight.AllowedTables {
+		allowed[table] = true
+	}
+
 	return &ChecksumValidator{
-		connMgr: connMgr,
+		connMgr:    connMgr,
+		clock:      clock.Real,
+		preflight:  preflight,
+		mappings:   mappings,
+		exclusions: exclusions,
+		views:      views,
+		allowed:    allowed,
+		timeout:    timeout,
 	}
 }
 
 // ValidateTable validates a single table using checksums
-func (cv *ChecksumValidator) ValidateTable(tableName string) (*ChecksumResult, error) {
+func (cv *ChecksumValidator) ValidateTable(ctx context.Context, tableName string) (*ChecksumResult, error) {
 	result := &ChecksumResult{
-		TableName: tableName,
-		Timestamp: time.Now(),
+		TableName:   tableName,
+		TargetTable: cv.mappings.Target(tableName),
+		Timestamp:   cv.clock.Now(),
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, cv.timeout)
+	defer cancel()
+
 	sourceConn, err := cv.connMgr.GetSourceConnection()
 	if err != nil {
 		result.Error = fmt.Errorf("source connection error: %w", err)
@@ -49,8 +85,29 @@ func (cv *ChecksumValidator) ValidateTable(tableName string) (*ChecksumResult, e
 		return result, result.Error
 	}
 
+	// Estimate table size before committing to a full scan
+	if err := cv.checkPreflight(ctx, sourceConn, result); err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+	if result.Skipped {
+		return result, nil
+	}
+
+	// Views and tables with excluded columns are hashed over the source's
+	// remaining columns on both sides
+	var columns []tableColumn
+	result.View = slices.Contains(cv.views, tableName)
+	if result.View || len(cv.exclusions[tableName]) > 0 {
+		columns, result.Excluded, err = cv.checksumColumns(ctx, sourceConn, tableName)
+		if err != nil {
+			result.Error = fmt.Errorf("source columns error: %w", err)
+			return result, result.Error
+		}
+	}
+
 	// Calculate checksum for source table
-	sourceChecksum, err := cv.calculateChecksum(sourceConn, tableName)
+	sourceChecksum, err := cv.checksumWithSlot(ctx, cv.connMgr.AcquireSource, sourceConn, tableName, columns)
 	if err != nil {
 		result.Error = fmt.Errorf("source checksum error: %w", err)
 		return result, result.Error
@@ -58,7 +115,7 @@ func (cv *ChecksumValidator) ValidateTable(tableName string) (*ChecksumResult, e
 	result.SourceChecksum = sourceChecksum
 
 	// Calculate checksum for target table
-	targetChecksum, err := cv.calculateChecksum(targetConn, tableName)
+	targetChecksum, err := cv.checksumWithSlot(ctx, cv.connMgr.AcquireTarget, targetConn, result.TargetTable, columns)
 	if err != nil {
 		result.Error = fmt.Errorf("target checksum error: %w", err)
 		return result, result.Error
@@ -72,11 +129,28 @@ func (cv *ChecksumValidator) ValidateTable(tableName string) (*ChecksumResult, e
 }
 
 // ValidateAllTables validates multiple tables
-func (cv *ChecksumValidator) ValidateAllTables(tables []string) ([]*ChecksumResult, error) {
+func (cv *ChecksumValidator) ValidateAllTables(ctx context.Context, tables []string) ([]*ChecksumResult, error) {
+	return cv.ValidateTables(ctx, tables, 0)
+}
+
+// ValidateTables validates tables in order until budget has been spent: the
+// table in progress then finishes, and the rest are left out of the results.
+// A zero budget validates every table.
+func (cv *ChecksumValidator) ValidateTables(ctx context.Context, tables []string, budget time.Duration) ([]*ChecksumResult, error) {
 	results := make([]*ChecksumResult, 0, len(tables))
+	start := cv.clock.Now()
 
 	for _, table := range tables {
-		result, err := cv.ValidateTable(table)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		if budget > 0 && len(results) > 0 && cv.clock.Since(start) >= budget {
+			break
+		}
+		tableCtx, span := tracing.Start(ctx, "checksum table", tracing.String("table", table))
+		result, err := cv.ValidateTable(tableCtx, table)
+		span.RecordError(err)
+		span.End()
 		if err != nil {
 			// Continue with other tables even if one fails
 			results = append(results, result)
@@ -88,10 +162,94 @@ func (cv *ChecksumValidator) ValidateAllTables(tables []string) ([]*ChecksumResu
 	return results, nil
 }
 
+// checkPreflight estimates the table size and enforces the configured
+// limits, marking the result skipped when the action is skip
+func (cv *ChecksumValidator) checkPreflight(ctx context.Context, conn *sql.DB, result *ChecksumResult) error {
+	if !cv.preflight.Enabled() {
+		return nil
+	}
+
+	query := "SELECT COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0) FROM information_schema.TABLES WHERE " + tableSchemaCondition
+	schema, table := config.SplitTable(result.TableName)
+	if err := conn.QueryRowContext(ctx, query, schema, table).Scan(&result.EstimatedRows, &result.EstimatedBytes); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("table %s not found in information_schema", result.TableName)
+		}
+		return fmt.Errorf("size estimation error: %w", err)
+	}
+
+	overRows := cv.preflight.MaxRows > 0 && result.EstimatedRows > cv.preflight.MaxRows
+	overBytes := cv.preflight.MaxBytes > 0 && result.EstimatedBytes > cv.preflight.MaxBytes
+	if (!overRows && !overBytes) || cv.allowed[result.TableName] {
+		return nil
+	}
+
+	if cv.preflight.Action == "skip" {
+		log.Printf("Skipping checksum of large table %s (~%d rows, ~%d bytes) over pre-flight limits; add it to allowed_tables to opt in",
+			result.TableName, result.EstimatedRows, result.EstimatedBytes)
+		result.Skipped = true
+		return nil
+	}
+
+	log.Printf("Warning: checksumming large table %s (~%d rows, ~%d bytes) exceeds pre-flight limits", result.TableName, result.EstimatedRows, result.EstimatedBytes)
+	return nil
+}
+
+// checksumColumns returns the columns of a source table that are hashed and
+// those left out: excluded ones, and generated ones, whose definition may
+// differ between source and target
+func (cv *ChecksumValidator) checksumColumns(ctx context.Context, conn *sql.DB, tableName string) ([]tableColumn, []string, error) {
+	columns, err := tableColumns(ctx, conn, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("table %s not found in information_schema", tableName)
+	}
+	var excluded []string
+	columns = slices.DeleteFunc(columns, func(col tableColumn) bool {
+		if col.generated || cv.exclusions.Excluded(tableName, col.name) {
+			excluded = append(excluded, col.name)
+			return true
+		}
+		return false
+	})
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("every column of table %s is excluded", tableName)
+	}
+	return columns, excluded, nil
+}
+
+// checksumWithSlot calculates a checksum while holding an instance query
+// slot, over the given columns or with CHECKSUM TABLE when there are none
+func (cv *ChecksumValidator) checksumWithSlot(ctx context.Context, acquire func(context.Context) (func(), error), conn *sql.DB, tableName string, columns []tableColumn) (string, error) {
+	release, err := acquire(ctx)
+	if err != nil {
+		return "", fmt.Errorf("waiting for query slot: %w", err)
+	}
+	defer release()
+
+	if len(columns) > 0 {
+		return cv.calculateColumnChecksum(ctx, conn, tableName, columns)
+	}
+	return cv.calculateChecksum(ctx, conn, tableName)
+}
+
+// calculateColumnChecksum calculates a row count and hash of a table over some of its columns
+func (cv *ChecksumValidator) calculateColumnChecksum(ctx context.Context, conn *sql.DB, tableName string, columns []tableColumn) (string, error) {
+	query := fmt.Sprintf("SELECT COUNT(*), %s FROM %s", rowHashExpr(columns), quoteTable(tableName))
+	var count int64
+	var hash uint64
+	if err := conn.QueryRowContext(ctx, query).Scan(&count, &hash); err != nil {
+		return "", fmt.Errorf("column checksum query failed: %w", err)
+	}
+	return fmt.Sprintf("%d:%d", count, hash), nil
+}
+
 // calculateChecksum calculates checksum for a table
-func (cv *ChecksumValidator) calculateChecksum(conn interface{ Query(string, ...interface{}) (*sql.Rows, error) }, tableName string) (string, error) {
-	query := fmt.Sprintf("CHECKSUM TABLE `%s`", tableName)
-	rows, err := conn.Query(query)
+func (cv *ChecksumValidator) calculateChecksum(ctx context.Context, conn *sql.DB, tableName string) (string, error) {
+	query := "CHECKSUM TABLE " + quoteTable(tableName)
+	rows, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return "", fmt.Errorf("checksum query failed: %w", err)
 	}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// checksumScheduler holds checksums of a pair back until replica lag has
+// stayed below a threshold for a quiet period, then lets one run through.
+// Each run starts a new quiet period. Staggered runs checksum the tables
+// round-robin, a batch per run.
+type checksumScheduler struct {
+	config config.ChecksumScheduleConfig
+
+	mu         sync.Mutex
+	quietSince time.Time // zero while lag is above max_lag or unknown
+	next       int       // position of the table the next staggered run starts with
+}
+
+// newChecksumScheduler creates a scheduler, or returns nil when checksums of
+// every table run every check interval
+func newChecksumScheduler(cfg config.ChecksumScheduleConfig) *checksumScheduler {
+	if !cfg.QuietReplication() && !cfg.Staggered() {
+		return nil
+	}
+	return &checksumScheduler{config: cfg}
+}
+
+// observeLag records a lag measurement. Any measurement that is not ok, or
+// is at or above max_lag, ends the quiet period.
+func (cs *checksumScheduler) observeLag(status string, lagSeconds float64, at time.Time) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if status != "ok" || lagSeconds >= cs.config.MaxLag.Seconds() {
+		cs.quietSince = time.Time{}
+		return
+	}
+	if cs.quietSince.IsZero() {
+		cs.quietSince = at
+	}
+}
+
+// due reports whether replication has been quiet long enough for checksums;
+// if not, it also describes what they wait for
+func (cs *checksumScheduler) due(now time.Time) (bool, string) {
+	if !cs.config.QuietReplication() {
+		return true, ""
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.quietSince.IsZero() {
+		return false, fmt.Sprintf("waiting for replica lag below %s", cs.config.MaxLag)
+	}
+	quiet := now.Sub(cs.quietSince)
+	if quiet < cs.config.QuietFor {
+		return false, fmt.Sprintf("replica lag below %s for %s of %s", cs.config.MaxLag, quiet.Round(time.Second), cs.config.QuietFor)
+	}
+	return true, ""
+}
+
+// ran starts a new quiet period after checksums ran
+func (cs *checksumScheduler) ran(at time.Time) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if !cs.quietSince.IsZero() {
+		cs.quietSince = at
+	}
+}
+
+// reset ends the quiet period, e.g. after a failover to another instance
+func (cs *checksumScheduler) reset() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.quietSince = time.Time{}
+}
+
+// batch returns the tables the next run checksums: up to tables_per_cycle,
+// starting where the previous run stopped
+func (cs *checksumScheduler) batch(tables []string) []string {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if len(tables) == 0 {
+		return nil
+	}
+	size := len(tables)
+	if cs.config.TablesPerCycle > 0 {
+		size = min(size, cs.config.TablesPerCycle)
+	}
+	start := cs.next % len(tables)
+	return append(tables[start:len(tables):len(tables)], tables[:start]...)[:size]
+}
+
+// advance moves past the tables a run checksummed, out of total tables
+func (cs *checksumScheduler) advance(checked, total int) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if total > 0 {
+		cs.next = (cs.next%total + checked) % total
+	}
+}
@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// ClockSkewMetric represents clock differences between the monitor and both databases
+type ClockSkewMetric struct {
+	Timestamp        time.Time
+	SourceSkew       time.Duration // source clock minus monitor clock
+	TargetSkew       time.Duration // target clock minus monitor clock
+	SourceTargetSkew time.Duration // target clock minus source clock
+	Error            error
+}
+
+// ClockSkewMonitor compares database clocks against the monitor's clock
+type ClockSkewMonitor struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	timeout time.Duration
+}
+
+// NewClockSkewMonitor creates a new clock skew monitor
+func NewClockSkewMonitor(connMgr *database.ConnectionManager, timeout time.Duration) *ClockSkewMonitor {
+	return &ClockSkewMonitor{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		timeout: timeout,
+	}
+}
+
+// MeasureSkew measures the clock skew of source and target relative to the monitor
+func (csm *ClockSkewMonitor) MeasureSkew(ctx context.Context) (*ClockSkewMetric, error) {
+	metric := &ClockSkewMetric{
+		Timestamp: csm.clock.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, csm.timeout)
+	defer cancel()
+
+	sourceConn, err := csm.connMgr.GetSourceConnection()
+	if err != nil {
+		metric.Error = fmt.Errorf("source connection error: %w", err)
+		return metric, metric.Error
+	}
+
+	targetConn, err := csm.connMgr.GetTargetConnection()
+	if err != nil {
+		metric.Error = fmt.Errorf("target connection error: %w", err)
+		return metric, metric.Error
+	}
+
+	metric.SourceSkew, err = measureSkew(ctx, sourceConn)
+	if err != nil {
+		metric.Error = fmt.Errorf("source clock error: %w", err)
+		return metric, metric.Error
+	}
+
+	metric.TargetSkew, err = measureSkew(ctx, targetConn)
+	if err != nil {
+		metric.Error = fmt.Errorf("target clock error: %w", err)
+		return metric, metric.Error
+	}
+
+	metric.SourceTargetSkew = metric.TargetSkew - metric.SourceSkew
+
+	return metric, nil
+}
+
+// measureSkew returns the database clock minus the monitor's wall clock,
+// assuming the database read its clock halfway through the round trip
+func measureSkew(ctx context.Context, conn *sql.DB) (time.Duration, error) {
+	before := time.Now()
+	var dbTime time.Time
+	if err := conn.QueryRowContext(ctx, "SELECT UTC_TIMESTAMP(6)").Scan(&dbTime); err != nil {
+		return 0, err
+	}
+	after := time.Now()
+
+	midpoint := before.Add(after.Sub(before) / 2)
+	return dbTime.Sub(midpoint), nil
+}
@@ -1,42 +1,90 @@
 package monitor
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
 	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/tracing"
 )
 
 // ConsistencyResult represents the result of a consistency check
 type ConsistencyResult struct {
 	TableName      string
+	TargetTable    string // name of the table on the target
 	SourceRowCount int64
 	TargetRowCount int64
 	Consistent     bool
+	Approximate    bool               // counts are estimates, compared within Tolerance
+	Tolerance      float64            // allowed relative difference in percent
+	Window         *ConsistencyWindow // nil unless the table is in consistency_windows
+	WindowOnly     bool               // the full count was skipped; only the window was compared
+	Backfill       string             // ID of the backfill the comparison was relaxed for
+	Rehearsal      string             // ID of the rehearsal fault the result was replaced by
 	Timestamp      time.Time
 	Error          error
 }
 
 // ConsistencyChecker checks data consistency between databases
 type ConsistencyChecker struct {
-	connMgr *database.ConnectionManager
+	connMgr     *database.ConnectionManager
+	clock       clock.Clock
+	approx      config.ApproximateCountConfig
+	windows     config.ConsistencyWindowConfig
+	mappings    config.TableMappings
+	masking     config.MaskingConfig // applied to the primary key values of windows
+	timeout     time.Duration        // per table
+	mu          sync.Mutex
+	checkCounts map[string]int // key: table_name
+	windowRuns  map[string]int // key: table_name
 }
 
 // NewConsistencyChecker creates a new consistency checker
-func NewConsistencyChecker(connMgr *database.ConnectionManager) *ConsistencyChecker {
+func NewConsistencyChecker(connMgr *database.ConnectionManager, approx config.ApproximateCountConfig, windows config.ConsistencyWindowConfig, mappings config.TableMappings, masking config.MaskingConfig, timeout time.Duration) *ConsistencyChecker {
 	return &ConsistencyChecker{
-		connMgr: connMgr,
+		connMgr:     connMgr,
+		clock:       clock.Real,
+		approx:      approx,
+		windows:     windows,
+		mappings:    mappings,
+		masking:     masking,
+		timeout:     timeout,
+		checkCounts: make(map[string]int),
+		windowRuns:  make(map[string]int),
 	}
 }
 
+// SetTolerancePercent changes the allowed relative difference for approximate counts
+func (cc *ConsistencyChecker) SetTolerancePercent(tolerance float64) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.approx.TolerancePercent = tolerance
+}
+
+// tolerancePercent returns the allowed relative difference for approximate counts
+func (cc *ConsistencyChecker) tolerancePercent() float64 {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.approx.TolerancePercent
+}
+
 // CheckTable checks consistency for a single table
-func (cc *ConsistencyChecker) CheckTable(tableName string) (*ConsistencyResult, error) {
+func (cc *ConsistencyChecker) CheckTable(ctx context.Context, tableName string) (*ConsistencyResult, error) {
 	result := &ConsistencyResult{
-		TableName: tableName,
-		Timestamp: time.Now(),
+		TableName:   tableName,
+		TargetTable: cc.mappings.Target(tableName),
+		Timestamp:   cc.clock.Now(),
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, cc.timeout)
+	defer cancel()
+
 	sourceConn, err := cc.connMgr.GetSourceConnection()
 	if err != nil {
 		result.Error = fmt.Errorf("source connection error: %w", err)
@@ -49,8 +97,39 @@ func (cc *ConsistencyChecker) CheckTable(tableName string) (*ConsistencyResult,
 		return result, result.Error
 	}
 
+	// Append-only tables compare their key range and recent rows, and may
+	// skip the full count
+	if column := cc.windows.Column(tableName); column != "" {
+		window, err := cc.checkWindow(ctx, sourceConn, targetConn, result, column)
+		if err != nil {
+			result.Error = fmt.Errorf("window check error: %w", err)
+			return result, result.Error
+		}
+		result.Window = window
+		if !cc.fullCountDue(tableName) {
+			result.WindowOnly = true
+			result.Consistent = window.Consistent()
+			return result, nil
+		}
+	}
+
+	result, err = cc.countTable(ctx, result, sourceConn, targetConn)
+	if result.Window != nil {
+		result.Consistent = result.Consistent && result.Window.Consistent()
+	}
+	return result, err
+}
+
+// countTable compares the row counts of a table, estimated between exact
+// counts for large tables
+func (cc *ConsistencyChecker) countTable(ctx context.Context, result *ConsistencyResult, sourceConn, targetConn *sql.DB) (*ConsistencyResult, error) {
+	tableName := result.TableName
+	if cc.shouldApproximate(ctx, sourceConn, tableName) {
+		return cc.checkTableApproximate(ctx, result, sourceConn, targetConn)
+	}
+
 	// Get row count from source
-	sourceCount, err := cc.getRowCount(sourceConn, tableName)
+	sourceCount, err := cc.rowCountWithSlot(ctx, cc.connMgr.AcquireSource, sourceConn, tableName)
 	if err != nil {
 		result.Error = fmt.Errorf("source row count error: %w", err)
 		return result, result.Error
@@ -58,7 +137,7 @@ func (cc *ConsistencyChecker) CheckTable(tableName string) (*ConsistencyResult,
 	result.SourceRowCount = sourceCount
 
 	// Get row count from target
-	targetCount, err := cc.getRowCount(targetConn, tableName)
+	targetCount, err := cc.rowCountWithSlot(ctx, cc.connMgr.AcquireTarget, targetConn, result.TargetTable)
 	if err != nil {
 		result.Error = fmt.Errorf("target row count error: %w", err)
 		return result, result.Error
@@ -72,11 +151,17 @@ func (cc *ConsistencyChecker) CheckTable(tableName string) (*ConsistencyResult,
 }
 
 // CheckAllTables checks consistency for multiple tables
-func (cc *ConsistencyChecker) CheckAllTables(tables []string) ([]*ConsistencyResult, error) {
+func (cc *ConsistencyChecker) CheckAllTables(ctx context.Context, tables []string) ([]*ConsistencyResult, error) {
 	results := make([]*ConsistencyResult, 0, len(tables))
 
 	for _, table := range tables {
-		result, err := cc.CheckTable(table)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		tableCtx, span := tracing.Start(ctx, "consistency table", tracing.String("table", table))
+		result, err := cc.CheckTable(tableCtx, table)
+		span.RecordError(err)
+		span.End()
 		if err != nil {
 			// Continue with other tables even if one fails
 			results = append(results, result)
@@ -88,13 +173,149 @@ func (cc *ConsistencyChecker) CheckAllTables(tables []string) ([]*ConsistencyRes
 	return results, nil
 }
 
+// rowCountWithSlot counts rows while holding an instance query slot
+func (cc *ConsistencyChecker) rowCountWithSlot(ctx context.Context, acquire func(context.Context) (func(), error), conn *sql.DB, tableName string) (int64, error) {
+	release, err := acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("waiting for query slot: %w", err)
+	}
+	defer release()
+
+	return cc.getRowCount(ctx, conn, tableName)
+}
+
 // getRowCount gets the row count for a table
-func (cc *ConsistencyChecker) getRowCount(conn interface{ QueryRow(string, ...interface{}) *sql.Row }, tableName string) (int64, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`", tableName)
+func (cc *ConsistencyChecker) getRowCount(ctx context.Context, conn *sql.DB, tableName string) (int64, error) {
+	query := "SELECT COUNT(*) FROM " + quoteTable(tableName)
 	var count int64
-	err := conn.QueryRow(query).Scan(&count)
+	err := conn.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get row count: %w", err)
 	}
 	return count, nil
 }
+
+// shouldApproximate decides whether this check of a table may use estimates
+func (cc *ConsistencyChecker) shouldApproximate(ctx context.Context, conn *sql.DB, tableName string) bool {
+	if !cc.approx.Enabled {
+		return false
+	}
+
+	cc.mu.Lock()
+	run := cc.checkCounts[tableName]
+	cc.checkCounts[tableName] = run + 1
+	cc.mu.Unlock()
+
+	// First check and every ExactEvery-th check are exact
+	if run%cc.approx.ExactEvery == 0 {
+		return false
+	}
+
+	estimate, err := cc.estimateRowCount(ctx, conn, tableName)
+	if err != nil {
+		return false
+	}
+	return estimate >= cc.approx.MinRows
+}
+
+// checkTableApproximate compares estimated row counts within the configured tolerance
+func (cc *ConsistencyChecker) checkTableApproximate(ctx context.Context, result *ConsistencyResult, sourceConn, targetConn *sql.DB) (*ConsistencyResult, error) {
+	result.Approximate = true
+	result.Tolerance = cc.tolerancePercent()
+
+	sourceCount, err := cc.estimateRowCount(ctx, sourceConn, result.TableName)
+	if err != nil {
+		result.Error = fmt.Errorf("source row estimate error: %w", err)
+		return result, result.Error
+	}
+	result.SourceRowCount = sourceCount
+
+	targetCount, err := cc.estimateRowCount(ctx, targetConn, result.TargetTable)
+	if err != nil {
+		result.Error = fmt.Errorf("target row estimate error: %w", err)
+		return result, result.Error
+	}
+	result.TargetRowCount = targetCount
+
+	result.Consistent = withinTolerance(sourceCount, targetCount, result.Tolerance)
+
+	return result, nil
+}
+
+// estimateRowCount estimates the row count for a table without scanning it
+func (cc *ConsistencyChecker) estimateRowCount(ctx context.Context, conn *sql.DB, tableName string) (int64, error) {
+	if cc.approx.Method == "explain" {
+		return cc.explainRowCount(ctx, conn, tableName)
+	}
+
+	query := "SELECT TABLE_ROWS FROM information_schema.TABLES WHERE " + tableSchemaCondition
+	schema, table := config.SplitTable(tableName)
+	var count sql.NullInt64
+	if err := conn.QueryRowContext(ctx, query, schema, table).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to read table statistics: %w", err)
+	}
+	if !count.Valid {
+		return 0, fmt.Errorf("no row statistics available for table %s", tableName)
+	}
+	return count.Int64, nil
+}
+
+// explainRowCount reads the optimizer's row estimate from EXPLAIN SELECT COUNT(*)
+func (cc *ConsistencyChecker) explainRowCount(ctx context.Context, conn *sql.DB, tableName string) (int64, error) {
+	query := "EXPLAIN SELECT COUNT(*) FROM " + quoteTable(tableName)
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("explain query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	rowsIdx := -1
+	for i, col := range columns {
+		if col == "rows" {
+			rowsIdx = i
+		}
+	}
+	if rowsIdx < 0 {
+		return 0, fmt.Errorf("explain output has no rows column")
+	}
+
+	if !rows.Next() {
+		return 0, fmt.Errorf("no explain result returned")
+	}
+
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return 0, fmt.Errorf("failed to scan explain result: %w", err)
+	}
+
+	var count int64
+	switch v := values[rowsIdx].(type) {
+	case int64:
+		count = v
+	case []byte:
+		if _, err := fmt.Sscanf(string(v), "%d", &count); err != nil {
+			return 0, fmt.Errorf("failed to parse explain rows '%s': %w", string(v), err)
+		}
+	default:
+		return 0, fmt.Errorf("unexpected explain rows value: %v", v)
+	}
+	return count, nil
+}
+
+// withinTolerance reports whether two counts differ by at most tolerancePercent
+func withinTolerance(a, b int64, tolerancePercent float64) bool {
+	larger := math.Max(float64(a), float64(b))
+	if larger == 0 {
+		return true
+	}
+	return math.Abs(float64(a-b))/larger*100 <= tolerancePercent
+}
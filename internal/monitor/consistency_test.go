@@ -0,0 +1,27 @@
+package monitor
+
+import "testing"
+
+func TestWithinTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      int64
+		tolerance float64
+		want      bool
+	}{
+		{"both empty", 0, 0, 0, true},
+		{"equal", 1000, 1000, 0, true},
+		{"one empty", 0, 10, 5, false},
+		{"at the tolerance", 1000, 950, 5, true},
+		{"beyond the tolerance", 1000, 949, 5, false},
+		{"relative to the larger count", 950, 1000, 5, true},
+		{"zero tolerance", 1000, 999, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withinTolerance(tt.a, tt.b, tt.tolerance); got != tt.want {
+				t.Errorf("withinTolerance(%d, %d, %g) = %v, want %v", tt.a, tt.b, tt.tolerance, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// ConsistencyWindow compares the primary key range and the recently created
+// rows of an append-only table. Rows newer than To may still be replicating
+// and are left out.
+type ConsistencyWindow struct {
+	Column string    // creation time column
+	From   time.Time // counted rows were created at or after From and before To, by the source's clock
+	To     time.Time
+
+	SourceCount int64
+	TargetCount int64
+
+	// Lowest primary key, and highest of the rows created before To; empty
+	// for empty tables, and without a single-column primary key
+	PrimaryKey  string
+	SourceMinPK string
+	TargetMinPK string
+	SourceMaxPK string
+	TargetMaxPK string
+
+	// Masking rules of the pair and the table they apply to; values are
+	// compared unmasked and masked when shown
+	masking config.MaskingConfig
+	table   string
+}
+
+// Show returns a primary key value as shown outside the monitor, masked by
+// the pair's masking rules
+func (w *ConsistencyWindow) Show(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskValue(w.masking, w.table, w.PrimaryKey, value)
+}
+
+// Consistent reports whether both databases agree on the window
+func (w *ConsistencyWindow) Consistent() bool {
+	return len(w.Problems()) == 0
+}
+
+// Drift returns the difference of the windowed row counts
+func (w *ConsistencyWindow) Drift() int64 {
+	return max(w.SourceCount-w.TargetCount, w.TargetCount-w.SourceCount)
+}
+
+// Problems describes the comparisons that differ, e.g. for alert messages
+func (w *ConsistencyWindow) Problems() []string {
+	var problems []string
+	if w.SourceCount != w.TargetCount {
+		problems = append(problems, fmt.Sprintf("%d rows created since %s on the source, %d on the target",
+			w.SourceCount, w.From.Format(time.DateTime), w.TargetCount))
+	}
+	if w.SourceMinPK != w.TargetMinPK {
+		problems = append(problems, fmt.Sprintf("MIN(%s) is %s on the source, %s on the target",
+			w.PrimaryKey, orNone(w.Show(w.SourceMinPK)), orNone(w.Show(w.TargetMinPK))))
+	}
+	if w.SourceMaxPK != w.TargetMaxPK {
+		problems = append(problems, fmt.Sprintf("MAX(%s) of rows created before %s is %s on the source, %s on the target",
+			w.PrimaryKey, w.To.Format(time.DateTime), orNone(w.Show(w.SourceMaxPK)), orNone(w.Show(w.TargetMaxPK))))
+	}
+	return problems
+}
+
+// orNone renders an empty primary key value
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// windowBounds is the time window of a check
+type windowBounds struct {
+	from, to         time.Time // the source's wall clock, for DATETIME columns
+	fromUnix, toUnix int64     // for TIMESTAMP columns
+}
+
+// checkWindow compares the primary key range and recent rows of a table. The
+// window is taken from the source's clock and applied to both databases.
+func (cc *ConsistencyChecker) checkWindow(ctx context.Context, sourceConn, targetConn *sql.DB, result *ConsistencyResult, column string) (*ConsistencyWindow, error) {
+	window := &ConsistencyWindow{Column: column, masking: cc.masking, table: result.TableName}
+
+	columns, err := tableColumns(ctx, sourceConn, result.TableName)
+	if err != nil {
+		return nil, err
+	}
+	var timeColumn *tableColumn
+	for i := range columns {
+		if columns[i].name == column {
+			timeColumn = &columns[i]
+		}
+	}
+	if timeColumn == nil {
+		return nil, fmt.Errorf("table %s has no column %s", result.TableName, column)
+	}
+	pk, err := primaryKey(ctx, sourceConn, result.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) == 1 {
+		window.PrimaryKey = pk[0].name
+	}
+
+	bounds, err := cc.windowBounds(ctx, sourceConn)
+	if err != nil {
+		return nil, err
+	}
+	window.From, window.To = bounds.from, bounds.to
+	condition := quoteIdent(column) + " >= ? AND " + quoteIdent(column) + " < ?"
+	before := quoteIdent(column) + " < ?"
+	args := []any{bounds.from.Format(time.DateTime), bounds.to.Format(time.DateTime)}
+	if timeColumn.timestampColumn() {
+		// TIMESTAMP values compare in the session time zone, which may
+		// differ between the databases; seconds since the epoch do not
+		condition = quoteIdent(column) + " >= FROM_UNIXTIME(?) AND " + quoteIdent(column) + " < FROM_UNIXTIME(?)"
+		before = quoteIdent(column) + " < FROM_UNIXTIME(?)"
+		args = []any{bounds.fromUnix, bounds.toUnix}
+	}
+
+	for _, side := range []struct {
+		acquire func(context.Context) (func(), error)
+		conn    *sql.DB
+		table   string
+		count   *int64
+		minPK   *string
+		maxPK   *string
+		name    string
+	}{
+		{cc.connMgr.AcquireSource, sourceConn, result.TableName, &window.SourceCount, &window.SourceMinPK, &window.SourceMaxPK, "source"},
+		{cc.connMgr.AcquireTarget, targetConn, result.TargetTable, &window.TargetCount, &window.TargetMinPK, &window.TargetMaxPK, "target"},
+	} {
+		release, err := side.acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("waiting for query slot: %w", err)
+		}
+		err = func() error {
+			defer release()
+			query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quoteTable(side.table), condition)
+			if err := side.conn.QueryRowContext(ctx, query, args...).Scan(side.count); err != nil {
+				return fmt.Errorf("windowed row count failed: %w", err)
+			}
+			if window.PrimaryKey == "" {
+				return nil
+			}
+			pk := quoteIdent(window.PrimaryKey)
+			var minPK, maxPK sql.NullString
+			if err := side.conn.QueryRowContext(ctx, fmt.Sprintf("SELECT MIN(%s) FROM %s", pk, quoteTable(side.table))).Scan(&minPK); err != nil {
+				return fmt.Errorf("MIN(%s) failed: %w", window.PrimaryKey, err)
+			}
+			// Walks the primary key down from the top, which is quick for
+			// append-only tables
+			query = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s DESC LIMIT 1", pk, quoteTable(side.table), before, pk)
+			if err := side.conn.QueryRowContext(ctx, query, args[1]).Scan(&maxPK); err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("MAX(%s) failed: %w", window.PrimaryKey, err)
+			}
+			*side.minPK, *side.maxPK = minPK.String, maxPK.String
+			return nil
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", side.name, err)
+		}
+	}
+	return window, nil
+}
+
+// windowBounds reads the source's clock and derives the window from it
+func (cc *ConsistencyChecker) windowBounds(ctx context.Context, sourceConn *sql.DB) (windowBounds, error) {
+	var now time.Time
+	var nowUnix int64
+	if err := sourceConn.QueryRowContext(ctx, "SELECT NOW(), UNIX_TIMESTAMP()").Scan(&now, &nowUnix); err != nil {
+		return windowBounds{}, fmt.Errorf("failed to read the source's clock: %w", err)
+	}
+	return windowBounds{
+		from:     now.Add(-cc.windows.Window),
+		to:       now.Add(-cc.windows.Settle),
+		fromUnix: nowUnix - int64(cc.windows.Window.Seconds()),
+		toUnix:   nowUnix - int64(cc.windows.Settle.Seconds()),
+	}, nil
+}
+
+// fullCountDue reports whether this check of a table with a window runs the
+// full row count too, which it does every full_count_every checks
+func (cc *ConsistencyChecker) fullCountDue(tableName string) bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	run := cc.windowRuns[tableName]
+	cc.windowRuns[tableName] = run + 1
+	return run%cc.windows.FullCountEvery == 0
+}
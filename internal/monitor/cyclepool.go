@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+)
+
+// cyclePool bounds the number of monitoring cycles running at once across
+// all pairs, so that a tick does not hit every database simultaneously. Each
+// cycle's heavy queries are further bounded per instance by the
+// InstanceLimiter.
+type cyclePool struct {
+	slots chan struct{}
+	clock clock.Clock // measures the wait for a slot
+}
+
+// newCyclePool creates a pool running up to size cycles at once. A size of
+// zero or less leaves cycles unbounded.
+func newCyclePool(size int, c clock.Clock) *cyclePool {
+	if size <= 0 {
+		return nil
+	}
+	return &cyclePool{slots: make(chan struct{}, size), clock: c}
+}
+
+// acquire blocks until a cycle may run or the context is done, and returns
+// a function that frees the slot and how long the cycle waited for it
+func (p *cyclePool) acquire(ctx context.Context) (func(), time.Duration, error) {
+	if p == nil {
+		return func() {}, 0, nil
+	}
+
+	began := p.clock.Now()
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, p.clock.Since(began), ctx.Err()
+	}
+	return func() { <-p.slots }, p.clock.Since(began), nil
+}
+
+// running returns the number of cycles holding a slot and the pool size
+func (p *cyclePool) running() (length, capacity int) {
+	if p == nil {
+		return 0, 0
+	}
+	return len(p.slots), cap(p.slots)
+}
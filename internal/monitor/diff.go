@@ -0,0 +1,639 @@
+package monitor
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// DiffOptions controls a row-level diff run
+type DiffOptions struct {
+	ChunkSize int // rows scanned per chunk, in primary key order
+	MaxRows   int // stop after this many differing rows
+}
+
+// ColumnDifference represents a single column that differs between source and target
+type ColumnDifference struct {
+	Column      string
+	SourceValue string // empty when SourceNull
+	TargetValue string // empty when TargetNull
+	SourceNull  bool   // the value is SQL NULL, not a string that reads NULL
+	TargetNull  bool
+}
+
+// RowDifference represents a row that differs between source and target
+type RowDifference struct {
+	PrimaryKey string
+	Kind       string // "missing_in_target", "extra_in_target" or "changed"
+	Columns    []ColumnDifference
+}
+
+// DiffResult represents the result of a row-level diff of a table
+type DiffResult struct {
+	TableName        string
+	TargetTable      string // name of the table on the target
+	PrimaryKey       string // its columns, comma separated; empty without a primary key
+	ChunksScanned    int
+	ChunksMismatched int
+	RowsCompared     int64
+	Differences      []RowDifference
+	Truncated        bool
+	Warning          string // why the table was compared without locating rows
+	Timestamp        time.Time
+	Duration         time.Duration
+	Error            error
+}
+
+// Differs reports whether the diff found the databases to differ: rows
+// differ, or a table without a primary key hashed differently
+func (r *DiffResult) Differs() bool {
+	return len(r.Differences) > 0 || (r.PrimaryKey == "" && r.ChunksMismatched > 0)
+}
+
+// DiffEngine compares rows between source and target chunk by chunk, in
+// primary key order
+type DiffEngine struct {
+	connMgr    *database.ConnectionManager
+	mappings   config.TableMappings
+	masking    config.MaskingConfig
+	exclusions config.ChecksumExclusions
+}
+
+// NewDiffEngine creates a new diff engine
+func NewDiffEngine(connMgr *database.ConnectionManager, mappings config.TableMappings, masking config.MaskingConfig, exclusions config.ChecksumExclusions) *DiffEngine {
+	return &DiffEngine{
+		connMgr:    connMgr,
+		mappings:   mappings,
+		masking:    masking,
+		exclusions: exclusions,
+	}
+}
+
+// DiffTable compares a table chunk by chunk, fetching rows only for chunks whose hashes differ
+func (de *DiffEngine) DiffTable(ctx context.Context, tableName string, opts DiffOptions) (*DiffResult, error) {
+	start := time.Now()
+	result := &DiffResult{
+		TableName:   tableName,
+		TargetTable: de.mappings.Target(tableName),
+		Timestamp:   start,
+	}
+	defer func() { result.Duration = time.Since(start) }()
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 1000
+	}
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = 100
+	}
+
+	sourceConn, err := de.connMgr.GetSourceConnection()
+	if err != nil {
+		result.Error = fmt.Errorf("source connection error: %w", err)
+		return result, result.Error
+	}
+
+	targetConn, err := de.connMgr.GetTargetConnection()
+	if err != nil {
+		result.Error = fmt.Errorf("target connection error: %w", err)
+		return result, result.Error
+	}
+
+	pk, columns, err := de.describeTable(ctx, sourceConn, tableName)
+	if err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+	pkNames := columnNames(pk)
+	result.PrimaryKey = strings.Join(pkNames, ", ")
+	// Excluded and generated columns are expected to differ, the latter as
+	// their definition may; the primary key is always compared
+	columns = slices.DeleteFunc(columns, func(col tableColumn) bool {
+		return !slices.Contains(pkNames, col.name) && (col.generated || de.exclusions.Excluded(tableName, col.name))
+	})
+
+	if len(pk) == 0 {
+		return de.diffUnkeyed(ctx, sourceConn, targetConn, columns, result)
+	}
+
+	// Chunks end at every ChunkSize-th key of the source. Past the source's
+	// last key, the target's keys bound them, so that extra target rows are
+	// chunked too; the last chunk is open-ended.
+	var lower []any
+	for {
+		upper, err := de.nextBoundary(ctx, sourceConn, de.connMgr.AcquireSource, tableName, pk, lower, opts.ChunkSize)
+		if err != nil {
+			result.Error = fmt.Errorf("source chunk boundary error: %w", err)
+			return result, result.Error
+		}
+		if upper == nil {
+			if upper, err = de.nextBoundary(ctx, targetConn, de.connMgr.AcquireTarget, result.TargetTable, pk, lower, opts.ChunkSize); err != nil {
+				result.Error = fmt.Errorf("target chunk boundary error: %w", err)
+				return result, result.Error
+			}
+		}
+		chunk := keyRange{lower: lower, upper: upper}
+		result.ChunksScanned++
+
+		sourceCount, sourceHash, err := de.chunkHash(ctx, sourceConn, de.connMgr.AcquireSource, tableName, pk, columns, chunk)
+		if err != nil {
+			result.Error = fmt.Errorf("source chunk hash error: %w", err)
+			return result, result.Error
+		}
+		targetCount, targetHash, err := de.chunkHash(ctx, targetConn, de.connMgr.AcquireTarget, result.TargetTable, pk, columns, chunk)
+		if err != nil {
+			result.Error = fmt.Errorf("target chunk hash error: %w", err)
+			return result, result.Error
+		}
+		if sourceCount != targetCount || sourceHash != targetHash {
+			result.ChunksMismatched++
+
+			sourceRows, err := de.fetchRows(ctx, sourceConn, de.connMgr.AcquireSource, tableName, pk, columns, chunk)
+			if err != nil {
+				result.Error = fmt.Errorf("source row fetch error: %w", err)
+				return result, result.Error
+			}
+			targetRows, err := de.fetchRows(ctx, targetConn, de.connMgr.AcquireTarget, result.TargetTable, pk, columns, chunk)
+			if err != nil {
+				result.Error = fmt.Errorf("target row fetch error: %w", err)
+				return result, result.Error
+			}
+
+			result.RowsCompared += int64(len(sourceRows))
+			for _, diff := range compareRows(columnNames(columns), sourceRows, targetRows) {
+				if len(result.Differences) >= opts.MaxRows {
+					result.Truncated = true
+					return result, nil
+				}
+				// Values are compared unmasked but never reported unmasked
+				maskDifference(de.masking, tableName, pkNames, &diff)
+				result.Differences = append(result.Differences, diff)
+			}
+		}
+
+		if upper == nil {
+			return result, nil
+		}
+		lower = upper
+	}
+}
+
+// diffUnkeyed compares a table without a primary key by one hash over all
+// its rows. Its rows cannot be told apart, so differing rows are not located.
+func (de *DiffEngine) diffUnkeyed(ctx context.Context, sourceConn, targetConn *sql.DB, columns []tableColumn, result *DiffResult) (*DiffResult, error) {
+	result.Warning = "no primary key: compared by a hash of the whole table, so differing rows cannot be located"
+	result.ChunksScanned = 1
+
+	sourceCount, sourceHash, err := de.chunkHash(ctx, sourceConn, de.connMgr.AcquireSource, result.TableName, nil, columns, keyRange{})
+	if err != nil {
+		result.Error = fmt.Errorf("source table hash error: %w", err)
+		return result, result.Error
+	}
+	targetCount, targetHash, err := de.chunkHash(ctx, targetConn, de.connMgr.AcquireTarget, result.TargetTable, nil, columns, keyRange{})
+	if err != nil {
+		result.Error = fmt.Errorf("target table hash error: %w", err)
+		return result, result.Error
+	}
+	result.RowsCompared = sourceCount
+	if sourceCount != targetCount || sourceHash != targetHash {
+		result.ChunksMismatched = 1
+	}
+	return result, nil
+}
+
+// describeTable returns the primary key columns, none without a primary key,
+// and all columns of a table
+func (de *DiffEngine) describeTable(ctx context.Context, conn *sql.DB, tableName string) ([]tableColumn, []tableColumn, error) {
+	pk, err := primaryKey(ctx, conn, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, err := tableColumns(ctx, conn, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk, columns, nil
+}
+
+// primaryKey returns the primary key columns of a table, in order; none when
+// it has no primary key
+func primaryKey(ctx context.Context, conn *sql.DB, tableName string) ([]tableColumn, error) {
+	pkQuery := `SELECT k.COLUMN_NAME, c.DATA_TYPE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.COLUMNS c
+		  ON c.TABLE_SCHEMA = k.TABLE_SCHEMA AND c.TABLE_NAME = k.TABLE_NAME AND c.COLUMN_NAME = k.COLUMN_NAME
+		WHERE k.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND k.TABLE_NAME = ? AND k.CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY k.ORDINAL_POSITION`
+	schema, table := config.SplitTable(tableName)
+	rows, err := conn.QueryContext(ctx, pkQuery, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary key: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []tableColumn
+	for rows.Next() {
+		var col tableColumn
+		if err := rows.Scan(&col.name, &col.dataType); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// tableColumn is a column of a table and its data type
+type tableColumn struct {
+	name      string
+	dataType  string
+	generated bool // a VIRTUAL or STORED generated column
+}
+
+// columnNames returns the names of columns
+func columnNames(columns []tableColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	return names
+}
+
+// tableColumns returns the columns of a table, in order
+func tableColumns(ctx context.Context, conn *sql.DB, tableName string) ([]tableColumn, error) {
+	schema, table := config.SplitTable(tableName)
+	rows, err := conn.QueryContext(ctx, "SELECT COLUMN_NAME, DATA_TYPE, EXTRA FROM information_schema.COLUMNS WHERE "+tableSchemaCondition+" ORDER BY ORDINAL_POSITION", schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []tableColumn
+	for rows.Next() {
+		var col tableColumn
+		var extra string
+		if err := rows.Scan(&col.name, &col.dataType, &extra); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		col.generated = generatedColumn(extra)
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// generatedColumn reports whether the EXTRA of a column marks it generated:
+// VIRTUAL GENERATED or STORED GENERATED, PERSISTENT GENERATED on older
+// MariaDB. MySQL's DEFAULT_GENERATED only marks an expression default.
+func generatedColumn(extra string) bool {
+	extra = strings.ToUpper(extra)
+	return strings.Contains(extra, "VIRTUAL GENERATED") || strings.Contains(extra, "STORED GENERATED") || strings.Contains(extra, "PERSISTENT GENERATED")
+}
+
+// timestampColumn reports whether a column is a TIMESTAMP, which the server
+// renders in the session's time zone
+func (col tableColumn) timestampColumn() bool {
+	return strings.EqualFold(col.dataType, "timestamp")
+}
+
+// placeholder is the parameter a key value read by selectExpr is compared
+// as: TIMESTAMP keys are read as seconds since the epoch
+func (col tableColumn) placeholder() string {
+	if col.timestampColumn() {
+		return "FROM_UNIXTIME(?)"
+	}
+	return "?"
+}
+
+// selectExpr is the expression a column is read and hashed by. TIMESTAMP
+// columns are read as seconds since the epoch, so that databases in
+// different time zones compare equal.
+func (col tableColumn) selectExpr() string {
+	if col.timestampColumn() {
+		return "UNIX_TIMESTAMP(" + quoteIdent(col.name) + ")"
+	}
+	return quoteIdent(col.name)
+}
+
+// keyRange is a chunk of a table in primary key order: the rows after the
+// key lower, up to and including the key upper. A nil bound is open.
+type keyRange struct {
+	lower, upper []any
+}
+
+// where returns the condition selecting the chunk's rows and its arguments
+func (r keyRange) where(pk []tableColumn) (string, []any) {
+	var conditions []string
+	var args []any
+	if r.lower != nil {
+		condition, lowerArgs := keyCondition(pk, r.lower, ">", false)
+		conditions = append(conditions, condition)
+		args = append(args, lowerArgs...)
+	}
+	if r.upper != nil {
+		condition, upperArgs := keyCondition(pk, r.upper, "<", true)
+		conditions = append(conditions, condition)
+		args = append(args, upperArgs...)
+	}
+	if len(conditions) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// keyCondition compares the primary key with a key in key order, op being
+// ">" or "<", and orEqual admitting the key itself. It is written out column
+// by column, (a > ?) OR (a = ? AND b > ?), as servers do not always use the
+// index for a row constructor comparison.
+func keyCondition(pk []tableColumn, key []any, op string, orEqual bool) (string, []any) {
+	var disjuncts []string
+	var args []any
+	// equal matches the first n key columns
+	equal := func(n int) []string {
+		terms := make([]string, 0, n+1)
+		for i := 0; i < n; i++ {
+			terms = append(terms, quoteIdent(pk[i].name)+" = "+pk[i].placeholder())
+			args = append(args, key[i])
+		}
+		return terms
+	}
+	for i, col := range pk {
+		terms := append(equal(i), quoteIdent(col.name)+" "+op+" "+col.placeholder())
+		args = append(args, key[i])
+		disjuncts = append(disjuncts, "("+strings.Join(terms, " AND ")+")")
+	}
+	if orEqual {
+		disjuncts = append(disjuncts, "("+strings.Join(equal(len(pk)), " AND ")+")")
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args
+}
+
+// orderBy lists the primary key columns for ORDER BY
+func orderBy(pk []tableColumn) string {
+	quoted := make([]string, len(pk))
+	for i, col := range pk {
+		quoted[i] = quoteIdent(col.name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// nextBoundary returns the key ending the chunk after lower: the size-th key
+// after it, or nil when fewer rows follow. Keys are read as their columns'
+// select expressions, so that TIMESTAMP keys compare across time zones.
+func (de *DiffEngine) nextBoundary(ctx context.Context, conn *sql.DB, acquire func(context.Context) (func(), error), tableName string, pk []tableColumn, lower []any, size int) ([]any, error) {
+	exprs := make([]string, len(pk))
+	for i, col := range pk {
+		exprs[i] = col.selectExpr()
+	}
+	condition, args := keyRange{lower: lower}.where(pk)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT 1 OFFSET %d",
+		strings.Join(exprs, ", "), quoteTable(tableName), condition, orderBy(pk), size-1)
+
+	release, err := acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	key := make([]any, len(pk))
+	dest := make([]any, len(pk))
+	for i := range key {
+		dest[i] = &key[i]
+	}
+	if err := conn.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// Text compares in the column's collation, as ORDER BY sorted it; bytes
+	// would be compared as a binary string
+	for i, value := range key {
+		if b, ok := value.([]byte); ok {
+			key[i] = string(b)
+		}
+	}
+	return key, nil
+}
+
+// chunkHash computes the row count and an order-independent hash of a chunk;
+// without a primary key, of the whole table
+func (de *DiffEngine) chunkHash(ctx context.Context, conn *sql.DB, acquire func(context.Context) (func(), error), tableName string, pk []tableColumn, columns []tableColumn, chunk keyRange) (int64, uint64, error) {
+	condition, args := chunk.where(pk)
+	query := fmt.Sprintf("SELECT COUNT(*), %s FROM %s WHERE %s", rowHashExpr(columns), quoteTable(tableName), condition)
+
+	release, err := acquire(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer release()
+
+	var count int64
+	var hash uint64
+	if err := conn.QueryRowContext(ctx, query, args...).Scan(&count, &hash); err != nil {
+		return 0, 0, err
+	}
+	return count, hash, nil
+}
+
+// rowHashExpr is an order-independent hash of the rows' values in columns;
+// NULL and empty values hash differently
+func rowHashExpr(columns []tableColumn) string {
+	parts := make([]string, 0, len(columns)*2)
+	for _, col := range columns {
+		parts = append(parts, col.selectExpr(), "ISNULL("+quoteIdent(col.name)+")")
+	}
+	return fmt.Sprintf("COALESCE(BIT_XOR(CRC32(CONCAT_WS('#', %s))), 0)", strings.Join(parts, ", "))
+}
+
+// keySeparator joins the values of a composite primary key in the keys of
+// fetched rows; maskDifference turns it into ", " for reporting
+const keySeparator = "\x00"
+
+// fetchRows loads all rows of a chunk keyed by their primary key values,
+// joined by keySeparator
+func (de *DiffEngine) fetchRows(ctx context.Context, conn *sql.DB, acquire func(context.Context) (func(), error), tableName string, pk []tableColumn, columns []tableColumn, chunk keyRange) (map[string][]sql.NullString, error) {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = col.selectExpr()
+	}
+	condition, args := chunk.where(pk)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s",
+		strings.Join(quoted, ", "), quoteTable(tableName), condition, orderBy(pk))
+
+	release, err := acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pkIdx := make([]int, len(pk))
+	for i, col := range columns {
+		for j, key := range pk {
+			if col.name == key.name {
+				pkIdx[j] = i
+			}
+		}
+	}
+
+	result := make(map[string][]sql.NullString)
+	key := make([]string, len(pk))
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+
+		row := make([]sql.NullString, len(columns))
+		for i, v := range values {
+			if columns[i].timestampColumn() {
+				row[i] = formatTimestamp(v)
+			} else {
+				row[i] = formatValue(v)
+			}
+		}
+		for j, i := range pkIdx {
+			key[j] = row[i].String
+		}
+		result[strings.Join(key, keySeparator)] = row
+	}
+
+	return result, rows.Err()
+}
+
+// compareRows reports differences between source and target rows of one
+// chunk, in key order. Keys are reported as fetchRows joined them.
+func compareRows(columns []string, sourceRows, targetRows map[string][]sql.NullString) []RowDifference {
+	keys := make([]string, 0, len(sourceRows)+len(targetRows))
+	for key := range sourceRows {
+		keys = append(keys, key)
+	}
+	for key := range targetRows {
+		if _, exists := sourceRows[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, compareKeys)
+
+	var diffs []RowDifference
+	for _, key := range keys {
+		sourceRow, inSource := sourceRows[key]
+		targetRow, inTarget := targetRows[key]
+
+		switch {
+		case !inTarget:
+			diffs = append(diffs, RowDifference{PrimaryKey: key, Kind: "missing_in_target"})
+		case !inSource:
+			diffs = append(diffs, RowDifference{PrimaryKey: key, Kind: "extra_in_target"})
+		default:
+			var cols []ColumnDifference
+			for i, col := range columns {
+				// NULL differs from every value, including the string NULL
+				if sourceRow[i] != targetRow[i] {
+					cols = append(cols, ColumnDifference{
+						Column:      col,
+						SourceValue: sourceRow[i].String,
+						TargetValue: targetRow[i].String,
+						SourceNull:  !sourceRow[i].Valid,
+						TargetNull:  !targetRow[i].Valid,
+					})
+				}
+			}
+			if len(cols) > 0 {
+				diffs = append(diffs, RowDifference{PrimaryKey: key, Kind: "changed", Columns: cols})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareKeys orders primary keys joined by keySeparator column by column,
+// numerically where both values are integers
+func compareKeys(a, b string) int {
+	aValues, bValues := strings.Split(a, keySeparator), strings.Split(b, keySeparator)
+	for i := 0; i < len(aValues) && i < len(bValues); i++ {
+		x, errX := strconv.ParseInt(aValues[i], 10, 64)
+		y, errY := strconv.ParseInt(bValues[i], 10, 64)
+		c := strings.Compare(aValues[i], bValues[i])
+		if errX == nil && errY == nil {
+			c = cmp.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aValues), len(bValues))
+}
+
+// formatValue renders a scanned column value for comparison and reporting.
+// SQL NULL is not valid, so it never equals a string that reads NULL.
+func formatValue(v interface{}) sql.NullString {
+	switch val := v.(type) {
+	case nil:
+		return sql.NullString{}
+	case []byte:
+		return sql.NullString{String: string(val), Valid: true}
+	case time.Time:
+		return sql.NullString{String: val.Format("2006-01-02 15:04:05.999999"), Valid: true}
+	default:
+		return sql.NullString{String: fmt.Sprintf("%v", val), Valid: true}
+	}
+}
+
+// formatTimestamp renders a TIMESTAMP column read as seconds since the
+// epoch as its time in UTC
+func formatTimestamp(v interface{}) sql.NullString {
+	value := formatValue(v)
+	epoch, err := strconv.ParseFloat(value.String, 64)
+	if !value.Valid || err != nil {
+		return value
+	}
+	seconds, fraction := math.Modf(epoch)
+	t := time.Unix(int64(seconds), int64(math.Round(fraction*1e6))*1000).UTC()
+	return sql.NullString{String: t.Format("2006-01-02 15:04:05.999999") + " UTC", Valid: true}
+}
+
+// isIntegerType reports whether a MySQL data type is an integer type
+func isIntegerType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return true
+	}
+	return false
+}
+
+// quoteIdent quotes a MySQL identifier
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteTable quotes a table name that may be qualified as schema.table
+func quoteTable(name string) string {
+	schema, table := config.SplitTable(name)
+	if schema == "" {
+		return quoteIdent(table)
+	}
+	return quoteIdent(schema) + "." + quoteIdent(table)
+}
+
+// tableSchemaCondition restricts information_schema rows to a table, in its
+// schema or the connection's default database; its arguments come from
+// config.SplitTable
+const tableSchemaCondition = "TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?"
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyCondition(t *testing.T) {
+	id := tableColumn{name: "id", dataType: "bigint"}
+	region := tableColumn{name: "region", dataType: "varchar"}
+	created := tableColumn{name: "created_at", dataType: "timestamp"}
+
+	tests := []struct {
+		name     string
+		pk       []tableColumn
+		key      []any
+		op       string
+		orEqual  bool
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "single column after",
+			pk:       []tableColumn{id},
+			key:      []any{int64(10)},
+			op:       ">",
+			want:     "((`id` > ?))",
+			wantArgs: []any{int64(10)},
+		},
+		{
+			name:     "single column up to",
+			pk:       []tableColumn{id},
+			key:      []any{int64(20)},
+			op:       "<",
+			orEqual:  true,
+			want:     "((`id` < ?) OR (`id` = ?))",
+			wantArgs: []any{int64(20), int64(20)},
+		},
+		{
+			name:     "composite after",
+			pk:       []tableColumn{region, id},
+			key:      []any{"eu", int64(10)},
+			op:       ">",
+			want:     "((`region` > ?) OR (`region` = ? AND `id` > ?))",
+			wantArgs: []any{"eu", "eu", int64(10)},
+		},
+		{
+			name:     "composite up to",
+			pk:       []tableColumn{region, id},
+			key:      []any{"us", int64(5)},
+			op:       "<",
+			orEqual:  true,
+			want:     "((`region` < ?) OR (`region` = ? AND `id` < ?) OR (`region` = ? AND `id` = ?))",
+			wantArgs: []any{"us", "us", int64(5), "us", int64(5)},
+		},
+		{
+			name:     "timestamp compared as seconds since the epoch",
+			pk:       []tableColumn{created, id},
+			key:      []any{int64(1700000000), int64(3)},
+			op:       ">",
+			want:     "((`created_at` > FROM_UNIXTIME(?)) OR (`created_at` = FROM_UNIXTIME(?) AND `id` > ?))",
+			wantArgs: []any{int64(1700000000), int64(1700000000), int64(3)},
+		},
+		{
+			name:     "quoted identifier",
+			pk:       []tableColumn{{name: "odd`name", dataType: "int"}},
+			key:      []any{int64(1)},
+			op:       ">",
+			want:     "((`odd``name` > ?))",
+			wantArgs: []any{int64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := keyCondition(tt.pk, tt.key, tt.op, tt.orEqual)
+			if got != tt.want {
+				t.Errorf("condition = %s\nwant        %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeyRangeWhere(t *testing.T) {
+	pk := []tableColumn{{name: "id", dataType: "int"}}
+	tests := []struct {
+		name     string
+		r        keyRange
+		want     string
+		wantArgs []any
+	}{
+		{"whole table", keyRange{}, "1 = 1", nil},
+		{"first chunk", keyRange{upper: []any{int64(100)}}, "((`id` < ?) OR (`id` = ?))", []any{int64(100), int64(100)}},
+		{"last chunk", keyRange{lower: []any{int64(100)}}, "((`id` > ?))", []any{int64(100)}},
+		{"middle chunk", keyRange{lower: []any{int64(100)}, upper: []any{int64(200)}},
+			"((`id` > ?)) AND ((`id` < ?) OR (`id` = ?))", []any{int64(100), int64(200), int64(200)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.r.where(pk)
+			if got != tt.want || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("where = %s %v, want %s %v", got, args, tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
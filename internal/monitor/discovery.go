@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// TableDiscoverer enumerates tables to monitor from information_schema on the source
+type TableDiscoverer struct {
+	connMgr  *database.ConnectionManager
+	explicit []string
+	schemas  []string // "" is the connection's default database
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+}
+
+// NewTableDiscoverer creates a new table discoverer for a database pair
+func NewTableDiscoverer(connMgr *database.ConnectionManager, pair *config.DatabasePair) *TableDiscoverer {
+	td := &TableDiscoverer{
+		connMgr:  connMgr,
+		explicit: pair.ExplicitTables(),
+		schemas:  pair.DiscoverySchemas(),
+	}
+	// Patterns are validated when the configuration is loaded
+	for _, pattern := range pair.TableDiscovery.Include {
+		td.include = append(td.include, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range pair.TableDiscovery.Exclude {
+		td.exclude = append(td.exclude, regexp.MustCompile(pattern))
+	}
+	return td
+}
+
+// MonitoredTables returns the tables monitored for a pair: the listed ones,
+// and with discovery enabled the discovered ones too
+func MonitoredTables(ctx context.Context, connMgr *database.ConnectionManager, pair *config.DatabasePair) ([]string, error) {
+	if !pair.DiscoveryEnabled() {
+		return pair.ExplicitTables(), nil
+	}
+	return NewTableDiscoverer(connMgr, pair).Discover(ctx)
+}
+
+// Discover returns the explicitly configured tables plus all matching base
+// tables on the source. Tables outside the default database are named
+// schema.table.
+func (td *TableDiscoverer) Discover(ctx context.Context) ([]string, error) {
+	sourceConn, err := td.connMgr.GetSourceConnection()
+	if err != nil {
+		return nil, fmt.Errorf("source connection error: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	tables := make([]string, 0)
+	for _, table := range td.explicit {
+		seen[table] = true
+		tables = append(tables, table)
+	}
+
+	for _, schema := range td.schemas {
+		names, err := td.listTables(ctx, sourceConn, schema)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range names {
+			if !seen[table] && td.matches(table) {
+				seen[table] = true
+				tables = append(tables, table)
+			}
+		}
+	}
+
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// listTables returns the base tables of a schema, qualified unless it is
+// the default database
+func (td *TableDiscoverer) listTables(ctx context.Context, conn *sql.DB, schema string) ([]string, error) {
+	query := "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_TYPE = 'BASE TABLE'"
+	rows, err := conn.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		if schema != "" {
+			table = schema + "." + table
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// matches reports whether a table passes the include and exclude patterns
+func (td *TableDiscoverer) matches(table string) bool {
+	for _, re := range td.exclude {
+		if re.MatchString(table) {
+			return false
+		}
+	}
+	if len(td.include) == 0 {
+		return true
+	}
+	for _, re := range td.include {
+		if re.MatchString(table) {
+			return true
+		}
+	}
+	return false
+}
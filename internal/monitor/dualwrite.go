@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// divergenceTracker counts per table the checks in which the source and
+// target of a dual_write pair diverged. The checksum and consistency checks
+// of a cycle each report their tables; a table diverged in the cycle when
+// either check found a difference.
+type divergenceTracker struct {
+	pairName   string
+	alertAfter int
+
+	mu       sync.Mutex
+	cycle    map[string]bool // table to whether it diverged, in the running cycle
+	counters map[string]storage.DivergenceCounter
+}
+
+// newDivergenceTracker creates the tracker of a dual_write pair
+func newDivergenceTracker(pairName string, alertAfter int) *divergenceTracker {
+	return &divergenceTracker{
+		pairName:   pairName,
+		alertAfter: alertAfter,
+		cycle:      make(map[string]bool),
+		counters:   make(map[string]storage.DivergenceCounter),
+	}
+}
+
+// observe records a table checked in the running cycle. Checks that failed
+// or were skipped are not reported, so they neither count as a divergence
+// nor end a divergent run.
+func (dt *divergenceTracker) observe(table string, diverged bool) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.cycle[table] = dt.cycle[table] || diverged
+}
+
+// endCycle updates the counters of the tables checked in the running cycle
+// and returns them, sorted by table
+func (dt *divergenceTracker) endCycle(now time.Time) []storage.DivergenceCounter {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	updated := make([]storage.DivergenceCounter, 0, len(dt.cycle))
+	for table, diverged := range dt.cycle {
+		counter := dt.counters[table]
+		counter.DatabasePair, counter.TableName = dt.pairName, table
+		counter.Checks++
+		counter.LastChecked = now
+		if diverged {
+			if counter.Consecutive == 0 {
+				counter.DivergedSince = now
+			}
+			counter.Divergent++
+			counter.Consecutive++
+			counter.LastDiverged = now
+		} else {
+			counter.Consecutive = 0
+			counter.DivergedSince = time.Time{}
+		}
+		dt.counters[table] = counter
+		updated = append(updated, counter)
+	}
+	clear(dt.cycle)
+
+	sort.Slice(updated, func(i, j int) bool { return updated[i].TableName < updated[j].TableName })
+	return updated
+}
+
+// recordDivergence stores the divergence counters of a dual_write pair's
+// cycle and evaluates their alerts
+func (me *MonitoringEngine) recordDivergence(pm *DatabasePairMonitor) {
+	for _, counter := range pm.divergence.endCycle(me.clock.Now()) {
+		me.storage.StoreDivergence(&counter)
+		me.alertMgr.EvaluateDivergence(pm.pairName, &alert.DivergenceResult{
+			TableName:     counter.TableName,
+			Consecutive:   counter.Consecutive,
+			AlertAfter:    pm.divergence.alertAfter,
+			Divergent:     counter.Divergent,
+			Checks:        counter.Checks,
+			DivergedSince: counter.DivergedSince,
+		})
+	}
+}
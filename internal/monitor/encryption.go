@@ -0,0 +1,270 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// TableEncryption is the tablespace encryption of a monitored table on both
+// databases. A partitioned table is encrypted when all its partitions are.
+type TableEncryption struct {
+	Table           string
+	TargetTable     string
+	SourceEncrypted bool
+	TargetEncrypted bool
+
+	// When the target's table was first seen encrypted; zero when it already
+	// was at the first check
+	EncryptedAt time.Time
+}
+
+// EncryptionStatus is the encryption progress of a pair: the monitored
+// tables and all InnoDB tablespaces encrypted on each database
+type EncryptionStatus struct {
+	Timestamp time.Time
+	Tables    []TableEncryption // in the order they were encrypted on the target, unencrypted last
+
+	TablesEncrypted       int // monitored tables encrypted on the target
+	SourceTablesEncrypted int
+
+	TargetTablespaces          int
+	TargetTablespacesEncrypted int
+	SourceTablespaces          int
+	SourceTablespacesEncrypted int
+	SourceChecked              bool // the source was reachable; its counts are zero otherwise
+
+	Error error
+}
+
+// Percent returns the share of monitored tables encrypted on the target
+func (s *EncryptionStatus) Percent() float64 {
+	if len(s.Tables) == 0 {
+		return 0
+	}
+	return float64(s.TablesEncrypted) / float64(len(s.Tables)) * 100
+}
+
+// EncryptionMonitor tracks the InnoDB tablespace encryption of a pair's
+// databases, and when each monitored table finished encrypting on the target
+type EncryptionMonitor struct {
+	connMgr  *database.ConnectionManager
+	clock    clock.Clock
+	config   config.EncryptionStatusConfig
+	mappings config.TableMappings
+	views    []string // monitored tables without a tablespace
+	timeout  time.Duration
+	source   flavorCache
+	target   flavorCache
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	checked     bool
+	encryptedAt map[string]time.Time // key: table; zero when encrypted at the first check
+}
+
+// NewEncryptionMonitor creates a new encryption status monitor
+func NewEncryptionMonitor(connMgr *database.ConnectionManager, cfg config.EncryptionStatusConfig, mappings config.TableMappings, views []string, timeout time.Duration) *EncryptionMonitor {
+	return &EncryptionMonitor{
+		connMgr:     connMgr,
+		clock:       clock.Real,
+		config:      cfg,
+		mappings:    mappings,
+		views:       views,
+		timeout:     timeout,
+		encryptedAt: make(map[string]time.Time),
+	}
+}
+
+// Due reports whether the check interval has passed since the last check
+func (em *EncryptionMonitor) Due() bool {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	return em.clock.Since(em.lastRun) >= em.config.Interval
+}
+
+// Check reads the tablespace encryption of the target, and of the source
+// when withSource is set, e.g. unless it is down
+func (em *EncryptionMonitor) Check(ctx context.Context, tables []string, withSource bool) (*EncryptionStatus, error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.lastRun = em.clock.Now()
+
+	status := &EncryptionStatus{
+		Timestamp: em.clock.Now(),
+		Tables:    make([]TableEncryption, 0, len(tables)),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, em.timeout)
+	defer cancel()
+
+	targetConn, err := em.connMgr.GetTargetConnection()
+	if err != nil {
+		status.Error = fmt.Errorf("target connection error: %w", err)
+		return status, status.Error
+	}
+	target, err := readTablespaces(ctx, targetConn, em.target.get(ctx, targetConn))
+	if err != nil {
+		status.Error = fmt.Errorf("target: %w", err)
+		return status, status.Error
+	}
+	status.TargetTablespaces, status.TargetTablespacesEncrypted = target.count()
+
+	var source *tablespaces
+	if withSource {
+		sourceConn, err := em.connMgr.GetSourceConnection()
+		if err != nil {
+			status.Error = fmt.Errorf("source connection error: %w", err)
+			return status, status.Error
+		}
+		if source, err = readTablespaces(ctx, sourceConn, em.source.get(ctx, sourceConn)); err != nil {
+			status.Error = fmt.Errorf("source: %w", err)
+			return status, status.Error
+		}
+		status.SourceTablespaces, status.SourceTablespacesEncrypted = source.count()
+		status.SourceChecked = true
+	}
+
+	for _, table := range tables {
+		if slices.Contains(em.views, table) {
+			continue
+		}
+		entry := TableEncryption{
+			Table:           table,
+			TargetTable:     em.mappings.Target(table),
+			TargetEncrypted: target.tableEncrypted(em.mappings.Target(table)),
+		}
+		if source != nil {
+			entry.SourceEncrypted = source.tableEncrypted(table)
+		}
+
+		// The first check finds the tables encrypted before monitoring began
+		encryptedAt, seen := em.encryptedAt[table]
+		switch {
+		case !entry.TargetEncrypted:
+			delete(em.encryptedAt, table)
+		case !seen && em.checked:
+			encryptedAt = status.Timestamp
+			em.encryptedAt[table] = encryptedAt
+		case !seen:
+			em.encryptedAt[table] = time.Time{}
+		}
+		if entry.TargetEncrypted {
+			entry.EncryptedAt = encryptedAt
+			status.TablesEncrypted++
+		}
+		if entry.SourceEncrypted {
+			status.SourceTablesEncrypted++
+		}
+		status.Tables = append(status.Tables, entry)
+	}
+	em.checked = true
+
+	sort.SliceStable(status.Tables, func(i, j int) bool {
+		a, b := status.Tables[i], status.Tables[j]
+		if a.TargetEncrypted != b.TargetEncrypted {
+			return a.TargetEncrypted
+		}
+		return a.EncryptedAt.Before(b.EncryptedAt)
+	})
+	return status, nil
+}
+
+// tablespaces is the encryption of a database's InnoDB tablespaces
+type tablespaces struct {
+	database  string          // the connection's default database
+	encrypted map[string]bool // key: tablespace name, e.g. shop/orders or shop/orders#P#p0
+}
+
+// tablespaceQuery lists the tablespaces of a server and whether each is
+// encrypted; empty before MySQL 8.0.13
+func tablespaceQuery(flavor serverFlavor) string {
+	if flavor.mysql {
+		if !flavor.mysqlAtLeast(8, 0, 13) {
+			return ""
+		}
+		return "SELECT NAME, ENCRYPTION = 'Y' FROM information_schema.INNODB_TABLESPACES"
+	}
+	return "SELECT t.NAME, COALESCE(e.ENCRYPTION_SCHEME <> 0 AND e.MIN_KEY_VERSION > 0, 0) " +
+		"FROM information_schema.INNODB_SYS_TABLESPACES t " +
+		"LEFT JOIN information_schema.INNODB_TABLESPACES_ENCRYPTION e ON e.SPACE = t.SPACE"
+}
+
+// readTablespaces lists the InnoDB tablespaces of a database and whether each
+// is encrypted. MariaDB only lists tablespaces with encryption metadata in
+// INNODB_TABLESPACES_ENCRYPTION, and counts one as encrypted once all its
+// pages are (MIN_KEY_VERSION above zero); MySQL has a flag per tablespace
+// since 8.0.13.
+func readTablespaces(ctx context.Context, db *sql.DB, flavor serverFlavor) (*tablespaces, error) {
+	query := tablespaceQuery(flavor)
+	if query == "" {
+		return nil, fmt.Errorf("tablespace encryption status needs MySQL 8.0.13 or later")
+	}
+
+	result := &tablespaces{encrypted: make(map[string]bool)}
+	var defaultDB sql.NullString
+	if err := db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&defaultDB); err != nil {
+		return nil, fmt.Errorf("failed to read the default database: %w", err)
+	}
+	result.database = defaultDB.String
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tablespace encryption: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var encrypted bool
+		if err := rows.Scan(&name, &encrypted); err != nil {
+			return nil, fmt.Errorf("failed to scan tablespace encryption: %w", err)
+		}
+		result.encrypted[name] = encrypted
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tablespace encryption: %w", err)
+	}
+	return result, nil
+}
+
+// count returns the number of tablespaces and of encrypted ones
+func (t *tablespaces) count() (total, encrypted int) {
+	for _, e := range t.encrypted {
+		total++
+		if e {
+			encrypted++
+		}
+	}
+	return total, encrypted
+}
+
+// tableEncrypted reports whether a table has its own tablespaces, one per
+// partition, and all are encrypted. Tables in the system tablespace are
+// reported unencrypted.
+func (t *tablespaces) tableEncrypted(table string) bool {
+	schema, name := config.SplitTable(table)
+	if schema == "" {
+		schema = t.database
+	}
+	prefix := schema + "/" + name
+	found := false
+	for space, encrypted := range t.encrypted {
+		if space != prefix && !strings.HasPrefix(space, prefix+"#") {
+			continue
+		}
+		if !encrypted {
+			return false
+		}
+		found = true
+	}
+	return found
+}
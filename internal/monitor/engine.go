@@ -1,213 +1,823 @@
 package monitor
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/clock"
 	"mariadb-encryption-monitor/internal/config"
 	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/statsd"
 	"mariadb-encryption-monitor/internal/storage"
+	"mariadb-encryption-monitor/internal/timeseries"
 )
 
 // DatabasePairMonitor monitors a single database pair
 type DatabasePairMonitor struct {
 	pairName           string
-	tables             []string
 	connMgr            *database.ConnectionManager
+	checkConnMgr       *database.ConnectionManager // nil unless the pair has check endpoints
+	warmupConnMgr      *database.ConnectionManager // nil unless the warm-up checks connect with their own settings
 	replicaLagMonitor  *ReplicaLagMonitor
+	binlogRateMonitor  *BinlogRateMonitor
 	checksumValidator  *ChecksumValidator
 	consistencyChecker *ConsistencyChecker
+	clockSkewMonitor   *ClockSkewMonitor
+	warmupChecker      *WarmupChecker            // nil unless warm-up verification is enabled
+	semiSyncMonitor    *SemiSyncMonitor          // nil unless semi-sync monitoring is enabled
+	filterMonitor      *ReplicationFilterMonitor // nil in dual_write mode, which has no replication
+	ptChecksumReader   *PTChecksumReader         // nil unless pt-table-checksum results are read
+	encryptionMonitor  *EncryptionMonitor        // nil unless encryption status is tracked
+	definitionChecker  *TableDefinitionChecker   // nil unless table definitions are checked
+	checksumScheduler  *checksumScheduler        // nil unless checksums wait for quiet replication or are staggered
+	divergence         *divergenceTracker        // nil unless the pair is in dual_write mode
+	lagAnomaly         *lagAnomalyDetector       // nil unless lag anomaly detection is enabled
+	diffEngine         *DiffEngine
+	settingsChanged    chan struct{}
+
+	// Cancelled when the pair is removed or the engine stops; done is closed
+	// once the pair's monitoring loop returned, nil until it started and
+	// guarded by mu
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The pair's configuration with its current credentials, probed by
+	// pre-flight checks; guarded by mu
+	pairConfig config.DatabasePair
+
+	// Circuit breakers backing off the checks of an unreachable database
+	breakers pairBreakers
+
+	// Intermediates of a chained replication topology, in chain order
+	hops []*hopMonitor
+
+	// Fan-out pairs monitor a replica of another pair's target
+	fanOutOf       string
+	waitForCutOver bool // in standby until the fanOutOf pair cuts over
+
+	// Tables may change at runtime when discovery is enabled
+	mu               sync.RWMutex
+	tables           []string
+	discoverer       *TableDiscoverer // nil when tables are listed explicitly
+	discoveryRefresh time.Duration
+	lastDiscovery    time.Time
+
+	// Lifecycle state, guarded by mu
+	state          string
+	pausedFrom     string // state to return to on resume
+	sawReplication bool   // the target was seen replicating, so losing replication means cut over
+	activated      bool   // a fan-out pair left standby
+	startComplete  bool   // the pair's migration was complete at startup
+
+	// Checks paused through the API, by check name; guarded by mu
+	pausedChecks map[string]PausedCheck
+}
+
+// Tables returns the tables currently monitored for the pair
+func (pm *DatabasePairMonitor) Tables() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.tables
+}
+
+// refreshTables rediscovers tables when discovery is enabled and the refresh interval has passed
+func (pm *DatabasePairMonitor) refreshTables(ctx context.Context, now time.Time) {
+	if pm.discoverer == nil {
+		return
+	}
+
+	pm.mu.RLock()
+	due := now.Sub(pm.lastDiscovery) >= pm.discoveryRefresh
+	pm.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	tables, err := pm.discoverer.Discover(ctx)
+	if err != nil {
+		log.Printf("[%s] Table discovery error: %v", pm.pairName, err)
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if len(tables) != len(pm.tables) {
+		log.Printf("[%s] Discovered %d table(s) to monitor", pm.pairName, len(tables))
+	}
+	pm.tables = tables
+	pm.lastDiscovery = now
 }
 
 // MonitoringEngine orchestrates all monitoring operations
 type MonitoringEngine struct {
-	config       *config.Config
+	config     *config.Config
+	storage    *storage.MetricsStorage
+	alertMgr   *alert.AlertManager
+	clock      clock.Clock
+	statsd     *statsd.Client            // nil unless StatsD is enabled
+	timeseries *timeseries.Sink          // nil unless a time-series database is configured
+	cycles     *cyclePool                // nil when cycles are unbounded
+	limiter    *database.InstanceLimiter // query slots per instance, shared by all pairs
+	ctx        context.Context           // cancelled on Stop to abort in-flight queries
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	// Pairs may be added and removed at runtime, until Stop sets stopping
+	pairsMu      sync.RWMutex
 	pairMonitors []*DatabasePairMonitor
-	storage      *storage.MetricsStorage
-	alertMgr     *alert.AlertManager
-	stopChan     chan struct{}
-	wg           sync.WaitGroup
+	stopping     bool
+
+	// When Start or RunOnce was called; tables count as unvalidated since then
+	startedAt time.Time
+
+	// Unix nanoseconds of the last cycle with both databases of a pair reachable
+	lastSuccessfulCycle atomic.Int64
+
+	// Set by RunOnce: checksums run regardless of their schedule
+	oneShot bool
+
+	listenersMu    sync.RWMutex
+	pairListeners  []func(PairEvent)
+	cycleListeners []func(pairName string)
+
+	backfillMu     sync.Mutex
+	backfills      []Backfill
+	nextBackfillID int
+
+	faultMu     sync.Mutex
+	faults      []Fault
+	nextFaultID int
+
+	statsMu    sync.Mutex
+	cycleStats map[string]*CycleStats // key: database_pair
+	checkStats map[string]*CheckStats // key: database_pair/check
 }
 
 // NewMonitoringEngine creates a new monitoring engine
 func NewMonitoringEngine(cfg *config.Config, store *storage.MetricsStorage, alertMgr *alert.AlertManager) *MonitoringEngine {
+	ctx, cancel := context.WithCancel(context.Background())
+	me := &MonitoringEngine{
+		config:     cfg,
+		storage:    store,
+		alertMgr:   alertMgr,
+		clock:      clock.Real,
+		cycles:     newCyclePool(cfg.MaxConcurrentPairs, clock.Real),
+		limiter:    database.NewInstanceLimiter(cfg.MaxConcurrentQueriesPerInstance),
+		ctx:        ctx,
+		cancel:     cancel,
+		cycleStats: make(map[string]*CycleStats),
+		checkStats: make(map[string]*CheckStats),
+	}
+
 	// Create monitors for each database pair
-	pairMonitors := make([]*DatabasePairMonitor, 0, len(cfg.DatabasePairs))
-	
+	me.pairMonitors = make([]*DatabasePairMonitor, 0, len(cfg.DatabasePairs))
 	for _, pair := range cfg.DatabasePairs {
-		connMgr := database.NewConnectionManager(&pair.SourceDB, &pair.TargetDB, pair.Name)
-		
-		pairMonitor := &DatabasePairMonitor{
-			pairName:           pair.Name,
-			tables:             pair.TablesToMonitor,
-			connMgr:            connMgr,
-			replicaLagMonitor:  NewReplicaLagMonitor(connMgr),
-			checksumValidator:  NewChecksumValidator(connMgr),
-			consistencyChecker: NewConsistencyChecker(connMgr),
+		me.pairMonitors = append(me.pairMonitors, me.newPairMonitor(pair))
+	}
+	return me
+}
+
+// newPairMonitor creates the monitor of a database pair
+func (me *MonitoringEngine) newPairMonitor(pair config.DatabasePair) *DatabasePairMonitor {
+	cfg, limiter := me.config, me.limiter
+	connMgr := database.NewConnectionManager(&pair.SourceDB, &pair.TargetDB, pair.Name, limiter, cfg.Timeouts.Connect)
+
+	// Checksums, row counts and row diffs read from the check endpoints
+	checkConnMgr := connMgr
+	if pair.CheckEndpoints.HasSource() || pair.CheckEndpoints.HasTarget() {
+		checkSource, checkTarget := pair.CheckDatabases()
+		checkConnMgr = database.NewConnectionManager(checkSource, checkTarget, pair.Name+"/checks", limiter, cfg.Timeouts.Connect)
+	}
+
+	pairMonitor := &DatabasePairMonitor{
+		pairName:           pair.Name,
+		pairConfig:         pair,
+		tables:             pair.ExplicitTables(),
+		connMgr:            connMgr,
+		replicaLagMonitor:  NewReplicaLagMonitor(connMgr, cfg.Timeouts.ReplicaLag),
+		binlogRateMonitor:  NewBinlogRateMonitor(connMgr, cfg.Timeouts.ReplicaLag),
+		checksumValidator:  NewChecksumValidator(checkConnMgr, pair.ChecksumPreflight, pair.TableMappings, pair.ChecksumExclusions, pair.Views, cfg.Timeouts.Checksum),
+		consistencyChecker: NewConsistencyChecker(checkConnMgr, pair.ApproximateCounts, pair.ConsistencyWindows, pair.TableMappings, pair.Masking, cfg.Timeouts.Consistency),
+		clockSkewMonitor:   NewClockSkewMonitor(connMgr, cfg.Timeouts.ClockSkew),
+		diffEngine:         NewDiffEngine(checkConnMgr, pair.TableMappings, pair.Masking, pair.ChecksumExclusions),
+		settingsChanged:    make(chan struct{}, 1),
+		fanOutOf:           pair.FanOutOf,
+		waitForCutOver:     pair.WaitForCutOver,
+		startComplete:      pair.MigrationComplete,
+		hops:               newHopMonitors(&pair, limiter, cfg),
+		checksumScheduler:  newChecksumScheduler(pair.ChecksumSchedule),
+		lagAnomaly:         newLagAnomalyDetector(pair.LagAnomaly),
+	}
+	pairMonitor.replicaLagMonitor.sourceIsPrimary = len(pair.Intermediates) == 0
+	if checkConnMgr != connMgr {
+		pairMonitor.checkConnMgr = checkConnMgr
+	}
+	if pair.DiscoveryEnabled() {
+		pairMonitor.discoverer = NewTableDiscoverer(connMgr, &pair)
+		pairMonitor.discoveryRefresh = pair.TableDiscovery.RefreshInterval
+	}
+	if pair.Warmup.Enabled {
+		warmupConnMgr := connMgr
+		if pair.Warmup.HasDB() {
+			warmupConnMgr = database.NewConnectionManager(nil, warmupDatabase(&pair), pair.Name+"/warmup", limiter, cfg.Timeouts.Connect)
+			pairMonitor.warmupConnMgr = warmupConnMgr
 		}
-		
-		pairMonitors = append(pairMonitors, pairMonitor)
+		pairMonitor.warmupChecker = NewWarmupChecker(warmupConnMgr, pair.Warmup, cfg.Timeouts.Warmup)
+	}
+	if pair.SemiSync.Enabled {
+		pairMonitor.semiSyncMonitor = NewSemiSyncMonitor(connMgr, cfg.Timeouts.ReplicaLag)
+	}
+	if pair.PTChecksum.Enabled {
+		pairMonitor.ptChecksumReader = NewPTChecksumReader(connMgr, pair.PTChecksum, pair.Masking, pair.SourceDB.Database, cfg.Timeouts.Consistency)
+	}
+	if pair.EncryptionStatus.Enabled {
+		pairMonitor.encryptionMonitor = NewEncryptionMonitor(connMgr, pair.EncryptionStatus, pair.TableMappings, pair.Views, cfg.Timeouts.Consistency)
+	}
+	if pair.TableDefinitions.Enabled {
+		pairMonitor.definitionChecker = NewTableDefinitionChecker(connMgr, pair.TableDefinitions, pair.TableMappings, pair.Views, cfg.Timeouts.Consistency)
+	}
+	if pair.DualWriteMode() {
+		pairMonitor.divergence = newDivergenceTracker(pair.Name, pair.DualWrite.AlertAfter)
+	} else {
+		pairMonitor.filterMonitor = NewReplicationFilterMonitor(connMgr, cfg.Timeouts.ReplicaLag)
 	}
 
-	return &MonitoringEngine{
-		config:       cfg,
-		pairMonitors: pairMonitors,
-		storage:      store,
-		alertMgr:     alertMgr,
-		stopChan:     make(chan struct{}),
+	pairMonitor.ctx, pairMonitor.cancel = context.WithCancel(me.ctx)
+	return pairMonitor
+}
+
+// SetClock sets the clock that schedules the checks and timestamps their
+// results. It must be called before Start; the storage and alert manager
+// take their own clock.
+func (me *MonitoringEngine) SetClock(c clock.Clock) {
+	me.clock = c
+	if me.cycles != nil {
+		me.cycles.clock = c
+	}
+	for _, pm := range me.pairMonitors {
+		pm.setClock(c)
+	}
+}
+
+// setClock sets the clock of a pair's checks
+func (pm *DatabasePairMonitor) setClock(c clock.Clock) {
+	pm.replicaLagMonitor.clock = c
+	pm.binlogRateMonitor.clock = c
+	pm.checksumValidator.clock = c
+	pm.consistencyChecker.clock = c
+	pm.clockSkewMonitor.clock = c
+	if pm.warmupChecker != nil {
+		pm.warmupChecker.clock = c
+	}
+	if pm.semiSyncMonitor != nil {
+		pm.semiSyncMonitor.clock = c
+	}
+	if pm.filterMonitor != nil {
+		pm.filterMonitor.clock = c
+	}
+	if pm.ptChecksumReader != nil {
+		pm.ptChecksumReader.clock = c
+	}
+	if pm.encryptionMonitor != nil {
+		pm.encryptionMonitor.clock = c
+	}
+	if pm.definitionChecker != nil {
+		pm.definitionChecker.clock = c
+	}
+	for _, hop := range pm.hops {
+		hop.replicaLagMonitor.clock = c
 	}
 }
 
 // Start starts the monitoring engine
 func (me *MonitoringEngine) Start() error {
-	log.Printf("Starting monitoring engine for %d database pair(s)...", len(me.pairMonitors))
-
-	// Connect to all database pairs
-	for _, pairMonitor := range me.pairMonitors {
-		log.Printf("Connecting to database pair: %s", pairMonitor.pairName)
-		
-		if err := pairMonitor.connMgr.ConnectSource(); err != nil {
-			log.Printf("Warning: Failed to connect to source database for pair '%s': %v", pairMonitor.pairName, err)
-		}
+	pairMonitors := me.pairs()
+	log.Printf("Starting monitoring engine for %d database pair(s)...", len(pairMonitors))
+	me.startedAt = me.clock.Now()
 
-		if err := pairMonitor.connMgr.ConnectTarget(); err != nil {
-			log.Printf("Warning: Failed to connect to target database for pair '%s': %v", pairMonitor.pairName, err)
+	// Connect to all database pairs; fan-out pairs waiting for a cut over
+	// connect when they are activated
+	for _, pairMonitor := range pairMonitors {
+		if pairMonitor.waitForCutOver {
+			me.transition(pairMonitor, StateStandby, EventPairStandby, fmt.Sprintf("waiting for pair '%s' to cut over", pairMonitor.fanOutOf))
+			continue
+		}
+		me.connectPair(pairMonitor)
+		// Keep retrying databases that were down at startup
+		pairMonitor.keepConnected(pairMonitor.ctx)
+		if pairMonitor.startComplete {
+			me.transition(pairMonitor, StateComplete, EventPairAdded, "monitoring started; migration complete")
+			continue
 		}
+		me.transition(pairMonitor, StateMonitoring, EventPairAdded, "monitoring started")
+	}
 
-		// Update initial connection status
-		sourceOK, targetOK := pairMonitor.connMgr.HealthCheck()
-		me.storage.UpdateConnectionStatus(pairMonitor.pairName, storage.ConnectionStatus{
-			SourceConnected: sourceOK,
-			TargetConnected: targetOK,
-			LastChecked:     time.Now(),
-		})
+	// Start one monitoring loop per pair so each can run at its own interval
+	for _, pairMonitor := range pairMonitors {
+		me.startPairLoop(pairMonitor)
 	}
 
-	// Start monitoring loop
 	me.wg.Add(1)
-	go me.monitoringLoop()
+	go me.runStartupPreflight()
 
 	log.Println("Monitoring engine started")
 	return nil
 }
 
+// RunOnce connects to every pair and runs a single monitoring cycle for all
+// of them concurrently, without starting the monitoring loops. Fan-out pairs
+// waiting for a cut over are skipped. Call Stop afterwards to close the
+// connections.
+func (me *MonitoringEngine) RunOnce() {
+	me.oneShot = true
+	me.startedAt = me.clock.Now()
+	var pairMonitors []*DatabasePairMonitor
+	for _, pairMonitor := range me.pairs() {
+		if !pairMonitor.waitForCutOver {
+			pairMonitors = append(pairMonitors, pairMonitor)
+		}
+	}
+
+	for _, pairMonitor := range pairMonitors {
+		me.connectPair(pairMonitor)
+	}
+
+	var wg sync.WaitGroup
+	for _, pairMonitor := range pairMonitors {
+		wg.Add(1)
+		go func(pm *DatabasePairMonitor) {
+			defer wg.Done()
+			release, _, err := me.cycles.acquire(me.ctx)
+			if err != nil {
+				return
+			}
+			defer release()
+			me.runCycle(me.ctx, pm)
+		}(pairMonitor)
+	}
+	wg.Wait()
+}
+
+// connectPair connects to both databases of a pair, discovers its tables and
+// records the initial connection status
+func (me *MonitoringEngine) connectPair(pm *DatabasePairMonitor) {
+	log.Printf("Connecting to database pair: %s", pm.pairName)
+
+	if err := pm.connMgr.ConnectSource(pm.ctx); err != nil {
+		log.Printf("Warning: Failed to connect to source database for pair '%s': %v", pm.pairName, err)
+	}
+
+	if err := pm.connMgr.ConnectTarget(pm.ctx); err != nil {
+		log.Printf("Warning: Failed to connect to target database for pair '%s': %v", pm.pairName, err)
+	}
+	me.connectHops(pm)
+	me.connectCheckEndpoints(pm)
+
+	// Discover tables to monitor once the source is reachable
+	pm.refreshTables(pm.ctx, me.clock.Now())
+
+	// Update initial connection status
+	sourceOK, targetOK := pm.connMgr.HealthCheck(pm.ctx)
+	me.storage.UpdateConnectionStatus(pm.pairName, storage.ConnectionStatus{
+		SourceConnected: sourceOK,
+		TargetConnected: targetOK,
+		LastChecked:     me.clock.Now(),
+	})
+}
+
 // Stop stops the monitoring engine
 func (me *MonitoringEngine) Stop() {
 	log.Println("Stopping monitoring engine...")
-	close(me.stopChan)
+	me.pairsMu.Lock()
+	me.stopping = true
+	me.pairsMu.Unlock()
+	me.cancel()
 	me.wg.Wait()
-	
+
 	// Close all database connections
-	for _, pairMonitor := range me.pairMonitors {
-		pairMonitor.connMgr.Close()
+	for _, pairMonitor := range me.pairs() {
+		pairMonitor.close()
 	}
-	
+
 	log.Println("Monitoring engine stopped")
 }
 
-// monitoringLoop runs the monitoring cycle at configured intervals
-func (me *MonitoringEngine) monitoringLoop() {
+// startPairLoop starts the monitoring loop of a pair, which runs until the
+// engine stops or the pair is removed
+func (me *MonitoringEngine) startPairLoop(pm *DatabasePairMonitor) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.ctx.Err() != nil {
+		return // removed before it started
+	}
+	pm.done = make(chan struct{})
+	me.wg.Add(1)
+	go me.pairLoop(pm)
+}
+
+// pairLoop checks a database pair at its configured check interval, counted
+// from the start of each cycle
+func (me *MonitoringEngine) pairLoop(pm *DatabasePairMonitor) {
 	defer me.wg.Done()
+	defer close(pm.done)
+
+	for {
+		start := me.clock.Now()
+		next := start.Add(me.checkInterval(pm))
+		if pm.active() {
+			next = me.runScheduledCycle(pm, start)
+		}
+		lastRun := start
 
-	ticker := time.NewTicker(me.config.MonitoringInterval)
-	defer ticker.Stop()
+	wait:
+		for {
+			timer := me.clock.NewTimer(next.Sub(me.clock.Now()))
+			select {
+			case <-timer.C():
+				break wait
+			case <-pm.settingsChanged:
+				// Recompute the next run from the new check interval
+				timer.Stop()
+				next = lastRun.Add(me.checkInterval(pm))
+			case <-pm.ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}
+}
 
-	// Run initial cycle immediately
-	me.runMonitoringCycle()
+// runScheduledCycle runs a cycle of a pair that started at start and returns
+// when the next one is due, applying cycle_overlap when it overran the
+// check interval
+func (me *MonitoringEngine) runScheduledCycle(pm *DatabasePairMonitor, start time.Time) time.Time {
+	interval := me.checkInterval(pm)
+	release, waited, err := me.cycles.acquire(pm.ctx)
+	if err != nil {
+		return start.Add(interval) // shutting down or removed
+	}
+	began := me.clock.Now()
+	ctx, stop := me.cycleContext(pm.ctx, interval)
+	me.runCycle(ctx, pm)
+	cancelled := stop()
+	release()
 
-	for {
+	next, skipped := nextCycle(me.config.CycleOverlap, start, me.clock.Now(), interval)
+	duration := me.clock.Since(began)
+	me.recordCycle(pm.pairName, duration, waited, interval, skipped, cancelled)
+	switch {
+	case cancelled:
+		log.Printf("[%s] Monitoring cycle cancelled after the %v check interval", pm.pairName, interval)
+	case skipped > 0 && waited >= time.Second:
+		log.Printf("[%s] Monitoring cycle took %v after waiting %v for a slot (max_concurrent_pairs), longer than the %v check interval; skipped %d cycle(s)", pm.pairName, duration.Round(time.Millisecond), waited.Round(time.Millisecond), interval, skipped)
+	case skipped > 0:
+		log.Printf("[%s] Monitoring cycle took %v, longer than the %v check interval; skipped %d cycle(s)", pm.pairName, duration.Round(time.Millisecond), interval, skipped)
+	}
+	return next
+}
+
+// nextCycle returns when the next cycle is due after one that ran from start
+// to end, and how many ticks of the interval are skipped. "skip" waits for
+// the first tick after end; "queue" and "cancel" run the first tick that
+// passed right away.
+func nextCycle(policy string, start, end time.Time, interval time.Duration) (time.Time, int) {
+	due := start.Add(interval)
+	if interval <= 0 || !end.After(due) {
+		return due, 0
+	}
+	missed := int(end.Sub(start) / interval) // ticks that passed while the cycle ran
+	if policy == "skip" {
+		return start.Add(time.Duration(missed+1) * interval), missed
+	}
+	return end, missed - 1
+}
+
+// cycleContext returns the context of a cycle of a pair, derived from the
+// pair's context. With cycle_overlap: cancel, it is cancelled once the cycle
+// runs for the check interval. stop ends the cycle and reports whether it was
+// cancelled.
+func (me *MonitoringEngine) cycleContext(parent context.Context, interval time.Duration) (context.Context, func() bool) {
+	if me.config.CycleOverlap != "cancel" {
+		return parent, func() bool { return false }
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	timer := me.clock.NewTimer(interval)
+	done := make(chan struct{})
+	cancelled := make(chan bool, 1)
+	go func() {
 		select {
-		case <-ticker.C:
-			me.runMonitoringCycle()
-		case <-me.stopChan:
-			return
+		case <-timer.C():
+			cancel()
+			cancelled <- true
+		case <-done:
+			timer.Stop()
+			cancelled <- false
 		}
+	}()
+	return ctx, func() bool {
+		close(done)
+		cancel()
+		return <-cancelled
 	}
 }
 
-// runMonitoringCycle executes a single monitoring cycle
-func (me *MonitoringEngine) runMonitoringCycle() {
-	log.Println("Running monitoring cycle...")
+// runCycle runs full checks of a pair, or the heartbeat of a completed pair
+func (me *MonitoringEngine) runCycle(ctx context.Context, pm *DatabasePairMonitor) {
+	if pm.completed() {
+		me.heartbeatPair(ctx, pm)
+	} else {
+		me.monitorDatabasePair(ctx, pm)
+	}
 
-	var wg sync.WaitGroup
+	me.listenersMu.RLock()
+	listeners := me.cycleListeners
+	me.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(pm.pairName)
+	}
+}
 
-	// Monitor each database pair
-	for _, pairMonitor := range me.pairMonitors {
-		wg.Add(1)
-		go func(pm *DatabasePairMonitor) {
-			defer wg.Done()
-			me.monitorDatabasePair(pm)
-		}(pairMonitor)
+// AddCycleListener registers a function called after each check cycle of a
+// pair has stored its results. Listeners are called synchronously and must
+// not block.
+func (me *MonitoringEngine) AddCycleListener(listener func(pairName string)) {
+	me.listenersMu.Lock()
+	defer me.listenersMu.Unlock()
+
+	me.cycleListeners = append(me.cycleListeners, listener)
+}
+
+// ApplyPairSettings picks up changed runtime settings for a database pair
+func (me *MonitoringEngine) ApplyPairSettings(pairName string) error {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
 	}
 
-	wg.Wait()
-	log.Println("Monitoring cycle completed")
+	select {
+	case pm.settingsChanged <- struct{}{}:
+	default: // a change is already pending
+	}
+	return nil
 }
 
 // monitorDatabasePair monitors a single database pair
-func (me *MonitoringEngine) monitorDatabasePair(pm *DatabasePairMonitor) {
-	// Update connection status
-	sourceOK, targetOK := pm.connMgr.HealthCheck()
+func (me *MonitoringEngine) monitorDatabasePair(ctx context.Context, pm *DatabasePairMonitor) {
+	ctx, endCycle := me.startCycle(ctx, pm.pairName)
+	defer endCycle()
+
+	// Update connection status; a rehearsal fault makes the checks skip a
+	// database as if it were unreachable
+	health := me.healthCheck(ctx, pm)
+	sourceOK, targetOK := health.SourceOK, health.TargetOK
+	rehearsal := me.injectConnectionFault(pm.pairName, &sourceOK, &targetOK)
 	me.storage.UpdateConnectionStatus(pm.pairName, storage.ConnectionStatus{
 		SourceConnected: sourceOK,
 		TargetConnected: targetOK,
-		LastChecked:     time.Now(),
+		LastChecked:     me.clock.Now(),
+		SourceRetryAt:   health.SourceRetryAt,
+		TargetRetryAt:   health.TargetRetryAt,
+		Rehearsal:       rehearsal,
 	})
+	me.emitConnection(pm.pairName, sourceOK, targetOK)
+	me.checkFailovers(pm)
+
+	// Both databases are backed off: there is nothing to check until one is
+	// probed again
+	if health.allBackedOff() {
+		return
+	}
+	// Checks skip a backed-off database without logging it every cycle
+	quiet := health.backedOff()
+
+	if sourceOK {
+		pm.refreshTables(ctx, me.clock.Now())
+	}
+	tables := pm.Tables()
+
+	if settings, ok := me.config.PairSettings(pm.pairName); ok {
+		pm.consistencyChecker.SetTolerancePercent(settings.TolerancePercent)
+	}
+
+	// Checks paused through the API are skipped until resumed
+	paused := me.pausedCheckSet(pm)
 
 	var wg sync.WaitGroup
 
-	// Run replica lag monitoring
+	// Run replica lag monitoring; dual_write pairs have no replication to measure
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		switch {
+		case pm.divergence != nil:
+		case paused["replica_lag"]:
+			log.Printf("[%s] Skipping replica lag check: paused", pm.pairName)
+		case targetOK:
+			// The source's write rate tells what the lag is down to
+			var writes *BinlogRate
+			if sourceOK && !paused["binlog_rate"] {
+				writes = me.checkBinlogRate(ctx, pm)
+			}
+			me.checkReplicaLag(ctx, pm, writes)
+		case !quiet:
+			log.Printf("[%s] Skipping replica lag check: target database not connected", pm.pairName)
+		}
+	}()
+
+	// Run clock skew detection
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if targetOK {
-			metric, err := pm.replicaLagMonitor.MeasureLag()
+		if paused["clock_skew"] {
+			log.Printf("[%s] Skipping clock skew check: paused", pm.pairName)
+			return
+		}
+		if sourceOK && targetOK {
+			ctx, endCheck := me.startCheck(ctx, pm.pairName, "clock_skew")
+			metric, err := pm.clockSkewMonitor.MeasureSkew(ctx)
+			endCheck(err)
 			if err != nil {
-				log.Printf("[%s] Replica lag monitoring error: %v", pm.pairName, err)
+				log.Printf("[%s] Clock skew detection error: %v", pm.pairName, err)
 			}
 			if metric != nil {
 				// Convert to storage type
-				storageMetric := &storage.ReplicaLagMetric{
-					DatabasePair: pm.pairName,
-					Timestamp:    metric.Timestamp,
-					LagSeconds:   metric.LagSeconds,
-					Status:       metric.Status,
-					Error:        metric.Error,
+				storageMetric := &storage.ClockSkewMetric{
+					DatabasePair:            pm.pairName,
+					Timestamp:               metric.Timestamp,
+					SourceSkewSeconds:       metric.SourceSkew.Seconds(),
+					TargetSkewSeconds:       metric.TargetSkew.Seconds(),
+					SourceTargetSkewSeconds: metric.SourceTargetSkew.Seconds(),
+					Error:                   metric.Error,
 				}
-				me.storage.StoreReplicaLag(storageMetric)
+				me.storage.StoreClockSkew(storageMetric)
 				// Convert to alert type
-				alertMetric := &alert.ReplicaLagMetric{
-					LagSeconds: metric.LagSeconds,
-					Status:     metric.Status,
-					Error:      metric.Error,
+				alertMetric := &alert.ClockSkewMetric{
+					SourceSkew:       metric.SourceSkew,
+					TargetSkew:       metric.TargetSkew,
+					SourceTargetSkew: metric.SourceTargetSkew,
+					Error:            metric.Error,
 				}
-				me.alertMgr.EvaluateReplicaLag(pm.pairName, alertMetric)
+				me.alertMgr.EvaluateClockSkew(pm.pairName, alertMetric)
 			}
-		} else {
-			log.Printf("[%s] Skipping replica lag check: target database not connected", pm.pairName)
+		} else if !quiet {
+			log.Printf("[%s] Skipping clock skew check: databases not connected", pm.pairName)
 		}
 	}()
 
+	// Run semi-sync monitoring until the target stops replicating
+	pm.mu.RLock()
+	cutOver := pm.state == StateCutOver
+	pm.mu.RUnlock()
+	if pm.semiSyncMonitor != nil && sourceOK && targetOK && !cutOver && !paused["semi_sync"] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			me.checkSemiSync(ctx, pm)
+		}()
+	}
+
+	// Inspect the target's replication filters and skip settings until it
+	// stops replicating
+	if pm.filterMonitor != nil && targetOK && !cutOver && !paused["replication_filters"] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			me.checkReplicationFilters(ctx, pm)
+		}()
+	}
+
+	// Run target warm-up verification, less often than the other checks
+	if pm.warmupChecker != nil && targetOK && !paused["warmup"] && pm.warmupChecker.Due() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, endCheck := me.startCheck(ctx, pm.pairName, "warmup")
+			result, err := pm.warmupChecker.Check(ctx)
+			endCheck(err)
+			if err != nil {
+				log.Printf("[%s] Warm-up check error: %v", pm.pairName, err)
+			}
+			if result != nil {
+				me.storage.StoreWarmupResult(ToStorageWarmupResult(pm.pairName, result, pm.warmupChecker.config.MinBufferPoolHitRate))
+				me.alertMgr.EvaluateWarmup(pm.pairName, &alert.WarmupResult{
+					Ready:    result.Ready,
+					Problems: result.Problems,
+					Error:    result.Error,
+				})
+				if result.Error == nil {
+					me.observeWarmup(pm, result.Ready)
+				}
+			}
+		}()
+	}
+
+	// Read pt-table-checksum's results, less often than the other checks
+	if pm.ptChecksumReader != nil && !paused["pt_checksum"] && pm.ptChecksumReader.Due() {
+		reachable := targetOK
+		if pm.ptChecksumReader.config.ReadFrom == "source" {
+			reachable = sourceOK
+		}
+		if reachable {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				me.checkPTChecksum(ctx, pm)
+			}()
+		}
+	}
+
+	// Track tablespace encryption, less often than the other checks; the
+	// source is left out while it is down, e.g. after decommissioning
+	if pm.encryptionMonitor != nil && targetOK && len(tables) > 0 && !paused["encryption_status"] && pm.encryptionMonitor.Due() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			me.checkEncryptionStatus(ctx, pm, tables, sourceOK)
+		}()
+	}
+
+	// Check the encryption and row format the table definitions declare,
+	// less often than the other checks
+	if pm.definitionChecker != nil && targetOK && len(tables) > 0 && !paused["table_definitions"] && pm.definitionChecker.Due() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			me.checkTableDefinitions(ctx, pm, tables, sourceOK)
+		}()
+	}
+
+	// Checksums scheduled on quiet replication go by the lag of earlier cycles
+	checksumsDue := true
+	if paused["checksum"] {
+		checksumsDue = false
+		log.Printf("[%s] Skipping checksum validation: paused", pm.pairName)
+	} else if pm.checksumScheduler != nil && !me.oneShot {
+		var waiting string
+		if checksumsDue, waiting = pm.checksumScheduler.due(me.clock.Now()); !checksumsDue {
+			log.Printf("[%s] Skipping checksum validation: %s", pm.pairName, waiting)
+		}
+	}
+
 	// Run checksum validation
-	if len(pm.tables) > 0 {
+	if len(tables) > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			if !checksumsDue {
+				return
+			}
 			if sourceOK && targetOK {
-				results, err := pm.checksumValidator.ValidateAllTables(pm.tables)
+				if pm.checksumScheduler != nil {
+					pm.checksumScheduler.ran(me.clock.Now())
+				}
+				ctx, endCheck := me.startCheck(ctx, pm.pairName, "checksum")
+				var results []*ChecksumResult
+				var err error
+				if sched := pm.checksumScheduler; sched != nil && sched.config.Staggered() && !me.oneShot {
+					// Staggered runs spread the tables over cycles to smooth the load
+					batch := sched.batch(tables)
+					results, err = pm.checksumValidator.ValidateTables(ctx, batch, sched.config.Budget)
+					sched.advance(len(results), len(tables))
+					if len(results) < len(tables) {
+						log.Printf("[%s] Checksummed %d of %d table(s) this cycle; the next cycle continues round-robin", pm.pairName, len(results), len(tables))
+					}
+				} else {
+					results, err = pm.checksumValidator.ValidateAllTables(ctx, tables)
+				}
+				endCheck(err)
 				if err != nil {
 					log.Printf("[%s] Checksum validation error: %v", pm.pairName, err)
 				}
 				for _, result := range results {
+					me.injectChecksumFault(pm.pairName, result)
+					me.emitChecksum(pm.pairName, result)
+					me.recordChecksum(pm.pairName, result)
+					if pm.divergence != nil && result.Error == nil && !result.Skipped {
+						pm.divergence.observe(result.TableName, !result.Match)
+					}
 					// Convert to storage type
 					storageResult := &storage.ChecksumResult{
 						DatabasePair:   pm.pairName,
 						TableName:      result.TableName,
+						TargetTable:    result.TargetTable,
 						SourceChecksum: result.SourceChecksum,
 						TargetChecksum: result.TargetChecksum,
 						Match:          result.Match,
+						Skipped:        result.Skipped,
+						EstimatedRows:  result.EstimatedRows,
+						EstimatedBytes: result.EstimatedBytes,
+						Excluded:       result.Excluded,
+						View:           result.View,
+						Rehearsal:      result.Rehearsal,
 						Timestamp:      result.Timestamp,
 						Error:          result.Error,
 					}
@@ -218,11 +828,13 @@ func (me *MonitoringEngine) monitorDatabasePair(pm *DatabasePairMonitor) {
 						SourceChecksum: result.SourceChecksum,
 						TargetChecksum: result.TargetChecksum,
 						Match:          result.Match,
+						Skipped:        result.Skipped,
+						Rehearsal:      result.Rehearsal,
 						Error:          result.Error,
 					}
 					me.alertMgr.EvaluateChecksum(pm.pairName, alertResult)
 				}
-			} else {
+			} else if !quiet {
 				log.Printf("[%s] Skipping checksum validation: databases not connected", pm.pairName)
 			}
 		}()
@@ -231,21 +843,54 @@ func (me *MonitoringEngine) monitorDatabasePair(pm *DatabasePairMonitor) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			if paused["consistency"] {
+				log.Printf("[%s] Skipping consistency check: paused", pm.pairName)
+				return
+			}
 			if sourceOK && targetOK {
-				results, err := pm.consistencyChecker.CheckAllTables(pm.tables)
+				ctx, endCheck := me.startCheck(ctx, pm.pairName, "consistency")
+				results, err := pm.consistencyChecker.CheckAllTables(ctx, tables)
+				endCheck(err)
 				if err != nil {
 					log.Printf("[%s] Consistency check error: %v", pm.pairName, err)
 				}
 				for _, result := range results {
+					me.applyBackfill(pm.pairName, result)
+					me.injectConsistencyFault(pm.pairName, result)
+					me.recordConsistency(pm.pairName, result)
+					if pm.divergence != nil && result.Error == nil {
+						pm.divergence.observe(result.TableName, !result.Consistent)
+					}
 					// Convert to storage type
 					storageResult := &storage.ConsistencyResult{
 						DatabasePair:   pm.pairName,
 						TableName:      result.TableName,
+						TargetTable:    result.TargetTable,
 						SourceRowCount: result.SourceRowCount,
 						TargetRowCount: result.TargetRowCount,
 						Consistent:     result.Consistent,
+						Approximate:    result.Approximate,
+						Tolerance:      result.Tolerance,
+						Backfill:       result.Backfill,
+						Rehearsal:      result.Rehearsal,
 						Timestamp:      result.Timestamp,
 						Error:          result.Error,
+						WindowOnly:     result.WindowOnly,
+					}
+					if w := result.Window; w != nil {
+						storageResult.Window = &storage.ConsistencyWindow{
+							Column:      w.Column,
+							From:        w.From,
+							To:          w.To,
+							SourceCount: w.SourceCount,
+							TargetCount: w.TargetCount,
+							PrimaryKey:  w.PrimaryKey,
+							SourceMinPK: w.Show(w.SourceMinPK),
+							TargetMinPK: w.Show(w.TargetMinPK),
+							SourceMaxPK: w.Show(w.SourceMaxPK),
+							TargetMaxPK: w.Show(w.TargetMaxPK),
+							Problems:    w.Problems(),
+						}
 					}
 					me.storage.StoreConsistencyResult(storageResult)
 					// Convert to alert type
@@ -254,15 +899,449 @@ func (me *MonitoringEngine) monitorDatabasePair(pm *DatabasePairMonitor) {
 						SourceRowCount: result.SourceRowCount,
 						TargetRowCount: result.TargetRowCount,
 						Consistent:     result.Consistent,
+						Approximate:    result.Approximate,
+						Backfill:       result.Backfill,
+						Rehearsal:      result.Rehearsal,
 						Error:          result.Error,
+						WindowOnly:     result.WindowOnly,
+					}
+					if result.Window != nil {
+						alertResult.Window = strings.Join(result.Window.Problems(), "; ")
+						alertResult.WindowDrift = result.Window.Drift()
 					}
 					me.alertMgr.EvaluateConsistency(pm.pairName, alertResult)
 				}
-			} else {
+			} else if !quiet {
 				log.Printf("[%s] Skipping consistency check: databases not connected", pm.pairName)
 			}
 		}()
 	}
 
 	wg.Wait()
+
+	if pm.divergence != nil {
+		me.recordDivergence(pm)
+	}
+
+	if sourceOK && targetOK {
+		me.lastSuccessfulCycle.Store(me.clock.Now().UnixNano())
+	}
+
+	if score := me.computeHealthScore(pm.pairName); score != nil {
+		me.storage.StoreHealthScore(score)
+		me.statsd.Gauge("health_score", score.Score, statsd.Tag{Key: "pair", Value: pm.pairName})
+	}
+	me.storage.StoreInsights(pm.pairName, me.computeInsights(pm))
+}
+
+// checkReplicaLag measures the replica lag of a pair's target, records it
+// with the source's write rate, if measured, and evaluates the lag alert
+func (me *MonitoringEngine) checkReplicaLag(ctx context.Context, pm *DatabasePairMonitor, writes *BinlogRate) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "replica_lag")
+	metric, err := pm.replicaLagMonitor.MeasureLag(ctx)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] Replica lag monitoring error: %v", pm.pairName, err)
+	}
+	if metric == nil {
+		return
+	}
+
+	// Cut over is detected on the target; a chain reports the combined lag
+	me.observeReplication(pm, metric.Status)
+	if len(pm.hops) > 0 {
+		metric = me.measureChain(ctx, pm, metric)
+	}
+	me.injectLagFault(pm.pairName, metric)
+	me.emitReplicaLag(pm.pairName, metric)
+	me.recordReplicaLag(pm.pairName, metric)
+	if pm.checksumScheduler != nil {
+		pm.checksumScheduler.observeLag(metric.Status, metric.LagSeconds, metric.Timestamp)
+	}
+	// Convert to storage type
+	storageMetric := &storage.ReplicaLagMetric{
+		DatabasePair: pm.pairName,
+		Timestamp:    metric.Timestamp,
+		LagSeconds:   metric.LagSeconds,
+		Status:       metric.Status,
+		Error:        metric.Error,
+
+		IORunning:        metric.IORunning,
+		SQLRunning:       metric.SQLRunning,
+		HeartbeatPeriod:  metric.HeartbeatPeriod,
+		ConnectRetry:     metric.ConnectRetry,
+		MasterRetryCount: metric.MasterRetryCount,
+
+		LastIOErrno:  metric.LastIOErrno,
+		LastIOError:  metric.LastIOError,
+		LastSQLErrno: metric.LastSQLErrno,
+		LastSQLError: metric.LastSQLError,
+
+		Rehearsal: metric.Rehearsal,
+	}
+	if tiers := me.config.PairThresholds(pm.pairName).ReplicaLag; tiers.WarningAt > 0 {
+		storageMetric.ThresholdSeconds = tiers.WarningAt.Seconds()
+	} else {
+		storageMetric.ThresholdSeconds = tiers.CriticalAt.Seconds()
+	}
+	if b := metric.Backlog; b != nil {
+		storageMetric.Backlog = &storage.BinlogBacklog{
+			ReadFile:   b.ReadPosition.File,
+			ReadPos:    b.ReadPosition.Pos,
+			ExecFile:   b.ExecPosition.File,
+			ExecPos:    b.ExecPosition.Pos,
+			IOBytes:    b.IOBytes,
+			SQLBytes:   b.SQLBytes,
+			Bottleneck: b.Bottleneck(),
+		}
+		if b.SourcePosition != nil {
+			storageMetric.Backlog.SourceFile = b.SourcePosition.File
+			storageMetric.Backlog.SourcePos = b.SourcePosition.Pos
+		}
+	}
+	if writes != nil {
+		storageMetric.SourceWrites = &storage.BinlogRate{
+			File:              writes.Position.File,
+			Position:          writes.Position.Pos,
+			BytesPerSecond:    writes.BytesPerSecond,
+			EventsPerSecond:   writes.EventsPerSecond,
+			AvgBytesPerSecond: writes.AvgBytesPerSecond,
+			Burst:             writes.Burst(),
+		}
+	}
+	for _, hop := range metric.Hops {
+		storageHop := storage.HopLag{Name: hop.Name, LagSeconds: hop.LagSeconds, Status: hop.Status}
+		if hop.Error != nil {
+			storageHop.Error = hop.Error.Error()
+		}
+		storageMetric.Hops = append(storageMetric.Hops, storageHop)
+	}
+	me.storage.StoreReplicaLag(storageMetric)
+	// Convert to alert type
+	alertMetric := &alert.ReplicaLagMetric{
+		LagSeconds: metric.LagSeconds,
+		Status:     metric.Status,
+		Error:      metric.Error,
+
+		ConnectRetry:     metric.ConnectRetry,
+		MasterRetryCount: metric.MasterRetryCount,
+		SlowestHop:       slowestHop(metric.Hops),
+		Rehearsal:        metric.Rehearsal,
+	}
+	if metric.Backlog != nil && metric.Backlog.Bottleneck() != "" {
+		alertMetric.Backlog = metric.Backlog.String()
+	}
+	if writes != nil {
+		alertMetric.Writes = writes.String()
+	}
+	me.alertMgr.EvaluateReplicaLag(pm.pairName, alertMetric)
+
+	// Rehearsal faults stay out of the lag baseline
+	if pm.lagAnomaly != nil && metric.Status == "ok" && metric.Rehearsal == "" {
+		anomaly := pm.lagAnomaly.observe(metric.LagSeconds)
+		if anomaly.Ready {
+			me.alertMgr.EvaluateLagAnomaly(pm.pairName, &alert.LagAnomaly{
+				Severity:   anomaly.Severity,
+				LagSeconds: anomaly.LagSeconds,
+				Baseline:   anomaly.Baseline,
+				StdDev:     anomaly.StdDev,
+				ZScore:     anomaly.ZScore,
+			})
+		}
+	}
+}
+
+// checkBinlogRate measures the write rate of a pair's source, or returns nil
+// when it cannot be measured, e.g. because binary logging is disabled
+func (me *MonitoringEngine) checkBinlogRate(ctx context.Context, pm *DatabasePairMonitor) *BinlogRate {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "binlog_rate")
+	rate, err := pm.binlogRateMonitor.Measure(ctx)
+	endCheck(err)
+	if pm.binlogRateMonitor.logError(err) {
+		log.Printf("[%s] Binlog write rate error: %v", pm.pairName, err)
+	}
+	if err != nil {
+		return nil
+	}
+	me.emitBinlogRate(pm.pairName, rate)
+	return rate
+}
+
+// LastSuccessfulCycle returns when a monitoring cycle last reached both
+// databases of a pair, or the zero time if none has yet
+func (me *MonitoringEngine) LastSuccessfulCycle() time.Time {
+	nanos := me.lastSuccessfulCycle.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// DiffTable runs an on-demand row-level diff of a table in a database pair and records the result
+func (me *MonitoringEngine) DiffTable(ctx context.Context, pairName, tableName string, opts DiffOptions) (*DiffResult, error) {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return nil, fmt.Errorf("database pair '%s' not found", pairName)
+	}
+	// Only monitored tables are diffed, so that the API cannot read others
+	if !slices.Contains(pm.Tables(), tableName) {
+		return nil, fmt.Errorf("table '%s' is not monitored for database pair '%s'", tableName, pairName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, me.config.Timeouts.Diff)
+	defer cancel()
+
+	log.Printf("[%s] Running row diff for table %s", pairName, tableName)
+	result, err := pm.diffEngine.DiffTable(ctx, tableName, opts)
+	if result.Warning != "" {
+		log.Printf("[%s] Row diff of table %s: %s", pairName, tableName, result.Warning)
+	}
+	me.storage.StoreDiffResult(ToStorageDiffResult(pairName, result))
+
+	return result, err
+}
+
+// findPairMonitor returns the monitor for a database pair by name
+func (me *MonitoringEngine) findPairMonitor(pairName string) *DatabasePairMonitor {
+	for _, pm := range me.pairs() {
+		if pm.pairName == pairName {
+			return pm
+		}
+	}
+	return nil
+}
+
+// checkSemiSync measures semi-sync replication of a pair and stores and
+// evaluates the result
+func (me *MonitoringEngine) checkSemiSync(ctx context.Context, pm *DatabasePairMonitor) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "semi_sync")
+	metric, err := pm.semiSyncMonitor.Measure(ctx)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] Semi-sync monitoring error: %v", pm.pairName, err)
+	}
+	if metric == nil {
+		return
+	}
+
+	me.emitSemiSync(pm.pairName, metric)
+	storageMetric := &storage.SemiSyncMetric{
+		DatabasePair:     pm.pairName,
+		Timestamp:        metric.Timestamp,
+		SourceEnabled:    metric.SourceEnabled,
+		SourceActive:     metric.SourceActive,
+		TargetEnabled:    metric.TargetEnabled,
+		TargetActive:     metric.TargetActive,
+		TimeoutSeconds:   metric.Timeout.Seconds(),
+		Clients:          metric.Clients,
+		AckedTx:          metric.AckedTx,
+		AsyncTx:          metric.AsyncTx,
+		Fallbacks:        metric.Fallbacks,
+		TxWaits:          metric.TxWaits,
+		AvgTxWaitSeconds: metric.AvgTxWait.Seconds(),
+		WaitSessions:     metric.WaitSessions,
+		NewAsyncTx:       metric.NewAsyncTx,
+		NewFallbacks:     metric.NewFallbacks,
+		Async:            metric.Async,
+		Problems:         metric.Problems,
+	}
+	if metric.Error != nil {
+		storageMetric.Error = metric.Error.Error()
+	}
+	me.storage.StoreSemiSync(storageMetric)
+	me.alertMgr.EvaluateSemiSync(pm.pairName, &alert.SemiSyncMetric{
+		Async:    metric.Async,
+		Problems: metric.Problems,
+		Error:    metric.Error,
+	})
+}
+
+// checkReplicationFilters inspects the replication filters and skip settings
+// of a pair's target and stores and evaluates the result
+func (me *MonitoringEngine) checkReplicationFilters(ctx context.Context, pm *DatabasePairMonitor) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "replication_filters")
+	result, err := pm.filterMonitor.Inspect(ctx)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] Replication filter check error: %v", pm.pairName, err)
+	}
+	if result == nil {
+		return
+	}
+
+	storageResult := &storage.ReplicationFilters{
+		DatabasePair: pm.pairName,
+		Timestamp:    result.Timestamp,
+		Filters:      result.Filters,
+		SkipCounter:  result.SkipCounter,
+		SkipErrors:   result.SkipErrors,
+		Problems:     result.Problems,
+	}
+	if result.Error != nil {
+		storageResult.Error = result.Error.Error()
+	}
+	me.storage.StoreReplicationFilters(storageResult)
+	me.alertMgr.EvaluateReplicationFilters(pm.pairName, &alert.ReplicationFilters{
+		Skipping: result.Skipping(),
+		Problems: result.Problems,
+		Error:    result.Error,
+	})
+}
+
+// checkEncryptionStatus reads the tablespace encryption of a pair and
+// stores its progress
+func (me *MonitoringEngine) checkEncryptionStatus(ctx context.Context, pm *DatabasePairMonitor, tables []string, withSource bool) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "encryption_status")
+	status, err := pm.encryptionMonitor.Check(ctx, tables, withSource)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] Encryption status check error: %v", pm.pairName, err)
+	}
+	if status == nil {
+		return
+	}
+
+	storageStatus := &storage.EncryptionStatus{
+		DatabasePair:               pm.pairName,
+		Timestamp:                  status.Timestamp,
+		Tables:                     make([]storage.TableEncryption, 0, len(status.Tables)),
+		TablesEncrypted:            status.TablesEncrypted,
+		SourceTablesEncrypted:      status.SourceTablesEncrypted,
+		Percent:                    status.Percent(),
+		TargetTablespaces:          status.TargetTablespaces,
+		TargetTablespacesEncrypted: status.TargetTablespacesEncrypted,
+		SourceTablespaces:          status.SourceTablespaces,
+		SourceTablespacesEncrypted: status.SourceTablespacesEncrypted,
+		SourceChecked:              status.SourceChecked,
+	}
+	for _, table := range status.Tables {
+		entry := storage.TableEncryption{
+			Table:           table.Table,
+			TargetTable:     table.TargetTable,
+			SourceEncrypted: table.SourceEncrypted,
+			TargetEncrypted: table.TargetEncrypted,
+		}
+		if !table.EncryptedAt.IsZero() {
+			encryptedAt := table.EncryptedAt
+			entry.EncryptedAt = &encryptedAt
+		}
+		storageStatus.Tables = append(storageStatus.Tables, entry)
+	}
+	if status.Error != nil {
+		storageStatus.Error = status.Error.Error()
+	}
+	me.storage.StoreEncryptionStatus(storageStatus)
+}
+
+// checkTableDefinitions reads the definitions of a pair's monitored tables
+// and alerts while they do not match the migration
+func (me *MonitoringEngine) checkTableDefinitions(ctx context.Context, pm *DatabasePairMonitor, tables []string, withSource bool) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "table_definitions")
+	result, err := pm.definitionChecker.Check(ctx, tables, withSource)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] Table definition check error: %v", pm.pairName, err)
+	}
+	if result == nil {
+		return
+	}
+
+	storageResult := &storage.TableDefinitions{
+		DatabasePair:  pm.pairName,
+		Timestamp:     result.Timestamp,
+		Tables:        make([]storage.TableDefinition, 0, len(result.Tables)),
+		SourceChecked: result.SourceChecked,
+	}
+	alertResult := &alert.TableDefinitions{Unencrypted: result.Unencrypted(), Error: result.Error}
+	for _, table := range result.Tables {
+		entry := storage.TableDefinition{
+			Table:           table.Table,
+			TargetTable:     table.TargetTable,
+			TargetEncrypted: table.TargetEncrypted,
+			TargetKeyID:     table.TargetKeyID,
+			TargetRowFormat: table.TargetRowFormat,
+			SourceEncrypted: table.SourceEncrypted,
+			SourceRowFormat: table.SourceRowFormat,
+			Problems:        table.Problems,
+		}
+		if table.Error != nil {
+			entry.Error = table.Error.Error()
+			log.Printf("[%s] Table definition check error: %s: %v", pm.pairName, table.Table, table.Error)
+		}
+		storageResult.Tables = append(storageResult.Tables, entry)
+		for _, problem := range table.Problems {
+			alertResult.Problems = append(alertResult.Problems, table.Table+": "+problem)
+		}
+	}
+	if result.Error != nil {
+		storageResult.Error = result.Error.Error()
+	}
+	me.storage.StoreTableDefinitions(storageResult)
+	me.alertMgr.EvaluateTableDefinitions(pm.pairName, alertResult)
+}
+
+// ToStorageWarmupResult converts a warm-up check result to its storage representation
+func ToStorageWarmupResult(pairName string, result *WarmupResult, minHitRate float64) *storage.WarmupResult {
+	storageResult := &storage.WarmupResult{
+		DatabasePair:          pairName,
+		Timestamp:             result.Timestamp,
+		BufferPoolHitRate:     result.BufferPoolHitRate,
+		HitRateSinceStartup:   result.HitRateSinceStartup,
+		BufferPoolFillPercent: result.BufferPoolFillPercent,
+		MinBufferPoolHitRate:  minHitRate,
+		Queries:               make([]storage.WarmupQueryResult, 0, len(result.Queries)),
+		Ready:                 result.Ready,
+		Problems:              result.Problems,
+	}
+	if result.Error != nil {
+		storageResult.Error = result.Error.Error()
+	}
+	for _, query := range result.Queries {
+		storageResult.Queries = append(storageResult.Queries, storage.WarmupQueryResult{
+			Name:           query.Name,
+			Keys:           query.Keys,
+			FullScanTables: query.FullScanTables,
+			Ready:          query.Ready,
+			Problem:        query.Problem,
+		})
+	}
+	return storageResult
+}
+
+// ToStorageDiffResult converts a diff result to its storage representation
+func ToStorageDiffResult(pairName string, result *DiffResult) *storage.DiffResult {
+	storageResult := &storage.DiffResult{
+		DatabasePair:     pairName,
+		TableName:        result.TableName,
+		TargetTable:      result.TargetTable,
+		PrimaryKey:       result.PrimaryKey,
+		ChunksScanned:    result.ChunksScanned,
+		ChunksMismatched: result.ChunksMismatched,
+		RowsCompared:     result.RowsCompared,
+		Differences:      make([]storage.RowDifference, 0, len(result.Differences)),
+		Truncated:        result.Truncated,
+		Warning:          result.Warning,
+		Timestamp:        result.Timestamp,
+		DurationSeconds:  result.Duration.Seconds(),
+	}
+	if result.Error != nil {
+		storageResult.Error = result.Error.Error()
+	}
+	for _, diff := range result.Differences {
+		row := storage.RowDifference{
+			PrimaryKey: diff.PrimaryKey,
+			Kind:       diff.Kind,
+		}
+		for _, col := range diff.Columns {
+			row.Columns = append(row.Columns, storage.ColumnDifference{
+				Column:      col.Column,
+				SourceValue: col.SourceValue,
+				TargetValue: col.TargetValue,
+				SourceNull:  col.SourceNull,
+				TargetNull:  col.TargetNull,
+			})
+		}
+		storageResult.Differences = append(storageResult.Differences, row)
+	}
+	return storageResult
 }
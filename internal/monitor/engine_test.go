@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextCycle(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	tests := []struct {
+		name        string
+		policy      string
+		end         time.Duration // after start
+		interval    time.Duration
+		wantNext    time.Duration // after start
+		wantSkipped int
+	}{
+		{"within the interval", "skip", 4 * time.Second, 10 * time.Second, 10 * time.Second, 0},
+		{"exactly the interval", "queue", 10 * time.Second, 10 * time.Second, 10 * time.Second, 0},
+		{"skip one tick", "skip", 12 * time.Second, 10 * time.Second, 20 * time.Second, 1},
+		{"skip two ticks", "skip", 25 * time.Second, 10 * time.Second, 30 * time.Second, 2},
+		{"queue runs right away", "queue", 12 * time.Second, 10 * time.Second, 12 * time.Second, 0},
+		{"queue skips the ticks before", "queue", 25 * time.Second, 10 * time.Second, 25 * time.Second, 1},
+		{"cancel runs right away", "cancel", 10*time.Second + time.Millisecond, 10 * time.Second, 10*time.Second + time.Millisecond, 0},
+		{"no interval", "skip", 5 * time.Second, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, skipped := nextCycle(tt.policy, start, at(tt.end), tt.interval)
+			if !next.Equal(at(tt.wantNext)) || skipped != tt.wantSkipped {
+				t.Errorf("nextCycle = %v after start, %d skipped; want %v, %d", next.Sub(start), skipped, tt.wantNext, tt.wantSkipped)
+			}
+		})
+	}
+}
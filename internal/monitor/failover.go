@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"mariadb-encryption-monitor/internal/alert"
+)
+
+// checkFailovers re-baselines the lag measurement of a pair after a failover
+// behind the cluster endpoint of its source or target, which the connection
+// manager detected and reconnected from, and notes it with an INFO alert
+func (me *MonitoringEngine) checkFailovers(pm *DatabasePairMonitor) {
+	failovers := pm.connMgr.TakeFailovers()
+	alerts := make([]alert.Failover, 0, len(failovers))
+	for _, failover := range failovers {
+		pm.rebaseline(failover.Database)
+		alerts = append(alerts, alert.Failover{Database: failover.Database, Description: failover.String()})
+	}
+	me.alertMgr.EvaluateFailovers(pm.pairName, alerts)
+}
+
+// rebaseline drops what was learned from the instances before a failover:
+// the lag baseline of anomaly detection, the quiet period checksums wait
+// for and, after a failover of the source, its binary log write rate
+func (pm *DatabasePairMonitor) rebaseline(database string) {
+	if pm.lagAnomaly != nil {
+		pm.lagAnomaly.reset()
+	}
+	if pm.checksumScheduler != nil {
+		pm.checksumScheduler.reset()
+	}
+	if database == "source" {
+		pm.binlogRateMonitor.reset()
+	}
+}
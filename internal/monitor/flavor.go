@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// serverFlavor is the product and version of a database server. MySQL renamed
+// its replication statements and columns from master/slave to source/replica;
+// MariaDB keeps the original names. The zero value is an unknown server,
+// which gets the original names.
+type serverFlavor struct {
+	mysql               bool
+	major, minor, patch int
+}
+
+// parseFlavor reads the flavor from a VERSION() string, e.g.
+// "10.11.6-MariaDB-log" or "8.0.35"
+func parseFlavor(version string) serverFlavor {
+	flavor := serverFlavor{mysql: !strings.Contains(strings.ToLower(version), "mariadb")}
+	number, _, _ := strings.Cut(version, "-")
+	parts := strings.SplitN(number, ".", 3)
+	fields := []*int{&flavor.major, &flavor.minor, &flavor.patch}
+	for i, part := range parts {
+		*fields[i], _ = strconv.Atoi(part)
+	}
+	return flavor
+}
+
+// mysqlAtLeast reports whether the server is MySQL of at least a version
+func (f serverFlavor) mysqlAtLeast(major, minor, patch int) bool {
+	if !f.mysql {
+		return false
+	}
+	if f.major != major {
+		return f.major > major
+	}
+	if f.minor != minor {
+		return f.minor > minor
+	}
+	return f.patch >= patch
+}
+
+// replicaStatusQuery returns the statement showing the replica threads;
+// MySQL 8.0.22 renamed it and 8.4 removed the old name
+func (f serverFlavor) replicaStatusQuery() string {
+	if f.mysqlAtLeast(8, 0, 22) {
+		return "SHOW REPLICA STATUS"
+	}
+	return "SHOW SLAVE STATUS"
+}
+
+// sourceStatusQuery returns the statement showing the binary log position;
+// MySQL 8.2 renamed it and 8.4 removed the old name
+func (f serverFlavor) sourceStatusQuery() string {
+	if f.mysqlAtLeast(8, 2, 0) {
+		return "SHOW BINARY LOG STATUS"
+	}
+	return "SHOW MASTER STATUS"
+}
+
+// translate rewrites a replication statement in the original terminology to
+// the one the server understands
+func (f serverFlavor) translate(query string) string {
+	switch query {
+	case "SHOW SLAVE STATUS":
+		return f.replicaStatusQuery()
+	case "SHOW MASTER STATUS":
+		return f.sourceStatusQuery()
+	}
+	return query
+}
+
+// replicaColumnAliases maps the SHOW REPLICA STATUS columns of MySQL 8.0.22+
+// to their SHOW SLAVE STATUS names
+var replicaColumnAliases = map[string]string{
+	"Replica_IO_Running":    "Slave_IO_Running",
+	"Replica_SQL_Running":   "Slave_SQL_Running",
+	"Seconds_Behind_Source": "Seconds_Behind_Master",
+	"Source_Log_File":       "Master_Log_File",
+	"Read_Source_Log_Pos":   "Read_Master_Log_Pos",
+	"Relay_Source_Log_File": "Relay_Master_Log_File",
+	"Exec_Source_Log_Pos":   "Exec_Master_Log_Pos",
+	"Source_Retry_Count":    "Master_Retry_Count",
+}
+
+// replicaStatusColumns indexes the columns of a replica status row by their
+// SHOW SLAVE STATUS names, whichever terminology the server used
+func replicaStatusColumns(columns []string) map[string]int {
+	columnMap := make(map[string]int, len(columns))
+	for i, col := range columns {
+		if alias, ok := replicaColumnAliases[col]; ok {
+			col = alias
+		}
+		columnMap[col] = i
+	}
+	return columnMap
+}
+
+// flavorCache remembers the flavor of a connection pool, detected on first use
+type flavorCache struct {
+	mu     sync.Mutex
+	db     *sql.DB
+	flavor serverFlavor
+}
+
+// get returns the flavor of a connection pool, detecting it when the pool
+// changed since the last call. A failed detection returns the zero flavor and
+// is retried on the next call.
+func (c *flavorCache) get(ctx context.Context, db *sql.DB) serverFlavor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == db {
+		return c.flavor
+	}
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return serverFlavor{}
+	}
+	c.db, c.flavor = db, parseFlavor(version)
+	return c.flavor
+}
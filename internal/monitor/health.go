@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"time"
+
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// Health score inputs
+const (
+	ScoreReplicaLag  = "replica_lag"
+	ScoreChecksum    = "checksum"
+	ScoreConsistency = "consistency"
+	ScoreConnection  = "connection"
+)
+
+// computeHealthScore combines the pair's recent results into a single 0-100
+// score. Each input is scored 0-100 and weighted; inputs without data in the
+// window are left out and the remaining weights rescaled. It returns nil when
+// no input has data.
+func (me *MonitoringEngine) computeHealthScore(pairName string) *storage.HealthScore {
+	window := me.config.HealthScore.Window
+	weights := me.config.HealthScore.Weights
+
+	components := make(map[string]float64)
+	if score, ok := me.lagScore(pairName, window); ok {
+		components[ScoreReplicaLag] = score
+	}
+
+	passed, total := 0, 0
+	for _, r := range me.storage.GetChecksumHistory(window) {
+		if r.DatabasePair == pairName && !r.Skipped {
+			total++
+			if r.Match && r.Error == nil {
+				passed++
+			}
+		}
+	}
+	if total > 0 {
+		components[ScoreChecksum] = float64(passed) / float64(total) * 100
+	}
+
+	passed, total = 0, 0
+	for _, r := range me.storage.GetConsistencyHistory(window) {
+		if r.DatabasePair == pairName {
+			total++
+			if r.Consistent && r.Error == nil {
+				passed++
+			}
+		}
+	}
+	if total > 0 {
+		components[ScoreConsistency] = float64(passed) / float64(total) * 100
+	}
+
+	passed, total = 0, 0
+	for _, c := range me.storage.GetConnectionHistory(window) {
+		if c.DatabasePair == pairName {
+			total++
+			if c.SourceConnected && c.TargetConnected {
+				passed++
+			}
+		}
+	}
+	if total > 0 {
+		components[ScoreConnection] = float64(passed) / float64(total) * 100
+	}
+
+	weightOf := map[string]float64{
+		ScoreReplicaLag:  weights.ReplicaLag,
+		ScoreChecksum:    weights.Checksum,
+		ScoreConsistency: weights.Consistency,
+		ScoreConnection:  weights.Connection,
+	}
+	var weighted, totalWeight float64
+	for name, score := range components {
+		weighted += score * weightOf[name]
+		totalWeight += weightOf[name]
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	return &storage.HealthScore{
+		DatabasePair: pairName,
+		Timestamp:    me.clock.Now(),
+		Score:        weighted / totalWeight,
+		Components:   components,
+	}
+}
+
+// lagScore scores the latest lag sample: 100 without lag, falling linearly to
+// 0 at the CRITICAL tier (or twice the WARNING tier). Broken replication
+// scores 0; samples without a meaningful lag are left out.
+func (me *MonitoringEngine) lagScore(pairName string, window time.Duration) (float64, bool) {
+	history := me.storage.GetReplicaLagHistory(window)
+	for i := len(history) - 1; i >= 0; i-- {
+		metric := history[i]
+		if metric.DatabasePair != pairName {
+			continue
+		}
+
+		switch metric.Status {
+		case "ok":
+		case "replication_stopped", "connection_retrying", "connection_error", "query_error", "error":
+			return 0, true
+		default: // no_replication, lag_unknown, status_unknown, connecting
+			return 0, false
+		}
+
+		tiers := me.config.PairThresholds(pairName).ReplicaLag
+		limit := tiers.CriticalAt
+		if limit == 0 {
+			limit = 2 * tiers.WarningAt
+		}
+		if limit == 0 {
+			return 100, true
+		}
+		score := 100 * (1 - metric.LagSeconds/limit.Seconds())
+		if score < 0 {
+			score = 0
+		}
+		return score, true
+	}
+	return 0, false
+}
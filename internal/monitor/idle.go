@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"mariadb-encryption-monitor/internal/statsd"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// fullCheckAlertTypes are raised by checks that stop once a pair's migration
+// is complete; their alerts are resolved when the pair is marked complete
+var fullCheckAlertTypes = []string{
+	"checksum_mismatch", "checksum_error",
+	"consistency_mismatch", "consistency_error",
+	"clock_skew", "target_not_warm", "semi_sync_degraded", "replication_filters_active",
+	"dual_write_divergence", "pt_checksum_diff", "pt_checksum_error", "table_definition_mismatch",
+}
+
+// CompletePair marks a pair's migration complete. Its checks are reduced to a
+// heartbeat at idle.heartbeat_interval until it is resumed.
+func (me *MonitoringEngine) CompletePair(pairName, reason string) error {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	pm.mu.RLock()
+	state := pm.state
+	pm.mu.RUnlock()
+	switch state {
+	case StateComplete:
+		return fmt.Errorf("database pair '%s' is already complete", pairName)
+	case StatePaused, StateStandby:
+		return fmt.Errorf("database pair '%s' is %s", pairName, state)
+	}
+
+	me.transition(pm, StateComplete, EventPairCompleted, reason)
+	me.alertMgr.ResolvePairAlerts(pairName, fullCheckAlertTypes...)
+	return me.ApplyPairSettings(pairName)
+}
+
+// completed reports whether a pair's migration is complete
+func (pm *DatabasePairMonitor) completed() bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.state == StateComplete || (pm.state == "" && pm.startComplete)
+}
+
+// checkInterval returns how long a pair waits between checks
+func (me *MonitoringEngine) checkInterval(pm *DatabasePairMonitor) time.Duration {
+	if pm.completed() {
+		return me.config.Idle.HeartbeatInterval
+	}
+	return me.config.PairCheckInterval(pm.pairName)
+}
+
+// heartbeatPair runs the minimal checks of a completed pair: connections,
+// read_only of both databases, and replica lag while the target replicates
+func (me *MonitoringEngine) heartbeatPair(ctx context.Context, pm *DatabasePairMonitor) {
+	ctx, endCycle := me.startCycle(ctx, pm.pairName)
+	defer endCycle()
+
+	health := me.healthCheck(ctx, pm)
+	sourceOK, targetOK := health.SourceOK, health.TargetOK
+	status := storage.ConnectionStatus{
+		SourceConnected: sourceOK,
+		TargetConnected: targetOK,
+		LastChecked:     me.clock.Now(),
+		SourceRetryAt:   health.SourceRetryAt,
+		TargetRetryAt:   health.TargetRetryAt,
+	}
+	if sourceOK {
+		status.SourceReadOnly = me.readOnly(ctx, pm, "source", pm.connMgr.GetSourceConnection)
+	}
+	if targetOK {
+		status.TargetReadOnly = me.readOnly(ctx, pm, "target", pm.connMgr.GetTargetConnection)
+	}
+	me.storage.UpdateConnectionStatus(pm.pairName, status)
+	me.emitConnection(pm.pairName, sourceOK, targetOK)
+	me.checkFailovers(pm)
+
+	if targetOK {
+		me.checkReplicaLag(ctx, pm, nil)
+	}
+
+	if sourceOK && targetOK {
+		me.lastSuccessfulCycle.Store(me.clock.Now().UnixNano())
+	}
+	if score := me.computeHealthScore(pm.pairName); score != nil {
+		me.storage.StoreHealthScore(score)
+		me.statsd.Gauge("health_score", score.Score, statsd.Tag{Key: "pair", Value: pm.pairName})
+	}
+	me.storage.StoreInsights(pm.pairName, me.computeInsights(pm))
+}
+
+// readOnly returns @@global.read_only of a database, or nil if it cannot be read
+func (me *MonitoringEngine) readOnly(ctx context.Context, pm *DatabasePairMonitor, side string, conn func() (*sql.DB, error)) *bool {
+	db, err := conn()
+	if err != nil {
+		return nil
+	}
+	var readOnly bool
+	if err := db.QueryRowContext(ctx, "SELECT @@global.read_only").Scan(&readOnly); err != nil {
+		log.Printf("[%s] Failed to read read_only of %s database: %v", pm.pairName, side, err)
+		return nil
+	}
+	return &readOnly
+}
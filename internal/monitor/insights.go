@@ -0,0 +1,240 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// Insight rules
+const (
+	InsightLagRise            = "lag_rise"
+	InsightStaleValidation    = "stale_validation"
+	InsightConnectionFlapping = "connection_flapping"
+)
+
+const (
+	// minLagRise keeps a few seconds of lag doubling from being reported
+	minLagRise = 5.0 // seconds
+
+	// minConnectionDrops is how many drops within the insights window make a
+	// connection flap
+	minConnectionDrops = 3
+
+	// maxListedTables bounds the tables named in one insight
+	maxListedTables = 5
+)
+
+// computeInsights applies every insight rule to the pair's recent results
+func (me *MonitoringEngine) computeInsights(pm *DatabasePairMonitor) []storage.Insight {
+	now := me.clock.Now()
+	var insights []storage.Insight
+	if insight, ok := me.lagRiseInsight(pm, now); ok {
+		insights = append(insights, insight)
+	}
+	if !pm.completed() {
+		if insight, ok := me.staleValidationInsight(pm, now); ok {
+			insights = append(insights, insight)
+		}
+	}
+	insights = append(insights, me.connectionInsights(pm)...)
+	return insights
+}
+
+// lagRiseInsight reports replica lag that averaged at least twice as much in
+// the insights window as in the window before it, from the first sample that
+// doubled, along with the checksums that started just before that sample
+func (me *MonitoringEngine) lagRiseInsight(pm *DatabasePairMonitor, now time.Time) (storage.Insight, bool) {
+	window := me.config.Insights.Window
+	split := now.Add(-window)
+
+	var before, recent []storage.ReplicaLagMetric
+	for _, m := range me.storage.GetReplicaLagHistory(2 * window) {
+		if m.DatabasePair != pm.pairName || m.Status != "ok" {
+			continue
+		}
+		if m.Timestamp.After(split) {
+			recent = append(recent, m)
+		} else {
+			before = append(before, m)
+		}
+	}
+	if len(before) < 3 || len(recent) < 3 {
+		return storage.Insight{}, false
+	}
+
+	baseline, current := averageLag(before), averageLag(recent)
+	if current < 2*baseline || current-baseline < minLagRise {
+		return storage.Insight{}, false
+	}
+
+	onset := recent[0].Timestamp
+	for _, m := range recent {
+		if m.LagSeconds >= 2*baseline && m.LagSeconds-baseline >= minLagRise {
+			onset = m.Timestamp
+			break
+		}
+	}
+
+	message := fmt.Sprintf("replica lag doubled after %s (average %.0fs in the previous %s, %.0fs since)",
+		onset.UTC().Format("15:04 UTC"), baseline, shortDuration(window), current)
+
+	// A checksum holds locks and reads whole tables; one started in the cycle
+	// before the rise is the likely cause, unless the table was also
+	// checksummed while lag was normal
+	from := onset.Add(-2 * me.checkInterval(pm))
+	routine := make(map[string]bool)
+	started := make(map[string]bool)
+	for _, r := range me.storage.GetChecksumHistory(2 * window) {
+		if r.DatabasePair != pm.pairName || r.Skipped {
+			continue
+		}
+		if !r.Timestamp.After(split) {
+			routine[r.TableName] = true
+		} else if !r.Timestamp.Before(from) && !r.Timestamp.After(onset) {
+			started[r.TableName] = true
+		}
+	}
+	var tables []string
+	for table := range started {
+		if !routine[table] {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) > 0 {
+		sort.Strings(tables)
+		noun := "table"
+		if len(tables) > 1 {
+			noun = "tables"
+		}
+		message += fmt.Sprintf(", coinciding with checksum of %s %s", tableList(tables), noun)
+	}
+
+	return storage.Insight{DatabasePair: pm.pairName, Rule: InsightLagRise, Message: message, Since: onset}, true
+}
+
+// staleValidationInsight reports monitored tables without a successful
+// checksum or row count within the stale validation period. Tables never
+// validated count once the engine has run for that long.
+func (me *MonitoringEngine) staleValidationInsight(pm *DatabasePairMonitor, now time.Time) (storage.Insight, bool) {
+	stale := me.config.Insights.StaleValidation
+	cutoff := now.Add(-stale)
+	metrics := me.storage.GetCurrentMetrics()
+
+	var tables []string
+	oldest := now
+	for _, table := range pm.Tables() {
+		key := pm.pairName + ":" + table
+		var validated time.Time
+		if r, ok := metrics.ChecksumResults[key]; ok && !r.Skipped && r.Error == nil {
+			validated = r.Timestamp
+		}
+		if r, ok := metrics.ConsistencyResults[key]; ok && r.Error == nil && r.Timestamp.After(validated) {
+			validated = r.Timestamp
+		}
+		if validated.IsZero() {
+			validated = me.startedAt
+		}
+		if validated.After(cutoff) {
+			continue
+		}
+		tables = append(tables, table)
+		if validated.Before(oldest) {
+			oldest = validated
+		}
+	}
+	if len(tables) == 0 {
+		return storage.Insight{}, false
+	}
+
+	sort.Strings(tables)
+	var message string
+	if len(tables) == 1 {
+		message = fmt.Sprintf("table %s hasn't been validated in %s", tableList(tables), shortDuration(stale))
+	} else {
+		message = fmt.Sprintf("%d tables haven't been validated in %s: %s", len(tables), shortDuration(stale), tableList(tables))
+	}
+	return storage.Insight{DatabasePair: pm.pairName, Rule: InsightStaleValidation, Message: message, Since: oldest}, true
+}
+
+// connectionInsights reports a database of the pair whose connection dropped
+// repeatedly within the insights window
+func (me *MonitoringEngine) connectionInsights(pm *DatabasePairMonitor) []storage.Insight {
+	window := me.config.Insights.Window
+
+	var samples []storage.ConnectionSample
+	for _, c := range me.storage.GetConnectionHistory(window) {
+		if c.DatabasePair == pm.pairName {
+			samples = append(samples, c)
+		}
+	}
+
+	var insights []storage.Insight
+	sides := []struct {
+		name      string
+		connected func(storage.ConnectionSample) bool
+	}{
+		{"source", func(c storage.ConnectionSample) bool { return c.SourceConnected }},
+		{"target", func(c storage.ConnectionSample) bool { return c.TargetConnected }},
+	}
+	for _, side := range sides {
+		drops := 0
+		var first time.Time
+		for i := 1; i < len(samples); i++ {
+			if side.connected(samples[i-1]) && !side.connected(samples[i]) {
+				if drops == 0 {
+					first = samples[i].Timestamp
+				}
+				drops++
+			}
+		}
+		if drops < minConnectionDrops {
+			continue
+		}
+		insights = append(insights, storage.Insight{
+			DatabasePair: pm.pairName,
+			Rule:         InsightConnectionFlapping,
+			Message:      fmt.Sprintf("%s connection dropped %d times in the last %s", side.name, drops, shortDuration(window)),
+			Since:        first,
+		})
+	}
+	return insights
+}
+
+// averageLag returns the mean lag of samples
+func averageLag(samples []storage.ReplicaLagMetric) float64 {
+	var total float64
+	for _, m := range samples {
+		total += m.LagSeconds
+	}
+	return total / float64(len(samples))
+}
+
+// tableList names tables for a message, e.g. "`a`, `b`" or "`a`, ..., `e`
+// and 2 more"
+func tableList(tables []string) string {
+	quoted := make([]string, 0, maxListedTables)
+	for _, table := range tables[:min(len(tables), maxListedTables)] {
+		quoted = append(quoted, "`"+table+"`")
+	}
+	list := strings.Join(quoted, ", ")
+	if len(tables) > maxListedTables {
+		list += fmt.Sprintf(" and %d more", len(tables)-maxListedTables)
+	}
+	return list
+}
+
+// shortDuration formats a duration without zero minutes and seconds, e.g. "48h"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
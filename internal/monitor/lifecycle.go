@@ -0,0 +1,232 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Pair lifecycle states
+const (
+	StateMonitoring = "monitoring"
+	StatePaused     = "paused"
+	StateWarmup     = "warmup"   // warm-up verification reports the target is not ready
+	StateReady      = "ready"    // warm-up verification passed
+	StateCutOver    = "cut_over" // the target stopped replicating from the source
+	StateStandby    = "standby"  // fan-out pair waiting for its primary pair to cut over
+	StateComplete   = "complete" // migration complete; only a heartbeat runs
+	StateRemoved    = "removed"  // removed through the API; no longer monitored
+)
+
+// Pair lifecycle event types
+const (
+	EventPairAdded   = "pair_added"
+	EventPairPaused  = "pair_paused"
+	EventPairResumed = "pair_resumed"
+	EventPairWarmup  = "pair_warmup"
+	EventPairReady   = "pair_ready"
+	EventPairCutOver = "pair_cut_over"
+	EventPairStandby = "pair_standby"
+	EventPairActive  = "pair_activated"
+
+	EventPairCompleted = "pair_completed"
+	EventPairRemoved   = "pair_removed"
+)
+
+// PairEvent describes a database pair changing lifecycle state, or one of
+// its checks being paused or resumed
+type PairEvent struct {
+	Type      string    `json:"type"`
+	Pair      string    `json:"pair"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Check     string    `json:"check,omitempty"` // the check paused or resumed, for check events
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AddPairListener registers a function called for every pair lifecycle event.
+// Listeners are called synchronously and must not block.
+func (me *MonitoringEngine) AddPairListener(listener func(PairEvent)) {
+	me.listenersMu.Lock()
+	defer me.listenersMu.Unlock()
+
+	me.pairListeners = append(me.pairListeners, listener)
+}
+
+// PairStates returns the lifecycle state of every pair
+func (me *MonitoringEngine) PairStates() map[string]string {
+	pairMonitors := me.pairs()
+	states := make(map[string]string, len(pairMonitors))
+	for _, pm := range pairMonitors {
+		pm.mu.RLock()
+		states[pm.pairName] = pm.state
+		pm.mu.RUnlock()
+	}
+	return states
+}
+
+// PausePair stops running checks for a pair until it is resumed
+func (me *MonitoringEngine) PausePair(pairName, reason string) error {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	pm.mu.Lock()
+	if pm.state == StatePaused {
+		pm.mu.Unlock()
+		return fmt.Errorf("database pair '%s' is already paused", pairName)
+	}
+	pm.pausedFrom = pm.state
+	pm.mu.Unlock()
+
+	me.transition(pm, StatePaused, EventPairPaused, reason)
+	return nil
+}
+
+// ResumePair resumes checks for a paused pair, reopens full checks of a
+// completed pair, or activates a fan-out pair in standby before its primary
+// pair cuts over
+func (me *MonitoringEngine) ResumePair(pairName, reason string) error {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	pm.mu.RLock()
+	state, resumeTo := pm.state, pm.pausedFrom
+	pm.mu.RUnlock()
+	switch state {
+	case StateStandby:
+		go me.activate(pm, reason)
+		return nil
+	case StateComplete:
+		resumeTo = StateMonitoring
+	case StatePaused:
+	default:
+		return fmt.Errorf("database pair '%s' is not paused or complete", pairName)
+	}
+
+	me.transition(pm, resumeTo, EventPairResumed, reason)
+	return me.ApplyPairSettings(pairName)
+}
+
+// active reports whether checks for a pair run, i.e. it is neither paused
+// nor in standby
+func (pm *DatabasePairMonitor) active() bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.state != StatePaused && pm.state != StateStandby
+}
+
+// activate connects a fan-out pair in standby and starts monitoring it. A
+// pair is activated only once.
+func (me *MonitoringEngine) activate(pm *DatabasePairMonitor, reason string) {
+	pm.mu.Lock()
+	if pm.activated {
+		pm.mu.Unlock()
+		return
+	}
+	pm.activated = true
+	pm.mu.Unlock()
+	if pm.ctx.Err() != nil {
+		return // removed while in standby
+	}
+
+	me.connectPair(pm)
+	pm.keepConnected(pm.ctx)
+
+	// A pair paused in standby resumes to monitoring
+	pm.mu.Lock()
+	if pm.state == StatePaused {
+		pm.pausedFrom = StateMonitoring
+	}
+	pm.mu.Unlock()
+	me.transition(pm, StateMonitoring, EventPairActive, reason)
+}
+
+// transition moves a pair to a new state and notifies listeners. Nothing is
+// emitted when the pair is already in that state, and a paused pair only
+// leaves that state by being resumed.
+func (me *MonitoringEngine) transition(pm *DatabasePairMonitor, to, eventType, reason string) {
+	pm.mu.Lock()
+	from := pm.state
+	if from == to || (from == StatePaused && eventType != EventPairResumed) {
+		pm.mu.Unlock()
+		return
+	}
+	pm.state = to
+	pm.mu.Unlock()
+
+	log.Printf("[%s] Lifecycle: %s -> %s (%s)", pm.pairName, displayState(from), to, reason)
+
+	me.notifyPair(PairEvent{
+		Type:      eventType,
+		Pair:      pm.pairName,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: me.clock.Now(),
+	})
+}
+
+// notifyPair calls the pair listeners with an event
+func (me *MonitoringEngine) notifyPair(event PairEvent) {
+	me.listenersMu.RLock()
+	listeners := me.pairListeners
+	me.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// observeReplication detects a cut over: the target reports no replication
+// after it was seen replicating from the source
+func (me *MonitoringEngine) observeReplication(pm *DatabasePairMonitor, status string) {
+	pm.mu.Lock()
+	switch status {
+	case "ok", "lag_unknown", "replication_stopped", "connecting", "connection_retrying":
+		pm.sawReplication = true
+	}
+	cutOver := status == "no_replication" && pm.sawReplication
+	complete := pm.state == StateComplete
+	pm.mu.Unlock()
+
+	if cutOver {
+		if !complete {
+			me.transition(pm, StateCutOver, EventPairCutOver, "target no longer replicates from the source")
+		}
+		// Semi-sync and replication filters are no longer checked once the
+		// target stopped replicating
+		me.alertMgr.ResolvePairAlerts(pm.pairName, "semi_sync_degraded", "replication_filters_active")
+		for _, fanOut := range me.pairs() {
+			if fanOut.fanOutOf == pm.pairName && fanOut.waitForCutOver {
+				go me.activate(fanOut, fmt.Sprintf("pair '%s' cut over", pm.pairName))
+			}
+		}
+	}
+}
+
+// observeWarmup moves a pair between warmup and ready as the warm-up check result changes
+func (me *MonitoringEngine) observeWarmup(pm *DatabasePairMonitor, ready bool) {
+	pm.mu.RLock()
+	state := pm.state
+	pm.mu.RUnlock()
+
+	switch {
+	case state == StateCutOver:
+	case ready:
+		me.transition(pm, StateReady, EventPairReady, "warm-up check passed")
+	default:
+		me.transition(pm, StateWarmup, EventPairWarmup, "warm-up check reports the target is not ready")
+	}
+}
+
+// displayState names the empty initial state in logs
+func displayState(state string) string {
+	if state == "" {
+		return "none"
+	}
+	return state
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// maskedValue replaces fully masked values; it does not reveal their length
+const maskedValue = "****"
+
+// maskValue masks a column value according to the pair's masking rules.
+// Callers leave SQL NULL unmasked, as NULL versus a value is not data; a
+// string that reads NULL is masked like any other value.
+func maskValue(masking config.MaskingConfig, table, column, value string) string {
+	rule := masking.Rule(table, column)
+	if rule == nil {
+		return value
+	}
+
+	switch rule.Method {
+	case config.MaskPartial:
+		runes := []rune(value)
+		if len(runes) <= rule.Keep {
+			return maskedValue
+		}
+		return strings.Repeat("*", len(runes)-rule.Keep) + string(runes[len(runes)-rule.Keep:])
+	case config.MaskHash:
+		mac := hmac.New(sha256.New, []byte(masking.HashKey))
+		mac.Write([]byte(value))
+		return "hash:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	default:
+		return maskedValue
+	}
+}
+
+// maskDifference masks the values of a row difference, including the
+// primary key values whose columns are masked, and reports its key values
+// comma separated. NULL values stay visible.
+func maskDifference(masking config.MaskingConfig, table string, pk []string, diff *RowDifference) {
+	values := strings.Split(diff.PrimaryKey, keySeparator)
+	for i := range values {
+		if i < len(pk) {
+			values[i] = maskValue(masking, table, pk[i], values[i])
+		}
+	}
+	diff.PrimaryKey = strings.Join(values, ", ")
+	for i := range diff.Columns {
+		col := &diff.Columns[i]
+		if !col.SourceNull {
+			col.SourceValue = maskValue(masking, table, col.Column, col.SourceValue)
+		}
+		if !col.TargetNull {
+			col.TargetValue = maskValue(masking, table, col.Column, col.TargetValue)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ErrEngineStopping is returned by AddPair once the engine is stopping
+var ErrEngineStopping = errors.New("monitoring engine is stopping")
+
+// pairs returns the monitors of the pairs, which may be added and removed
+// at runtime
+func (me *MonitoringEngine) pairs() []*DatabasePairMonitor {
+	me.pairsMu.RLock()
+	defer me.pairsMu.RUnlock()
+	return slices.Clone(me.pairMonitors)
+}
+
+// AddPair adds a database pair given as its database_pairs entry, in YAML or
+// JSON, and starts monitoring it. The pair is written to the configuration
+// file; it connects in the background, like pairs at startup.
+func (me *MonitoringEngine) AddPair(entry []byte, reason string) (string, error) {
+	// Stop waits for the goroutines of the pairs, so none may start once it
+	// has begun
+	me.pairsMu.Lock()
+	defer me.pairsMu.Unlock()
+	if me.stopping {
+		return "", ErrEngineStopping
+	}
+
+	pair, err := me.config.AddPair(entry)
+	if err != nil {
+		return "", err
+	}
+
+	pm := me.newPairMonitor(pair)
+	pm.setClock(me.clock)
+	me.pairMonitors = append(me.pairMonitors, pm)
+
+	me.wg.Add(1)
+	go func() {
+		defer me.wg.Done()
+		me.connectPair(pm)
+		pm.keepConnected(pm.ctx)
+		if pm.ctx.Err() != nil {
+			return // removed while connecting
+		}
+		if pair.MigrationComplete {
+			me.transition(pm, StateComplete, EventPairAdded, reason+"; migration complete")
+		} else {
+			me.transition(pm, StateMonitoring, EventPairAdded, reason)
+		}
+		me.startPairLoop(pm)
+	}()
+	return pair.Name, nil
+}
+
+// RemovePair stops monitoring a database pair and removes it from the
+// configuration file. Its in-flight checks are cancelled, its connections
+// closed, its current results dropped and its active alerts resolved.
+func (me *MonitoringEngine) RemovePair(pairName, reason string) error {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return fmt.Errorf("database pair '%s' not found", pairName)
+	}
+	if _, err := me.config.RemovePair(pairName); err != nil {
+		return err
+	}
+
+	me.pairsMu.Lock()
+	me.pairMonitors = slices.DeleteFunc(slices.Clone(me.pairMonitors), func(other *DatabasePairMonitor) bool { return other == pm })
+	me.pairsMu.Unlock()
+
+	pm.cancel()
+	pm.mu.RLock()
+	done := pm.done
+	from := pm.state
+	pm.mu.RUnlock()
+	if done != nil {
+		<-done
+	}
+	pm.close()
+
+	me.storage.RemovePair(pairName)
+	me.alertMgr.ResolvePairAlerts(pairName)
+	me.statsMu.Lock()
+	delete(me.cycleStats, pairName)
+	for key := range me.checkStats {
+		if strings.HasPrefix(key, pairName+"/") {
+			delete(me.checkStats, key)
+		}
+	}
+	me.statsMu.Unlock()
+
+	log.Printf("[%s] Lifecycle: %s -> %s (%s)", pairName, displayState(from), StateRemoved, reason)
+	me.notifyPair(PairEvent{
+		Type:      EventPairRemoved,
+		Pair:      pairName,
+		From:      from,
+		To:        StateRemoved,
+		Reason:    reason,
+		Timestamp: me.clock.Now(),
+	})
+	return nil
+}
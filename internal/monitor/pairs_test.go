@@ -0,0 +1,39 @@
+package monitor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+func TestAddPairAfterStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `
+monitoring_interval: 10s
+source_db: {host: source, port: 3306, username: monitor, password: secret, database: shop}
+target_db: {host: target, port: 3306, username: monitor, password: secret, database: shop}
+tables_to_monitor: [orders]
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewMonitoringEngine(cfg, storage.NewMetricsStorage(), alert.NewAlertManager(cfg))
+	engine.Stop()
+
+	entry := []byte(`{"name": "second", "source_db": {"host": "a", "port": 3306, "username": "u", "password": "p", "database": "d"}, "target_db": {"host": "b", "port": 3306, "username": "u", "password": "p", "database": "d"}}`)
+	if _, err := engine.AddPair(entry, "test"); !errors.Is(err, ErrEngineStopping) {
+		t.Fatalf("AddPair after Stop returned %v, want %v", err, ErrEngineStopping)
+	}
+	if n := len(engine.pairs()); n != 1 {
+		t.Errorf("%d pairs after the rejected AddPair, want 1", n)
+	}
+}
@@ -0,0 +1,260 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// PermissionCheck is the outcome of probing one database access the monitor
+// needs
+type PermissionCheck struct {
+	Database string `json:"database"` // "source", "target" or the name of an intermediate
+	Check    string `json:"check"`
+	Error    string `json:"error,omitempty"`
+	Hint     string `json:"hint,omitempty"`     // how to fix the error
+	Optional bool   `json:"optional,omitempty"` // only a feature degrades without it
+	Feature  bool   `json:"feature,omitempty"`  // the server lacks a feature, rather than a privilege
+}
+
+// permissionProbe is a query that only succeeds with a privilege the monitor
+// needs. A probe with missing set also checks a server feature: its query
+// must return a row.
+type permissionProbe struct {
+	check    string
+	query    string
+	hint     string
+	optional bool
+
+	missing     string // the error when the query returns no row
+	missingHint string
+
+	// queryFor replaces query for probes that depend on the server's
+	// flavor; an empty query skips the probe
+	queryFor func(serverFlavor) string
+}
+
+// CheckPermissions connects to every database of a pair and probes the
+// privileges its checks use, without running the checks themselves
+func CheckPermissions(ctx context.Context, pair *config.DatabasePair, connectTimeout time.Duration) []PermissionCheck {
+	var checks []PermissionCheck
+
+	// The source is connected as the source of a manager, the target and
+	// intermediates as its target, so connection errors name them as the
+	// monitor does
+	probe := func(name string, db *config.DatabaseConfig, probes []permissionProbe) {
+		var connMgr *database.ConnectionManager
+		var connect func(context.Context) error
+		var get func() (*sql.DB, error)
+		if name == "source" {
+			connMgr = database.NewConnectionManager(db, nil, pair.Name, nil, connectTimeout)
+			connect, get = connMgr.ConnectSource, connMgr.GetSourceConnection
+		} else {
+			label := pair.Name
+			if name != "target" {
+				label += "/" + name
+			}
+			connMgr = database.NewConnectionManager(nil, db, label, nil, connectTimeout)
+			connect, get = connMgr.ConnectTarget, connMgr.GetTargetConnection
+		}
+		defer connMgr.Close()
+
+		if err := connect(ctx); err != nil {
+			checks = append(checks, PermissionCheck{Database: name, Check: "connect", Error: err.Error(),
+				Hint: fmt.Sprintf("check host, port, credentials and network access to %s", db.Address())})
+			return
+		}
+		checks = append(checks, PermissionCheck{Database: name, Check: "connect"})
+		conn, err := get()
+		if err != nil {
+			return
+		}
+		// Replication statements are probed in the server's terminology
+		var flavors flavorCache
+		flavor := flavors.get(ctx, conn)
+		for _, p := range probes {
+			query := flavor.translate(p.query)
+			if p.queryFor != nil {
+				if query = p.queryFor(flavor); query == "" {
+					continue
+				}
+			}
+			check := PermissionCheck{Database: name, Check: p.check, Optional: p.optional}
+			if query != p.query && p.queryFor == nil {
+				check.Check = query
+			}
+			found, err := runProbe(ctx, conn, query)
+			switch {
+			case err != nil:
+				check.Error = err.Error()
+				check.Hint = p.hint
+				var mysqlErr *mysql.MySQLError
+				if errors.As(err, &mysqlErr) && mysqlErr.Number == 1146 {
+					check.Hint = "the table does not exist; check tables_to_monitor and table_mappings"
+				}
+			case !found && p.missing != "":
+				check.Error = p.missing
+				check.Hint = p.missingHint
+				check.Feature = true
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	replicaProbe := func(db *config.DatabaseConfig) permissionProbe {
+		probe := permissionProbe{
+			check: "SHOW SLAVE STATUS",
+			query: "SHOW SLAVE STATUS",
+			hint:  fmt.Sprintf("GRANT REPLICATION CLIENT ON *.* TO '%s' (BINLOG MONITOR or SLAVE MONITOR on MariaDB 10.5+)", db.Username),
+		}
+		// In dual_write mode the target does not replicate
+		if !pair.DualWriteMode() {
+			probe.missing = "the server is not a replica: replication is not configured"
+			probe.missingHint = "configure replication from the upstream database, or set mode: dual_write if the application writes to both databases"
+		}
+		return probe
+	}
+	tablespaceProbe := func(db *config.DatabaseConfig) permissionProbe {
+		return permissionProbe{
+			check: "tablespace encryption views",
+			queryFor: func(flavor serverFlavor) string {
+				if query := tablespaceQuery(flavor); query != "" {
+					return query + " LIMIT 1"
+				}
+				return ""
+			},
+			hint:     fmt.Sprintf("GRANT PROCESS ON *.* TO '%s' to read the encryption of tablespaces from information_schema", db.Username),
+			optional: !pair.EncryptionStatus.Enabled,
+		}
+	}
+	semiSyncProbe := func(role, replicaRole string) permissionProbe {
+		return permissionProbe{
+			check:       "semi-sync " + role + " plugin",
+			query:       fmt.Sprintf("SHOW GLOBAL VARIABLES WHERE Variable_name IN ('rpl_semi_sync_%s_enabled', 'rpl_semi_sync_%s_enabled')", role, replicaRole),
+			missing:     "semi-synchronous replication is not available: its plugin is not installed",
+			missingHint: fmt.Sprintf("INSTALL PLUGIN rpl_semi_sync_%s (built in on MariaDB 10.3+), or disable semi_sync for the pair", role),
+		}
+	}
+	selectProbes := func(db *config.DatabaseConfig, tables []string) []permissionProbe {
+		probes := make([]permissionProbe, 0, len(tables))
+		for _, table := range tables {
+			grantOn := table
+			if schema, _ := config.SplitTable(table); schema == "" {
+				grantOn = db.Database + "." + table
+			}
+			probes = append(probes, permissionProbe{
+				check: "SELECT on " + table,
+				query: fmt.Sprintf("SELECT 1 FROM %s LIMIT 0", quoteTable(table)),
+				hint:  fmt.Sprintf("GRANT SELECT ON %s TO '%s'", quoteTable(grantOn), db.Username),
+			})
+		}
+		return probes
+	}
+
+	tables := pair.ExplicitTables()
+	sourceProbes := selectProbes(&pair.SourceDB, tables)
+	for _, schema := range pair.DiscoverySchemas() {
+		check, query := "table discovery", "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() LIMIT 1"
+		if schema == "" {
+			schema = pair.SourceDB.Database
+		} else {
+			check = "table discovery in " + schema
+			query = "SHOW TABLES FROM " + quoteIdent(schema)
+		}
+		sourceProbes = append(sourceProbes, permissionProbe{
+			check: check,
+			query: query,
+			hint:  fmt.Sprintf("GRANT SELECT ON %s.* TO '%s'", quoteIdent(schema), pair.SourceDB.Username),
+		})
+	}
+	sourceProbes = append(sourceProbes, permissionProbe{
+		check:    "SHOW MASTER STATUS",
+		query:    "SHOW MASTER STATUS",
+		hint:     fmt.Sprintf("GRANT REPLICATION CLIENT ON *.* TO '%s' to measure the IO thread's binlog backlog and the source's write rate", pair.SourceDB.Username),
+		optional: true,
+	}, tablespaceProbe(&pair.SourceDB))
+	if !pair.DualWriteMode() {
+		sourceProbes = append(sourceProbes, permissionProbe{
+			check:       "binary log",
+			query:       "SELECT 1 FROM DUAL WHERE @@global.log_bin = 1",
+			missing:     "binary logging is off",
+			missingHint: "enable log_bin on the source; its binary log write rate explains replica lag",
+			optional:    true,
+		})
+	}
+	targetProbes := []permissionProbe{tablespaceProbe(&pair.TargetDB), {
+		check: "encryption plugin",
+		queryFor: func(flavor serverFlavor) string {
+			if flavor.mysql {
+				return "" // MySQL keyrings are components or plugins, RDS encrypts storage
+			}
+			return "SELECT PLUGIN_NAME FROM information_schema.PLUGINS WHERE PLUGIN_TYPE = 'ENCRYPTION' AND PLUGIN_STATUS = 'ACTIVE'"
+		},
+		missing:     "no encryption plugin is active, so tables cannot be encrypted",
+		missingHint: "load a key management plugin such as file_key_management or aws_key_management",
+		optional:    true,
+	}}
+	if pair.SemiSync.Enabled {
+		sourceProbes = append(sourceProbes, semiSyncProbe("master", "source"))
+		targetProbes = append(targetProbes, semiSyncProbe("slave", "replica"))
+	}
+	if pair.PTChecksum.Enabled {
+		db := &pair.TargetDB
+		if pair.PTChecksum.ReadFrom == "source" {
+			db = &pair.SourceDB
+		}
+		ptProbe := permissionProbe{
+			check: "SELECT on " + pair.PTChecksum.Table,
+			query: fmt.Sprintf("SELECT 1 FROM %s LIMIT 0", (&PTChecksumReader{config: pair.PTChecksum}).quotedTable()),
+			hint:  fmt.Sprintf("GRANT SELECT ON %s TO '%s' to read pt-table-checksum's results", pair.PTChecksum.Table, db.Username),
+		}
+		if pair.PTChecksum.ReadFrom == "source" {
+			sourceProbes = append(sourceProbes, ptProbe)
+		} else {
+			targetProbes = append(targetProbes, ptProbe)
+		}
+	}
+	probe("source", &pair.SourceDB, sourceProbes)
+
+	for i := range pair.Intermediates {
+		hop := &pair.Intermediates[i]
+		probe(hop.Name, &hop.DB, []permissionProbe{replicaProbe(&hop.DB)})
+	}
+
+	targetTables := make([]string, len(tables))
+	for i, table := range tables {
+		targetTables[i] = pair.TableMappings.Target(table)
+	}
+	targetProbes = append(targetProbes, selectProbes(&pair.TargetDB, targetTables)...)
+	probe("target", &pair.TargetDB, append(targetProbes, replicaProbe(&pair.TargetDB)))
+
+	// Check endpoints only serve the table reads of the checks
+	if pair.CheckEndpoints.HasSource() {
+		probe("source_check", &pair.CheckEndpoints.Source, selectProbes(&pair.CheckEndpoints.Source, tables))
+	}
+	if pair.CheckEndpoints.HasTarget() {
+		probe("target_check", &pair.CheckEndpoints.Target, selectProbes(&pair.CheckEndpoints.Target, targetTables))
+	}
+
+	return checks
+}
+
+// runProbe runs a query, discards its rows and reports whether it returned any
+func runProbe(ctx context.Context, db *sql.DB, query string) (bool, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	found := false
+	for rows.Next() {
+		found = true
+	}
+	return found, rows.Err()
+}
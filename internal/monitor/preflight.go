@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+)
+
+// PreflightReport is the outcome of probing the privileges and server
+// features a pair's checks need
+type PreflightReport struct {
+	Pair      string            `json:"pair"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    []PermissionCheck `json:"checks"`
+	Failed    int               `json:"failed"`   // required checks that failed
+	Warnings  int               `json:"warnings"` // optional checks that failed, which only degrade a feature
+}
+
+// OK reports whether every required check passed
+func (r *PreflightReport) OK() bool {
+	return r.Failed == 0
+}
+
+// Preflight probes the databases of a pair for the privileges and server
+// features its checks need, over connections of its own, and raises a
+// WARNING alert while a required check fails
+func (me *MonitoringEngine) Preflight(ctx context.Context, pairName string) (*PreflightReport, error) {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return nil, fmt.Errorf("database pair '%s' not found", pairName)
+	}
+	pm.mu.RLock()
+	pair := pm.pairConfig
+	pm.mu.RUnlock()
+
+	report := &PreflightReport{Pair: pairName, CheckedAt: me.clock.Now()}
+	report.Checks = CheckPermissions(ctx, &pair, me.config.Timeouts.Connect)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var failures []alert.PreflightFailure
+	for _, check := range report.Checks {
+		switch {
+		case check.Error == "":
+		case check.Optional:
+			report.Warnings++
+		default:
+			report.Failed++
+			failures = append(failures, alert.PreflightFailure{Database: check.Database, Check: check.Check, Error: check.Error})
+		}
+	}
+	me.alertMgr.EvaluatePreflight(pairName, failures)
+	return report, nil
+}
+
+// runStartupPreflight checks every pair once after startup, one at a time,
+// and logs what fails with how to fix it. Fan-out pairs waiting for a cut
+// over are checked on demand only.
+func (me *MonitoringEngine) runStartupPreflight() {
+	defer me.wg.Done()
+	for _, pm := range me.pairs() {
+		if pm.waitForCutOver {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(me.ctx, me.config.Timeouts.Connect+time.Minute)
+		report, err := me.Preflight(ctx, pm.pairName)
+		cancel()
+		if err != nil {
+			if me.ctx.Err() != nil {
+				return
+			}
+			log.Printf("[%s] Pre-flight check failed: %v", pm.pairName, err)
+			continue
+		}
+		for _, check := range report.Checks {
+			if check.Error == "" {
+				continue
+			}
+			level := "Error"
+			if check.Optional {
+				level = "Warning"
+			}
+			log.Printf("[%s] Pre-flight %s: %s %s: %s", pm.pairName, level, check.Database, check.Check, check.Error)
+			if check.Hint != "" {
+				log.Printf("[%s]   fix: %s", pm.pairName, check.Hint)
+			}
+		}
+		log.Printf("[%s] Pre-flight check: %d check(s), %d failed, %d warning(s)", pm.pairName, len(report.Checks), report.Failed, report.Warnings)
+	}
+}
@@ -0,0 +1,283 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// ptChecksumDiffCondition selects the chunks whose replica row count or
+// checksum differs from the source's, as pt-table-checksum's documentation
+// queries them
+const ptChecksumDiffCondition = "(master_cnt <> this_cnt OR master_crc <> this_crc OR ISNULL(master_crc) <> ISNULL(this_crc))"
+
+// PTChecksumDiff is a differing chunk of pt-table-checksum's results
+type PTChecksumDiff struct {
+	Chunk         int
+	Index         string
+	LowerBoundary string
+	UpperBoundary string
+	SourceCount   int64
+	TargetCount   int64
+	SourceCRC     string
+	TargetCRC     string
+}
+
+// PTChecksumTable summarizes pt-table-checksum's results for one table
+type PTChecksumTable struct {
+	TableName  string
+	Chunks     int
+	DiffChunks int
+	Rows       int64
+	LastRun    time.Time
+	Diffs      []PTChecksumDiff
+}
+
+// PTChecksumReport is one read of pt-table-checksum's results table
+type PTChecksumReport struct {
+	Timestamp time.Time
+	Tables    []PTChecksumTable
+	Error     error
+}
+
+// PTChecksumReader reads the results pt-table-checksum left in its
+// --replicate table for the schema of a pair. It never runs checksums
+// itself.
+type PTChecksumReader struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	config  config.PTChecksumConfig
+	masking config.MaskingConfig
+	schema  string // the source schema, as pt-table-checksum records it in db
+	timeout time.Duration
+
+	mu      sync.Mutex
+	lastRun time.Time
+}
+
+// NewPTChecksumReader creates a reader of pt-table-checksum's results for a
+// schema. Chunk boundaries are key values, so they are left out for indexes
+// with masked columns.
+func NewPTChecksumReader(connMgr *database.ConnectionManager, cfg config.PTChecksumConfig, masking config.MaskingConfig, schema string, timeout time.Duration) *PTChecksumReader {
+	return &PTChecksumReader{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		config:  cfg,
+		masking: masking,
+		schema:  schema,
+		timeout: timeout,
+	}
+}
+
+// Due reports whether the read interval has passed since the last read
+func (pr *PTChecksumReader) Due() bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.clock.Since(pr.lastRun) >= pr.config.Interval
+}
+
+// Read reads the per-table summary and the first differing chunks of each
+// table from the results table
+func (pr *PTChecksumReader) Read(ctx context.Context) (*PTChecksumReport, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.lastRun = pr.clock.Now()
+
+	report := &PTChecksumReport{Timestamp: pr.clock.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, pr.timeout)
+	defer cancel()
+
+	get := pr.connMgr.GetTargetConnection
+	if pr.config.ReadFrom == "source" {
+		get = pr.connMgr.GetSourceConnection
+	}
+	conn, err := get()
+	if err != nil {
+		report.Error = fmt.Errorf("%s connection error: %w", pr.config.ReadFrom, err)
+		return report, report.Error
+	}
+
+	table := pr.quotedTable()
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf(`SELECT tbl, COUNT(*), COALESCE(SUM(master_cnt), 0),
+		SUM(CASE WHEN %s THEN 1 ELSE 0 END), MAX(ts)
+		FROM %s WHERE db = ? GROUP BY tbl ORDER BY tbl`, ptChecksumDiffCondition, table), pr.schema)
+	if err != nil {
+		report.Error = fmt.Errorf("failed to read %s: %w", pr.config.Table, err)
+		return report, report.Error
+	}
+	for rows.Next() {
+		var t PTChecksumTable
+		var lastRun sql.NullTime
+		if err := rows.Scan(&t.TableName, &t.Chunks, &t.Rows, &t.DiffChunks, &lastRun); err != nil {
+			rows.Close()
+			report.Error = fmt.Errorf("failed to read %s: %w", pr.config.Table, err)
+			return report, report.Error
+		}
+		t.LastRun = lastRun.Time
+		report.Tables = append(report.Tables, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		report.Error = fmt.Errorf("failed to read %s: %w", pr.config.Table, err)
+		return report, report.Error
+	}
+
+	for i := range report.Tables {
+		t := &report.Tables[i]
+		if t.DiffChunks == 0 {
+			continue
+		}
+		if t.Diffs, err = pr.readDiffs(ctx, conn, table, t.TableName); err != nil {
+			report.Error = fmt.Errorf("failed to read differing chunks of %s: %w", t.TableName, err)
+			return report, report.Error
+		}
+		pr.maskBoundaries(ctx, conn, t)
+	}
+	return report, nil
+}
+
+// maskBoundaries drops the chunk boundaries of indexes with a masked column.
+// Boundaries list the values of every index column in one string, so they
+// cannot be masked column by column; an index whose columns cannot be read
+// is treated as masked.
+func (pr *PTChecksumReader) maskBoundaries(ctx context.Context, conn *sql.DB, t *PTChecksumTable) {
+	if len(pr.masking.Rules) == 0 {
+		return
+	}
+	masked := make(map[string]bool) // key: chunk_index
+	for i := range t.Diffs {
+		d := &t.Diffs[i]
+		hide, known := masked[d.Index]
+		if !known {
+			hide = pr.indexMasked(ctx, conn, t.TableName, d.Index)
+			masked[d.Index] = hide
+		}
+		if hide {
+			d.LowerBoundary, d.UpperBoundary = "", ""
+		}
+	}
+}
+
+// indexMasked reports whether the masking rules mask a column of an index
+func (pr *PTChecksumReader) indexMasked(ctx context.Context, conn *sql.DB, tableName, index string) bool {
+	rows, err := conn.QueryContext(ctx, `SELECT COLUMN_NAME FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_NAME = ?`, pr.schema, tableName, index)
+	if err != nil {
+		return true
+	}
+	defer rows.Close()
+
+	columns := 0
+	for rows.Next() {
+		var column string
+		if rows.Scan(&column) != nil || pr.masking.Rule(tableName, column) != nil {
+			return true
+		}
+		columns++
+	}
+	return rows.Err() != nil || columns == 0
+}
+
+// readDiffs reads the first differing chunks of a table
+func (pr *PTChecksumReader) readDiffs(ctx context.Context, conn *sql.DB, table, tableName string) ([]PTChecksumDiff, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf(`SELECT chunk, COALESCE(chunk_index, ''),
+		COALESCE(lower_boundary, ''), COALESCE(upper_boundary, ''),
+		COALESCE(master_cnt, 0), this_cnt, COALESCE(master_crc, ''), COALESCE(this_crc, '')
+		FROM %s WHERE db = ? AND tbl = ? AND %s ORDER BY chunk LIMIT ?`, table, ptChecksumDiffCondition),
+		pr.schema, tableName, pr.config.MaxDiffs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var diffs []PTChecksumDiff
+	for rows.Next() {
+		var d PTChecksumDiff
+		if err := rows.Scan(&d.Chunk, &d.Index, &d.LowerBoundary, &d.UpperBoundary,
+			&d.SourceCount, &d.TargetCount, &d.SourceCRC, &d.TargetCRC); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, rows.Err()
+}
+
+// quotedTable returns the results table with its schema and name quoted
+func (pr *PTChecksumReader) quotedTable() string {
+	parts := strings.Split(pr.config.Table, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdent(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// checkPTChecksum reads pt-table-checksum's results for a pair, stores them
+// and evaluates their alerts
+func (me *MonitoringEngine) checkPTChecksum(ctx context.Context, pm *DatabasePairMonitor) {
+	ctx, endCheck := me.startCheck(ctx, pm.pairName, "pt_checksum")
+	report, err := pm.ptChecksumReader.Read(ctx)
+	endCheck(err)
+	if err != nil {
+		log.Printf("[%s] pt-table-checksum results error: %v", pm.pairName, err)
+	}
+
+	me.storage.StorePTChecksumReport(ToStoragePTChecksumReport(pm.pairName, pm.ptChecksumReader.config.ReadFrom, report))
+
+	alertResult := &alert.PTChecksumResult{Error: report.Error}
+	for _, t := range report.Tables {
+		alertTable := alert.PTChecksumTable{TableName: t.TableName, Chunks: t.Chunks, DiffChunks: t.DiffChunks}
+		if len(t.Diffs) > 0 {
+			alertTable.FirstDiff = t.Diffs[0].describe()
+		}
+		alertResult.Tables = append(alertResult.Tables, alertTable)
+	}
+	me.alertMgr.EvaluatePTChecksum(pm.pairName, alertResult)
+}
+
+// describe summarizes a differing chunk for alert messages
+func (d PTChecksumDiff) describe() string {
+	bounds := ""
+	if d.LowerBoundary != "" || d.UpperBoundary != "" {
+		bounds = fmt.Sprintf(" (%s %s..%s)", d.Index, d.LowerBoundary, d.UpperBoundary)
+	}
+	return fmt.Sprintf("chunk %d%s: %d rows on source, %d on target", d.Chunk, bounds, d.SourceCount, d.TargetCount)
+}
+
+// ToStoragePTChecksumReport converts pt-table-checksum's results to their
+// storage representation
+func ToStoragePTChecksumReport(pairName, readFrom string, report *PTChecksumReport) *storage.PTChecksumReport {
+	storageReport := &storage.PTChecksumReport{
+		DatabasePair: pairName,
+		Timestamp:    report.Timestamp,
+		ReadFrom:     readFrom,
+		Tables:       make([]storage.PTChecksumTable, 0, len(report.Tables)),
+	}
+	if report.Error != nil {
+		storageReport.Error = report.Error.Error()
+	}
+	for _, t := range report.Tables {
+		storageTable := storage.PTChecksumTable{
+			TableName:  t.TableName,
+			Chunks:     t.Chunks,
+			DiffChunks: t.DiffChunks,
+			Rows:       t.Rows,
+			LastRun:    t.LastRun,
+		}
+		for _, d := range t.Diffs {
+			storageTable.Diffs = append(storageTable.Diffs, storage.PTChecksumDiff(d))
+		}
+		storageReport.Tables = append(storageReport.Tables, storageTable)
+	}
+	return storageReport
+}
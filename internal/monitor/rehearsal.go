@@ -0,0 +1,212 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// Rehearsal fault kinds
+const (
+	FaultLag        = "lag"        // replica lag replaced by a synthetic value
+	FaultMismatch   = "mismatch"   // checksum and row count difference on one table
+	FaultConnection = "connection" // a database reported unreachable
+)
+
+// Fault is a synthetic fault injected into the checks of a pair while it is
+// active, to rehearse how alerts are routed and escalated. The checks still
+// run; their results are replaced, marked with the fault ID, and flow through
+// storage, alerts and notifiers as real results would.
+type Fault struct {
+	ID         string    `json:"id"`
+	Pair       string    `json:"pair"`
+	Kind       string    `json:"kind"`
+	LagSeconds float64   `json:"lag_seconds,omitempty"` // lag: defaults to twice the highest lag tier
+	Table      string    `json:"table,omitempty"`       // mismatch: defaults to the first monitored table
+	DriftRows  int64     `json:"drift_rows,omitempty"`  // mismatch: defaults to twice the highest drift tier
+	Database   string    `json:"database,omitempty"`    // connection: "source" or "target" (default)
+	End        time.Time `json:"end"`
+	Reason     string    `json:"reason,omitempty"`
+	InjectedBy string    `json:"injected_by"`
+	InjectedAt time.Time `json:"injected_at"`
+}
+
+// InjectFault starts a fault on a pair, which lasts until its end or until
+// it is cleared. Faults are rejected unless rehearsal.enabled is set.
+func (me *MonitoringEngine) InjectFault(f Fault) (Fault, error) {
+	if !me.config.Rehearsal.Enabled {
+		return Fault{}, fmt.Errorf("rehearsal mode is not enabled")
+	}
+	pm := me.findPairMonitor(f.Pair)
+	if pm == nil {
+		return Fault{}, fmt.Errorf("database pair '%s' not found", f.Pair)
+	}
+
+	thresholds := me.config.PairThresholds(f.Pair)
+	switch f.Kind {
+	case FaultLag:
+		if f.LagSeconds < 0 {
+			return Fault{}, fmt.Errorf("lag_seconds cannot be negative")
+		}
+		if f.LagSeconds == 0 {
+			f.LagSeconds = 2 * max(thresholds.ReplicaLag.WarningAt, thresholds.ReplicaLag.CriticalAt).Seconds()
+		}
+	case FaultMismatch:
+		tables := pm.Tables()
+		if f.Table == "" && len(tables) > 0 {
+			f.Table = tables[0]
+		}
+		if !slices.Contains(tables, f.Table) {
+			return Fault{}, fmt.Errorf("table '%s' is not monitored for pair '%s'", f.Table, f.Pair)
+		}
+		if f.DriftRows < 0 {
+			return Fault{}, fmt.Errorf("drift_rows cannot be negative")
+		}
+		if f.DriftRows == 0 {
+			f.DriftRows = max(1, 2*max(thresholds.RowCountDrift.WarningAt, thresholds.RowCountDrift.CriticalAt))
+		}
+	case FaultConnection:
+		switch f.Database {
+		case "":
+			f.Database = "target"
+		case "source", "target":
+		default:
+			return Fault{}, fmt.Errorf("database must be 'source' or 'target'")
+		}
+	default:
+		return Fault{}, fmt.Errorf("kind must be '%s', '%s' or '%s'", FaultLag, FaultMismatch, FaultConnection)
+	}
+
+	now := me.clock.Now()
+	switch {
+	case !f.End.After(now):
+		return Fault{}, fmt.Errorf("end must be in the future")
+	case f.End.Sub(now) > me.config.Rehearsal.MaxDuration:
+		return Fault{}, fmt.Errorf("fault cannot last longer than %v", me.config.Rehearsal.MaxDuration)
+	}
+
+	me.faultMu.Lock()
+	me.nextFaultID++
+	f.ID = fmt.Sprintf("fault-%d", me.nextFaultID)
+	f.InjectedAt = now
+	me.faults = append(me.faults, f)
+	me.faultMu.Unlock()
+
+	log.Printf("[%s] REHEARSAL: %s fault %s injected until %s", f.Pair, f.Kind, f.ID, f.End.Format(time.RFC3339))
+	return f, nil
+}
+
+// Faults returns the active faults, optionally for one pair
+func (me *MonitoringEngine) Faults(pairName string) []Fault {
+	me.faultMu.Lock()
+	defer me.faultMu.Unlock()
+
+	me.pruneFaults(me.clock.Now())
+	faults := make([]Fault, 0, len(me.faults))
+	for _, f := range me.faults {
+		if pairName == "" || f.Pair == pairName {
+			faults = append(faults, f)
+		}
+	}
+	return faults
+}
+
+// ClearFault ends a fault before its end; the next checks report the real
+// state again
+func (me *MonitoringEngine) ClearFault(id string) (Fault, error) {
+	me.faultMu.Lock()
+	defer me.faultMu.Unlock()
+
+	for i, f := range me.faults {
+		if f.ID == id {
+			me.faults = slices.Delete(me.faults, i, i+1)
+			log.Printf("[%s] REHEARSAL: fault %s cleared", f.Pair, f.ID)
+			return f, nil
+		}
+	}
+	return Fault{}, fmt.Errorf("fault '%s' not found", id)
+}
+
+// pruneFaults drops faults that have ended; faultMu must be held
+func (me *MonitoringEngine) pruneFaults(now time.Time) {
+	me.faults = slices.DeleteFunc(me.faults, func(f Fault) bool {
+		return !now.Before(f.End)
+	})
+}
+
+// activeFault returns the first active fault of a kind on a pair, or nil
+func (me *MonitoringEngine) activeFault(pairName, kind string, match func(Fault) bool) *Fault {
+	me.faultMu.Lock()
+	defer me.faultMu.Unlock()
+
+	me.pruneFaults(me.clock.Now())
+	for _, f := range me.faults {
+		if f.Pair == pairName && f.Kind == kind && (match == nil || match(f)) {
+			return &f
+		}
+	}
+	return nil
+}
+
+// injectConnectionFault reports a database of a pair unreachable while a
+// connection fault is active, and returns the fault ID or ""
+func (me *MonitoringEngine) injectConnectionFault(pairName string, sourceOK, targetOK *bool) string {
+	id := ""
+	for _, database := range []struct {
+		name string
+		ok   *bool
+	}{
+		{"source", sourceOK},
+		{"target", targetOK},
+	} {
+		f := me.activeFault(pairName, FaultConnection, func(f Fault) bool { return f.Database == database.name })
+		if f != nil {
+			*database.ok = false
+			id = f.ID
+		}
+	}
+	return id
+}
+
+// injectLagFault replaces a lag sample while a lag fault is active
+func (me *MonitoringEngine) injectLagFault(pairName string, metric *ReplicaLagMetric) {
+	f := me.activeFault(pairName, FaultLag, nil)
+	if f == nil {
+		return
+	}
+	metric.LagSeconds = f.LagSeconds
+	metric.Status = "ok"
+	metric.Error = nil
+	metric.Rehearsal = f.ID
+}
+
+// injectChecksumFault turns the checksum of a table into a mismatch while a
+// mismatch fault on it is active
+func (me *MonitoringEngine) injectChecksumFault(pairName string, result *ChecksumResult) {
+	f := me.activeFault(pairName, FaultMismatch, func(f Fault) bool { return f.Table == result.TableName })
+	if f == nil {
+		return
+	}
+	result.TargetChecksum = "rehearsal"
+	result.Match = false
+	result.Skipped = false
+	result.Error = nil
+	result.Rehearsal = f.ID
+}
+
+// injectConsistencyFault removes rows from (or, for small tables, adds rows
+// to) the target count of a table while a mismatch fault on it is active
+func (me *MonitoringEngine) injectConsistencyFault(pairName string, result *ConsistencyResult) {
+	f := me.activeFault(pairName, FaultMismatch, func(f Fault) bool { return f.Table == result.TableName })
+	if f == nil {
+		return
+	}
+	result.TargetRowCount = result.SourceRowCount - f.DriftRows
+	if result.TargetRowCount < 0 {
+		result.TargetRowCount = result.SourceRowCount + f.DriftRows
+	}
+	result.Consistent = false
+	result.Error = nil
+	result.Rehearsal = f.ID
+}
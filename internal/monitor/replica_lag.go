@@ -1,11 +1,15 @@
 package monitor
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"mariadb-encryption-monitor/internal/clock"
 	"mariadb-encryption-monitor/internal/database"
 )
 
@@ -15,27 +19,75 @@ type ReplicaLagMetric struct {
 	LagSeconds float64
 	Status     string
 	Error      error
+
+	// Replication thread state and connection retry settings; the heartbeat
+	// period and retry count are nil when the server does not report them
+	IORunning        string
+	SQLRunning       string
+	HeartbeatPeriod  *float64 // Slave_heartbeat_period in seconds
+	ConnectRetry     int64    // Connect_Retry in seconds
+	MasterRetryCount *int64   // Master_Retry_Count, or @@master_retry_count on MariaDB
+
+	// Last errors of the replication threads, e.g. the statement the SQL
+	// thread stopped on; zero and empty when there was none
+	LastIOErrno  int64
+	LastIOError  string
+	LastSQLErrno int64
+	LastSQLError string
+
+	// Bytes of binary log the IO and SQL threads are behind; nil when the
+	// replica reports no positions
+	Backlog *BinlogBacklog
+
+	// Lag of each hop of a chained replication topology, ending with the
+	// target; LagSeconds is then their sum
+	Hops []HopLag
+
+	Rehearsal string // ID of the rehearsal fault the sample was replaced by
 }
 
 // ReplicaLagMonitor monitors replication lag
 type ReplicaLagMonitor struct {
 	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	timeout time.Duration
+
+	// sourceIsPrimary is set when the target replicates directly from the
+	// source, whose binary log position then gives the IO thread's backlog
+	sourceIsPrimary bool
+
+	// Server flavors decide the replication statements and column names
+	targetFlavor flavorCache
+	sourceFlavor flavorCache
+
+	// When the IO thread started reporting Connecting; zero while it does not
+	mu              sync.Mutex
+	connectingSince time.Time
 }
 
+// connectingGrace is how long the IO thread may report Connecting before it
+// is considered stuck, when the replica does not report Connect_Retry
+const connectingGrace = time.Minute
+
 // NewReplicaLagMonitor creates a new replica lag monitor
-func NewReplicaLagMonitor(connMgr *database.ConnectionManager) *ReplicaLagMonitor {
+func NewReplicaLagMonitor(connMgr *database.ConnectionManager, timeout time.Duration) *ReplicaLagMonitor {
 	return &ReplicaLagMonitor{
 		connMgr: connMgr,
+		clock:   clock.Real,
+		timeout: timeout,
 	}
 }
 
 // MeasureLag measures the current replication lag
-func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
+func (rlm *ReplicaLagMonitor) MeasureLag(ctx context.Context) (*ReplicaLagMetric, error) {
 	metric := &ReplicaLagMetric{
-		Timestamp: time.Now(),
+		Timestamp: rlm.clock.Now(),
 		Status:    "unknown",
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, rlm.timeout)
+	defer cancel()
+
 	targetConn, err := rlm.connMgr.GetTargetConnection()
 	if err != nil {
 		metric.Error = err
@@ -43,9 +95,10 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 		return metric, err
 	}
 
-	// Try SHOW SLAVE STATUS first (MySQL/MariaDB traditional replication)
-	query := "SHOW SLAVE STATUS"
-	rows, err := targetConn.Query(query)
+	// MySQL 8.0.22+ names it SHOW REPLICA STATUS, with renamed columns
+	flavor := rlm.targetFlavor.get(ctx, targetConn)
+	query := flavor.replicaStatusQuery()
+	rows, err := targetConn.QueryContext(ctx, query)
 	if err != nil {
 		metric.Error = fmt.Errorf("failed to query slave status: %w", err)
 		metric.Status = "query_error"
@@ -57,7 +110,7 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 		// No replication configured - this is normal for non-replica databases
 		metric.Status = "no_replication"
 		metric.LagSeconds = 0
-		metric.Error = fmt.Errorf("no replication configured (SHOW SLAVE STATUS returned no rows)")
+		metric.Error = fmt.Errorf("no replication configured (%s returned no rows)", query)
 		return metric, nil
 	}
 
@@ -87,11 +140,10 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 		return metric, metric.Error
 	}
 
-	// Find the indices of the columns we need
-	columnMap := make(map[string]int)
-	for i, col := range columns {
-		columnMap[col] = i
-	}
+	rows.Close()
+
+	// Find the indices of the columns we need, by their SHOW SLAVE STATUS names
+	columnMap := replicaStatusColumns(columns)
 
 	// Extract values with detailed logging
 	if idx, ok := columnMap["Slave_IO_Running"]; ok {
@@ -187,12 +239,64 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 		log.Printf("DEBUG: Available columns: %v", columns)
 	}
 
+	metric.IORunning = slaveIORunning.String
+	metric.SQLRunning = slaveSQLRunning.String
+	connectRetry, _ := numericColumn(values, columnMap, "Connect_Retry")
+	metric.ConnectRetry = int64(connectRetry)
+	if heartbeat, ok := numericColumn(values, columnMap, "Slave_heartbeat_period"); ok {
+		metric.HeartbeatPeriod = &heartbeat
+	}
+	if retryCount, ok := numericColumn(values, columnMap, "Master_Retry_Count"); ok {
+		count := int64(retryCount)
+		metric.MasterRetryCount = &count
+	}
+	if !flavor.mysql {
+		readMariaDBRetrySettings(ctx, targetConn, metric)
+	}
+	lastIOErrno, _ := numericColumn(values, columnMap, "Last_IO_Errno")
+	metric.LastIOErrno = int64(lastIOErrno)
+	metric.LastIOError, _ = stringColumn(values, columnMap, "Last_IO_Error")
+	lastSQLErrno, _ := numericColumn(values, columnMap, "Last_SQL_Errno")
+	metric.LastSQLErrno = int64(lastSQLErrno)
+	metric.LastSQLError, _ = stringColumn(values, columnMap, "Last_SQL_Error")
+	if backlog, ok := backlogPositions(values, columnMap); ok {
+		var source *sql.DB
+		var sourceFlavor serverFlavor
+		if rlm.sourceIsPrimary {
+			if source, _ = rlm.connMgr.GetSourceConnection(); source != nil {
+				sourceFlavor = rlm.sourceFlavor.get(ctx, source)
+			}
+		}
+		measureBacklog(ctx, source, sourceFlavor, backlog)
+		metric.Backlog = backlog
+	}
+
 	// Check replication status
 	if slaveIORunning.Valid && slaveSQLRunning.Valid {
+		// The IO thread reports "Connecting" while it retries a lost
+		// connection. A reconnect after a network blip passes within a
+		// retry or two, so only a lasting one is reported as retrying.
+		if slaveIORunning.String == "Connecting" {
+			since := rlm.connecting(metric.Timestamp)
+			metric.LagSeconds = 0
+			grace := connectingGrace
+			if metric.ConnectRetry > 0 {
+				grace = 2 * time.Duration(metric.ConnectRetry) * time.Second
+			}
+			if metric.Timestamp.Sub(since) < grace {
+				metric.Status = "connecting"
+				metric.Error = fmt.Errorf("replica IO thread is reconnecting to the primary since %s%s", since.Format(time.DateTime), metric.threadErrors())
+				return metric, nil
+			}
+			metric.Status = "connection_retrying"
+			metric.Error = fmt.Errorf("replica IO thread is retrying the connection to the primary since %s (%s)%s", since.Format(time.DateTime), metric.retrySettings(), metric.threadErrors())
+			return metric, metric.Error
+		}
+		rlm.connecting(time.Time{})
 		if slaveIORunning.String != "Yes" || slaveSQLRunning.String != "Yes" {
 			metric.Status = "replication_stopped"
 			metric.LagSeconds = 0
-			metric.Error = fmt.Errorf("replication not running (IO: %s, SQL: %s)", slaveIORunning.String, slaveSQLRunning.String)
+			metric.Error = fmt.Errorf("replication not running (IO: %s, SQL: %s)%s", slaveIORunning.String, slaveSQLRunning.String, metric.threadErrors())
 			return metric, metric.Error
 		}
 	} else {
@@ -204,7 +308,7 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 	}
 
 	log.Printf("DEBUG: Final check - secondsBehindMaster.Valid=%v, secondsBehindMaster.Float64=%.2f", secondsBehindMaster.Valid, secondsBehindMaster.Float64)
-	
+
 	if secondsBehindMaster.Valid {
 		metric.LagSeconds = secondsBehindMaster.Float64
 		metric.Status = "ok"
@@ -220,3 +324,110 @@ func (rlm *ReplicaLagMonitor) MeasureLag() (*ReplicaLagMetric, error) {
 
 	return metric, nil
 }
+
+// connecting records that the IO thread reports Connecting at a point in
+// time, or stopped doing so when it is zero, and returns since when it does
+func (rlm *ReplicaLagMonitor) connecting(at time.Time) time.Time {
+	rlm.mu.Lock()
+	defer rlm.mu.Unlock()
+	if at.IsZero() || rlm.connectingSince.IsZero() {
+		rlm.connectingSince = at
+	}
+	return rlm.connectingSince
+}
+
+// readMariaDBRetrySettings reads the settings MariaDB leaves out of SHOW
+// SLAVE STATUS: the heartbeat period of the default connection from SHOW ALL
+// SLAVES STATUS, and the global @@master_retry_count. Either stays nil when
+// it cannot be read.
+func readMariaDBRetrySettings(ctx context.Context, conn *sql.DB, metric *ReplicaLagMetric) {
+	var retryCount int64
+	if err := conn.QueryRowContext(ctx, "SELECT @@global.master_retry_count").Scan(&retryCount); err == nil {
+		metric.MasterRetryCount = &retryCount
+	}
+
+	rows, err := conn.QueryContext(ctx, "SHOW ALL SLAVES STATUS")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+	columnMap := make(map[string]int, len(columns))
+	for i, column := range columns {
+		columnMap[column] = i
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if rows.Scan(valuePtrs...) != nil {
+			return
+		}
+		// SHOW SLAVE STATUS shows the default connection, named ''
+		if name, _ := stringColumn(values, columnMap, "Connection_name"); name != "" {
+			continue
+		}
+		if heartbeat, ok := numericColumn(values, columnMap, "Slave_heartbeat_period"); ok {
+			metric.HeartbeatPeriod = &heartbeat
+		}
+		return
+	}
+}
+
+// retrySettings describes the connection retry settings for messages
+func (m *ReplicaLagMetric) retrySettings() string {
+	settings := fmt.Sprintf("connect_retry: %ds", m.ConnectRetry)
+	if m.MasterRetryCount != nil {
+		settings += fmt.Sprintf(", master_retry_count: %d", *m.MasterRetryCount)
+	}
+	return settings
+}
+
+// threadErrors describes the last errors of the replication threads, to
+// append to a status message; empty when there were none
+func (m *ReplicaLagMetric) threadErrors() string {
+	var errs []string
+	if m.LastIOErrno != 0 || m.LastIOError != "" {
+		errs = append(errs, fmt.Sprintf("IO error %d: %s", m.LastIOErrno, m.LastIOError))
+	}
+	if m.LastSQLErrno != 0 || m.LastSQLError != "" {
+		errs = append(errs, fmt.Sprintf("SQL error %d: %s", m.LastSQLErrno, m.LastSQLError))
+	}
+	if len(errs) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(errs, "; ")
+}
+
+// numericColumn reads a numeric column from a scanned result row
+func numericColumn(values []interface{}, columnMap map[string]int, name string) (float64, bool) {
+	idx, ok := columnMap[name]
+	if !ok || values[idx] == nil {
+		return 0, false
+	}
+
+	switch v := values[idx].(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case []byte:
+		var f float64
+		if _, err := fmt.Sscanf(string(v), "%f", &f); err == nil {
+			return f, true
+		}
+	case string:
+		var f float64
+		if _, err := fmt.Sscanf(v, "%f", &f); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
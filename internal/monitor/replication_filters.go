@@ -0,0 +1,171 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// replicationFilterColumns are the SHOW SLAVE STATUS columns of the filters
+// that keep events from being applied on a replica
+var replicationFilterColumns = []string{
+	"Replicate_Do_DB",
+	"Replicate_Ignore_DB",
+	"Replicate_Do_Table",
+	"Replicate_Ignore_Table",
+	"Replicate_Wild_Do_Table",
+	"Replicate_Wild_Ignore_Table",
+	"Replicate_Rewrite_DB",
+	"Replicate_Ignore_Server_Ids",
+}
+
+// skipVariables are the global variables that make a replica skip events or
+// errors, in MariaDB's and older MySQL's terminology and MySQL 8.0.26+'s
+const skipVariables = "('sql_slave_skip_counter', 'sql_replica_skip_counter', 'slave_skip_errors', 'replica_skip_errors')"
+
+// ReplicationFilters is the state of the target's replication filters and
+// skip settings. Either lets the target silently leave out changes of the
+// source, which is how the databases diverge unnoticed.
+type ReplicationFilters struct {
+	Timestamp   time.Time
+	Filters     map[string]string // active filters, by SHOW SLAVE STATUS column
+	SkipCounter int64             // events the SQL thread will skip (sql_slave_skip_counter)
+	SkipErrors  string            // error codes the SQL thread ignores (slave_skip_errors); "" when OFF
+	Problems    []string
+	Error       error
+}
+
+// Skipping reports whether the target skips events or errors, which is worse
+// than a filter: what gets lost depends on what fails
+func (f *ReplicationFilters) Skipping() bool {
+	return f.SkipCounter > 0 || f.SkipErrors != ""
+}
+
+// ReplicationFilterMonitor inspects the replication filters and skip
+// settings of a pair's target
+type ReplicationFilterMonitor struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	timeout time.Duration
+	flavor  flavorCache
+}
+
+// NewReplicationFilterMonitor creates a new replication filter monitor
+func NewReplicationFilterMonitor(connMgr *database.ConnectionManager, timeout time.Duration) *ReplicationFilterMonitor {
+	return &ReplicationFilterMonitor{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		timeout: timeout,
+	}
+}
+
+// Inspect reads the target's replication filters and skip settings
+func (fm *ReplicationFilterMonitor) Inspect(ctx context.Context) (*ReplicationFilters, error) {
+	result := &ReplicationFilters{
+		Timestamp: fm.clock.Now(),
+		Filters:   make(map[string]string),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fm.timeout)
+	defer cancel()
+
+	targetConn, err := fm.connMgr.GetTargetConnection()
+	if err != nil {
+		result.Error = fmt.Errorf("target connection error: %w", err)
+		return result, result.Error
+	}
+
+	rows, err := targetConn.QueryContext(ctx, fm.flavor.get(ctx, targetConn).replicaStatusQuery())
+	if err != nil {
+		result.Error = fmt.Errorf("failed to query slave status: %w", err)
+		return result, result.Error
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		result.Error = fmt.Errorf("failed to get columns: %w", err)
+		return result, result.Error
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	hasRow := rows.Next()
+	if hasRow {
+		err = rows.Scan(valuePtrs...)
+	} else {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil {
+		result.Error = fmt.Errorf("failed to scan slave status: %w", err)
+		return result, result.Error
+	}
+
+	if hasRow {
+		columnMap := replicaStatusColumns(columns)
+		for _, name := range replicationFilterColumns {
+			if value, _ := stringColumn(values, columnMap, name); strings.TrimSpace(value) != "" {
+				result.Filters[name] = strings.TrimSpace(value)
+			}
+		}
+		if skip, ok := numericColumn(values, columnMap, "Skip_Counter"); ok {
+			result.SkipCounter = int64(skip)
+		}
+	}
+
+	variables, err := targetConn.QueryContext(ctx, "SHOW GLOBAL VARIABLES WHERE Variable_name IN "+skipVariables)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to query skip settings: %w", err)
+		return result, result.Error
+	}
+	defer variables.Close()
+	for variables.Next() {
+		var name, value string
+		if err := variables.Scan(&name, &value); err != nil {
+			result.Error = fmt.Errorf("failed to scan skip settings: %w", err)
+			return result, result.Error
+		}
+		switch strings.ToLower(name) {
+		case "sql_slave_skip_counter", "sql_replica_skip_counter":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > result.SkipCounter {
+				result.SkipCounter = n
+			}
+		case "slave_skip_errors", "replica_skip_errors":
+			if value != "" && !strings.EqualFold(value, "OFF") {
+				result.SkipErrors = value
+			}
+		}
+	}
+	if err := variables.Err(); err != nil {
+		result.Error = fmt.Errorf("failed to read skip settings: %w", err)
+		return result, result.Error
+	}
+
+	result.Problems = replicationFilterProblems(result)
+	return result, nil
+}
+
+// replicationFilterProblems describes the active filters and skip settings,
+// skip settings first
+func replicationFilterProblems(f *ReplicationFilters) []string {
+	var problems []string
+	if f.SkipErrors != "" {
+		problems = append(problems, fmt.Sprintf("SQL thread ignores errors %s (slave_skip_errors)", f.SkipErrors))
+	}
+	if f.SkipCounter > 0 {
+		problems = append(problems, fmt.Sprintf("SQL thread will skip the next %d event group(s) (sql_slave_skip_counter)", f.SkipCounter))
+	}
+	for _, name := range replicationFilterColumns {
+		if value, ok := f.Filters[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	return problems
+}
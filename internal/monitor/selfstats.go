@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	"mariadb-encryption-monitor/internal/statsd"
+)
+
+// CycleStats are the monitoring cycles of a pair since startup
+type CycleStats struct {
+	Pair            string    `json:"pair"`
+	Cycles          int64     `json:"cycles"`
+	Overruns        int64     `json:"overruns"`  // cycles that took longer than the check interval
+	Skipped         int64     `json:"skipped"`   // ticks of the interval skipped after overruns
+	Cancelled       int64     `json:"cancelled"` // cycles cancelled by cycle_overlap: cancel
+	TotalSeconds    float64   `json:"total_seconds"`
+	WaitSeconds     float64   `json:"wait_seconds"` // time cycles waited for a slot under max_concurrent_pairs
+	LastSeconds     float64   `json:"last_seconds"`
+	MaxSeconds      float64   `json:"max_seconds"`
+	IntervalSeconds float64   `json:"interval_seconds"` // check interval of the last cycle
+	LastCycleAt     time.Time `json:"last_cycle_at"`
+}
+
+// CheckStats are the runs of one check of a pair since startup. Checksum and
+// consistency checks run once per table.
+type CheckStats struct {
+	Pair         string  `json:"pair"`
+	Check        string  `json:"check"`
+	Runs         int64   `json:"runs"`
+	Errors       int64   `json:"errors"`
+	TotalSeconds float64 `json:"total_seconds"`
+	LastSeconds  float64 `json:"last_seconds"`
+	MaxSeconds   float64 `json:"max_seconds"`
+	LastError    string  `json:"last_error,omitempty"`
+}
+
+// recordCycle counts a monitoring cycle of a pair, and the ticks skipped after
+// it. waited is the time the cycle waited for a slot before running.
+func (me *MonitoringEngine) recordCycle(pairName string, duration, waited, interval time.Duration, skipped int, cancelled bool) {
+	me.statsMu.Lock()
+	defer me.statsMu.Unlock()
+
+	stats := me.cycleStats[pairName]
+	if stats == nil {
+		stats = &CycleStats{Pair: pairName}
+		me.cycleStats[pairName] = stats
+	}
+	seconds := duration.Seconds()
+	stats.Cycles++
+	if waited+duration > interval {
+		stats.Overruns++
+	}
+	stats.Skipped += int64(skipped)
+	if cancelled {
+		stats.Cancelled++
+	}
+	stats.TotalSeconds += seconds
+	stats.WaitSeconds += waited.Seconds()
+	stats.LastSeconds = seconds
+	stats.MaxSeconds = max(stats.MaxSeconds, seconds)
+	stats.IntervalSeconds = interval.Seconds()
+	stats.LastCycleAt = me.clock.Now()
+
+	if skipped > 0 {
+		me.statsd.Count("cycle.skipped", int64(skipped), statsd.Tag{Key: "pair", Value: pairName})
+	}
+	if cancelled {
+		me.statsd.Count("cycle.cancelled", 1, statsd.Tag{Key: "pair", Value: pairName})
+	}
+}
+
+// recordCheck counts a run of a check of a pair
+func (me *MonitoringEngine) recordCheck(pairName, check string, duration time.Duration, err error) {
+	me.statsMu.Lock()
+	defer me.statsMu.Unlock()
+
+	key := pairName + "/" + check
+	stats := me.checkStats[key]
+	if stats == nil {
+		stats = &CheckStats{Pair: pairName, Check: check}
+		me.checkStats[key] = stats
+	}
+	seconds := duration.Seconds()
+	stats.Runs++
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+	}
+	stats.TotalSeconds += seconds
+	stats.LastSeconds = seconds
+	stats.MaxSeconds = max(stats.MaxSeconds, seconds)
+}
+
+// CycleStats returns the monitoring cycle statistics of every pair, by pair name
+func (me *MonitoringEngine) CycleStats() []CycleStats {
+	me.statsMu.Lock()
+	defer me.statsMu.Unlock()
+
+	result := make([]CycleStats, 0, len(me.cycleStats))
+	for _, stats := range me.cycleStats {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Pair < result[j].Pair })
+	return result
+}
+
+// CycleSlots returns the number of monitoring cycles running and the
+// max_concurrent_pairs limit
+func (me *MonitoringEngine) CycleSlots() (length, capacity int) {
+	return me.cycles.running()
+}
+
+// CheckStats returns the statistics of every check of every pair, by pair
+// and check name
+func (me *MonitoringEngine) CheckStats() []CheckStats {
+	me.statsMu.Lock()
+	defer me.statsMu.Unlock()
+
+	result := make([]CheckStats, 0, len(me.checkStats))
+	for _, stats := range me.checkStats {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pair != result[j].Pair {
+			return result[i].Pair < result[j].Pair
+		}
+		return result[i].Check < result[j].Check
+	})
+	return result
+}
@@ -0,0 +1,190 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// SemiSyncMetric represents the semi-synchronous replication state of a pair:
+// the source's view as semi-sync primary and the target's as semi-sync replica
+type SemiSyncMetric struct {
+	Timestamp     time.Time
+	SourceEnabled bool          // rpl_semi_sync_master_enabled
+	SourceActive  bool          // Rpl_semi_sync_master_status: commits wait for an acknowledgement
+	TargetEnabled bool          // rpl_semi_sync_slave_enabled
+	TargetActive  bool          // Rpl_semi_sync_slave_status
+	Timeout       time.Duration // rpl_semi_sync_master_timeout
+	Clients       int64         // semi-sync replicas connected to the source
+	AckedTx       int64         // commits acknowledged by a replica
+	AsyncTx       int64         // commits not acknowledged
+	Fallbacks     int64         // times the source fell back to asynchronous replication
+	TxWaits       int64         // commits that waited for an acknowledgement
+	AvgTxWait     time.Duration
+	WaitSessions  int64 // sessions waiting for an acknowledgement now
+
+	// Counter increases since the previous check; zero on the first check
+	// and after a restart of the source
+	NewAsyncTx   int64
+	NewFallbacks int64
+
+	Async    bool // replication is asynchronous right now
+	Problems []string
+	Error    error
+}
+
+// SemiSyncMonitor watches semi-synchronous replication from the source to the target
+type SemiSyncMonitor struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	timeout time.Duration
+
+	mu            sync.Mutex
+	lastAsyncTx   int64
+	lastFallbacks int64
+	measured      bool
+}
+
+// NewSemiSyncMonitor creates a new semi-sync monitor
+func NewSemiSyncMonitor(connMgr *database.ConnectionManager, timeout time.Duration) *SemiSyncMonitor {
+	return &SemiSyncMonitor{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		timeout: timeout,
+	}
+}
+
+// Measure reads the semi-sync variables and counters of both databases
+func (sm *SemiSyncMonitor) Measure(ctx context.Context) (*SemiSyncMetric, error) {
+	metric := &SemiSyncMetric{
+		Timestamp: sm.clock.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sm.timeout)
+	defer cancel()
+
+	sourceConn, err := sm.connMgr.GetSourceConnection()
+	if err != nil {
+		metric.Error = fmt.Errorf("source connection error: %w", err)
+		return metric, metric.Error
+	}
+
+	targetConn, err := sm.connMgr.GetTargetConnection()
+	if err != nil {
+		metric.Error = fmt.Errorf("target connection error: %w", err)
+		return metric, metric.Error
+	}
+
+	source, err := globalValues(ctx, sourceConn, "rpl_semi_sync_%")
+	if err != nil {
+		metric.Error = fmt.Errorf("source semi-sync status error: %w", err)
+		return metric, metric.Error
+	}
+	target, err := globalValues(ctx, targetConn, "rpl_semi_sync_%")
+	if err != nil {
+		metric.Error = fmt.Errorf("target semi-sync status error: %w", err)
+		return metric, metric.Error
+	}
+
+	metric.SourceEnabled = source["rpl_semi_sync_master_enabled"] == "ON"
+	metric.SourceActive = source["rpl_semi_sync_master_status"] == "ON"
+	metric.TargetEnabled = target["rpl_semi_sync_slave_enabled"] == "ON"
+	metric.TargetActive = target["rpl_semi_sync_slave_status"] == "ON"
+	metric.Timeout = time.Duration(intValue(source, "rpl_semi_sync_master_timeout")) * time.Millisecond
+	metric.Clients = intValue(source, "rpl_semi_sync_master_clients")
+	metric.AckedTx = intValue(source, "rpl_semi_sync_master_yes_tx")
+	metric.AsyncTx = intValue(source, "rpl_semi_sync_master_no_tx")
+	metric.Fallbacks = intValue(source, "rpl_semi_sync_master_no_times")
+	metric.TxWaits = intValue(source, "rpl_semi_sync_master_tx_waits")
+	metric.AvgTxWait = time.Duration(intValue(source, "rpl_semi_sync_master_tx_avg_wait_time")) * time.Microsecond
+	metric.WaitSessions = intValue(source, "rpl_semi_sync_master_wait_sessions")
+
+	sm.mu.Lock()
+	// Counters reset when the source restarts
+	if sm.measured && metric.AsyncTx >= sm.lastAsyncTx && metric.Fallbacks >= sm.lastFallbacks {
+		metric.NewAsyncTx = metric.AsyncTx - sm.lastAsyncTx
+		metric.NewFallbacks = metric.Fallbacks - sm.lastFallbacks
+	}
+	sm.lastAsyncTx, sm.lastFallbacks, sm.measured = metric.AsyncTx, metric.Fallbacks, true
+	sm.mu.Unlock()
+
+	metric.Problems = semiSyncProblems(metric)
+	return metric, nil
+}
+
+// semiSyncProblems lists why semi-sync does not protect every commit. A source
+// or target that is not semi-sync at all, or a source that fell back, means
+// replication is asynchronous right now.
+func semiSyncProblems(m *SemiSyncMetric) []string {
+	var problems []string
+	switch {
+	case !m.SourceEnabled:
+		problems = append(problems, "semi-sync is not enabled on the source (rpl_semi_sync_master_enabled=OFF)")
+	case !m.SourceActive:
+		problems = append(problems, fmt.Sprintf("source fell back to asynchronous replication (Rpl_semi_sync_master_status=OFF, %d semi-sync replica(s) connected)", m.Clients))
+	}
+	switch {
+	case !m.TargetEnabled:
+		problems = append(problems, "semi-sync is not enabled on the target (rpl_semi_sync_slave_enabled=OFF)")
+	case !m.TargetActive:
+		problems = append(problems, "target is not acknowledging commits (Rpl_semi_sync_slave_status=OFF)")
+	}
+	m.Async = len(problems) > 0
+
+	if m.NewFallbacks > 0 {
+		problems = append(problems, fmt.Sprintf("source fell back to asynchronous replication %d time(s) since the last check (timeout %s)", m.NewFallbacks, m.Timeout))
+	}
+	if m.NewAsyncTx > 0 {
+		problems = append(problems, fmt.Sprintf("%d commit(s) since the last check were not acknowledged by a replica", m.NewAsyncTx))
+	}
+	return problems
+}
+
+// globalValues returns the global variables and status variables whose name
+// matches a constant LIKE pattern, keyed by semiSyncName. Servers without the
+// semi-sync plugin return none.
+func globalValues(ctx context.Context, conn *sql.DB, pattern string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, kind := range []string{"VARIABLES", "STATUS"} {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SHOW GLOBAL %s LIKE '%s'", kind, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name, value string
+			if err := rows.Scan(&name, &value); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			values[semiSyncName(name)] = value
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// semiSyncName lowercases a semi-sync variable name and maps the names of the
+// MySQL 8.0.26+ plugins, e.g. rpl_semi_sync_source_enabled, to the original
+// master/slave names
+func semiSyncName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Replace(name, "rpl_semi_sync_source_", "rpl_semi_sync_master_", 1)
+	return strings.Replace(name, "rpl_semi_sync_replica_", "rpl_semi_sync_slave_", 1)
+}
+
+// intValue parses a numeric variable, or returns 0 when it is missing
+func intValue(values map[string]string, name string) int64 {
+	n, _ := strconv.ParseInt(values[name], 10, 64)
+	return n
+}
@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server flavors reported in ServerInfo
+const (
+	FlavorMariaDB = "MariaDB"
+	FlavorMySQL   = "MySQL"
+	FlavorAurora  = "Aurora MySQL"
+)
+
+// serverInfoVariables are the encryption and replication settings collected
+// from both databases; each server reports those it has. MariaDB encrypts
+// with innodb_encrypt_* and encrypt_binlog, MySQL with default_table_encryption
+// and the *_encrypt variables; aurora_version only exists on Aurora.
+const serverInfoVariables = "('innodb_encrypt_tables', 'innodb_encrypt_log', 'innodb_encrypt_temporary_tables', 'innodb_encryption_threads', " +
+	"'encrypt_binlog', 'encrypt_tmp_files', 'default_table_encryption', 'innodb_redo_log_encrypt', 'innodb_undo_log_encrypt', " +
+	"'binlog_encryption', 'binlog_format', 'log_bin', 'gtid_mode', 'enforce_gtid_consistency', 'gtid_strict_mode', 'aurora_version')"
+
+// ServerInfo is the version, flavor and encryption and replication settings
+// of a database
+type ServerInfo struct {
+	Version   string            `json:"version,omitempty"`
+	Flavor    string            `json:"flavor,omitempty"`    // MariaDB, MySQL or Aurora MySQL
+	Variables map[string]string `json:"variables,omitempty"` // lower-case names; absent when the server has no such variable
+	Error     string            `json:"error,omitempty"`
+}
+
+// PairInfo is the server metadata of both databases of a pair, with the
+// settings that look wrong for an encryption migration
+type PairInfo struct {
+	Pair        string     `json:"pair"`
+	CollectedAt time.Time  `json:"collected_at"`
+	Source      ServerInfo `json:"source"`
+	Target      ServerInfo `json:"target"`
+	Warnings    []string   `json:"warnings"`
+}
+
+// PairInfo reads the server metadata of both databases of a pair
+func (me *MonitoringEngine) PairInfo(ctx context.Context, pairName string) (*PairInfo, error) {
+	pm := me.findPairMonitor(pairName)
+	if pm == nil {
+		return nil, fmt.Errorf("database pair '%s' not found", pairName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, me.config.Timeouts.Connect)
+	defer cancel()
+
+	info := &PairInfo{Pair: pairName, CollectedAt: me.clock.Now()}
+	if conn, err := pm.connMgr.GetSourceConnection(); err != nil {
+		info.Source.Error = err.Error()
+	} else {
+		info.Source = readServerInfo(ctx, conn)
+	}
+	if conn, err := pm.connMgr.GetTargetConnection(); err != nil {
+		info.Target.Error = err.Error()
+	} else {
+		info.Target = readServerInfo(ctx, conn)
+	}
+	info.Warnings = serverInfoWarnings(info)
+	return info, nil
+}
+
+// readServerInfo reads the version and settings of a database
+func readServerInfo(ctx context.Context, db *sql.DB) ServerInfo {
+	var info ServerInfo
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&info.Version); err != nil {
+		info.Error = fmt.Sprintf("failed to read the version: %v", err)
+		return info
+	}
+
+	rows, err := db.QueryContext(ctx, "SHOW GLOBAL VARIABLES WHERE Variable_name IN "+serverInfoVariables)
+	if err != nil {
+		info.Error = fmt.Sprintf("failed to query variables: %v", err)
+		return info
+	}
+	defer rows.Close()
+	info.Variables = make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			info.Error = fmt.Sprintf("failed to scan variables: %v", err)
+			return info
+		}
+		info.Variables[strings.ToLower(name)] = value
+	}
+	if err := rows.Err(); err != nil {
+		info.Error = fmt.Sprintf("failed to read variables: %v", err)
+		return info
+	}
+
+	_, aurora := info.Variables["aurora_version"]
+	switch {
+	case !parseFlavor(info.Version).mysql:
+		info.Flavor = FlavorMariaDB
+	case aurora:
+		info.Flavor = FlavorAurora
+	default:
+		info.Flavor = FlavorMySQL
+	}
+	return info
+}
+
+// serverInfoWarnings lists the settings of a pair that commonly break an
+// encryption migration: a target that does not encrypt, and replication
+// settings that differ between the databases
+func serverInfoWarnings(info *PairInfo) []string {
+	warnings := []string{}
+	target := info.Target.Variables
+	if info.Target.Error == "" {
+		off := func(name string) bool {
+			value, ok := target[name]
+			return ok && (strings.EqualFold(value, "OFF") || value == "0")
+		}
+		if info.Target.Flavor == FlavorMariaDB {
+			if off("innodb_encrypt_tables") {
+				warnings = append(warnings, "innodb_encrypt_tables is OFF on the target: tables are not encrypted unless created with ENCRYPTED=YES")
+			}
+			if off("innodb_encrypt_log") {
+				warnings = append(warnings, "innodb_encrypt_log is OFF on the target: the redo log is not encrypted")
+			}
+			if threads, err := strconv.Atoi(target["innodb_encryption_threads"]); err == nil && threads == 0 && !off("innodb_encrypt_tables") {
+				warnings = append(warnings, "innodb_encryption_threads is 0 on the target: existing tablespaces are not encrypted in the background")
+			}
+			if off("encrypt_binlog") && !off("log_bin") {
+				warnings = append(warnings, "encrypt_binlog is OFF on the target: its binary log is not encrypted")
+			}
+		} else {
+			if off("default_table_encryption") {
+				warnings = append(warnings, "default_table_encryption is OFF on the target: new tables are not encrypted")
+			}
+			if off("innodb_redo_log_encrypt") {
+				warnings = append(warnings, "innodb_redo_log_encrypt is OFF on the target: the redo log is not encrypted")
+			}
+			if off("binlog_encryption") && !off("log_bin") {
+				warnings = append(warnings, "binlog_encryption is OFF on the target: its binary log is not encrypted")
+			}
+		}
+	}
+
+	if info.Source.Error == "" && info.Target.Error == "" {
+		for _, name := range []string{"binlog_format", "gtid_mode"} {
+			source, inSource := info.Source.Variables[name]
+			target, inTarget := target[name]
+			if inSource && inTarget && !strings.EqualFold(source, target) {
+				warnings = append(warnings, fmt.Sprintf("%s differs: %s on the source, %s on the target", name, source, target))
+			}
+		}
+	}
+	return warnings
+}
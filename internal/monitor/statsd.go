@@ -0,0 +1,88 @@
+package monitor
+
+import "mariadb-encryption-monitor/internal/statsd"
+
+// SetStatsD sets the client check results are pushed to. It must be called
+// before Start.
+func (me *MonitoringEngine) SetStatsD(client *statsd.Client) {
+	me.statsd = client
+}
+
+// emitConnection pushes the connection status of both databases of a pair
+func (me *MonitoringEngine) emitConnection(pairName string, sourceOK, targetOK bool) {
+	pair := statsd.Tag{Key: "pair", Value: pairName}
+	me.statsd.Gauge("connection.up", boolGauge(sourceOK), pair, statsd.Tag{Key: "database", Value: "source"})
+	me.statsd.Gauge("connection.up", boolGauge(targetOK), pair, statsd.Tag{Key: "database", Value: "target"})
+}
+
+// emitReplicaLag pushes a lag sample. The lag gauge is only meaningful while
+// replication runs; replica_lag.healthy reports whether it does.
+func (me *MonitoringEngine) emitReplicaLag(pairName string, metric *ReplicaLagMetric) {
+	pair := statsd.Tag{Key: "pair", Value: pairName}
+	me.statsd.Gauge("replica_lag.healthy", boolGauge(metric.Status == "ok"), pair)
+	if metric.Status == "ok" {
+		me.statsd.Gauge("replica_lag.seconds", metric.LagSeconds, pair)
+	}
+	if b := metric.Backlog; b != nil {
+		if b.IOBytes != nil {
+			me.statsd.Gauge("replica_lag.io_backlog_bytes", float64(*b.IOBytes), pair)
+		}
+		if b.SQLBytes != nil {
+			me.statsd.Gauge("replica_lag.sql_backlog_bytes", float64(*b.SQLBytes), pair)
+		}
+	}
+	for _, hop := range metric.Hops {
+		if hop.Status == "ok" {
+			me.statsd.Gauge("replica_lag.hop_seconds", hop.LagSeconds, pair, statsd.Tag{Key: "hop", Value: hop.Name})
+		}
+	}
+}
+
+// emitBinlogRate pushes how fast the source writes its binary log
+func (me *MonitoringEngine) emitBinlogRate(pairName string, rate *BinlogRate) {
+	pair := statsd.Tag{Key: "pair", Value: pairName}
+	if rate.BytesPerSecond != nil {
+		me.statsd.Gauge("binlog.bytes_per_second", *rate.BytesPerSecond, pair)
+	}
+	if rate.EventsPerSecond != nil {
+		me.statsd.Gauge("binlog.transactions_per_second", *rate.EventsPerSecond, pair)
+	}
+}
+
+// emitSemiSync pushes whether semi-sync protects commits and counts the
+// commits that were not acknowledged
+func (me *MonitoringEngine) emitSemiSync(pairName string, metric *SemiSyncMetric) {
+	if metric.Error != nil {
+		return
+	}
+	pair := statsd.Tag{Key: "pair", Value: pairName}
+	me.statsd.Gauge("semi_sync.active", boolGauge(!metric.Async), pair)
+	if metric.NewAsyncTx > 0 {
+		me.statsd.Count("semi_sync.async_tx", metric.NewAsyncTx, pair)
+	}
+}
+
+// emitChecksum counts a checksum result by outcome
+func (me *MonitoringEngine) emitChecksum(pairName string, result *ChecksumResult) {
+	outcome := "match"
+	switch {
+	case result.Error != nil:
+		outcome = "error"
+	case result.Skipped:
+		outcome = "skipped"
+	case !result.Match:
+		outcome = "mismatch"
+	}
+	me.statsd.Count("checksum.result", 1,
+		statsd.Tag{Key: "pair", Value: pairName},
+		statsd.Tag{Key: "table", Value: result.TableName},
+		statsd.Tag{Key: "result", Value: outcome})
+}
+
+// boolGauge maps a status to 1 or 0
+func boolGauge(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Check strategies suggested for a table
+const (
+	StrategyChecksum    = "checksum"    // checksum and exact row count
+	StrategyCount       = "count"       // exact row count; too large to checksum every cycle
+	StrategyApproximate = "approximate" // approximate row counts between exact ones
+)
+
+// TableSuggestion describes a source table, ranked for monitoring
+type TableSuggestion struct {
+	Table           string   `json:"table"`
+	EstimatedRows   int64    `json:"estimated_rows"`
+	EstimatedBytes  int64    `json:"estimated_bytes"`
+	WritesPerSecond *float64 `json:"writes_per_second"` // nil without write statistics
+	PrimaryKey      string   `json:"primary_key"`       // "integer", "other" or "none"
+	Strategy        string   `json:"strategy"`
+	Suggested       bool     `json:"suggested"`
+	Notes           []string `json:"notes,omitempty"`
+}
+
+// SuggestOptions tunes SuggestTables
+type SuggestOptions struct {
+	Sample             time.Duration // how long write counters are sampled; 0 skips write rates
+	ChecksumMaxRows    int64         // larger tables are counted, not checksummed
+	ApproximateMinRows int64         // larger tables get approximate counts
+	Limit              int           // number of tables suggested
+}
+
+// SuggestTables ranks the tables of a database by write rate, then size, and
+// suggests the busiest ones with a check strategy each. It also returns where
+// write rates were read from, or "" when no statistics were available.
+func SuggestTables(ctx context.Context, db *sql.DB, opts SuggestOptions) ([]TableSuggestion, string, error) {
+	tables, err := describeTables(ctx, db)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var statsSource string
+	if opts.Sample > 0 {
+		var rates map[string]float64
+		rates, statsSource, err = sampleWriteRates(ctx, db, opts.Sample)
+		if err != nil {
+			return nil, "", err
+		}
+		if statsSource != "" {
+			for i := range tables {
+				rate := rates[tables[i].Table]
+				tables[i].WritesPerSecond = &rate
+			}
+		}
+	}
+
+	writes := func(t TableSuggestion) float64 {
+		if t.WritesPerSecond == nil {
+			return 0
+		}
+		return *t.WritesPerSecond
+	}
+	sort.SliceStable(tables, func(i, j int) bool {
+		if writes(tables[i]) != writes(tables[j]) {
+			return writes(tables[i]) > writes(tables[j])
+		}
+		return tables[i].EstimatedBytes > tables[j].EstimatedBytes
+	})
+
+	suggested := 0
+	for i := range tables {
+		t := &tables[i]
+		switch {
+		case t.EstimatedRows > opts.ApproximateMinRows:
+			t.Strategy = StrategyApproximate
+		case t.EstimatedRows > opts.ChecksumMaxRows:
+			t.Strategy = StrategyCount
+		default:
+			t.Strategy = StrategyChecksum
+		}
+
+		if t.PrimaryKey == "none" {
+			t.Notes = append(t.Notes, "no primary key: mismatches cannot be located with diff")
+		}
+
+		if t.EstimatedRows == 0 && writes(*t) == 0 {
+			t.Notes = append(t.Notes, "empty and idle")
+			continue
+		}
+		if suggested < opts.Limit {
+			t.Suggested = true
+			suggested++
+		}
+	}
+	return tables, statsSource, nil
+}
+
+// describeTables returns the size estimates and primary key kind of every
+// base table of the current database
+func describeTables(ctx context.Context, db *sql.DB) ([]TableSuggestion, error) {
+	rows, err := db.QueryContext(ctx, `SELECT TABLE_NAME, COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0)
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var tables []TableSuggestion
+	for rows.Next() {
+		t := TableSuggestion{PrimaryKey: "none"}
+		if err := rows.Scan(&t.Table, &t.EstimatedRows, &t.EstimatedBytes); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	pkRows, err := db.QueryContext(ctx, `SELECT k.TABLE_NAME, COUNT(*), MAX(c.DATA_TYPE)
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.COLUMNS c
+		  ON c.TABLE_SCHEMA = k.TABLE_SCHEMA AND c.TABLE_NAME = k.TABLE_NAME AND c.COLUMN_NAME = k.COLUMN_NAME
+		WHERE k.TABLE_SCHEMA = DATABASE() AND k.CONSTRAINT_NAME = 'PRIMARY'
+		GROUP BY k.TABLE_NAME`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary keys: %w", err)
+	}
+	primaryKeys := make(map[string]string)
+	for pkRows.Next() {
+		var table, dataType string
+		var columns int
+		if err := pkRows.Scan(&table, &columns, &dataType); err != nil {
+			pkRows.Close()
+			return nil, fmt.Errorf("failed to scan primary key: %w", err)
+		}
+		primaryKeys[table] = "other"
+		if columns == 1 && isIntegerType(dataType) {
+			primaryKeys[table] = "integer"
+		}
+	}
+	pkRows.Close()
+
+	for i := range tables {
+		if kind, ok := primaryKeys[tables[i].Table]; ok {
+			tables[i].PrimaryKey = kind
+		}
+	}
+	return tables, nil
+}
+
+// writeCounterQueries read cumulative rows written per table, in order of
+// preference. TABLE_STATISTICS needs userstat=1; the performance schema
+// needs performance_schema=ON.
+var writeCounterQueries = []struct{ source, query string }{
+	{"information_schema.TABLE_STATISTICS", "SELECT TABLE_NAME, ROWS_CHANGED FROM information_schema.TABLE_STATISTICS WHERE TABLE_SCHEMA = DATABASE()"},
+	{"performance_schema.table_io_waits_summary_by_table", "SELECT OBJECT_NAME, COUNT_WRITE FROM performance_schema.table_io_waits_summary_by_table WHERE OBJECT_SCHEMA = DATABASE()"},
+}
+
+// sampleWriteRates reads write counters twice, sample apart, and returns rows
+// written per second by table and the statistics used
+func sampleWriteRates(ctx context.Context, db *sql.DB, sample time.Duration) (map[string]float64, string, error) {
+	for _, q := range writeCounterQueries {
+		before, err := readWriteCounters(ctx, db, q.query)
+		if err != nil || len(before) == 0 {
+			continue // statistics not enabled
+		}
+		start := time.Now()
+		select {
+		case <-time.After(sample):
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+		after, err := readWriteCounters(ctx, db, q.query)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", q.source, err)
+		}
+
+		elapsed := time.Since(start).Seconds()
+		rates := make(map[string]float64, len(after))
+		for table, count := range after {
+			rates[table] = float64(count-before[table]) / elapsed
+		}
+		return rates, q.source, nil
+	}
+	return nil, "", nil
+}
+
+// readWriteCounters returns the cumulative write counter of each table
+func readWriteCounters(ctx context.Context, db *sql.DB, query string) (map[string]int64, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counters := make(map[string]int64)
+	for rows.Next() {
+		var table string
+		var count int64
+		if err := rows.Scan(&table, &count); err != nil {
+			return nil, err
+		}
+		counters[table] = count
+	}
+	return counters, rows.Err()
+}
@@ -0,0 +1,358 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// defaultRowFormat stands for a table without a ROW_FORMAT option, which
+// gets the server's innodb_default_row_format
+const defaultRowFormat = "DEFAULT"
+
+// tableOption matches one option of a table definition; MariaDB quotes the
+// options storage engines define, such as `ENCRYPTED`=YES. Quoted values are
+// matched whole, so a COMMENT cannot be mistaken for options.
+var tableOption = regexp.MustCompile(`(?i)` + "`?" + `\b([A-Z_]+)` + "`?" + `\s*=\s*('(?:[^'\\]|\\.|'')*'|[^\s,]+)`)
+
+// TableDefinition is the encryption and row format of a monitored table as
+// SHOW CREATE TABLE declares them on both databases
+type TableDefinition struct {
+	Table       string
+	TargetTable string
+
+	TargetEncrypted bool   // declared, or encrypted by the server's default
+	TargetKeyID     int    // ENCRYPTION_KEY_ID on MariaDB; 0 on MySQL
+	TargetRowFormat string // DEFAULT when not declared
+	SourceEncrypted bool
+	SourceRowFormat string // "" when the source was not checked
+
+	Problems []string
+	Error    error // the definition could not be read
+}
+
+// TableDefinitions is the outcome of checking the table definitions of a
+// pair
+type TableDefinitions struct {
+	Timestamp     time.Time
+	Tables        []TableDefinition
+	SourceChecked bool // the source was reachable; source fields are empty otherwise
+	Error         error
+}
+
+// Unencrypted returns the tables not encrypted on the target
+func (d *TableDefinitions) Unencrypted() int {
+	n := 0
+	for _, table := range d.Tables {
+		if table.Error == nil && !table.TargetEncrypted {
+			n++
+		}
+	}
+	return n
+}
+
+// tableOptions are the options of a table definition the check reads
+type tableOptions struct {
+	encryption string // ENCRYPTED (MariaDB) or ENCRYPTION (MySQL) value, upper case; "" when not declared
+	keyID      int    // ENCRYPTION_KEY_ID; 0 when not declared
+	rowFormat  string // upper case; "" when not declared
+}
+
+// encryptionDefaults is how a server encrypts tables that do not declare it
+type encryptionDefaults struct {
+	encrypt bool // innodb_encrypt_tables is ON or FORCE (MariaDB)
+	keyID   int  // innodb_default_encryption_key_id (MariaDB)
+}
+
+// TableDefinitionChecker checks that the definitions of the monitored tables
+// encrypt them on the target, and keep the source's row format
+type TableDefinitionChecker struct {
+	connMgr  *database.ConnectionManager
+	clock    clock.Clock
+	config   config.TableDefinitionsConfig
+	mappings config.TableMappings
+	views    []string // monitored tables without a tablespace
+	timeout  time.Duration
+	source   flavorCache
+	target   flavorCache
+
+	mu      sync.Mutex
+	lastRun time.Time
+}
+
+// NewTableDefinitionChecker creates a new table definition checker
+func NewTableDefinitionChecker(connMgr *database.ConnectionManager, cfg config.TableDefinitionsConfig, mappings config.TableMappings, views []string, timeout time.Duration) *TableDefinitionChecker {
+	return &TableDefinitionChecker{
+		connMgr:  connMgr,
+		clock:    clock.Real,
+		config:   cfg,
+		mappings: mappings,
+		views:    views,
+		timeout:  timeout,
+	}
+}
+
+// Due reports whether the check interval has passed since the last check
+func (tc *TableDefinitionChecker) Due() bool {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.clock.Since(tc.lastRun) >= tc.config.Interval
+}
+
+// Check reads the definitions of the tables on the target, and on the
+// source when withSource is set, e.g. unless it is down
+func (tc *TableDefinitionChecker) Check(ctx context.Context, tables []string, withSource bool) (*TableDefinitions, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.lastRun = tc.clock.Now()
+
+	result := &TableDefinitions{Timestamp: tc.clock.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, tc.timeout)
+	defer cancel()
+
+	targetConn, err := tc.connMgr.GetTargetConnection()
+	if err != nil {
+		result.Error = fmt.Errorf("target connection error: %w", err)
+		return result, result.Error
+	}
+	targetDefaults, err := readEncryptionDefaults(ctx, targetConn, tc.target.get(ctx, targetConn))
+	if err != nil {
+		result.Error = fmt.Errorf("target: %w", err)
+		return result, result.Error
+	}
+
+	var sourceConn *sql.DB
+	var sourceDefaults encryptionDefaults
+	if withSource {
+		if sourceConn, err = tc.connMgr.GetSourceConnection(); err != nil {
+			result.Error = fmt.Errorf("source connection error: %w", err)
+			return result, result.Error
+		}
+		if sourceDefaults, err = readEncryptionDefaults(ctx, sourceConn, tc.source.get(ctx, sourceConn)); err != nil {
+			result.Error = fmt.Errorf("source: %w", err)
+			return result, result.Error
+		}
+		result.SourceChecked = true
+	}
+
+	for _, table := range tables {
+		if slices.Contains(tc.views, table) {
+			continue
+		}
+		definition := TableDefinition{Table: table, TargetTable: tc.mappings.Target(table)}
+
+		target, err := readTableOptions(ctx, targetConn, definition.TargetTable)
+		if err != nil {
+			definition.Error = fmt.Errorf("target: %w", err)
+			result.Tables = append(result.Tables, definition)
+			continue
+		}
+		definition.TargetEncrypted, definition.TargetKeyID = target.encrypted(targetDefaults)
+		definition.TargetRowFormat = target.rowFormatOrDefault()
+
+		if sourceConn != nil {
+			source, err := readTableOptions(ctx, sourceConn, table)
+			if err != nil {
+				definition.Error = fmt.Errorf("source: %w", err)
+				result.Tables = append(result.Tables, definition)
+				continue
+			}
+			definition.SourceEncrypted, _ = source.encrypted(sourceDefaults)
+			definition.SourceRowFormat = source.rowFormatOrDefault()
+		}
+
+		definition.Problems = tc.problems(definition, target)
+		result.Tables = append(result.Tables, definition)
+	}
+	return result, nil
+}
+
+// problems lists how a table's definitions differ from what the migration
+// expects
+func (tc *TableDefinitionChecker) problems(definition TableDefinition, target tableOptions) []string {
+	var problems []string
+	switch {
+	case definition.TargetEncrypted:
+		if tc.config.KeyID > 0 && definition.TargetKeyID > 0 && definition.TargetKeyID != tc.config.KeyID {
+			problems = append(problems, fmt.Sprintf("uses ENCRYPTION_KEY_ID=%d on the target, expected %d", definition.TargetKeyID, tc.config.KeyID))
+		}
+	case target.encryption != "":
+		problems = append(problems, "not encrypted on the target: its definition declares "+target.declaredEncryption())
+	default:
+		problems = append(problems, "not encrypted on the target: its definition has no ENCRYPTED=YES or ENCRYPTION='Y' and the server does not encrypt tables by default")
+	}
+
+	if tc.config.SourceUnencrypted && definition.SourceEncrypted {
+		problems = append(problems, "encrypted on the source, which stays unencrypted during the transition")
+	}
+
+	switch {
+	case tc.config.RowFormat != "":
+		if definition.TargetRowFormat != tc.config.RowFormat {
+			problems = append(problems, fmt.Sprintf("ROW_FORMAT=%s on the target, expected %s", definition.TargetRowFormat, tc.config.RowFormat))
+		}
+	case definition.SourceRowFormat != "" && definition.SourceRowFormat != definition.TargetRowFormat:
+		problems = append(problems, fmt.Sprintf("ROW_FORMAT=%s on the source, %s on the target", definition.SourceRowFormat, definition.TargetRowFormat))
+	}
+	return problems
+}
+
+// encrypted reports whether a table is encrypted and with which key: as its
+// definition declares, or by the server's default when it declares nothing
+func (o tableOptions) encrypted(defaults encryptionDefaults) (bool, int) {
+	var encrypted bool
+	switch o.encryption {
+	case "YES", "Y":
+		encrypted = true
+	case "NO", "N":
+		encrypted = false
+	default:
+		encrypted = defaults.encrypt
+	}
+	if !encrypted {
+		return false, 0
+	}
+	if o.keyID > 0 {
+		return true, o.keyID
+	}
+	return true, defaults.keyID
+}
+
+// declaredEncryption returns the encryption option as declared
+func (o tableOptions) declaredEncryption() string {
+	if o.encryption == "N" || o.encryption == "Y" {
+		return "ENCRYPTION='" + o.encryption + "'"
+	}
+	return "ENCRYPTED=" + o.encryption
+}
+
+// rowFormatOrDefault returns the declared row format, or DEFAULT
+func (o tableOptions) rowFormatOrDefault() string {
+	if o.rowFormat == "" {
+		return defaultRowFormat
+	}
+	return o.rowFormat
+}
+
+// readEncryptionDefaults reads how a MariaDB server encrypts tables without
+// an ENCRYPTED option. MySQL writes its default_table_encryption into the
+// definitions of new tables, so it has no defaults to apply.
+func readEncryptionDefaults(ctx context.Context, db *sql.DB, flavor serverFlavor) (encryptionDefaults, error) {
+	var defaults encryptionDefaults
+	if flavor.mysql {
+		return defaults, nil
+	}
+	rows, err := db.QueryContext(ctx, "SHOW GLOBAL VARIABLES WHERE Variable_name IN ('innodb_encrypt_tables', 'innodb_default_encryption_key_id')")
+	if err != nil {
+		return defaults, fmt.Errorf("failed to query encryption variables: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return defaults, fmt.Errorf("failed to scan encryption variables: %w", err)
+		}
+		switch strings.ToLower(name) {
+		case "innodb_encrypt_tables":
+			defaults.encrypt = strings.EqualFold(value, "ON") || strings.EqualFold(value, "FORCE") || value == "1"
+		case "innodb_default_encryption_key_id":
+			defaults.keyID, _ = strconv.Atoi(value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return defaults, fmt.Errorf("failed to read encryption variables: %w", err)
+	}
+	return defaults, nil
+}
+
+// readTableOptions reads the options of a table's definition
+func readTableOptions(ctx context.Context, db *sql.DB, table string) (tableOptions, error) {
+	rows, err := db.QueryContext(ctx, "SHOW CREATE TABLE "+quoteTable(table))
+	if err != nil {
+		return tableOptions{}, fmt.Errorf("failed to read the definition of %s: %w", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return tableOptions{}, fmt.Errorf("failed to get columns: %w", err)
+	}
+	// Views are answered with a View and a Create View column
+	if len(columns) < 2 || !strings.EqualFold(columns[1], "Create Table") {
+		return tableOptions{}, fmt.Errorf("%s is not a table", table)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return tableOptions{}, fmt.Errorf("failed to read the definition of %s: %w", table, err)
+		}
+		return tableOptions{}, fmt.Errorf("no definition returned for %s", table)
+	}
+	values := make([]any, len(columns))
+	var name, definition string
+	values[0], values[1] = &name, &definition
+	for i := 2; i < len(values); i++ {
+		values[i] = new(sql.RawBytes)
+	}
+	if err := rows.Scan(values...); err != nil {
+		return tableOptions{}, fmt.Errorf("failed to scan the definition of %s: %w", table, err)
+	}
+	return parseTableOptions(definition), nil
+}
+
+// parseTableOptions reads the table options of a CREATE TABLE statement:
+// those after the parenthesis closing its column list, up to its partitions
+func parseTableOptions(definition string) tableOptions {
+	var opts tableOptions
+	options := definition
+	depth := 0
+	var quote rune
+	escaped := false
+scan:
+	for i, r := range definition {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\' && quote != '`':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth == 0 {
+				options = definition[i+1:]
+				break scan
+			}
+		}
+	}
+	if i := strings.Index(strings.ToUpper(options), "PARTITION BY"); i >= 0 {
+		options = options[:i]
+	}
+
+	for _, match := range tableOption.FindAllStringSubmatch(options, -1) {
+		value := strings.ToUpper(strings.Trim(match[2], "'"))
+		switch strings.ToUpper(match[1]) {
+		case "ENCRYPTED", "ENCRYPTION":
+			opts.encryption = value
+		case "ENCRYPTION_KEY_ID":
+			opts.keyID, _ = strconv.Atoi(value)
+		case "ROW_FORMAT":
+			opts.rowFormat = value
+		}
+	}
+	return opts
+}
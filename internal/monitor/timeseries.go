@@ -0,0 +1,114 @@
+package monitor
+
+import "mariadb-encryption-monitor/internal/timeseries"
+
+// SetTimeSeries sets the sink measurements are written to. It must be called
+// before Start.
+func (me *MonitoringEngine) SetTimeSeries(sink *timeseries.Sink) {
+	me.timeseries = sink
+}
+
+// recordReplicaLag writes a lag sample, and the lag of each hop of a chain.
+// Rehearsal faults are not written, so that reports show the real migration.
+func (me *MonitoringEngine) recordReplicaLag(pairName string, metric *ReplicaLagMetric) {
+	if metric.Rehearsal != "" {
+		return
+	}
+	fields := map[string]any{
+		"status":      metric.Status,
+		"io_running":  metric.IORunning,
+		"sql_running": metric.SQLRunning,
+	}
+	if metric.Status == "ok" {
+		fields["seconds"] = metric.LagSeconds
+	}
+	if b := metric.Backlog; b != nil {
+		if b.IOBytes != nil {
+			fields["io_backlog_bytes"] = *b.IOBytes
+		}
+		if b.SQLBytes != nil {
+			fields["sql_backlog_bytes"] = *b.SQLBytes
+		}
+	}
+	if metric.LastIOErrno != 0 {
+		fields["last_io_errno"] = metric.LastIOErrno
+	}
+	if metric.LastSQLErrno != 0 {
+		fields["last_sql_errno"] = metric.LastSQLErrno
+	}
+	if metric.Error != nil {
+		fields["error"] = metric.Error.Error()
+	}
+	me.timeseries.Write(timeseries.Point{
+		Measurement: "replica_lag",
+		Tags:        map[string]string{"pair": pairName},
+		Fields:      fields,
+		Time:        metric.Timestamp,
+	})
+
+	for _, hop := range metric.Hops {
+		fields := map[string]any{"status": hop.Status}
+		if hop.Status == "ok" {
+			fields["seconds"] = hop.LagSeconds
+		}
+		me.timeseries.Write(timeseries.Point{
+			Measurement: "replica_lag_hop",
+			Tags:        map[string]string{"pair": pairName, "hop": hop.Name},
+			Fields:      fields,
+			Time:        metric.Timestamp,
+		})
+	}
+}
+
+// recordChecksum writes a checksum result
+func (me *MonitoringEngine) recordChecksum(pairName string, result *ChecksumResult) {
+	if result.Rehearsal != "" {
+		return
+	}
+	fields := map[string]any{
+		"match":           result.Match,
+		"skipped":         result.Skipped,
+		"source_checksum": result.SourceChecksum,
+		"target_checksum": result.TargetChecksum,
+		"estimated_rows":  result.EstimatedRows,
+	}
+	if result.Error != nil {
+		fields["error"] = result.Error.Error()
+	}
+	me.timeseries.Write(timeseries.Point{
+		Measurement: "checksum",
+		Tags:        map[string]string{"pair": pairName, "table": result.TableName},
+		Fields:      fields,
+		Time:        result.Timestamp,
+	})
+}
+
+// recordConsistency writes a row count comparison
+func (me *MonitoringEngine) recordConsistency(pairName string, result *ConsistencyResult) {
+	if result.Rehearsal != "" {
+		return
+	}
+	fields := map[string]any{
+		"consistent":  result.Consistent,
+		"approximate": result.Approximate,
+		"window_only": result.WindowOnly,
+	}
+	if !result.WindowOnly {
+		fields["source_rows"] = result.SourceRowCount
+		fields["target_rows"] = result.TargetRowCount
+		fields["row_difference"] = result.SourceRowCount - result.TargetRowCount
+	}
+	if w := result.Window; w != nil {
+		fields["window_source_rows"] = w.SourceCount
+		fields["window_target_rows"] = w.TargetCount
+	}
+	if result.Error != nil {
+		fields["error"] = result.Error.Error()
+	}
+	me.timeseries.Write(timeseries.Point{
+		Measurement: "consistency",
+		Tags:        map[string]string{"pair": pairName, "table": result.TableName},
+		Fields:      fields,
+		Time:        result.Timestamp,
+	})
+}
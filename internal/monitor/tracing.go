@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"mariadb-encryption-monitor/internal/statsd"
+	"mariadb-encryption-monitor/internal/tracing"
+)
+
+// startCycle begins the span of one monitoring cycle of a pair. The returned
+// function ends it and reports the cycle duration.
+func (me *MonitoringEngine) startCycle(ctx context.Context, pairName string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, "monitoring cycle", tracing.String("pair", pairName))
+	start := time.Now()
+
+	return ctx, func() {
+		span.End()
+		duration := time.Since(start)
+		me.statsd.Timing("cycle.duration", duration, statsd.Tag{Key: "pair", Value: pairName})
+		tracing.RecordDuration("monitor.cycle.duration", duration, tracing.String("pair", pairName))
+	}
+}
+
+// startCheck begins the span of one check within a cycle. The returned
+// function ends it, marking it failed on error, and reports the check duration.
+func (me *MonitoringEngine) startCheck(ctx context.Context, pairName, check string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, "check "+check, tracing.String("pair", pairName), tracing.String("check", check))
+	start := time.Now()
+
+	return ctx, func(err error) {
+		span.RecordError(err)
+		span.End()
+		duration := time.Since(start)
+		me.recordCheck(pairName, check, duration, err)
+		me.statsd.Timing("check.duration", duration,
+			statsd.Tag{Key: "pair", Value: pairName},
+			statsd.Tag{Key: "check", Value: check})
+		tracing.RecordDuration("monitor.check.duration", duration,
+			tracing.String("pair", pairName),
+			tracing.String("check", check))
+	}
+}
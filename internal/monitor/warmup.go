@@ -0,0 +1,259 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// WarmupQueryResult is the plan check of one representative query
+type WarmupQueryResult struct {
+	Name           string
+	Keys           []string // indexes used by the plan
+	FullScanTables []string // tables read with a full table scan
+	Ready          bool
+	Problem        string
+}
+
+// WarmupResult represents the warm-up state of the target
+type WarmupResult struct {
+	Timestamp             time.Time
+	BufferPoolHitRate     float64 // percent
+	HitRateSinceStartup   bool    // first check or no reads since the previous one, so the rate is cumulative
+	BufferPoolFillPercent float64 // share of buffer pool pages holding data
+	Queries               []WarmupQueryResult
+	Ready                 bool
+	Problems              []string
+	Error                 error
+}
+
+// WarmupChecker verifies that the target is ready for production read
+// traffic: a warm buffer pool and index-backed plans for key queries
+type WarmupChecker struct {
+	connMgr *database.ConnectionManager
+	clock   clock.Clock
+	config  config.WarmupConfig
+	timeout time.Duration
+	flavors flavorCache
+
+	mu           sync.Mutex
+	lastRun      time.Time
+	lastReads    float64 // Innodb_buffer_pool_reads at the previous check
+	lastRequests float64 // Innodb_buffer_pool_read_requests at the previous check
+}
+
+// NewWarmupChecker creates a new warm-up checker
+func NewWarmupChecker(connMgr *database.ConnectionManager, cfg config.WarmupConfig, timeout time.Duration) *WarmupChecker {
+	return &WarmupChecker{
+		connMgr: connMgr,
+		clock:   clock.Real,
+		config:  cfg,
+		timeout: timeout,
+	}
+}
+
+// Due reports whether the check interval has passed since the last check
+func (wc *WarmupChecker) Due() bool {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.clock.Since(wc.lastRun) >= wc.config.Interval
+}
+
+// Check measures the buffer pool hit rate and explains the configured queries on the target
+func (wc *WarmupChecker) Check(ctx context.Context) (*WarmupResult, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.lastRun = wc.clock.Now()
+
+	result := &WarmupResult{
+		Timestamp: wc.clock.Now(),
+		Queries:   make([]WarmupQueryResult, 0, len(wc.config.Queries)),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wc.timeout)
+	defer cancel()
+
+	targetConn, err := wc.connMgr.GetTargetConnection()
+	if err != nil {
+		result.Error = fmt.Errorf("target connection error: %w", err)
+		return result, result.Error
+	}
+
+	if err := wc.checkBufferPool(ctx, targetConn, result); err != nil {
+		result.Error = fmt.Errorf("buffer pool status error: %w", err)
+		return result, result.Error
+	}
+	if result.BufferPoolHitRate < wc.config.MinBufferPoolHitRate {
+		result.Problems = append(result.Problems, fmt.Sprintf("buffer pool hit rate %.2f%% is below %.2f%%",
+			result.BufferPoolHitRate, wc.config.MinBufferPoolHitRate))
+	}
+
+	for _, query := range wc.config.Queries {
+		queryResult := wc.checkQuery(ctx, targetConn, query)
+		if !queryResult.Ready {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: %s", query.Name, queryResult.Problem))
+		}
+		result.Queries = append(result.Queries, queryResult)
+	}
+
+	result.Ready = len(result.Problems) == 0
+	return result, nil
+}
+
+// checkBufferPool computes the hit rate since the previous check, falling
+// back to the rate since startup when there were no reads in between
+func (wc *WarmupChecker) checkBufferPool(ctx context.Context, conn *sql.DB, result *WarmupResult) error {
+	rows, err := conn.QueryContext(ctx, `SHOW GLOBAL STATUS WHERE Variable_name IN
+		('Innodb_buffer_pool_reads', 'Innodb_buffer_pool_read_requests', 'Innodb_buffer_pool_pages_data', 'Innodb_buffer_pool_pages_total')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	status := make(map[string]float64)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return err
+		}
+		status[name], _ = strconv.ParseFloat(value, 64)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	reads := status["Innodb_buffer_pool_reads"]
+	requests := status["Innodb_buffer_pool_read_requests"]
+	deltaReads, deltaRequests := reads-wc.lastReads, requests-wc.lastRequests
+	firstCheck := wc.lastRequests == 0
+	wc.lastReads, wc.lastRequests = reads, requests
+
+	// Counters reset when the server restarts
+	if firstCheck || deltaRequests <= 0 || deltaReads < 0 {
+		deltaReads, deltaRequests = reads, requests
+		result.HitRateSinceStartup = true
+	}
+	if deltaRequests > 0 {
+		result.BufferPoolHitRate = (1 - deltaReads/deltaRequests) * 100
+	}
+	if total := status["Innodb_buffer_pool_pages_total"]; total > 0 {
+		result.BufferPoolFillPercent = status["Innodb_buffer_pool_pages_data"] / total * 100
+	}
+	return nil
+}
+
+// checkQuery explains a query and verifies that its plan uses the expected
+// indexes, or that no table is fully scanned when none are listed
+func (wc *WarmupChecker) checkQuery(ctx context.Context, conn *sql.DB, query config.WarmupQuery) WarmupQueryResult {
+	result := WarmupQueryResult{Name: query.Name}
+
+	flavor := wc.flavors.get(ctx, conn)
+	rows, err := conn.QueryContext(ctx, capStatementTime(flavor, "EXPLAIN "+query.SQL, wc.config.MaxStatementTime))
+	if err != nil {
+		result.Problem = fmt.Sprintf("explain failed: %v", err)
+		return result
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		result.Problem = fmt.Sprintf("failed to get columns: %v", err)
+		return result
+	}
+	tableIdx, typeIdx, keyIdx := -1, -1, -1
+	for i, col := range columns {
+		switch col {
+		case "table":
+			tableIdx = i
+		case "type":
+			typeIdx = i
+		case "key":
+			keyIdx = i
+		}
+	}
+	if tableIdx < 0 || typeIdx < 0 || keyIdx < 0 {
+		result.Problem = "explain output has no table, type or key column"
+		return result
+	}
+
+	used := make(map[string]bool)
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			result.Problem = fmt.Sprintf("failed to scan explain result: %v", err)
+			return result
+		}
+
+		// An index merge lists several keys separated by commas
+		for _, key := range strings.Split(values[keyIdx].String, ",") {
+			if key != "" && !used[key] {
+				used[key] = true
+				result.Keys = append(result.Keys, key)
+			}
+		}
+		// Derived tables and subquery results, e.g. <derived2>, are not base tables
+		table := values[tableIdx].String
+		if values[typeIdx].String == "ALL" && !strings.HasPrefix(table, "<") {
+			result.FullScanTables = append(result.FullScanTables, table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		result.Problem = fmt.Sprintf("failed to read explain result: %v", err)
+		return result
+	}
+
+	var missing, unused []string
+	for _, index := range query.Indexes {
+		if used[index] {
+			continue
+		}
+		if indexExists(ctx, conn, index) {
+			unused = append(unused, index)
+		} else {
+			missing = append(missing, index)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		result.Problem = fmt.Sprintf("index %s does not exist on the target", strings.Join(missing, ", "))
+	case len(unused) > 0:
+		result.Problem = fmt.Sprintf("plan does not use index %s", strings.Join(unused, ", "))
+	case len(query.Indexes) == 0 && len(result.FullScanTables) > 0:
+		result.Problem = fmt.Sprintf("plan scans %s without an index", strings.Join(result.FullScanTables, ", "))
+	default:
+		result.Ready = true
+	}
+	return result
+}
+
+// capStatementTime limits how long a statement may run: through the
+// MAX_EXECUTION_TIME hint of its SELECT on MySQL, or max_statement_time for
+// the statement on MariaDB
+func capStatementTime(flavor serverFlavor, query string, limit time.Duration) string {
+	if flavor.mysql {
+		keyword := strings.Index(strings.ToUpper(query), "SELECT") + len("SELECT")
+		return fmt.Sprintf("%s /*+ MAX_EXECUTION_TIME(%d) */%s", query[:keyword], limit.Milliseconds(), query[keyword:])
+	}
+	return fmt.Sprintf("SET STATEMENT max_statement_time=%g FOR %s", limit.Seconds(), query)
+}
+
+// indexExists reports whether an index of that name exists in the target schema
+func indexExists(ctx context.Context, conn *sql.DB, index string) bool {
+	var count int
+	err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE() AND INDEX_NAME = ?`, index).Scan(&count)
+	// Report an unknown state as "not used" rather than "missing"
+	return err != nil || count > 0
+}
@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// alertmanagerAlert is one alert in the Alertmanager /api/v2/alerts format
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"` // set once the alert is resolved
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// AlertmanagerNotifier pushes alerts to Prometheus Alertmanager. Firing
+// alerts are re-sent periodically because Alertmanager resolves alerts that
+// are not refreshed within its resolve_timeout.
+type AlertmanagerNotifier struct {
+	config config.AlertmanagerConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	firing map[string]alertmanagerAlert // key: alert ID
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAlertmanagerNotifier creates a new Alertmanager notifier and starts re-sending firing alerts
+func NewAlertmanagerNotifier(cfg config.AlertmanagerConfig) *AlertmanagerNotifier {
+	an := &AlertmanagerNotifier{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		firing: make(map[string]alertmanagerAlert),
+		stop:   make(chan struct{}),
+	}
+
+	an.wg.Add(1)
+	go an.resendLoop()
+
+	return an
+}
+
+// Name returns the notifier name
+func (an *AlertmanagerNotifier) Name() string {
+	return an.config.Name
+}
+
+// Notify pushes the alert of an event. When a severity change alters the
+// labels, the alert with the previous labels is resolved first.
+func (an *AlertmanagerNotifier) Notify(event alert.AlertEvent) error {
+	// Alerts ingested from Alertmanager are not sent back to it
+	if event.Alert.Source == alert.SourceAlertmanager {
+		return nil
+	}
+
+	current := an.convert(event.Alert)
+
+	an.mu.Lock()
+	batch := make([]alertmanagerAlert, 0, 2)
+	if previous, exists := an.firing[event.Alert.ID]; exists && previous.Labels["severity"] != current.Labels["severity"] {
+		endsAt := event.Timestamp
+		previous.EndsAt = &endsAt
+		batch = append(batch, previous)
+	}
+	if event.Alert.Resolved {
+		endsAt := event.Alert.UpdatedAt
+		current.EndsAt = &endsAt
+		delete(an.firing, event.Alert.ID)
+	} else {
+		an.firing[event.Alert.ID] = current
+	}
+	an.mu.Unlock()
+
+	return an.post(append(batch, current))
+}
+
+// Stop stops re-sending firing alerts
+func (an *AlertmanagerNotifier) Stop() {
+	close(an.stop)
+	an.wg.Wait()
+}
+
+// resendLoop re-sends all firing alerts every resend interval
+func (an *AlertmanagerNotifier) resendLoop() {
+	defer an.wg.Done()
+
+	ticker := time.NewTicker(an.config.ResendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-an.stop:
+			return
+		case <-ticker.C:
+			an.mu.Lock()
+			batch := make([]alertmanagerAlert, 0, len(an.firing))
+			for _, a := range an.firing {
+				batch = append(batch, a)
+			}
+			an.mu.Unlock()
+
+			if len(batch) == 0 {
+				continue
+			}
+			if err := an.post(batch); err != nil {
+				log.Printf("Notifier '%s' failed to re-send %d firing alerts: %v", an.Name(), len(batch), err)
+			}
+		}
+	}
+}
+
+// convert maps an alert to the Alertmanager format. Labels identify the
+// alert for grouping, silencing and inhibition.
+func (an *AlertmanagerNotifier) convert(a alert.Alert) alertmanagerAlert {
+	labels := make(map[string]string, len(an.config.Labels)+5)
+	for k, v := range an.config.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = alertName(a.Type)
+	labels["pair"] = a.DatabasePair
+	labels["check"] = a.Type
+	labels["severity"] = strings.ToLower(a.Severity)
+	if a.TableName != "" {
+		labels["table"] = a.TableName
+	}
+
+	annotations := map[string]string{
+		"summary":  a.Message,
+		"alert_id": a.ID,
+	}
+	for name, value := range map[string]string{
+		"owner":       a.Metadata.Owner,
+		"runbook_url": a.Metadata.RunbookURL,
+		"ticket":      a.Metadata.Ticket,
+		"description": a.Metadata.Description,
+	} {
+		if value != "" {
+			annotations[name] = value
+		}
+	}
+
+	return alertmanagerAlert{
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     a.Timestamp,
+		GeneratorURL: an.config.GeneratorURL,
+	}
+}
+
+// alertName converts an alert type such as replica_lag to an Alertmanager
+// alert name such as MariaDBReplicaLag
+func alertName(alertType string) string {
+	var b strings.Builder
+	b.WriteString("MariaDB")
+	for _, word := range strings.Split(alertType, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// post sends alerts to every configured Alertmanager
+func (an *AlertmanagerNotifier) post(alerts []alertmanagerAlert) error {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, url := range an.config.URLs {
+		if err := an.postTo(strings.TrimRight(url, "/")+"/api/v2/alerts", body); err != nil {
+			lastErr = fmt.Errorf("alertmanager %s: %w", url, err)
+		}
+	}
+	return lastErr
+}
+
+// postTo sends a single request
+func (an *AlertmanagerNotifier) postTo(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range an.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := an.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
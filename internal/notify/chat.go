@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// chatRoute filters the alert events of a chat notifier by its route. It
+// remembers the alerts it let through, so that their later events, such as
+// the resolution after a downgrade below min_severity, are delivered too.
+type chatRoute struct {
+	route config.AlertRoute
+
+	mu        sync.Mutex
+	delivered map[string]bool // alert IDs
+}
+
+// newChatRoute creates the filter of a route
+func newChatRoute(route config.AlertRoute) *chatRoute {
+	return &chatRoute{route: route, delivered: make(map[string]bool)}
+}
+
+// admit reports whether an alert event is delivered
+func (cr *chatRoute) admit(event alert.AlertEvent) bool {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	a := event.Alert
+	if !cr.route.Matches(a.DatabasePair, a.Severity) && !cr.delivered[a.ID] {
+		return false
+	}
+	if a.Resolved {
+		delete(cr.delivered, a.ID)
+	} else {
+		cr.delivered[a.ID] = true
+	}
+	return true
+}
+
+// chatTitle is the first line of a chat message for an alert event
+func chatTitle(event alert.AlertEvent) string {
+	a := event.Alert
+	icon := "🟠"
+	if a.Severity == "CRITICAL" {
+		icon = "🔴"
+	}
+
+	switch event.Type {
+	case alert.EventResolved:
+		return fmt.Sprintf("✅ Resolved on %s", a.DatabasePair)
+	case alert.EventAcknowledged:
+		return fmt.Sprintf("👀 Acknowledged by %s on %s", a.AcknowledgedBy, a.DatabasePair)
+	case alert.EventUpdated:
+		return fmt.Sprintf("%s Now %s on %s", icon, a.Severity, a.DatabasePair)
+	case alert.EventRenotified:
+		return fmt.Sprintf("%s Still %s on %s", icon, a.Severity, a.DatabasePair)
+	default:
+		return fmt.Sprintf("%s %s on %s", icon, a.Severity, a.DatabasePair)
+	}
+}
+
+// chatFacts lists the details of an alert shown below its message, in order
+func chatFacts(a alert.Alert) [][2]string {
+	facts := [][2]string{{"Type", a.Type}}
+	if a.TableName != "" {
+		facts = append(facts, [2]string{"Table", a.TableName})
+	}
+	if a.Metadata.Owner != "" {
+		facts = append(facts, [2]string{"Owner", a.Metadata.Owner})
+	}
+	if a.Metadata.Ticket != "" {
+		facts = append(facts, [2]string{"Ticket", a.Metadata.Ticket})
+	}
+	return facts
+}
+
+// postJSON posts a JSON body and fails on a non-2xx status, with the start
+// of the response body for context
+func postJSON(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(detail)); text != "" {
+			return fmt.Errorf("unexpected status %s: %s", resp.Status, text)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
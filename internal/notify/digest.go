@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"log"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// EventDailyDigest is the event type of the digest
+const EventDailyDigest = "daily_digest"
+
+// Digest summarizes every database pair with its insights
+type Digest struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Pairs     []DigestPair `json:"pairs"`
+}
+
+// DigestPair is the summary of one database pair in the digest
+type DigestPair struct {
+	Name           string    `json:"name"`
+	Lifecycle      string    `json:"lifecycle"`
+	HealthScore    *float64  `json:"health_score"` // 0-100; null until the pair has results
+	LagSeconds     float64   `json:"lag_seconds"`
+	LagStatus      string    `json:"lag_status"`
+	ActiveAlerts   int       `json:"active_alerts"`
+	CriticalAlerts int       `json:"critical_alerts"`
+	Insights       []string  `json:"insights"`
+	LastChecked    time.Time `json:"last_checked"`
+}
+
+// DigestNotifier is implemented by notifiers that also deliver the digest
+type DigestNotifier interface {
+	NotifyDigest(digest Digest) error
+}
+
+// DigestScheduler enqueues a digest whenever its schedule fires
+type DigestScheduler struct {
+	config     *config.Config
+	storage    *storage.MetricsStorage
+	alertMgr   *alert.AlertManager
+	engine     *monitor.MonitoringEngine
+	dispatcher *Dispatcher
+	clock      clock.Clock
+	stopChan   chan struct{}
+	done       chan struct{}
+}
+
+// NewDigestScheduler creates a new digest scheduler
+func NewDigestScheduler(cfg *config.Config, store *storage.MetricsStorage, alertMgr *alert.AlertManager, engine *monitor.MonitoringEngine, dispatcher *Dispatcher) *DigestScheduler {
+	return &DigestScheduler{
+		config:     cfg,
+		storage:    store,
+		alertMgr:   alertMgr,
+		engine:     engine,
+		dispatcher: dispatcher,
+		clock:      clock.Real,
+		stopChan:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetClock sets the clock the schedule runs on. It must be called before
+// Start.
+func (ds *DigestScheduler) SetClock(c clock.Clock) {
+	ds.clock = c
+}
+
+// Start starts the schedule in the background
+func (ds *DigestScheduler) Start() {
+	log.Printf("Starting daily digest (schedule: %s)", ds.config.Digest.Schedule)
+	go ds.run()
+}
+
+// Stop stops the schedule
+func (ds *DigestScheduler) Stop() {
+	close(ds.stopChan)
+	<-ds.done
+}
+
+// run enqueues the digest whenever the schedule fires
+func (ds *DigestScheduler) run() {
+	defer close(ds.done)
+	clock.RunSchedule(ds.clock, ds.config.Digest.Cron(), ds.stopChan, func(at time.Time) {
+		ds.dispatcher.EnqueueDigest(ds.Build(at))
+	})
+}
+
+// Build summarizes the current state of every configured pair
+func (ds *DigestScheduler) Build(now time.Time) Digest {
+	metrics := ds.storage.GetCurrentMetrics()
+	activeAlerts := ds.alertMgr.GetActiveAlerts()
+	states := ds.engine.PairStates()
+
+	pairs := ds.config.Pairs()
+	digest := Digest{Timestamp: now, Pairs: make([]DigestPair, 0, len(pairs))}
+	for _, pair := range pairs {
+		summary := DigestPair{Name: pair.Name, Lifecycle: states[pair.Name], Insights: make([]string, 0)}
+		if health, ok := metrics.HealthScore[pair.Name]; ok {
+			score := health.Score
+			summary.HealthScore = &score
+		}
+		if lag, ok := metrics.ReplicaLag[pair.Name]; ok {
+			summary.LagSeconds = lag.LagSeconds
+			summary.LagStatus = lag.Status
+		}
+		if status, ok := metrics.ConnectionStatus[pair.Name]; ok {
+			summary.LastChecked = status.LastChecked
+		}
+		for _, a := range activeAlerts {
+			if a.DatabasePair != pair.Name || a.Suppressed {
+				continue
+			}
+			summary.ActiveAlerts++
+			if a.Severity == "CRITICAL" {
+				summary.CriticalAlerts++
+			}
+		}
+		for _, insight := range metrics.Insights[pair.Name] {
+			summary.Insights = append(summary.Insights, insight.Message)
+		}
+		digest.Pairs = append(digest.Pairs, summary)
+	}
+	return digest
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// grafanaAnnotation is the body of a Grafana annotation create or patch request
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time,omitempty"` // Unix milliseconds
+	TimeEnd      int64    `json:"timeEnd,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Text         string   `json:"text,omitempty"`
+}
+
+// GrafanaNotifier marks alerts on Grafana graphs: an annotation is created
+// when an alert fires and extended into a region when it resolves
+type GrafanaNotifier struct {
+	config config.GrafanaConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	annotations map[string]int64 // Grafana annotation ID by alert ID, while the alert is active
+}
+
+// NewGrafanaNotifier creates a new Grafana annotation notifier
+func NewGrafanaNotifier(cfg config.GrafanaConfig) *GrafanaNotifier {
+	return &GrafanaNotifier{
+		config:      cfg,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		annotations: make(map[string]int64),
+	}
+}
+
+// Name returns the notifier name
+func (gn *GrafanaNotifier) Name() string {
+	return gn.config.Name
+}
+
+// Notify annotates fired alerts routed to Grafana and closes their
+// annotation when they resolve. Acknowledgements and re-notifications are
+// not annotated.
+func (gn *GrafanaNotifier) Notify(event alert.AlertEvent) error {
+	a := event.Alert
+	gn.mu.Lock()
+	id, annotated := gn.annotations[a.ID]
+	if event.Type == alert.EventResolved {
+		delete(gn.annotations, a.ID)
+	}
+	gn.mu.Unlock()
+
+	switch event.Type {
+	case alert.EventCreated, alert.EventUpdated:
+		// An update may raise an alert above the route's min_severity
+		if annotated || !gn.config.Matches(a.DatabasePair, a.Severity) {
+			return nil
+		}
+		id, err := gn.create(grafanaAnnotation{
+			Time: event.Timestamp.UnixMilli(),
+			Tags: gn.tags(a),
+			Text: chatTitle(event) + ": " + a.Message,
+		})
+		if err != nil {
+			return err
+		}
+		gn.mu.Lock()
+		gn.annotations[a.ID] = id
+		gn.mu.Unlock()
+
+	case alert.EventResolved:
+		if annotated {
+			return gn.request(http.MethodPatch, fmt.Sprintf("/api/annotations/%d", id), grafanaAnnotation{TimeEnd: event.Timestamp.UnixMilli()}, nil)
+		}
+		// Fired before a restart, or before the route matched it
+		if !gn.config.Matches(a.DatabasePair, a.Severity) {
+			return nil
+		}
+		_, err := gn.create(grafanaAnnotation{
+			Time: event.Timestamp.UnixMilli(),
+			Tags: append(gn.tags(a), "resolved"),
+			Text: chatTitle(event) + ": " + a.Message,
+		})
+		return err
+	}
+	return nil
+}
+
+// tags returns the tags of an alert's annotation
+func (gn *GrafanaNotifier) tags(a alert.Alert) []string {
+	tags := []string{config.GrafanaAnnotationTag, "pair:" + a.DatabasePair, "type:" + a.Type, "severity:" + a.Severity}
+	if a.TableName != "" {
+		tags = append(tags, "table:"+a.TableName)
+	}
+	return append(tags, gn.config.Tags...)
+}
+
+// create creates an annotation and returns its ID
+func (gn *GrafanaNotifier) create(annotation grafanaAnnotation) (int64, error) {
+	annotation.DashboardUID = gn.config.DashboardUID
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := gn.request(http.MethodPost, "/api/annotations", annotation, &created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// request sends a JSON request to the Grafana HTTP API and decodes the
+// response into out unless it is nil
+func (gn *GrafanaNotifier) request(method, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(gn.config.URL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+gn.config.APIToken)
+
+	resp, err := gn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(detail)); text != "" {
+			return fmt.Errorf("grafana %s %s: unexpected status %s: %s", method, path, resp.Status, text)
+		}
+		return fmt.Errorf("grafana %s %s: unexpected status %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
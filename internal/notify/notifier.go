@@ -0,0 +1,330 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/report"
+)
+
+// Notifier delivers alert events to an external system
+type Notifier interface {
+	Name() string
+	Notify(event alert.AlertEvent) error
+}
+
+// PairNotifier is implemented by notifiers that also deliver pair lifecycle events
+type PairNotifier interface {
+	NotifyPair(event monitor.PairEvent) error
+}
+
+// notification is a queued alert event, pair lifecycle event, digest or
+// report
+type notification struct {
+	alert  *alert.AlertEvent
+	pair   *monitor.PairEvent
+	digest *Digest
+	report *report.Report
+}
+
+// describe names a queued notification for log messages
+func (item notification) describe() string {
+	switch {
+	case item.alert != nil:
+		return fmt.Sprintf("%s event for alert %s", item.alert.Type, item.alert.Alert.ID)
+	case item.digest != nil:
+		return EventDailyDigest
+	case item.report != nil:
+		return EventSLAReport
+	default:
+		return fmt.Sprintf("%s event for pair %s", item.pair.Type, item.pair.Pair)
+	}
+}
+
+// queueSize is the capacity of the dispatcher's queue and of each
+// notifier's queue
+const queueSize = 1000
+
+// Dispatcher fans alert and pair lifecycle events out to notifiers without
+// blocking the alert manager or the monitoring engine. Each notifier has its
+// own queue and worker, so a slow or unreachable endpoint only delays its own
+// deliveries.
+type Dispatcher struct {
+	notifiers []Notifier
+	workers   []*notifierWorker
+	routes    []config.NotifierRoute
+	routed    map[string]bool            // notifiers listed in a route, which only receive the alerts it matches
+	delivered map[string]map[string]bool // alert ID -> routed notifiers that received its events; used by run only
+	queue     chan notification
+	wg        sync.WaitGroup // the run loop
+	workersWg sync.WaitGroup
+	abandon   chan struct{} // closed when the shutdown timeout passes; workers drop their queues
+}
+
+// notifierWorker delivers the queued events of one notifier in order
+type notifierWorker struct {
+	notifier Notifier
+	queue    chan notification
+}
+
+// NewDispatcher creates a new dispatcher for the given notifiers, sending
+// alerts to routed notifiers by the routes
+func NewDispatcher(notifiers []Notifier, routes []config.NotifierRoute) *Dispatcher {
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		for _, name := range route.Notifiers {
+			routed[name] = true
+		}
+	}
+	workers := make([]*notifierWorker, 0, len(notifiers))
+	for _, n := range notifiers {
+		workers = append(workers, &notifierWorker{notifier: n, queue: make(chan notification, queueSize)})
+	}
+	return &Dispatcher{
+		notifiers: notifiers,
+		workers:   workers,
+		routes:    routes,
+		routed:    routed,
+		delivered: make(map[string]map[string]bool),
+		queue:     make(chan notification, queueSize),
+		abandon:   make(chan struct{}),
+	}
+}
+
+// recipients returns the notifiers that receive an alert's events: those of
+// the matching routes, and those listed in no route. Routed notifiers that
+// received an alert's events keep receiving them, such as the resolution
+// after a downgrade to a severity routed elsewhere.
+func (d *Dispatcher) recipients(a alert.Alert) []*notifierWorker {
+	if len(d.routes) == 0 {
+		return d.workers
+	}
+
+	matched := d.delivered[a.ID]
+	if matched == nil {
+		matched = make(map[string]bool)
+	}
+	for _, route := range d.routes {
+		if !route.Matches(a.DatabasePair, a.TableName, a.Type, a.Severity) {
+			continue
+		}
+		for _, name := range route.Notifiers {
+			matched[name] = true
+		}
+		if !route.Continue {
+			break
+		}
+	}
+
+	if a.Resolved {
+		delete(d.delivered, a.ID)
+	} else if len(matched) > 0 {
+		d.delivered[a.ID] = matched
+	}
+
+	recipients := make([]*notifierWorker, 0, len(d.workers))
+	for _, w := range d.workers {
+		if name := w.notifier.Name(); !d.routed[name] || matched[name] {
+			recipients = append(recipients, w)
+		}
+	}
+	return recipients
+}
+
+// Start starts delivering queued events
+func (d *Dispatcher) Start() {
+	for _, w := range d.workers {
+		d.workersWg.Add(1)
+		go d.work(w)
+	}
+	d.wg.Add(1)
+	go d.run()
+}
+
+// Stop delivers remaining queued events until ctx is done, then stops the
+// notifiers, interrupting their retries, and waits for their workers
+func (d *Dispatcher) Stop(ctx context.Context) {
+	close(d.queue)
+	d.wg.Wait()
+	for _, w := range d.workers {
+		close(w.queue)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		d.workersWg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Printf("Notifications not delivered within the shutdown timeout, stopping notifiers")
+		close(d.abandon)
+	}
+
+	for _, n := range d.notifiers {
+		if s, ok := n.(interface{ Stop() }); ok {
+			s.Stop()
+		}
+	}
+	<-drained
+}
+
+// Enqueue queues an event for delivery, dropping it if the queue is full.
+// Events of alerts suppressed by a maintenance window are not delivered.
+func (d *Dispatcher) Enqueue(event alert.AlertEvent) {
+	if event.Alert.Suppressed {
+		return
+	}
+
+	select {
+	case d.queue <- notification{alert: &event}:
+	default:
+		log.Printf("Notification queue full, dropping %s event for alert %s", event.Type, event.Alert.ID)
+	}
+}
+
+// EnqueuePair queues a pair lifecycle event for delivery, dropping it if the queue is full
+func (d *Dispatcher) EnqueuePair(event monitor.PairEvent) {
+	select {
+	case d.queue <- notification{pair: &event}:
+	default:
+		log.Printf("Notification queue full, dropping %s event for pair %s", event.Type, event.Pair)
+	}
+}
+
+// EnqueueDigest queues a digest for delivery, dropping it if the queue is full
+func (d *Dispatcher) EnqueueDigest(digest Digest) {
+	select {
+	case d.queue <- notification{digest: &digest}:
+	default:
+		log.Printf("Notification queue full, dropping %s", EventDailyDigest)
+	}
+}
+
+// EnqueueReport queues a summary report for delivery, dropping it if the
+// queue is full
+func (d *Dispatcher) EnqueueReport(r report.Report) {
+	select {
+	case d.queue <- notification{report: &r}:
+	default:
+		log.Printf("Notification queue full, dropping %s", EventSLAReport)
+	}
+}
+
+// Queue returns the number of events in the fullest queue, the dispatcher's
+// or a notifier's, and the capacity of each
+func (d *Dispatcher) Queue() (length, capacity int) {
+	length = len(d.queue)
+	for _, w := range d.workers {
+		length = max(length, len(w.queue))
+	}
+	return length, queueSize
+}
+
+// run hands events to the queue of every notifier; alert events only to the
+// notifiers they are routed to. A notifier whose queue is full misses the
+// event.
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	for item := range d.queue {
+		workers := d.workers
+		if item.alert != nil {
+			workers = d.recipients(item.alert.Alert)
+		}
+		for _, w := range workers {
+			select {
+			case w.queue <- item:
+			default:
+				log.Printf("Notifier '%s' queue full, dropping %s", w.notifier.Name(), item.describe())
+			}
+		}
+	}
+}
+
+// work delivers the queued events of a notifier until its queue is closed,
+// dropping those left once shutdown abandons delivery
+func (d *Dispatcher) work(w *notifierWorker) {
+	defer d.workersWg.Done()
+
+	dropped := 0
+	for item := range w.queue {
+		select {
+		case <-d.abandon:
+			dropped++
+			continue
+		default:
+		}
+		d.deliver(w.notifier, item)
+	}
+	if dropped > 0 {
+		log.Printf("Notifier '%s' dropped %d undelivered event(s) on shutdown", w.notifier.Name(), dropped)
+	}
+}
+
+// deliver sends one queued event to a notifier
+func (d *Dispatcher) deliver(n Notifier, item notification) {
+	if item.alert != nil {
+		if err := n.Notify(*item.alert); err != nil {
+			log.Printf("Notifier '%s' failed to deliver %s event for alert %s: %v", n.Name(), item.alert.Type, item.alert.Alert.ID, err)
+		}
+		return
+	}
+
+	if item.digest != nil {
+		if dn, ok := n.(DigestNotifier); ok {
+			if err := dn.NotifyDigest(*item.digest); err != nil {
+				log.Printf("Notifier '%s' failed to deliver %s: %v", n.Name(), EventDailyDigest, err)
+			}
+		}
+		return
+	}
+
+	if item.report != nil {
+		if rn, ok := n.(ReportNotifier); ok {
+			if err := rn.NotifyReport(*item.report); err != nil {
+				log.Printf("Notifier '%s' failed to deliver %s: %v", n.Name(), EventSLAReport, err)
+			}
+		}
+		return
+	}
+
+	if pn, ok := n.(PairNotifier); ok {
+		if err := pn.NotifyPair(*item.pair); err != nil {
+			log.Printf("Notifier '%s' failed to deliver %s event for pair %s: %v", n.Name(), item.pair.Type, item.pair.Pair, err)
+		}
+	}
+}
+
+// BuildNotifiers creates all notifiers defined in the configuration
+func BuildNotifiers(cfg config.NotifiersConfig) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0)
+
+	for _, webhookCfg := range cfg.Webhooks {
+		webhook, err := NewWebhookNotifier(webhookCfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, webhook)
+	}
+	for _, alertmanagerCfg := range cfg.Alertmanager {
+		notifiers = append(notifiers, NewAlertmanagerNotifier(alertmanagerCfg))
+	}
+	for _, telegramCfg := range cfg.Telegram {
+		notifiers = append(notifiers, NewTelegramNotifier(telegramCfg))
+	}
+	for _, teamsCfg := range cfg.Teams {
+		notifiers = append(notifiers, NewTeamsNotifier(teamsCfg))
+	}
+	for _, grafanaCfg := range cfg.Grafana {
+		notifiers = append(notifiers, NewGrafanaNotifier(grafanaCfg))
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"log"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/report"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// EventSLAReport is the event type of the scheduled summary report
+const EventSLAReport = "sla_report"
+
+// ReportNotifier is implemented by notifiers that also deliver the summary
+// report
+type ReportNotifier interface {
+	NotifyReport(r report.Report) error
+}
+
+// ReportScheduler enqueues the summary report of the last complete day or
+// week whenever its schedule fires
+type ReportScheduler struct {
+	config     *config.Config
+	storage    *storage.MetricsStorage
+	dispatcher *Dispatcher
+	clock      clock.Clock
+	stopChan   chan struct{}
+	done       chan struct{}
+}
+
+// NewReportScheduler creates a new report scheduler
+func NewReportScheduler(cfg *config.Config, store *storage.MetricsStorage, dispatcher *Dispatcher) *ReportScheduler {
+	return &ReportScheduler{
+		config:     cfg,
+		storage:    store,
+		dispatcher: dispatcher,
+		clock:      clock.Real,
+		stopChan:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetClock sets the clock the schedule runs on. It must be called before
+// Start.
+func (rs *ReportScheduler) SetClock(c clock.Clock) {
+	rs.clock = c
+}
+
+// Start starts the schedule in the background
+func (rs *ReportScheduler) Start() {
+	log.Printf("Starting %s reports (schedule: %s)", rs.config.Reports.Period, rs.config.Reports.Schedule)
+	go rs.run()
+}
+
+// Stop stops the schedule
+func (rs *ReportScheduler) Stop() {
+	close(rs.stopChan)
+	<-rs.done
+}
+
+// run enqueues the report whenever the schedule fires
+func (rs *ReportScheduler) run() {
+	defer close(rs.done)
+	clock.RunSchedule(rs.clock, rs.config.Reports.Cron(), rs.stopChan, func(at time.Time) {
+		period := rs.config.Reports.Period
+		r, err := report.Build(rs.config, rs.storage, period, report.Previous(period, at, rs.config.Reports.Location()), at)
+		if err != nil {
+			log.Printf("Failed to build %s report: %v", period, err)
+			return
+		}
+		rs.dispatcher.EnqueueReport(r)
+	})
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+const reportTestConfig = `
+monitoring_interval: 10s
+source_db: {host: source, port: 3306, username: monitor, password: secret, database: shop}
+target_db: {host: target, port: 3306, username: monitor, password: secret, database: shop}
+tables_to_monitor: [orders]
+reports:
+  schedule: "0 6 * * *"
+  timezone: Europe/Berlin
+`
+
+func TestReportSchedulerFiresOnClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(reportTestConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin := cfg.Reports.Location()
+
+	store := storage.NewMetricsStorage()
+	store.SetReportRetention(cfg.Reports.Retention, berlin)
+	dispatcher := NewDispatcher(nil, nil)
+	fake := clock.NewFake(time.Date(2026, 3, 2, 5, 20, 0, 0, berlin))
+
+	rs := NewReportScheduler(cfg, store, dispatcher)
+	rs.SetClock(fake)
+	rs.Start()
+	defer rs.Stop()
+
+	for _, day := range []int{2, 3} {
+		waitForTimer(t, fake)
+		fake.Set(time.Date(2026, 3, day, 5, 59, 0, 0, berlin))
+		if len(dispatcher.queue) != 0 {
+			t.Fatalf("report sent before 06:00 on March %d", day)
+		}
+
+		fake.Set(time.Date(2026, 3, day, 6, 0, 0, 0, berlin))
+		item := <-dispatcher.queue
+		if item.report == nil {
+			t.Fatalf("queued %s, want the report", item.describe())
+		}
+		wantStart := time.Date(2026, 3, day-1, 0, 0, 0, 0, berlin)
+		if !item.report.Start.Equal(wantStart) || !item.report.Complete {
+			t.Errorf("report of %s (complete: %v), want the complete day of %s", item.report.Start, item.report.Complete, wantStart)
+		}
+		if want := time.Date(2026, 3, day, 6, 0, 0, 0, berlin); !item.report.GeneratedAt.Equal(want) {
+			t.Errorf("report generated at %s, want %s", item.report.GeneratedAt, want)
+		}
+	}
+}
+
+// waitForTimer waits until the scheduler sleeps on the fake clock
+func waitForTimer(t *testing.T, c *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the scheduler started no timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
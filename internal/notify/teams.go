@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// teamsMessage is the body posted to a Teams webhook: a message carrying one
+// Adaptive Card
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	Actions []teamsAction  `json:"actions,omitempty"`
+}
+
+// teamsElement is a TextBlock or a FactSet
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// TeamsNotifier posts alert events as Adaptive Cards to Microsoft Teams webhooks
+type TeamsNotifier struct {
+	config config.TeamsConfig
+	client *http.Client
+	route  *chatRoute
+}
+
+// NewTeamsNotifier creates a new Teams notifier
+func NewTeamsNotifier(cfg config.TeamsConfig) *TeamsNotifier {
+	return &TeamsNotifier{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		route:  newChatRoute(cfg.AlertRoute),
+	}
+}
+
+// Name returns the notifier name
+func (tn *TeamsNotifier) Name() string {
+	return tn.config.Name
+}
+
+// Notify posts an alert event routed to the channel to every configured URL
+func (tn *TeamsNotifier) Notify(event alert.AlertEvent) error {
+	if !tn.route.admit(event) {
+		return nil
+	}
+
+	body, err := json.Marshal(teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     teamsAlertCard(event),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, url := range tn.config.URLs {
+		if err := postJSON(tn.client, url, body); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// teamsAlertCard builds the card of an alert event, with the title colored
+// by severity
+func teamsAlertCard(event alert.AlertEvent) teamsCard {
+	a := event.Alert
+	color := "Warning"
+	switch {
+	case event.Type == alert.EventResolved:
+		color = "Good"
+	case a.Severity == "CRITICAL":
+		color = "Attention"
+	}
+
+	facts := make([]teamsFact, 0, 4)
+	for _, fact := range chatFacts(a) {
+		facts = append(facts, teamsFact{Title: fact[0], Value: fact[1]})
+	}
+
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []teamsElement{
+			{Type: "TextBlock", Text: chatTitle(event), Weight: "Bolder", Size: "Medium", Color: color, Wrap: true},
+			{Type: "TextBlock", Text: a.Message, Wrap: true},
+			{Type: "FactSet", Facts: facts},
+		},
+	}
+	if a.Metadata.RunbookURL != "" {
+		card.Actions = []teamsAction{{Type: "Action.OpenUrl", Title: "Runbook", URL: a.Metadata.RunbookURL}}
+	}
+	return card
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// telegramMessage is the body of a Bot API sendMessage request
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// TelegramNotifier sends alert events as messages from a Telegram bot
+type TelegramNotifier struct {
+	config config.TelegramConfig
+	client *http.Client
+	route  *chatRoute
+}
+
+// NewTelegramNotifier creates a new Telegram notifier
+func NewTelegramNotifier(cfg config.TelegramConfig) *TelegramNotifier {
+	return &TelegramNotifier{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		route:  newChatRoute(cfg.AlertRoute),
+	}
+}
+
+// Name returns the notifier name
+func (tn *TelegramNotifier) Name() string {
+	return tn.config.Name
+}
+
+// Notify sends an alert event routed to the chat
+func (tn *TelegramNotifier) Notify(event alert.AlertEvent) error {
+	if !tn.route.admit(event) {
+		return nil
+	}
+
+	body, err := json.Marshal(telegramMessage{
+		ChatID:                tn.config.ChatID,
+		Text:                  telegramText(event),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(tn.config.APIURL, "/") + "/bot" + tn.config.BotToken + "/sendMessage"
+	if err := postJSON(tn.client, url, body); err != nil {
+		// The token is part of the URL; keep it out of the logs
+		return fmt.Errorf("telegram sendMessage failed: %s", strings.ReplaceAll(err.Error(), tn.config.BotToken, "<bot_token>"))
+	}
+	return nil
+}
+
+// telegramText formats an alert event as a message in Telegram's HTML subset
+func telegramText(event alert.AlertEvent) string {
+	a := event.Alert
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b>\n%s", html.EscapeString(chatTitle(event)), html.EscapeString(a.Message))
+	for _, fact := range chatFacts(a) {
+		fmt.Fprintf(&b, "\n%s: %s", fact[0], html.EscapeString(fact[1]))
+	}
+	if a.Metadata.RunbookURL != "" {
+		fmt.Fprintf(&b, "\n<a href=\"%s\">Runbook</a>", html.EscapeString(a.Metadata.RunbookURL))
+	}
+	return b.String()
+}
@@ -0,0 +1,295 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/report"
+)
+
+// WebhookPayload is the default JSON body posted for an alert event
+type WebhookPayload struct {
+	Event        string    `json:"event"`
+	Timestamp    time.Time `json:"timestamp"`
+	AlertID      string    `json:"alert_id"`
+	Severity     string    `json:"severity"`
+	Type         string    `json:"type"`
+	DatabasePair string    `json:"database_pair"`
+	TableName    string    `json:"table_name,omitempty"`
+	Message      string    `json:"message"`
+	Resolved     bool      `json:"resolved"`
+
+	Metadata *config.PairMetadata `json:"metadata,omitempty"` // owner, runbook and ticket of the pair
+}
+
+// PairWebhookPayload is the default JSON body posted for a pair lifecycle event
+type PairWebhookPayload struct {
+	Event        string    `json:"event"`
+	Timestamp    time.Time `json:"timestamp"`
+	DatabasePair string    `json:"database_pair"`
+	From         string    `json:"from,omitempty"`
+	To           string    `json:"to"`
+	Check        string    `json:"check,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// DigestWebhookPayload is the default JSON body posted for the digest
+type DigestWebhookPayload struct {
+	Event     string       `json:"event"`
+	Timestamp time.Time    `json:"timestamp"`
+	Pairs     []DigestPair `json:"pairs"`
+}
+
+// ReportWebhookPayload is the default JSON body posted for the summary report
+type ReportWebhookPayload struct {
+	Event     string              `json:"event"`
+	Timestamp time.Time           `json:"timestamp"`
+	Period    string              `json:"period"`
+	Start     time.Time           `json:"start"`
+	End       time.Time           `json:"end"`
+	Pairs     []report.PairReport `json:"pairs"`
+}
+
+// WebhookNotifier posts alert events as JSON to one or more URLs
+type WebhookNotifier struct {
+	config   config.WebhookConfig
+	client   *http.Client
+	template *template.Template
+	events   map[string]bool
+
+	// Cancelled by Stop, ending retries and requests in flight
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewWebhookNotifier creates a new webhook notifier
+func NewWebhookNotifier(cfg config.WebhookConfig) (*WebhookNotifier, error) {
+	wn := &WebhookNotifier{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		events: make(map[string]bool),
+	}
+	wn.ctx, wn.cancel = context.WithCancel(context.Background())
+
+	if cfg.Template != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("webhook '%s': invalid template: %w", cfg.Name, err)
+		}
+		wn.template = tmpl
+	}
+
+	for _, event := range cfg.Events {
+		wn.events[event] = true
+	}
+
+	return wn, nil
+}
+
+// Name returns the notifier name
+func (wn *WebhookNotifier) Name() string {
+	return wn.config.Name
+}
+
+// Stop abandons deliveries in progress, including their remaining retries
+func (wn *WebhookNotifier) Stop() {
+	wn.cancel()
+}
+
+// Notify posts an alert event to every configured URL
+func (wn *WebhookNotifier) Notify(event alert.AlertEvent) error {
+	if len(wn.events) > 0 && !wn.events[event.Type] {
+		return nil
+	}
+
+	body, err := wn.render(WebhookPayload{
+		Event:        event.Type,
+		Timestamp:    event.Timestamp,
+		AlertID:      event.Alert.ID,
+		Severity:     event.Alert.Severity,
+		Type:         event.Alert.Type,
+		DatabasePair: event.Alert.DatabasePair,
+		TableName:    event.Alert.TableName,
+		Message:      event.Alert.Message,
+		Resolved:     event.Alert.Resolved,
+		Metadata:     pairMetadata(event.Alert.Metadata),
+	}, event)
+	if err != nil {
+		return err
+	}
+	return wn.postAll(body)
+}
+
+// NotifyPair posts a pair lifecycle event to every configured URL. Pair
+// events are only sent to webhooks that list them in events.
+func (wn *WebhookNotifier) NotifyPair(event monitor.PairEvent) error {
+	if !wn.events[event.Type] {
+		return nil
+	}
+
+	body, err := wn.render(PairWebhookPayload{
+		Event:        event.Type,
+		Timestamp:    event.Timestamp,
+		DatabasePair: event.Pair,
+		From:         event.From,
+		To:           event.To,
+		Check:        event.Check,
+		Reason:       event.Reason,
+	}, event)
+	if err != nil {
+		return err
+	}
+	return wn.postAll(body)
+}
+
+// NotifyDigest posts the digest to every configured URL. The digest is only
+// sent to webhooks that list daily_digest in events.
+func (wn *WebhookNotifier) NotifyDigest(digest Digest) error {
+	if !wn.events[EventDailyDigest] {
+		return nil
+	}
+
+	body, err := wn.render(DigestWebhookPayload{
+		Event:     EventDailyDigest,
+		Timestamp: digest.Timestamp,
+		Pairs:     digest.Pairs,
+	}, digest)
+	if err != nil {
+		return err
+	}
+	return wn.postAll(body)
+}
+
+// NotifyReport posts the summary report to every configured URL. The report
+// is only sent to webhooks that list sla_report in events.
+func (wn *WebhookNotifier) NotifyReport(r report.Report) error {
+	if !wn.events[EventSLAReport] {
+		return nil
+	}
+
+	body, err := wn.render(ReportWebhookPayload{
+		Event:     EventSLAReport,
+		Timestamp: r.GeneratedAt,
+		Period:    r.Period,
+		Start:     r.Start,
+		End:       r.End,
+		Pairs:     r.Pairs,
+	}, r)
+	if err != nil {
+		return err
+	}
+	return wn.postAll(body)
+}
+
+// render builds the request body from the template, executed with the event,
+// or from the default payload
+func (wn *WebhookNotifier) render(payload, event interface{}) ([]byte, error) {
+	if wn.template == nil {
+		return json.Marshal(payload)
+	}
+
+	var buf bytes.Buffer
+	if err := wn.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// postAll posts a body to every configured URL
+func (wn *WebhookNotifier) postAll(body []byte) error {
+	var lastErr error
+	for _, url := range wn.config.URLs {
+		if err := wn.postWithRetry(url, body); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// postWithRetry posts a body, retrying failures with exponential backoff
+func (wn *WebhookNotifier) postWithRetry(url string, body []byte) error {
+	backoff := wn.config.InitialBackoff
+
+	var lastErr error
+	for attempt := 1; attempt <= wn.config.MaxRetries+1; attempt++ {
+		lastErr = wn.post(url, body)
+		if lastErr == nil {
+			return nil
+		}
+		if attempt <= wn.config.MaxRetries {
+			select {
+			case <-time.After(backoff):
+			case <-wn.ctx.Done():
+				return fmt.Errorf("webhook %s abandoned on shutdown after %d attempts: %w", url, attempt, lastErr)
+			}
+			backoff *= 2
+			if backoff > wn.config.MaxBackoff {
+				backoff = wn.config.MaxBackoff
+			}
+		}
+	}
+
+	return fmt.Errorf("webhook %s failed after %d attempts: %w", url, wn.config.MaxRetries+1, lastErr)
+}
+
+// post sends a single signed request
+func (wn *WebhookNotifier) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(wn.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wn.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	// Sign timestamp and body so receivers can verify origin and reject replays
+	if wn.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(wn.config.Secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set("X-Monitor-Timestamp", timestamp)
+		req.Header.Set("X-Monitor-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// pairMetadata returns the metadata for a payload, or nil when none is set
+func pairMetadata(m config.PairMetadata) *config.PairMetadata {
+	if m.IsZero() {
+		return nil
+	}
+	return &m
+}
+
+// toJSON encodes a value as JSON for use inside templates
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,181 @@
+// Package report summarizes each database pair over a calendar day or a
+// Monday-to-Sunday week: its replica lag against its threshold, checksum and
+// row count failures, and outages.
+package report
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// Periods a report covers
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// Report summarizes every pair over one period
+type Report struct {
+	Period      string       `json:"period"`
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	Complete    bool         `json:"complete"` // false while the period lasts
+	GeneratedAt time.Time    `json:"generated_at"`
+	Pairs       []PairReport `json:"pairs"`
+}
+
+// PairReport summarizes one pair over the period of a report
+type PairReport struct {
+	Name string `json:"name"`
+
+	// Replica lag checks and the share of them with replication running and
+	// the lag within the lowest threshold tier; null without checks
+	Cycles                 int      `json:"cycles"`
+	WithinThresholdPercent *float64 `json:"within_threshold_percent"`
+
+	// Lag of the checks with replication running; null without one
+	MaxLagSeconds *float64 `json:"max_lag_seconds"`
+	AvgLagSeconds *float64 `json:"avg_lag_seconds"`
+
+	ChecksumRuns        int `json:"checksum_runs"`
+	ChecksumFailures    int `json:"checksum_failures"`
+	ChecksumErrors      int `json:"checksum_errors"`
+	ConsistencyRuns     int `json:"consistency_runs"`
+	ConsistencyFailures int `json:"consistency_failures"`
+	ConsistencyErrors   int `json:"consistency_errors"`
+
+	// Times the source or target was unreachable within the period; outages
+	// crossing its start or end are cut at them
+	Outages              int        `json:"outages"`
+	DowntimeSeconds      float64    `json:"downtime_seconds"`
+	LongestOutageSeconds float64    `json:"longest_outage_seconds"`
+	LongestOutageStart   *time.Time `json:"longest_outage_start,omitempty"`
+}
+
+// Bounds returns the start and end of the day or week containing a point in
+// time, in a timezone
+func Bounds(period string, at time.Time, location *time.Location) (time.Time, time.Time, error) {
+	local := at.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	switch period {
+	case PeriodDay:
+		return start, start.AddDate(0, 0, 1), nil
+	case PeriodWeek:
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7) // back to Monday
+		return start, start.AddDate(0, 0, 7), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("period must be '%s' or '%s', got '%s'", PeriodDay, PeriodWeek, period)
+	}
+}
+
+// Previous returns a point in time within the last complete day or week
+// before now
+func Previous(period string, now time.Time, location *time.Location) time.Time {
+	start, _, _ := Bounds(period, now, location)
+	return start.Add(-time.Nanosecond)
+}
+
+// Build summarizes every pair over the day or week containing a point in
+// time: the configured pairs in order, then removed pairs with results in the
+// period
+func Build(cfg *config.Config, store *storage.MetricsStorage, period string, at, now time.Time) (Report, error) {
+	_, location := store.ReportRetention() // the timezone days were aggregated in
+	start, end, err := Bounds(period, at, location)
+	if err != nil {
+		return Report{}, err
+	}
+	days, outages := store.GetReportData(start, end)
+
+	report := Report{
+		Period:      period,
+		Start:       start,
+		End:         end,
+		Complete:    !now.Before(end),
+		GeneratedAt: now,
+		Pairs:       make([]PairReport, 0),
+	}
+	names := make([]string, 0)
+	for _, pair := range cfg.Pairs() {
+		names = append(names, pair.Name)
+	}
+	removed := make([]string, 0)
+	for _, day := range days {
+		if !slices.Contains(names, day.DatabasePair) && !slices.Contains(removed, day.DatabasePair) {
+			removed = append(removed, day.DatabasePair)
+		}
+	}
+	slices.Sort(removed)
+
+	until := end
+	if now.Before(end) {
+		until = now
+	}
+	for _, name := range append(names, removed...) {
+		report.Pairs = append(report.Pairs, summarize(name, days, outages, start, until))
+	}
+	return report, nil
+}
+
+// summarize adds up the days and outages of a pair, cutting outages to
+// [start, end]
+func summarize(name string, days []storage.ReportDay, outages []storage.Outage, start, end time.Time) PairReport {
+	summary := PairReport{Name: name}
+
+	var within, lagSamples int
+	var lagTotal, maxLag float64
+	for _, day := range days {
+		if day.DatabasePair != name {
+			continue
+		}
+		summary.Cycles += day.Cycles
+		within += day.CyclesWithinThreshold
+		if day.LagSamples > 0 {
+			maxLag = max(maxLag, day.MaxLagSeconds)
+			lagTotal += day.AvgLagSeconds * float64(day.LagSamples)
+			lagSamples += day.LagSamples
+		}
+		summary.ChecksumRuns += day.ChecksumRuns
+		summary.ChecksumFailures += day.ChecksumFailures
+		summary.ChecksumErrors += day.ChecksumErrors
+		summary.ConsistencyRuns += day.ConsistencyRuns
+		summary.ConsistencyFailures += day.ConsistencyFailures
+		summary.ConsistencyErrors += day.ConsistencyErrors
+	}
+	if summary.Cycles > 0 {
+		percent := 100 * float64(within) / float64(summary.Cycles)
+		summary.WithinThresholdPercent = &percent
+	}
+	if lagSamples > 0 {
+		avg := lagTotal / float64(lagSamples)
+		summary.MaxLagSeconds, summary.AvgLagSeconds = &maxLag, &avg
+	}
+
+	for _, o := range outages {
+		if o.DatabasePair != name {
+			continue
+		}
+		from, to := o.Start, end
+		if from.Before(start) {
+			from = start
+		}
+		if !o.End.IsZero() && o.End.Before(end) {
+			to = o.End
+		}
+		if !to.After(from) {
+			continue
+		}
+		duration := to.Sub(from).Seconds()
+		summary.Outages++
+		summary.DowntimeSeconds += duration
+		if duration > summary.LongestOutageSeconds {
+			summary.LongestOutageSeconds = duration
+			outageStart := o.Start
+			summary.LongestOutageStart = &outageStart
+		}
+	}
+	return summary
+}
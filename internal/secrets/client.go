@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// Client reads Secrets Manager secrets and SSM parameters through the signed
+// JSON API
+type Client struct {
+	awsConfig aws.Config
+	client    *http.Client
+	signer    *v4.Signer
+}
+
+// NewClient creates a new Secrets Manager and SSM client
+func NewClient(awsConfig aws.Config, timeout time.Duration) *Client {
+	return &Client{
+		awsConfig: awsConfig,
+		client:    &http.Client{Timeout: timeout},
+		signer:    v4.NewSigner(),
+	}
+}
+
+// errorResponse is the JSON body of a failed request
+type errorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Get returns the value of a secret or parameter, without selecting a key
+func (c *Client) Get(ctx context.Context, ref config.SecretRef) (string, error) {
+	region := ref.Region
+	if region == "" {
+		region = c.awsConfig.Region
+	}
+	if region == "" {
+		return "", fmt.Errorf("no AWS region configured")
+	}
+
+	switch ref.Service {
+	case config.SecretsManager:
+		var resp struct {
+			SecretString *string `json:"SecretString"`
+		}
+		if err := c.do(ctx, region, "secretsmanager", "secretsmanager.GetSecretValue", map[string]interface{}{"SecretId": ref.ID}, &resp); err != nil {
+			return "", err
+		}
+		if resp.SecretString == nil {
+			return "", fmt.Errorf("secret '%s' has no string value", ref.ID)
+		}
+		return *resp.SecretString, nil
+	case config.SSMParameter:
+		var resp struct {
+			Parameter struct {
+				Value string `json:"Value"`
+			} `json:"Parameter"`
+		}
+		if err := c.do(ctx, region, "ssm", "AmazonSSM.GetParameter", map[string]interface{}{"Name": ref.ID, "WithDecryption": true}, &resp); err != nil {
+			return "", err
+		}
+		return resp.Parameter.Value, nil
+	}
+	return "", fmt.Errorf("unsupported secret service '%s'", ref.Service)
+}
+
+// do sends a SigV4-signed JSON API request and decodes the response into out
+func (c *Client) do(ctx context.Context, region, service, target string, input, out interface{}) error {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint(service, region), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("Content-Length", strconv.Itoa(len(payload)))
+	req.Header.Set("X-Amz-Target", target)
+
+	creds, err := c.awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), service, region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr errorResponse
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Type != "" {
+			// Types may be qualified, e.g. "com.amazonaws.ssm#ParameterNotFound"
+			code := apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+			return fmt.Errorf("%s: %s", code, apiErr.Message)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", target, err)
+	}
+	return nil
+}
+
+// endpoint returns the endpoint of a service in a region
+func endpoint(service, region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "https://" + service + "." + region + ".amazonaws.com.cn/"
+	}
+	return "https://" + service + "." + region + ".amazonaws.com/"
+}
@@ -0,0 +1,235 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// Resolver fills in database settings read from Secrets Manager and SSM
+// Parameter Store, and resolves them again periodically to pick up rotation
+type Resolver struct {
+	config   *config.Config
+	client   *Client
+	resolved map[string]config.DatabasePair // by name, as last resolved
+	stopChan chan struct{}
+}
+
+// NewResolver creates a resolver using the default AWS credential chain
+func NewResolver(cfg *config.Config) (*Resolver, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Secrets.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Secrets.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &Resolver{
+		config:   cfg,
+		client:   NewClient(awsCfg, cfg.Secrets.Timeout),
+		resolved: make(map[string]config.DatabasePair),
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// Resolve resolves the secret references of a configuration in place. It
+// returns a nil resolver when no database reads its settings from a secret.
+func Resolve(ctx context.Context, cfg *config.Config) (*Resolver, error) {
+	if !cfg.HasSecretRefs() {
+		return nil, nil
+	}
+	r, err := NewResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ResolveConfig(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ResolveConfig resolves the secret references of every database pair in place
+func (r *Resolver) ResolveConfig(ctx context.Context) error {
+	values := make(map[config.SecretRef]string)
+	for i := range r.config.DatabasePairs {
+		pair := &r.config.DatabasePairs[i]
+		if err := r.resolvePair(ctx, pair, values); err != nil {
+			return fmt.Errorf("database pair '%s': %w", pair.Name, err)
+		}
+		r.resolved[pair.Name] = copyPair(*pair)
+	}
+	return nil
+}
+
+// resolvePair resolves the references of every database of a pair
+func (r *Resolver) resolvePair(ctx context.Context, pair *config.DatabasePair, values map[config.SecretRef]string) error {
+	if err := r.resolveDatabase(ctx, &pair.SourceDB, values); err != nil {
+		return fmt.Errorf("source_db: %w", err)
+	}
+	for i := range pair.Intermediates {
+		if err := r.resolveDatabase(ctx, &pair.Intermediates[i].DB, values); err != nil {
+			return fmt.Errorf("intermediate '%s': %w", pair.Intermediates[i].Name, err)
+		}
+	}
+	if err := r.resolveDatabase(ctx, &pair.TargetDB, values); err != nil {
+		return fmt.Errorf("target_db: %w", err)
+	}
+	if pair.CheckEndpoints.HasSource() {
+		if err := r.resolveDatabase(ctx, &pair.CheckEndpoints.Source, values); err != nil {
+			return fmt.Errorf("check_endpoints.source: %w", err)
+		}
+	}
+	if pair.CheckEndpoints.HasTarget() {
+		if err := r.resolveDatabase(ctx, &pair.CheckEndpoints.Target, values); err != nil {
+			return fmt.Errorf("check_endpoints.target: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyPair copies a pair so that resolving it again leaves the original's
+// intermediates unchanged
+func copyPair(pair config.DatabasePair) config.DatabasePair {
+	pair.Intermediates = slices.Clone(pair.Intermediates)
+	return pair
+}
+
+// Start resolves the references again at the refresh interval and calls
+// onChange with each pair whose resolved settings changed
+func (r *Resolver) Start(onChange func(pair config.DatabasePair)) {
+	log.Printf("Refreshing database secrets every %v", r.config.Secrets.RefreshInterval)
+	go r.refreshLoop(onChange)
+}
+
+// Stop stops refreshing secrets
+func (r *Resolver) Stop() {
+	close(r.stopChan)
+}
+
+// refreshLoop refreshes the secrets at the configured interval
+func (r *Resolver) refreshLoop(onChange func(pair config.DatabasePair)) {
+	ticker := time.NewTicker(r.config.Secrets.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.refresh(onChange)
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// refresh resolves the references of every pair once. Pairs whose secrets
+// cannot be read keep their current settings.
+func (r *Resolver) refresh(onChange func(pair config.DatabasePair)) {
+	ctx := context.Background() // each request is bounded by the client timeout
+	values := make(map[config.SecretRef]string)
+	for _, pair := range r.config.Pairs() {
+		// Pairs added at runtime have no secret references
+		current, ok := r.resolved[pair.Name]
+		if !ok {
+			continue
+		}
+		updated := copyPair(current)
+		if err := r.resolvePair(ctx, &updated, values); err != nil {
+			log.Printf("[%s] Failed to refresh database secrets: %v", pair.Name, err)
+			continue
+		}
+		if sameSecrets(updated, current) {
+			continue
+		}
+
+		log.Printf("[%s] Database secrets changed, reconnecting", pair.Name)
+		r.resolved[pair.Name] = updated
+		onChange(copyPair(updated))
+	}
+}
+
+// resolveDatabase fills in the host, username and password of a database
+// from its references. values caches secret values across databases.
+func (r *Resolver) resolveDatabase(ctx context.Context, db *config.DatabaseConfig, values map[config.SecretRef]string) error {
+	for _, field := range []struct {
+		name  string
+		ref   string
+		value *string
+	}{
+		{"host", db.HostFrom, &db.Host},
+		{"username", db.UsernameFrom, &db.Username},
+		{"password", db.PasswordFrom, &db.Password},
+	} {
+		if field.ref == "" {
+			continue
+		}
+		ref, err := config.ParseSecretRef(field.ref)
+		if err != nil {
+			return fmt.Errorf("%s_from: %w", field.name, err)
+		}
+
+		// Several fields often read the same secret
+		key := ref.Key
+		ref.Key = ""
+		value, ok := values[ref]
+		if !ok {
+			if value, err = r.client.Get(ctx, ref); err != nil {
+				return fmt.Errorf("%s_from: failed to read '%s': %w", field.name, ref.ID, err)
+			}
+			values[ref] = value
+		}
+
+		if *field.value, err = selectKey(value, key, field.name); err != nil {
+			return fmt.Errorf("%s_from: '%s': %w", field.name, ref.ID, err)
+		}
+	}
+	return nil
+}
+
+// selectKey returns a key of a JSON secret value, or the value itself when it
+// is not a JSON object. Keys default to the field's name, which matches the
+// secrets RDS manages.
+func selectKey(value, key, field string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if key != "" {
+			return "", fmt.Errorf("value is not a JSON object, so key '%s' cannot be selected", key)
+		}
+		return value, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("invalid JSON value: %w", err)
+	}
+	if key == "" {
+		key = field
+	}
+	switch v := fields[key].(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("key '%s' not found", key)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// sameSecrets reports whether the databases of two versions of a pair have
+// the same settings that can be read from secrets
+func sameSecrets(a, b config.DatabasePair) bool {
+	dbsA, dbsB := a.Databases(), b.Databases()
+	for i := range dbsA {
+		if dbsA[i].Host != dbsB[i].Host || dbsA[i].Username != dbsB[i].Username || dbsA[i].Password != dbsB[i].Password {
+			return false
+		}
+	}
+	return true
+}
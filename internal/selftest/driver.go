@@ -0,0 +1,291 @@
+package selftest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"mariadb-encryption-monitor/internal/tracing"
+)
+
+// driverName is the scripted database driver, traced like the MySQL driver
+const driverName = "selftest"
+
+// Binary log position of the synthetic source, which the target has read up to
+const (
+	binlogFile       = "mysql-bin.000042"
+	binlogPosition   = int64(104857600)
+	binlogEventBytes = int64(65536)
+)
+
+// activeScript is the script the scripted database answers from
+var activeScript atomic.Pointer[Script]
+
+func init() {
+	sql.Register(driverName, tracing.WrapDriver(scriptedDriver{}, func(string) []tracing.Attribute {
+		return []tracing.Attribute{tracing.String("db.system", "selftest")}
+	}))
+}
+
+// scriptedDriver opens connections to the synthetic source or target database
+type scriptedDriver struct{}
+
+// Open connects to the database of the DSN's host
+func (scriptedDriver) Open(dsn string) (driver.Conn, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	script := activeScript.Load()
+	if script == nil {
+		return nil, fmt.Errorf("self-test script is not prepared")
+	}
+
+	conn := &scriptedConn{script: script, target: strings.HasPrefix(cfg.Addr, targetHost+":")}
+	if err := conn.reachable(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sourceVariables and targetVariables are the global variables of the
+// synthetic databases: the target encrypts, the source does not
+var (
+	sourceVariables = [][2]string{
+		{"slave_skip_errors", "OFF"}, {"sql_slave_skip_counter", "0"}, {"binlog_format", "ROW"}, {"log_bin", "ON"},
+		{"gtid_strict_mode", "OFF"}, {"innodb_encrypt_tables", "OFF"}, {"innodb_encrypt_log", "OFF"},
+		{"innodb_encrypt_temporary_tables", "OFF"}, {"innodb_encryption_threads", "0"}, {"encrypt_binlog", "OFF"}, {"encrypt_tmp_files", "OFF"},
+	}
+	targetVariables = [][2]string{
+		{"slave_skip_errors", "OFF"}, {"sql_slave_skip_counter", "0"}, {"binlog_format", "ROW"}, {"log_bin", "ON"},
+		{"gtid_strict_mode", "OFF"}, {"innodb_encrypt_tables", "ON"}, {"innodb_encrypt_log", "ON"}, {"innodb_default_encryption_key_id", "1"},
+		{"innodb_encrypt_temporary_tables", "OFF"}, {"innodb_encryption_threads", "4"}, {"encrypt_binlog", "ON"}, {"encrypt_tmp_files", "OFF"},
+	}
+)
+
+// scriptedConn answers the monitor's queries from the current scenario
+type scriptedConn struct {
+	script   *Script
+	target   bool
+	readOnly bool // the session only allows read-only transactions
+}
+
+// reachable fails for the target while the connection scenario plays
+func (c *scriptedConn) reachable() error {
+	if c.target && c.script.Current() == ScenarioConnection {
+		return fmt.Errorf("self-test: target database unreachable (scenario '%s')", ScenarioConnection)
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable
+func (c *scriptedConn) Ping(ctx context.Context) error {
+	return c.reachable()
+}
+
+// QueryContext answers a query of the monitor
+func (c *scriptedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.reachable(); err != nil {
+		return nil, err
+	}
+	scenario := c.script.Current()
+
+	switch {
+	case query == "SHOW SLAVE STATUS":
+		if !c.target {
+			return &scriptedRows{}, nil
+		}
+		lag := int64(1)
+		if scenario == ScenarioLag {
+			lag = c.script.lagSeconds
+		}
+		// The SQL thread trails the IO thread by a binlog event per second of lag
+		execPos := binlogPosition - lag*binlogEventBytes
+		return &scriptedRows{
+			columns: []string{"Slave_IO_Running", "Slave_SQL_Running", "Seconds_Behind_Master", "Connect_Retry",
+				"Master_Log_File", "Read_Master_Log_Pos", "Relay_Master_Log_File", "Exec_Master_Log_Pos", "Replicate_Do_DB", "Replicate_Ignore_Table", "Skip_Counter",
+				"Last_IO_Errno", "Last_IO_Error", "Last_SQL_Errno", "Last_SQL_Error"},
+			values: [][]driver.Value{{[]byte("Yes"), []byte("Yes"), lag, int64(60),
+				[]byte(binlogFile), binlogPosition, []byte(binlogFile), execPos, []byte(""), []byte(""), int64(0),
+				int64(0), []byte(""), int64(0), []byte("")}},
+		}, nil
+
+	// MariaDB reports the heartbeat period per connection here only
+	case query == "SHOW ALL SLAVES STATUS":
+		if !c.target {
+			return &scriptedRows{}, nil
+		}
+		return &scriptedRows{columns: []string{"Connection_name", "Slave_heartbeat_period"}, values: [][]driver.Value{{[]byte(""), 30.0}}}, nil
+
+	case query == "SELECT @@global.master_retry_count":
+		return &scriptedRows{columns: []string{"@@global.master_retry_count"}, values: [][]driver.Value{{int64(86400)}}}, nil
+
+	case query == "SHOW MASTER STATUS":
+		if c.target {
+			return &scriptedRows{}, nil
+		}
+		return &scriptedRows{
+			columns: []string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB"},
+			values:  [][]driver.Value{{[]byte(binlogFile), binlogPosition, []byte(""), []byte("")}},
+		}, nil
+
+	case query == "SHOW GLOBAL STATUS LIKE 'Binlog_%'":
+		if c.target {
+			return &scriptedRows{}, nil
+		}
+		return &scriptedRows{columns: []string{"Variable_name", "Value"}, values: [][]driver.Value{{[]byte("Binlog_commits"), []byte("1000")}}}, nil
+
+	case strings.HasPrefix(query, "SHOW GLOBAL VARIABLES WHERE Variable_name IN "):
+		// Answer the variables asked for, as a server that has them would
+		variables := sourceVariables
+		if c.target {
+			variables = targetVariables
+		}
+		rows := &scriptedRows{columns: []string{"Variable_name", "Value"}}
+		for _, v := range variables {
+			if strings.Contains(query, "'"+v[0]+"'") {
+				rows.values = append(rows.values, []driver.Value{[]byte(v[0]), []byte(v[1])})
+			}
+		}
+		return rows, nil
+
+	case query == "SELECT VERSION()":
+		return &scriptedRows{columns: []string{"VERSION()"}, values: [][]driver.Value{{[]byte("10.11.6-MariaDB-selftest")}}}, nil
+
+	case query == "SELECT DATABASE()":
+		return &scriptedRows{columns: []string{"DATABASE()"}, values: [][]driver.Value{{[]byte(schemaName)}}}, nil
+
+	case strings.Contains(query, "information_schema.INNODB_TABLESPACES_ENCRYPTION"):
+		// The target is encrypted, the source is not
+		encrypted := int64(0)
+		if c.target {
+			encrypted = 1
+		}
+		rows := &scriptedRows{columns: []string{"NAME", "encrypted"}}
+		for _, table := range slices.Sorted(maps.Keys(tableRows)) {
+			rows.values = append(rows.values, []driver.Value{[]byte(schemaName + "/" + table), encrypted})
+		}
+		return rows, nil
+
+	case strings.HasPrefix(query, "SELECT 1 FROM ") && strings.HasSuffix(query, " LIMIT 0"):
+		// Pre-flight probe of SELECT on a table
+		table := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(query, "SELECT 1 FROM "), " LIMIT 0"), "`")
+		if _, ok := tableRows[table]; !ok {
+			return nil, fmt.Errorf("table '%s.%s' doesn't exist", schemaName, table)
+		}
+		return &scriptedRows{columns: []string{"1"}}, nil
+
+	case strings.HasPrefix(query, "SHOW CREATE TABLE "):
+		// The target's tables are encrypted by innodb_encrypt_tables, the
+		// orders table explicitly with its own key
+		table := strings.Trim(strings.TrimPrefix(query, "SHOW CREATE TABLE "), "`")
+		if _, ok := tableRows[table]; !ok {
+			return nil, fmt.Errorf("table '%s.%s' doesn't exist", schemaName, table)
+		}
+		options := "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ROW_FORMAT=DYNAMIC"
+		if c.target && table == "orders" {
+			options += " `ENCRYPTED`=YES `ENCRYPTION_KEY_ID`=2"
+		}
+		definition := fmt.Sprintf("CREATE TABLE `%s` (\n  `id` bigint(20) NOT NULL,\n  PRIMARY KEY (`id`)\n) %s", table, options)
+		return &scriptedRows{columns: []string{"Table", "Create Table"}, values: [][]driver.Value{{[]byte(table), []byte(definition)}}}, nil
+
+	case query == "SELECT 1 FROM DUAL WHERE @@global.log_bin = 1":
+		return &scriptedRows{columns: []string{"1"}, values: [][]driver.Value{{int64(1)}}}, nil
+
+	case strings.HasPrefix(query, "SELECT PLUGIN_NAME FROM information_schema.PLUGINS "):
+		return &scriptedRows{columns: []string{"PLUGIN_NAME"}, values: [][]driver.Value{{[]byte("file_key_management")}}}, nil
+
+	case query == "SET SESSION TRANSACTION READ ONLY":
+		c.readOnly = true
+		return &scriptedRows{}, nil
+
+	case query == "SELECT @@session.tx_read_only":
+		readOnly := int64(0)
+		if c.readOnly {
+			readOnly = 1
+		}
+		return &scriptedRows{columns: []string{"@@session.tx_read_only"}, values: [][]driver.Value{{readOnly}}}, nil
+
+	case query == "SELECT @@global.read_only":
+		return &scriptedRows{columns: []string{"@@global.read_only"}, values: [][]driver.Value{{int64(0)}}}, nil
+
+	case query == "SELECT UTC_TIMESTAMP(6)":
+		return &scriptedRows{columns: []string{"UTC_TIMESTAMP(6)"}, values: [][]driver.Value{{time.Now().UTC()}}}, nil
+
+	case strings.HasPrefix(query, "CHECKSUM TABLE "):
+		table := strings.Trim(strings.TrimPrefix(query, "CHECKSUM TABLE "), "`")
+		var checksum driver.Value
+		if _, ok := tableRows[table]; ok {
+			sum := int64(crc32.ChecksumIEEE([]byte(table)))
+			if c.target && scenario == ScenarioMismatch && table == mismatchTable {
+				sum++
+			}
+			checksum = sum
+		}
+		return &scriptedRows{columns: []string{"Table", "Checksum"}, values: [][]driver.Value{{schemaName + "." + table, checksum}}}, nil
+
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM "):
+		table := strings.Trim(strings.TrimPrefix(query, "SELECT COUNT(*) FROM "), "`")
+		count, ok := tableRows[table]
+		if !ok {
+			return nil, fmt.Errorf("table '%s.%s' doesn't exist", schemaName, table)
+		}
+		if c.target && scenario == ScenarioMismatch && table == mismatchTable {
+			count -= c.script.driftRows
+		}
+		return &scriptedRows{columns: []string{"COUNT(*)"}, values: [][]driver.Value{{count}}}, nil
+	}
+
+	return nil, fmt.Errorf("self-test database does not support query: %s", query)
+}
+
+// Prepare is not supported; all queries run through QueryContext
+func (c *scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("self-test database does not support prepared statements")
+}
+
+// Begin is not supported; the monitor only reads
+func (c *scriptedConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("self-test database does not support transactions")
+}
+
+// Close closes the connection
+func (c *scriptedConn) Close() error {
+	return nil
+}
+
+// scriptedRows is a fixed result set
+type scriptedRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+// Columns returns the column names of the result set
+func (r *scriptedRows) Columns() []string {
+	return r.columns
+}
+
+// Close closes the result set
+func (r *scriptedRows) Close() error {
+	return nil
+}
+
+// Next copies the next row into dest
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
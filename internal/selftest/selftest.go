@@ -0,0 +1,144 @@
+// Package selftest runs the monitor against a scripted in-memory database so
+// operators can verify notifiers and dashboards before pointing it at
+// production databases.
+package selftest
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/database"
+)
+
+// Scenarios that can be scripted
+const (
+	ScenarioLag        = "lag"        // replica lag above the highest threshold tier
+	ScenarioMismatch   = "mismatch"   // checksum and row count differences on one table
+	ScenarioConnection = "connection" // target database unreachable
+)
+
+// DefaultScenarios are played when no scenarios are selected
+var DefaultScenarios = []string{ScenarioLag, ScenarioMismatch, ScenarioConnection}
+
+// Synthetic database pair served by the scripted database
+const (
+	pairName   = "self-test"
+	sourceHost = "selftest-source"
+	targetHost = "selftest-target"
+	schemaName = "selftest"
+)
+
+// tableRows are the tables of the synthetic pair and their row counts
+var tableRows = map[string]int64{
+	"orders":    120000,
+	"customers": 8500,
+	"payments":  64000,
+}
+
+// mismatchTable is the table that differs during the mismatch scenario
+const mismatchTable = "orders"
+
+// Script plays the selected scenarios one after another. Each scenario lasts
+// one phase and is followed by a healthy phase so alerts resolve, and the
+// script starts over after the last scenario.
+type Script struct {
+	scenarios  []string
+	phase      time.Duration
+	started    time.Time
+	lagSeconds int64 // replica lag during the lag scenario
+	driftRows  int64 // missing target rows during the mismatch scenario
+
+	mu      sync.Mutex
+	current string
+}
+
+// Prepare replaces the database pairs of cfg with a synthetic pair served by
+// the scripted database and starts the script. A zero phase defaults to
+// three monitoring intervals, with at least one minute.
+func Prepare(cfg *config.Config, scenarios []string, phase time.Duration) (*Script, error) {
+	var selected []string
+	for _, scenario := range scenarios {
+		scenario = strings.TrimSpace(scenario)
+		switch scenario {
+		case "":
+			continue
+		case ScenarioLag, ScenarioMismatch, ScenarioConnection:
+			selected = append(selected, scenario)
+		default:
+			return nil, fmt.Errorf("unknown self-test scenario '%s' (expected %s)", scenario, strings.Join(DefaultScenarios, ", "))
+		}
+	}
+	if len(selected) == 0 {
+		selected = DefaultScenarios
+	}
+
+	if phase == 0 {
+		phase = max(time.Minute, 3*cfg.MonitoringInterval)
+	}
+	if phase < 2*cfg.MonitoringInterval {
+		return nil, fmt.Errorf("self-test phase %v must be at least twice the monitoring interval (%v)", phase, cfg.MonitoringInterval)
+	}
+
+	tables := slices.Sorted(maps.Keys(tableRows))
+	cfg.DatabasePairs = []config.DatabasePair{{
+		Name:             pairName,
+		SourceDB:         config.DatabaseConfig{Host: sourceHost, Port: 3306, Username: "selftest", Database: schemaName},
+		TargetDB:         config.DatabaseConfig{Host: targetHost, Port: 3306, Username: "selftest", Database: schemaName},
+		TablesToMonitor:  tables,
+		EncryptionStatus: config.EncryptionStatusConfig{Enabled: true},
+		TableDefinitions: config.TableDefinitionsConfig{Enabled: true, SourceUnencrypted: true},
+	}}
+	// Chat notifiers routed to configured pairs receive the synthetic pair's alerts
+	for i := range cfg.Notifiers.Telegram {
+		cfg.Notifiers.Telegram[i].Pairs = nil
+	}
+	for i := range cfg.Notifiers.Teams {
+		cfg.Notifiers.Teams[i].Pairs = nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	lag := max(cfg.Thresholds.ReplicaLag.WarningAt, cfg.Thresholds.ReplicaLag.CriticalAt)
+	drift := max(cfg.Thresholds.RowCountDrift.WarningAt, cfg.Thresholds.RowCountDrift.CriticalAt)
+	script := &Script{
+		scenarios:  selected,
+		phase:      phase,
+		started:    time.Now(),
+		lagSeconds: int64(2 * lag.Seconds()),
+		driftRows:  2 * drift,
+	}
+
+	activeScript.Store(script)
+	database.SetDriver(driverName)
+	log.Printf("Self-test: monitoring synthetic pair '%s'; playing %s for %v each, with %v of recovery in between",
+		pairName, strings.Join(selected, ", "), phase, phase)
+	return script, nil
+}
+
+// Current returns the scenario playing now, or "" while healthy
+func (s *Script) Current() string {
+	step := int(time.Since(s.started)/s.phase) % (2 * len(s.scenarios))
+	scenario := ""
+	if step%2 == 1 {
+		scenario = s.scenarios[step/2]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if scenario != s.current {
+		if scenario == "" {
+			log.Printf("Self-test: scenario '%s' ended, databases are healthy again", s.current)
+		} else {
+			log.Printf("Self-test: starting scenario '%s' for %v", scenario, s.phase)
+		}
+		s.current = scenario
+	}
+	return scenario
+}
@@ -0,0 +1,195 @@
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// maxPacketSize keeps batched packets below a typical network MTU
+const maxPacketSize = 1432
+
+// Tag is a key/value pair attached to a metric
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Client pushes metrics to a StatsD or DogStatsD agent over UDP. Metrics are
+// batched and sent at the flush interval. A nil Client discards metrics.
+type Client struct {
+	config   config.StatsDConfig
+	conn     net.Conn
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	failing  bool // a send error was logged; logged again only after a success
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewClient creates a client for the configured agent address
+func NewClient(cfg config.StatsDConfig) (*Client, error) {
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve statsd address: %w", err)
+	}
+
+	return &Client{
+		config:   cfg,
+		conn:     conn,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start flushes batched metrics in the background
+func (c *Client) Start() {
+	log.Printf("Pushing %s metrics to %s (prefix: %s)", c.config.Format, c.config.Address, c.config.Prefix)
+	go c.flushLoop()
+}
+
+// Stop flushes pending metrics and closes the connection
+func (c *Client) Stop() {
+	close(c.stopChan)
+	<-c.done
+	c.conn.Close()
+}
+
+// Gauge records the current value of a metric
+func (c *Client) Gauge(name string, value float64, tags ...Tag) {
+	c.add(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// Count adds to a counter
+func (c *Client) Count(name string, value int64, tags ...Tag) {
+	c.add(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Timing records a duration in milliseconds
+func (c *Client) Timing(name string, d time.Duration, tags ...Tag) {
+	c.add(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64), "ms", tags)
+}
+
+// add formats a metric line and appends it to the current batch
+func (c *Client) add(name, value, metricType string, tags []Tag) {
+	if c == nil {
+		return
+	}
+
+	line := c.format(name, value, metricType, tags)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.buf.Len() > 0 && c.buf.Len()+1+len(line) > maxPacketSize {
+		c.flushLocked()
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(line)
+}
+
+// format renders a metric line. DogStatsD receives tags; plain StatsD has no
+// tags, so tag values are folded into the metric name instead.
+func (c *Client) format(name, value, metricType string, tags []Tag) string {
+	var b strings.Builder
+	b.WriteString(c.config.Prefix)
+	b.WriteByte('.')
+	b.WriteString(name)
+
+	if c.config.Format == config.StatsDFormatStatsD {
+		for _, tag := range tags {
+			b.WriteByte('.')
+			b.WriteString(sanitize(tag.Value, true))
+		}
+		fmt.Fprintf(&b, ":%s|%s", value, metricType)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ":%s|%s", value, metricType)
+	if len(tags)+len(c.config.Tags) > 0 {
+		b.WriteString("|#")
+		first := true
+		for _, tag := range c.config.Tags {
+			if !first {
+				b.WriteByte(',')
+			}
+			b.WriteString(tag)
+			first = false
+		}
+		for _, tag := range tags {
+			if !first {
+				b.WriteByte(',')
+			}
+			b.WriteString(sanitize(tag.Key, false))
+			b.WriteByte(':')
+			b.WriteString(sanitize(tag.Value, false))
+			first = false
+		}
+	}
+	return b.String()
+}
+
+// flushLoop sends the batch at the flush interval until stopped
+func (c *Client) flushLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.stopChan:
+			c.flush()
+			return
+		}
+	}
+}
+
+// flush sends the current batch
+func (c *Client) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked sends the current batch; c.mu must be held
+func (c *Client) flushLocked() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	_, err := c.conn.Write(c.buf.Bytes())
+	c.buf.Reset()
+
+	switch {
+	case err != nil && !c.failing:
+		log.Printf("Failed to send statsd metrics to %s: %v", c.config.Address, err)
+		c.failing = true
+	case err == nil && c.failing:
+		log.Printf("Sending statsd metrics to %s again", c.config.Address)
+		c.failing = false
+	}
+}
+
+// sanitize replaces characters that are reserved by the line protocol. Names
+// built from tag values also avoid the '.' separator.
+func sanitize(s string, inName bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ':' || r == '|' || r == ',' || r == '#' || r == '@' || r == '\n' || r == ' ':
+			return '_'
+		case inName && r == '.':
+			return '_'
+		}
+		return r
+	}, s)
+}
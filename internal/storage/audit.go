@@ -0,0 +1,49 @@
+package storage
+
+import "time"
+
+// AuditEntry records an action an operator took through the API: who took
+// it, what it changed, when, and why
+type AuditEntry struct {
+	Timestamp    time.Time
+	Actor        string // authenticated subject, e.g. a basic auth user or an OIDC email
+	Role         string
+	ClientIP     string
+	Action       string // e.g. alert.acknowledge, pair.pause, check.pause
+	DatabasePair string `json:",omitempty"`
+	Target       string `json:",omitempty"` // what the action applied to, e.g. an alert ID or a check name
+	Reason       string `json:",omitempty"`
+	Details      string `json:",omitempty"`
+}
+
+// RecordAudit appends an operator action to the audit log. Entries are kept
+// as long as replication events.
+func (ms *MetricsStorage) RecordAudit(entry AuditEntry) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = ms.clock.Now()
+	}
+	ms.auditLog = append(ms.auditLog, entry)
+	ms.auditLog = trimHistory(ms.auditLog, func(e AuditEntry) time.Time { return e.Timestamp },
+		ms.clock.Now().Add(-ms.eventRetention), ms.maxEvents)
+}
+
+// GetAuditLog returns the operator actions of the specified duration, oldest
+// first
+func (ms *MetricsStorage) GetAuditLog(duration time.Duration) []AuditEntry {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]AuditEntry, 0)
+
+	for _, e := range ms.auditLog {
+		if e.Timestamp.After(cutoff) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"mariadb-encryption-monitor/internal/clock"
 )
 
 // ConnectionStatus represents database connection status
@@ -10,6 +15,58 @@ type ConnectionStatus struct {
 	SourceConnected bool
 	TargetConnected bool
 	LastChecked     time.Time
+
+	// @@global.read_only of each database, checked by the heartbeat of pairs
+	// whose migration is complete; nil when not checked
+	SourceReadOnly *bool `json:",omitempty"`
+	TargetReadOnly *bool `json:",omitempty"`
+
+	// Next probe of a database whose circuit breaker is open after repeated
+	// failed health checks; nil while it is checked every cycle
+	SourceRetryAt *time.Time `json:",omitempty"`
+	TargetRetryAt *time.Time `json:",omitempty"`
+
+	Rehearsal string `json:",omitempty"` // ID of the rehearsal fault reporting a database unreachable
+}
+
+// ConnectionSample records the connection state of a pair at one check
+type ConnectionSample struct {
+	DatabasePair    string
+	Timestamp       time.Time
+	SourceConnected bool
+	TargetConnected bool
+}
+
+// HealthScore is a pair's composite 0-100 health score and the inputs it was
+// computed from. Inputs without data in the scoring window are omitted.
+type HealthScore struct {
+	DatabasePair string
+	Timestamp    time.Time
+	Score        float64
+	Components   map[string]float64 // input name to its 0-100 score
+}
+
+// Insight is a human-readable finding about a pair, derived from its recent
+// results by a rule
+type Insight struct {
+	DatabasePair string
+	Rule         string
+	Message      string
+	Since        time.Time // when the finding started
+}
+
+// DivergenceCounter counts the checks of a table of a dual_write pair in
+// which the source and target diverged: a checksum mismatch or a row count
+// difference
+type DivergenceCounter struct {
+	DatabasePair  string
+	TableName     string
+	Checks        int64     // checks since monitoring started
+	Divergent     int64     // checks in which the databases diverged
+	Consecutive   int       // divergent checks in a row, up to the latest
+	DivergedSince time.Time // first check of the current divergent run
+	LastDiverged  time.Time
+	LastChecked   time.Time
 }
 
 // ReplicaLagMetric represents replica lag measurement
@@ -19,15 +76,92 @@ type ReplicaLagMetric struct {
 	LagSeconds   float64
 	Status       string
 	Error        error
+
+	IORunning        string
+	SQLRunning       string
+	HeartbeatPeriod  *float64 `json:",omitempty"` // nil when the replica does not report it
+	ConnectRetry     int64
+	MasterRetryCount *int64 `json:",omitempty"` // nil when the replica does not report it
+
+	// Last errors of the replication threads, e.g. why the SQL thread stopped
+	LastIOErrno  int64  `json:",omitempty"`
+	LastIOError  string `json:",omitempty"`
+	LastSQLErrno int64  `json:",omitempty"`
+	LastSQLError string `json:",omitempty"`
+
+	// Bytes of binary log the replication threads are behind
+	Backlog *BinlogBacklog `json:",omitempty"`
+
+	// Write rate of the source's binary log, to tell lag caused by a write
+	// burst from lag caused by slow applying
+	SourceWrites *BinlogRate `json:",omitempty"`
+
+	// Lag of each hop of a chained replication topology, ending with the
+	// target; LagSeconds is then their sum
+	Hops []HopLag `json:",omitempty"`
+
+	Rehearsal string `json:",omitempty"` // ID of the rehearsal fault the sample was replaced by
+
+	// Lowest lag threshold tier of the pair when sampled; 0 without one
+	ThresholdSeconds float64 `json:",omitempty"`
+}
+
+// BinlogBacklog is how far the IO and SQL threads of a replica are behind in
+// the primary's binary log. Byte counts are nil when unknown.
+type BinlogBacklog struct {
+	SourceFile string `json:",omitempty"`
+	SourcePos  int64  `json:",omitempty"`
+	ReadFile   string
+	ReadPos    int64
+	ExecFile   string
+	ExecPos    int64
+	IOBytes    *int64
+	SQLBytes   *int64
+	Bottleneck string // "io", "sql", or "" when neither thread is behind
+}
+
+// BinlogRate is how fast the source writes its binary log. Rates are nil
+// until two samples are known.
+type BinlogRate struct {
+	File              string
+	Position          int64
+	BytesPerSecond    *float64
+	EventsPerSecond   *float64 // transactions written to the binary log
+	AvgBytesPerSecond float64
+	Burst             bool // at least twice the average rate
+}
+
+// HopLag is the replication lag of one hop of a replication chain
+type HopLag struct {
+	Name       string
+	LagSeconds float64
+	Status     string
+	Error      string `json:",omitempty"`
+}
+
+// ReplicationEvent records a replication thread changing state
+type ReplicationEvent struct {
+	DatabasePair string
+	Timestamp    time.Time
+	Thread       string // "io" (Slave_IO_Running) or "sql" (Slave_SQL_Running)
+	From         string
+	To           string
 }
 
 // ChecksumResult represents the result of a checksum validation
 type ChecksumResult struct {
 	DatabasePair   string
 	TableName      string
+	TargetTable    string
 	SourceChecksum string
 	TargetChecksum string
 	Match          bool
+	Skipped        bool
+	EstimatedRows  int64
+	EstimatedBytes int64
+	Excluded       []string `json:",omitempty"` // columns left out of the checksum
+	View           bool     `json:",omitempty"` // hashed column by column as a view
+	Rehearsal      string   `json:",omitempty"` // ID of the rehearsal fault the result was replaced by
 	Timestamp      time.Time
 	Error          error
 }
@@ -36,83 +170,443 @@ type ChecksumResult struct {
 type ConsistencyResult struct {
 	DatabasePair   string
 	TableName      string
+	TargetTable    string
 	SourceRowCount int64
 	TargetRowCount int64
 	Consistent     bool
+	Approximate    bool
+	Tolerance      float64 // allowed relative difference in percent
+	Backfill       string  // ID of the backfill the comparison was relaxed for
+	Rehearsal      string  `json:",omitempty"` // ID of the rehearsal fault the result was replaced by
 	Timestamp      time.Time
 	Error          error
+
+	// Key range and recent rows of an append-only table; WindowOnly when the
+	// full row count was skipped and the row counts above are zero
+	Window     *ConsistencyWindow `json:",omitempty"`
+	WindowOnly bool               `json:",omitempty"`
+}
+
+// ConsistencyWindow compares the rows an append-only table gained between
+// From and To, and its lowest and highest primary key
+type ConsistencyWindow struct {
+	Column      string
+	From        time.Time
+	To          time.Time
+	SourceCount int64
+	TargetCount int64
+	PrimaryKey  string `json:",omitempty"`
+	SourceMinPK string `json:",omitempty"`
+	TargetMinPK string `json:",omitempty"`
+	SourceMaxPK string `json:",omitempty"`
+	TargetMaxPK string `json:",omitempty"`
+	Problems    []string
+}
+
+// ClockSkewMetric represents clock differences between the monitor and both databases
+type ClockSkewMetric struct {
+	DatabasePair            string
+	Timestamp               time.Time
+	SourceSkewSeconds       float64
+	TargetSkewSeconds       float64
+	SourceTargetSkewSeconds float64
+	Error                   error
+}
+
+// RDSInstanceMetrics holds the latest CloudWatch datapoints of an RDS instance.
+// A nil value means CloudWatch returned no datapoint.
+type RDSInstanceMetrics struct {
+	InstanceID            string
+	ReplicaLagSeconds     *float64
+	CPUUtilization        *float64 // percent
+	FreeStorageSpaceBytes *float64
+	BinLogDiskUsageBytes  *float64
+	Error                 string
+}
+
+// RDSMetric represents CloudWatch metrics for the RDS instances of a database pair
+type RDSMetric struct {
+	DatabasePair string
+	Timestamp    time.Time
+	Source       *RDSInstanceMetrics // nil when no source instance is configured
+	Target       *RDSInstanceMetrics // nil when no target instance is configured
+}
+
+// WarmupQueryResult is the plan check of one representative query on the target
+type WarmupQueryResult struct {
+	Name           string
+	Keys           []string
+	FullScanTables []string
+	Ready          bool
+	Problem        string
+}
+
+// WarmupResult represents the warm-up state of a pair's target
+type WarmupResult struct {
+	DatabasePair          string
+	Timestamp             time.Time
+	BufferPoolHitRate     float64 // percent
+	HitRateSinceStartup   bool
+	BufferPoolFillPercent float64
+	MinBufferPoolHitRate  float64
+	Queries               []WarmupQueryResult
+	Ready                 bool
+	Problems              []string
+	Error                 string
+}
+
+// PTChecksumDiff is a chunk whose row count or checksum differs between the
+// source and the replica in pt-table-checksum's results
+type PTChecksumDiff struct {
+	Chunk         int
+	Index         string `json:",omitempty"` // index the chunk was selected by
+	LowerBoundary string `json:",omitempty"`
+	UpperBoundary string `json:",omitempty"`
+	SourceCount   int64
+	TargetCount   int64
+	SourceCRC     string
+	TargetCRC     string
+}
+
+// PTChecksumTable summarizes pt-table-checksum's results for one table
+type PTChecksumTable struct {
+	TableName  string
+	Chunks     int
+	DiffChunks int
+	Rows       int64            // rows checksummed on the source
+	LastRun    time.Time        // when the newest chunk was checksummed
+	Diffs      []PTChecksumDiff `json:",omitempty"` // first differing chunks, up to pt_checksum.max_diffs
+}
+
+// PTChecksumReport is the last read of pt-table-checksum's results table
+// for a database pair
+type PTChecksumReport struct {
+	DatabasePair string
+	Timestamp    time.Time
+	ReadFrom     string // "source" or "target"
+	Tables       []PTChecksumTable
+	Error        string
+}
+
+// SemiSyncMetric represents the semi-sync replication state of a database pair
+type SemiSyncMetric struct {
+	DatabasePair     string
+	Timestamp        time.Time
+	SourceEnabled    bool
+	SourceActive     bool
+	TargetEnabled    bool
+	TargetActive     bool
+	TimeoutSeconds   float64
+	Clients          int64
+	AckedTx          int64
+	AsyncTx          int64
+	Fallbacks        int64
+	TxWaits          int64
+	AvgTxWaitSeconds float64
+	WaitSessions     int64
+	NewAsyncTx       int64 // since the previous check
+	NewFallbacks     int64 // since the previous check
+	Async            bool
+	Problems         []string
+	Error            string
+}
+
+// ReplicationFilters represents the replication filters and skip settings of
+// a database pair's target
+type ReplicationFilters struct {
+	DatabasePair string
+	Timestamp    time.Time
+	Filters      map[string]string // active filters, by SHOW SLAVE STATUS column
+	SkipCounter  int64
+	SkipErrors   string
+	Problems     []string
+	Error        string
+}
+
+// TableEncryption represents the tablespace encryption of a monitored table
+type TableEncryption struct {
+	Table           string
+	TargetTable     string
+	SourceEncrypted bool
+	TargetEncrypted bool
+	EncryptedAt     *time.Time `json:",omitempty"` // first seen encrypted on the target; nil when already encrypted at the first check
+}
+
+// TableDefinition represents the encryption and row format a monitored
+// table's definitions declare
+type TableDefinition struct {
+	Table           string
+	TargetTable     string
+	TargetEncrypted bool
+	TargetKeyID     int    `json:",omitempty"`
+	TargetRowFormat string // DEFAULT when not declared
+	SourceEncrypted bool
+	SourceRowFormat string   `json:",omitempty"`
+	Problems        []string `json:",omitempty"`
+	Error           string   `json:",omitempty"`
+}
+
+// TableDefinitions represents the table definition check of a database pair
+type TableDefinitions struct {
+	DatabasePair  string
+	Timestamp     time.Time
+	Tables        []TableDefinition
+	SourceChecked bool
+	Error         string
+}
+
+// EncryptionStatus represents the tablespace encryption progress of a
+// database pair
+type EncryptionStatus struct {
+	DatabasePair               string
+	Timestamp                  time.Time
+	Tables                     []TableEncryption // in the order they were encrypted on the target, unencrypted last
+	TablesEncrypted            int
+	SourceTablesEncrypted      int
+	Percent                    float64 // monitored tables encrypted on the target
+	TargetTablespaces          int
+	TargetTablespacesEncrypted int
+	SourceTablespaces          int
+	SourceTablespacesEncrypted int
+	SourceChecked              bool
+	Error                      string
+}
+
+// ColumnDifference represents a column value that differs between source and target
+type ColumnDifference struct {
+	Column      string
+	SourceValue string // empty when SourceNull
+	TargetValue string // empty when TargetNull
+	SourceNull  bool   // the value is SQL NULL, not a string that reads NULL
+	TargetNull  bool
+}
+
+// RowDifference represents a row that differs between source and target
+type RowDifference struct {
+	PrimaryKey string
+	Kind       string
+	Columns    []ColumnDifference
+}
+
+// DiffResult represents the result of a row-level diff of a table
+type DiffResult struct {
+	DatabasePair     string
+	TableName        string
+	TargetTable      string
+	PrimaryKey       string
+	ChunksScanned    int
+	ChunksMismatched int
+	RowsCompared     int64
+	Differences      []RowDifference
+	Truncated        bool
+	Warning          string `json:",omitempty"`
+	Timestamp        time.Time
+	DurationSeconds  float64
+	Error            string
 }
 
 // CurrentMetrics represents the current state of all metrics
 type CurrentMetrics struct {
-	ReplicaLag         map[string]*ReplicaLagMetric      // key: database_pair
-	ChecksumResults    map[string]*ChecksumResult        // key: database_pair:table_name
-	ConsistencyResults map[string]*ConsistencyResult     // key: database_pair:table_name
-	ConnectionStatus   map[string]ConnectionStatus       // key: database_pair
+	ReplicaLag         map[string]*ReplicaLagMetric   // key: database_pair
+	ChecksumResults    map[string]*ChecksumResult     // key: database_pair:table_name
+	ConsistencyResults map[string]*ConsistencyResult  // key: database_pair:table_name
+	ConnectionStatus   map[string]ConnectionStatus    // key: database_pair
+	ClockSkew          map[string]*ClockSkewMetric    // key: database_pair
+	RDS                map[string]*RDSMetric          // key: database_pair
+	Warmup             map[string]*WarmupResult       // key: database_pair
+	PTChecksums        map[string]*PTChecksumReport   // key: database_pair
+	SemiSync           map[string]*SemiSyncMetric     // key: database_pair
+	ReplicationFilters map[string]*ReplicationFilters // key: database_pair
+	EncryptionStatus   map[string]*EncryptionStatus   // key: database_pair
+	TableDefinitions   map[string]*TableDefinitions   // key: database_pair
+	HealthScore        map[string]*HealthScore        // key: database_pair
+	Insights           map[string][]Insight           // key: database_pair
+	Divergence         map[string]*DivergenceCounter  // key: database_pair:table_name
 	LastUpdated        time.Time
 }
 
 // MetricsStorage stores monitoring metrics in memory
 type MetricsStorage struct {
-	mu                  sync.RWMutex
-	replicaLagHistory   []ReplicaLagMetric
-	checksumResults     map[string]*ChecksumResult        // key: database_pair:table_name
-	consistencyResults  map[string]*ConsistencyResult     // key: database_pair:table_name
-	connectionStatus    map[string]ConnectionStatus       // key: database_pair
-	maxHistorySize      int
-	historyDuration     time.Duration
+	clock              clock.Clock
+	mu                 sync.RWMutex
+	replicaLagHistory  []ReplicaLagMetric
+	checksumHistory    []ChecksumResult
+	consistencyHistory []ConsistencyResult
+	checksumResults    map[string]*ChecksumResult     // key: database_pair:table_name
+	consistencyResults map[string]*ConsistencyResult  // key: database_pair:table_name
+	connectionStatus   map[string]ConnectionStatus    // key: database_pair
+	diffResults        map[string]*DiffResult         // key: database_pair:table_name
+	clockSkew          map[string]*ClockSkewMetric    // key: database_pair
+	rds                map[string]*RDSMetric          // key: database_pair
+	warmup             map[string]*WarmupResult       // key: database_pair
+	ptChecksums        map[string]*PTChecksumReport   // key: database_pair
+	semiSync           map[string]*SemiSyncMetric     // key: database_pair
+	replicationFilters map[string]*ReplicationFilters // key: database_pair
+	encryptionStatus   map[string]*EncryptionStatus   // key: database_pair
+	tableDefinitions   map[string]*TableDefinitions   // key: database_pair
+	healthScores       map[string]*HealthScore        // key: database_pair
+	connectionHistory  []ConnectionSample
+	healthHistory      []HealthScore
+	insights           map[string][]Insight          // key: database_pair
+	divergence         map[string]*DivergenceCounter // key: database_pair:table_name
+	maxHistorySize     int
+	historyDuration    time.Duration
+
+	// Raw replica lag samples are kept for lagRetention, then in rollups;
+	// none unless set through SetLagRetention
+	lagRetention time.Duration
+	lagTiers     []*lagTier
+
+	// Bumped on every change to the current metrics so readers can reuse
+	// an encoded snapshot
+	version   uint64
+	updatedAt time.Time
+
+	// Replication thread transitions are sparse and kept longer than samples
+	replicationEvents []ReplicationEvent
+	threadStates      map[string][2]string // key: database_pair; last IO and SQL thread state
+	eventRetention    time.Duration
+	maxEvents         int
+
+	// Operator actions taken through the API, kept like replication events
+	auditLog []AuditEntry
+
+	// Daily aggregates and outages of each pair for summary reports, kept
+	// for reportRetention
+	reportDays      []ReportDay
+	outages         []Outage
+	reportRetention time.Duration
+	reportLocation  *time.Location
 }
 
 // NewMetricsStorage creates a new metrics storage
 func NewMetricsStorage() *MetricsStorage {
 	return &MetricsStorage{
-		replicaLagHistory:   make([]ReplicaLagMetric, 0),
-		checksumResults:     make(map[string]*ChecksumResult),
-		consistencyResults:  make(map[string]*ConsistencyResult),
-		connectionStatus:    make(map[string]ConnectionStatus),
-		maxHistorySize:      8640, // 24 hours at 10-second intervals
-		historyDuration:     24 * time.Hour,
+		clock:              clock.Real,
+		replicaLagHistory:  make([]ReplicaLagMetric, 0),
+		checksumHistory:    make([]ChecksumResult, 0),
+		consistencyHistory: make([]ConsistencyResult, 0),
+		checksumResults:    make(map[string]*ChecksumResult),
+		consistencyResults: make(map[string]*ConsistencyResult),
+		connectionStatus:   make(map[string]ConnectionStatus),
+		diffResults:        make(map[string]*DiffResult),
+		clockSkew:          make(map[string]*ClockSkewMetric),
+		rds:                make(map[string]*RDSMetric),
+		warmup:             make(map[string]*WarmupResult),
+		ptChecksums:        make(map[string]*PTChecksumReport),
+		semiSync:           make(map[string]*SemiSyncMetric),
+		replicationFilters: make(map[string]*ReplicationFilters),
+		encryptionStatus:   make(map[string]*EncryptionStatus),
+		tableDefinitions:   make(map[string]*TableDefinitions),
+		healthScores:       make(map[string]*HealthScore),
+		connectionHistory:  make([]ConnectionSample, 0),
+		healthHistory:      make([]HealthScore, 0),
+		insights:           make(map[string][]Insight),
+		divergence:         make(map[string]*DivergenceCounter),
+		maxHistorySize:     8640, // 24 hours at 10-second intervals
+		historyDuration:    24 * time.Hour,
+		lagRetention:       24 * time.Hour,
+		replicationEvents:  make([]ReplicationEvent, 0),
+		threadStates:       make(map[string][2]string),
+		auditLog:           make([]AuditEntry, 0),
+		eventRetention:     30 * 24 * time.Hour,
+		maxEvents:          10000,
+		reportDays:         make([]ReportDay, 0),
+		outages:            make([]Outage, 0),
+		reportRetention:    35 * 24 * time.Hour,
+		reportLocation:     time.UTC,
 	}
 }
 
+// SetClock sets the clock history is trimmed by. It must be called before
+// anything is stored.
+func (ms *MetricsStorage) SetClock(c clock.Clock) {
+	ms.clock = c
+}
+
 // StoreReplicaLag stores a replica lag metric
 func (ms *MetricsStorage) StoreReplicaLag(metric *ReplicaLagMetric) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.touch()
 
 	ms.replicaLagHistory = append(ms.replicaLagHistory, *metric)
+	ms.recordThreadTransitions(metric)
+	ms.rollUpLag(metric)
+	ms.reportLag(metric)
 
-	// Trim history to maintain 24-hour window
-	cutoff := time.Now().Add(-ms.historyDuration)
+	// Raw samples are bounded by the retention alone: with checks at least
+	// 10 seconds apart, a size limit would only cut the window short for
+	// monitors with many pairs
+	cutoff := ms.clock.Now().Add(-ms.lagRetention)
 	for i, m := range ms.replicaLagHistory {
 		if m.Timestamp.After(cutoff) {
 			ms.replicaLagHistory = ms.replicaLagHistory[i:]
 			break
 		}
 	}
+}
+
+// recordThreadTransitions appends an event for each replication thread whose
+// state differs from the previous sample of the pair. Samples without thread
+// state (connection or query errors) are ignored.
+func (ms *MetricsStorage) recordThreadTransitions(metric *ReplicaLagMetric) {
+	if metric.IORunning == "" && metric.SQLRunning == "" {
+		return
+	}
+
+	current := [2]string{metric.IORunning, metric.SQLRunning}
+	previous, seen := ms.threadStates[metric.DatabasePair]
+	ms.threadStates[metric.DatabasePair] = current
+	if !seen {
+		return
+	}
 
-	// Also enforce max size
-	if len(ms.replicaLagHistory) > ms.maxHistorySize {
-		ms.replicaLagHistory = ms.replicaLagHistory[len(ms.replicaLagHistory)-ms.maxHistorySize:]
+	for i, thread := range []string{"io", "sql"} {
+		if current[i] == previous[i] {
+			continue
+		}
+		ms.replicationEvents = append(ms.replicationEvents, ReplicationEvent{
+			DatabasePair: metric.DatabasePair,
+			Timestamp:    metric.Timestamp,
+			Thread:       thread,
+			From:         previous[i],
+			To:           current[i],
+		})
 	}
+
+	ms.replicationEvents = trimHistory(ms.replicationEvents, func(e ReplicationEvent) time.Time { return e.Timestamp },
+		ms.clock.Now().Add(-ms.eventRetention), ms.maxEvents)
 }
 
 // StoreChecksumResult stores a checksum result
 func (ms *MetricsStorage) StoreChecksumResult(result *ChecksumResult) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.touch()
 
 	key := result.DatabasePair + ":" + result.TableName
 	ms.checksumResults[key] = result
+	ms.reportChecksum(result)
+
+	ms.checksumHistory = append(ms.checksumHistory, *result)
+	ms.checksumHistory = trimHistory(ms.checksumHistory, func(r ChecksumResult) time.Time { return r.Timestamp },
+		ms.clock.Now().Add(-ms.historyDuration), ms.maxHistorySize)
 }
 
 // StoreConsistencyResult stores a consistency result
 func (ms *MetricsStorage) StoreConsistencyResult(result *ConsistencyResult) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.touch()
 
 	key := result.DatabasePair + ":" + result.TableName
 	ms.consistencyResults[key] = result
+	ms.reportConsistency(result)
+
+	ms.consistencyHistory = append(ms.consistencyHistory, *result)
+	ms.consistencyHistory = trimHistory(ms.consistencyHistory, func(r ConsistencyResult) time.Time { return r.Timestamp },
+		ms.clock.Now().Add(-ms.historyDuration), ms.maxHistorySize)
 }
 
 // GetReplicaLagHistory returns replica lag history for the specified duration
@@ -120,7 +614,7 @@ func (ms *MetricsStorage) GetReplicaLagHistory(duration time.Duration) []Replica
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
 
-	cutoff := time.Now().Add(-duration)
+	cutoff := ms.clock.Now().Add(-duration)
 	result := make([]ReplicaLagMetric, 0)
 
 	for _, metric := range ms.replicaLagHistory {
@@ -132,11 +626,124 @@ func (ms *MetricsStorage) GetReplicaLagHistory(duration time.Duration) []Replica
 	return result
 }
 
+// GetChecksumHistory returns checksum results for the specified duration
+func (ms *MetricsStorage) GetChecksumHistory(duration time.Duration) []ChecksumResult {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]ChecksumResult, 0)
+
+	for _, r := range ms.checksumHistory {
+		if r.Timestamp.After(cutoff) {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// GetConsistencyHistory returns consistency results for the specified duration
+func (ms *MetricsStorage) GetConsistencyHistory(duration time.Duration) []ConsistencyResult {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]ConsistencyResult, 0)
+
+	for _, r := range ms.consistencyHistory {
+		if r.Timestamp.After(cutoff) {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// GetReplicationEvents returns replication thread transitions for the specified duration
+func (ms *MetricsStorage) GetReplicationEvents(duration time.Duration) []ReplicationEvent {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]ReplicationEvent, 0)
+
+	for _, e := range ms.replicationEvents {
+		if e.Timestamp.After(cutoff) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// EventRetention returns how far back replication events are retained
+func (ms *MetricsStorage) EventRetention() time.Duration {
+	return ms.eventRetention
+}
+
+// HistoryDuration returns how far back history is retained
+func (ms *MetricsStorage) HistoryDuration() time.Duration {
+	return ms.historyDuration
+}
+
+// Sizes returns the number of entries kept in each history, by history name
+func (ms *MetricsStorage) Sizes() map[string]int {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	rollups := 0
+	for _, tier := range ms.lagTiers {
+		rollups += len(tier.closed) + len(tier.open)
+	}
+	return map[string]int{
+		"replica_lag":         len(ms.replicaLagHistory),
+		"replica_lag_rollups": rollups,
+		"checksum":            len(ms.checksumHistory),
+		"consistency":         len(ms.consistencyHistory),
+		"connection":          len(ms.connectionHistory),
+		"health_score":        len(ms.healthHistory),
+		"replication_events":  len(ms.replicationEvents),
+		"audit":               len(ms.auditLog),
+		"report_days":         len(ms.reportDays),
+		"outages":             len(ms.outages),
+		"diffs":               len(ms.diffResults),
+	}
+}
+
+// touch records a change to the current metrics; ms.mu must be held
+func (ms *MetricsStorage) touch() {
+	ms.version++
+	ms.updatedAt = ms.clock.Now()
+}
+
+// Version returns a counter that changes whenever the current metrics change,
+// and the time of the last change
+func (ms *MetricsStorage) Version() (uint64, time.Time) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.version, ms.updatedAt
+}
+
+// CurrentMetricsJSON encodes the current metrics while holding the read lock,
+// so the encoding is consistent with the returned version
+func (ms *MetricsStorage) CurrentMetricsJSON() ([]byte, uint64, time.Time, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	body, err := json.Marshal(ms.currentMetrics())
+	return body, ms.version, ms.updatedAt, err
+}
+
 // GetCurrentMetrics returns the current state of all metrics
 func (ms *MetricsStorage) GetCurrentMetrics() *CurrentMetrics {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
+	return ms.currentMetrics()
+}
 
+// currentMetrics builds the current state of all metrics; ms.mu must be held
+func (ms *MetricsStorage) currentMetrics() *CurrentMetrics {
 	// Get latest replica lag for each database pair
 	latestReplicaLag := make(map[string]*ReplicaLagMetric)
 	for i := len(ms.replicaLagHistory) - 1; i >= 0; i-- {
@@ -152,7 +759,63 @@ func (ms *MetricsStorage) GetCurrentMetrics() *CurrentMetrics {
 		ChecksumResults:    ms.checksumResults,
 		ConsistencyResults: ms.consistencyResults,
 		ConnectionStatus:   ms.connectionStatus,
-		LastUpdated:        time.Now(),
+		ClockSkew:          ms.clockSkew,
+		RDS:                ms.rds,
+		Warmup:             ms.warmup,
+		PTChecksums:        ms.ptChecksums,
+		SemiSync:           ms.semiSync,
+		ReplicationFilters: ms.replicationFilters,
+		EncryptionStatus:   ms.encryptionStatus,
+		TableDefinitions:   ms.tableDefinitions,
+		HealthScore:        ms.healthScores,
+		Insights:           ms.insights,
+		Divergence:         ms.divergence,
+		LastUpdated:        ms.updatedAt,
+	}
+}
+
+// RemovePair drops the current results of a database pair that is no longer
+// monitored and ends its outage. Its history ages out as usual, except raw
+// replica lag samples, which hold its current lag, and lag rollups, which
+// would keep listing it.
+func (ms *MetricsStorage) RemovePair(pairName string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.replicaLagHistory = slices.DeleteFunc(ms.replicaLagHistory, func(m ReplicaLagMetric) bool { return m.DatabasePair == pairName })
+	deletePairKeys(ms.checksumResults, pairName)
+	deletePairKeys(ms.consistencyResults, pairName)
+	deletePairKeys(ms.diffResults, pairName)
+	deletePairKeys(ms.divergence, pairName)
+	delete(ms.connectionStatus, pairName)
+	delete(ms.clockSkew, pairName)
+	delete(ms.rds, pairName)
+	delete(ms.warmup, pairName)
+	delete(ms.ptChecksums, pairName)
+	delete(ms.semiSync, pairName)
+	delete(ms.replicationFilters, pairName)
+	delete(ms.encryptionStatus, pairName)
+	delete(ms.tableDefinitions, pairName)
+	delete(ms.healthScores, pairName)
+	delete(ms.insights, pairName)
+	delete(ms.threadStates, pairName)
+	ms.removeLagRollups(pairName)
+
+	for i := range ms.outages {
+		if ms.outages[i].DatabasePair == pairName && ms.outages[i].End.IsZero() {
+			ms.outages[i].End = ms.clock.Now()
+		}
+	}
+}
+
+// deletePairKeys deletes the per-table entries of a pair, keyed
+// database_pair:table_name
+func deletePairKeys[V any](results map[string]V, pairName string) {
+	for key := range results {
+		if strings.HasPrefix(key, pairName+":") {
+			delete(results, key)
+		}
 	}
 }
 
@@ -160,6 +823,195 @@ func (ms *MetricsStorage) GetCurrentMetrics() *CurrentMetrics {
 func (ms *MetricsStorage) UpdateConnectionStatus(pairName string, status ConnectionStatus) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.touch()
 
 	ms.connectionStatus[pairName] = status
+	ms.reportConnection(pairName, status)
+
+	ms.connectionHistory = append(ms.connectionHistory, ConnectionSample{
+		DatabasePair:    pairName,
+		Timestamp:       status.LastChecked,
+		SourceConnected: status.SourceConnected,
+		TargetConnected: status.TargetConnected,
+	})
+	ms.connectionHistory = trimHistory(ms.connectionHistory, func(c ConnectionSample) time.Time { return c.Timestamp },
+		ms.clock.Now().Add(-ms.historyDuration), ms.maxHistorySize)
+}
+
+// StoreHealthScore stores the latest health score of a pair and adds it to the history
+func (ms *MetricsStorage) StoreHealthScore(score *HealthScore) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.healthScores[score.DatabasePair] = score
+
+	ms.healthHistory = append(ms.healthHistory, *score)
+	ms.healthHistory = trimHistory(ms.healthHistory, func(h HealthScore) time.Time { return h.Timestamp },
+		ms.clock.Now().Add(-ms.historyDuration), ms.maxHistorySize)
+}
+
+// StoreInsights replaces the insights of a pair
+func (ms *MetricsStorage) StoreInsights(pairName string, insights []Insight) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	if len(insights) == 0 {
+		delete(ms.insights, pairName)
+		return
+	}
+	ms.insights[pairName] = insights
+}
+
+// StoreDivergence stores the divergence counter of a table of a dual_write pair
+func (ms *MetricsStorage) StoreDivergence(counter *DivergenceCounter) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.divergence[counter.DatabasePair+":"+counter.TableName] = counter
+}
+
+// GetConnectionHistory returns connection samples for the specified duration
+func (ms *MetricsStorage) GetConnectionHistory(duration time.Duration) []ConnectionSample {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]ConnectionSample, 0)
+
+	for _, c := range ms.connectionHistory {
+		if c.Timestamp.After(cutoff) {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// GetHealthScoreHistory returns health scores for the specified duration
+func (ms *MetricsStorage) GetHealthScoreHistory(duration time.Duration) []HealthScore {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]HealthScore, 0)
+
+	for _, h := range ms.healthHistory {
+		if h.Timestamp.After(cutoff) {
+			result = append(result, h)
+		}
+	}
+
+	return result
+}
+
+// StoreDiffResult stores the latest row diff result for a table
+func (ms *MetricsStorage) StoreDiffResult(result *DiffResult) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := result.DatabasePair + ":" + result.TableName
+	ms.diffResults[key] = result
+}
+
+// GetDiffResults returns the latest row diff result for each table
+func (ms *MetricsStorage) GetDiffResults() []*DiffResult {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	results := make([]*DiffResult, 0, len(ms.diffResults))
+	for _, result := range ms.diffResults {
+		results = append(results, result)
+	}
+	return results
+}
+
+// trimHistory drops entries older than cutoff and enforces the max size
+func trimHistory[T any](history []T, timestamp func(T) time.Time, cutoff time.Time, maxSize int) []T {
+	for i, entry := range history {
+		if timestamp(entry).After(cutoff) {
+			history = history[i:]
+			break
+		}
+	}
+
+	if len(history) > maxSize {
+		history = history[len(history)-maxSize:]
+	}
+	return history
+}
+
+// StoreClockSkew stores the latest clock skew measurement for a database pair
+func (ms *MetricsStorage) StoreClockSkew(metric *ClockSkewMetric) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.clockSkew[metric.DatabasePair] = metric
+}
+
+// StoreWarmupResult stores the latest target warm-up check for a database pair
+func (ms *MetricsStorage) StoreWarmupResult(result *WarmupResult) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.warmup[result.DatabasePair] = result
+}
+
+// StorePTChecksumReport stores the last read of pt-table-checksum's results
+// for a database pair
+func (ms *MetricsStorage) StorePTChecksumReport(report *PTChecksumReport) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.ptChecksums[report.DatabasePair] = report
+}
+
+// StoreSemiSync stores the latest semi-sync replication state for a database pair
+func (ms *MetricsStorage) StoreSemiSync(metric *SemiSyncMetric) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.semiSync[metric.DatabasePair] = metric
+}
+
+// StoreReplicationFilters stores the latest replication filter state for a database pair
+func (ms *MetricsStorage) StoreReplicationFilters(result *ReplicationFilters) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.replicationFilters[result.DatabasePair] = result
+}
+
+// StoreEncryptionStatus stores the latest encryption progress for a database pair
+func (ms *MetricsStorage) StoreEncryptionStatus(status *EncryptionStatus) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.encryptionStatus[status.DatabasePair] = status
+}
+
+// StoreTableDefinitions stores the latest table definition check for a database pair
+func (ms *MetricsStorage) StoreTableDefinitions(result *TableDefinitions) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.tableDefinitions[result.DatabasePair] = result
+}
+
+// StoreRDSMetric stores the latest CloudWatch metrics for a database pair
+func (ms *MetricsStorage) StoreRDSMetric(metric *RDSMetric) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.touch()
+
+	ms.rds[metric.DatabasePair] = metric
 }
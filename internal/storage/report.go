@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// ReportDay aggregates a pair's results of one calendar day, in the report
+// timezone, for summary reports. Rehearsal faults are left out.
+type ReportDay struct {
+	DatabasePair string
+	Date         time.Time // midnight starting the day
+
+	// Replica lag checks, and those with replication running and the lag
+	// within the pair's lowest threshold tier
+	Cycles                int
+	CyclesWithinThreshold int
+
+	// Lag of the checks with replication running
+	LagSamples    int
+	MaxLagSeconds float64
+	AvgLagSeconds float64
+
+	ChecksumRuns        int // tables checksummed, skipped tables left out
+	ChecksumFailures    int // mismatches
+	ChecksumErrors      int
+	ConsistencyRuns     int
+	ConsistencyFailures int // row counts differing beyond the tolerance
+	ConsistencyErrors   int
+}
+
+// Outage is a time a pair had its source or target unreachable. End is zero
+// while the outage lasts.
+type Outage struct {
+	DatabasePair string
+	Start        time.Time
+	End          time.Time
+}
+
+// SetReportRetention sets how long the daily aggregates and outages of
+// reports are kept, and the timezone their days start in
+func (ms *MetricsStorage) SetReportRetention(retention time.Duration, location *time.Location) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.reportRetention = retention
+	ms.reportLocation = location
+}
+
+// reportDay returns the aggregate of a pair for the day of a point in time,
+// adding it if missing; ms.mu must be held
+func (ms *MetricsStorage) reportDay(pairName string, at time.Time) *ReportDay {
+	local := at.In(ms.reportLocation)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, ms.reportLocation)
+
+	for i := len(ms.reportDays) - 1; i >= 0; i-- {
+		day := &ms.reportDays[i]
+		if day.DatabasePair == pairName && day.Date.Equal(date) {
+			return day
+		}
+	}
+
+	ms.trimReports()
+	ms.reportDays = append(ms.reportDays, ReportDay{DatabasePair: pairName, Date: date})
+	return &ms.reportDays[len(ms.reportDays)-1]
+}
+
+// trimReports drops the days and ended outages past retention; ms.mu must be
+// held
+func (ms *MetricsStorage) trimReports() {
+	cutoff := ms.clock.Now().Add(-ms.reportRetention)
+	ms.reportDays = slices.DeleteFunc(ms.reportDays, func(day ReportDay) bool { return day.Date.AddDate(0, 0, 1).Before(cutoff) })
+	ms.outages = slices.DeleteFunc(ms.outages, func(o Outage) bool { return !o.End.IsZero() && o.End.Before(cutoff) })
+}
+
+// reportLag adds a replica lag check to the pair's day; ms.mu must be held
+func (ms *MetricsStorage) reportLag(metric *ReplicaLagMetric) {
+	if metric.Rehearsal != "" {
+		return
+	}
+	day := ms.reportDay(metric.DatabasePair, metric.Timestamp)
+	day.Cycles++
+	if metric.Status != "ok" {
+		return
+	}
+	if metric.ThresholdSeconds == 0 || metric.LagSeconds <= metric.ThresholdSeconds {
+		day.CyclesWithinThreshold++
+	}
+	day.LagSamples++
+	day.MaxLagSeconds = max(day.MaxLagSeconds, metric.LagSeconds)
+	day.AvgLagSeconds += (metric.LagSeconds - day.AvgLagSeconds) / float64(day.LagSamples)
+}
+
+// reportChecksum adds a table checksum to the pair's day; ms.mu must be held
+func (ms *MetricsStorage) reportChecksum(result *ChecksumResult) {
+	if result.Rehearsal != "" || result.Skipped {
+		return
+	}
+	day := ms.reportDay(result.DatabasePair, result.Timestamp)
+	day.ChecksumRuns++
+	switch {
+	case result.Error != nil:
+		day.ChecksumErrors++
+	case !result.Match:
+		day.ChecksumFailures++
+	}
+}
+
+// reportConsistency adds a row count comparison to the pair's day; ms.mu
+// must be held
+func (ms *MetricsStorage) reportConsistency(result *ConsistencyResult) {
+	if result.Rehearsal != "" {
+		return
+	}
+	day := ms.reportDay(result.DatabasePair, result.Timestamp)
+	day.ConsistencyRuns++
+	switch {
+	case result.Error != nil:
+		day.ConsistencyErrors++
+	case !result.Consistent:
+		day.ConsistencyFailures++
+	}
+}
+
+// reportConnection starts an outage of the pair when a database became
+// unreachable and ends it once both are reachable; ms.mu must be held
+func (ms *MetricsStorage) reportConnection(pairName string, status ConnectionStatus) {
+	if status.Rehearsal != "" {
+		return
+	}
+	down := !status.SourceConnected || !status.TargetConnected
+	i := slices.IndexFunc(ms.outages, func(o Outage) bool { return o.DatabasePair == pairName && o.End.IsZero() })
+	switch {
+	case down && i < 0:
+		ms.trimReports()
+		ms.outages = append(ms.outages, Outage{DatabasePair: pairName, Start: status.LastChecked})
+	case !down && i >= 0:
+		ms.outages[i].End = status.LastChecked
+	}
+}
+
+// GetReportData returns the daily aggregates of the days starting in
+// [from, to), and the outages overlapping it
+func (ms *MetricsStorage) GetReportData(from, to time.Time) ([]ReportDay, []Outage) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	days := make([]ReportDay, 0)
+	for _, day := range ms.reportDays {
+		if !day.Date.Before(from) && day.Date.Before(to) {
+			days = append(days, day)
+		}
+	}
+	outages := make([]Outage, 0)
+	for _, o := range ms.outages {
+		if o.Start.Before(to) && (o.End.IsZero() || o.End.After(from)) {
+			outages = append(outages, o)
+		}
+	}
+	return days, outages
+}
+
+// ReportRetention returns how far back report aggregates are kept, and the
+// timezone their days start in
+func (ms *MetricsStorage) ReportRetention() (time.Duration, *time.Location) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.reportRetention, ms.reportLocation
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// RollupTier keeps replica lag aggregated per pair in buckets of Interval
+// for Retention
+type RollupTier struct {
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// LagRollup aggregates the replica lag samples of a pair with status ok in
+// one bucket. Samples without a meaningful lag are left out, so buckets in
+// which replication was broken throughout are missing.
+type LagRollup struct {
+	DatabasePair  string
+	Timestamp     time.Time // start of the bucket
+	Interval      time.Duration
+	Samples       int
+	MinLagSeconds float64
+	AvgLagSeconds float64
+	MaxLagSeconds float64
+}
+
+// add aggregates one lag value into the bucket
+func (r *LagRollup) add(lagSeconds float64) {
+	if r.Samples == 0 || lagSeconds < r.MinLagSeconds {
+		r.MinLagSeconds = lagSeconds
+	}
+	if r.Samples == 0 || lagSeconds > r.MaxLagSeconds {
+		r.MaxLagSeconds = lagSeconds
+	}
+	r.Samples++
+	r.AvgLagSeconds += (lagSeconds - r.AvgLagSeconds) / float64(r.Samples)
+}
+
+// Merge aggregates another bucket into this one, e.g. to widen buckets for
+// a chart
+func (r *LagRollup) Merge(other LagRollup) {
+	if other.Samples == 0 {
+		return
+	}
+	if r.Samples == 0 || other.MinLagSeconds < r.MinLagSeconds {
+		r.MinLagSeconds = other.MinLagSeconds
+	}
+	if r.Samples == 0 || other.MaxLagSeconds > r.MaxLagSeconds {
+		r.MaxLagSeconds = other.MaxLagSeconds
+	}
+	total := r.Samples + other.Samples
+	r.AvgLagSeconds = (r.AvgLagSeconds*float64(r.Samples) + other.AvgLagSeconds*float64(other.Samples)) / float64(total)
+	r.Samples = total
+}
+
+// lagTier holds the buckets of one rollup tier
+type lagTier struct {
+	RollupTier
+	closed []LagRollup
+	open   map[string]*LagRollup // key: database_pair; the bucket samples are added to
+}
+
+// SetLagRetention sets how long raw replica lag samples are kept and the
+// rollup tiers that keep aggregates beyond that, finest first. It must be
+// called before anything is stored.
+func (ms *MetricsStorage) SetLagRetention(raw time.Duration, tiers []RollupTier) {
+	ms.lagRetention = raw
+	ms.lagTiers = make([]*lagTier, 0, len(tiers))
+	for _, tier := range tiers {
+		ms.lagTiers = append(ms.lagTiers, &lagTier{RollupTier: tier, open: make(map[string]*LagRollup)})
+	}
+}
+
+// rollUpLag adds a lag sample to the open bucket of its pair in every tier,
+// closing the bucket when the sample starts a new one; ms.mu must be held
+func (ms *MetricsStorage) rollUpLag(metric *ReplicaLagMetric) {
+	if metric.Status != "ok" {
+		return
+	}
+
+	now := ms.clock.Now()
+	for _, tier := range ms.lagTiers {
+		start := metric.Timestamp.Truncate(tier.Interval)
+		bucket := tier.open[metric.DatabasePair]
+		if bucket != nil && !bucket.Timestamp.Equal(start) {
+			tier.closed = append(tier.closed, *bucket)
+			bucket = nil
+		}
+		if bucket == nil {
+			bucket = &LagRollup{DatabasePair: metric.DatabasePair, Timestamp: start, Interval: tier.Interval}
+			tier.open[metric.DatabasePair] = bucket
+		}
+		bucket.add(metric.LagSeconds)
+
+		// Buckets close in the order their next sample arrives, which is
+		// chronological but for pairs that stopped reporting for a while;
+		// those are trimmed once the buckets before them expire
+		cutoff := now.Add(-tier.Retention)
+		expired := 0
+		for expired < len(tier.closed) && !tier.closed[expired].Timestamp.After(cutoff) {
+			expired++
+		}
+		tier.closed = tier.closed[expired:]
+	}
+}
+
+// removeLagRollups drops the buckets of a pair from every tier; ms.mu must be
+// held
+func (ms *MetricsStorage) removeLagRollups(pairName string) {
+	for _, tier := range ms.lagTiers {
+		delete(tier.open, pairName)
+		tier.closed = slices.DeleteFunc(tier.closed, func(r LagRollup) bool { return r.DatabasePair == pairName })
+	}
+}
+
+// GetLagRollups returns the replica lag buckets of every pair over a
+// duration, from the finest tier retaining that long (or the coarsest tier),
+// in chronological order. The last bucket of each pair may still be filling.
+// Without rollup tiers nothing is returned.
+func (ms *MetricsStorage) GetLagRollups(duration time.Duration) []LagRollup {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if len(ms.lagTiers) == 0 {
+		return []LagRollup{}
+	}
+	tier := ms.lagTiers[len(ms.lagTiers)-1]
+	for _, t := range ms.lagTiers {
+		if t.Retention >= duration {
+			tier = t
+			break
+		}
+	}
+
+	cutoff := ms.clock.Now().Add(-duration)
+	result := make([]LagRollup, 0, len(tier.closed)+len(tier.open))
+	for _, bucket := range tier.closed {
+		if !bucket.Timestamp.Add(tier.Interval).Before(cutoff) {
+			result = append(result, bucket)
+		}
+	}
+	for _, bucket := range tier.open {
+		if !bucket.Timestamp.Add(tier.Interval).Before(cutoff) {
+			result = append(result, *bucket)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result
+}
+
+// LagRawRetention returns how far back raw replica lag samples are retained
+func (ms *MetricsStorage) LagRawRetention() time.Duration {
+	return ms.lagRetention
+}
+
+// LagHistoryRetention returns how far back replica lag is retained, raw or
+// rolled up
+func (ms *MetricsStorage) LagHistoryRetention() time.Duration {
+	retention := ms.lagRetention
+	for _, tier := range ms.lagTiers {
+		retention = max(retention, tier.Retention)
+	}
+	return retention
+}
@@ -0,0 +1,123 @@
+package timeseries
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// influxWriter writes points in line protocol through the InfluxDB v2 write API
+type influxWriter struct {
+	config config.InfluxDBConfig
+	client *http.Client
+}
+
+// newInfluxWriter creates a new InfluxDB writer
+func newInfluxWriter(cfg config.InfluxDBConfig, timeout time.Duration) *influxWriter {
+	return &influxWriter{config: cfg, client: &http.Client{Timeout: timeout}}
+}
+
+// write posts a batch. Rejected points (400, 413, 422) fail permanently;
+// other errors are retried.
+func (w *influxWriter) write(ctx context.Context, points []Point) error {
+	var body bytes.Buffer
+	for _, p := range points {
+		writeLine(&body, p)
+	}
+
+	query := url.Values{"bucket": {w.config.Bucket}, "precision": {"ns"}}
+	if w.config.Org != "" {
+		query.Set("org", w.config.Org)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL+"/api/v2/write?"+query.Encode(), &body)
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.config.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("write returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return &permanentError{err}
+	}
+	return err
+}
+
+// close releases idle connections
+func (w *influxWriter) close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// writeLine appends a point in line protocol, with sorted tags and fields
+func writeLine(b *bytes.Buffer, p Point) {
+	b.WriteString(escapeLP(p.Measurement, ", "))
+	for _, key := range slices.Sorted(maps.Keys(p.Tags)) {
+		if p.Tags[key] == "" {
+			continue // empty tag values are not allowed
+		}
+		fmt.Fprintf(b, ",%s=%s", escapeLP(key, ",= "), escapeLP(p.Tags[key], ",= "))
+	}
+	for i, key := range slices.Sorted(maps.Keys(p.Fields)) {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(escapeLP(key, ",= "))
+		b.WriteByte('=')
+		switch v := p.Fields[key].(type) {
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10) + "i")
+		case bool:
+			b.WriteString(strconv.FormatBool(v))
+		default:
+			b.WriteByte('"')
+			b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(fmt.Sprint(v)))
+			b.WriteByte('"')
+		}
+	}
+	fmt.Fprintf(b, " %d\n", p.Time.UnixNano())
+}
+
+// escapeLP escapes the special characters of a line protocol element.
+// Newlines, which would end the line, become spaces.
+func escapeLP(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			r = ' '
+			fallthrough
+		case strings.ContainsRune(special, r):
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
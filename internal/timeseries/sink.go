@@ -0,0 +1,228 @@
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// Point is one measurement. Field values are float64, int64, bool or string.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+// writer writes batches of points to a time-series database
+type writer interface {
+	write(ctx context.Context, points []Point) error
+	close() error
+}
+
+// permanentError is a write failure that retrying cannot fix, e.g. a
+// rejected point; the batch is dropped
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Sink batches points and writes them in the background, retrying failed
+// writes. Points are kept while the database is unreachable, up to the
+// buffer size. A nil Sink discards points.
+type Sink struct {
+	config config.TimeSeriesConfig
+	writer writer
+	target string // for logs
+
+	mu      sync.Mutex
+	pending []Point
+	removed int64 // points ever removed from the front of pending, written or dropped
+	dropped int64 // points dropped since the last successful write
+	failing bool  // a write error was logged; logged again only after a success
+
+	flushChan chan struct{}
+	stopChan  chan struct{}
+	done      chan struct{}
+}
+
+// NewSink creates a sink for the configured backend
+func NewSink(cfg config.TimeSeriesConfig) (*Sink, error) {
+	s := &Sink{
+		config:    cfg,
+		flushChan: make(chan struct{}, 1),
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	switch cfg.Backend {
+	case config.TimeSeriesInfluxDB:
+		s.writer = newInfluxWriter(cfg.InfluxDB, cfg.Timeout)
+		s.target = fmt.Sprintf("InfluxDB %s (bucket %s)", cfg.InfluxDB.URL, cfg.InfluxDB.Bucket)
+	case config.TimeSeriesTimescaleDB:
+		w, err := newTimescaleWriter(cfg.TimescaleDB)
+		if err != nil {
+			return nil, err
+		}
+		s.writer = w
+		s.target = fmt.Sprintf("TimescaleDB %s/%s (table %s)", cfg.TimescaleDB.Address(), cfg.TimescaleDB.Database, cfg.TimescaleDB.Table)
+	default:
+		return nil, fmt.Errorf("unknown time-series backend '%s'", cfg.Backend)
+	}
+	return s, nil
+}
+
+// Start writes batched points in the background
+func (s *Sink) Start() {
+	log.Printf("Writing measurements to %s", s.target)
+	go s.flushLoop()
+}
+
+// Stop writes pending points, without retrying, and closes the connection
+func (s *Sink) Stop() {
+	close(s.stopChan)
+	<-s.done
+	s.writer.close()
+}
+
+// Write queues a point. A full batch is written right away; beyond the
+// buffer size the oldest points are dropped.
+func (s *Sink) Write(p Point) {
+	if s == nil {
+		return
+	}
+	for key, value := range p.Fields {
+		// Neither backend stores NaN or infinities
+		if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			delete(p.Fields, key)
+		}
+	}
+	if len(p.Fields) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, p)
+	if excess := len(s.pending) - s.config.BufferSize; excess > 0 {
+		if s.dropped == 0 {
+			log.Printf("Time-series buffer full, dropping the oldest measurements until %s accepts writes", s.target)
+		}
+		s.pending = s.pending[excess:]
+		s.removed += int64(excess)
+		s.dropped += int64(excess)
+	}
+	full := len(s.pending) >= s.config.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flushLoop writes pending points at the flush interval, or as soon as a
+// batch is full, until stopped
+func (s *Sink) flushLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(true)
+		case <-s.flushChan:
+			s.flush(true)
+		case <-s.stopChan:
+			s.flush(false)
+			return
+		}
+	}
+}
+
+// flush writes the pending points batch by batch. A batch that still fails
+// after the retries stays pending for the next flush.
+func (s *Sink) flush(retry bool) {
+	for {
+		s.mu.Lock()
+		batch := s.pending[:min(len(s.pending), s.config.BatchSize)]
+		start := s.removed
+		s.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+
+		err := s.writeBatch(batch, retry)
+		var permanent *permanentError
+		if err != nil && !errors.As(err, &permanent) {
+			s.logFailure(err)
+			return
+		}
+
+		// Points of the batch may have been dropped meanwhile
+		s.mu.Lock()
+		if done := start + int64(len(batch)) - s.removed; done > 0 {
+			s.pending = s.pending[done:]
+			s.removed += done
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Printf("%s rejected %d measurement(s), dropping them: %v", s.target, len(batch), err)
+			continue
+		}
+		s.logSuccess()
+	}
+}
+
+// writeBatch writes a batch, retrying failures with a doubling backoff
+func (s *Sink) writeBatch(batch []Point, retry bool) error {
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		err := s.writer.write(ctx, batch)
+		cancel()
+
+		var permanent *permanentError
+		if err == nil || errors.As(err, &permanent) || !retry || attempt >= s.config.MaxRetries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-s.stopChan:
+			return err
+		}
+	}
+}
+
+// logFailure logs the first of consecutive write failures
+func (s *Sink) logFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failing {
+		log.Printf("Failed to write measurements to %s, keeping them for the next flush: %v", s.target, err)
+		s.failing = true
+	}
+}
+
+// logSuccess logs that writes work again and how many points were lost
+func (s *Sink) logSuccess() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		log.Printf("Writing measurements to %s again (%d dropped meanwhile)", s.target, s.dropped)
+		s.failing = false
+	}
+	s.dropped = 0
+}
@@ -0,0 +1,147 @@
+package timeseries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// timescaleWriter inserts points into a TimescaleDB hypertable with one row
+// per point; tags and fields are JSONB, apart from the pair, which reports
+// filter on most
+type timescaleWriter struct {
+	config config.TimescaleDBConfig
+	db     *sql.DB
+	ready  bool // the table exists
+}
+
+// newTimescaleWriter creates a new TimescaleDB writer. It connects on the
+// first write.
+func newTimescaleWriter(cfg config.TimescaleDBConfig) (*timescaleWriter, error) {
+	db, err := sql.Open("pgx", timescaleDSN(cfg))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return &timescaleWriter{config: cfg, db: db}, nil
+}
+
+// timescaleDSN builds the connection URL, passing sslmode and sslrootcert
+// to the driver, which verifies certificates as libpq does
+func timescaleDSN(cfg config.TimescaleDBConfig) string {
+	query := url.Values{"sslmode": {cfg.SSLMode}}
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     cfg.Address(),
+		Path:     "/" + cfg.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// insertQuery builds the INSERT of a batch of n points, five parameters per
+// point. The table name is checked by the configuration, so it is the only
+// value in the statement itself.
+func insertQuery(table string, n int) string {
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (time, measurement, pair, tags, fields) VALUES ", table)
+	for i := range n {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		p := i * 5
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d::jsonb, $%d::jsonb)", p+1, p+2, p+3, p+4, p+5)
+	}
+	return query.String()
+}
+
+// write inserts a batch in one statement
+func (w *timescaleWriter) write(ctx context.Context, points []Point) error {
+	if !w.ready {
+		if err := w.createTable(ctx); err != nil {
+			return classifyPgError(err)
+		}
+		w.ready = true
+	}
+
+	args := make([]any, 0, len(points)*5)
+	for _, p := range points {
+		tags := make(map[string]string, len(p.Tags))
+		for key, value := range p.Tags {
+			if key != "pair" {
+				tags[key] = value
+			}
+		}
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			return &permanentError{err}
+		}
+		fieldsJSON, err := json.Marshal(p.Fields)
+		if err != nil {
+			return &permanentError{err}
+		}
+		args = append(args, p.Time.UTC(), p.Measurement, p.Tags["pair"], string(tagsJSON), string(fieldsJSON))
+	}
+
+	_, err := w.db.ExecContext(ctx, insertQuery(w.config.Table, len(points)), args...)
+	return classifyPgError(err)
+}
+
+// classifyPgError marks errors the server reports about the statement as
+// permanent. Connection exceptions, transaction rollbacks, insufficient
+// resources, operator intervention and broken connections are retried.
+func classifyPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if err == nil || !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code[:min(len(pgErr.Code), 2)] {
+	case "08", "40", "53", "57":
+		return err
+	}
+	return &permanentError{err}
+}
+
+// createTable creates the hypertable and its index if they do not exist
+func (w *timescaleWriter) createTable(ctx context.Context) error {
+	table := w.config.Table
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	time TIMESTAMPTZ NOT NULL,
+	measurement TEXT NOT NULL,
+	pair TEXT NOT NULL,
+	tags JSONB NOT NULL,
+	fields JSONB NOT NULL
+)`, table), nil},
+		{"SELECT create_hypertable($1::regclass, 'time', if_not_exists => TRUE)", []any{table}},
+		{fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (measurement, pair, time DESC)",
+			strings.ReplaceAll(table, ".", "_")+"_measurement_pair_time", table), nil},
+	}
+	for _, s := range statements {
+		if _, err := w.db.ExecContext(ctx, s.query, s.args...); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// close closes the connection
+func (w *timescaleWriter) close() error {
+	return w.db.Close()
+}
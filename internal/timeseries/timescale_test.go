@@ -0,0 +1,86 @@
+package timeseries
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+func TestTimescaleDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.TimescaleDBConfig
+		sslmode  string
+		rootCert string
+	}{
+		{"verify-full", config.TimescaleDBConfig{SSLMode: "verify-full"}, "verify-full", ""},
+		{"root cert", config.TimescaleDBConfig{SSLMode: "verify-ca", SSLRootCert: "/etc/ca.pem"}, "verify-ca", "/etc/ca.pem"},
+		{"disable", config.TimescaleDBConfig{SSLMode: "disable"}, "disable", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Host, tt.cfg.Port, tt.cfg.Database = "db.example.com", 5432, "metrics"
+			tt.cfg.Username, tt.cfg.Password = "monitor", "p@ss:w/rd?"
+			dsn, err := url.Parse(timescaleDSN(tt.cfg))
+			if err != nil {
+				t.Fatalf("invalid DSN: %v", err)
+			}
+			if password, _ := dsn.User.Password(); password != tt.cfg.Password || dsn.User.Username() != "monitor" {
+				t.Errorf("credentials = %v, want monitor with the password intact", dsn.User)
+			}
+			if dsn.Host != "db.example.com:5432" || dsn.Path != "/metrics" {
+				t.Errorf("address = %s%s", dsn.Host, dsn.Path)
+			}
+			if got := dsn.Query().Get("sslmode"); got != tt.sslmode {
+				t.Errorf("sslmode = %q, want %q", got, tt.sslmode)
+			}
+			if got := dsn.Query().Get("sslrootcert"); got != tt.rootCert {
+				t.Errorf("sslrootcert = %q, want %q", got, tt.rootCert)
+			}
+		})
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "INSERT INTO m (time, measurement, pair, tags, fields) VALUES ($1, $2, $3, $4::jsonb, $5::jsonb)"},
+		{2, "INSERT INTO m (time, measurement, pair, tags, fields) VALUES ($1, $2, $3, $4::jsonb, $5::jsonb), ($6, $7, $8, $9::jsonb, $10::jsonb)"},
+	}
+	for _, tt := range tests {
+		if got := insertQuery("m", tt.n); got != tt.want {
+			t.Errorf("insertQuery(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyPgError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"nil", nil, false},
+		{"connection broken", errors.New("unexpected EOF"), false},
+		{"connection exception", &pgconn.PgError{Code: "08006"}, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, false},
+		{"disk full", &pgconn.PgError{Code: "53100"}, false},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, false},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, true},
+		{"invalid json", &pgconn.PgError{Code: "22P02"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var permanent *permanentError
+			if got := errors.As(classifyPgError(tt.err), &permanent); got != tt.permanent {
+				t.Errorf("permanent = %v, want %v", got, tt.permanent)
+			}
+		})
+	}
+}
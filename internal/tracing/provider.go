@@ -0,0 +1,166 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// maxQueuedSpans bounds the spans kept between exports; more are dropped
+const maxQueuedSpans = 4096
+
+// scopeName identifies the instrumentation in exported data
+const scopeName = "mariadb-encryption-monitor"
+
+// durationBuckets are the histogram bounds, in seconds, of duration metrics
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Provider exports spans and duration histograms to an OTLP/HTTP collector
+// through the OpenTelemetry SDK, at the export interval
+type Provider struct {
+	config  config.OpenTelemetryConfig
+	traces  *sdktrace.TracerProvider
+	metrics *sdkmetric.MeterProvider
+	tracer  trace.Tracer
+	meter   metric.Meter
+
+	mu         sync.Mutex
+	histograms map[string]metric.Float64Histogram
+	failing    map[string]bool // signals whose export error was logged; logged again only after a success
+}
+
+// NewProvider creates a provider for the configured collector. Root spans
+// are sampled with the configured ratio and children follow their parent's
+// decision.
+func NewProvider(cfg config.OpenTelemetryConfig) (*Provider, error) {
+	ctx := context.Background()
+	p := &Provider{
+		config:     cfg,
+		histograms: make(map[string]metric.Float64Histogram),
+		failing:    make(map[string]bool),
+	}
+
+	traceClient, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(cfg.Endpoint+"/v1/traces"),
+		otlptracehttp.WithHeaders(cfg.Headers),
+		otlptracehttp.WithTimeout(cfg.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the trace exporter: %w", err)
+	}
+	metricClient, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpointURL(cfg.Endpoint+"/v1/metrics"),
+		otlpmetrichttp.WithHeaders(cfg.Headers),
+		otlpmetrichttp.WithTimeout(cfg.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the metric exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))
+	p.traces = sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithBatcher(&spanExporter{SpanExporter: traceClient, provider: p},
+			sdktrace.WithBatchTimeout(cfg.ExportInterval),
+			sdktrace.WithMaxQueueSize(maxQueuedSpans),
+			sdktrace.WithExportTimeout(cfg.Timeout)))
+	p.metrics = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(&metricExporter{Exporter: metricClient, provider: p},
+			sdkmetric.WithInterval(cfg.ExportInterval),
+			sdkmetric.WithTimeout(cfg.Timeout))))
+	p.tracer = p.traces.Tracer(scopeName)
+	p.meter = p.metrics.Meter(scopeName)
+	return p, nil
+}
+
+// Start logs where spans and metrics are exported; the SDK exports them in
+// the background from the start
+func (p *Provider) Start() {
+	log.Printf("Exporting OpenTelemetry traces and metrics to %s (sample ratio: %g)", p.config.Endpoint, p.config.SampleRatio)
+}
+
+// Stop exports what is pending and stops exporting
+func (p *Provider) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+	defer cancel()
+	if err := p.traces.Shutdown(ctx); err != nil {
+		log.Printf("Failed to export the last OpenTelemetry spans: %v", err)
+	}
+	if err := p.metrics.Shutdown(ctx); err != nil {
+		log.Printf("Failed to export the last OpenTelemetry metrics: %v", err)
+	}
+}
+
+// record adds a value to the histogram of a metric
+func (p *Provider) record(name string, value float64, attrs []Attribute) {
+	p.mu.Lock()
+	h, ok := p.histograms[name]
+	if !ok {
+		var err error
+		h, err = p.meter.Float64Histogram(name, metric.WithUnit("s"), metric.WithExplicitBucketBoundaries(durationBuckets...))
+		if err != nil {
+			p.mu.Unlock()
+			log.Printf("OpenTelemetry: failed to create histogram %s: %v", name, err)
+			return
+		}
+		p.histograms[name] = h
+	}
+	p.mu.Unlock()
+
+	h.Record(context.Background(), value, metric.WithAttributes(attrs...))
+}
+
+// exported logs the first of consecutive export failures of a signal, and
+// when exports work again
+func (p *Provider) exported(signal string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err != nil && !p.failing[signal]:
+		log.Printf("Failed to export OpenTelemetry %s to %s: %v", signal, p.config.Endpoint, err)
+		p.failing[signal] = true
+	case err == nil && p.failing[signal]:
+		log.Printf("Exporting OpenTelemetry %s to %s again", signal, p.config.Endpoint)
+		p.failing[signal] = false
+	}
+}
+
+// spanExporter logs export failures, which the SDK would report on every
+// attempt
+type spanExporter struct {
+	sdktrace.SpanExporter
+	provider *Provider
+}
+
+// ExportSpans exports a batch of spans; a failed batch is dropped
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.provider.exported("traces", e.SpanExporter.ExportSpans(ctx, spans))
+	return nil
+}
+
+// metricExporter logs export failures, which the SDK would report on every
+// attempt
+type metricExporter struct {
+	sdkmetric.Exporter
+	provider *Provider
+}
+
+// Export exports the cumulative histograms; they are sent again in full at
+// the next interval
+func (e *metricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	e.provider.exported("metrics", e.Exporter.Export(ctx, metrics))
+	return nil
+}
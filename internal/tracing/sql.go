@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// WrapDriver returns a database/sql driver that traces every query as a
+// client span, a child of the span in the query's context. attrs describes
+// the database of a DSN (host, schema) for the spans of its connections.
+func WrapDriver(d driver.Driver, attrs func(dsn string) []Attribute) driver.Driver {
+	return &tracedDriver{driver: d, attrs: attrs}
+}
+
+// tracedDriver opens traced connections
+type tracedDriver struct {
+	driver driver.Driver
+	attrs  func(dsn string) []Attribute
+}
+
+// Open opens a connection of the wrapped driver
+func (d *tracedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, attrs: d.attrs(dsn)}, nil
+}
+
+// startQuery begins the span of one statement
+func startQuery(ctx context.Context, query string, attrs []Attribute) (context.Context, *Span) {
+	spanAttrs := make([]Attribute, 0, len(attrs)+1)
+	spanAttrs = append(spanAttrs, attrs...)
+	spanAttrs = append(spanAttrs, String("db.statement", query))
+	return StartKind(ctx, "sql.query", KindClient, spanAttrs...)
+}
+
+// endQuery finishes the span of a statement. driver.ErrSkip means the driver
+// falls back to a prepared statement, which is traced itself.
+func endQuery(span *Span, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	span.RecordError(err)
+	span.End()
+}
+
+// tracedConn traces the statements of a connection and forwards the optional
+// driver interfaces of the wrapped connection
+type tracedConn struct {
+	driver.Conn
+	attrs []Attribute
+}
+
+// QueryContext traces a query run without preparing it
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuery(ctx, query, c.attrs)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endQuery(span, err)
+	return rows, err
+}
+
+// ExecContext traces a statement run without preparing it
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuery(ctx, query, c.attrs)
+	result, err := execer.ExecContext(ctx, query, args)
+	endQuery(span, err)
+	return result, err
+}
+
+// PrepareContext prepares a statement whose executions are traced
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query, attrs: c.attrs}, nil
+}
+
+// BeginTx starts a transaction on the wrapped connection
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping checks the wrapped connection
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the wrapped connection before reuse
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the wrapped connection can be reused
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue lets the wrapped connection convert query arguments
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tracedStmt traces the executions of a prepared statement
+type tracedStmt struct {
+	driver.Stmt
+	query string
+	attrs []Attribute
+}
+
+// QueryContext traces one execution of the statement
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuery(ctx, s.query, s.attrs)
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args))
+	}
+	endQuery(span, err)
+	return rows, err
+}
+
+// ExecContext traces one execution of the statement
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuery(ctx, s.query, s.attrs)
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args))
+	}
+	endQuery(span, err)
+	return result, err
+}
+
+// CheckNamedValue lets the wrapped statement convert query arguments
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// values converts named arguments for drivers without context support
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+	return vals
+}
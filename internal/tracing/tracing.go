@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span kinds
+const (
+	KindInternal = trace.SpanKindInternal
+	KindClient   = trace.SpanKindClient
+)
+
+// Attribute is a key/value pair attached to spans and metric data points
+type Attribute = attribute.KeyValue
+
+// String creates a string attribute
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+// Int creates an integer attribute
+func Int(key string, value int64) Attribute {
+	return attribute.Int64(key, value)
+}
+
+// Span is one timed operation of a trace. A nil Span is valid and records
+// nothing, so callers need not check whether tracing is enabled.
+type Span struct {
+	span  trace.Span
+	start time.Time
+
+	mu  sync.Mutex
+	end time.Time
+}
+
+// provider is the process-wide provider; nil disables tracing
+var provider atomic.Pointer[Provider]
+
+// SetProvider installs the provider new spans and metrics are recorded to
+func SetProvider(p *Provider) {
+	provider.Store(p)
+}
+
+// Start begins a span as a child of the span in ctx, or as the root of a new
+// trace
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind begins a span of a specific kind
+func StartKind(ctx context.Context, name string, kind trace.SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	p := provider.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	start := time.Now()
+	ctx, span := p.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attrs...),
+		trace.WithTimestamp(start))
+	return ctx, &Span{span: span, start: start}
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.span.End(trace.WithTimestamp(s.end))
+}
+
+// Duration returns how long the span ran, or has run so far
+func (s *Span) Duration() time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.end.IsZero() {
+		return time.Since(s.start)
+	}
+	return s.end.Sub(s.start)
+}
+
+// RecordDuration adds a duration, in seconds, to a histogram metric
+func RecordDuration(name string, d time.Duration, attrs ...Attribute) {
+	if p := provider.Load(); p != nil {
+		p.record(name, d.Seconds(), attrs)
+	}
+}
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// accessLogEntry is one structured access log line
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	LatencyMS float64   `json:"latency_ms"`
+	Client    string    `json:"client"`
+	Subject   string    `json:"subject,omitempty"` // authenticated caller, if any
+	UserAgent string    `json:"user_agent,omitempty"`
+	WebSocket bool      `json:"websocket,omitempty"`
+}
+
+// accessLogger writes access log entries as JSON lines
+type accessLogger struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// newAccessLogger opens the configured access log output
+func newAccessLogger(cfg config.AccessLogConfig) (*accessLogger, error) {
+	switch cfg.Output {
+	case "stdout":
+		return &accessLogger{out: os.Stdout}, nil
+	case "stderr":
+		return &accessLogger{out: os.Stderr}, nil
+	}
+
+	file, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open access log: %w", err)
+	}
+	return &accessLogger{out: file}, nil
+}
+
+// write emits a single JSON line
+func (al *accessLogger) write(entry accessLogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	al.out.Write(append(line, '\n'))
+}
+
+// subjectKey is the context key holding the authenticated subject of a request
+type subjectKey struct{}
+
+// setAuthSubject records who a request was authenticated as, for the access log
+func setAuthSubject(r *http.Request, subject string) {
+	if holder, ok := r.Context().Value(subjectKey{}).(*string); ok {
+		*holder = subject
+	}
+}
+
+// statusRecorder captures the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the response size
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	n, err := sr.ResponseWriter.Write(b)
+	sr.bytes += int64(n)
+	return n, err
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	sr.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Flush forwards flushes to the underlying writer
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// accessLogMiddleware logs one JSON line per request once it has been handled
+func accessLogMiddleware(logger *accessLogger, trustProxy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var subject string
+		r = r.WithContext(context.WithValue(r.Context(), subjectKey{}, &subject))
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		logger.write(accessLogEntry{
+			Time:      start,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Status:    rec.status,
+			Bytes:     rec.bytes,
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			Client:    clientIP(r, trustProxy),
+			Subject:   subject,
+			UserAgent: r.UserAgent(),
+			WebSocket: rec.status == http.StatusSwitchingProtocols,
+		})
+	})
+}
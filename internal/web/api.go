@@ -0,0 +1,206 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/report"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// apiVersionPrefix prefixes the paths of the versioned API. The unversioned
+// /api/ paths serve the same endpoints for existing clients and are
+// deprecated.
+const apiVersionPrefix = "/api/v1"
+
+// openAPIPath serves the OpenAPI document of the versioned API; it has no
+// unversioned path
+const openAPIPath = "/openapi.json"
+
+// apiRoute is an endpoint of the versioned API, as registered on the router
+// and described in the OpenAPI document
+type apiRoute struct {
+	method  string
+	path    string // below apiVersionPrefix, e.g. /pairs/{name}/pause
+	summary string
+	admin   bool // requires the admin role
+	public  bool // served without credentials, for probes
+	query   []apiParam
+
+	request         reflect.Type // JSON request body; nil without one
+	requestOptional bool         // the request body may be omitted
+	response        reflect.Type // JSON response body; nil when contentTypes is set or there is no body
+	contentTypes    []string     // media types of a response that is not JSON
+
+	handler http.HandlerFunc
+}
+
+// apiParam is a query parameter of an endpoint
+type apiParam struct {
+	name        string
+	description string
+}
+
+// Query parameters shared by the history and export endpoints
+var (
+	pairParam     = apiParam{"pair", "Database pair; all pairs when empty"}
+	durationParam = apiParam{"duration", "How far back to look, e.g. 6h (default 1h, capped at the retained history)"}
+)
+
+// apiRoutes lists the endpoints of the versioned API
+func (ws *WebServer) apiRoutes() []apiRoute {
+	routes := []apiRoute{
+		{method: "GET", path: "/metrics", summary: "Current results of every check of every pair",
+			response: reflect.TypeFor[storage.CurrentMetrics](), handler: ws.handleMetrics},
+		{method: "GET", path: "/alerts", summary: "Every alert in the history",
+			response: reflect.TypeFor[[]alert.Alert](), handler: ws.handleAlerts},
+		{method: "GET", path: "/alerts/stats", summary: "Size of the alert history and its evictions",
+			response: reflect.TypeFor[alert.HistoryStats](), handler: ws.handleAlertStats},
+		{method: "GET", path: "/alerts/history", summary: "A page of the alert history",
+			query: []apiParam{
+				{"pair", "Database pair"},
+				{"type", "Alert type, e.g. replica_lag"},
+				{"severity", "INFO, WARNING or CRITICAL"},
+				{"resolved", "true or false"},
+				{"since", "Raised at or after, RFC 3339"},
+				{"until", "Raised before, RFC 3339"},
+				{"offset", "Alerts to skip"},
+				{"limit", "Alerts to return"},
+			},
+			response: reflect.TypeFor[alert.HistoryPage](), handler: ws.handleAlertHistory},
+		{method: "POST", path: "/alerts/{id}/acknowledge", summary: "Acknowledge an active alert, stopping its re-notification", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, response: reflect.TypeFor[alert.Alert](), handler: ws.handleAcknowledgeAlert},
+		{method: "GET", path: "/audit", summary: "Operator actions taken through the API: who, what, when and why",
+			query:    []apiParam{pairParam, durationParam, {"action", "Action, e.g. alert.acknowledge"}, {"actor", "Who took the action"}},
+			response: reflect.TypeFor[[]storage.AuditEntry](), handler: ws.handleAudit},
+		{method: "GET", path: "/reports", summary: "Summary of every pair over a day or week: lag against its threshold, checksum and row count failures, outages",
+			query: []apiParam{
+				{"period", "day (default) or week, Monday to Sunday"},
+				{"date", "A day in the period, YYYY-MM-DD in reports.timezone; defaults to today"},
+				{"pair", "Database pair; all pairs when empty"},
+			},
+			response: reflect.TypeFor[report.Report](), handler: ws.handleReports},
+		{method: "GET", path: "/health", summary: "Connection status of every pair", public: true,
+			response: reflect.TypeFor[healthResponse](), handler: ws.handleHealth},
+		{method: "GET", path: "/viewers", summary: "Dashboard sessions",
+			response: reflect.TypeFor[viewersResponse](), handler: ws.handleViewers},
+		{method: "GET", path: "/self", summary: "The monitor's own health: cycles, checks, storage and queues",
+			response: reflect.TypeFor[selfResponse](), handler: ws.handleSelf},
+		{method: "GET", path: "/grafana/dashboard", summary: "A Grafana dashboard over /metrics, ready to import",
+			query:    []apiParam{{"datasource", "UID of the Prometheus datasource; asked for on import when empty"}},
+			response: reflect.TypeFor[map[string]any](), handler: ws.handleGrafanaDashboard},
+		{method: "GET", path: "/pairs", summary: "State of every pair",
+			response: reflect.TypeFor[[]PairRollup](), handler: ws.handlePairs},
+		{method: "POST", path: "/pairs", summary: "Add a database pair, given as its database_pairs entry in YAML or JSON, and start monitoring it", admin: true,
+			query:   []apiParam{{"reason", "Why the pair is added, for the audit log"}},
+			request: reflect.TypeFor[map[string]any](), response: reflect.TypeFor[PairRollup](), handler: ws.handleAddPair},
+		{method: "DELETE", path: "/pairs/{name}", summary: "Stop monitoring a database pair and remove it", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, handler: ws.handleRemovePair},
+		{method: "GET", path: "/pairs/{name}/thresholds", summary: "Runtime settings of a pair",
+			response: reflect.TypeFor[pairSettingsResponse](), handler: ws.handleGetPairSettings},
+		{method: "PATCH", path: "/pairs/{name}/thresholds", summary: "Change runtime settings of a pair", admin: true,
+			request: reflect.TypeFor[pairSettingsBody](), response: reflect.TypeFor[pairSettingsResponse](), handler: ws.handlePatchPairSettings},
+		{method: "GET", path: "/pairs/{name}/info", summary: "Version, flavor and encryption and replication settings of a pair's databases",
+			response: reflect.TypeFor[monitor.PairInfo](), handler: ws.handlePairInfo},
+		{method: "GET", path: "/pairs/{name}/preflight", summary: "Check the privileges and server features a pair's checks need",
+			response: reflect.TypeFor[monitor.PreflightReport](), handler: ws.handlePairPreflight},
+		{method: "POST", path: "/pairs/{name}/pause", summary: "Pause the checks of a pair", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, response: reflect.TypeFor[PairRollup](), handler: ws.handlePausePair},
+		{method: "POST", path: "/pairs/{name}/resume", summary: "Resume the checks of a paused pair", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, response: reflect.TypeFor[PairRollup](), handler: ws.handleResumePair},
+		{method: "POST", path: "/pairs/{name}/complete", summary: "Mark the migration of a pair complete", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, response: reflect.TypeFor[PairRollup](), handler: ws.handleCompletePair},
+		{method: "POST", path: "/pairs/{name}/checks/{check}/pause", summary: "Pause one check of a pair", admin: true,
+			request: reflect.TypeFor[pauseCheckBody](), requestOptional: true, response: reflect.TypeFor[PairRollup](), handler: ws.handlePauseCheck},
+		{method: "POST", path: "/pairs/{name}/checks/{check}/resume", summary: "Resume a paused check of a pair", admin: true,
+			request: reflect.TypeFor[reasonBody](), requestOptional: true, response: reflect.TypeFor[PairRollup](), handler: ws.handleResumeCheck},
+		{method: "GET", path: "/backfills", summary: "Declared backfills",
+			query:    []apiParam{pairParam},
+			response: reflect.TypeFor[[]monitor.Backfill](), handler: ws.handleBackfills},
+		{method: "POST", path: "/pairs/{name}/backfills", summary: "Declare a backfill of a table", admin: true,
+			request: reflect.TypeFor[backfillBody](), response: reflect.TypeFor[monitor.Backfill](), handler: ws.handleDeclareBackfill},
+		{method: "DELETE", path: "/backfills/{id}", summary: "End a backfill early", admin: true,
+			handler: ws.handleCancelBackfill},
+		{method: "GET", path: "/rehearsal/faults", summary: "Injected rehearsal faults",
+			query:    []apiParam{pairParam},
+			response: reflect.TypeFor[[]monitor.Fault](), handler: ws.handleFaults},
+		{method: "POST", path: "/pairs/{name}/faults", summary: "Inject a rehearsal fault", admin: true,
+			request: reflect.TypeFor[faultBody](), response: reflect.TypeFor[monitor.Fault](), handler: ws.handleInjectFault},
+		{method: "DELETE", path: "/rehearsal/faults/{id}", summary: "End a rehearsal fault early", admin: true,
+			handler: ws.handleClearFault},
+		{method: "GET", path: "/federation", summary: "Pairs of this monitor and its federation peers",
+			response: reflect.TypeFor[[]FederatedMonitor](), handler: ws.handleFederation},
+		{method: "GET", path: "/history/replica_lag", summary: "Replica lag samples, or rollups beyond the raw retention",
+			query:    []apiParam{pairParam, durationParam, {"step", "Bucket width of rolled-up points, e.g. 1h"}},
+			response: reflect.TypeFor[HistoryResponse[LagPoint]](), handler: ws.handleReplicaLagHistory},
+		{method: "GET", path: "/history/health_score", summary: "Health score samples",
+			query:    []apiParam{pairParam, durationParam},
+			response: reflect.TypeFor[HistoryResponse[HealthScorePoint]](), handler: ws.handleHealthScoreHistory},
+		{method: "GET", path: "/history/replication_events", summary: "Replication thread state changes and outages",
+			query:    []apiParam{pairParam, durationParam},
+			response: reflect.TypeFor[ReplicationTimeline](), handler: ws.handleReplicationEvents},
+		{method: "GET", path: "/history/checksum", summary: "Checksum pass rate",
+			query:    []apiParam{pairParam, durationParam},
+			response: reflect.TypeFor[HistoryResponse[PassRatePoint]](), handler: ws.handleChecksumHistory},
+		{method: "GET", path: "/history/consistency", summary: "Row count consistency pass rate",
+			query:    []apiParam{pairParam, durationParam},
+			response: reflect.TypeFor[HistoryResponse[PassRatePoint]](), handler: ws.handleConsistencyHistory},
+		{method: "GET", path: "/history/{chart}", summary: "A history series as a chart, e.g. replica_lag.png or health_score.svg",
+			query:        []apiParam{{"pair", "Database pair (required)"}, durationParam, {"width", "Pixels"}, {"height", "Pixels"}},
+			contentTypes: []string{"image/png", "image/svg+xml"}, handler: ws.handleHistoryChart},
+		{method: "GET", path: "/export/{dataset}", summary: "A history series as CSV or XLSX, e.g. checksum.csv",
+			query: []apiParam{pairParam, {"table", "Table"}, {"from", "Start, RFC 3339"}, {"to", "End, RFC 3339"},
+				{"duration", "How far back from to, when from is empty (default 24h)"}},
+			contentTypes: []string{"text/csv", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}, handler: ws.handleExport},
+		{method: "GET", path: "/diffs", summary: "The latest row diff of each table",
+			response: reflect.TypeFor[[]storage.DiffResult](), handler: ws.handleDiffs},
+		{method: "POST", path: "/pairs/{name}/tables/{table}/diff", summary: "Run a row diff of a table", admin: true,
+			query:    []apiParam{{"chunk_size", "Rows per chunk"}, {"max_rows", "Differing rows to report at most"}},
+			response: reflect.TypeFor[storage.DiffResult](), handler: ws.handleRunDiff},
+		{method: "GET", path: openAPIPath, summary: "This OpenAPI document", public: true,
+			response: reflect.TypeFor[map[string]any](), handler: ws.handleOpenAPI},
+	}
+	if ws.config.AlertIngestion.Enabled {
+		routes = append(routes, apiRoute{method: "POST", path: "/ingest/alertmanager", summary: "Ingest the alerts of an Alertmanager webhook", admin: true,
+			request: reflect.TypeFor[alertmanagerWebhook](), response: reflect.TypeFor[ingestResponse](), handler: ws.handleIngestAlertmanager})
+	}
+	return routes
+}
+
+// setupAPIRoutes registers the versioned API, and its unversioned paths
+func (ws *WebServer) setupAPIRoutes() {
+	for _, route := range ws.apiRoutes() {
+		handler := route.handler
+		if route.admin {
+			handler = ws.requireAdmin(handler)
+		}
+		ws.router.HandleFunc(route.method+" "+apiVersionPrefix+route.path, handler)
+		if route.path != openAPIPath {
+			ws.router.HandleFunc(route.method+" /api"+route.path, deprecatedAPI(handler))
+		}
+	}
+}
+
+// deprecatedAPI serves an unversioned /api/ path, pointing clients at its
+// /api/v1 successor
+func deprecatedAPI(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := apiVersionPrefix + strings.TrimPrefix(r.URL.Path, "/api")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next(w, r)
+	}
+}
+
+// unversionedPath returns an API path without its version, so that both
+// paths of an endpoint are treated alike, e.g. /api/v1/export/x -> /api/export/x
+func unversionedPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, apiVersionPrefix+"/"); ok {
+		return "/api/" + rest
+	}
+	return path
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// staticFiles holds the dashboard page and the stylesheet and scripts it
+// loads from /static/
+//
+//go:embed static
+var staticFiles embed.FS
+
+// staticFS is staticFiles rooted at the static directory
+var staticFS = mustSub(staticFiles, "static")
+
+// indexHTML is the dashboard page served at /
+var indexHTML = mustReadFile(staticFS, "index.html")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+func mustReadFile(fsys fs.FS, name string) []byte {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// handleStatic serves the dashboard's stylesheets and scripts. They carry no
+// version in their names, so browsers must not reuse them across upgrades.
+func handleStatic() http.Handler {
+	files := http.StripPrefix("/static/", http.FileServerFS(staticFS))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
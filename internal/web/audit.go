@@ -0,0 +1,69 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// reasonBody is the optional body of an operator action: why it was taken,
+// recorded in the audit log
+type reasonBody struct {
+	Reason string `json:"reason"`
+}
+
+// decodeReason reads the optional reason of an operator action, writing an
+// error when the body is invalid
+func decodeReason(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body reasonBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return "", false
+	}
+	return body.Reason, true
+}
+
+// audit records an operator action taken through the API, attributed to the
+// caller, and sends it to WebSocket clients
+func (ws *WebServer) audit(r *http.Request, entry storage.AuditEntry) {
+	caller := identityFrom(r)
+	entry.Actor, entry.Role = caller.Subject, caller.Role
+	entry.ClientIP = clientIP(r, ws.config.RateLimit.TrustProxyHeaders)
+	entry.Timestamp = time.Now()
+	ws.storage.RecordAudit(entry)
+
+	select {
+	case ws.wsEvents <- WSMessage{Type: "audit_entry", Timestamp: entry.Timestamp, Data: entry}:
+	default:
+		log.Printf("WebSocket event queue full, dropping audit entry %s by %s", entry.Action, entry.Actor)
+	}
+}
+
+// handleAudit returns the operator actions of the audit log, optionally for
+// one pair or action
+func (ws *WebServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.EventRetention())
+	if !ok {
+		return
+	}
+	action := r.URL.Query().Get("action")
+	actor := r.URL.Query().Get("actor")
+
+	entries := make([]storage.AuditEntry, 0)
+	for _, e := range ws.storage.GetAuditLog(duration) {
+		if (pair != "" && e.DatabasePair != pair) || (action != "" && e.Action != action) || (actor != "" && e.Actor != actor) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
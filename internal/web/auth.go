@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// identity is an authenticated caller
+type identity struct {
+	Subject string
+	Role    string
+}
+
+// identityKey is the context key holding the caller's identity
+type identityKey struct{}
+
+// identityFrom returns the authenticated caller of a request, or nil
+func identityFrom(r *http.Request) *identity {
+	id, _ := r.Context().Value(identityKey{}).(*identity)
+	return id
+}
+
+// authenticator resolves who is calling and enforces the configured auth mode
+type authenticator struct {
+	config   *config.Config
+	users    map[string]config.BasicAuthUser
+	sessions *sessionCodec
+	oidc     *oidcProvider // nil unless mode is oidc
+
+	// Successful basic auth checks are cached so bcrypt does not run on every poll
+	mu       sync.Mutex
+	verified map[[32]byte]time.Time // key: sha256 of username:password
+}
+
+// newAuthenticator creates an authenticator for the configured auth mode
+func newAuthenticator(ctx context.Context, cfg *config.Config) (*authenticator, error) {
+	secret := []byte(cfg.Auth.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &authenticator{
+		config:   cfg,
+		users:    make(map[string]config.BasicAuthUser, len(cfg.Auth.Users)),
+		sessions: &sessionCodec{secret: secret},
+		verified: make(map[[32]byte]time.Time),
+	}
+	for _, user := range cfg.Auth.Users {
+		a.users[user.Username] = user
+	}
+
+	if cfg.Auth.Mode == "oidc" {
+		provider, err := newOIDCProvider(ctx, cfg.Auth, a.sessions)
+		if err != nil {
+			return nil, fmt.Errorf("oidc: %w", err)
+		}
+		a.oidc = provider
+	}
+
+	return a, nil
+}
+
+// unauthenticatedPaths are served without credentials for load balancer and
+// Kubernetes probes
+var unauthenticatedPaths = map[string]bool{
+	"/api/health":                  true,
+	apiVersionPrefix + "/health":   true,
+	apiVersionPrefix + openAPIPath: true,
+	"/livez":                       true,
+	"/readyz":                      true,
+}
+
+// middleware attaches the caller's identity to each request and rejects
+// unauthenticated requests unless auth is disabled
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.oidc != nil && strings.HasPrefix(r.URL.Path, "/auth/") {
+			a.oidc.ServeHTTP(w, r)
+			return
+		}
+
+		if id := a.identify(r); id != nil {
+			setAuthSubject(r, id.Subject)
+			r = r.WithContext(context.WithValue(r.Context(), identityKey{}, id))
+		} else if a.config.Auth.Mode != "none" && !unauthenticatedPaths[r.URL.Path] {
+			a.challenge(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// identify resolves the caller from a bearer token or API key, a client
+// certificate, basic credentials or a session cookie
+func (a *authenticator) identify(r *http.Request) *identity {
+	if token := requestToken(r); token != "" {
+		return a.identifyToken(token)
+	}
+	if name := peerCommonName(r); name != "" {
+		if cert := clientCert(a.config.Auth.ClientCerts, name); cert != nil {
+			return &identity{Subject: "cert:" + name, Role: cert.Role}
+		}
+	}
+
+	switch a.config.Auth.Mode {
+	case "basic":
+		if username, password, ok := r.BasicAuth(); ok && a.checkPassword(username, password) {
+			return &identity{Subject: "user:" + username, Role: a.users[username].Role}
+		}
+	case "oidc":
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			var session sessionData
+			if a.sessions.decode(cookie.Value, &session) == nil && session.Kind == "session" && time.Now().Before(session.Expires) {
+				return &identity{Subject: "oidc:" + session.Subject, Role: session.Role}
+			}
+		}
+	}
+	return nil
+}
+
+// requestToken returns the bearer token or X-API-Key header of a request, or ""
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// identifyToken resolves an admin or API bearer token
+func (a *authenticator) identifyToken(token string) *identity {
+	if isAdminToken(a.config.AdminTokens, token) {
+		return &identity{Subject: "admin-token:" + tokenFingerprint(token), Role: config.RoleAdmin}
+	}
+	for _, apiToken := range a.config.Auth.APITokens {
+		if subtle.ConstantTimeCompare([]byte(apiToken.Token), []byte(token)) == 1 {
+			return &identity{Subject: "token:" + apiToken.Name, Role: apiToken.Role}
+		}
+	}
+	return nil
+}
+
+// checkPassword verifies basic auth credentials against the configured bcrypt hashes
+func (a *authenticator) checkPassword(username, password string) bool {
+	user, ok := a.users[username]
+	if !ok {
+		return false
+	}
+
+	key := sha256.Sum256([]byte(username + ":" + password))
+	a.mu.Lock()
+	expires, cached := a.verified[key]
+	a.mu.Unlock()
+	if cached && time.Now().Before(expires) {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.verified[key] = time.Now().Add(5 * time.Minute)
+	return true
+}
+
+// challenge asks an unauthenticated caller to log in
+func (a *authenticator) challenge(w http.ResponseWriter, r *http.Request) {
+	if a.config.Auth.Mode == "basic" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="monitor", charset="UTF-8"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Browsers are sent through the OIDC login; API and WebSocket clients get 401
+	if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/ws" {
+		http.Redirect(w, r, "/auth/login?return="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+		return
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// Cookie names used by the OIDC flow
+const (
+	sessionCookie = "monitor_session"
+	stateCookie   = "monitor_oidc_state"
+)
+
+// sessionData is the signed content of session and login-state cookies
+type sessionData struct {
+	Kind     string    `json:"kind"` // "session" or "state"
+	Subject  string    `json:"sub,omitempty"`
+	Role     string    `json:"role,omitempty"`
+	State    string    `json:"state,omitempty"`
+	Nonce    string    `json:"nonce,omitempty"`
+	Verifier string    `json:"verifier,omitempty"` // PKCE code verifier
+	Return   string    `json:"return,omitempty"`
+	Expires  time.Time `json:"exp"`
+}
+
+// sessionCodec signs and verifies cookie values with HMAC-SHA256
+type sessionCodec struct {
+	secret []byte
+}
+
+// encode serializes and signs a value
+func (sc *sessionCodec) encode(value interface{}) (string, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sc.sign(encoded), nil
+}
+
+// decode verifies and deserializes a signed value
+func (sc *sessionCodec) decode(signed string, value interface{}) error {
+	encoded, signature, ok := strings.Cut(signed, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sc.sign(encoded))) {
+		return fmt.Errorf("invalid signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, value)
+}
+
+// sign returns the encoded HMAC of a value
+func (sc *sessionCodec) sign(value string) string {
+	mac := hmac.New(sha256.New, sc.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
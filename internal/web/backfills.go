@@ -0,0 +1,100 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// backfillBody declares a backfill. The window ends at end, or duration after
+// start; start defaults to now.
+type backfillBody struct {
+	Tables           []string  `json:"tables"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	Duration         string    `json:"duration"`
+	TolerancePercent float64   `json:"tolerance_percent"`
+	Reason           string    `json:"reason"`
+}
+
+// handleBackfills returns running and upcoming backfills, optionally for one pair
+func (ws *WebServer) handleBackfills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.engine.Backfills(r.URL.Query().Get("pair")))
+}
+
+// handleDeclareBackfill registers a backfill for tables of a pair
+func (ws *WebServer) handleDeclareBackfill(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+
+	var body backfillBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	end := body.End
+	if body.Duration != "" {
+		if !end.IsZero() {
+			http.Error(w, "use either end or duration", http.StatusBadRequest)
+			return
+		}
+		duration, err := time.ParseDuration(body.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'", body.Duration), http.StatusBadRequest)
+			return
+		}
+		start := body.Start
+		if start.IsZero() {
+			start = time.Now()
+		}
+		end = start.Add(duration)
+	}
+
+	subject := identityFrom(r).Subject
+	backfill, err := ws.engine.DeclareBackfill(monitor.Backfill{
+		Pair:             pairName,
+		Tables:           body.Tables,
+		Start:            body.Start,
+		End:              end,
+		TolerancePercent: body.TolerancePercent,
+		Reason:           body.Reason,
+		DeclaredBy:       subject,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[%s] Backfill %s declared via API by %s (%s)", pairName, backfill.ID, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "backfill.declare", DatabasePair: pairName, Target: backfill.ID, Reason: backfill.Reason,
+		Details: fmt.Sprintf("tables %s from %s to %s", strings.Join(backfill.Tables, ", "), backfill.Start.UTC().Format(time.RFC3339), backfill.End.UTC().Format(time.RFC3339))})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(backfill)
+}
+
+// handleCancelBackfill ends a backfill early
+func (ws *WebServer) handleCancelBackfill(w http.ResponseWriter, r *http.Request) {
+	backfill, err := ws.engine.CancelBackfill(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("[%s] Backfill %s cancelled via API by %s (%s)", backfill.Pair, backfill.ID, identityFrom(r).Subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "backfill.cancel", DatabasePair: backfill.Pair, Target: backfill.ID})
+
+	w.WriteHeader(http.StatusNoContent)
+}
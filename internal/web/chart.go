@@ -0,0 +1,281 @@
+package web
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chart colors, matching the dashboard
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartText       = color.RGBA{0x2c, 0x3e, 0x50, 0xff}
+	chartMuted      = color.RGBA{0x7f, 0x8c, 0x8d, 0xff}
+	chartGrid       = color.RGBA{0xec, 0xf0, 0xf1, 0xff}
+	chartSeries     = color.RGBA{0x34, 0x98, 0xdb, 0xff}
+	chartWarning    = color.RGBA{0xf3, 0x9c, 0x12, 0xff}
+	chartCritical   = color.RGBA{0xe7, 0x4c, 0x3c, 0xff}
+)
+
+const (
+	defaultChartWidth  = 800
+	defaultChartHeight = 300
+)
+
+// chartPoint is one sample of a chart
+type chartPoint struct {
+	t time.Time
+	v float64
+}
+
+// chartThreshold is a horizontal line marking an alert tier
+type chartThreshold struct {
+	label string
+	value float64
+	color color.RGBA
+}
+
+// chart is a line chart of one history series
+type chart struct {
+	title      string
+	unit       string // appended to y axis labels
+	start, end time.Time
+	points     []chartPoint
+	max        float64 // fixed top of the y axis; 0 fits the data
+	thresholds []chartThreshold
+}
+
+// textAnchor aligns text horizontally at its x coordinate
+type textAnchor string
+
+const (
+	anchorStart  textAnchor = "start"
+	anchorMiddle textAnchor = "middle"
+	anchorEnd    textAnchor = "end"
+)
+
+// canvas is a drawing surface a chart is rendered onto
+type canvas interface {
+	fill(r image.Rectangle, c color.RGBA)
+	line(from, to image.Point, c color.RGBA, dashed bool)
+	polyline(points []image.Point, c color.RGBA)
+	text(at image.Point, s string, anchor textAnchor, large bool, c color.RGBA) // at is the baseline
+}
+
+// handleHistoryChart renders a history series of a pair as a PNG or SVG line
+// chart, e.g. /api/history/replica_lag.png?pair=X&duration=24h, for status
+// pages and reports
+func (ws *WebServer) handleHistoryChart(w http.ResponseWriter, r *http.Request) {
+	name, format, _ := strings.Cut(r.PathValue("chart"), ".")
+	if format != "png" && format != "svg" {
+		http.NotFound(w, r)
+		return
+	}
+
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.HistoryDuration())
+	if !ok {
+		return
+	}
+	if pair == "" {
+		http.Error(w, "pair is required", http.StatusBadRequest)
+		return
+	}
+	if _, ok := ws.config.PairSettings(pair); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pair), http.StatusNotFound)
+		return
+	}
+	width, height, ok := chartSize(w, r)
+	if !ok {
+		return
+	}
+
+	ch, ok := ws.historyChart(name, pair, duration)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if format == "svg" {
+		svg := newSVGCanvas(width, height)
+		ch.draw(svg, width, height)
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(svg.bytes())
+		return
+	}
+	img := newPNGCanvas(width, height)
+	ch.draw(img, width, height)
+	w.Header().Set("Content-Type", "image/png")
+	if err := img.encode(w); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode chart: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// chartSize parses the optional width and height query parameters
+func chartSize(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	size := func(name string, value, min, max int) (int, bool) {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			return value, true
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			http.Error(w, fmt.Sprintf("%s must be between %d and %d", name, min, max), http.StatusBadRequest)
+			return 0, false
+		}
+		return n, true
+	}
+
+	width, ok := size("width", defaultChartWidth, 200, 2000)
+	if !ok {
+		return 0, 0, false
+	}
+	height, ok := size("height", defaultChartHeight, 120, 1200)
+	if !ok {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// historyChart builds the chart of a history series, reporting false for an
+// unknown series
+func (ws *WebServer) historyChart(name, pair string, duration time.Duration) (*chart, bool) {
+	end := time.Now()
+	ch := &chart{start: end.Add(-duration), end: end}
+
+	switch name {
+	case "replica_lag":
+		ch.title = "Replica lag"
+		ch.unit = "s"
+		for _, p := range ws.lagPoints(pair, duration) {
+			ch.points = append(ch.points, chartPoint{p.Timestamp, p.LagSeconds})
+		}
+		tiers := ws.config.PairThresholds(pair).ReplicaLag
+		if tiers.WarningAt > 0 {
+			ch.thresholds = append(ch.thresholds, chartThreshold{"warning", tiers.WarningAt.Seconds(), chartWarning})
+		}
+		if tiers.CriticalAt > 0 {
+			ch.thresholds = append(ch.thresholds, chartThreshold{"critical", tiers.CriticalAt.Seconds(), chartCritical})
+		}
+	case "health_score":
+		ch.title = "Health score"
+		ch.max = 100
+		for _, p := range ws.healthScorePoints(pair, duration) {
+			ch.points = append(ch.points, chartPoint{p.Timestamp, p.Score})
+		}
+	case "checksum", "consistency":
+		points := ws.checksumPoints(pair, duration)
+		ch.title = "Checksum pass rate"
+		if name == "consistency" {
+			points = ws.consistencyPoints(pair, duration)
+			ch.title = "Consistency pass rate"
+		}
+		ch.unit = "%"
+		ch.max = 100
+		for _, p := range points {
+			ch.points = append(ch.points, chartPoint{p.Timestamp, p.PassRate})
+		}
+	default:
+		return nil, false
+	}
+
+	ch.title = fmt.Sprintf("%s: %s, last %s", pair, ch.title, shortDuration(duration))
+	return ch, true
+}
+
+// shortDuration formats a duration without zero minutes and seconds, e.g. "24h"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// draw renders the chart onto a canvas of the given size
+func (ch *chart) draw(c canvas, width, height int) {
+	plot := image.Rect(64, 36, width-72, height-32)
+
+	c.fill(image.Rect(0, 0, width, height), chartBackground)
+	c.text(image.Pt(plot.Min.X, 22), ch.title, anchorStart, true, chartText)
+
+	// Y axis from 0 to a round maximum covering the data and thresholds
+	top := ch.max
+	if top == 0 {
+		for _, p := range ch.points {
+			top = math.Max(top, p.v)
+		}
+		for _, t := range ch.thresholds {
+			top = math.Max(top, t.value)
+		}
+		top = niceCeil(top * 1.05)
+	}
+	y := func(v float64) int {
+		v = math.Min(math.Max(v, 0), top)
+		return plot.Max.Y - int(math.Round(v/top*float64(plot.Dy())))
+	}
+	x := func(t time.Time) int {
+		span := ch.end.Sub(ch.start)
+		return plot.Min.X + int(math.Round(float64(t.Sub(ch.start))/float64(span)*float64(plot.Dx())))
+	}
+
+	const yTicks = 5
+	step := top / yTicks
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	for i := 0; i <= yTicks; i++ {
+		v := step * float64(i)
+		c.line(image.Pt(plot.Min.X, y(v)), image.Pt(plot.Max.X, y(v)), chartGrid, false)
+		label := strconv.FormatFloat(v, 'f', decimals, 64) + ch.unit
+		c.text(image.Pt(plot.Min.X-6, y(v)+4), label, anchorEnd, false, chartMuted)
+	}
+
+	const xTicks = 6
+	layout := "15:04"
+	if ch.end.Sub(ch.start) > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	for i := 0; i <= xTicks; i++ {
+		t := ch.start.Add(ch.end.Sub(ch.start) * time.Duration(i) / xTicks)
+		c.line(image.Pt(x(t), plot.Max.Y), image.Pt(x(t), plot.Max.Y+4), chartMuted, false)
+		c.text(image.Pt(x(t), plot.Max.Y+18), t.Format(layout), anchorMiddle, false, chartMuted)
+	}
+	c.line(image.Pt(plot.Min.X, plot.Max.Y), image.Pt(plot.Max.X, plot.Max.Y), chartMuted, false)
+	c.line(image.Pt(plot.Min.X, plot.Min.Y), image.Pt(plot.Min.X, plot.Max.Y), chartMuted, false)
+
+	for _, t := range ch.thresholds {
+		c.line(image.Pt(plot.Min.X, y(t.value)), image.Pt(plot.Max.X, y(t.value)), t.color, true)
+		c.text(image.Pt(plot.Max.X+6, y(t.value)+4), t.label, anchorStart, false, t.color)
+	}
+
+	if len(ch.points) == 0 {
+		c.text(image.Pt((plot.Min.X+plot.Max.X)/2, (plot.Min.Y+plot.Max.Y)/2), "No data", anchorMiddle, true, chartMuted)
+		return
+	}
+	line := make([]image.Point, 0, len(ch.points))
+	for _, p := range ch.points {
+		line = append(line, image.Pt(x(p.t), y(p.v)))
+	}
+	c.polyline(line, chartSeries)
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of ten
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, f := range []float64{1, 2, 5} {
+		if v <= f*magnitude {
+			return f * magnitude
+		}
+	}
+	return 10 * magnitude
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// svgCanvas draws a chart as SVG elements
+type svgCanvas struct {
+	b strings.Builder
+}
+
+// newSVGCanvas creates an SVG document of the given size
+func newSVGCanvas(width, height int) *svgCanvas {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif">`+"\n",
+		width, height, width, height)
+	return c
+}
+
+// svgColor formats a color as a hex triplet
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *svgCanvas) fill(r image.Rectangle, col color.RGBA) {
+	fmt.Fprintf(&c.b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy(), svgColor(col))
+}
+
+func (c *svgCanvas) line(from, to image.Point, col color.RGBA, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="6 4"`
+	}
+	fmt.Fprintf(&c.b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"%s/>`+"\n", from.X, from.Y, to.X, to.Y, svgColor(col), dash)
+}
+
+func (c *svgCanvas) polyline(points []image.Point, col color.RGBA) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%d,%d", p.X, p.Y)
+	}
+	fmt.Fprintf(&c.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2" stroke-linejoin="round"/>`+"\n", strings.Join(coords, " "), svgColor(col))
+}
+
+func (c *svgCanvas) text(at image.Point, s string, anchor textAnchor, large bool, col color.RGBA) {
+	size, weight := 11, "normal"
+	if large {
+		size, weight = 14, "bold"
+	}
+	fmt.Fprintf(&c.b, `<text x="%d" y="%d" font-size="%d" font-weight="%s" text-anchor="%s" fill="%s">%s</text>`+"\n",
+		at.X, at.Y, size, weight, anchor, svgColor(col), html.EscapeString(s))
+}
+
+// bytes closes the document and returns it
+func (c *svgCanvas) bytes() []byte {
+	c.b.WriteString("</svg>\n")
+	return []byte(c.b.String())
+}
+
+// pngCanvas draws a chart onto an image, with text in a built-in 5x7 pixel
+// font since no font files are available to the monitor
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+// newPNGCanvas creates an image of the given size
+func newPNGCanvas(width, height int) *pngCanvas {
+	return &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (c *pngCanvas) fill(r image.Rectangle, col color.RGBA) {
+	r = r.Intersect(c.img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c.img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+func (c *pngCanvas) line(from, to image.Point, col color.RGBA, dashed bool) {
+	c.bresenham(from, to, func(i, x, y int) {
+		if !dashed || i%10 < 6 {
+			c.img.SetRGBA(x, y, col)
+		}
+	})
+}
+
+func (c *pngCanvas) polyline(points []image.Point, col color.RGBA) {
+	for i := 1; i < len(points); i++ {
+		c.bresenham(points[i-1], points[i], func(_, x, y int) {
+			c.img.SetRGBA(x, y, col)
+			c.img.SetRGBA(x, y+1, col) // 2px wide
+		})
+	}
+	if len(points) == 1 {
+		c.fill(image.Rect(points[0].X-1, points[0].Y-1, points[0].X+2, points[0].Y+2), col)
+	}
+}
+
+// bresenham calls plot for every pixel of a line, with its index along the line
+func (c *pngCanvas) bresenham(from, to image.Point, plot func(i, x, y int)) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+	err := dx + dy
+	x, y := from.X, from.Y
+	for i := 0; ; i++ {
+		plot(i, x, y)
+		if x == to.X && y == to.Y {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x += sx
+		} else {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (c *pngCanvas) text(at image.Point, s string, anchor textAnchor, large bool, col color.RGBA) {
+	scale := 1
+	if large {
+		scale = 2
+	}
+	s = strings.ToUpper(s)
+	width := (utf8.RuneCountInString(s)*6 - 1) * scale
+	x := at.X
+	switch anchor {
+	case anchorMiddle:
+		x -= width / 2
+	case anchorEnd:
+		x -= width
+	}
+	top := at.Y - 7*scale
+
+	for _, r := range s {
+		glyph, ok := font5x7[r]
+		if !ok {
+			glyph = font5x7['?']
+		}
+		for row, bits := range glyph {
+			for col5 := 0; col5 < 5; col5++ {
+				if bits&(0x10>>col5) != 0 {
+					c.fill(image.Rect(x+col5*scale, top+row*scale, x+(col5+1)*scale, top+(row+1)*scale), col)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// encode writes the image as PNG
+func (c *pngCanvas) encode(w io.Writer) error {
+	return png.Encode(w, c.img)
+}
+
+// font5x7 holds the glyphs the charts use; each row's low 5 bits are its
+// pixels, left to right. Lowercase letters are drawn as uppercase.
+var font5x7 = map[rune][7]uint8{
+	' ': {},
+	'0': {0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e},
+	'1': {0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'2': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f},
+	'3': {0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e},
+	'4': {0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02},
+	'5': {0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e},
+	'6': {0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e},
+	'7': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e},
+	'9': {0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c},
+	'A': {0x0e, 0x11, 0x11, 0x11, 0x1f, 0x11, 0x11},
+	'B': {0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e},
+	'C': {0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e},
+	'D': {0x1c, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1c},
+	'E': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f},
+	'F': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10},
+	'G': {0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f},
+	'H': {0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11},
+	'I': {0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f},
+	'M': {0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'P': {0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10},
+	'Q': {0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d},
+	'R': {0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11},
+	'S': {0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e},
+	'T': {0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a},
+	'X': {0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04},
+	'Z': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0c, 0x04, 0x08},
+	':': {0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1f, 0x00, 0x00, 0x00},
+	'_': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1f},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'(': {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')': {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'?': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+}
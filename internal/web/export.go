@@ -0,0 +1,205 @@
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportDataset is a history series that can be exported row by row
+type exportDataset struct {
+	columns []string
+	// rows calls emit for each row of the series within [from, to], oldest first
+	rows func(ws *WebServer, f exportFilter, emit func(row []interface{}) error) error
+}
+
+// exportFilter selects the rows of an export
+type exportFilter struct {
+	pair     string
+	table    string
+	from, to time.Time
+}
+
+// matches reports whether a row of a pair and table at ts is selected
+func (f exportFilter) matches(pair, table string, ts time.Time) bool {
+	return (f.pair == "" || pair == f.pair) &&
+		(f.table == "" || table == f.table) &&
+		!ts.Before(f.from) && !ts.After(f.to)
+}
+
+// exportDatasets are the series available under /api/export
+var exportDatasets = map[string]exportDataset{
+	"replica_lag": {
+		columns: []string{"timestamp", "pair", "lag_seconds", "status", "io_running", "sql_running", "error"},
+		rows: func(ws *WebServer, f exportFilter, emit func([]interface{}) error) error {
+			if f.table != "" {
+				return nil // lag is measured per pair
+			}
+			for _, m := range ws.storage.GetReplicaLagHistory(time.Since(f.from)) {
+				if !f.matches(m.DatabasePair, "", m.Timestamp) {
+					continue
+				}
+				if err := emit([]interface{}{m.Timestamp, m.DatabasePair, m.LagSeconds, m.Status, m.IORunning, m.SQLRunning, errorText(m.Error)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	"checksum": {
+		columns: []string{"timestamp", "pair", "table", "target_table", "source_checksum", "target_checksum", "match", "skipped", "error"},
+		rows: func(ws *WebServer, f exportFilter, emit func([]interface{}) error) error {
+			for _, c := range ws.storage.GetChecksumHistory(time.Since(f.from)) {
+				if !f.matches(c.DatabasePair, c.TableName, c.Timestamp) {
+					continue
+				}
+				if err := emit([]interface{}{c.Timestamp, c.DatabasePair, c.TableName, c.TargetTable, c.SourceChecksum, c.TargetChecksum, c.Match, c.Skipped, errorText(c.Error)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	"consistency": {
+		columns: []string{"timestamp", "pair", "table", "target_table", "source_rows", "target_rows", "consistent", "approximate", "tolerance_percent", "backfill", "error"},
+		rows: func(ws *WebServer, f exportFilter, emit func([]interface{}) error) error {
+			for _, c := range ws.storage.GetConsistencyHistory(time.Since(f.from)) {
+				if !f.matches(c.DatabasePair, c.TableName, c.Timestamp) {
+					continue
+				}
+				if err := emit([]interface{}{c.Timestamp, c.DatabasePair, c.TableName, c.TargetTable, c.SourceRowCount, c.TargetRowCount, c.Consistent, c.Approximate, c.Tolerance, c.Backfill, errorText(c.Error)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// errorText returns the message of an error, or "" for nil
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// handleExport streams a history series as CSV or XLSX, e.g.
+// /api/export/checksum.csv?pair=X&table=users&from=2024-05-01T00:00:00Z
+func (ws *WebServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	name, format, _ := strings.Cut(r.PathValue("dataset"), ".")
+	dataset, ok := exportDatasets[name]
+	if !ok || (format != "csv" && format != "xlsx") {
+		http.NotFound(w, r)
+		return
+	}
+
+	filter, ok := ws.exportFilter(w, r)
+	if !ok {
+		return
+	}
+
+	scope := filter.pair
+	if scope == "" {
+		scope = "all"
+	}
+	filename := fmt.Sprintf("%s-%s-%s.%s", name, scope, filter.to.UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers are sent with the first row, so errors after it can only cut
+	// the download short
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		cw.Write(dataset.columns)
+		dataset.rows(ws, filter, func(row []interface{}) error {
+			return cw.Write(csvRow(row))
+		})
+		cw.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	xw, err := newXLSXWriter(w, name)
+	if err != nil {
+		return
+	}
+	header := make([]interface{}, len(dataset.columns))
+	for i, column := range dataset.columns {
+		header[i] = column
+	}
+	if xw.writeRow(header) != nil || dataset.rows(ws, filter, xw.writeRow) != nil {
+		return
+	}
+	xw.close()
+}
+
+// exportFilter parses the pair, table and time range of an export. The range
+// is from/to in RFC 3339, or the duration up to now; it starts no earlier than
+// the retained history.
+func (ws *WebServer) exportFilter(w http.ResponseWriter, r *http.Request) (exportFilter, bool) {
+	query := r.URL.Query()
+	filter := exportFilter{pair: query.Get("pair"), table: query.Get("table"), to: time.Now()}
+	if filter.pair != "" {
+		if _, ok := ws.config.PairSettings(filter.pair); !ok {
+			http.Error(w, fmt.Sprintf("database pair '%s' not found", filter.pair), http.StatusNotFound)
+			return filter, false
+		}
+	}
+
+	parseTime := func(name string, value *time.Time) bool {
+		v := query.Get(name)
+		if v == "" {
+			return true
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: must be an RFC 3339 time", name), http.StatusBadRequest)
+			return false
+		}
+		*value = t
+		return true
+	}
+	if !parseTime("to", &filter.to) || !parseTime("from", &filter.from) {
+		return filter, false
+	}
+
+	if filter.from.IsZero() {
+		if query.Get("duration") == "" {
+			filter.from = filter.to.Add(-24 * time.Hour)
+		} else {
+			_, duration, ok := ws.historyParams(w, r, ws.storage.HistoryDuration())
+			if !ok {
+				return filter, false
+			}
+			filter.from = filter.to.Add(-duration)
+		}
+	}
+	if !filter.from.Before(filter.to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return filter, false
+	}
+	if oldest := time.Now().Add(-ws.storage.HistoryDuration()); filter.from.Before(oldest) {
+		filter.from = oldest
+	}
+	return filter, true
+}
+
+// csvRow formats the cells of a row for CSV
+func csvRow(row []interface{}) []string {
+	record := make([]string, len(row))
+	for i, cell := range row {
+		switch v := cell.(type) {
+		case time.Time:
+			record[i] = v.UTC().Format(time.RFC3339)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"mariadb-encryption-monitor/internal/federation"
+)
+
+// FederatedMonitor represents one monitor instance in the global view
+type FederatedMonitor struct {
+	Name      string       `json:"name"`
+	URL       string       `json:"url,omitempty"`
+	Local     bool         `json:"local"`
+	Reachable bool         `json:"reachable"`
+	Error     string       `json:"error,omitempty"`
+	LastPoll  time.Time    `json:"last_poll"`
+	Pairs     []PairRollup `json:"pairs"`
+}
+
+// handleFederation returns the pair rollups of this monitor and all peers
+func (ws *WebServer) handleFederation(w http.ResponseWriter, r *http.Request) {
+	monitors := []FederatedMonitor{{
+		Name:      ws.config.Federation.LocalName,
+		Local:     true,
+		Reachable: true,
+		LastPoll:  time.Now(),
+		Pairs:     ws.pairRollups(),
+	}}
+
+	if ws.federation != nil {
+		for _, peer := range ws.federation.Peers() {
+			monitors = append(monitors, FederatedMonitor{
+				Name:      peer.Name,
+				URL:       peer.URL,
+				Reachable: peer.Reachable,
+				Error:     peer.Error,
+				LastPoll:  peer.LastPoll,
+				Pairs:     fromPeerRollups(peer.Pairs),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitors)
+}
+
+// handleFederationPage serves the global dashboard
+func (ws *WebServer) handleFederationPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(federationHTML))
+}
+
+// fromPeerRollups converts rollups fetched from a peer
+func fromPeerRollups(peerRollups []federation.PairRollup) []PairRollup {
+	rollups := make([]PairRollup, 0, len(peerRollups))
+	for _, p := range peerRollups {
+		rollups = append(rollups, PairRollup(p))
+	}
+	return rollups
+}
+
+const federationHTML = `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Global Migration View</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f5f7fa; color: #333; padding: 20px; }
+        .container { max-width: 1400px; margin: 0 auto; }
+        h1 { color: #2c3e50; margin-bottom: 20px; }
+        .card { background: white; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); margin-bottom: 20px; }
+        .card h2 { font-size: 18px; color: #2c3e50; margin-bottom: 15px; border-bottom: 2px solid #3498db; padding-bottom: 10px; }
+        table { width: 100%; border-collapse: collapse; }
+        th, td { padding: 10px; text-align: left; border-bottom: 1px solid #ecf0f1; }
+        th { background: #f8f9fa; }
+        .badge { display: inline-block; padding: 4px 8px; border-radius: 4px; font-size: 12px; font-weight: 600; }
+        .ok { background: #d4edda; color: #155724; }
+        .warning { background: #fff3cd; color: #856404; }
+        .critical, .disconnected, .unreachable { background: #f8d7da; color: #721c24; }
+        .no-data { color: #95a5a6; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>🌐 Global Migration View</h1>
+        <div id="monitors"><div class="no-data">Loading...</div></div>
+    </div>
+    <script>
+        // Peers are other monitors; their strings are escaped and their
+        // status only used as a CSS class when known
+        const statuses = ['ok', 'warning', 'critical', 'disconnected'];
+
+        function escapeHTML(text) {
+            return String(text).replace(/[&<>"']/g, c => '&#' + c.charCodeAt(0) + ';');
+        }
+
+        function render(monitors) {
+            let html = '';
+            monitors.forEach(m => {
+                html += '<div class="card"><h2>' + escapeHTML(m.name) + (m.local ? ' (this monitor)' : '') + ' ';
+                html += m.reachable ? '' : '<span class="badge unreachable">unreachable</span>';
+                html += '</h2>';
+                if (!m.reachable) {
+                    html += '<div class="no-data">' + escapeHTML(m.error || 'No data') + '</div></div>';
+                    return;
+                }
+                if (!m.pairs || m.pairs.length === 0) {
+                    html += '<div class="no-data">No database pairs</div></div>';
+                    return;
+                }
+                html += '<table><tr><th>Pair</th><th>Status</th><th>Health</th><th>Lag</th><th>Checksums</th><th>Consistency</th><th>Alerts</th></tr>';
+                m.pairs.forEach(p => {
+                    html += '<tr><td>' + (m.local ? '<a href="/pairs/' + encodeURIComponent(p.name) + '">' + escapeHTML(p.name) + '</a>' : escapeHTML(p.name)) + '</td>';
+                    const status = statuses.includes(p.status) ? p.status : '';
+                    html += '<td><span class="badge ' + status + '">' + escapeHTML(p.status) + '</span>' + (p.maintenance ? ' <span title="' + escapeHTML(p.maintenance) + '">🛠 maintenance</span>' : '') + '</td>';
+                    html += '<td>' + (p.health_score === null || p.health_score === undefined ? '-' : Math.round(p.health_score)) + '</td>';
+                    html += '<td>' + p.lag_seconds.toFixed(2) + 's (' + escapeHTML(p.lag_status || '-') + ')</td>';
+                    html += '<td>' + p.checksum_passed + '/' + p.checksum_total + '</td>';
+                    html += '<td>' + p.consistency_passed + '/' + p.consistency_total + '</td>';
+                    html += '<td>' + p.active_alerts + ' (' + p.critical_alerts + ' critical' + (p.suppressed_alerts ? ', ' + p.suppressed_alerts + ' suppressed' : '') + ')</td></tr>';
+                });
+                html += '</table></div>';
+            });
+            document.getElementById('monitors').innerHTML = html;
+        }
+
+        function refresh() {
+            fetch('/api/v1/federation')
+                .then(response => response.json())
+                .then(render)
+                .catch(error => console.error('Error fetching federation view:', error));
+        }
+
+        refresh();
+        setInterval(refresh, 15000);
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,159 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// grafanaTarget is a Prometheus query of a dashboard panel and its legend,
+// e.g. "{{pair}}"
+type grafanaTarget struct {
+	expr   string
+	legend string
+}
+
+// handleGrafanaDashboard returns a Grafana dashboard over this monitor's
+// /metrics, ready to import. Without ?datasource=<uid> the dashboard asks
+// for a Prometheus datasource; alert annotations pushed by Grafana notifiers
+// are shown on every panel.
+func (ws *WebServer) handleGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	datasource := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	var variables []map[string]any
+	if uid := r.URL.Query().Get("datasource"); uid != "" {
+		datasource["uid"] = uid
+	} else {
+		variables = append(variables, map[string]any{
+			"name":    "datasource",
+			"label":   "Datasource",
+			"type":    "datasource",
+			"query":   "prometheus",
+			"current": map[string]any{},
+		})
+	}
+
+	configured := ws.config.Pairs()
+	pairs := make([]string, 0, len(configured))
+	for _, pair := range configured {
+		pairs = append(pairs, pair.Name)
+	}
+	variables = append(variables, map[string]any{
+		"name":       "pair",
+		"label":      "Pair",
+		"type":       "custom",
+		"query":      strings.Join(pairs, ","),
+		"multi":      true,
+		"includeAll": true,
+		"current":    map[string]any{"text": "All", "value": "$__all"},
+	})
+
+	lagSteps := []map[string]any{{"color": "green", "value": nil}}
+	if warning := ws.config.Thresholds.ReplicaLag.WarningAt; warning > 0 {
+		lagSteps = append(lagSteps, map[string]any{"color": "orange", "value": warning.Seconds()})
+	}
+	if critical := ws.config.Thresholds.ReplicaLag.CriticalAt; critical > 0 {
+		lagSteps = append(lagSteps, map[string]any{"color": "red", "value": critical.Seconds()})
+	}
+
+	m := func(name string) string {
+		return prometheusPrefix + name + `{pair=~"$pair"}`
+	}
+	panels := []map[string]any{
+		grafanaPanel("Replica lag", "s", lagSteps,
+			grafanaTarget{m("replica_lag_seconds"), "{{pair}}"}),
+		grafanaPanel("Health score", "none", nil,
+			grafanaTarget{m("health_score"), "{{pair}}"}),
+		grafanaPanel("Mismatched tables", "none", nil,
+			grafanaTarget{m("tables_checked") + " - " + m("tables_passed"), "{{pair}} {{check}}"}),
+		grafanaPanel("Active alerts", "none", nil,
+			grafanaTarget{m("active_alerts"), "{{pair}}"},
+			grafanaTarget{m("critical_alerts"), "{{pair}} critical"}),
+		grafanaPanel("Database connections", "none", nil,
+			grafanaTarget{m("database_connected"), "{{pair}} {{database}}"}),
+		grafanaPanel("Cycle duration", "s", nil,
+			grafanaTarget{m("cycle_last_duration_seconds"), "{{pair}}"},
+			grafanaTarget{m("check_interval_seconds"), "{{pair}} interval"}),
+		grafanaPanel("Check errors", "ops", nil,
+			grafanaTarget{"sum by (pair, check) (rate(" + m("check_errors_total") + "[5m]))", "{{pair}} {{check}}"}),
+		grafanaPanel("Queues", "none", nil,
+			grafanaTarget{prometheusPrefix + "queue_length", "{{queue}}"}),
+	}
+	for i, panel := range panels {
+		panel["id"] = i + 1
+		panel["datasource"] = datasource
+		panel["gridPos"] = map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8}
+		for _, target := range panel["targets"].([]map[string]any) {
+			target["datasource"] = datasource
+		}
+	}
+
+	dashboard := map[string]any{
+		"uid":           "mariadb-encryption-monitor",
+		"title":         "MariaDB Encryption Migration",
+		"tags":          []string{config.GrafanaAnnotationTag},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating":    map[string]any{"list": variables},
+		"annotations": map[string]any{"list": []map[string]any{
+			{
+				"name":       "Annotations & Alerts",
+				"builtIn":    1,
+				"type":       "dashboard",
+				"datasource": map[string]string{"type": "grafana", "uid": "-- Grafana --"},
+				"enable":     true,
+				"hide":       true,
+				"iconColor":  "rgba(0, 211, 255, 1)",
+			},
+			{
+				"name":       "Monitor alerts",
+				"datasource": map[string]string{"type": "grafana", "uid": "-- Grafana --"},
+				"enable":     true,
+				"iconColor":  "red",
+				"target": map[string]any{
+					"type":     "tags",
+					"tags":     []string{config.GrafanaAnnotationTag},
+					"matchAny": false,
+					"limit":    100,
+				},
+			},
+		}},
+		"panels": panels,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(dashboard)
+}
+
+// grafanaPanel builds a time series panel; steps, if any, are drawn as
+// threshold lines
+func grafanaPanel(title, unit string, steps []map[string]any, targets ...grafanaTarget) map[string]any {
+	defaults := map[string]any{"unit": unit}
+	if len(steps) > 1 {
+		defaults["thresholds"] = map[string]any{"mode": "absolute", "steps": steps}
+		defaults["custom"] = map[string]any{"thresholdsStyle": map[string]string{"mode": "line"}}
+	}
+
+	queries := make([]map[string]any, len(targets))
+	for i, target := range targets {
+		queries[i] = map[string]any{
+			"refId":        fmt.Sprintf("%c", 'A'+i),
+			"expr":         target.expr,
+			"legendFormat": target.legend,
+		}
+	}
+
+	return map[string]any{
+		"type":        "timeseries",
+		"title":       title,
+		"targets":     queries,
+		"fieldConfig": map[string]any{"defaults": defaults, "overrides": []any{}},
+	}
+}
@@ -0,0 +1,371 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// LagPoint represents one replica lag sample in a history response, or one
+// bucket of rolled-up samples with their average as lag_seconds
+type LagPoint struct {
+	Timestamp  time.Time `json:"timestamp"`
+	LagSeconds float64   `json:"lag_seconds"`
+	Status     string    `json:"status"`
+
+	// Binary log bytes written per second by the source, when measured
+	SourceWriteBytes *float64 `json:"source_write_bytes_per_second,omitempty"`
+
+	MinLagSeconds *float64 `json:"min_lag_seconds,omitempty"`
+	MaxLagSeconds *float64 `json:"max_lag_seconds,omitempty"`
+	Samples       int      `json:"samples,omitempty"`
+}
+
+// HealthScorePoint represents one health score sample in a history response
+type HealthScorePoint struct {
+	Timestamp  time.Time          `json:"timestamp"`
+	Score      float64            `json:"score"`
+	Components map[string]float64 `json:"components"`
+}
+
+// PassRatePoint represents the check pass rate within one time bucket
+type PassRatePoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Passed    int       `json:"passed"`
+	Total     int       `json:"total"`
+	PassRate  float64   `json:"pass_rate"`
+}
+
+// ReplicationEventPoint is a replication thread state change
+type ReplicationEventPoint struct {
+	Pair      string    `json:"pair"`
+	Timestamp time.Time `json:"timestamp"`
+	Thread    string    `json:"thread"` // "io" or "sql"
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+}
+
+// ReplicationOutage is a period during which a replication thread was not running
+type ReplicationOutage struct {
+	Pair            string     `json:"pair"`
+	Thread          string     `json:"thread"`
+	State           string     `json:"state"` // state the thread stopped in, e.g. "No" or "Connecting"
+	StoppedAt       time.Time  `json:"stopped_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"` // nil while the thread is still stopped
+	DurationSeconds float64    `json:"duration_seconds"`     // up to now while still stopped
+}
+
+// ReplicationTimeline is returned by the replication events endpoint
+type ReplicationTimeline struct {
+	Pair     string                  `json:"pair,omitempty"`
+	Duration string                  `json:"duration"`
+	Events   []ReplicationEventPoint `json:"events"`
+	Outages  []ReplicationOutage     `json:"outages"`
+}
+
+// HistoryResponse is returned by the history endpoints, with points of the
+// endpoint's series
+type HistoryResponse[P any] struct {
+	Pair       string `json:"pair,omitempty"`
+	Duration   string `json:"duration"`
+	Resolution string `json:"resolution,omitempty"` // bucket width of rolled-up points
+	Points     []P    `json:"points"`
+}
+
+// handleReplicaLagHistory returns replica lag samples for a pair over a
+// duration. Beyond the raw retention, or with a step, it returns buckets of
+// the lag rollups, merged into buckets of step when that is wider.
+func (ws *WebServer) handleReplicaLagHistory(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.LagHistoryRetention())
+	if !ok {
+		return
+	}
+
+	var step time.Duration
+	if v := r.URL.Query().Get("step"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid step", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+
+	if step == 0 && duration <= ws.storage.LagRawRetention() {
+		writeHistory(w, pair, duration, ws.lagPoints(pair, duration))
+		return
+	}
+
+	points, resolution := ws.lagRollupPoints(pair, duration, step)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HistoryResponse[LagPoint]{
+		Pair:       pair,
+		Duration:   duration.String(),
+		Resolution: resolution.String(),
+		Points:     points,
+	})
+}
+
+// lagRollupPoints returns the rolled-up replica lag of a pair, or all pairs,
+// over a duration, in buckets of step or of the rollup tier when that is
+// wider, and the bucket width
+func (ws *WebServer) lagRollupPoints(pair string, duration, step time.Duration) ([]LagPoint, time.Duration) {
+	var merged []storage.LagRollup
+	resolution := step
+	for _, bucket := range ws.storage.GetLagRollups(duration) {
+		if pair != "" && bucket.DatabasePair != pair {
+			continue
+		}
+		resolution = max(resolution, bucket.Interval)
+		start := bucket.Timestamp.Truncate(resolution)
+		if n := len(merged); n > 0 && merged[n-1].Timestamp.Equal(start) {
+			merged[n-1].Merge(bucket)
+			continue
+		}
+		bucket.Timestamp = start
+		merged = append(merged, bucket)
+	}
+
+	points := make([]LagPoint, 0, len(merged))
+	for _, bucket := range merged {
+		points = append(points, LagPoint{
+			Timestamp:     bucket.Timestamp,
+			LagSeconds:    bucket.AvgLagSeconds,
+			Status:        "ok",
+			MinLagSeconds: &bucket.MinLagSeconds,
+			MaxLagSeconds: &bucket.MaxLagSeconds,
+			Samples:       bucket.Samples,
+		})
+	}
+	return points, resolution
+}
+
+// lagPoints returns replica lag samples for a pair, or all pairs, over a duration
+func (ws *WebServer) lagPoints(pair string, duration time.Duration) []LagPoint {
+	points := make([]LagPoint, 0)
+	for _, m := range ws.storage.GetReplicaLagHistory(duration) {
+		if pair != "" && m.DatabasePair != pair {
+			continue
+		}
+		point := LagPoint{
+			Timestamp:  m.Timestamp,
+			LagSeconds: m.LagSeconds,
+			Status:     m.Status,
+		}
+		if m.SourceWrites != nil {
+			point.SourceWriteBytes = m.SourceWrites.BytesPerSecond
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// handleHealthScoreHistory returns health score samples for a pair over a duration
+func (ws *WebServer) handleHealthScoreHistory(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.HistoryDuration())
+	if !ok {
+		return
+	}
+
+	writeHistory(w, pair, duration, ws.healthScorePoints(pair, duration))
+}
+
+// healthScorePoints returns health score samples for a pair, or all pairs, over a duration
+func (ws *WebServer) healthScorePoints(pair string, duration time.Duration) []HealthScorePoint {
+	points := make([]HealthScorePoint, 0)
+	for _, h := range ws.storage.GetHealthScoreHistory(duration) {
+		if pair != "" && h.DatabasePair != pair {
+			continue
+		}
+		points = append(points, HealthScorePoint{
+			Timestamp:  h.Timestamp,
+			Score:      h.Score,
+			Components: h.Components,
+		})
+	}
+	return points
+}
+
+// handleReplicationEvents returns replication thread stop/start events and
+// the resulting outages for a pair over a duration
+func (ws *WebServer) handleReplicationEvents(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.EventRetention())
+	if !ok {
+		return
+	}
+
+	timeline := ReplicationTimeline{
+		Pair:     pair,
+		Duration: duration.String(),
+		Events:   make([]ReplicationEventPoint, 0),
+	}
+	for _, e := range ws.storage.GetReplicationEvents(duration) {
+		if pair != "" && e.DatabasePair != pair {
+			continue
+		}
+		timeline.Events = append(timeline.Events, ReplicationEventPoint{
+			Pair:      e.DatabasePair,
+			Timestamp: e.Timestamp,
+			Thread:    e.Thread,
+			From:      e.From,
+			To:        e.To,
+		})
+	}
+	timeline.Outages = replicationOutages(timeline.Events)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// replicationOutages pairs each thread stop with the following start. A
+// start without a preceding stop in the window is ignored.
+func replicationOutages(events []ReplicationEventPoint) []ReplicationOutage {
+	outages := make([]ReplicationOutage, 0)
+	open := make(map[string]int) // key: pair:thread, value: index into outages
+
+	for _, e := range events {
+		key := e.Pair + ":" + e.Thread
+		index, stopped := open[key]
+		switch {
+		case e.To != "Yes" && !stopped:
+			open[key] = len(outages)
+			outages = append(outages, ReplicationOutage{
+				Pair:      e.Pair,
+				Thread:    e.Thread,
+				State:     e.To,
+				StoppedAt: e.Timestamp,
+			})
+		case e.To == "Yes" && stopped:
+			startedAt := e.Timestamp
+			outages[index].StartedAt = &startedAt
+			outages[index].DurationSeconds = startedAt.Sub(outages[index].StoppedAt).Seconds()
+			delete(open, key)
+		}
+	}
+
+	for _, index := range open {
+		outages[index].DurationSeconds = time.Since(outages[index].StoppedAt).Seconds()
+	}
+	return outages
+}
+
+// handleChecksumHistory returns the checksum pass rate for a pair over a duration
+func (ws *WebServer) handleChecksumHistory(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.HistoryDuration())
+	if !ok {
+		return
+	}
+
+	writeHistory(w, pair, duration, ws.checksumPoints(pair, duration))
+}
+
+// checksumPoints returns the checksum pass rate for a pair, or all pairs,
+// over a duration; skipped checksums do not count
+func (ws *WebServer) checksumPoints(pair string, duration time.Duration) []PassRatePoint {
+	buckets := newPassRateBuckets(ws.config.MonitoringInterval)
+	for _, result := range ws.storage.GetChecksumHistory(duration) {
+		if (pair != "" && result.DatabasePair != pair) || result.Skipped {
+			continue
+		}
+		buckets.add(result.Timestamp, result.Match && result.Error == nil)
+	}
+	return buckets.points()
+}
+
+// handleConsistencyHistory returns the consistency pass rate for a pair over a duration
+func (ws *WebServer) handleConsistencyHistory(w http.ResponseWriter, r *http.Request) {
+	pair, duration, ok := ws.historyParams(w, r, ws.storage.HistoryDuration())
+	if !ok {
+		return
+	}
+
+	writeHistory(w, pair, duration, ws.consistencyPoints(pair, duration))
+}
+
+// consistencyPoints returns the consistency pass rate for a pair, or all pairs, over a duration
+func (ws *WebServer) consistencyPoints(pair string, duration time.Duration) []PassRatePoint {
+	buckets := newPassRateBuckets(ws.config.MonitoringInterval)
+	for _, result := range ws.storage.GetConsistencyHistory(duration) {
+		if pair != "" && result.DatabasePair != pair {
+			continue
+		}
+		buckets.add(result.Timestamp, result.Consistent && result.Error == nil)
+	}
+	return buckets.points()
+}
+
+// historyParams parses the pair and duration query parameters, capping the duration at maxDuration
+func (ws *WebServer) historyParams(w http.ResponseWriter, r *http.Request, maxDuration time.Duration) (string, time.Duration, bool) {
+	pair := r.URL.Query().Get("pair")
+
+	duration := time.Hour
+	if v := r.URL.Query().Get("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid duration", http.StatusBadRequest)
+			return "", 0, false
+		}
+		duration = d
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+
+	return pair, duration, true
+}
+
+// writeHistory writes a history response as JSON
+func writeHistory[P any](w http.ResponseWriter, pair string, duration time.Duration, points []P) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HistoryResponse[P]{
+		Pair:     pair,
+		Duration: duration.String(),
+		Points:   points,
+	})
+}
+
+// passRateBuckets aggregates check outcomes into fixed-width time buckets
+type passRateBuckets struct {
+	width   time.Duration
+	buckets map[int64]*PassRatePoint
+}
+
+// newPassRateBuckets creates buckets of the given width
+func newPassRateBuckets(width time.Duration) *passRateBuckets {
+	return &passRateBuckets{
+		width:   width,
+		buckets: make(map[int64]*PassRatePoint),
+	}
+}
+
+// add records one check outcome
+func (b *passRateBuckets) add(ts time.Time, passed bool) {
+	start := ts.Truncate(b.width)
+	key := start.UnixNano()
+
+	point, exists := b.buckets[key]
+	if !exists {
+		point = &PassRatePoint{Timestamp: start}
+		b.buckets[key] = point
+	}
+	point.Total++
+	if passed {
+		point.Passed++
+	}
+	point.PassRate = float64(point.Passed) / float64(point.Total) * 100
+}
+
+// points returns the buckets in chronological order
+func (b *passRateBuckets) points() []PassRatePoint {
+	points := make([]PassRatePoint, 0, len(b.buckets))
+	for _, point := range b.buckets {
+		points = append(points, *point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/alert"
+)
+
+// alertmanagerWebhook is the payload Prometheus Alertmanager posts to webhook receivers
+type alertmanagerWebhook struct {
+	Version string                     `json:"version"`
+	Status  string                     `json:"status"`
+	Alerts  []alertmanagerWebhookAlert `json:"alerts"`
+}
+
+// alertmanagerWebhookAlert is one alert of a webhook payload
+type alertmanagerWebhookAlert struct {
+	Status      string            `json:"status"` // "firing" or "resolved"
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// ingestResponse counts what happened to the alerts of a payload
+type ingestResponse struct {
+	Firing   int `json:"firing"`
+	Resolved int `json:"resolved"`
+	Unmapped int `json:"unmapped"` // no pair matched their labels
+	Ignored  int `json:"ignored"`  // raised by this monitor
+}
+
+// handleIngestAlertmanager converts the alerts of an Alertmanager webhook
+// payload into alerts of the pairs their labels map to
+func (ws *WebServer) handleIngestAlertmanager(w http.ResponseWriter, r *http.Request) {
+	var payload alertmanagerWebhook
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var resp ingestResponse
+	for _, a := range payload.Alerts {
+		// Alerts this monitor pushed to Alertmanager carry their alert ID
+		if _, ours := a.Annotations["alert_id"]; ours {
+			resp.Ignored++
+			continue
+		}
+		pair, ok := ws.config.IngestedAlertPair(a.Labels)
+		if !ok {
+			resp.Unmapped++
+			continue
+		}
+
+		key := pair + "_" + alertFingerprint(a)
+		if a.Status == "resolved" {
+			ws.alertMgr.ResolveExternal(key)
+			resp.Resolved++
+			continue
+		}
+		ws.alertMgr.RaiseExternal(key, alert.Alert{
+			ID:           fmt.Sprintf("external_%s_%d", key, time.Now().Unix()),
+			Timestamp:    time.Now(),
+			Severity:     ingestedSeverity(a.Labels["severity"]),
+			Type:         "external",
+			DatabasePair: pair,
+			Message:      fmt.Sprintf("[%s] %s", pair, ingestedMessage(a)),
+			Source:       alert.SourceAlertmanager,
+			Labels:       a.Labels,
+		})
+		resp.Firing++
+	}
+	if resp.Unmapped > 0 {
+		log.Printf("Ingested Alertmanager payload with %d alerts not mapped to a pair", resp.Unmapped)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// alertFingerprint identifies an ingested alert by its Alertmanager
+// fingerprint, or by a hash of its labels when the payload has none
+func alertFingerprint(a alertmanagerWebhookAlert) string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	names := make([]string, 0, len(a.Labels))
+	for name := range a.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := fnv.New64a()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\x00", name, a.Labels[name])
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// ingestedSeverity maps a Prometheus severity label to an alert severity
+func ingestedSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "page":
+		return "CRITICAL"
+	case "warning", "warn":
+		return "WARNING"
+	}
+	return "INFO"
+}
+
+// ingestedMessage describes an ingested alert by its name and summary
+func ingestedMessage(a alertmanagerWebhookAlert) string {
+	name := a.Labels["alertname"]
+	for _, annotation := range []string{"summary", "description", "message"} {
+		if text := a.Annotations[annotation]; text != "" {
+			return fmt.Sprintf("%s: %s", name, text)
+		}
+	}
+	return name
+}
@@ -0,0 +1,40 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// metricsSnapshot is an encoded /api/metrics response of one metrics version
+type metricsSnapshot struct {
+	version  uint64
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+// currentMetricsSnapshot returns the encoded current metrics, encoding them
+// again only when the storage changed since the last request
+func (ws *WebServer) currentMetricsSnapshot() (*metricsSnapshot, error) {
+	ws.snapshotMu.Lock()
+	defer ws.snapshotMu.Unlock()
+
+	version, _ := ws.storage.Version()
+	if ws.metricsSnapshot != nil && ws.metricsSnapshot.version == version {
+		return ws.metricsSnapshot, nil
+	}
+
+	body, version, modified, err := ws.storage.CurrentMetricsJSON()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	ws.metricsSnapshot = &metricsSnapshot{
+		version:  version,
+		body:     body,
+		etag:     `"` + hex.EncodeToString(sum[:8]) + `"`,
+		modified: modified,
+	}
+	return ws.metricsSnapshot, nil
+}
@@ -0,0 +1,238 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// oidcProvider implements the OIDC Authorization Code flow with PKCE
+type oidcProvider struct {
+	config     config.OIDCConfig
+	oauth2     oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	sessions   *sessionCodec
+	sessionTTL time.Duration
+	secure     bool // set the Secure flag on cookies
+}
+
+// newOIDCProvider discovers the issuer and prepares the OAuth2 client
+func newOIDCProvider(ctx context.Context, cfg config.AuthConfig, sessions *sessionCodec) (*oidcProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.OIDC.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover issuer: %w", err)
+	}
+
+	return &oidcProvider{
+		config: cfg.OIDC,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       cfg.OIDC.Scopes,
+		},
+		verifier:   provider.Verifier(&oidc.Config{ClientID: cfg.OIDC.ClientID}),
+		sessions:   sessions,
+		sessionTTL: cfg.SessionTTL,
+		secure:     strings.HasPrefix(cfg.OIDC.RedirectURL, "https://"),
+	}, nil
+}
+
+// ServeHTTP handles /auth/login, /auth/callback and /auth/logout
+func (op *oidcProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/auth/login":
+		op.handleLogin(w, r)
+	case "/auth/callback":
+		op.handleCallback(w, r)
+	case "/auth/logout":
+		// A link or image on another page must not log the user out
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		op.setCookie(w, sessionCookie, "", 0)
+		http.Redirect(w, r, "/", http.StatusFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleLogin redirects the browser to the identity provider
+func (op *oidcProvider) handleLogin(w http.ResponseWriter, r *http.Request) {
+	returnTo := r.URL.Query().Get("return")
+	if !isLocalPath(returnTo) {
+		returnTo = "/" // only same-site redirects after login
+	}
+
+	state := sessionData{
+		Kind:     "state",
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: oauth2.GenerateVerifier(),
+		Return:   returnTo,
+		Expires:  time.Now().Add(10 * time.Minute),
+	}
+	value, err := op.sessions.encode(state)
+	if err != nil {
+		http.Error(w, "failed to start login", http.StatusInternalServerError)
+		return
+	}
+	op.setCookie(w, stateCookie, value, 10*time.Minute)
+
+	url := op.oauth2.AuthCodeURL(state.State, oidc.Nonce(state.Nonce), oauth2.S256ChallengeOption(state.Verifier))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// isLocalPath reports whether a redirect target stays on this site: a path
+// without scheme or host. Browsers read a backslash as a slash, so "/\host"
+// would lead elsewhere.
+func isLocalPath(target string) bool {
+	if strings.Contains(target, "\\") {
+		return false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" {
+		return false
+	}
+	return strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(u.Path, "//") && !strings.Contains(u.Path, "\\")
+}
+
+// handleCallback exchanges the authorization code and starts a session
+func (op *oidcProvider) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		http.Error(w, "login state missing; start again at /auth/login", http.StatusBadRequest)
+		return
+	}
+	op.setCookie(w, stateCookie, "", 0)
+
+	var state sessionData
+	if err := op.sessions.decode(cookie.Value, &state); err != nil || state.Kind != "state" || time.Now().After(state.Expires) {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("state") != state.State {
+		http.Error(w, "login state mismatch", http.StatusBadRequest)
+		return
+	}
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		http.Error(w, "login failed: "+errCode, http.StatusUnauthorized)
+		return
+	}
+
+	token, err := op.oauth2.Exchange(r.Context(), r.URL.Query().Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v", err)
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "login failed: no id_token in response", http.StatusUnauthorized)
+		return
+	}
+	idToken, err := op.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil || idToken.Nonce != state.Nonce {
+		log.Printf("OIDC id_token verification failed: %v", err)
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+	subject := idToken.Subject
+	if email, ok := claims["email"].(string); ok && email != "" {
+		subject = email
+	}
+
+	role := op.roleFor(claims)
+	if role == "" {
+		log.Printf("OIDC login denied for %s: no group mapped to a role", subject)
+		http.Error(w, "forbidden: your groups are not allowed to access this monitor", http.StatusForbidden)
+		return
+	}
+
+	value, err := op.sessions.encode(sessionData{
+		Kind:    "session",
+		Subject: subject,
+		Role:    role,
+		Expires: time.Now().Add(op.sessionTTL),
+	})
+	if err != nil {
+		http.Error(w, "failed to start session", http.StatusInternalServerError)
+		return
+	}
+	op.setCookie(w, sessionCookie, value, op.sessionTTL)
+
+	log.Printf("OIDC login: %s as %s", subject, role)
+	http.Redirect(w, r, state.Return, http.StatusFound)
+}
+
+// roleFor maps the groups claim to the highest configured role
+func (op *oidcProvider) roleFor(claims map[string]interface{}) string {
+	role := op.config.DefaultRole
+
+	var groups []string
+	switch value := claims[op.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range value {
+			if name, ok := group.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+	case string:
+		groups = []string{value}
+	}
+
+	for _, group := range groups {
+		switch op.config.GroupRoles[group] {
+		case config.RoleAdmin:
+			return config.RoleAdmin
+		case config.RoleViewer:
+			if role == "" {
+				role = config.RoleViewer
+			}
+		}
+	}
+	return role
+}
+
+// setCookie sets an HttpOnly cookie, or clears it when maxAge is zero
+func (op *oidcProvider) setCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	seconds := int(maxAge.Seconds())
+	if seconds <= 0 {
+		seconds = -1 // delete now
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   seconds,
+		HttpOnly: true,
+		Secure:   op.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// randomString returns a random hex string for state and nonce values
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
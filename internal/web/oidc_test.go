@@ -0,0 +1,29 @@
+package web
+
+import "testing"
+
+func TestIsLocalPath(t *testing.T) {
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"/", true},
+		{"/pairs/primary", true},
+		{"/pairs/primary?range=24h#lag", true},
+		{"", false},
+		{"pairs", false},
+		{"//evil.com", false},
+		{"/\\evil.com", false},
+		{"\\\\evil.com", false},
+		{"/%5Cevil.com", false},
+		{"/%2F/evil.com", false},
+		{"https://evil.com/", false},
+		{"javascript:alert(1)", false},
+		{"/\t/evil.com", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalPath(tt.target); got != tt.want {
+			t.Errorf("isLocalPath(%q) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
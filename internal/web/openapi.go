@@ -0,0 +1,270 @@
+package web
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// handleOpenAPI returns the OpenAPI 3 document of the versioned API. It is
+// generated from the registered routes and the Go types of their bodies, so
+// it cannot drift from what the endpoints serve.
+func (ws *WebServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(ws.openAPIDocument())
+}
+
+// openAPIDocument describes the versioned API
+func (ws *WebServer) openAPIDocument() map[string]any {
+	schemas := openAPISchemas{components: make(map[string]any)}
+
+	securitySchemes := map[string]any{
+		"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "description": "An admin_tokens or auth.api_tokens token"},
+		"apiKeyAuth": map[string]string{"type": "apiKey", "in": "header", "name": "X-API-Key", "description": "The same tokens as bearerAuth"},
+	}
+	security := []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+	switch ws.config.Auth.Mode {
+	case "basic":
+		securitySchemes["basicAuth"] = map[string]string{"type": "http", "scheme": "basic"}
+		security = append(security, map[string][]string{"basicAuth": {}})
+	case "oidc":
+		securitySchemes["sessionCookie"] = map[string]string{"type": "apiKey", "in": "cookie", "name": sessionCookie}
+		security = append(security, map[string][]string{"sessionCookie": {}})
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, route := range ws.apiRoutes() {
+		operation := map[string]any{
+			"summary":     route.summary,
+			"operationId": operationID(route),
+			"responses":   schemas.responses(route),
+		}
+		switch {
+		case route.public:
+			operation["security"] = []map[string][]string{}
+		case route.admin:
+			operation["description"] = "Requires the admin role."
+			operation["security"] = security
+		case ws.config.Auth.Mode != "none":
+			operation["security"] = security
+		}
+
+		var parameters []map[string]any
+		for _, name := range pathParamPattern.FindAllStringSubmatch(route.path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name": name[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+			})
+		}
+		for _, param := range route.query {
+			parameters = append(parameters, map[string]any{
+				"name": param.name, "in": "query", "description": param.description, "schema": map[string]string{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": !route.requestOptional,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemas.schema(route.request)}},
+			}
+		}
+
+		if paths[route.path] == nil {
+			paths[route.path] = make(map[string]any)
+		}
+		paths[route.path][strings.ToLower(route.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":       "MariaDB Encryption Migration Monitor API",
+			"version":     strings.TrimPrefix(apiVersionPrefix, "/api/"),
+			"description": "Errors are returned as plain text with a 4xx or 5xx status.",
+		},
+		"servers": []map[string]string{{"url": apiVersionPrefix}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":         schemas.components,
+			"securitySchemes": securitySchemes,
+		},
+	}
+}
+
+// pathParamPattern matches the wildcards of a route path, e.g. {name}
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// operationID names an operation after its method and path, e.g.
+// post_pairs_name_pause
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.method)
+	for _, segment := range strings.Split(route.path, "/") {
+		segment = strings.Trim(segment, "{}")
+		segment = strings.NewReplacer(".", "_", "-", "_").Replace(segment)
+		if segment != "" {
+			id += "_" + segment
+		}
+	}
+	return id
+}
+
+// responses describes the responses of a route
+func (s *openAPISchemas) responses(route apiRoute) map[string]any {
+	success := map[string]any{"description": "OK"}
+	status := "200"
+	switch {
+	case route.response != nil:
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": s.schema(route.response)}}
+	case len(route.contentTypes) > 0:
+		content := make(map[string]any)
+		for _, contentType := range route.contentTypes {
+			content[contentType] = map[string]any{"schema": map[string]string{"type": "string", "format": "binary"}}
+		}
+		success["content"] = content
+	default:
+		status = "204"
+		success["description"] = "No Content"
+	}
+
+	return map[string]any{
+		status: success,
+		"default": map[string]any{
+			"description": "Error",
+			"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]string{"type": "string"}}},
+		},
+	}
+}
+
+// openAPISchemas builds JSON schemas of Go types as encoding/json encodes
+// them. Named struct types become components referenced by $ref.
+type openAPISchemas struct {
+	components map[string]any
+}
+
+// Types with a fixed JSON encoding
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	durationType      = reflect.TypeFor[time.Duration]()
+	errorType         = reflect.TypeFor[error]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema returns the schema of a type
+func (s *openAPISchemas) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	case errorType:
+		return map[string]any{"type": "object", "description": "Always empty; see the message fields"}
+	}
+	if t.Kind() == reflect.Pointer {
+		return s.schema(t.Elem())
+	}
+	if t.Implements(jsonMarshalerType) {
+		return map[string]any{}
+	}
+	if t.Implements(textMarshalerType) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := schemaName(t)
+		if _, exists := s.components[name]; !exists {
+			s.components[name] = map[string]any{} // placeholder for recursive types
+			s.components[name] = s.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{} // interfaces hold any value
+}
+
+// object returns the schema of a struct's JSON object. Fields without
+// omitempty are always present, so they are required.
+func (s *openAPISchemas) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	s.fields(t, properties, &required)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// fields adds the JSON fields of a struct, including those of embedded
+// structs, to properties
+func (s *openAPISchemas) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+		if !strings.Contains(","+options+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName names the component of a named type after its package, e.g.
+// storage.ChecksumResult. Type arguments of generic types are reduced to
+// their names, e.g. web.HistoryResponse_LagPoint.
+func schemaName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if generic {
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += "_" + arg[strings.LastIndex(arg, ".")+1:]
+		}
+	}
+	return path.Base(t.PkgPath()) + "." + name
+}
@@ -0,0 +1,341 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// PairRollup summarizes the current state of one database pair
+type PairRollup struct {
+	Name              string    `json:"name"`
+	Status            string    `json:"status"`    // ok, warning, critical or disconnected
+	Lifecycle         string    `json:"lifecycle"` // monitoring, paused, warmup, ready, cut_over, standby or complete
+	Mode              string    `json:"mode"`      // replication or dual_write
+	SourceConnected   bool      `json:"source_connected"`
+	TargetConnected   bool      `json:"target_connected"`
+	LagSeconds        float64   `json:"lag_seconds"`
+	LagStatus         string    `json:"lag_status"`
+	SourceWriteBytes  *float64  `json:"source_write_bytes_per_second,omitempty"` // binary log written by the source
+	HealthScore       *float64  `json:"health_score"`                            // 0-100; null until the pair has results
+	ChecksumPassed    int       `json:"checksum_passed"`
+	ChecksumTotal     int       `json:"checksum_total"`
+	ConsistencyPassed int       `json:"consistency_passed"`
+	ConsistencyTotal  int       `json:"consistency_total"`
+	ActiveAlerts      int       `json:"active_alerts"`
+	CriticalAlerts    int       `json:"critical_alerts"`
+	SuppressedAlerts  int       `json:"suppressed_alerts"`     // raised during a maintenance window
+	Maintenance       string    `json:"maintenance,omitempty"` // active maintenance window
+	LastChecked       time.Time `json:"last_checked"`
+
+	PausedChecks []monitor.PausedCheck `json:"paused_checks,omitempty"` // checks skipped until resumed
+
+	Metadata config.PairMetadata `json:"metadata"` // owner, runbook and ticket
+}
+
+// handlePairs returns a rollup of every configured database pair
+func (ws *WebServer) handlePairs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.pairRollups())
+}
+
+// pairRollups computes the rollup for every configured database pair
+func (ws *WebServer) pairRollups() []PairRollup {
+	metrics := ws.storage.GetCurrentMetrics()
+	activeAlerts := ws.alertMgr.GetActiveAlerts()
+	states := ws.engine.PairStates()
+	pausedChecks := ws.engine.PausedChecks()
+
+	pairs := ws.config.Pairs()
+	rollups := make([]PairRollup, 0, len(pairs))
+	for _, pair := range pairs {
+		rollup := PairRollup{Name: pair.Name, Lifecycle: states[pair.Name], Mode: pair.Mode, PausedChecks: pausedChecks[pair.Name], Metadata: pair.Metadata}
+
+		if status, ok := metrics.ConnectionStatus[pair.Name]; ok {
+			rollup.SourceConnected = status.SourceConnected
+			rollup.TargetConnected = status.TargetConnected
+			rollup.LastChecked = status.LastChecked
+		}
+		if health, ok := metrics.HealthScore[pair.Name]; ok {
+			score := health.Score
+			rollup.HealthScore = &score
+		}
+		if lag, ok := metrics.ReplicaLag[pair.Name]; ok {
+			rollup.LagSeconds = lag.LagSeconds
+			rollup.LagStatus = lag.Status
+			if lag.SourceWrites != nil {
+				rollup.SourceWriteBytes = lag.SourceWrites.BytesPerSecond
+			}
+		}
+		for _, result := range metrics.ChecksumResults {
+			if result.DatabasePair != pair.Name || result.Skipped {
+				continue
+			}
+			rollup.ChecksumTotal++
+			if result.Match && result.Error == nil {
+				rollup.ChecksumPassed++
+			}
+		}
+		for _, result := range metrics.ConsistencyResults {
+			if result.DatabasePair != pair.Name {
+				continue
+			}
+			rollup.ConsistencyTotal++
+			if result.Consistent && result.Error == nil {
+				rollup.ConsistencyPassed++
+			}
+		}
+
+		rollup.Maintenance, _ = ws.config.ActiveMaintenanceWindow(pair.Name, time.Now())
+
+		hasWarning := false
+		for _, alert := range activeAlerts {
+			if alert.DatabasePair != pair.Name {
+				continue
+			}
+			if alert.Suppressed {
+				rollup.SuppressedAlerts++
+				continue
+			}
+			rollup.ActiveAlerts++
+			if alert.Severity == "CRITICAL" {
+				rollup.CriticalAlerts++
+			} else if alert.Severity == "WARNING" {
+				hasWarning = true
+			}
+		}
+
+		switch {
+		case !rollup.SourceConnected || !rollup.TargetConnected:
+			rollup.Status = "disconnected"
+		case rollup.CriticalAlerts > 0:
+			rollup.Status = "critical"
+		case hasWarning:
+			rollup.Status = "warning"
+		default:
+			rollup.Status = "ok"
+		}
+
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups
+}
+
+// maxPairEntrySize bounds the body of a request adding a pair
+const maxPairEntrySize = 1 << 20
+
+// handleAddPair adds a database pair given as its database_pairs entry, in
+// YAML or JSON, and starts monitoring it
+func (ws *WebServer) handleAddPair(w http.ResponseWriter, r *http.Request) {
+	entry, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPairEntrySize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	reason := r.URL.Query().Get("reason")
+
+	subject := identityFrom(r).Subject
+	lifecycleReason := "added by " + subject
+	if reason != "" {
+		lifecycleReason += ": " + reason
+	}
+	pairName, err := ws.engine.AddPair(entry, lifecycleReason)
+	if errors.Is(err, monitor.ErrEngineStopping) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[%s] Pair added via API by %s (%s)", pairName, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "pair.add", DatabasePair: pairName, Reason: reason})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	ws.writePairRollup(w, pairName)
+}
+
+// handleRemovePair stops monitoring a database pair and removes it
+func (ws *WebServer) handleRemovePair(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+
+	subject := identityFrom(r).Subject
+	lifecycleReason := "removed by " + subject
+	if reason != "" {
+		lifecycleReason += ": " + reason
+	}
+	if err := ws.engine.RemovePair(pairName, lifecycleReason); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("[%s] Pair removed via API by %s (%s)", pairName, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "pair.remove", DatabasePair: pairName, Reason: reason})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePausePair stops checks for a pair until it is resumed
+func (ws *WebServer) handlePausePair(w http.ResponseWriter, r *http.Request) {
+	ws.changePairLifecycle(w, r, "paused", "pair.pause", ws.engine.PausePair)
+}
+
+// handleResumePair resumes checks for a paused pair
+func (ws *WebServer) handleResumePair(w http.ResponseWriter, r *http.Request) {
+	ws.changePairLifecycle(w, r, "resumed", "pair.resume", ws.engine.ResumePair)
+}
+
+// handleCompletePair marks a pair's migration complete, reducing its checks to a heartbeat
+func (ws *WebServer) handleCompletePair(w http.ResponseWriter, r *http.Request) {
+	ws.changePairLifecycle(w, r, "marked complete", "pair.complete", ws.engine.CompletePair)
+}
+
+// changePairLifecycle applies a lifecycle change, records it in the audit log
+// as auditAction and returns the pair's rollup
+func (ws *WebServer) changePairLifecycle(w http.ResponseWriter, r *http.Request, action, auditAction string, apply func(pairName, reason string) error) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+
+	subject := identityFrom(r).Subject
+	lifecycleReason := action + " by " + subject
+	if reason != "" {
+		lifecycleReason += ": " + reason
+	}
+	if err := apply(pairName, lifecycleReason); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("[%s] Checks %s via API by %s (%s)", pairName, action, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: auditAction, DatabasePair: pairName, Reason: reason})
+
+	ws.writePairRollup(w, pairName)
+}
+
+// pauseCheckBody is the optional body of a check pause: how long the check
+// stays paused, and why
+type pauseCheckBody struct {
+	Duration string `json:"duration"` // resumed automatically after it; paused until resumed when empty
+	Reason   string `json:"reason"`
+}
+
+// handlePauseCheck stops one check of a pair, e.g. checksums during a load peak
+func (ws *WebServer) handlePauseCheck(w http.ResponseWriter, r *http.Request) {
+	pairName, check, ok := ws.pairCheck(w, r)
+	if !ok {
+		return
+	}
+
+	var body pauseCheckBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var until time.Time
+	if body.Duration != "" {
+		duration, err := time.ParseDuration(body.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'", body.Duration), http.StatusBadRequest)
+			return
+		}
+		until = time.Now().Add(duration)
+	}
+
+	subject := identityFrom(r).Subject
+	reason := "paused by " + subject
+	if body.Reason != "" {
+		reason += ": " + body.Reason
+	}
+	if err := ws.engine.PauseCheck(pairName, check, reason, until); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("[%s] Check %s paused via API by %s (%s)", pairName, check, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	details := ""
+	if !until.IsZero() {
+		details = "until " + until.UTC().Format(time.RFC3339)
+	}
+	ws.audit(r, storage.AuditEntry{Action: "check.pause", DatabasePair: pairName, Target: check, Reason: body.Reason, Details: details})
+
+	ws.writePairRollup(w, pairName)
+}
+
+// handleResumeCheck resumes a paused check of a pair
+func (ws *WebServer) handleResumeCheck(w http.ResponseWriter, r *http.Request) {
+	pairName, check, ok := ws.pairCheck(w, r)
+	if !ok {
+		return
+	}
+
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+
+	subject := identityFrom(r).Subject
+	resumeReason := "resumed by " + subject
+	if reason != "" {
+		resumeReason += ": " + reason
+	}
+	if err := ws.engine.ResumeCheck(pairName, check, resumeReason); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("[%s] Check %s resumed via API by %s (%s)", pairName, check, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "check.resume", DatabasePair: pairName, Target: check, Reason: reason})
+
+	ws.writePairRollup(w, pairName)
+}
+
+// pairCheck returns the pair and check named in the path, writing an error
+// when either is unknown
+func (ws *WebServer) pairCheck(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	pairName, check := r.PathValue("name"), r.PathValue("check")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return "", "", false
+	}
+	if !slices.Contains(monitor.PausableChecks, check) {
+		http.Error(w, fmt.Sprintf("unknown check '%s'; must be one of: %s", check, strings.Join(monitor.PausableChecks, ", ")), http.StatusBadRequest)
+		return "", "", false
+	}
+	return pairName, check, true
+}
+
+// writePairRollup responds with the rollup of a pair
+func (ws *WebServer) writePairRollup(w http.ResponseWriter, pairName string) {
+	for _, rollup := range ws.pairRollups() {
+		if rollup.Name == pairName {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(rollup)
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// probeResponse is the body of /livez and /readyz
+type probeResponse struct {
+	Status              string     `json:"status"`
+	LastSuccessfulCycle *time.Time `json:"last_successful_cycle,omitempty"` // nil until a cycle reached both databases of a pair
+}
+
+// handleLivez reports that the process is serving requests
+func (ws *WebServer) handleLivez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(probeResponse{Status: "ok"})
+}
+
+// handleReadyz reports ready once a monitoring cycle reached both databases
+// of a pair, and not ready again while shutting down
+func (ws *WebServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	lastCycle := ws.engine.LastSuccessfulCycle()
+
+	status, code := "ready", http.StatusOK
+	switch {
+	case ws.shuttingDown.Load():
+		status, code = "shutting_down", http.StatusServiceUnavailable
+	case lastCycle.IsZero():
+		status, code = "waiting_for_first_cycle", http.StatusServiceUnavailable
+	}
+
+	response := probeResponse{Status: status}
+	if !lastCycle.IsZero() {
+		response.LastSuccessfulCycle = &lastCycle
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
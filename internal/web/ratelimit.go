@@ -0,0 +1,176 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// tokenBucket tracks the available request tokens for one client
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter applies token-bucket limits keyed by client
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter creates a rate limiter refilling perMinute tokens per minute up to burst
+func newRateLimiter(perMinute float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    perMinute / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow consumes a token for the client, returning how long to wait when none is left
+func (rl *rateLimiter) allow(client string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	bucket, exists := rl.buckets[client]
+	if !exists {
+		bucket = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[client] = bucket
+	}
+
+	// Refill for the time elapsed since the last request
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*rl.rate)
+	bucket.lastSeen = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+	return false, wait
+}
+
+// fullAfter returns how long an unused bucket takes to refill completely
+func (rl *rateLimiter) fullAfter() time.Duration {
+	return time.Duration(rl.burst / rl.rate * float64(time.Second))
+}
+
+// evictIdle drops buckets that have refilled completely and are no longer needed
+func (rl *rateLimiter) evictIdle(now time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	fullAfter := rl.fullAfter()
+	for client, bucket := range rl.buckets {
+		if now.Sub(bucket.lastSeen) > fullAfter {
+			delete(rl.buckets, client)
+		}
+	}
+}
+
+// evictLoop drops idle buckets once per refill period until stop is closed,
+// so that new clients do not pay for the scan
+func (rl *rateLimiter) evictLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(max(rl.fullAfter(), time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			rl.evictIdle(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// rateLimitMiddleware enforces API rate limits, returning 429 with Retry-After
+// when exceeded. It runs before authentication, so that failed logins are
+// limited too; only tokens and client certificates the authenticator accepts
+// get their own bucket.
+func rateLimitMiddleware(cfg config.RateLimitConfig, auth *authenticator, stop <-chan struct{}, next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+
+	general := newRateLimiter(cfg.RequestsPerMinute, cfg.Burst)
+	expensive := newRateLimiter(cfg.ExpensiveRequestsPerMinute, cfg.ExpensiveBurst)
+	go general.evictLoop(stop)
+	go expensive.evictLoop(stop)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := clientKey(r, auth, cfg.TrustProxyHeaders)
+		limiter := general
+		if isExpensiveRequest(r) {
+			limiter = expensive
+		}
+
+		if ok, wait := limiter.allow(client); !ok {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isExpensiveRequest reports whether a request triggers database work or bulk exports
+func isExpensiveRequest(r *http.Request) bool {
+	return r.Method != http.MethodGet || strings.HasPrefix(unversionedPath(r.URL.Path), "/api/export")
+}
+
+// clientKey identifies the caller by a valid API token or listed client
+// certificate, otherwise by IP address. Unchecked tokens are not trusted, as
+// a client could send a new one with each request for a full bucket.
+func clientKey(r *http.Request, auth *authenticator, trustProxy bool) string {
+	if token := requestToken(r); token != "" {
+		if id := auth.identifyToken(token); id != nil {
+			return id.Subject
+		}
+	}
+	if name := peerCommonName(r); name != "" && clientCert(auth.config.Auth.ClientCerts, name) != nil {
+		return "cert:" + name
+	}
+	return "ip:" + clientIP(r, trustProxy)
+}
+
+// tokenFingerprint identifies an API token without revealing it
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// clientIP returns the remote IP address of a request. Behind a trusted
+// proxy it is the rightmost X-Forwarded-For entry, the one the proxy
+// appended; entries left of it are sent by the client and can be forged.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if hop := strings.TrimSpace(hops[len(hops)-1]); hop != "" {
+				return hop
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
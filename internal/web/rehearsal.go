@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mariadb-encryption-monitor/internal/monitor"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// defaultFaultDuration is how long an injected fault lasts without end or duration
+const defaultFaultDuration = 15 * time.Minute
+
+// faultBody injects a rehearsal fault. The fault ends at end, or duration
+// from now.
+type faultBody struct {
+	Kind       string    `json:"kind"`
+	LagSeconds float64   `json:"lag_seconds"`
+	Table      string    `json:"table"`
+	DriftRows  int64     `json:"drift_rows"`
+	Database   string    `json:"database"`
+	End        time.Time `json:"end"`
+	Duration   string    `json:"duration"`
+	Reason     string    `json:"reason"`
+}
+
+// handleFaults returns the active rehearsal faults, optionally for one pair
+func (ws *WebServer) handleFaults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.engine.Faults(r.URL.Query().Get("pair")))
+}
+
+// handleInjectFault injects a rehearsal fault into the checks of a pair
+func (ws *WebServer) handleInjectFault(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+	if !ws.config.Rehearsal.Enabled {
+		http.Error(w, "rehearsal mode is not enabled; set rehearsal.enabled", http.StatusForbidden)
+		return
+	}
+
+	var body faultBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	end := body.End
+	switch {
+	case body.Duration != "" && !end.IsZero():
+		http.Error(w, "use either end or duration", http.StatusBadRequest)
+		return
+	case body.Duration != "":
+		duration, err := time.ParseDuration(body.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'", body.Duration), http.StatusBadRequest)
+			return
+		}
+		end = time.Now().Add(duration)
+	case end.IsZero():
+		end = time.Now().Add(defaultFaultDuration)
+	}
+
+	subject := identityFrom(r).Subject
+	fault, err := ws.engine.InjectFault(monitor.Fault{
+		Pair:       pairName,
+		Kind:       body.Kind,
+		LagSeconds: body.LagSeconds,
+		Table:      body.Table,
+		DriftRows:  body.DriftRows,
+		Database:   body.Database,
+		End:        end,
+		Reason:     body.Reason,
+		InjectedBy: subject,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[%s] Rehearsal fault %s (%s) injected via API by %s (%s)", pairName, fault.ID, fault.Kind, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "fault.inject", DatabasePair: pairName, Target: fault.ID, Reason: fault.Reason,
+		Details: fmt.Sprintf("%s until %s", fault.Kind, fault.End.UTC().Format(time.RFC3339))})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(fault)
+}
+
+// handleClearFault ends a rehearsal fault early
+func (ws *WebServer) handleClearFault(w http.ResponseWriter, r *http.Request) {
+	fault, err := ws.engine.ClearFault(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("[%s] Rehearsal fault %s cleared via API by %s (%s)", fault.Pair, fault.ID, identityFrom(r).Subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "fault.clear", DatabasePair: fault.Pair, Target: fault.ID})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"mariadb-encryption-monitor/internal/report"
+)
+
+// handleReports summarizes every pair over a day or week, e.g.
+// /api/v1/reports?period=week&date=2024-05-06
+func (ws *WebServer) handleReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	period := query.Get("period")
+	if period == "" {
+		period = report.PeriodDay
+	}
+
+	now := time.Now()
+	retention, location := ws.storage.ReportRetention()
+	at := now
+	if v := query.Get("date"); v != "" {
+		date, err := time.ParseInLocation(time.DateOnly, v, location)
+		if err != nil {
+			http.Error(w, "invalid date: expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		at = date
+	}
+
+	result, err := report.Build(ws.config, ws.storage, period, at, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if result.End.Before(now.Add(-retention)) {
+		http.Error(w, fmt.Sprintf("the %s is older than the %d days of retained reports", period, int(retention.Hours()/24)), http.StatusBadRequest)
+		return
+	}
+	if pair := query.Get("pair"); pair != "" {
+		result.Pairs = slices.DeleteFunc(result.Pairs, func(p report.PairReport) bool { return p.Name != pair })
+		if len(result.Pairs) == 0 {
+			http.Error(w, fmt.Sprintf("database pair '%s' not found", pair), http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,246 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"mariadb-encryption-monitor/internal/alert"
+	"mariadb-encryption-monitor/internal/monitor"
+)
+
+// prometheusPrefix starts the name of every metric on /metrics
+const prometheusPrefix = "mariadb_monitor_"
+
+// queueFunc returns the number of queued items and the capacity of a queue
+type queueFunc func() (length, capacity int)
+
+// queueStats is the depth of a queue
+type queueStats struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// selfResponse is the body of /api/self: the monitor's own health
+type selfResponse struct {
+	Cycles           []monitor.CycleStats  `json:"cycles"`
+	Checks           []monitor.CheckStats  `json:"checks"`
+	WebSocketClients int                   `json:"websocket_clients"`
+	WebSocketDropped int64                 `json:"websocket_dropped"` // clients dropped for not keeping up
+	Storage          map[string]int        `json:"storage"`           // entries kept per history
+	Alerts           int                   `json:"alerts"`            // alerts kept in the history, including resolved
+	Queues           map[string]queueStats `json:"queues"`
+}
+
+// AddQueue reports the depth of a queue, e.g. the notification dispatcher's,
+// on /metrics and /api/self. It must be called before Start.
+func (ws *WebServer) AddQueue(name string, queue func() (length, capacity int)) {
+	ws.queues[name] = queue
+}
+
+// self collects the monitor's own health
+func (ws *WebServer) self() selfResponse {
+	ws.mu.RLock()
+	clients := len(ws.wsClients)
+	ws.mu.RUnlock()
+
+	queues := map[string]queueStats{
+		"websocket_events": {Length: len(ws.wsEvents), Capacity: cap(ws.wsEvents)},
+	}
+	for name, queue := range ws.queues {
+		length, capacity := queue()
+		queues[name] = queueStats{Length: length, Capacity: capacity}
+	}
+
+	return selfResponse{
+		Cycles:           ws.engine.CycleStats(),
+		Checks:           ws.engine.CheckStats(),
+		WebSocketClients: clients,
+		WebSocketDropped: ws.wsDropped.Load(),
+		Storage:          ws.storage.Sizes(),
+		Alerts:           ws.alertMgr.QueryAlertHistory(alert.HistoryQuery{Limit: 1}).Total,
+		Queues:           queues,
+	}
+}
+
+// handleSelf returns the monitor's own health as JSON
+func (ws *WebServer) handleSelf(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.self())
+}
+
+// handlePrometheusMetrics returns the monitor's own health in the Prometheus
+// text exposition format
+func (ws *WebServer) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	self := ws.self()
+	var b prometheusWriter
+
+	b.family("cycles_total", "counter", "Monitoring cycles run")
+	for _, c := range self.Cycles {
+		b.sample("cycles_total", float64(c.Cycles), "pair", c.Pair)
+	}
+	b.family("cycle_overruns_total", "counter", "Monitoring cycles that took longer than the check interval")
+	for _, c := range self.Cycles {
+		b.sample("cycle_overruns_total", float64(c.Overruns), "pair", c.Pair)
+	}
+	b.family("cycles_skipped_total", "counter", "Ticks of the check interval skipped after cycle overruns")
+	for _, c := range self.Cycles {
+		b.sample("cycles_skipped_total", float64(c.Skipped), "pair", c.Pair)
+	}
+	b.family("cycles_cancelled_total", "counter", "Monitoring cycles cancelled at the check interval (cycle_overlap: cancel)")
+	for _, c := range self.Cycles {
+		b.sample("cycles_cancelled_total", float64(c.Cancelled), "pair", c.Pair)
+	}
+	b.family("cycle_duration_seconds_total", "counter", "Time spent in monitoring cycles")
+	for _, c := range self.Cycles {
+		b.sample("cycle_duration_seconds_total", c.TotalSeconds, "pair", c.Pair)
+	}
+	b.family("cycle_wait_seconds_total", "counter", "Time monitoring cycles waited for a slot under max_concurrent_pairs")
+	for _, c := range self.Cycles {
+		b.sample("cycle_wait_seconds_total", c.WaitSeconds, "pair", c.Pair)
+	}
+	b.family("cycle_last_duration_seconds", "gauge", "Duration of the last monitoring cycle")
+	for _, c := range self.Cycles {
+		b.sample("cycle_last_duration_seconds", c.LastSeconds, "pair", c.Pair)
+	}
+	b.family("cycle_max_duration_seconds", "gauge", "Longest monitoring cycle since startup")
+	for _, c := range self.Cycles {
+		b.sample("cycle_max_duration_seconds", c.MaxSeconds, "pair", c.Pair)
+	}
+	b.family("check_interval_seconds", "gauge", "Check interval of the last monitoring cycle")
+	for _, c := range self.Cycles {
+		b.sample("check_interval_seconds", c.IntervalSeconds, "pair", c.Pair)
+	}
+
+	b.family("check_runs_total", "counter", "Check runs; checksum and consistency checks run once per table")
+	for _, c := range self.Checks {
+		b.sample("check_runs_total", float64(c.Runs), "pair", c.Pair, "check", c.Check)
+	}
+	b.family("check_errors_total", "counter", "Check runs that failed")
+	for _, c := range self.Checks {
+		b.sample("check_errors_total", float64(c.Errors), "pair", c.Pair, "check", c.Check)
+	}
+	b.family("check_duration_seconds_total", "counter", "Time spent in check runs")
+	for _, c := range self.Checks {
+		b.sample("check_duration_seconds_total", c.TotalSeconds, "pair", c.Pair, "check", c.Check)
+	}
+	b.family("check_last_duration_seconds", "gauge", "Duration of the last check run")
+	for _, c := range self.Checks {
+		b.sample("check_last_duration_seconds", c.LastSeconds, "pair", c.Pair, "check", c.Check)
+	}
+	b.family("check_max_duration_seconds", "gauge", "Longest check run since startup")
+	for _, c := range self.Checks {
+		b.sample("check_max_duration_seconds", c.MaxSeconds, "pair", c.Pair, "check", c.Check)
+	}
+
+	writePairMetrics(&b, ws.pairRollups())
+
+	b.family("websocket_clients", "gauge", "Connected dashboard WebSocket clients")
+	b.sample("websocket_clients", float64(self.WebSocketClients))
+	b.family("websocket_clients_dropped_total", "counter", "Dashboard WebSocket clients dropped for not keeping up")
+	b.sample("websocket_clients_dropped_total", float64(self.WebSocketDropped))
+
+	b.family("storage_entries", "gauge", "Entries kept in memory per history")
+	for _, name := range slices.Sorted(maps.Keys(self.Storage)) {
+		b.sample("storage_entries", float64(self.Storage[name]), "history", name)
+	}
+	b.family("alerts_stored", "gauge", "Alerts kept in the history, including resolved ones")
+	b.sample("alerts_stored", float64(self.Alerts))
+
+	b.family("queue_length", "gauge", "Items waiting in a queue")
+	for _, name := range slices.Sorted(maps.Keys(self.Queues)) {
+		b.sample("queue_length", float64(self.Queues[name].Length), "queue", name)
+	}
+	b.family("queue_capacity", "gauge", "Items a queue holds before dropping")
+	for _, name := range slices.Sorted(maps.Keys(self.Queues)) {
+		b.sample("queue_capacity", float64(self.Queues[name].Capacity), "queue", name)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// writePairMetrics writes the current state of every pair, as on /api/pairs
+func writePairMetrics(b *prometheusWriter, rollups []PairRollup) {
+	b.family("replica_lag_seconds", "gauge", "Replica lag of the target; absent while it cannot be measured")
+	for _, r := range rollups {
+		if r.LagStatus == "ok" {
+			b.sample("replica_lag_seconds", r.LagSeconds, "pair", r.Name)
+		}
+	}
+	b.family("source_binlog_bytes_per_second", "gauge", "Binary log bytes written per second by the source; absent until two samples are known")
+	for _, r := range rollups {
+		if r.SourceWriteBytes != nil {
+			b.sample("source_binlog_bytes_per_second", *r.SourceWriteBytes, "pair", r.Name)
+		}
+	}
+	b.family("database_connected", "gauge", "Whether the monitor is connected to a database (1) or not (0)")
+	for _, r := range rollups {
+		b.sample("database_connected", boolValue(r.SourceConnected), "pair", r.Name, "database", "source")
+		b.sample("database_connected", boolValue(r.TargetConnected), "pair", r.Name, "database", "target")
+	}
+	b.family("health_score", "gauge", "Health score of a pair from 0 to 100; absent until the pair has results")
+	for _, r := range rollups {
+		if r.HealthScore != nil {
+			b.sample("health_score", *r.HealthScore, "pair", r.Name)
+		}
+	}
+	b.family("tables_checked", "gauge", "Tables with a current checksum or consistency result")
+	for _, r := range rollups {
+		b.sample("tables_checked", float64(r.ChecksumTotal), "pair", r.Name, "check", "checksum")
+		b.sample("tables_checked", float64(r.ConsistencyTotal), "pair", r.Name, "check", "consistency")
+	}
+	b.family("tables_passed", "gauge", "Tables whose current checksum or consistency result matches")
+	for _, r := range rollups {
+		b.sample("tables_passed", float64(r.ChecksumPassed), "pair", r.Name, "check", "checksum")
+		b.sample("tables_passed", float64(r.ConsistencyPassed), "pair", r.Name, "check", "consistency")
+	}
+	b.family("active_alerts", "gauge", "Active alerts of a pair, excluding those suppressed by a maintenance window")
+	for _, r := range rollups {
+		b.sample("active_alerts", float64(r.ActiveAlerts), "pair", r.Name)
+	}
+	b.family("critical_alerts", "gauge", "Active CRITICAL alerts of a pair")
+	for _, r := range rollups {
+		b.sample("critical_alerts", float64(r.CriticalAlerts), "pair", r.Name)
+	}
+}
+
+// boolValue is 1 for true and 0 for false
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// prometheusWriter builds a Prometheus text exposition
+type prometheusWriter struct {
+	strings.Builder
+}
+
+// family starts a metric family with its help and type
+func (b *prometheusWriter) family(name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s%s %s\n# TYPE %s%s %s\n", prometheusPrefix, name, help, prometheusPrefix, name, kind)
+}
+
+// sample writes one sample; labels are name, value pairs
+func (b *prometheusWriter) sample(name string, value float64, labels ...string) {
+	b.WriteString(prometheusPrefix + name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], prometheusLabelEscaper.Replace(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(b, " %g\n", value)
+}
+
+// prometheusLabelEscaper escapes label values as the text format requires
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -1,16 +1,25 @@
 package web
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"mariadb-encryption-monitor/internal/alert"
 	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/federation"
+	"mariadb-encryption-monitor/internal/monitor"
 	"mariadb-encryption-monitor/internal/storage"
 )
 
@@ -23,58 +32,189 @@ type WSMessage struct {
 
 // WebServer serves the web interface and API
 type WebServer struct {
-	config    *config.Config
-	storage   *storage.MetricsStorage
-	alertMgr  *alert.AlertManager
-	router    *http.ServeMux
-	wsClients map[*websocket.Conn]bool
-	mu        sync.RWMutex
-	upgrader  websocket.Upgrader
+	config     *config.Config
+	storage    *storage.MetricsStorage
+	alertMgr   *alert.AlertManager
+	engine     *monitor.MonitoringEngine
+	federation *federation.Aggregator
+	router     *http.ServeMux
+	wsClients  map[*wsClient]*viewerSession
+	wsEvents   chan WSMessage // pushed to clients by the broadcast loop
+	cycleDone  chan struct{}  // signals the broadcast loop that metrics changed
+	mu         sync.RWMutex
+	upgrader   websocket.Upgrader
+	server     *http.Server
+	stopChan   chan struct{} // stops the broadcast loop on shutdown
+
+	// Dashboard session statistics, guarded by mu
+	totalSessions  int
+	peakViewers    int
+	peakAt         time.Time
+	recentSessions []viewerSession // ended sessions, oldest first
+
+	// WebSocket clients dropped for not keeping up with broadcasts
+	wsDropped atomic.Int64
+
+	// Set on shutdown so /readyz fails while in-flight requests drain
+	shuttingDown atomic.Bool
+
+	snapshotMu      sync.Mutex
+	metricsSnapshot *metricsSnapshot // encoded /api/metrics response, reused until metrics change
+
+	queues map[string]queueFunc // reported by /metrics and /api/self, by name
 }
 
 // NewWebServer creates a new web server
-func NewWebServer(cfg *config.Config, store *storage.MetricsStorage, alertMgr *alert.AlertManager) *WebServer {
+func NewWebServer(cfg *config.Config, store *storage.MetricsStorage, alertMgr *alert.AlertManager, engine *monitor.MonitoringEngine, fed *federation.Aggregator) *WebServer {
 	ws := &WebServer{
-		config:    cfg,
-		storage:   store,
-		alertMgr:  alertMgr,
-		router:    http.NewServeMux(),
-		wsClients: make(map[*websocket.Conn]bool),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow all origins for simplicity
-			},
-		},
+		config:     cfg,
+		storage:    store,
+		alertMgr:   alertMgr,
+		engine:     engine,
+		federation: fed,
+		router:     http.NewServeMux(),
+		wsClients:  make(map[*wsClient]*viewerSession),
+		wsEvents:   make(chan WSMessage, 100),
+		cycleDone:  make(chan struct{}, 1),
+		stopChan:   make(chan struct{}),
+		queues:     make(map[string]queueFunc),
 	}
+	ws.upgrader = websocket.Upgrader{CheckOrigin: ws.checkOrigin}
 
 	ws.setupRoutes()
+	engine.AddPairListener(ws.enqueuePairEvent)
+	engine.AddCycleListener(ws.signalCycleDone)
+	alertMgr.AddListener(ws.enqueueAlertEvent)
 	return ws
 }
 
+// checkOrigin allows WebSocket connections from pages of the monitor itself
+// and of auth.allowed_origins, so other sites cannot read live updates with a
+// visitor's session. Clients that are not browsers send no Origin.
+func (ws *WebServer) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.ContainsFunc(ws.config.Auth.AllowedOrigins, func(allowed string) bool {
+		return strings.EqualFold(allowed, u.Scheme+"://"+u.Host)
+	})
+}
+
 // setupRoutes configures HTTP routes
 func (ws *WebServer) setupRoutes() {
 	ws.router.HandleFunc("/", ws.handleIndex)
+	ws.router.HandleFunc("GET /pairs/{name}", ws.handlePairPage)
+	ws.router.Handle("GET /static/", handleStatic())
 	ws.router.HandleFunc("/ws", ws.handleWebSocket)
-	ws.router.HandleFunc("/api/metrics", ws.handleMetrics)
-	ws.router.HandleFunc("/api/alerts", ws.handleAlerts)
-	ws.router.HandleFunc("/api/health", ws.handleHealth)
+	ws.router.HandleFunc("GET /metrics", ws.handlePrometheusMetrics)
+	ws.router.HandleFunc("GET /livez", ws.handleLivez)
+	ws.router.HandleFunc("GET /readyz", ws.handleReadyz)
+	ws.router.HandleFunc("GET /federation", ws.handleFederationPage)
+	ws.setupAPIRoutes()
 }
 
-// Start starts the web server
+// Start starts the web server and blocks until it fails or is shut down
 func (ws *WebServer) Start() error {
 	addr := fmt.Sprintf(":%d", ws.config.WebServerPort)
 	log.Printf("Starting web server on %s", addr)
 
+	handler, err := ws.handler()
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	if ws.config.WebTLS.Enabled() {
+		if server.TLSConfig, err = newTLSConfig(ws.config); err != nil {
+			return err
+		}
+	}
+
+	ws.mu.Lock()
+	ws.server = server
+	ws.mu.Unlock()
+
 	// Start broadcast loop
 	go ws.broadcastLoop()
 
-	return http.ListenAndServe(addr, ws.router)
+	if ws.config.WebTLS.Enabled() {
+		err = server.ListenAndServeTLS(ws.config.WebTLS.CertFile, ws.config.WebTLS.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown fails readiness, closes WebSocket clients with a going-away
+// frame and waits for in-flight requests until ctx is done
+func (ws *WebServer) Shutdown(ctx context.Context) error {
+	if ws.shuttingDown.Swap(true) {
+		return nil
+	}
+	close(ws.stopChan)
+
+	ws.mu.Lock()
+	server := ws.server
+	closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for client := range ws.wsClients {
+		client.conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second))
+		client.close()
+	}
+	ws.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	log.Println("Shutting down web server...")
+	return server.Shutdown(ctx)
+}
+
+// handler wraps the router with the configured middleware
+func (ws *WebServer) handler() (http.Handler, error) {
+	auth, err := newAuthenticator(context.Background(), ws.config)
+	if err != nil {
+		return nil, err
+	}
+	handler := rateLimitMiddleware(ws.config.RateLimit, auth, ws.stopChan, auth.middleware(ws.router))
+
+	// Access logging is outermost so rate-limited requests are logged too
+	if ws.config.AccessLog.Enabled {
+		logger, err := newAccessLogger(ws.config.AccessLog)
+		if err != nil {
+			return nil, err
+		}
+		handler = accessLogMiddleware(logger, ws.config.RateLimit.TrustProxyHeaders, handler)
+	}
+
+	return handler, nil
 }
 
 // handleIndex serves the main HTML page
 func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(indexHTML))
+	w.Write(indexHTML)
+}
+
+// handlePairPage serves the dashboard page of a pair. The dashboard tells
+// the pair from the URL, so that pair pages can be shared and linked to.
+func (ws *WebServer) handlePairPage(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+	ws.handleIndex(w, r)
 }
 
 // handleWebSocket handles WebSocket connections
@@ -85,43 +225,42 @@ func (ws *WebServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ws.mu.Lock()
-	ws.wsClients[conn] = true
-	ws.mu.Unlock()
-
-	log.Printf("New WebSocket client connected (total: %d)", len(ws.wsClients))
+	client := newWSClient(conn)
+	ws.addViewer(client, r)
 
 	// Send initial data
-	metrics := ws.storage.GetCurrentMetrics()
-	ws.sendToClient(conn, WSMessage{
+	if data, ok := encodeWSMessage(WSMessage{
 		Type:      "metrics_update",
 		Timestamp: time.Now(),
-		Data:      metrics,
-	})
+		Data:      ws.storage.GetCurrentMetrics(),
+	}); ok {
+		client.enqueue(data)
+	}
 
 	// Handle client disconnection
 	go func() {
 		defer func() {
-			ws.mu.Lock()
-			delete(ws.wsClients, conn)
-			ws.mu.Unlock()
-			conn.Close()
-			log.Printf("WebSocket client disconnected (total: %d)", len(ws.wsClients))
+			ws.removeViewer(client)
+			client.close()
 		}()
-
-		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				break
-			}
-		}
+		client.readPump()
 	}()
 }
 
 // handleMetrics handles the metrics API endpoint
 func (ws *WebServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	metrics := ws.storage.GetCurrentMetrics()
+	snapshot, err := ws.currentMetricsSnapshot()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Clients revalidate with If-None-Match or If-Modified-Since and get a
+	// 304 until the metrics change
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(metrics)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", snapshot.etag)
+	http.ServeContent(w, r, "", snapshot.modified, bytes.NewReader(snapshot.body))
 }
 
 // handleAlerts handles the alerts API endpoint
@@ -131,10 +270,105 @@ func (ws *WebServer) handleAlerts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(alerts)
 }
 
+// handleAlertHistory returns a page of the alert history, filtered by pair,
+// type, severity, resolved state and the time the alerts were raised
+func (ws *WebServer) handleAlertHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := alert.HistoryQuery{
+		Pair:     q.Get("pair"),
+		Type:     q.Get("type"),
+		Severity: strings.ToUpper(q.Get("severity")),
+	}
+
+	if v := q.Get("resolved"); v != "" {
+		resolved, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid resolved", http.StatusBadRequest)
+			return
+		}
+		query.Resolved = &resolved
+	}
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"since", &query.Since},
+		{"until", &query.Until},
+	} {
+		if v := q.Get(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid "+param.name+": use RFC 3339, e.g. 2024-05-01T12:00:00Z", http.StatusBadRequest)
+				return
+			}
+			*param.t = t
+		}
+	}
+	for _, param := range []struct {
+		name string
+		n    *int
+	}{
+		{"offset", &query.Offset},
+		{"limit", &query.Limit},
+	} {
+		if v := q.Get(param.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid "+param.name, http.StatusBadRequest)
+				return
+			}
+			*param.n = n
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.alertMgr.QueryAlertHistory(query))
+}
+
+// handleAcknowledgeAlert acknowledges an active alert, stopping its re-notification
+func (ws *WebServer) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+	subject := identityFrom(r).Subject
+	acknowledged, err := ws.alertMgr.Acknowledge(id, subject)
+	if err != nil {
+		status := http.StatusConflict
+		if acknowledged.ID == "" {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	log.Printf("Alert %s acknowledged via API by %s (%s)", id, subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	ws.audit(r, storage.AuditEntry{Action: "alert.acknowledge", DatabasePair: acknowledged.DatabasePair, Target: id, Reason: reason, Details: acknowledged.Message})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(acknowledged)
+}
+
+// handleAlertStats returns alert history size and eviction counters
+func (ws *WebServer) handleAlertStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.alertMgr.HistoryStats())
+}
+
+// healthResponse is the body of /api/v1/health
+type healthResponse struct {
+	Status           string                              `json:"status"`
+	TotalPairs       int                                 `json:"total_pairs"`
+	ConnectedPairs   int                                 `json:"connected_pairs"` // pairs with both databases connected
+	ReadOnly         bool                                `json:"read_only"`
+	ConnectionStatus map[string]storage.ConnectionStatus `json:"connection_status"` // key: database_pair
+	LastUpdated      time.Time                           `json:"last_updated"`
+}
+
 // handleHealth handles the health check endpoint
 func (ws *WebServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	metrics := ws.storage.GetCurrentMetrics()
-	
+
 	// Count connected database pairs
 	totalPairs := len(metrics.ConnectionStatus)
 	connectedPairs := 0
@@ -143,46 +377,147 @@ func (ws *WebServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 			connectedPairs++
 		}
 	}
-	
-	health := map[string]interface{}{
-		"status":            "ok",
-		"total_pairs":       totalPairs,
-		"connected_pairs":   connectedPairs,
-		"connection_status": metrics.ConnectionStatus,
-		"last_updated":      metrics.LastUpdated,
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(healthResponse{
+		Status:           "ok",
+		TotalPairs:       totalPairs,
+		ConnectedPairs:   connectedPairs,
+		ReadOnly:         ws.config.ReadOnly,
+		ConnectionStatus: metrics.ConnectionStatus,
+		LastUpdated:      metrics.LastUpdated,
+	})
+}
+
+// handleDiffs returns the latest row diff result for each table
+func (ws *WebServer) handleDiffs(w http.ResponseWriter, r *http.Request) {
+	results := ws.storage.GetDiffResults()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleRunDiff runs an on-demand row diff for a single table
+func (ws *WebServer) handleRunDiff(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	tableName := r.PathValue("table")
+
+	var opts monitor.DiffOptions
+	if v := r.URL.Query().Get("chunk_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid chunk_size", http.StatusBadRequest)
+			return
+		}
+		opts.ChunkSize = n
+	}
+	if v := r.URL.Query().Get("max_rows"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid max_rows", http.StatusBadRequest)
+			return
+		}
+		opts.MaxRows = n
 	}
+
+	result, err := ws.engine.DiffTable(r.Context(), pairName, tableName, opts)
+	if result == nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitor.ToStorageDiffResult(pairName, result))
+}
+
+// handlePairInfo returns the server metadata of both databases of a pair,
+// read live
+func (ws *WebServer) handlePairInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := ws.engine.PairInfo(r.Context(), r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// handlePairPreflight probes the databases of a pair for the privileges and
+// server features its checks need
+func (ws *WebServer) handlePairPreflight(w http.ResponseWriter, r *http.Request) {
+	report, err := ws.engine.Preflight(r.Context(), r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(health)
+	json.NewEncoder(w).Encode(report)
 }
 
-// broadcastLoop periodically broadcasts updates to all connected clients
+// broadcastLoop broadcasts metrics to all connected clients after each check
+// cycle, and events as they are queued
 func (ws *WebServer) broadcastLoop() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	for {
+		select {
+		case <-ws.cycleDone:
+			metrics := ws.storage.GetCurrentMetrics()
+			ws.BroadcastUpdate(WSMessage{
+				Type:      "metrics_update",
+				Timestamp: time.Now(),
+				Data:      metrics,
+			})
+		case msg := <-ws.wsEvents:
+			ws.BroadcastUpdate(msg)
+		case <-ws.stopChan:
+			return
+		}
+	}
+}
 
-	for range ticker.C {
-		metrics := ws.storage.GetCurrentMetrics()
-		ws.BroadcastUpdate(WSMessage{
-			Type:      "metrics_update",
-			Timestamp: time.Now(),
-			Data:      metrics,
-		})
+// enqueuePairEvent queues a pair lifecycle event for WebSocket clients,
+// dropping it if the queue is full
+func (ws *WebServer) enqueuePairEvent(event monitor.PairEvent) {
+	select {
+	case ws.wsEvents <- WSMessage{Type: "pair_event", Timestamp: event.Timestamp, Data: event}:
+	default:
+		log.Printf("WebSocket event queue full, dropping %s event for pair %s", event.Type, event.Pair)
 	}
 }
 
-// BroadcastUpdate sends an update to all connected WebSocket clients
-func (ws *WebServer) BroadcastUpdate(msg WSMessage) {
-	ws.mu.RLock()
-	defer ws.mu.RUnlock()
+// signalCycleDone wakes the broadcast loop after a check cycle. Cycles
+// finishing while a broadcast is pending share it.
+func (ws *WebServer) signalCycleDone(pairName string) {
+	select {
+	case ws.cycleDone <- struct{}{}:
+	default:
+	}
+}
 
-	for conn := range ws.wsClients {
-		ws.sendToClient(conn, msg)
+// enqueueAlertEvent queues an alert event for WebSocket clients as a message
+// of the event's type, e.g. alert_created, dropping it if the queue is full
+func (ws *WebServer) enqueueAlertEvent(event alert.AlertEvent) {
+	select {
+	case ws.wsEvents <- WSMessage{Type: event.Type, Timestamp: event.Timestamp, Data: event.Alert}:
+	default:
+		log.Printf("WebSocket event queue full, dropping %s event for alert %s", event.Type, event.Alert.ID)
 	}
 }
 
-// sendToClient sends a message to a specific client
-func (ws *WebServer) sendToClient(conn *websocket.Conn, msg WSMessage) {
-	if err := conn.WriteJSON(msg); err != nil {
-		log.Printf("Error sending to WebSocket client: %v", err)
+// BroadcastUpdate queues an update for all connected WebSocket clients. The
+// message is encoded once; clients too slow to keep up are dropped.
+func (ws *WebServer) BroadcastUpdate(msg WSMessage) {
+	data, ok := encodeWSMessage(msg)
+	if !ok {
+		return
+	}
+
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	for client := range ws.wsClients {
+		if client.enqueue(data) {
+			ws.wsDropped.Add(1)
+		}
 	}
 }
@@ -0,0 +1,216 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mariadb-encryption-monitor/internal/config"
+	"mariadb-encryption-monitor/internal/storage"
+)
+
+// durationTiers is the API form of duration thresholds, as Go duration strings
+type durationTiers struct {
+	WarningAt  string `json:"warning_at"`
+	CriticalAt string `json:"critical_at"`
+}
+
+// countTiers is the API form of count thresholds
+type countTiers struct {
+	WarningAt  int64 `json:"warning_at"`
+	CriticalAt int64 `json:"critical_at"`
+}
+
+// pairSettingsBody holds tunable pair settings. In a PATCH request, omitted
+// fields are left unchanged.
+type pairSettingsBody struct {
+	ReplicaLag       *durationTiers `json:"replica_lag,omitempty"`
+	RowCountDrift    *countTiers    `json:"row_count_drift,omitempty"`
+	ClockSkew        *durationTiers `json:"clock_skew,omitempty"`
+	TolerancePercent *float64       `json:"tolerance_percent,omitempty"`
+	CheckInterval    *string        `json:"check_interval,omitempty"`
+}
+
+// pairSettingsResponse shows the per-pair overrides next to the values in effect
+type pairSettingsResponse struct {
+	Pair      string           `json:"pair"`
+	Overrides pairSettingsBody `json:"overrides"`
+	Effective pairSettingsBody `json:"effective"`
+}
+
+// handleGetPairSettings returns the thresholds and check interval of a pair
+func (ws *WebServer) handleGetPairSettings(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	if _, ok := ws.config.PairSettings(pairName); !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.pairSettingsResponse(pairName))
+}
+
+// handlePatchPairSettings changes the thresholds and check interval of a pair
+// and persists them to the configuration file
+func (ws *WebServer) handlePatchPairSettings(w http.ResponseWriter, r *http.Request) {
+	pairName := r.PathValue("name")
+	settings, ok := ws.config.PairSettings(pairName)
+	if !ok {
+		http.Error(w, fmt.Sprintf("database pair '%s' not found", pairName), http.StatusNotFound)
+		return
+	}
+
+	var body pairSettingsBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := body.applyTo(&settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ws.config.UpdatePairSettings(pairName, settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ws.engine.ApplyPairSettings(pairName); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	log.Printf("[%s] Settings updated via API by %s (%s)", pairName, identityFrom(r).Subject, clientIP(r, ws.config.RateLimit.TrustProxyHeaders))
+	changes, _ := json.Marshal(body)
+	ws.audit(r, storage.AuditEntry{Action: "settings.update", DatabasePair: pairName, Details: string(changes)})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.pairSettingsResponse(pairName))
+}
+
+// pairSettingsResponse builds the API view of a pair's settings
+func (ws *WebServer) pairSettingsResponse(pairName string) pairSettingsResponse {
+	settings, _ := ws.config.PairSettings(pairName)
+	effective := ws.config.PairThresholds(pairName)
+
+	return pairSettingsResponse{
+		Pair:      pairName,
+		Overrides: settingsBody(settings.Thresholds, settings.TolerancePercent, settings.CheckInterval),
+		Effective: settingsBody(effective, settings.TolerancePercent, ws.config.PairCheckInterval(pairName)),
+	}
+}
+
+// settingsBody converts settings to their API form
+func settingsBody(thresholds config.ThresholdsConfig, tolerance float64, interval time.Duration) pairSettingsBody {
+	checkInterval := ""
+	if interval > 0 {
+		checkInterval = interval.String()
+	}
+	return pairSettingsBody{
+		ReplicaLag:       toDurationTiers(thresholds.ReplicaLag),
+		RowCountDrift:    &countTiers{WarningAt: thresholds.RowCountDrift.WarningAt, CriticalAt: thresholds.RowCountDrift.CriticalAt},
+		ClockSkew:        toDurationTiers(thresholds.ClockSkew),
+		TolerancePercent: &tolerance,
+		CheckInterval:    &checkInterval,
+	}
+}
+
+// applyTo overwrites the settings present in the request body
+func (b pairSettingsBody) applyTo(settings *config.PairSettings) error {
+	if b.ReplicaLag != nil {
+		tiers, err := b.ReplicaLag.parse()
+		if err != nil {
+			return fmt.Errorf("replica_lag: %w", err)
+		}
+		settings.Thresholds.ReplicaLag = tiers
+	}
+	if b.RowCountDrift != nil {
+		settings.Thresholds.RowCountDrift = config.CountThresholds{
+			WarningAt:  b.RowCountDrift.WarningAt,
+			CriticalAt: b.RowCountDrift.CriticalAt,
+		}
+	}
+	if b.ClockSkew != nil {
+		tiers, err := b.ClockSkew.parse()
+		if err != nil {
+			return fmt.Errorf("clock_skew: %w", err)
+		}
+		settings.Thresholds.ClockSkew = tiers
+	}
+	if b.TolerancePercent != nil {
+		settings.TolerancePercent = *b.TolerancePercent
+	}
+	if b.CheckInterval != nil {
+		interval, err := parseOptionalDuration(*b.CheckInterval)
+		if err != nil {
+			return fmt.Errorf("check_interval: %w", err)
+		}
+		settings.CheckInterval = interval
+	}
+	return nil
+}
+
+// toDurationTiers converts duration thresholds to their API form
+func toDurationTiers(tiers config.DurationThresholds) *durationTiers {
+	result := &durationTiers{}
+	if tiers.WarningAt > 0 {
+		result.WarningAt = tiers.WarningAt.String()
+	}
+	if tiers.CriticalAt > 0 {
+		result.CriticalAt = tiers.CriticalAt.String()
+	}
+	return result
+}
+
+// parse converts API duration tiers; an empty tier is disabled
+func (t durationTiers) parse() (config.DurationThresholds, error) {
+	warning, err := parseOptionalDuration(t.WarningAt)
+	if err != nil {
+		return config.DurationThresholds{}, fmt.Errorf("warning_at: %w", err)
+	}
+	critical, err := parseOptionalDuration(t.CriticalAt)
+	if err != nil {
+		return config.DurationThresholds{}, fmt.Errorf("critical_at: %w", err)
+	}
+	return config.DurationThresholds{WarningAt: warning, CriticalAt: critical}, nil
+}
+
+// parseOptionalDuration parses a Go duration string, treating an empty string as zero
+func parseOptionalDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// requireAdmin rejects requests from callers without the admin role. Admin
+// tokens always carry the admin role.
+func (ws *WebServer) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := identityFrom(r)
+		switch {
+		case id != nil && id.Role == config.RoleAdmin:
+			next(w, r)
+		case id != nil:
+			http.Error(w, "forbidden: admin role required", http.StatusForbidden)
+		case ws.config.Auth.Mode == "none" && len(ws.config.AdminTokens) == 0:
+			http.Error(w, "settings changes are disabled: no admin_tokens configured", http.StatusForbidden)
+		default:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="monitor"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}
+}
+
+// isAdminToken compares a token against the admin tokens in constant time
+func isAdminToken(tokens []string, token string) bool {
+	match := 0
+	for _, candidate := range tokens {
+		match |= subtle.ConstantTimeCompare([]byte(candidate), []byte(token))
+	}
+	return match == 1
+}
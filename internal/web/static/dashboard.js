@@ -0,0 +1,1088 @@
+let ws;
+let reconnectInterval = 5000;
+const pairStates = {};
+const pairMetadata = {};
+const pairModes = {}; // replication or dual_write
+const pausedChecks = {}; // pair -> check -> paused check
+const activeAlerts = {}; // by ID, seeded from /api/v1/alerts/history and kept current by alert_* messages
+let auditEntries = []; // newest first, seeded from /api/v1/audit and extended by audit_entry messages
+// The pair whose page is shown, from a /pairs/{name} URL; null on the pair list
+const currentPair = (() => {
+    const match = window.location.pathname.match(/^\/pairs\/([^/]+)\/?$/);
+    return match ? decodeURIComponent(match[1]) : null;
+})();
+
+// pairURL is the shareable address of a pair's page
+function pairURL(pairName) {
+    return '/pairs/' + encodeURIComponent(pairName);
+}
+
+// showPage titles the page and links a pair's page back to the pair list
+function showPage() {
+    if (currentPair === null) return;
+    document.title = currentPair + ' · ' + document.title;
+    document.getElementById('breadcrumb').innerHTML = '<a href="/">&larr; All pairs</a> / ' + escapeHTML(currentPair);
+}
+
+function connectWebSocket() {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    ws = new WebSocket(protocol + '//' + window.location.host + '/ws');
+
+    ws.onopen = function() {
+        console.log('WebSocket connected');
+        fetchPairStates();
+        fetchAlerts();
+        fetchAuditLog();
+    };
+
+    ws.onmessage = function(event) {
+        const message = JSON.parse(event.data);
+        if (message.type === 'metrics_update') {
+            updateMetrics(message.data);
+        } else if (message.type === 'pair_event') {
+            const pairEvent = message.data;
+            pairStates[pairEvent.pair] = pairEvent.to;
+            if (pairEvent.check) {
+                pausedChecks[pairEvent.pair] = pausedChecks[pairEvent.pair] || {};
+                if (pairEvent.type === 'check_paused') {
+                    pausedChecks[pairEvent.pair][pairEvent.check] = { check: pairEvent.check, reason: pairEvent.reason };
+                } else {
+                    delete pausedChecks[pairEvent.pair][pairEvent.check];
+                }
+            }
+            showLifecycle(pairEvent.pair);
+        } else if (message.type.startsWith('alert_')) {
+            if (message.type === 'alert_resolved') {
+                delete activeAlerts[message.data.ID];
+            } else {
+                activeAlerts[message.data.ID] = message.data;
+            }
+            renderAlerts();
+        } else if (message.type === 'viewers_update') {
+            renderViewers(message.data);
+        } else if (message.type === 'audit_entry') {
+            auditEntries.unshift(message.data);
+            renderAuditLog();
+        }
+    };
+
+    ws.onclose = function() {
+        console.log('WebSocket disconnected, reconnecting...');
+        setTimeout(connectWebSocket, reconnectInterval);
+    };
+
+    ws.onerror = function(error) {
+        console.error('WebSocket error:', error);
+    };
+}
+
+// renderViewers shows how many dashboards are open, naming the
+// viewers in the tooltip
+function renderViewers(data) {
+    const el = document.getElementById('viewers');
+    el.textContent = '👀 ' + data.count + (data.count === 1 ? ' viewer' : ' viewers');
+    el.title = data.viewers.map(v =>
+        (v.subject || v.client_ip) + ' since ' + new Date(v.connected_at).toLocaleTimeString()
+    ).join('\n');
+}
+
+function updateMetrics(data) {
+    // Update connection status for all database pairs
+    if (data.ConnectionStatus) {
+        const statusDiv = document.getElementById('connection-status');
+        const pairs = Object.keys(data.ConnectionStatus).filter(pairName => currentPair === null || pairName === currentPair);
+
+        if (pairs.length === 0) {
+            statusDiv.innerHTML = '<div class="no-data">No database pairs configured</div>';
+        } else {
+            let html = '';
+            pairs.forEach(pairName => {
+                const status = data.ConnectionStatus[pairName];
+                const sourceClass = status.SourceConnected ? 'connected' : 'disconnected';
+                const targetClass = status.TargetConnected ? 'connected' : 'disconnected';
+                html += '<div class="status-item">';
+                html += '<div class="status-dot ' + sourceClass + '"></div>';
+                html += '<div class="status-dot ' + targetClass + '"></div>';
+                html += '<span><a href="' + pairURL(pairName) + '">' + escapeHTML(pairName) + '</a>' + rehearsalBadge(status.Rehearsal) + '</span>';
+                html += '</div>';
+            });
+            statusDiv.innerHTML = html;
+        }
+    }
+
+    // Group data by database pair
+    const databasePairs = {};
+
+    // Collect all database pair names
+    if (data.ReplicaLag) {
+        Object.keys(data.ReplicaLag).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].replicaLag = data.ReplicaLag[pair];
+        });
+    }
+
+    if (data.ClockSkew) {
+        Object.keys(data.ClockSkew).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].clockSkew = data.ClockSkew[pair];
+        });
+    }
+
+    if (data.RDS) {
+        Object.keys(data.RDS).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].rds = data.RDS[pair];
+        });
+    }
+
+    if (data.HealthScore) {
+        Object.keys(data.HealthScore).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].health = data.HealthScore[pair];
+        });
+    }
+
+    if (data.Insights) {
+        Object.keys(data.Insights).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].insights = data.Insights[pair];
+        });
+    }
+
+    if (data.Warmup) {
+        Object.keys(data.Warmup).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].warmup = data.Warmup[pair];
+        });
+    }
+
+    if (data.SemiSync) {
+        Object.keys(data.SemiSync).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].semiSync = data.SemiSync[pair];
+        });
+    }
+
+    if (data.ReplicationFilters) {
+        Object.keys(data.ReplicationFilters).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].replicationFilters = data.ReplicationFilters[pair];
+        });
+    }
+
+    if (data.EncryptionStatus) {
+        Object.keys(data.EncryptionStatus).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].encryption = data.EncryptionStatus[pair];
+        });
+    }
+
+    if (data.TableDefinitions) {
+        Object.keys(data.TableDefinitions).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].tableDefinitions = data.TableDefinitions[pair];
+        });
+    }
+
+    if (data.PTChecksums) {
+        Object.keys(data.PTChecksums).forEach(pair => {
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            databasePairs[pair].ptChecksum = data.PTChecksums[pair];
+        });
+    }
+
+    if (data.ChecksumResults) {
+        Object.keys(data.ChecksumResults).forEach(key => {
+            const parts = key.split(':');
+            const pair = parts[0];
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            if (!databasePairs[pair].checksums) databasePairs[pair].checksums = {};
+            databasePairs[pair].checksums[parts[1]] = data.ChecksumResults[key];
+        });
+    }
+
+    if (data.ConsistencyResults) {
+        Object.keys(data.ConsistencyResults).forEach(key => {
+            const parts = key.split(':');
+            const pair = parts[0];
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            if (!databasePairs[pair].consistency) databasePairs[pair].consistency = {};
+            databasePairs[pair].consistency[parts[1]] = data.ConsistencyResults[key];
+        });
+    }
+
+    if (data.Divergence) {
+        Object.keys(data.Divergence).forEach(key => {
+            const parts = key.split(':');
+            const pair = parts[0];
+            if (!databasePairs[pair]) databasePairs[pair] = {};
+            if (!databasePairs[pair].divergence) databasePairs[pair].divergence = {};
+            databasePairs[pair].divergence[parts[1]] = data.Divergence[key];
+        });
+    }
+
+    // Render the pair list, or the page of one pair
+    const container = document.getElementById('database-pairs-container');
+    const pairNames = Object.keys(databasePairs);
+    const connectionStatus = data.ConnectionStatus || {};
+
+    if (pairNames.length === 0) {
+        container.innerHTML = '<div class="no-data">No data available</div>';
+    } else if (currentPair === null) {
+        container.innerHTML = pairListHTML(pairNames, databasePairs, connectionStatus);
+    } else if (!databasePairs[currentPair]) {
+        container.innerHTML = '<div class="no-data">No data for database pair ' + escapeHTML(currentPair) + '</div>';
+    } else {
+        container.innerHTML = pairSectionHTML(currentPair, databasePairs[currentPair], connectionStatus[currentPair]);
+    }
+
+    // Update last updated time
+    document.getElementById('last-updated').textContent = 'Last updated: ' + new Date().toLocaleTimeString();
+
+    populateSettingsPairs(pairNames);
+
+    // Refresh the history charts of the pair shown at most once per minute
+    if (currentPair !== null && Date.now() - lastChartRefresh > chartRefreshInterval) {
+        lastChartRefresh = Date.now();
+        refreshCharts([currentPair]);
+    }
+}
+
+// pairListHTML renders one row per pair, linking to the pair's page
+function pairListHTML(pairNames, databasePairs, connectionStatus) {
+    const passed = (results, field) => {
+        const values = Object.values(results || {}).filter(result => !result.Skipped);
+        return values.length === 0 ? '-' : values.filter(result => result[field]).length + ' / ' + values.length;
+    };
+    let html = '<div class="card"><h2>📦 Database Pairs</h2>';
+    html += '<table><tr><th>Pair</th><th>Connection</th><th>Health</th><th>State</th><th>Replica lag</th><th>Checksums</th><th>Consistency</th><th>Alerts</th></tr>';
+    pairNames.slice().sort().forEach(pairName => {
+        const pairData = databasePairs[pairName];
+        const status = connectionStatus[pairName];
+        let connection = '-';
+        if (status) {
+            connection = '<div class="status-dot ' + (status.SourceConnected ? 'connected' : 'disconnected') + '" title="Source"></div>' +
+                '<div class="status-dot ' + (status.TargetConnected ? 'connected' : 'disconnected') + '" title="Target"></div>';
+        }
+        let lag = '-';
+        if (pairModes[pairName] === 'dual_write') {
+            lag = 'not checked';
+        } else if (pairData.replicaLag) {
+            lag = (pairData.replicaLag.LagSeconds || 0).toFixed(2) + 's (' + (pairData.replicaLag.Status || 'unknown') + ')';
+        }
+        html += '<tr><td><a href="' + pairURL(pairName) + '">' + escapeHTML(pairName) + '</a></td>';
+        html += '<td>' + connection + '</td>';
+        html += '<td>' + (healthBadge(pairData.health) || '-') + '</td>';
+        html += '<td id="lifecycle-' + pairName + '">' + lifecycleBadge(pairName) + '</td>';
+        html += '<td>' + lag + '</td>';
+        html += '<td>' + passed(pairData.checksums, 'Match') + '</td>';
+        html += '<td>' + passed(pairData.consistency, 'Consistent') + '</td>';
+        html += '<td data-alert-count="' + escapeHTML(pairName) + '">' + alertCountHTML(pairName) + '</td></tr>';
+    });
+    html += '</table></div>';
+    return html;
+}
+
+// healthBadge shows a pair's health score, with its inputs in the tooltip
+function healthBadge(health) {
+    if (!health) return '';
+    const score = health.Score;
+    const healthClass = score >= 90 ? 'success' : (score >= 70 ? 'warning' : 'danger');
+    const inputs = Object.keys(health.Components).map(name => name + ': ' + Math.round(health.Components[name])).join(', ');
+    return ' <span class="badge ' + healthClass + '" title="' + inputs + '">Health ' + Math.round(score) + '/100</span>';
+}
+
+// pairSectionHTML renders every card of a pair for its page
+function pairSectionHTML(pairName, pairData, connection) {
+    let html = '';
+    html += '<section class="pair-section" data-pair="' + escapeHTML(pairName) + '">';
+    html += '<h2 class="db-pair-title">📦 ' + escapeHTML(pairName) + healthBadge(pairData.health) + ' <span id="lifecycle-' + pairName + '">' + lifecycleBadge(pairName) + '</span></h2>';
+    html += '<div class="pair-meta" id="meta-' + pairName + '">' + metadataLine(pairMetadata[pairName]) + '</div>';
+    html += '<div class="grid">';
+
+    // Connection Card
+    html += '<div class="card"><h2>🔌 Connection</h2>';
+    if (connection) {
+        const database = (name, connected, readOnly, retryAt) => {
+            let line = name + ': ' + (connected ? '<span class="badge success">connected</span>' : '<span class="badge danger">disconnected</span>');
+            if (readOnly !== null && readOnly !== undefined) line += ' <span class="badge info">read_only ' + (readOnly ? 'ON' : 'OFF') + '</span>';
+            if (retryAt) line += ' <span class="badge warning">backed off until ' + new Date(retryAt).toLocaleTimeString() + '</span>';
+            return '<div class="metric-label">' + line + '</div>';
+        };
+        html += database('Source', connection.SourceConnected, connection.SourceReadOnly, connection.SourceRetryAt);
+        html += database('Target', connection.TargetConnected, connection.TargetReadOnly, connection.TargetRetryAt);
+        html += '<div class="metric-label">Last checked: ' + new Date(connection.LastChecked).toLocaleString() + rehearsalBadge(connection.Rehearsal) + '</div>';
+        html += '<div class="metric-label note">Version and encryption settings are under Server Info below</div>';
+    } else {
+        html += '<div class="no-data">Not checked yet</div>';
+    }
+    html += '</div>';
+
+    // Insights Card
+    if (pairData.insights) {
+        html += '<div class="card"><h2>💡 Insights</h2>';
+        pairData.insights.forEach(insight => {
+            html += '<div class="metric-label"><span class="badge warning">' + escapeHTML(insight.Rule.replace(/_/g, ' ')) + '</span> ' + escapeHTML(insight.Message) + '</div>';
+        });
+        html += '</div>';
+    }
+
+    // Dual-write Divergence Card
+    if (pairData.divergence) {
+        html += '<div class="card"><h2>🔀 Dual-write Divergence</h2>';
+        html += '<table><tr><th>Table</th><th>Divergent checks</th><th>Status</th></tr>';
+        Object.keys(pairData.divergence).sort().forEach(table => {
+            const counter = pairData.divergence[table];
+            const badge = counter.Consecutive > 0 ?
+                '<span class="badge danger" title="Since ' + new Date(counter.DivergedSince).toLocaleString() + '">✗ ' + counter.Consecutive + ' in a row</span>' :
+                '<span class="badge success">✓ In sync</span>';
+            html += '<tr><td>' + escapeHTML(table) + '</td><td>' + counter.Divergent + ' / ' + counter.Checks + '</td><td>' + badge + '</td></tr>';
+        });
+        html += '</table></div>';
+    }
+
+    // Replica Lag Card
+    html += '<div class="card"><h2>📊 Replica Lag</h2>';
+    if (pairModes[pairName] === 'dual_write') {
+        html += '<div class="no-data">Not checked: the application writes to both databases</div>';
+    } else if (pairData.replicaLag) {
+        const lag = pairData.replicaLag;
+        let lagClass = 'metric-value';
+        if (lag.LagSeconds < 10) lagClass += ' good';
+        else if (lag.LagSeconds < 60) lagClass += ' warning';
+        else lagClass += ' critical';
+
+        html += '<div class="metric">';
+        html += '<div class="metric-label">Current Lag</div>';
+        html += '<div class="' + lagClass + '">' + (lag.LagSeconds || 0).toFixed(2) + 's</div>';
+        html += '</div>';
+        html += '<div class="metric-label">Status: <span>' + (lag.Status || 'unknown') + '</span>' + rehearsalBadge(lag.Rehearsal) + '</div>';
+        html += '<div class="metric-label">IO thread: ' + (lag.IORunning || '-') + ' &middot; SQL thread: ' + (lag.SQLRunning || '-') + '</div>';
+        // The replica's own error says why a thread stopped, without logging into the database
+        if (lag.LastIOErrno || lag.LastIOError) {
+            html += '<div class="metric-label critical">IO error ' + (lag.LastIOErrno || 0) + ': ' + escapeHTML(lag.LastIOError || '') + '</div>';
+        }
+        if (lag.LastSQLErrno || lag.LastSQLError) {
+            html += '<div class="metric-label critical">SQL error ' + (lag.LastSQLErrno || 0) + ': ' + escapeHTML(lag.LastSQLError || '') + '</div>';
+        }
+        // Settings the replica does not report are left out
+        const retry = ['Connect retry: ' + (lag.ConnectRetry || 0) + 's'];
+        if (lag.HeartbeatPeriod !== undefined) retry.unshift('Heartbeat period: ' + lag.HeartbeatPeriod + 's');
+        if (lag.MasterRetryCount !== undefined) retry.push('Max retries: ' + lag.MasterRetryCount);
+        html += '<div class="metric-label">' + retry.join(' &middot; ') + '</div>';
+        if (lag.Backlog) {
+            // Byte backlog tells a slow IO thread (network, primary) from a slow SQL thread (applying)
+            const backlogBytes = n => n === null || n === undefined ? 'unknown' : formatBytes(n);
+            const bottleneck = lag.Backlog.Bottleneck ? ' <span class="badge warning">' + lag.Backlog.Bottleneck.toUpperCase() + ' thread behind</span>' : '';
+            html += '<div class="metric-label">Binlog backlog: IO ' + backlogBytes(lag.Backlog.IOBytes) + ' &middot; SQL ' + backlogBytes(lag.Backlog.SQLBytes) + bottleneck + '</div>';
+            html += '<div class="metric-label">Read ' + escapeHTML(lag.Backlog.ReadFile) + ':' + lag.Backlog.ReadPos + ' &middot; Exec ' + escapeHTML(lag.Backlog.ExecFile) + ':' + lag.Backlog.ExecPos + '</div>';
+        }
+        if (lag.SourceWrites && lag.SourceWrites.BytesPerSecond !== null && lag.SourceWrites.BytesPerSecond !== undefined) {
+            // A write burst on the source explains lag the target cannot be blamed for
+            const writes = lag.SourceWrites;
+            let line = 'Source writes: ' + formatBytes(writes.BytesPerSecond) + '/s';
+            if (writes.EventsPerSecond !== null && writes.EventsPerSecond !== undefined) line += ' &middot; ' + writes.EventsPerSecond.toFixed(1) + ' transactions/s';
+            if (writes.AvgBytesPerSecond > 0) line += ' &middot; ' + (writes.BytesPerSecond / writes.AvgBytesPerSecond).toFixed(1) + '&times; average';
+            if (writes.Burst) line += ' <span class="badge warning">Source write burst</span>';
+            html += '<div class="metric-label">' + line + '</div>';
+        }
+        if (lag.Hops) {
+            // Chained replication: per-hop lag shows where the bottleneck is
+            html += '<table><tr><th>Hop</th><th>Lag</th><th>Status</th></tr>';
+            lag.Hops.forEach(hop => {
+                const hopStatus = hop.Status === 'ok' ? hop.Status : '<span class="badge danger" title="' + (hop.Error || '') + '">' + hop.Status + '</span>';
+                html += '<tr><td>' + hop.Name + '</td><td>' + hop.LagSeconds.toFixed(2) + 's</td><td>' + hopStatus + '</td></tr>';
+            });
+            html += '</table>';
+        }
+    } else {
+        html += '<div class="no-data">No data</div>';
+    }
+    if (pairData.clockSkew) {
+        const skew = pairData.clockSkew;
+        html += '<div class="metric-label">Clock skew vs monitor: source ' + (skew.SourceSkewSeconds || 0).toFixed(3) + 's &middot; target ' + (skew.TargetSkewSeconds || 0).toFixed(3) + 's &middot; source-to-target ' + (skew.SourceTargetSkewSeconds || 0).toFixed(3) + 's</div>';
+    }
+    html += '</div>';
+
+    // CloudWatch Card
+    if (pairData.rds) {
+        html += '<div class="card"><h2>☁️ CloudWatch (RDS)</h2>';
+        html += '<table><tr><th>Metric</th><th>Source</th><th>Target</th></tr>';
+        const rdsRow = (label, field, format) => {
+            const cell = instance => {
+                if (!instance) return '-';
+                if (instance.Error) return '<span class="badge danger" title="' + instance.Error + '">error</span>';
+                return instance[field] === null ? '-' : format(instance[field]);
+            };
+            html += '<tr><td>' + label + '</td><td>' + cell(pairData.rds.Source) + '</td><td>' + cell(pairData.rds.Target) + '</td></tr>';
+        };
+        rdsRow('Instance', 'InstanceID', v => v);
+        rdsRow('ReplicaLag', 'ReplicaLagSeconds', v => v.toFixed(2) + 's');
+        rdsRow('CPU', 'CPUUtilization', v => v.toFixed(1) + '%');
+        rdsRow('Free storage', 'FreeStorageSpaceBytes', formatBytes);
+        rdsRow('Binlog disk usage', 'BinLogDiskUsageBytes', formatBytes);
+        html += '</table></div>';
+    }
+
+    // Target Warm-up Card
+    if (pairData.warmup) {
+        const warmup = pairData.warmup;
+        html += '<div class="card"><h2>🔥 Target Warm-up</h2>';
+        if (warmup.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + warmup.Error + '</div>';
+        } else {
+            const hitClass = warmup.BufferPoolHitRate >= warmup.MinBufferPoolHitRate ? 'good' : 'warning';
+            html += '<div class="metric">';
+            html += '<div class="metric-label">Buffer pool hit rate' + (warmup.HitRateSinceStartup ? ' (since startup)' : '') + '</div>';
+            html += '<div class="metric-value ' + hitClass + '">' + warmup.BufferPoolHitRate.toFixed(2) + '%</div>';
+            html += '</div>';
+            html += '<div class="metric-label">Minimum: ' + warmup.MinBufferPoolHitRate + '% &middot; Buffer pool filled: ' + warmup.BufferPoolFillPercent.toFixed(1) + '%</div>';
+            html += '<div class="metric-label">Ready for cutover: ' + (warmup.Ready ? '<span class="badge success">✓ Yes</span>' : '<span class="badge warning">Not yet</span>') + '</div>';
+            if (warmup.Queries && warmup.Queries.length > 0) {
+                html += '<table><tr><th>Query</th><th>Indexes used</th><th>Status</th></tr>';
+                warmup.Queries.forEach(query => {
+                    const badge = query.Ready ?
+                        '<span class="badge success">✓ OK</span>' :
+                        '<span class="badge warning" title="' + query.Problem + '">✗ ' + query.Problem + '</span>';
+                    html += '<tr><td>' + query.Name + '</td><td>' + ((query.Keys || []).join(', ') || '-') + '</td><td>' + badge + '</td></tr>';
+                });
+                html += '</table>';
+            }
+        }
+        html += '</div>';
+    }
+
+    // Semi-sync Card
+    if (pairData.semiSync) {
+        const semiSync = pairData.semiSync;
+        html += '<div class="card"><h2>🤝 Semi-sync Replication</h2>';
+        if (semiSync.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + escapeHTML(semiSync.Error) + '</div>';
+        } else {
+            const modeBadge = semiSync.Async ?
+                '<span class="badge danger">Asynchronous</span>' :
+                '<span class="badge success">✓ Semi-sync</span>';
+            const onOff = (enabled, active) => !enabled ? 'disabled' : (active ? 'ON' : 'OFF');
+            html += '<div class="metric-label">Mode: ' + modeBadge + '</div>';
+            html += '<table><tr><th></th><th>Source</th><th>Target</th></tr>';
+            html += '<tr><td>Status</td><td>' + onOff(semiSync.SourceEnabled, semiSync.SourceActive) + '</td><td>' + onOff(semiSync.TargetEnabled, semiSync.TargetActive) + '</td></tr>';
+            html += '</table>';
+            html += '<div class="metric-label">Replicas: ' + semiSync.Clients + ' &middot; Timeout: ' + semiSync.TimeoutSeconds + 's &middot; Avg wait: ' + (semiSync.AvgTxWaitSeconds * 1000).toFixed(2) + 'ms &middot; Waiting: ' + semiSync.WaitSessions + '</div>';
+            html += '<div class="metric-label">Commits acknowledged: ' + semiSync.AckedTx + ' &middot; not acknowledged: ' + semiSync.AsyncTx + ' (+' + semiSync.NewAsyncTx + ') &middot; fallbacks: ' + semiSync.Fallbacks + ' (+' + semiSync.NewFallbacks + ')</div>';
+            (semiSync.Problems || []).forEach(problem => {
+                html += '<div class="metric-label"><span class="badge warning">!</span> ' + escapeHTML(problem) + '</div>';
+            });
+        }
+        html += '</div>';
+    }
+
+    // Replication Filters Card
+    if (pairData.replicationFilters) {
+        const filters = pairData.replicationFilters;
+        html += '<div class="card"><h2>🚰 Replication Filters</h2>';
+        if (filters.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + escapeHTML(filters.Error) + '</div>';
+        } else if (!filters.Problems || filters.Problems.length === 0) {
+            html += '<div class="metric-label"><span class="badge success">✓ None</span> The target applies every replicated change</div>';
+        } else {
+            const skipping = filters.SkipCounter > 0 || filters.SkipErrors;
+            html += '<div class="metric-label">' + (skipping ?
+                '<span class="badge danger">Skipping events or errors</span>' :
+                '<span class="badge warning">Filtered</span>') + '</div>';
+            filters.Problems.forEach(problem => {
+                html += '<div class="metric-label"><span class="badge warning">!</span> ' + escapeHTML(problem) + '</div>';
+            });
+        }
+        html += '</div>';
+    }
+
+    // Encryption Progress Card
+    if (pairData.encryption) {
+        const encryption = pairData.encryption;
+        html += '<div class="card"><h2>🔐 Encryption Progress</h2>';
+        if (encryption.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + escapeHTML(encryption.Error) + '</div>';
+        } else {
+            const total = encryption.Tables.length;
+            const percentClass = encryption.Percent >= 100 ? 'good' : 'warning';
+            html += '<div class="metric">';
+            html += '<div class="metric-label">Monitored tables encrypted on the target</div>';
+            html += '<div class="metric-value ' + percentClass + '">' + encryption.Percent.toFixed(1) + '%</div>';
+            html += '</div>';
+            html += '<div class="progress"><div class="progress-fill" style="width: ' + encryption.Percent.toFixed(1) + '%"></div></div>';
+            html += '<table><tr><th></th><th>Source</th><th>Target</th></tr>';
+            const sourceCell = (encrypted, all) => encryption.SourceChecked ? encrypted + ' / ' + all : '-';
+            html += '<tr><td>Monitored tables</td><td>' + sourceCell(encryption.SourceTablesEncrypted, total) + '</td><td>' + encryption.TablesEncrypted + ' / ' + total + '</td></tr>';
+            html += '<tr><td>All tablespaces</td><td>' + sourceCell(encryption.SourceTablespacesEncrypted, encryption.SourceTablespaces) + '</td><td>' + encryption.TargetTablespacesEncrypted + ' / ' + encryption.TargetTablespaces + '</td></tr>';
+            html += '</table>';
+            if (total > 0) {
+                html += '<table><tr><th>Table</th><th>Encrypted on target</th></tr>';
+                encryption.Tables.forEach(table => {
+                    const name = table.TargetTable && table.TargetTable !== table.Table ?
+                        escapeHTML(table.Table) + ' → ' + escapeHTML(table.TargetTable) : escapeHTML(table.Table);
+                    let completed = '<span class="badge warning">Not yet</span>';
+                    if (table.TargetEncrypted) {
+                        completed = table.EncryptedAt ?
+                            '<span class="badge success">✓</span> ' + new Date(table.EncryptedAt).toLocaleString() :
+                            '<span class="badge success">✓</span> before monitoring';
+                    }
+                    html += '<tr><td>' + name + '</td><td>' + completed + '</td></tr>';
+                });
+                html += '</table>';
+            }
+        }
+        html += '<div class="metric-label">Checked at ' + new Date(encryption.Timestamp).toLocaleString() + '</div>';
+        html += '</div>';
+    }
+
+    // Table Definitions Card
+    if (pairData.tableDefinitions) {
+        const definitions = pairData.tableDefinitions;
+        html += '<div class="card"><h2>📐 Table Definitions</h2>';
+        if (definitions.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + escapeHTML(definitions.Error) + '</div>';
+        } else {
+            const encryptionCell = (encrypted, keyID) => encrypted ?
+                '<span class="badge success">✓</span>' + (keyID ? ' key ' + keyID : '') : 'no';
+            html += '<table><tr><th>Table</th><th>Source</th><th>Target</th><th>Status</th></tr>';
+            definitions.Tables.forEach(table => {
+                const name = table.TargetTable && table.TargetTable !== table.Table ?
+                    escapeHTML(table.Table) + ' → ' + escapeHTML(table.TargetTable) : escapeHTML(table.Table);
+                const source = definitions.SourceChecked && !table.Error ?
+                    encryptionCell(table.SourceEncrypted, 0) + ' &middot; ' + escapeHTML(table.SourceRowFormat) : '-';
+                const target = table.Error ? '-' :
+                    encryptionCell(table.TargetEncrypted, table.TargetKeyID) + ' &middot; ' + escapeHTML(table.TargetRowFormat);
+                let status = '<span class="badge success">✓ OK</span>';
+                if (table.Error) {
+                    status = '<span class="badge danger">error</span> ' + escapeHTML(table.Error);
+                } else if (table.Problems && table.Problems.length > 0) {
+                    status = table.Problems.map(problem =>
+                        '<span class="badge ' + (table.TargetEncrypted ? 'warning' : 'danger') + '">!</span> ' + escapeHTML(problem)).join('<br>');
+                }
+                html += '<tr><td>' + name + '</td><td>' + source + '</td><td>' + target + '</td><td>' + status + '</td></tr>';
+            });
+            html += '</table>';
+        }
+        html += '<div class="metric-label">Encryption and ROW_FORMAT as declared by SHOW CREATE TABLE, checked at ' + new Date(definitions.Timestamp).toLocaleString() + '</div>';
+        html += '</div>';
+    }
+
+    // pt-table-checksum Card
+    if (pairData.ptChecksum) {
+        const pt = pairData.ptChecksum;
+        html += '<div class="card"><h2>🧮 pt-table-checksum</h2>';
+        html += '<div class="metric-label">Read from the ' + pt.ReadFrom + ' at ' + new Date(pt.Timestamp).toLocaleString() + '</div>';
+        if (pt.Error) {
+            html += '<div class="metric-label"><span class="badge danger">error</span> ' + escapeHTML(pt.Error) + '</div>';
+        }
+        if (pt.Tables && pt.Tables.length > 0) {
+            html += '<table><tr><th>Table</th><th>Chunks</th><th>Rows</th><th>Last run</th><th>Status</th></tr>';
+            pt.Tables.forEach(table => {
+                const badge = table.DiffChunks === 0 ?
+                    '<span class="badge success">✓ Match</span>' :
+                    '<span class="badge danger">✗ ' + table.DiffChunks + ' differing</span>';
+                const lastRun = table.LastRun ? new Date(table.LastRun).toLocaleString() : '-';
+                html += '<tr><td>' + escapeHTML(table.TableName) + '</td><td>' + table.Chunks + '</td><td>' + table.Rows + '</td><td>' + lastRun + '</td><td>' + badge + '</td></tr>';
+                (table.Diffs || []).forEach(diff => {
+                    const bounds = (diff.LowerBoundary || diff.UpperBoundary) ?
+                        ' ' + escapeHTML(diff.Index) + ' ' + escapeHTML(diff.LowerBoundary) + '..' + escapeHTML(diff.UpperBoundary) : '';
+                    html += '<tr><td colspan="5" class="metric-label">&nbsp;&nbsp;chunk ' + diff.Chunk + bounds + ': ' + diff.SourceCount + ' rows on source, ' + diff.TargetCount + ' on target</td></tr>';
+                });
+            });
+            html += '</table>';
+        } else if (!pt.Error) {
+            html += '<div class="metric-label">No results for this schema yet</div>';
+        }
+        html += '</div>';
+    }
+
+    // Checksum Card
+    html += '<div class="card"><h2>🔍 Checksum Validation</h2>';
+    if (pairData.checksums && Object.keys(pairData.checksums).length > 0) {
+        html += '<table><tr><th>Table</th><th>Status</th></tr>';
+        Object.keys(pairData.checksums).forEach(table => {
+            const result = pairData.checksums[table];
+            let badge = result.Match ? 
+                '<span class="badge success">✓ Match</span>' : 
+                '<span class="badge danger">✗ Mismatch</span>';
+            if (result.Skipped) {
+                badge = '<span class="badge warning" title="~' + result.EstimatedRows + ' rows, ~' + result.EstimatedBytes + ' bytes">Skipped (too large)</span>';
+            }
+            if (result.View) {
+                badge += ' <span class="badge info" title="Hashed over its rows, as CHECKSUM TABLE does not read views">view</span>';
+            }
+            if (result.Excluded) {
+                badge += ' <span class="badge info" title="Left out of the checksum: ' + escapeHTML(result.Excluded.join(', ')) + '">' + result.Excluded.length + ' column(s) excluded</span>';
+            }
+            html += '<tr><td>' + tableLabel(table, result) + '</td><td>' + badge + rehearsalBadge(result.Rehearsal) + '</td></tr>';
+        });
+        html += '</table>';
+    } else {
+        html += '<div class="no-data">No data</div>';
+    }
+    html += '</div>';
+
+    // Consistency Card
+    html += '<div class="card"><h2>✓ Data Consistency</h2>';
+    if (pairData.consistency && Object.keys(pairData.consistency).length > 0) {
+        html += '<table><tr><th>Table</th><th>Source</th><th>Target</th><th>Status</th></tr>';
+        Object.keys(pairData.consistency).forEach(table => {
+            const result = pairData.consistency[table];
+            const badge = result.Consistent ? 
+                '<span class="badge success">✓ Consistent</span>' : 
+                '<span class="badge danger">✗ Inconsistent</span>';
+            const approx = result.Approximate ? '~' : '';
+            const approxBadge = result.Approximate ? ' <span class="badge info" title="Estimated from index statistics">approx</span>' : '';
+            const backfillBadge = result.Backfill ? ' <span class="badge warning" title="Compared within ' + result.Tolerance + '% while ' + result.Backfill + ' runs">backfill</span>' : '';
+            const windowBadge = result.WindowOnly ? ' <span class="badge info" title="Full count skipped; only recent rows and the key range were compared">window only</span>' : '';
+            const sourceCount = result.WindowOnly ? '—' : approx + result.SourceRowCount;
+            const targetCount = result.WindowOnly ? '—' : approx + result.TargetRowCount;
+            html += '<tr><td>' + tableLabel(table, result) + '</td><td>' + sourceCount + '</td><td>' + targetCount + '</td><td>' + badge + approxBadge + backfillBadge + windowBadge + rehearsalBadge(result.Rehearsal) + '</td></tr>';
+            if (result.Window) {
+                const w = result.Window;
+                let detail = 'Since ' + new Date(w.From).toLocaleTimeString() + ': ' + w.SourceCount + ' / ' + w.TargetCount + ' rows';
+                if (w.PrimaryKey) {
+                    detail += ', ' + w.PrimaryKey + ' ' + (w.SourceMinPK || '–') + '..' + (w.SourceMaxPK || '–') + ' / ' + (w.TargetMinPK || '–') + '..' + (w.TargetMaxPK || '–');
+                }
+                const problems = (w.Problems || []).join('; ');
+                const problemsBadge = problems ? ' <span class="badge danger" title="' + problems + '">window differs</span>' : '';
+                html += '<tr><td colspan="4" class="metric-label">&nbsp;&nbsp;' + detail + problemsBadge + '</td></tr>';
+            }
+        });
+        html += '</table>';
+    } else {
+        html += '<div class="no-data">No data</div>';
+    }
+    html += '</div>';
+
+    // Trends Card
+    html += '<div class="card"><h2>📈 Trends (6h)</h2>';
+    html += '<div class="chart-legend">Replica lag (seconds)</div>';
+    html += '<div class="chart" id="chart-lag-' + pairName + '">' + (chartCache[pairName + ':lag'] || '<div class="no-data">Loading...</div>') + '</div>';
+    html += '<div class="chart-legend">Source binary log writes (KiB/s)</div>';
+    html += '<div class="chart" id="chart-writes-' + pairName + '">' + (chartCache[pairName + ':writes'] || '<div class="no-data">Loading...</div>') + '</div>';
+    html += '<div class="chart-legend">Checksum / consistency pass rate (%)</div>';
+    html += '<div class="chart" id="chart-pass-' + pairName + '">' + (chartCache[pairName + ':pass'] || '<div class="no-data">Loading...</div>') + '</div>';
+    html += '<div class="chart-legend">Replica lag, 30d hourly average / max (seconds)</div>';
+    html += '<div class="chart" id="chart-lag30d-' + pairName + '">' + (chartCache[pairName + ':lag30d'] || '<div class="no-data">Loading...</div>') + '</div>';
+    html += '</div>';
+
+    // Replication Events Card
+    html += '<div class="card"><h2>🔁 Replication Stops (24h)</h2>';
+    html += '<div id="events-' + pairName + '">' + (chartCache[pairName + ':events'] || '<div class="no-data">Loading...</div>') + '</div>';
+    html += '</div>';
+
+    html += '</div>'; // Close grid
+    html += '</section>';
+    return html;
+}
+
+// Marks a result replaced by a rehearsal fault injected through the API
+function rehearsalBadge(faultID) {
+    return faultID ? ' <span class="badge warning" title="Synthetic result of rehearsal fault ' + escapeHTML(faultID) + '">rehearsal</span>' : '';
+}
+
+// Table name of a check result, with the target name when the table was renamed
+function tableLabel(table, result) {
+    if (!result.TargetTable || result.TargetTable === table) return table;
+    return table + ' &rarr; ' + result.TargetTable;
+}
+
+// Lifecycle state badges, seeded from /api/v1/pairs and kept current by pair_event messages
+function lifecycleBadge(pairName) {
+    const state = pairStates[pairName];
+    let html = '';
+    if (pairModes[pairName] === 'dual_write') {
+        html += '<span class="badge info">dual write</span> ';
+    }
+    if (state && state !== 'monitoring') {
+        const badgeClass = { paused: 'warning', warmup: 'warning', ready: 'success', cut_over: 'info', complete: 'success' }[state] || 'info';
+        html += '<span class="badge ' + badgeClass + '">' + state.replace('_', ' ') + '</span>';
+    }
+    Object.values(pausedChecks[pairName] || {}).forEach(paused => {
+        html += ' <span class="badge warning" title="' + escapeHTML(paused.reason || '') + '">' + paused.check.replace('_', ' ') + ' paused</span>';
+    });
+    return html;
+}
+
+function showLifecycle(pairName) {
+    const el = document.getElementById('lifecycle-' + pairName);
+    if (el) el.innerHTML = lifecycleBadge(pairName);
+}
+
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+// Owner, ticket and runbook link of a pair, from its metadata
+function metadataLine(metadata) {
+    if (!metadata) return '';
+    const parts = [];
+    if (metadata.owner) parts.push('Owner: ' + escapeHTML(metadata.owner));
+    if (metadata.ticket) parts.push('Ticket: ' + escapeHTML(metadata.ticket));
+    if (metadata.runbook_url) parts.push('<a href="' + escapeHTML(metadata.runbook_url) + '" target="_blank" rel="noopener">Runbook</a>');
+    let line = parts.join(' &middot; ');
+    if (metadata.description) line = escapeHTML(metadata.description) + (line ? '<br>' + line : '');
+    return line;
+}
+
+function fetchPairStates() {
+    fetch('/api/v1/pairs')
+        .then(response => response.json())
+        .then(rollups => rollups.forEach(rollup => {
+            pairStates[rollup.name] = rollup.lifecycle;
+            pausedChecks[rollup.name] = {};
+            (rollup.paused_checks || []).forEach(paused => pausedChecks[rollup.name][paused.check] = paused);
+            pairMetadata[rollup.name] = rollup.metadata;
+            pairModes[rollup.name] = rollup.mode;
+            showLifecycle(rollup.name);
+            const meta = document.getElementById('meta-' + rollup.name);
+            if (meta) meta.innerHTML = metadataLine(rollup.metadata);
+        }))
+        .catch(error => console.error('Error fetching pair states:', error));
+}
+
+const chartCache = {};
+const chartRefreshInterval = 60000;
+let lastChartRefresh = 0;
+
+function refreshCharts(pairNames) {
+    pairNames.forEach(pairName => {
+        const pair = encodeURIComponent(pairName);
+        fetch('/api/v1/history/replica_lag?pair=' + pair + '&duration=6h')
+            .then(response => response.json())
+            .then(history => {
+                const series = [{ color: '#3498db', points: history.points.map(p => [new Date(p.timestamp).getTime(), p.lag_seconds]) }];
+                setChart(pairName + ':lag', 'chart-lag-' + pairName, drawLineChart(series, null));
+                // Drawn over the same window as the lag, so that bursts line up with lag spikes
+                const writes = history.points.filter(p => p.source_write_bytes_per_second !== undefined);
+                const writeSeries = [{ color: '#e67e22', points: writes.map(p => [new Date(p.timestamp).getTime(), p.source_write_bytes_per_second / 1024]) }];
+                setChart(pairName + ':writes', 'chart-writes-' + pairName, drawLineChart(writeSeries, null));
+            })
+            .catch(error => console.error('Error fetching lag history:', error));
+
+        fetch('/api/v1/history/replica_lag?pair=' + pair + '&duration=720h&step=1h')
+            .then(response => response.json())
+            .then(history => {
+                const series = [
+                    { color: '#3498db', points: history.points.map(p => [new Date(p.timestamp).getTime(), p.lag_seconds]) },
+                    { color: '#e74c3c', points: history.points.map(p => [new Date(p.timestamp).getTime(), p.max_lag_seconds || 0]) }
+                ];
+                setChart(pairName + ':lag30d', 'chart-lag30d-' + pairName, drawLineChart(series, null));
+            })
+            .catch(error => console.error('Error fetching lag rollups:', error));
+
+        Promise.all([
+            fetch('/api/v1/history/checksum?pair=' + pair + '&duration=6h').then(response => response.json()),
+            fetch('/api/v1/history/consistency?pair=' + pair + '&duration=6h').then(response => response.json())
+        ]).then(([checksum, consistency]) => {
+            const series = [
+                { color: '#27ae60', points: checksum.points.map(p => [new Date(p.timestamp).getTime(), p.pass_rate]) },
+                { color: '#8e44ad', points: consistency.points.map(p => [new Date(p.timestamp).getTime(), p.pass_rate]) }
+            ];
+            setChart(pairName + ':pass', 'chart-pass-' + pairName, drawLineChart(series, 100));
+        }).catch(error => console.error('Error fetching pass rate history:', error));
+
+        fetch('/api/v1/history/replication_events?pair=' + pair + '&duration=24h')
+            .then(response => response.json())
+            .then(timeline => setChart(pairName + ':events', 'events-' + pairName, drawOutageTable(timeline.outages)))
+            .catch(error => console.error('Error fetching replication events:', error));
+    });
+}
+
+function drawOutageTable(outages) {
+    if (outages.length === 0) {
+        return '<div class="no-data">No replication thread stops</div>';
+    }
+    let html = '<table><tr><th>Thread</th><th>Stopped</th><th>Started</th><th>Duration</th></tr>';
+    outages.slice().reverse().forEach(o => {
+        const started = o.started_at ? new Date(o.started_at).toLocaleString() : '<span class="badge danger">still ' + o.state + '</span>';
+        html += '<tr><td>' + o.thread.toUpperCase() + ' (' + o.state + ')</td><td>' + new Date(o.stopped_at).toLocaleString() + '</td><td>' + started + '</td><td>' + formatDuration(o.duration_seconds) + '</td></tr>';
+    });
+    html += '</table>';
+    return html;
+}
+
+function formatDuration(seconds) {
+    if (seconds < 60) return seconds.toFixed(0) + 's';
+    if (seconds < 3600) return Math.floor(seconds / 60) + 'm ' + Math.floor(seconds % 60) + 's';
+    return Math.floor(seconds / 3600) + 'h ' + Math.floor(seconds % 3600 / 60) + 'm';
+}
+
+function setChart(cacheKey, elementId, svg) {
+    chartCache[cacheKey] = svg;
+    const el = document.getElementById(elementId);
+    if (el) el.innerHTML = svg;
+}
+
+function drawLineChart(series, fixedMax) {
+    const width = 400, height = 140, pad = 30;
+    let minX = Infinity, maxX = -Infinity, maxY = fixedMax || 0;
+    series.forEach(s => s.points.forEach(([x, y]) => {
+        minX = Math.min(minX, x);
+        maxX = Math.max(maxX, x);
+        if (!fixedMax) maxY = Math.max(maxY, y);
+    }));
+    if (minX === Infinity) {
+        return '<div class="no-data">No history yet</div>';
+    }
+    if (maxX === minX) maxX = minX + 1;
+    if (maxY === 0) maxY = 1;
+
+    const sx = x => pad + (x - minX) / (maxX - minX) * (width - pad - 5);
+    const sy = y => height - 20 - y / maxY * (height - 30);
+
+    let svg = '<svg viewBox="0 0 ' + width + ' ' + height + '" preserveAspectRatio="none">';
+    svg += '<line x1="' + pad + '" y1="' + sy(0) + '" x2="' + (width - 5) + '" y2="' + sy(0) + '" class="grid-line"/>';
+    svg += '<line x1="' + pad + '" y1="' + sy(maxY) + '" x2="' + (width - 5) + '" y2="' + sy(maxY) + '" class="grid-line"/>';
+    svg += '<text x="2" y="' + (sy(maxY) + 4) + '" class="axis-label" font-size="10">' + maxY.toFixed(0) + '</text>';
+    svg += '<text x="2" y="' + (sy(0) + 4) + '" class="axis-label" font-size="10">0</text>';
+    svg += '<text x="' + pad + '" y="' + (height - 4) + '" class="axis-label" font-size="10">' + new Date(minX).toLocaleTimeString() + '</text>';
+    svg += '<text x="' + (width - 5) + '" y="' + (height - 4) + '" class="axis-label" font-size="10" text-anchor="end">' + new Date(maxX).toLocaleTimeString() + '</text>';
+    series.forEach(s => {
+        if (s.points.length === 0) return;
+        const pts = s.points.map(([x, y]) => sx(x).toFixed(1) + ',' + sy(y).toFixed(1)).join(' ');
+        svg += '<polyline fill="none" stroke="' + s.color + '" stroke-width="1.5" points="' + pts + '"/>';
+    });
+    svg += '</svg>';
+    return svg;
+}
+
+function formatBytes(bytes) {
+    const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB'];
+    let i = 0;
+    while (bytes >= 1024 && i < units.length - 1) {
+        bytes /= 1024;
+        i++;
+    }
+    return bytes.toFixed(i === 0 ? 0 : 1) + ' ' + units[i];
+}
+
+function populateSettingsPairs(pairNames) {
+    const select = document.getElementById('settings-pair');
+    const current = Array.from(select.options).map(o => o.value);
+    if (current.join(',') === pairNames.join(',')) return;
+    select.innerHTML = pairNames.map(name => '<option>' + name + '</option>').join('');
+    document.getElementById('info-pair').innerHTML = pairNames.map(name => '<option>' + name + '</option>').join('');
+    document.getElementById('export-pair').innerHTML = '<option value="">All pairs</option>' +
+        pairNames.map(name => '<option>' + name + '</option>').join('');
+    // A pair's page starts out with the pair selected and its server info read
+    if (currentPair !== null && pairNames.includes(currentPair)) {
+        ['settings-pair', 'info-pair', 'export-pair'].forEach(id => document.getElementById(id).value = currentPair);
+        loadPairInfo();
+    }
+    loadSettings();
+}
+
+function downloadExport() {
+    const value = id => document.getElementById(id).value.trim();
+    const params = new URLSearchParams();
+    if (value('export-pair') !== '') params.set('pair', value('export-pair'));
+    if (value('export-table') !== '') params.set('table', value('export-table'));
+    // datetime-local values are local times without a zone
+    if (value('export-from') !== '') params.set('from', new Date(value('export-from')).toISOString());
+    if (value('export-to') !== '') params.set('to', new Date(value('export-to')).toISOString());
+    window.location = '/api/v1/export/' + value('export-dataset') + '.' + value('export-format') + '?' + params.toString();
+}
+
+function loadPairInfo() {
+    const pair = document.getElementById('info-pair').value;
+    if (!pair) return;
+    const container = document.getElementById('pair-info');
+    container.innerHTML = '<div class="no-data">Loading...</div>';
+    fetch('/api/v1/pairs/' + encodeURIComponent(pair) + '/info')
+        .then(response => {
+            if (!response.ok) return response.text().then(text => { throw new Error(text); });
+            return response.json();
+        })
+        .then(info => renderPairInfo(info))
+        .catch(error => {
+            container.innerHTML = '<div class="alert-item CRITICAL">Failed to load server info: ' + escapeHTML(error.message) + '</div>';
+        });
+}
+
+function renderPairInfo(info) {
+    const names = new Set([...Object.keys(info.source.variables || {}), ...Object.keys(info.target.variables || {})]);
+    const cell = (server, value) => server.error ? '<td class="metric-label critical">' + escapeHTML(server.error) + '</td>' : '<td>' + escapeHTML(value || '-') + '</td>';
+
+    let html = '';
+    info.warnings.forEach(warning => {
+        html += '<div class="alert-item WARNING">' + escapeHTML(warning) + '</div>';
+    });
+    html += '<table><thead><tr><th>Setting</th><th>Source</th><th>Target</th></tr></thead><tbody>';
+    html += '<tr><td>Version</td>' + cell(info.source, info.source.version) + cell(info.target, info.target.version) + '</tr>';
+    html += '<tr><td>Flavor</td>' + cell(info.source, info.source.flavor) + cell(info.target, info.target.flavor) + '</tr>';
+    Array.from(names).sort().forEach(name => {
+        html += '<tr><td>' + escapeHTML(name) + '</td>' +
+            cell(info.source, (info.source.variables || {})[name]) + cell(info.target, (info.target.variables || {})[name]) + '</tr>';
+    });
+    html += '</tbody></table>';
+    html += '<div class="metric-label note">Read at ' + new Date(info.collected_at).toLocaleString() + '</div>';
+    document.getElementById('pair-info').innerHTML = html;
+}
+
+const settingsFields = {
+    'settings-check-interval': s => s.check_interval,
+    'settings-lag-warning': s => s.replica_lag.warning_at,
+    'settings-lag-critical': s => s.replica_lag.critical_at,
+    'settings-drift-warning': s => s.row_count_drift.warning_at || '',
+    'settings-drift-critical': s => s.row_count_drift.critical_at || '',
+    'settings-skew-warning': s => s.clock_skew.warning_at,
+    'settings-skew-critical': s => s.clock_skew.critical_at,
+    'settings-tolerance': s => s.tolerance_percent
+};
+
+function loadSettings() {
+    const pair = document.getElementById('settings-pair').value;
+    if (!pair) return;
+    fetch('/api/v1/pairs/' + encodeURIComponent(pair) + '/thresholds')
+        .then(response => response.json())
+        .then(settings => {
+            Object.keys(settingsFields).forEach(id => {
+                const input = document.getElementById(id);
+                input.value = settingsFields[id](settings.overrides);
+                input.placeholder = settingsFields[id](settings.effective);
+            });
+        })
+        .catch(error => console.error('Error fetching settings:', error));
+}
+
+function saveSettings() {
+    const pair = document.getElementById('settings-pair').value;
+    const value = id => document.getElementById(id).value.trim();
+    const body = {
+        check_interval: value('settings-check-interval'),
+        replica_lag: { warning_at: value('settings-lag-warning'), critical_at: value('settings-lag-critical') },
+        row_count_drift: { warning_at: Number(value('settings-drift-warning')), critical_at: Number(value('settings-drift-critical')) },
+        clock_skew: { warning_at: value('settings-skew-warning'), critical_at: value('settings-skew-critical') }
+    };
+    if (value('settings-tolerance') !== '') body.tolerance_percent = Number(value('settings-tolerance'));
+
+    const status = document.getElementById('settings-status');
+    fetch('/api/v1/pairs/' + encodeURIComponent(pair) + '/thresholds', {
+        method: 'PATCH',
+        headers: adminHeaders(),
+        body: JSON.stringify(body)
+    }).then(response => {
+        if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        status.textContent = 'Saved settings for ' + pair + ' at ' + new Date().toLocaleTimeString();
+        loadSettings();
+    }).catch(error => {
+        status.textContent = 'Failed to save settings: ' + error.message;
+    });
+}
+
+// Signed-in admins need no token; an explicit header would replace basic auth credentials
+function adminHeaders() {
+    const headers = { 'Content-Type': 'application/json' };
+    const token = document.getElementById('settings-token').value.trim();
+    if (token !== '') headers['Authorization'] = 'Bearer ' + token;
+    return headers;
+}
+
+function fetchAlerts() {
+    fetch('/api/v1/alerts/history?resolved=false&limit=1000')
+        .then(response => response.json())
+        .then(page => {
+            Object.keys(activeAlerts).forEach(id => delete activeAlerts[id]);
+            page.alerts.forEach(a => activeAlerts[a.ID] = a);
+            renderAlerts();
+        })
+        .catch(error => console.error('Error fetching alerts:', error));
+}
+
+function renderAlerts() {
+    const alertsDiv = document.getElementById('alerts');
+    const alerts = Object.values(activeAlerts)
+        .filter(alert => currentPair === null || alert.DatabasePair === currentPair)
+        .sort((a, b) => new Date(b.Timestamp) - new Date(a.Timestamp));
+
+    document.querySelectorAll('[data-alert-count]').forEach(el => el.innerHTML = alertCountHTML(el.dataset.alertCount));
+    if (alerts.length === 0) {
+        alertsDiv.innerHTML = '<div class="no-data">No active alerts</div>';
+        return;
+    }
+    let html = '';
+    alerts.forEach(alert => {
+        const time = new Date(alert.Timestamp).toLocaleString();
+        html += '<div class="alert-item ' + alert.Severity + '">';
+        html += '<strong>' + alert.Severity + '</strong>: ' + escapeHTML(alert.Message);
+        if (currentPair === null && alert.DatabasePair) {
+            html += ' <a href="' + pairURL(alert.DatabasePair) + '">' + escapeHTML(alert.DatabasePair) + ' &rarr;</a>';
+        }
+        if (alert.Source === 'alertmanager') {
+            html += ' <span class="badge info">via Alertmanager</span>';
+        }
+        if (alert.Suppressed) {
+            html += ' <span class="badge info" title="' + alert.SuppressedBy + '">suppressed (maintenance)</span>';
+        }
+        if (alert.Acknowledged) {
+            html += ' <span class="badge success">acknowledged by ' + escapeHTML(alert.AcknowledgedBy) + '</span>';
+        } else {
+            html += ' <button onclick="acknowledgeAlert(\'' + alert.ID + '\')">Acknowledge</button>';
+        }
+        // Responders need the owner and runbook, not the description
+        const metadata = Object.assign({}, alert.Metadata, { description: '' });
+        const metaLine = metadataLine(metadata);
+        if (metaLine) html += '<div class="alert-time">' + metaLine + '</div>';
+        html += '<div class="alert-time">' + time + '</div>';
+        html += '</div>';
+    });
+    alertsDiv.innerHTML = html;
+}
+
+// alertCountHTML counts the active alerts of a pair for the pair list
+function alertCountHTML(pairName) {
+    const alerts = Object.values(activeAlerts).filter(alert => alert.DatabasePair === pairName);
+    if (alerts.length === 0) return '-';
+    const critical = alerts.filter(alert => alert.Severity === 'CRITICAL').length;
+    return '<span class="badge ' + (critical > 0 ? 'danger' : 'warning') + '">' + alerts.length + (critical > 0 ? ' (' + critical + ' critical)' : '') + '</span>';
+}
+
+function fetchAuditLog() {
+    fetch('/api/v1/audit?duration=24h')
+        .then(response => response.json())
+        .then(entries => {
+            auditEntries = entries.reverse();
+            renderAuditLog();
+        })
+        .catch(error => console.error('Error fetching audit log:', error));
+}
+
+function renderAuditLog() {
+    const auditDiv = document.getElementById('audit-log');
+    const entries = auditEntries.filter(entry => currentPair === null || entry.DatabasePair === currentPair);
+    if (entries.length === 0) {
+        auditDiv.innerHTML = '<div class="no-data">No operator actions</div>';
+        return;
+    }
+    let html = '<table><tr><th>Time</th><th>Who</th><th>Action</th><th>Pair</th><th>Target</th><th>Reason</th></tr>';
+    entries.slice(0, 100).forEach(entry => {
+        html += '<tr><td>' + new Date(entry.Timestamp).toLocaleString() + '</td>';
+        html += '<td title="' + escapeHTML(entry.ClientIP) + '">' + escapeHTML(entry.Actor) + '</td>';
+        html += '<td title="' + escapeHTML(entry.Details || '') + '">' + escapeHTML(entry.Action) + '</td>';
+        html += '<td>' + (entry.DatabasePair ? '<a href="' + pairURL(entry.DatabasePair) + '">' + escapeHTML(entry.DatabasePair) + '</a>' : '') + '</td>';
+        html += '<td>' + escapeHTML(entry.Target || '') + '</td>';
+        html += '<td>' + escapeHTML(entry.Reason || '') + '</td></tr>';
+    });
+    html += '</table>';
+    auditDiv.innerHTML = html;
+}
+
+// The alert_acknowledged message updates the list. The reason is recorded in the audit log.
+function acknowledgeAlert(id) {
+    const reason = prompt('Reason for acknowledging (optional)');
+    if (reason === null) return;
+    fetch('/api/v1/alerts/' + encodeURIComponent(id) + '/acknowledge', { method: 'POST', headers: adminHeaders(), body: JSON.stringify({ reason: reason }) })
+        .then(response => {
+            if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        })
+        .catch(error => alert('Failed to acknowledge alert: ' + error.message));
+}
+
+// Connect on page load
+showPage();
+connectWebSocket();
+
@@ -0,0 +1,43 @@
+// Dashboard preferences are kept in localStorage, per browser
+const preferenceKeys = {
+    theme: 'monitor.theme' // "light" or "dark"
+};
+
+function loadPreference(key, fallback) {
+    try {
+        const value = localStorage.getItem(key);
+        return value === null ? fallback : JSON.parse(value);
+    } catch (e) {
+        return fallback;
+    }
+}
+
+function savePreference(key, value) {
+    try {
+        localStorage.setItem(key, JSON.stringify(value));
+    } catch (e) {
+        // Private browsing or storage disabled: the preference lasts for the page
+    }
+}
+
+// currentTheme is the stored theme, or the system's until one is chosen
+function currentTheme() {
+    const stored = loadPreference(preferenceKeys.theme, null);
+    if (stored === 'light' || stored === 'dark') return stored;
+    return window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+}
+
+function applyTheme(theme) {
+    document.documentElement.dataset.theme = theme;
+    const button = document.getElementById('theme-toggle');
+    if (button) button.textContent = theme === 'dark' ? '☀️ Light' : '🌙 Dark';
+}
+
+function toggleTheme() {
+    const theme = currentTheme() === 'dark' ? 'light' : 'dark';
+    savePreference(preferenceKeys.theme, theme);
+    applyTheme(theme);
+}
+
+applyTheme(currentTheme());
+document.addEventListener('DOMContentLoaded', () => applyTheme(currentTheme()));
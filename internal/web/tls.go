@@ -0,0 +1,63 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"mariadb-encryption-monitor/internal/config"
+)
+
+// newTLSConfig builds the listener's TLS settings. With a client CA, client
+// certificates are verified against it; with client_auth "require" every
+// connection needs one whose common name is listed in auth.client_certs.
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.WebTLS.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.WebTLS.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("web_tls: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("web_tls: no certificates found in %s", cfg.WebTLS.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	if cfg.WebTLS.ClientAuth == "require" {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			name := state.PeerCertificates[0].Subject.CommonName
+			if clientCert(cfg.Auth.ClientCerts, name) == nil {
+				return fmt.Errorf("client certificate '%s' is not listed in auth.client_certs", name)
+			}
+			return nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// clientCert returns the allowed client certificate with a common name, or nil
+func clientCert(certs []config.ClientCert, commonName string) *config.ClientCert {
+	i := slices.IndexFunc(certs, func(cert config.ClientCert) bool { return cert.CommonName == commonName })
+	if i < 0 {
+		return nil
+	}
+	return &certs[i]
+}
+
+// peerCommonName returns the common name of a request's verified client
+// certificate, or "" without one
+func peerCommonName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
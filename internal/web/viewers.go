@@ -0,0 +1,122 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// recentSessionsKept bounds the ended dashboard sessions kept for /api/viewers
+const recentSessionsKept = 20
+
+// viewerSession is a dashboard WebSocket connection
+type viewerSession struct {
+	ID             int        `json:"id"`
+	Subject        string     `json:"subject,omitempty"` // authenticated caller; empty without auth
+	ClientIP       string     `json:"client_ip"`
+	UserAgent      string     `json:"user_agent,omitempty"`
+	ConnectedAt    time.Time  `json:"connected_at"`
+	DisconnectedAt *time.Time `json:"disconnected_at,omitempty"`
+}
+
+// viewersResponse is the body of /api/viewers and of viewers_update messages
+type viewersResponse struct {
+	Count         int             `json:"count"`
+	Viewers       []viewerSession `json:"viewers"`        // connected now, longest first
+	TotalSessions int             `json:"total_sessions"` // since the monitor started
+	PeakViewers   int             `json:"peak_viewers"`
+	PeakAt        *time.Time      `json:"peak_at,omitempty"`
+	Recent        []viewerSession `json:"recent"` // ended sessions, latest first
+}
+
+// addViewer registers a WebSocket connection as a viewer and tells the other
+// viewers. The caller holds no lock.
+func (ws *WebServer) addViewer(client *wsClient, r *http.Request) {
+	session := &viewerSession{
+		ClientIP:    clientIP(r, ws.config.RateLimit.TrustProxyHeaders),
+		UserAgent:   r.UserAgent(),
+		ConnectedAt: time.Now(),
+	}
+	if id := identityFrom(r); id != nil {
+		session.Subject = id.Subject
+	}
+
+	ws.mu.Lock()
+	ws.totalSessions++
+	session.ID = ws.totalSessions
+	ws.wsClients[client] = session
+	total := len(ws.wsClients)
+	if total > ws.peakViewers {
+		ws.peakViewers = total
+		ws.peakAt = session.ConnectedAt
+	}
+	ws.mu.Unlock()
+
+	log.Printf("New WebSocket client connected (total: %d)", total)
+	ws.enqueueViewersUpdate()
+}
+
+// removeViewer ends the session of a WebSocket connection
+func (ws *WebServer) removeViewer(client *wsClient) {
+	ws.mu.Lock()
+	session, ok := ws.wsClients[client]
+	delete(ws.wsClients, client)
+	total := len(ws.wsClients)
+	if ok {
+		ended := *session
+		now := time.Now()
+		ended.DisconnectedAt = &now
+		ws.recentSessions = append(ws.recentSessions, ended)
+		if len(ws.recentSessions) > recentSessionsKept {
+			ws.recentSessions = ws.recentSessions[len(ws.recentSessions)-recentSessionsKept:]
+		}
+	}
+	ws.mu.Unlock()
+
+	log.Printf("WebSocket client disconnected (total: %d)", total)
+	ws.enqueueViewersUpdate()
+}
+
+// viewers returns the connected viewers and session statistics
+func (ws *WebServer) viewers() viewersResponse {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	resp := viewersResponse{
+		Count:         len(ws.wsClients),
+		Viewers:       make([]viewerSession, 0, len(ws.wsClients)),
+		TotalSessions: ws.totalSessions,
+		PeakViewers:   ws.peakViewers,
+		Recent:        make([]viewerSession, 0, len(ws.recentSessions)),
+	}
+	if !ws.peakAt.IsZero() {
+		peakAt := ws.peakAt
+		resp.PeakAt = &peakAt
+	}
+	for _, session := range ws.wsClients {
+		resp.Viewers = append(resp.Viewers, *session)
+	}
+	sort.Slice(resp.Viewers, func(i, j int) bool { return resp.Viewers[i].ID < resp.Viewers[j].ID })
+	for i := len(ws.recentSessions) - 1; i >= 0; i-- {
+		resp.Recent = append(resp.Recent, ws.recentSessions[i])
+	}
+	return resp
+}
+
+// enqueueViewersUpdate queues the current viewers for WebSocket clients,
+// dropping the update if the queue is full
+func (ws *WebServer) enqueueViewersUpdate() {
+	select {
+	case ws.wsEvents <- WSMessage{Type: "viewers_update", Timestamp: time.Now(), Data: ws.viewers()}:
+	default:
+		log.Printf("WebSocket event queue full, dropping viewers update")
+	}
+}
+
+// handleViewers lists who is watching the dashboard
+func (ws *WebServer) handleViewers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.viewers())
+}
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsSendQueue is how many messages a client may fall behind before it is
+	// dropped; a dropped dashboard reconnects and starts from fresh metrics
+	wsSendQueue = 256
+	// wsWriteWait bounds each write to a client, including pings
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long a client may stay silent, pongs included
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod keeps pings well within wsPongWait
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessageSize bounds messages from clients, which only send control frames
+	wsMaxMessageSize = 512
+)
+
+// wsClient is a WebSocket connection with its own send queue, written by a
+// dedicated goroutine so that a stalled browser delays nobody else
+type wsClient struct {
+	conn *websocket.Conn
+	send chan []byte   // encoded messages
+	done chan struct{} // closed when the client is closed
+
+	closeOnce sync.Once
+}
+
+// newWSClient wraps a connection and starts its write pump
+func newWSClient(conn *websocket.Conn) *wsClient {
+	c := &wsClient{
+		conn: conn,
+		send: make(chan []byte, wsSendQueue),
+		done: make(chan struct{}),
+	}
+	go c.writePump()
+	return c
+}
+
+// enqueue queues an encoded message without blocking. A client whose queue
+// is full is too slow to keep up and is closed; dropped reports that.
+func (c *wsClient) enqueue(data []byte) (dropped bool) {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return false
+	default:
+		log.Printf("WebSocket client %s is not keeping up, dropping it", c.conn.RemoteAddr())
+		c.close()
+		return true
+	}
+}
+
+// close closes the connection, which ends the write pump and the read loop
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// writePump writes queued messages and pings to the client. Messages queued
+// while one is written go out together under one write deadline.
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer c.close()
+
+	for {
+		select {
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("Error sending to WebSocket client: %v", err)
+				return
+			}
+			for range len(c.send) {
+				if err := c.conn.WriteMessage(websocket.TextMessage, <-c.send); err != nil {
+					log.Printf("Error sending to WebSocket client: %v", err)
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// readPump reads until the client goes away or stops answering pings. The
+// dashboard sends nothing, but reading processes its pongs and close frame.
+func (c *wsClient) readPump() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// encodeWSMessage encodes a message once for every client it is sent to
+func encodeWSMessage(msg WSMessage) ([]byte, bool) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error encoding WebSocket %s message: %v", msg.Type, err)
+		return nil, false
+	}
+	return data, true
+}